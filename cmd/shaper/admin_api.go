@@ -0,0 +1,74 @@
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/history"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+)
+
+// configureAdminAPI builds the /admin/ API the metrics listeners mount: the
+// history endpoint, plus snapshot, suppression, step, p95, and episodes
+// endpoints when configured or supported by the controller, behind the admin
+// access rules.
+//
+//nolint:ireturn // minimal builds return a nil handler
+func configureAdminAPI(
+	ctx context.Context,
+	deps runDeps,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+	store *history.Store,
+) (http.Handler, error) {
+	admin := adminhttp.NewHandler()
+	admin.Handle(adminhttp.Prefix+"history", adminhttp.NewHistoryHandler(store))
+	configureSnapshot(cfg, exporter, store, admin)
+
+	if suppressor, ok := controller.(adminhttp.SuppressionController); ok {
+		admin.Handle(adminhttp.Prefix+"suppress", adminhttp.NewSuppressHandler(suppressor))
+	}
+
+	if stepper, ok := controller.(adminhttp.StepController); ok {
+		admin.Handle(adminhttp.Prefix+"step", adminhttp.NewStepHandler(stepper))
+	}
+
+	if provider, ok := controller.(adminhttp.P95HistoryProvider); ok {
+		admin.Handle(adminhttp.Prefix+"p95", adminhttp.NewP95Handler(provider))
+	}
+
+	if provider, ok := controller.(adminhttp.SuppressionEpisodeProvider); ok {
+		admin.Handle(adminhttp.Prefix+"episodes", adminhttp.NewEpisodesHandler(provider))
+	}
+
+	err := configureAdminAuth(ctx, deps, logger, cfg, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+// configureSnapshot mounts /admin/snapshot when admin.snapshotDir is set, so
+// support bundles can capture metrics and history on demand.
+func configureSnapshot(
+	cfg runtimeConfig,
+	exporter *metricshttp.Exporter,
+	store *history.Store,
+	admin *adminhttp.Handler,
+) {
+	dir := strings.TrimSpace(cfg.Admin.SnapshotDir)
+	if dir == "" {
+		return
+	}
+
+	admin.Handle(adminhttp.Prefix+"snapshot", adminhttp.NewSnapshotHandler(dir, exporter, store))
+}
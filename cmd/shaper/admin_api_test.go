@@ -0,0 +1,104 @@
+//go:build !minimal
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/history"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+)
+
+func TestConfigureAdminAPIMountsControllerRoutes(t *testing.T) {
+	t.Parallel()
+
+	store, err := history.Open("")
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+
+	controller := &suppressibleStubController{requests: make(chan string, 1)}
+
+	admin, err := configureAdminAPI(
+		t.Context(),
+		runDeps{},
+		zap.NewNop(),
+		defaultRuntimeConfig(),
+		controller,
+		metricshttp.NewExporter(),
+		store,
+	)
+	if err != nil {
+		t.Fatalf("configureAdminAPI returned error: %v", err)
+	}
+
+	for _, test := range []struct {
+		method string
+		target string
+		want   int
+	}{
+		{method: http.MethodGet, target: "/admin/history", want: http.StatusOK},
+		{method: http.MethodPost, target: "/admin/suppress?duration=10m", want: http.StatusOK},
+		{method: http.MethodGet, target: "/admin/episodes", want: http.StatusOK},
+		{method: http.MethodPost, target: "/admin/snapshot", want: http.StatusNotFound},
+	} {
+		request := httptest.NewRequest(test.method, test.target, nil)
+		request.RemoteAddr = "127.0.0.1:40000"
+
+		recorder := httptest.NewRecorder()
+		admin.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.want {
+			t.Fatalf(
+				"%s %s: expected %d, got %d", test.method, test.target, test.want, recorder.Code,
+			)
+		}
+	}
+
+	if source := <-controller.requests; source != adminhttp.SuppressionSource {
+		t.Fatalf("expected admin source, got %q", source)
+	}
+
+	remote := httptest.NewRequest(http.MethodGet, "/admin/history", nil)
+	recorder := httptest.NewRecorder()
+	admin.ServeHTTP(recorder, remote)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected remote callers to be refused by default, got %d", recorder.Code)
+	}
+}
+
+func TestConfigureSnapshotMountsRouteWhenDirectorySet(t *testing.T) {
+	t.Parallel()
+
+	store, err := history.Open("")
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+
+	cfg := defaultRuntimeConfig()
+	admin := adminhttp.NewHandler()
+
+	configureSnapshot(cfg, metricshttp.NewExporter(), store, admin)
+
+	recorder := httptest.NewRecorder()
+	admin.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/snapshot", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected no snapshot route by default, got %d", recorder.Code)
+	}
+
+	cfg.Admin.SnapshotDir = t.TempDir()
+	configureSnapshot(cfg, metricshttp.NewExporter(), store, admin)
+
+	recorder = httptest.NewRecorder()
+	admin.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/snapshot", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected snapshot route, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,137 @@
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var errDynamicGroupReaderMissing = errors.New("dynamic group reader unavailable")
+
+//nolint:ireturn // factory returns interface so tests can substitute readers.
+func newInstancePrincipalDynamicGroupReader(region string) (dynamicGroupReader, error) {
+	client, err := oci.NewInstancePrincipalIdentityClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build identity client: %w", err)
+	}
+
+	return client, nil
+}
+
+// configureAdminAuth requires instance principal signatures on the admin API
+// when admin.dynamicGroupId or admin.matchingRule is set. The dynamic group is
+// read once at startup, so membership edits apply after a restart. Without
+// authentication the API is limited to loopback callers unless
+// admin.allowRemote opts out.
+func configureAdminAuth(
+	ctx context.Context,
+	deps runDeps,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	admin *adminhttp.Handler,
+) error {
+	if !cfg.Admin.authEnabled() {
+		if !cfg.Admin.AllowRemote {
+			admin.RestrictToLoopback()
+
+			return nil
+		}
+
+		logger.Warn("admin api accepts unauthenticated requests from any address")
+
+		return nil
+	}
+
+	text := cfg.Admin.MatchingRule
+
+	groupID := strings.TrimSpace(cfg.Admin.DynamicGroupID)
+	if groupID != "" {
+		if deps.newDynamicGroupReader == nil {
+			return errDynamicGroupReaderMissing
+		}
+
+		reader, err := deps.newDynamicGroupReader(cfg.OCI.Region)
+		if err != nil {
+			return err
+		}
+
+		text, err = reader.DynamicGroupMatchingRule(ctx, groupID)
+		if err != nil {
+			return fmt.Errorf("read admin dynamic group: %w", err)
+		}
+	}
+
+	rule, err := oci.ParseMatchingRule(text)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidAdminAuth, err)
+	}
+
+	verifier, err := oci.NewPrincipalVerifier(
+		cfg.Admin.IssuerKeysURL,
+		cfg.Admin.TenancyID,
+		rule,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidAdminAuth, err)
+	}
+
+	admin.RequireAuthentication(principalAuthenticator{verifier: verifier, logger: logger})
+
+	logger.Info(
+		"admin api requires instance principal signatures",
+		zap.String("dynamicGroupId", groupID),
+		zap.String("matchingRule", strings.TrimSpace(text)),
+	)
+
+	return nil
+}
+
+type principalVerifier interface {
+	Verify(request *http.Request) (oci.Principal, error)
+}
+
+// principalAuthenticator logs every admin request with the instance that
+// signed it, so remote control actions leave an audit trail.
+type principalAuthenticator struct {
+	verifier principalVerifier
+	logger   *zap.Logger
+}
+
+func (a principalAuthenticator) Authenticate(request *http.Request) error {
+	principal, err := a.verifier.Verify(request)
+	if err != nil {
+		a.logger.Warn(
+			"admin request rejected",
+			zap.String("method", request.Method),
+			zap.String("path", request.URL.Path),
+			zap.String("remoteAddr", request.RemoteAddr),
+			zap.String("instanceId", principal.InstanceID),
+			zap.Error(err),
+		)
+
+		if errors.Is(err, oci.ErrForbidden) {
+			return fmt.Errorf("%w: %w", adminhttp.ErrForbidden, err)
+		}
+
+		return fmt.Errorf("verify admin request: %w", err)
+	}
+
+	a.logger.Info(
+		"admin request authenticated",
+		zap.String("method", request.Method),
+		zap.String("path", request.URL.Path),
+		zap.String("instanceId", principal.InstanceID),
+		zap.String("compartmentId", principal.CompartmentID),
+	)
+
+	return nil
+}
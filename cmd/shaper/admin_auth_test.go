@@ -0,0 +1,260 @@
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+type stubDynamicGroupReader struct {
+	rule      string
+	requested string
+}
+
+func (s *stubDynamicGroupReader) DynamicGroupMatchingRule(
+	_ context.Context,
+	dynamicGroupOCID string,
+) (string, error) {
+	s.requested = dynamicGroupOCID
+
+	return s.rule, nil
+}
+
+type stubPrincipalVerifier struct {
+	principal oci.Principal
+	err       error
+}
+
+func (s stubPrincipalVerifier) Verify(*http.Request) (oci.Principal, error) {
+	return s.principal, s.err
+}
+
+func TestConfigureAdminAuthReadsDynamicGroup(t *testing.T) {
+	t.Parallel()
+
+	reader := &stubDynamicGroupReader{
+		rule: "ANY {instance.compartment.id = 'ocid1.compartment.oc1..fleet'}",
+	}
+
+	var region string
+
+	deps := runDeps{
+		newDynamicGroupReader: func(r string) (dynamicGroupReader, error) {
+			region = r
+
+			return reader, nil
+		},
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Region = stubRegion
+	cfg.Admin.DynamicGroupID = "ocid1.dynamicgroup.oc1..fleet"
+	cfg.Admin.IssuerKeysURL = "https://keys.example.com/jwks"
+	cfg.Admin.TenancyID = "ocid1.tenancy.oc1..fleet"
+
+	admin := adminhttp.NewHandler()
+	admin.Handle(adminhttp.Prefix+"history", http.NotFoundHandler())
+
+	err := configureAdminAuth(t.Context(), deps, zap.NewNop(), cfg, admin)
+	if err != nil {
+		t.Fatalf("configureAdminAuth returned error: %v", err)
+	}
+
+	if reader.requested != cfg.Admin.DynamicGroupID || region != stubRegion {
+		t.Fatalf("unexpected dynamic group lookup %q in %q", reader.requested, region)
+	}
+
+	recorder := httptest.NewRecorder()
+	admin.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/history", nil))
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unsigned request to be rejected, got %d", recorder.Code)
+	}
+
+	reader.rule = "tag.fleet.role.value = 'shaper'"
+
+	err = configureAdminAuth(t.Context(), deps, zap.NewNop(), cfg, adminhttp.NewHandler())
+	if !errors.Is(err, errInvalidAdminAuth) {
+		t.Fatalf("expected unsupported rule to be rejected, got %v", err)
+	}
+}
+
+func TestPrincipalAuthenticatorLogsDecisions(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	principal := oci.Principal{InstanceID: "ocid1.instance.oc1..controller"}
+	request := httptest.NewRequest(http.MethodPost, "/admin/step", nil)
+
+	accepted := principalAuthenticator{
+		verifier: stubPrincipalVerifier{principal: principal},
+		logger:   zap.New(core),
+	}
+	if err := accepted.Authenticate(request); err != nil {
+		t.Fatalf("expected member to be accepted, got %v", err)
+	}
+
+	rejected := principalAuthenticator{
+		verifier: stubPrincipalVerifier{principal: principal, err: oci.ErrForbidden},
+		logger:   zap.New(core),
+	}
+
+	err := rejected.Authenticate(request)
+	if !errors.Is(err, adminhttp.ErrForbidden) {
+		t.Fatalf("expected adminhttp.ErrForbidden, got %v", err)
+	}
+
+	entries := logs.FilterMessage("admin request rejected").All()
+	if logs.FilterMessage("admin request authenticated").Len() != 1 || len(entries) != 1 {
+		t.Fatalf("expected one accepted and one rejected entry, got %d logs", logs.Len())
+	}
+
+	if entries[0].ContextMap()["instanceId"] != principal.InstanceID {
+		t.Fatalf("expected rejected instance to be logged, got %v", entries[0].ContextMap())
+	}
+}
+
+func TestLoadConfigValidatesAdminAuth(t *testing.T) {
+	t.Setenv(envAdminRule, "instance.id = 'ocid1.instance.oc1..controller'")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, errInvalidAdminAuth) {
+		t.Fatalf("expected missing issuer keys URL to be rejected, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse exit code, got %d", code)
+	}
+
+	t.Setenv(envAdminIssuerKeys, "http://keys.example.com/jwks")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, errInvalidAdminAuth) {
+		t.Fatalf("expected a plain http issuer keys URL to be rejected, got %v", err)
+	}
+
+	t.Setenv(envAdminIssuerKeys, "https://keys.example.com/jwks")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, errInvalidAdminAuth) {
+		t.Fatalf("expected missing tenancy to be rejected, got %v", err)
+	}
+
+	t.Setenv(envAdminTenancy, "ocid1.tenancy.oc1..fleet")
+
+	cfg, err := loadConfig("")
+	if err != nil || !cfg.Admin.authEnabled() {
+		t.Fatalf("expected inline matching rule to enable auth, got %v", err)
+	}
+
+	t.Setenv(envAdminGroup, "ocid1.dynamicgroup.oc1..fleet")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, errInvalidAdminAuth) {
+		t.Fatalf("expected conflicting sources to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesAdminSnapshotDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte("admin:\n  snapshotDir: /var/tmp/snapshots\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil || cfg.Admin.SnapshotDir != "/var/tmp/snapshots" {
+		t.Fatalf("expected snapshot dir from file, got %q (%v)", cfg.Admin.SnapshotDir, err)
+	}
+
+	t.Setenv(envAdminSnapshotDir, "/run/snapshots")
+
+	cfg, err = loadConfig(path)
+	if err != nil || cfg.Admin.SnapshotDir != "/run/snapshots" {
+		t.Fatalf("expected snapshot dir from env, got %q (%v)", cfg.Admin.SnapshotDir, err)
+	}
+}
+
+func TestConfigureAdminAuthRestrictsUnauthenticatedAPIToLoopback(t *testing.T) {
+	t.Parallel()
+
+	serve := func(admin *adminhttp.Handler, remoteAddr string) int {
+		request := httptest.NewRequest(http.MethodPost, "/admin/step", nil)
+		request.RemoteAddr = remoteAddr
+
+		recorder := httptest.NewRecorder()
+		admin.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	admin := adminhttp.NewHandler()
+	admin.Handle(adminhttp.Prefix+"step", http.NotFoundHandler())
+
+	err := configureAdminAuth(t.Context(), runDeps{}, zap.NewNop(), defaultRuntimeConfig(), admin)
+	if err != nil {
+		t.Fatalf("configureAdminAuth returned error: %v", err)
+	}
+
+	if code := serve(admin, "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected remote caller to be refused, got %d", code)
+	}
+
+	if code := serve(admin, "127.0.0.1:5000"); code != http.StatusNotFound {
+		t.Fatalf("expected loopback caller to be routed, got %d", code)
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.Admin.AllowRemote = true
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	open := adminhttp.NewHandler()
+	open.Handle(adminhttp.Prefix+"step", http.NotFoundHandler())
+
+	err = configureAdminAuth(t.Context(), runDeps{}, zap.New(core), cfg, open)
+	if err != nil {
+		t.Fatalf("configureAdminAuth returned error: %v", err)
+	}
+
+	if code := serve(open, "192.0.2.10:5000"); code != http.StatusNotFound {
+		t.Fatalf("expected allowRemote to route remote callers, got %d", code)
+	}
+
+	if logs.FilterMessageSnippet("unauthenticated requests").Len() != 1 {
+		t.Fatalf("expected a warning for the open admin api, got %+v", logs.All())
+	}
+}
+
+func TestLoadConfigParsesAdminAllowRemote(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte("admin:\n  allowRemote: true\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil || !cfg.Admin.AllowRemote {
+		t.Fatalf("expected allowRemote from file, got %v (%v)", cfg.Admin.AllowRemote, err)
+	}
+
+	t.Setenv(envAdminAllowRemote, "false")
+
+	cfg, err = loadConfig(path)
+	if err != nil || cfg.Admin.AllowRemote {
+		t.Fatalf("expected env to disable allowRemote, got %v (%v)", cfg.Admin.AllowRemote, err)
+	}
+}
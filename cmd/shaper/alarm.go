@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+const (
+	alarmDestinationsCommand = "destinations"
+
+	// defaultAlarmWait bounds how long --set waits for the update to take effect.
+	defaultAlarmWait = 2 * time.Minute
+	// alarmWaitInterval spaces the GetAlarm polls made while waiting.
+	alarmWaitInterval = 5 * time.Second
+)
+
+var (
+	errUnknownAlarmCommand = errors.New("unknown alarm subcommand")
+	errAlarmManagerMissing = errors.New("alarm management is unavailable in this build")
+)
+
+type alarmManager interface {
+	ListTopics(ctx context.Context, compartmentID string) ([]oci.Topic, error)
+	FindGuardrailAlarm(
+		ctx context.Context,
+		compartmentID string,
+		instanceID string,
+	) (oci.GuardrailAlarm, error)
+	SetAlarmDestinations(ctx context.Context, alarmID string, topicIDs []string) error
+	WaitForAlarmActive(
+		ctx context.Context,
+		alarmID string,
+		destinations []string,
+		interval time.Duration,
+		report func(oci.AlarmStatus),
+	) error
+	VerifyDestinations(ctx context.Context, alarm oci.GuardrailAlarm) error
+}
+
+type alarmOptions struct {
+	compartmentID string
+	instanceID    string
+	region        string
+	topics        string
+	wait          time.Duration
+	verify        bool
+}
+
+func newInstancePrincipalAlarmManager(region string) (alarmManager, error) {
+	client, err := oci.NewInstancePrincipalAlarmClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build alarm client: %w", err)
+	}
+
+	return client, nil
+}
+
+func parseAlarmArgs(args []string) (alarmOptions, error) {
+	var opts alarmOptions
+
+	if len(args) == 0 || args[0] != alarmDestinationsCommand {
+		return alarmOptions{}, fmt.Errorf(
+			"%w: %q",
+			errUnknownAlarmCommand,
+			strings.Join(args, " "),
+		)
+	}
+
+	flagSet := flag.NewFlagSet("shaper alarm destinations", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&opts.compartmentID, "compartment", "", "Compartment OCID to inspect")
+	flagSet.StringVar(&opts.instanceID, "instance", "", "Instance OCID guarded by the alarm")
+	flagSet.StringVar(&opts.region, "region", "", "OCI region for the API endpoints")
+	flagSet.StringVar(
+		&opts.topics,
+		"set",
+		"",
+		"Comma-separated topic OCIDs to wire as the guardrail alarm destinations",
+	)
+	flagSet.DurationVar(
+		&opts.wait,
+		"wait",
+		defaultAlarmWait,
+		"How long --set polls for the alarm to be ACTIVE with the new destinations; 0 skips",
+	)
+	flagSet.BoolVar(
+		&opts.verify,
+		"verify",
+		false,
+		"Fail unless every guardrail alarm destination is an ACTIVE topic",
+	)
+
+	err := flagSet.Parse(args[1:])
+	if err != nil {
+		return alarmOptions{}, fmt.Errorf("parse alarm arguments: %w", err)
+	}
+
+	return opts, nil
+}
+
+// runAlarm lists the Notifications topics in the compartment and wires or verifies the
+// destinations of the seven-day P95 guardrail alarm for the instance.
+//
+//nolint:cyclop,funlen // subcommand flow reports each step before exiting
+func runAlarm(ctx context.Context, deps runDeps, opts options, stderr io.Writer) int {
+	alarmOpts, err := parseAlarmArgs(opts.alarmArgs)
+	if err != nil {
+		return writeError(stderr, err, exitCodeParseError)
+	}
+
+	configPath, _, exitCode, configFetched := resolveConfigPathOrExit(ctx, deps, opts, stderr)
+	if !configFetched {
+		return exitCode
+	}
+
+	cfg, exitCode, configLoaded := loadRuntimeConfigOrExit(deps, configPath, stderr)
+	if !configLoaded {
+		return exitCode
+	}
+
+	applyAlarmOverrides(&cfg, alarmOpts)
+
+	compartmentID, instanceID, region, err := resolveAlarmScope(ctx, deps, cfg)
+	if err != nil {
+		return writeError(stderr, err, exitCodeForRunError(err))
+	}
+
+	if deps.newAlarmManager == nil {
+		return writeError(stderr, errAlarmManagerMissing, exitCodeRuntimeError)
+	}
+
+	manager, err := deps.newAlarmManager(region)
+	if err != nil {
+		return writeError(stderr, err, exitCodeOCIAuthError)
+	}
+
+	writer := deps.stdout
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	topics, err := manager.ListTopics(ctx, compartmentID)
+	if err != nil {
+		return writeError(stderr, err, exitCodeRuntimeError)
+	}
+
+	for _, topic := range topics {
+		_, _ = fmt.Fprintf(writer, "topic: %s %s (%s)\n", topic.Name, topic.ID, topic.State)
+	}
+
+	alarm, err := manager.FindGuardrailAlarm(ctx, compartmentID, instanceID)
+	if err != nil {
+		return writeError(stderr, err, exitCodeRuntimeError)
+	}
+
+	_, _ = fmt.Fprintf(writer, "guardrail: %s %s\n", alarm.DisplayName, alarm.ID)
+
+	topicIDs := splitList(alarmOpts.topics)
+	if len(topicIDs) > 0 {
+		err = manager.SetAlarmDestinations(ctx, alarm.ID, topicIDs)
+		if err != nil {
+			return writeError(stderr, err, exitCodeRuntimeError)
+		}
+
+		err = waitForAlarm(ctx, manager, writer, alarm.ID, topicIDs, alarmOpts.wait)
+		if err != nil {
+			return writeError(stderr, err, exitCodeRuntimeError)
+		}
+
+		alarm.Destinations = topicIDs
+	}
+
+	_, _ = fmt.Fprintf(writer, "destinations: %s\n", strings.Join(alarm.Destinations, ","))
+
+	if !alarmOpts.verify {
+		return exitCodeSuccess
+	}
+
+	err = manager.VerifyDestinations(ctx, alarm)
+	if err != nil {
+		return writeError(stderr, err, exitCodeRuntimeError)
+	}
+
+	_, _ = fmt.Fprintln(writer, "verify: ok")
+
+	return exitCodeSuccess
+}
+
+// waitForAlarm polls until the updated alarm is ACTIVE with topicIDs as its
+// destinations, printing each lifecycle state it passes through, because
+// UpdateAlarm succeeding only means the change was accepted.
+func waitForAlarm(
+	ctx context.Context,
+	manager alarmManager,
+	writer io.Writer,
+	alarmID string,
+	topicIDs []string,
+	wait time.Duration,
+) error {
+	if wait <= 0 {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	err := manager.WaitForAlarmActive(
+		waitCtx,
+		alarmID,
+		topicIDs,
+		alarmWaitInterval,
+		func(status oci.AlarmStatus) {
+			_, _ = fmt.Fprintf(
+				writer,
+				"alarm state: %s destinations: %s\n",
+				status.State,
+				strings.Join(status.Destinations, ","),
+			)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("wait for alarm update: %w", err)
+	}
+
+	return nil
+}
+
+func applyAlarmOverrides(cfg *runtimeConfig, opts alarmOptions) {
+	if value := strings.TrimSpace(opts.compartmentID); value != "" {
+		cfg.OCI.CompartmentID = value
+	}
+
+	if value := strings.TrimSpace(opts.instanceID); value != "" {
+		cfg.OCI.InstanceID = value
+	}
+
+	if value := strings.TrimSpace(opts.region); value != "" {
+		cfg.OCI.Region = value
+	}
+}
+
+func resolveAlarmScope(
+	ctx context.Context,
+	deps runDeps,
+	cfg runtimeConfig,
+) (string, string, string, error) {
+	var imdsClient imds.Client
+	if deps.newIMDS != nil {
+		imdsClient = deps.newIMDS()
+	}
+
+	metadata, err := resolveCompartmentAndRegion(ctx, cfg, imdsClient)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	instanceID := strings.TrimSpace(cfg.OCI.InstanceID)
+	if instanceID == "" && imdsClient != nil {
+		instanceID, err = imdsClient.InstanceID(ctx)
+		if err != nil {
+			return "", "", "", fmt.Errorf(
+				"lookup instance ocid: %w: %w",
+				errIMDSUnreachable,
+				err,
+			)
+		}
+	}
+
+	return metadata.CompartmentID, strings.TrimSpace(instanceID), metadata.Region, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/imds"
+)
+
+// alarmSilencer is implemented by controllers that can tighten their target
+// while the guardrail alarm is suppressed (see adapt.AdaptiveController).
+type alarmSilencer interface {
+	SetAlarmSilence(until time.Time, floor float64)
+}
+
+// alarmSilenceWatcher polls the guardrail alarm for a suppression window. While
+// the alarm is muted nothing else warns before reclamation, so the silence is
+// logged, exported, and handed to the controller.
+type alarmSilenceWatcher struct {
+	manager       alarmManager
+	imds          imds.Client
+	compartmentID string
+	instanceID    string
+	interval      time.Duration
+	floor         float64
+	silencer      alarmSilencer
+	exporter      *metricshttp.Exporter
+	logger        *zap.Logger
+	now           func() time.Time
+
+	until time.Time
+}
+
+// configureAlarmSilenceWatch starts the alarm.watchInterval poll of the
+// guardrail alarm. A missing alarm client only warns: the daemon shapes the
+// same way without it.
+func configureAlarmSilenceWatch(
+	ctx context.Context,
+	logger *zap.Logger,
+	deps runDeps,
+	cfg runtimeConfig,
+	imdsClient imds.Client,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) {
+	if cfg.Alarm.WatchInterval <= 0 || cfg.OCI.Offline || deps.newAlarmManager == nil {
+		return
+	}
+
+	manager, err := deps.newAlarmManager(cfg.OCI.Region)
+	if err != nil {
+		logger.Warn("failed to build alarm client; guardrail silence watch disabled",
+			zap.Error(err))
+
+		return
+	}
+
+	silencer, _ := controller.(alarmSilencer)
+
+	watcher := &alarmSilenceWatcher{
+		manager:       manager,
+		imds:          imdsClient,
+		compartmentID: cfg.OCI.CompartmentID,
+		instanceID:    strings.TrimSpace(cfg.OCI.InstanceID),
+		interval:      cfg.Alarm.WatchInterval,
+		floor:         cfg.Alarm.SilencedTargetMin,
+		silencer:      silencer,
+		exporter:      exporter,
+		logger:        logger,
+		now:           time.Now,
+		until:         time.Time{},
+	}
+
+	go watcher.run(ctx)
+}
+
+func (w *alarmSilenceWatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.check(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Warn("failed to check guardrail alarm suppression", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *alarmSilenceWatcher) check(ctx context.Context) error {
+	if w.instanceID == "" && w.imds != nil {
+		instanceID, err := w.imds.InstanceID(ctx)
+		if err != nil {
+			return fmt.Errorf("lookup instance ocid: %w", err)
+		}
+
+		w.instanceID = strings.TrimSpace(instanceID)
+	}
+
+	alarm, err := w.manager.FindGuardrailAlarm(ctx, w.compartmentID, w.instanceID)
+	if err != nil {
+		return fmt.Errorf("find guardrail alarm: %w", err)
+	}
+
+	until := alarm.SilencedUntil(w.now())
+
+	if w.exporter != nil {
+		w.exporter.SetAlarmSilenced(!until.IsZero())
+	}
+
+	if until.Equal(w.until) {
+		return nil
+	}
+
+	switch {
+	case !until.IsZero():
+		w.logger.Warn(
+			"guardrail alarm silenced; the shaper is the only protection against reclamation",
+			zap.String("alarmId", alarm.ID),
+			zap.Time("until", until),
+			zap.String("description", alarm.Suppression.Description),
+			zap.Float64("silencedTargetMin", w.floor),
+		)
+	case !w.until.IsZero():
+		w.logger.Info("guardrail alarm silence ended", zap.String("alarmId", alarm.ID))
+	}
+
+	w.until = until
+
+	if w.silencer != nil {
+		w.silencer.SetAlarmSilence(until, w.floor)
+	}
+
+	return nil
+}
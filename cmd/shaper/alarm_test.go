@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var errAlarmStubFailure = errors.New("alarm stub failure")
+
+type stubAlarmManager struct {
+	topics    []oci.Topic
+	alarm     oci.GuardrailAlarm
+	findErr   error
+	verifyErr error
+	updated   []string
+	region    string
+	scope     [2]string
+	statuses  []oci.AlarmStatus
+	waitErr   error
+	waited    bool
+}
+
+func (s *stubAlarmManager) ListTopics(context.Context, string) ([]oci.Topic, error) {
+	return s.topics, nil
+}
+
+func (s *stubAlarmManager) FindGuardrailAlarm(
+	_ context.Context,
+	compartmentID string,
+	instanceID string,
+) (oci.GuardrailAlarm, error) {
+	s.scope = [2]string{compartmentID, instanceID}
+
+	return s.alarm, s.findErr
+}
+
+func (s *stubAlarmManager) SetAlarmDestinations(
+	_ context.Context,
+	_ string,
+	topicIDs []string,
+) error {
+	s.updated = topicIDs
+
+	return nil
+}
+
+func (s *stubAlarmManager) WaitForAlarmActive(
+	_ context.Context,
+	_ string,
+	_ []string,
+	_ time.Duration,
+	report func(oci.AlarmStatus),
+) error {
+	s.waited = true
+
+	for _, status := range s.statuses {
+		report(status)
+	}
+
+	return s.waitErr
+}
+
+func (s *stubAlarmManager) VerifyDestinations(context.Context, oci.GuardrailAlarm) error {
+	return s.verifyErr
+}
+
+func runAlarmWithStub(t *testing.T, manager *stubAlarmManager, args ...string) (int, string) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+
+	deps := defaultRunDeps()
+	deps.loadConfig = func(string) (runtimeConfig, error) {
+		cfg := defaultRuntimeConfig()
+		cfg.OCI.Offline = true
+		cfg.OCI.Region = "us-ashburn-1"
+
+		return cfg, nil
+	}
+	deps.newAlarmManager = func(region string) (alarmManager, error) {
+		manager.region = region
+
+		return manager, nil
+	}
+	deps.stdout = &stdout
+
+	base := []string{
+		"alarm", "destinations",
+		"--compartment", "ocid1.compartment.oc1..example",
+		"--instance", "ocid1.instance.oc1..example",
+	}
+
+	exitCode := run(t.Context(), append(base, args...), deps, new(bytes.Buffer))
+
+	return exitCode, stdout.String()
+}
+
+func TestAlarmDestinationsListsTopicsAndGuardrail(t *testing.T) {
+	t.Parallel()
+
+	manager := &stubAlarmManager{
+		topics: []oci.Topic{{ID: "ocid1.onstopic.oc1..ops", Name: "ops", State: "ACTIVE"}},
+		alarm: oci.GuardrailAlarm{
+			ID:           "ocid1.alarm.oc1..guardrail",
+			DisplayName:  "cpu-guardrail",
+			Destinations: []string{"ocid1.onstopic.oc1..ops"},
+		},
+	}
+
+	exitCode, output := runAlarmWithStub(t, manager, "--verify")
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success, got %d", exitCode)
+	}
+
+	for _, want := range []string{
+		"topic: ops ocid1.onstopic.oc1..ops (ACTIVE)",
+		"guardrail: cpu-guardrail ocid1.alarm.oc1..guardrail",
+		"destinations: ocid1.onstopic.oc1..ops",
+		"verify: ok",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, output)
+		}
+	}
+
+	if manager.region != "us-ashburn-1" {
+		t.Fatalf("expected configured region, got %q", manager.region)
+	}
+
+	wantScope := [2]string{"ocid1.compartment.oc1..example", "ocid1.instance.oc1..example"}
+	if manager.scope != wantScope {
+		t.Fatalf("unexpected guardrail scope %v", manager.scope)
+	}
+}
+
+func TestAlarmDestinationsWiresTopics(t *testing.T) {
+	t.Parallel()
+
+	manager := &stubAlarmManager{
+		alarm: oci.GuardrailAlarm{ID: "ocid1.alarm.oc1..guardrail"},
+		statuses: []oci.AlarmStatus{
+			{State: "ACTIVE", Destinations: []string{"ocid1.onstopic.oc1..old"}},
+			{State: "ACTIVE", Destinations: []string{"ocid1.onstopic.oc1..a"}},
+		},
+	}
+
+	exitCode, output := runAlarmWithStub(
+		t,
+		manager,
+		"--set",
+		"ocid1.onstopic.oc1..a, ocid1.onstopic.oc1..b",
+	)
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success, got %d", exitCode)
+	}
+
+	if strings.Join(manager.updated, ",") != "ocid1.onstopic.oc1..a,ocid1.onstopic.oc1..b" {
+		t.Fatalf("unexpected destinations %v", manager.updated)
+	}
+
+	if !strings.Contains(output, "destinations: ocid1.onstopic.oc1..a,ocid1.onstopic.oc1..b") {
+		t.Fatalf("expected updated destinations in output, got %q", output)
+	}
+
+	if !strings.Contains(output, "alarm state: ACTIVE destinations: ocid1.onstopic.oc1..old\n") {
+		t.Fatalf("expected intermediate alarm states in output, got %q", output)
+	}
+}
+
+func TestAlarmDestinationsSkipsWaitWhenDisabled(t *testing.T) {
+	t.Parallel()
+
+	manager := &stubAlarmManager{alarm: oci.GuardrailAlarm{ID: "ocid1.alarm.oc1..guardrail"}}
+
+	exitCode, _ := runAlarmWithStub(t, manager, "--set", "ocid1.onstopic.oc1..a", "--wait", "0")
+	if exitCode != exitCodeSuccess || manager.waited {
+		t.Fatalf("expected --wait 0 to skip polling, got %d / %v", exitCode, manager.waited)
+	}
+}
+
+func TestAlarmDestinationsFailures(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		manager *stubAlarmManager
+		args    []string
+	}{
+		{
+			name:    "guardrail missing",
+			manager: &stubAlarmManager{findErr: oci.ErrGuardrailAlarmNotFound},
+		},
+		{
+			name: "update not applied",
+			manager: &stubAlarmManager{
+				alarm:   oci.GuardrailAlarm{ID: "ocid1.alarm.oc1..guardrail"},
+				waitErr: oci.ErrAlarmNotActive,
+			},
+			args: []string{"--set", "ocid1.onstopic.oc1..a"},
+		},
+		{
+			name:    "verification failed",
+			manager: &stubAlarmManager{verifyErr: errAlarmStubFailure},
+			args:    []string{"--verify"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			exitCode, _ := runAlarmWithStub(t, testCase.manager, testCase.args...)
+			if exitCode != exitCodeRuntimeError {
+				t.Fatalf("expected runtime error exit code, got %d", exitCode)
+			}
+		})
+	}
+}
+
+func TestParseAlarmArgsRejectsUnknownSubcommand(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseAlarmArgs([]string{"create"})
+	if !errors.Is(err, errUnknownAlarmCommand) {
+		t.Fatalf("expected errUnknownAlarmCommand, got %v", err)
+	}
+}
+
+type recordingSilencer struct {
+	calls []time.Time
+	floor float64
+}
+
+func (r *recordingSilencer) SetAlarmSilence(until time.Time, floor float64) {
+	r.calls = append(r.calls, until)
+	r.floor = floor
+}
+
+func TestAlarmSilenceWatcherTracksSuppression(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour)
+	manager := &stubAlarmManager{ //nolint:exhaustruct
+		alarm: oci.GuardrailAlarm{
+			ID:           "ocid1.alarm.oc1..guardrail",
+			DisplayName:  "guardrail",
+			Destinations: nil,
+			Suppression: oci.AlarmSuppression{
+				From:        now.Add(-time.Hour),
+				Until:       until,
+				Description: "maintenance",
+			},
+		},
+	}
+	core, logs := observer.New(zapcore.InfoLevel)
+	silencer := new(recordingSilencer)
+	exporter := metricshttp.NewExporter()
+
+	watcher := &alarmSilenceWatcher{ //nolint:exhaustruct
+		manager:       manager,
+		compartmentID: "ocid1.compartment.oc1..example",
+		instanceID:    "ocid1.instance.oc1..example",
+		floor:         0.3,
+		silencer:      silencer,
+		exporter:      exporter,
+		logger:        zap.New(core),
+		now:           func() time.Time { return now },
+	}
+
+	for range 2 {
+		err := watcher.check(t.Context())
+		if err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+
+	if len(silencer.calls) != 1 || !silencer.calls[0].Equal(until) || silencer.floor != 0.3 {
+		t.Fatalf("unexpected silence updates %v (floor %v)", silencer.calls, silencer.floor)
+	}
+
+	data, _ := exporter.Render()
+	if !strings.Contains(string(data), "shaper_guardrail_alarm_silenced 1\n") {
+		t.Fatalf("expected silenced gauge, got %s", data)
+	}
+
+	manager.alarm.Suppression = oci.AlarmSuppression{} //nolint:exhaustruct
+
+	err := watcher.check(t.Context())
+	if err != nil || len(silencer.calls) != 2 || !silencer.calls[1].IsZero() {
+		t.Fatalf("expected the silence to be cleared, got %v (%v)", silencer.calls, err)
+	}
+
+	if logs.FilterMessageSnippet("guardrail alarm silenced").Len() != 1 ||
+		logs.FilterMessage("guardrail alarm silence ended").Len() != 1 {
+		t.Fatalf("unexpected log entries %+v", logs.All())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oci-cpu-shaper/internal/buildinfo"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+)
+
+// configureAudit opens the audit ring, records the start, and installs the
+// ring next to the webhook notifier as the decision observer. The returned
+// function records the exit code and closes the ring; a run that ends without
+// it leaves no stop record, which is how shaperctl audit dump tells a crash
+// from a shutdown. A ring that cannot be opened only costs the audit trail.
+func configureAudit(
+	logger *zap.Logger,
+	cfg auditConfig,
+	info buildinfo.Info,
+	mode string,
+	controller adapt.Controller,
+	notifier decisionWebhook,
+) func(code int) {
+	path := strings.TrimSpace(cfg.Path)
+	if path == "" {
+		return func(int) {}
+	}
+
+	ring, err := audit.Open(path, cfg.Records)
+	if err != nil {
+		logger.Warn("audit ring unavailable", zap.String("path", path), zap.Error(err))
+
+		return func(int) {}
+	}
+
+	ring.SetErrorHandler(func(err error) {
+		logger.Warn("audit record dropped", zap.Error(err))
+	})
+
+	appendAudit(logger, ring, audit.KindStart, map[string]any{
+		"mode":    strings.TrimSpace(mode),
+		"version": info.Version,
+	})
+
+	if publisher, ok := controller.(decisionPublisher); ok {
+		observers := adapt.DecisionObservers{ring}
+		if notifier != nil {
+			observers = append(observers, notifier)
+		}
+
+		publisher.SetDecisionObserver(observers)
+	}
+
+	logger.Info("recording audit ring", zap.String("path", path), zap.Int("records", cfg.Records))
+
+	return func(code int) {
+		appendAudit(logger, ring, audit.KindStop, map[string]any{"exitCode": code})
+
+		err := ring.Close()
+		if err != nil {
+			logger.Warn("failed to close audit ring", zap.Error(err))
+		}
+	}
+}
+
+func appendAudit(logger *zap.Logger, ring *audit.Ring, kind string, fields map[string]any) {
+	record := audit.Record{Sequence: 0, Timestamp: time.Time{}, Kind: kind, Fields: fields}
+
+	err := ring.Append(record)
+	if err != nil {
+		logger.Warn("audit record dropped", zap.String("kind", kind), zap.Error(err))
+	}
+}
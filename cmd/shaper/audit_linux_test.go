@@ -0,0 +1,96 @@
+//go:build linux
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oci-cpu-shaper/internal/buildinfo"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+	"oci-cpu-shaper/pkg/http/webhook"
+)
+
+func TestConfigureAuditRecordsStartDecisionsAndStop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+
+	notifier, err := webhook.NewNotifier("https://automation.example.com/shaper", nil, time.Second)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	stop := configureAudit(
+		zap.NewNop(),
+		auditConfig{Path: " " + path + " ", Records: 8},
+		buildinfo.Info{Version: "v1.2.3", GitCommit: "", BuildDate: ""},
+		modeEnforce,
+		controller,
+		notifier,
+	)
+
+	observers, ok := controller.observer.(adapt.DecisionObservers)
+	if !ok || len(observers) != 2 || observers[1] != notifier {
+		t.Fatalf("expected the ring and the webhook as observers, got %#v", controller.observer)
+	}
+
+	decision := adapt.Decision{
+		StepID:       "",
+		Timestamp:    time.Time{},
+		ResourceID:   "",
+		Mode:         modeEnforce,
+		State:        adapt.StateNormal,
+		P95:          0.2,
+		Target:       0.3,
+		NextInterval: time.Hour,
+		Err:          nil,
+	}
+
+	observers[0].ObserveDecision(decision)
+	stop(exitCodeSuccess)
+
+	records, err := audit.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	kinds := make([]string, 0, len(records))
+	for _, record := range records {
+		kinds = append(kinds, record.Kind)
+	}
+
+	if len(records) != 3 || kinds[0] != audit.KindStart || kinds[1] != audit.KindDecision ||
+		kinds[2] != audit.KindStop {
+		t.Fatalf("unexpected audit kinds %v", kinds)
+	}
+
+	if records[0].Fields["version"] != "v1.2.3" || records[2].Fields["exitCode"] != 0.0 {
+		t.Fatalf("unexpected start/stop fields %+v / %+v", records[0].Fields, records[2].Fields)
+	}
+
+	// A closed ring drops further decisions and records through the warning hook.
+	observers[0].ObserveDecision(decision)
+	stop(exitCodeSuccess)
+}
+
+func TestConfigureAuditSkipsWhenDisabledOrUnavailable(t *testing.T) {
+	t.Parallel()
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+	info := buildinfo.Info{Version: "", GitCommit: "", BuildDate: ""}
+
+	configureAudit(zap.NewNop(), auditConfig{Path: "", Records: 8}, info, "", controller, nil)(0)
+
+	missing := auditConfig{Path: filepath.Join(t.TempDir(), "missing", "audit.ring"), Records: 8}
+	configureAudit(zap.NewNop(), missing, info, "", controller, nil)(0)
+
+	if controller.observer != nil {
+		t.Fatalf("expected no observer, got %#v", controller.observer)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/budget"
+	"oci-cpu-shaper/pkg/health"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+// configureAPIBudget counts Monitoring and IMDS calls made by the daemon against
+// the configured daily budgets, exposes the counts through the exporter, and
+// logs a warning once per day when a budget is nearly spent.
+//
+//nolint:ireturn // returns the wrapped IMDS client for further wiring.
+func configureAPIBudget(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	imdsClient imds.Client,
+	exporter *metricshttp.Exporter,
+) (context.Context, imds.Client) {
+	tracker := budget.NewTracker(map[string]int{
+		budget.APIMonitoring: cfg.OCI.MonitoringBudget,
+		budget.APIIMDS:       cfg.OCI.IMDSBudget,
+	})
+
+	healthRegistryFromContext(ctx).Register(health.ComponentGuards, budgetCheck(tracker))
+
+	tracker.SetWarnHandler(func(usage budget.Usage) {
+		logger.Warn(
+			"oci api usage approaching daily budget",
+			zap.String("api", usage.API),
+			zap.Int("calls", usage.Calls),
+			zap.Int("budget", usage.Limit),
+		)
+	})
+
+	if exporter != nil {
+		exporter.SetAPIUsageSource(func() []metricshttp.APIUsage {
+			snapshot := tracker.Snapshot()
+
+			usages := make([]metricshttp.APIUsage, 0, len(snapshot))
+			for _, usage := range snapshot {
+				usages = append(usages, metricshttp.APIUsage{
+					API:   usage.API,
+					Calls: usage.Calls,
+					Limit: usage.Limit,
+				})
+			}
+
+			return usages
+		})
+	}
+
+	factory := metricsClientFactoryFromContext(ctx)
+	ctx = withMetricsClientFactory(
+		ctx,
+		func(compartmentID, region string) (oci.MetricsClient, error) {
+			client, err := factory(compartmentID, region)
+			if err != nil {
+				return nil, err
+			}
+
+			return budget.NewMetricsClient(client, tracker), nil
+		},
+	)
+
+	if imdsClient == nil {
+		return ctx, nil
+	}
+
+	return ctx, budget.NewIMDSClient(imdsClient, tracker)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+func TestConfigureAPIBudgetCountsCallsAndWarns(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	exporter := metricshttp.NewExporter()
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.MonitoringBudget = 2
+	cfg.OCI.IMDSBudget = 0
+
+	ctx := withMetricsClientFactory(
+		t.Context(),
+		func(string, string) (oci.MetricsClient, error) {
+			return newStubMetricsClient(), nil
+		},
+	)
+
+	ctx, imdsClient := configureAPIBudget(ctx, zap.New(core), cfg, newOfflineStubIMDS(), exporter)
+
+	metricsClient, err := metricsClientFactoryFromContext(ctx)("ocid1.compartment", "region")
+	if err != nil {
+		t.Fatalf("factory returned error: %v", err)
+	}
+
+	for range 2 {
+		_, _ = metricsClient.QueryP95CPU(context.Background(), "ocid1.instance")
+	}
+
+	_, _ = imdsClient.InstanceID(context.Background())
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_api_calls_today{api=\"imds\"} 1\n",
+		"shaper_api_calls_today{api=\"monitoring\"} 2\n",
+		"shaper_api_budget_remaining{api=\"monitoring\"} 0\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in output, got %s", want, data)
+		}
+	}
+
+	warnings := logs.FilterMessageSnippet("approaching daily budget").All()
+	if len(warnings) != 1 || warnings[0].ContextMap()["api"] != "monitoring" {
+		t.Fatalf("expected a single monitoring budget warning, got %+v", warnings)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/est"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/shape"
+)
+
+const (
+	// calibrationTarget is the duty cycle the startup self-test runs at.
+	calibrationTarget = 0.5
+	// calibrationTolerance is how far the measured share may stray from the
+	// expected one before the self-test warns.
+	calibrationTolerance = 0.1
+)
+
+type poolCalibrator interface {
+	Calibrate(
+		ctx context.Context,
+		source est.Source,
+		target float64,
+		window time.Duration,
+	) (shape.Calibration, error)
+}
+
+// calibratePool runs the pool.calibration self-test before the controller
+// takes over, so timer or scheduling problems that keep the workers from
+// reaching their target are reported at startup rather than after a week of
+// low P95 values. Failures only warn.
+func calibratePool(
+	ctx context.Context,
+	logger *zap.Logger,
+	pool poolStarter,
+	source est.Source,
+	window time.Duration,
+	exporter *metricshttp.Exporter,
+) {
+	calibrator, ok := pool.(poolCalibrator)
+	if !ok || window <= 0 {
+		return
+	}
+
+	calibration, err := calibrator.Calibrate(ctx, source, calibrationTarget, window)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("worker pool calibration failed", zap.Error(err))
+		}
+
+		return
+	}
+
+	if exporter != nil {
+		exporter.SetPoolCalibrationError(calibration.Error())
+	}
+
+	fields := []zap.Field{
+		zap.Float64("target", calibration.Target),
+		zap.Float64("expected", calibration.Expected),
+		zap.Float64("achieved", calibration.Achieved),
+		zap.Float64("baseline", calibration.Baseline),
+		zap.Float64("error", calibration.Error()),
+	}
+
+	if math.Abs(calibration.Error()) > calibrationTolerance {
+		logger.Warn(
+			"worker pool calibration error exceeds tolerance; check timer resolution "+
+				"and cpu scheduling",
+			fields...,
+		)
+
+		return
+	}
+
+	logger.Info("worker pool calibrated", fields...)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/est"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/shape"
+)
+
+var errStubCalibration = errors.New("stub: calibration failed")
+
+type calibratingPool struct {
+	stubPoolStarter
+
+	calibration shape.Calibration
+	err         error
+	windows     []time.Duration
+}
+
+func (c *calibratingPool) Calibrate(
+	_ context.Context,
+	_ est.Source,
+	target float64,
+	window time.Duration,
+) (shape.Calibration, error) {
+	c.windows = append(c.windows, window)
+	c.calibration.Target = target
+
+	return c.calibration, c.err
+}
+
+func TestCalibratePoolReportsError(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+	exporter := metricshttp.NewExporter()
+	pool := &calibratingPool{ //nolint:exhaustruct
+		calibration: shape.Calibration{Expected: 0.25, Achieved: 0.27}, //nolint:exhaustruct
+	}
+
+	calibratePool(t.Context(), logger, pool, nil, 0, exporter)
+
+	if len(pool.windows) != 0 {
+		t.Fatalf("expected a zero window to skip calibration, got %v", pool.windows)
+	}
+
+	calibratePool(t.Context(), logger, pool, nil, 4*time.Second, exporter)
+
+	data, _ := exporter.Render()
+	if logs.FilterMessage("worker pool calibrated").Len() != 1 ||
+		!strings.Contains(string(data), "shaper_pool_calibration_error 0.0200\n") {
+		t.Fatalf("expected a calibrated entry and gauge, got %+v / %s", logs.All(), data)
+	}
+
+	pool.calibration.Achieved = 0.05
+	calibratePool(t.Context(), logger, pool, nil, 4*time.Second, exporter)
+
+	if logs.FilterMessageSnippet("calibration error exceeds tolerance").Len() != 1 {
+		t.Fatalf("expected a tolerance warning, got %+v", logs.All())
+	}
+
+	pool.err = errStubCalibration
+	calibratePool(t.Context(), logger, pool, nil, 4*time.Second, exporter)
+
+	if logs.FilterMessage("worker pool calibration failed").Len() != 1 {
+		t.Fatalf("expected a failure warning, got %+v", logs.All())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/canary"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+)
+
+// configHash fingerprints the effective configuration, including environment
+// overrides. The canary settings are excluded so tuning the observation period
+// does not itself trigger a canary.
+func configHash(cfg runtimeConfig) string {
+	cfg.Canary = canaryConfig{}
+
+	return canary.Hash(cfg)
+}
+
+type canaryGateKey struct{}
+
+func withCanaryGate(ctx context.Context, gate *canary.Gate) context.Context {
+	return context.WithValue(ctx, canaryGateKey{}, gate)
+}
+
+func canaryGateFromContext(ctx context.Context) *canary.Gate {
+	gate, _ := ctx.Value(canaryGateKey{}).(*canary.Gate)
+
+	return gate
+}
+
+// configureCanary reports the configuration hash and decides whether an
+// enforce run starts as a dry-run canary. It returns the mode to build the
+// controller with and, for canaries, the rollout to start once it exists.
+func configureCanary(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	mode string,
+	exporter *metricshttp.Exporter,
+) (context.Context, string, *canary.Rollout) {
+	hash := configHash(cfg)
+
+	status := metricshttp.ConfigStatus{Hash: hash, Canary: false}
+	defer func() {
+		if exporter != nil {
+			exporter.SetConfigStatus(status)
+		}
+	}()
+
+	if strings.TrimSpace(mode) != modeEnforce || cfg.Canary.Observation <= 0 {
+		return ctx, mode, nil
+	}
+
+	stateFile := strings.TrimSpace(cfg.Canary.StateFile)
+
+	promoted, err := canary.ReadPromoted(stateFile)
+	if err != nil {
+		logger.Warn("failed to read promoted config hash", zap.Error(err))
+	}
+
+	if promoted == hash {
+		return ctx, mode, nil
+	}
+
+	status.Canary = true
+
+	logger.Info(
+		"new configuration starts in dry-run canary",
+		zap.String("configHash", hash),
+		zap.String("promotedHash", promoted),
+		zap.Duration("observation", cfg.Canary.Observation),
+	)
+
+	rollout := canary.NewRollout(hash, stateFile, cfg.Canary.Observation)
+	rollout.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	if exporter != nil {
+		rollout.SetPromoteHandler(func(hash string) {
+			exporter.SetConfigStatus(metricshttp.ConfigStatus{Hash: hash, Canary: false})
+		})
+	}
+
+	return withCanaryGate(ctx, rollout.Gate()), modeDryRun, rollout
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/canary"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+)
+
+type modeRecordingController struct {
+	adapt.Controller
+
+	mode string
+}
+
+func (m *modeRecordingController) SetMode(mode string) { m.mode = mode }
+
+func TestConfigHashIgnoresCanarySettings(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	base := configHash(cfg)
+
+	if len(base) != canary.HashLength {
+		t.Fatalf("expected a %d character hash, got %q", canary.HashLength, base)
+	}
+
+	cfg.Canary.Observation = time.Hour
+	cfg.Canary.StateFile = "/tmp/elsewhere"
+
+	if configHash(cfg) != base {
+		t.Fatal("expected canary settings to be excluded from the hash")
+	}
+
+	cfg.Controller.TargetMax += 0.05
+
+	if configHash(cfg) == base {
+		t.Fatal("expected a threshold change to change the hash")
+	}
+}
+
+func TestConfigureCanaryPromotesNewEnforceConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.Canary.Observation = time.Hour
+	cfg.Canary.StateFile = filepath.Join(t.TempDir(), "state", "promoted-config")
+
+	exporter := metricshttp.NewExporter()
+
+	ctx, mode, rollout := configureCanary(t.Context(), zap.NewNop(), cfg, modeEnforce, exporter)
+	if mode != modeDryRun || rollout == nil {
+		t.Fatalf("expected an unpromoted config to start as a canary, got %q", mode)
+	}
+
+	gate := canaryGateFromContext(ctx)
+	if gate == nil {
+		t.Fatal("expected the canary gate in the context")
+	}
+
+	if gate != rollout.Gate() {
+		t.Fatal("expected the context gate to belong to the rollout")
+	}
+
+	assertRenderContains(t, exporter, "shaper_config_canary 1\n")
+
+	controller := new(modeRecordingController)
+	rollout.Promote(controller)
+
+	if controller.mode != modeEnforce {
+		t.Fatalf("expected the controller promoted to enforce, got %q", controller.mode)
+	}
+
+	assertRenderContains(t, exporter, "shaper_config_canary 0\n")
+
+	state, err := os.ReadFile(cfg.Canary.StateFile)
+	if err != nil || strings.TrimSpace(string(state)) != configHash(cfg) {
+		t.Fatalf("expected the promoted hash to be recorded, got %q (err=%v)", state, err)
+	}
+
+	_, mode, rollout = configureCanary(t.Context(), zap.NewNop(), cfg, modeEnforce, exporter)
+	if mode != modeEnforce || rollout != nil {
+		t.Fatalf("expected a promoted config to enforce directly, got %q", mode)
+	}
+
+	_, mode, rollout = configureCanary(t.Context(), zap.NewNop(), cfg, modeDryRun, exporter)
+	if mode != modeDryRun || rollout != nil {
+		t.Fatalf("expected dry-run to bypass the canary, got %q", mode)
+	}
+}
+
+func assertRenderContains(t *testing.T, exporter *metricshttp.Exporter, want string) {
+	t.Helper()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected %q in output, got %s", want, data)
+	}
+}
@@ -4,14 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+	"oci-cpu-shaper/pkg/canary"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
+	"oci-cpu-shaper/pkg/hooks"
+	listenerhttp "oci-cpu-shaper/pkg/http/listener"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/http/webhook"
+	"oci-cpu-shaper/pkg/memtrim"
+	"oci-cpu-shaper/pkg/metadata"
+	"oci-cpu-shaper/pkg/oci"
 	"oci-cpu-shaper/pkg/shape"
+	"oci-cpu-shaper/pkg/suppress"
+	"oci-cpu-shaper/pkg/update"
 )
 
 const (
@@ -25,16 +37,129 @@ const (
 	envFastInterval      = "SHAPER_FAST_INTERVAL"
 	envPoolWorkers       = "SHAPER_WORKER_COUNT"
 	envHTTPBind          = "HTTP_ADDR"
+	envHTTPNetwork       = "HTTP_NETWORK"
+	envHTTPBindFallback  = "HTTP_BIND_FALLBACK"
 	envCompartmentID     = "OCI_COMPARTMENT_ID"
 	envOCIRegion         = "OCI_REGION"
 	envInstanceID        = "OCI_INSTANCE_ID"
 	envOCIOffline        = "OCI_OFFLINE"
+	envOCIDisplayName    = "OCI_RESOLVE_DISPLAY_NAME"
+	envOCIPreferRegion   = "OCI_PREFER_INSTANCE_REGION"
 	envFallbackTarget    = "SHAPER_FALLBACK_TARGET"
 	envRelaxedThreshold  = "SHAPER_RELAXED_THRESHOLD"
 	envGoalLow           = "SHAPER_GOAL_LOW"
 	envGoalHigh          = "SHAPER_GOAL_HIGH"
 	envSuppressThreshold = "SHAPER_SUPPRESS_THRESHOLD"
 	envSuppressResume    = "SHAPER_SUPPRESS_RESUME"
+	envRuntimeMetrics    = "SHAPER_RUNTIME_METRICS"
+	envMetricsNamespace  = "SHAPER_METRICS_NAMESPACE"
+	envMetricsLabels     = "SHAPER_METRICS_LABELS"
+	envScrapeSample      = "SHAPER_SCRAPE_SAMPLE_INTERVAL"
+	envAccessLogSample   = "SHAPER_ACCESS_LOG_SAMPLE"
+	envTextfileDir       = "SHAPER_TEXTFILE_DIR"
+	envTextfileInterval  = "SHAPER_TEXTFILE_INTERVAL"
+	envWebhookURL        = "SHAPER_WEBHOOK_URL"
+	envWebhookTimeout    = "SHAPER_WEBHOOK_TIMEOUT"
+	envHistoryPath       = "SHAPER_HISTORY_PATH"
+	envHistoryKeyFile    = "SHAPER_HISTORY_KEY_FILE"
+	envHistoryVaultKey   = "SHAPER_HISTORY_VAULT_SECRET_ID"
+	envMaxTargetChanges  = "SHAPER_MAX_TARGET_CHANGES_PER_HOUR"
+	envEstimatorRestart  = "SHAPER_ESTIMATOR_RESTART_AFTER"
+	envEstimatorBuffer   = "SHAPER_ESTIMATOR_BUFFER"
+	envSuppressFile      = "SHAPER_SUPPRESS_FILE"
+	envSuppressFileTTL   = "SHAPER_SUPPRESS_FILE_DURATION"
+	envMaintenanceWatch  = "SHAPER_SUPPRESS_MAINTENANCE_INTERVAL"
+	envMaintenanceLead   = "SHAPER_SUPPRESS_MAINTENANCE_LEAD"
+	envMaintenanceGrace  = "SHAPER_SUPPRESS_MAINTENANCE_GRACE"
+	envHookPre           = "SHAPER_HOOK_PRE_APPLY"
+	envHookPreTimeout    = "SHAPER_HOOK_PRE_APPLY_TIMEOUT"
+	envHookPost          = "SHAPER_HOOK_POST_APPLY"
+	envHookPostTimeout   = "SHAPER_HOOK_POST_APPLY_TIMEOUT"
+	envMonitoringBudget  = "OCI_MONITORING_DAILY_BUDGET"
+	envIMDSBudget        = "OCI_IMDS_DAILY_BUDGET"
+	envPoolStartFailure  = "SHAPER_POOL_START_FAILURE_POLICY"
+	envUpdateCheck       = "SHAPER_UPDATE_CHECK"
+	envMetadataRefresh   = "OCI_METADATA_REFRESH_INTERVAL"
+	envStatusMetadata    = "OCI_STATUS_METADATA_INTERVAL"
+	envResourceGroup     = "OCI_RESOURCE_GROUP"
+	envQueryDimensions   = "OCI_QUERY_DIMENSIONS"
+	envUpdateInterval    = "SHAPER_UPDATE_CHECK_INTERVAL"
+	envControllerPolicy  = "SHAPER_CONTROLLER_POLICY"
+	envInitialState      = "SHAPER_CONTROLLER_INITIAL_STATE"
+	envAdminGroup        = "SHAPER_ADMIN_DYNAMIC_GROUP_ID"
+	envAdminRule         = "SHAPER_ADMIN_MATCHING_RULE"
+	envAdminIssuerKeys   = "SHAPER_ADMIN_ISSUER_KEYS_URL"
+	envAdminTenancy      = "SHAPER_ADMIN_TENANCY_ID"
+	envAdminSnapshotDir  = "SHAPER_ADMIN_SNAPSHOT_DIR"
+	envAdminAllowRemote  = "SHAPER_ADMIN_ALLOW_REMOTE"
+	envCanaryObservation = "SHAPER_CANARY_OBSERVATION"
+	envCanaryStateFile   = "SHAPER_CANARY_STATE_FILE"
+	envAlarmWatch        = "SHAPER_ALARM_WATCH_INTERVAL"
+	envAlarmSilencedMin  = "SHAPER_ALARM_SILENCED_TARGET_MIN"
+	envPoolCalibration   = "SHAPER_POOL_CALIBRATION"
+	envPoolRestartMissed = "SHAPER_POOL_RESTART_AFTER_MISSED_QUANTA"
+	envLogBackend        = "SHAPER_LOG_BACKEND"
+	envHealthDisable     = "SHAPER_HEALTH_DISABLE"
+	envAuditPath         = "SHAPER_AUDIT_PATH"
+	envAuditRecords      = "SHAPER_AUDIT_RECORDS"
+	envStrictEnv         = "SHAPER_STRICT_ENV"
+	envSuppressLearnFor  = "SHAPER_SUPPRESS_LEARN_FOR"
+	envRunAsUser         = "SHAPER_RUN_AS_USER"
+	envRunAsGroup        = "SHAPER_RUN_AS_GROUP"
+	envSuppressLearnFile = "SHAPER_SUPPRESS_LEARN_STATE_FILE"
+	envMemoryLimit       = "SHAPER_MEMORY_LIMIT_MIB"
+	envMemoryTrim        = "SHAPER_MEMORY_TRIM_INTERVAL"
+	envMemoryTrimAbove   = "SHAPER_MEMORY_TRIM_ABOVE_MIB"
+)
+
+const (
+	defaultSuppressFile         = "/run/oci-cpu-shaper/suppress"
+	defaultSuppressFileDuration = time.Hour
+	defaultSuppressLearnFile    = "/var/lib/oci-cpu-shaper/suppress-learning.json"
+	defaultMonitoringBudget     = 1440
+	defaultIMDSBudget           = 1440
+	secondsPerHour              = 3600
+	bytesPerMiB                 = 1 << 20
+)
+
+// Estimator cadences derived when estimator.interval is unset. Suppression
+// needs fresh host samples to react within seconds; without it the sampler only
+// feeds host_cpu_percent, so it can run at a fraction of the slow-loop cadence.
+const (
+	suppressingEstimatorInterval = time.Second
+	idleEstimatorIntervalMax     = 15 * time.Second
+	idleEstimatorSamplesPerStep  = 240
+)
+
+// Logging backends selectable with log.backend.
+const (
+	logBackendZap  = "zap"
+	logBackendSlog = "slog"
+)
+
+const (
+	httpNetworkDual = "dual"
+	httpNetworkTCP4 = "tcp4"
+	httpNetworkTCP6 = "tcp6"
+)
+
+var (
+	errInvalidHTTPNetwork        = errors.New("unsupported http.network")
+	errInvalidHTTPListener       = errors.New("invalid http.listeners entry")
+	errInvalidStartFailurePolicy = errors.New("unsupported pool.startFailurePolicy")
+	errInvalidOCPUCount          = errors.New("ocpu count must be positive")
+	errHistoryKeyConflict        = errors.New(
+		"history.keyFile and history.vaultSecretId are mutually exclusive",
+	)
+	errInvalidAdminAuth  = errors.New("invalid admin authentication config")
+	errInvalidAlarm      = errors.New("invalid alarm config")
+	errInvalidSuppress   = errors.New("invalid suppression config")
+	errInvalidEnv        = errors.New("invalid environment overrides")
+	errEnvNegative       = errors.New("must not be negative")
+	errEnvNotBool        = errors.New("must be a boolean")
+	errEnvNotDimensions  = errors.New("must be comma-separated name=value pairs")
+	errInvalidLogBackend = errors.New("unsupported log.backend")
+	errMinimalBuild      = errors.New("not available in minimal builds")
 )
 
 type runtimeConfig struct {
@@ -43,6 +168,22 @@ type runtimeConfig struct {
 	Pool       poolConfig
 	HTTP       httpConfig
 	OCI        ociConfig
+	Webhook    webhookConfig
+	History    historyConfig
+	Suppress   suppressConfig
+	Hooks      hooksConfig
+	Update     updateConfig
+	Admin      adminConfig
+	Canary     canaryConfig
+	Alarm      alarmConfig
+	Log        logConfig
+	Health     healthConfig
+	Audit      auditConfig
+	RunAs      runAsConfig
+	Memory     memoryConfig
+	// IgnoredEnv lists the environment overrides that did not parse and kept
+	// the file or default value, so startup can warn about them.
+	IgnoredEnv []string
 }
 
 type controllerConfig struct {
@@ -59,19 +200,90 @@ type controllerConfig struct {
 	RelaxedThreshold  float64
 	SuppressThreshold float64
 	SuppressResume    float64
+	MaxChangesPerHour int
+	OCPUSeconds       ocpuSecondsConfig
+	Policy            string
+	InitialState      string
+	PID               adapt.PIDGains
+	Schedule          []adapt.ScheduleWindow
+	Blackout          []adapt.BlackoutWindow
+	SuppressLearning  adapt.SuppressLearning
+	// SuppressLearningFile keeps the learning progress across restarts; empty
+	// starts learning afresh on every start.
+	SuppressLearningFile string
+}
+
+// ocpuSecondsConfig expresses targets as absolute OCPU-seconds per hour. Non-zero
+// values replace the matching ratio once the OCPU count is known.
+type ocpuSecondsConfig struct {
+	Start    float64
+	Min      float64
+	Max      float64
+	Fallback float64
 }
 
 type estimatorConfig struct {
-	Interval time.Duration
+	Interval     time.Duration
+	RestartAfter int
+	// Buffer is how many observations queue for a slow controller before the
+	// oldest is dropped; zero selects est.DefaultBuffer.
+	Buffer int
 }
 
 type poolConfig struct {
-	Workers int
-	Quantum time.Duration
+	// Workers is the duty-cycle worker count; zero derives it from the shape.
+	Workers            int
+	Quantum            time.Duration
+	StartFailurePolicy shape.StartFailurePolicy
+	// Calibration is how long the startup self-test runs; zero skips it.
+	Calibration time.Duration
+	// RestartAfterMissedQuanta replaces a worker that misses this many
+	// heartbeats; zero disables restarts.
+	RestartAfterMissedQuanta int
 }
 
 type httpConfig struct {
+	Bind           string
+	Network        string
+	RuntimeMetrics bool
+	// MetricsNamespace prefixes the exported metric names; see
+	// metricshttp.Exporter.SetNamespace.
+	MetricsNamespace string
+	// MetricsLabels are attached to every exported series; see
+	// metricshttp.Exporter.SetStaticLabels.
+	MetricsLabels map[string]string
+	// Listeners replaces Bind when set, giving each address its own TLS and
+	// authentication settings.
+	Listeners []listenerConfig
+	// BindFallback is the listenerhttp.Fallback* applied when a listener
+	// cannot bind its address.
+	BindFallback string
+	// ScrapeSampleInterval bounds how often a scrape takes an on-demand host
+	// CPU sample; zero keeps host_cpu_percent at the last estimator tick.
+	ScrapeSampleInterval time.Duration
+	// TextfileDir, when set, is a node_exporter textfile collector directory
+	// the series are written into every TextfileInterval.
+	TextfileDir      string
+	TextfileInterval time.Duration
+	// AccessLogSample is the share of requests on every listener written to
+	// the access log; zero disables it.
+	AccessLogSample float64
+}
+
+// listenerConfig is one http.listeners entry. Auth gates /metrics on that
+// listener only; /healthz stays open and the admin API keeps its own checks.
+type listenerConfig struct {
 	Bind string
+	TLS  listenerhttp.TLS
+	Auth listenerAuthConfig
+}
+
+// listenerAuthConfig names the files holding a listener's credentials, so
+// secrets stay out of the configuration file.
+type listenerAuthConfig struct {
+	BearerTokenFile string
+	Username        string
+	PasswordFile    string
 }
 
 type ociConfig struct {
@@ -79,6 +291,144 @@ type ociConfig struct {
 	Region        string
 	InstanceID    string
 	Offline       bool
+	DisplayName   bool
+	// PreferInstanceRegion replaces a Region that names another region than
+	// the one IMDS reports for the instance; otherwise the mismatch is only
+	// logged.
+	PreferInstanceRegion bool
+	// MonitoringBudget and IMDSBudget cap daily API calls before warnings are
+	// logged; zero counts calls without a budget.
+	MonitoringBudget int
+	IMDSBudget       int
+	// MetadataRefresh is how often compartment, region and shape are re-read
+	// from IMDS to detect moves and resizes; zero disables the re-reads.
+	MetadataRefresh time.Duration
+	// StatusMetadata is how often the mode, state and target are written into
+	// the instance's custom metadata when they changed; zero disables it.
+	StatusMetadata time.Duration
+	// ResourceGroup and QueryDimensions narrow every Monitoring query beyond
+	// the instance; see oci.QueryScope.
+	ResourceGroup   string
+	QueryDimensions map[string]string
+}
+
+type webhookConfig struct {
+	URL     string
+	Timeout time.Duration
+}
+
+type updateConfig struct {
+	Check      bool
+	Interval   time.Duration
+	Repository string
+}
+
+// adminConfig enables instance principal authentication of the admin API. The
+// accepted instances come from DynamicGroupID or an inline MatchingRule.
+// SnapshotDir enables /admin/snapshot, which writes metrics and history there.
+// Without authentication the admin API only answers loopback callers unless
+// AllowRemote is set.
+type adminConfig struct {
+	DynamicGroupID string
+	MatchingRule   string
+	IssuerKeysURL  string
+	TenancyID      string
+	SnapshotDir    string
+	AllowRemote    bool
+}
+
+func (a adminConfig) authEnabled() bool {
+	return strings.TrimSpace(a.DynamicGroupID) != "" || strings.TrimSpace(a.MatchingRule) != ""
+}
+
+// canaryConfig holds enforce runs with a configuration whose hash differs from
+// the one recorded in StateFile in dry-run for Observation before promoting
+// them; zero Observation disables the canary.
+type canaryConfig struct {
+	Observation time.Duration
+	StateFile   string
+}
+
+// alarmConfig polls the guardrail alarm for suppression windows every
+// WatchInterval; zero disables the watch. While the alarm is silenced a
+// positive SilencedTargetMin keeps a floor under the target.
+type alarmConfig struct {
+	WatchInterval     time.Duration
+	SilencedTargetMin float64
+}
+
+// logConfig selects the backend that writes the daemon log: zap's JSON encoder
+// or the standard library's slog JSON handler, with the same keys either way.
+type logConfig struct {
+	Backend string
+}
+
+// healthConfig lists the health.Component* names excluded from the /healthz
+// aggregate, such as oci on hosts that expect Monitoring to be unreachable.
+type healthConfig struct {
+	Disable []string
+}
+
+// auditConfig keeps the last Records decision and lifecycle records in a
+// memory-mapped ring at Path for post-crash inspection; an empty Path disables
+// it.
+type auditConfig struct {
+	Path    string
+	Records int
+}
+
+// runAsConfig names the user and group, each a name or numeric ID, the daemon
+// switches to once its privileged setup is done; an empty User keeps the
+// starting identity.
+type runAsConfig struct {
+	User  string
+	Group string
+}
+
+// memoryConfig sets the Go soft memory limit to LimitMiB and, every
+// TrimInterval, returns freed heap to the OS once the resident set exceeds
+// TrimAboveMiB. Zero leaves the runtime's limit and disables trimming.
+type memoryConfig struct {
+	LimitMiB     int
+	TrimInterval time.Duration
+	TrimAboveMiB int
+}
+
+func (m memoryConfig) trimConfig() memtrim.Config {
+	return memtrim.Config{
+		Limit:     int64(m.LimitMiB) * bytesPerMiB,
+		Interval:  m.TrimInterval,
+		TrimAbove: int64(m.TrimAboveMiB) * bytesPerMiB,
+	}
+}
+
+type historyConfig struct {
+	Path string
+	// KeyFile and VaultSecretID select where the AES-256 key that encrypts the
+	// history file comes from. At most one may be set; neither keeps it plaintext.
+	KeyFile       string
+	VaultSecretID string
+}
+
+type suppressConfig struct {
+	File         string
+	FileDuration time.Duration
+	// MaintenanceInterval is how often IMDS is polled for scheduled
+	// maintenance; MaintenanceLead and MaintenanceGrace bound the pause
+	// around it.
+	MaintenanceInterval time.Duration
+	MaintenanceLead     time.Duration
+	MaintenanceGrace    time.Duration
+}
+
+type hooksConfig struct {
+	PreApply  hookConfig
+	PostApply hookConfig
+}
+
+type hookConfig struct {
+	Command []string
+	Timeout time.Duration
 }
 
 type fileConfig struct {
@@ -87,6 +437,19 @@ type fileConfig struct {
 	Pool       poolFileConfig       `yaml:"pool"`
 	HTTP       httpFileConfig       `yaml:"http"`
 	OCI        ociFileConfig        `yaml:"oci"`
+	Webhook    webhookFileConfig    `yaml:"webhook"`
+	History    historyFileConfig    `yaml:"history"`
+	Suppress   suppressFileConfig   `yaml:"suppression"`
+	Hooks      hooksFileConfig      `yaml:"hooks"`
+	Update     updateFileConfig     `yaml:"update"`
+	Admin      adminFileConfig      `yaml:"admin"`
+	Canary     canaryFileConfig     `yaml:"canary"`
+	Alarm      alarmFileConfig      `yaml:"alarm"`
+	Log        logFileConfig        `yaml:"log"`
+	Health     healthFileConfig     `yaml:"health"`
+	Audit      auditFileConfig      `yaml:"audit"`
+	RunAs      runAsFileConfig      `yaml:"runAs"`
+	Memory     memoryFileConfig     `yaml:"memory"`
 }
 
 type controllerFileConfig struct {
@@ -103,19 +466,161 @@ type controllerFileConfig struct {
 	RelaxedThreshold  *float64       `yaml:"relaxedThreshold"`
 	SuppressThreshold *float64       `yaml:"suppressThreshold"`
 	SuppressResume    *float64       `yaml:"suppressResume"`
+	MaxChangesPerHour *int           `yaml:"maxChangesPerHour"`
+
+	OCPUSeconds  ocpuSecondsFileConfig `yaml:"ocpuSecondsPerHour"`
+	Policy       *string               `yaml:"policy"`
+	InitialState *string               `yaml:"initialState"`
+	PID          pidFileConfig         `yaml:"pid"`
+	Schedule     []scheduleWindowFile  `yaml:"schedule"`
+	Blackout     []blackoutWindowFile  `yaml:"blackout"`
+
+	SuppressLearning suppressLearningFileConfig `yaml:"suppressLearning"`
+	// TimeZone is the IANA zone of schedule and blackout windows that do not
+	// name their own; without either they follow the process's local time.
+	TimeZone *timeZone `yaml:"timezone"`
+}
+
+type pidFileConfig struct {
+	Proportional *float64 `yaml:"proportional"`
+	Integral     *float64 `yaml:"integral"`
+	Derivative   *float64 `yaml:"derivative"`
+}
+
+type scheduleWindowFile struct {
+	Start     timeOfDay `yaml:"start"`
+	End       timeOfDay `yaml:"end"`
+	TargetMax float64   `yaml:"targetMax"`
+	TimeZone  *timeZone `yaml:"timezone"`
+}
+
+type blackoutWindowFile struct {
+	Start    timeOfDay `yaml:"start"`
+	End      timeOfDay `yaml:"end"`
+	TimeZone *timeZone `yaml:"timezone"`
+}
+
+// timeZone decodes an IANA time zone name such as "Europe/Berlin".
+type timeZone struct {
+	location *time.Location
+}
+
+func (z *timeZone) UnmarshalYAML(node *yaml.Node) error {
+	location, err := time.LoadLocation(strings.TrimSpace(node.Value))
+	if err != nil {
+		return fmt.Errorf(
+			"%w: controller time zone %q: %w",
+			adapt.ErrInvalidConfig,
+			node.Value,
+			err,
+		)
+	}
+
+	z.location = location
+
+	return nil
+}
+
+// windowLocation returns the zone a window names, else the controller's, else
+// nil for local time.
+func windowLocation(window, controller *timeZone) *time.Location {
+	switch {
+	case window != nil:
+		return window.location
+	case controller != nil:
+		return controller.location
+	default:
+		return nil
+	}
+}
+
+// timeOfDay decodes an "HH:MM" wall-clock time as an offset from midnight.
+type timeOfDay time.Duration
+
+func (t *timeOfDay) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(node.Value))
+	if err != nil {
+		return fmt.Errorf(
+			"%w: controller window time %q must use HH:MM",
+			adapt.ErrInvalidConfig,
+			node.Value,
+		)
+	}
+
+	*t = timeOfDay(time.Duration(parsed.Hour())*time.Hour +
+		time.Duration(parsed.Minute())*time.Minute)
+
+	return nil
+}
+
+type ocpuSecondsFileConfig struct {
+	Start    *float64 `yaml:"start"`
+	Min      *float64 `yaml:"min"`
+	Max      *float64 `yaml:"max"`
+	Fallback *float64 `yaml:"fallback"`
+}
+
+type suppressLearningFileConfig struct {
+	LearnFor     *time.Duration `yaml:"learnFor"`
+	Quantile     *float64       `yaml:"quantile"`
+	Headroom     *float64       `yaml:"headroom"`
+	ResumeGap    *float64       `yaml:"resumeGap"`
+	ThresholdMin *float64       `yaml:"thresholdMin"`
+	ThresholdMax *float64       `yaml:"thresholdMax"`
+	StateFile    *string        `yaml:"stateFile"`
 }
 
 type estimatorFileConfig struct {
-	Interval *time.Duration `yaml:"interval"`
+	Interval     *time.Duration `yaml:"interval"`
+	RestartAfter *int           `yaml:"restartAfter"`
+	Buffer       *int           `yaml:"buffer"`
 }
 
 type poolFileConfig struct {
-	Workers *int           `yaml:"workers"`
-	Quantum *time.Duration `yaml:"quantum"`
+	Workers                  *int           `yaml:"workers"`
+	Quantum                  *time.Duration `yaml:"quantum"`
+	StartFailurePolicy       *string        `yaml:"startFailurePolicy"`
+	Calibration              *time.Duration `yaml:"calibration"`
+	RestartAfterMissedQuanta *int           `yaml:"restartAfterMissedQuanta"`
 }
 
 type httpFileConfig struct {
-	Bind *string `yaml:"bind"`
+	Bind           *string `yaml:"bind"`
+	Network        *string `yaml:"network"`
+	RuntimeMetrics *bool   `yaml:"runtimeMetrics"`
+	// MetricsNamespace overrides the metric name prefix (default "shaper").
+	MetricsNamespace *string `yaml:"metricsNamespace"`
+	// MetricsLabels adds static labels, such as team or env, to every series.
+	MetricsLabels map[string]string `yaml:"metricsLabels"`
+	// Listeners, when present, replaces bind with per-listener settings.
+	Listeners []listenerFileConfig `yaml:"listeners"`
+	// BindFallback selects none, retry, or ephemeral when a bind fails.
+	BindFallback *string `yaml:"bindFallback"`
+	// ScrapeSampleInterval enables on-demand host CPU samples at scrape time.
+	ScrapeSampleInterval *time.Duration `yaml:"scrapeSampleInterval"`
+	// TextfileDir and TextfileInterval write the series for node_exporter.
+	TextfileDir      *string        `yaml:"textfileDir"`
+	TextfileInterval *time.Duration `yaml:"textfileInterval"`
+	// AccessLogSample logs that share of the requests the listeners serve.
+	AccessLogSample *float64 `yaml:"accessLogSample"`
+}
+
+type listenerFileConfig struct {
+	Bind string                 `yaml:"bind"`
+	TLS  listenerTLSFileConfig  `yaml:"tls"`
+	Auth listenerAuthFileConfig `yaml:"auth"`
+}
+
+type listenerTLSFileConfig struct {
+	CertFile     string `yaml:"certFile"`
+	KeyFile      string `yaml:"keyFile"`
+	ClientCAFile string `yaml:"clientCAFile"`
+}
+
+type listenerAuthFileConfig struct {
+	BearerTokenFile string `yaml:"bearerTokenFile"`
+	Username        string `yaml:"username"`
+	PasswordFile    string `yaml:"passwordFile"`
 }
 
 type ociFileConfig struct {
@@ -123,6 +628,96 @@ type ociFileConfig struct {
 	Region        *string `yaml:"region"`
 	InstanceID    *string `yaml:"instanceId"`
 	Offline       *bool   `yaml:"offline"`
+	DisplayName   *bool   `yaml:"resolveDisplayName"`
+
+	PreferInstanceRegion *bool `yaml:"preferInstanceRegion"`
+
+	MonitoringBudget *int `yaml:"monitoringDailyBudget"`
+	IMDSBudget       *int `yaml:"imdsDailyBudget"`
+
+	MetadataRefresh *time.Duration `yaml:"metadataRefreshInterval"`
+	StatusMetadata  *time.Duration `yaml:"statusMetadataInterval"`
+
+	ResourceGroup   *string           `yaml:"resourceGroup"`
+	QueryDimensions map[string]string `yaml:"queryDimensions"`
+}
+
+type webhookFileConfig struct {
+	URL     *string        `yaml:"url"`
+	Timeout *time.Duration `yaml:"timeout"`
+}
+
+type auditFileConfig struct {
+	Path    *string `yaml:"path"`
+	Records *int    `yaml:"records"`
+}
+
+type runAsFileConfig struct {
+	User  *string `yaml:"user"`
+	Group *string `yaml:"group"`
+}
+
+type memoryFileConfig struct {
+	LimitMiB     *int           `yaml:"limitMiB"`
+	TrimInterval *time.Duration `yaml:"trimInterval"`
+	TrimAboveMiB *int           `yaml:"trimAboveMiB"`
+}
+
+type historyFileConfig struct {
+	Path          *string `yaml:"path"`
+	KeyFile       *string `yaml:"keyFile"`
+	VaultSecretID *string `yaml:"vaultSecretId"`
+}
+
+type updateFileConfig struct {
+	Check      *bool          `yaml:"check"`
+	Interval   *time.Duration `yaml:"interval"`
+	Repository *string        `yaml:"repository"`
+}
+
+type adminFileConfig struct {
+	DynamicGroupID *string `yaml:"dynamicGroupId"`
+	MatchingRule   *string `yaml:"matchingRule"`
+	IssuerKeysURL  *string `yaml:"issuerKeysUrl"`
+	TenancyID      *string `yaml:"tenancyId"`
+	SnapshotDir    *string `yaml:"snapshotDir"`
+	AllowRemote    *bool   `yaml:"allowRemote"`
+}
+
+type canaryFileConfig struct {
+	Observation *time.Duration `yaml:"observation"`
+	StateFile   *string        `yaml:"stateFile"`
+}
+
+type alarmFileConfig struct {
+	WatchInterval     *time.Duration `yaml:"watchInterval"`
+	SilencedTargetMin *float64       `yaml:"silencedTargetMin"`
+}
+
+type logFileConfig struct {
+	Backend *string `yaml:"backend"`
+}
+
+type healthFileConfig struct {
+	Disable []string `yaml:"disable"`
+}
+
+type suppressFileConfig struct {
+	File                *string        `yaml:"file"`
+	FileDuration        *time.Duration `yaml:"fileDuration"`
+	MaintenanceInterval *time.Duration `yaml:"maintenanceInterval"`
+	MaintenanceLead     *time.Duration `yaml:"maintenanceLead"`
+	MaintenanceGrace    *time.Duration `yaml:"maintenanceGrace"`
+}
+
+type hooksFileConfig struct {
+	PreApply  hookFileConfig `yaml:"preApply"`
+	PostApply hookFileConfig `yaml:"postApply"`
+}
+
+type hookFileConfig struct {
+	Command []string       `yaml:"command"`
+	Timeout *time.Duration `yaml:"timeout"`
 }
 
 func defaultRuntimeConfig() runtimeConfig {
@@ -143,17 +738,49 @@ func defaultRuntimeConfig() runtimeConfig {
 	cfg.Controller.RelaxedThreshold = defaults.RelaxedThreshold
 	cfg.Controller.SuppressThreshold = defaults.SuppressThreshold
 	cfg.Controller.SuppressResume = defaults.SuppressResume
+	cfg.Controller.MaxChangesPerHour = defaults.MaxChangesPerHour
+	cfg.Controller.Policy = defaults.Policy
+	cfg.Controller.InitialState = defaults.InitialState
+	cfg.Controller.PID = defaults.PID
+	cfg.Controller.SuppressLearning = defaults.SuppressLearning
+	cfg.Controller.SuppressLearningFile = defaultSuppressLearnFile
 
-	cfg.Estimator.Interval = time.Second
-
-	cfg.Pool.Workers = runtime.NumCPU()
-	if cfg.Pool.Workers <= 0 {
-		cfg.Pool.Workers = 1
-	}
+	cfg.Estimator.RestartAfter = est.DefaultRestartThreshold
+	cfg.Estimator.Buffer = est.DefaultBuffer
 
 	cfg.Pool.Quantum = shape.DefaultQuantum
+	cfg.Pool.StartFailurePolicy = shape.StartFailureContinue
+	cfg.Pool.RestartAfterMissedQuanta = shape.DefaultRestartAfterMissedQuanta
 
 	cfg.HTTP.Bind = ":9108"
+	cfg.HTTP.Network = httpNetworkDual
+	cfg.HTTP.MetricsNamespace = metricshttp.DefaultNamespace
+	cfg.HTTP.BindFallback = listenerhttp.FallbackNone
+	cfg.HTTP.TextfileInterval = metricshttp.DefaultTextfileInterval
+
+	cfg.OCI.MonitoringBudget = defaultMonitoringBudget
+	cfg.OCI.IMDSBudget = defaultIMDSBudget
+	cfg.OCI.MetadataRefresh = metadata.DefaultRefreshInterval
+
+	cfg.Webhook.Timeout = webhook.DefaultTimeout
+
+	cfg.Suppress.File = defaultSuppressFile
+	cfg.Suppress.FileDuration = defaultSuppressFileDuration
+	cfg.Suppress.MaintenanceInterval = suppress.DefaultMaintenanceInterval
+	cfg.Suppress.MaintenanceLead = suppress.DefaultMaintenanceLead
+	cfg.Suppress.MaintenanceGrace = suppress.DefaultMaintenanceGrace
+
+	cfg.Hooks.PreApply.Timeout = hooks.DefaultTimeout
+	cfg.Hooks.PostApply.Timeout = hooks.DefaultTimeout
+
+	cfg.Update.Interval = update.DefaultInterval
+	cfg.Update.Repository = update.DefaultRepository
+
+	cfg.Canary.StateFile = canary.DefaultStateFile
+
+	cfg.Log.Backend = logBackendZap
+
+	cfg.Audit.Records = audit.DefaultRecords
 
 	return cfg
 }
@@ -169,16 +796,333 @@ func loadConfig(path string) (runtimeConfig, error) {
 		}
 	}
 
-	applyEnvOverrides(&cfg)
+	err := applyEnvOverrides(&cfg)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
 
-	err := adapt.ValidateConfig(runtimeToAdaptControllerConfig(cfg))
+	err = adapt.ValidateConfig(runtimeToAdaptControllerConfig(cfg))
 	if err != nil {
 		return runtimeConfig{}, fmt.Errorf("validate controller config: %w", err)
 	}
 
+	err = validateHTTPConfig(cfg.HTTP)
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("validate http config: %w", err)
+	}
+
+	cfg.Pool.StartFailurePolicy, err = shape.ParseStartFailurePolicy(
+		string(cfg.Pool.StartFailurePolicy),
+	)
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("%w: %w", errInvalidStartFailurePolicy, err)
+	}
+
+	if cfg.Pool.RestartAfterMissedQuanta < 0 {
+		return runtimeConfig{}, fmt.Errorf(
+			"pool.restartAfterMissedQuanta: %w, got %d",
+			shape.ErrInvalidMissedQuanta,
+			cfg.Pool.RestartAfterMissedQuanta,
+		)
+	}
+
+	if strings.TrimSpace(cfg.History.KeyFile) != "" &&
+		strings.TrimSpace(cfg.History.VaultSecretID) != "" {
+		return runtimeConfig{}, errHistoryKeyConflict
+	}
+
+	err = validateAdminConfig(cfg.Admin)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	err = validateAlarmConfig(cfg.Alarm)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	err = validateSuppressConfig(cfg.Suppress)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	err = validateLogConfig(cfg.Log)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	err = validateHealthConfig(cfg.Health)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	err = oci.ValidateQueryScope(cfg.OCI.queryScope())
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("validate oci query scope: %w", err)
+	}
+
+	err = audit.ValidateRecords(cfg.Audit.Records)
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("audit.records: %w", err)
+	}
+
+	err = cfg.Memory.trimConfig().Validate()
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("memory: %w", err)
+	}
+
+	err = validateBuildProfile(cfg, minimalBuild)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
 	return cfg, nil
 }
 
+// validateBuildProfile rejects the settings of the subsystems a minimal build
+// leaves out, so such a binary refuses a configuration it cannot honour
+// instead of silently ignoring part of it.
+func validateBuildProfile(cfg runtimeConfig, minimal bool) error {
+	if !minimal {
+		return nil
+	}
+
+	var excluded []string
+
+	if cfg.Admin.authEnabled() {
+		excluded = append(excluded, "admin.dynamicGroupId/admin.matchingRule")
+	}
+
+	if strings.TrimSpace(cfg.Admin.SnapshotDir) != "" {
+		excluded = append(excluded, "admin.snapshotDir")
+	}
+
+	if cfg.Admin.AllowRemote {
+		excluded = append(excluded, "admin.allowRemote")
+	}
+
+	if strings.TrimSpace(cfg.Webhook.URL) != "" {
+		excluded = append(excluded, "webhook.url")
+	}
+
+	if cfg.Update.Check {
+		excluded = append(excluded, "update.check")
+	}
+
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errMinimalBuild, strings.Join(excluded, ", "))
+}
+
+func validateAdminConfig(cfg adminConfig) error {
+	if !cfg.authEnabled() {
+		return nil
+	}
+
+	if strings.TrimSpace(cfg.DynamicGroupID) != "" && strings.TrimSpace(cfg.MatchingRule) != "" {
+		return fmt.Errorf(
+			"%w: admin.dynamicGroupId and admin.matchingRule are mutually exclusive",
+			errInvalidAdminAuth,
+		)
+	}
+
+	if strings.TrimSpace(cfg.IssuerKeysURL) == "" {
+		return fmt.Errorf("%w: admin.issuerKeysUrl is required", errInvalidAdminAuth)
+	}
+
+	_, err := oci.ValidateIssuerKeysURL(cfg.IssuerKeysURL)
+	if err != nil {
+		return fmt.Errorf("%w: admin.issuerKeysUrl: %w", errInvalidAdminAuth, err)
+	}
+
+	if strings.TrimSpace(cfg.TenancyID) == "" {
+		return fmt.Errorf("%w: admin.tenancyId is required", errInvalidAdminAuth)
+	}
+
+	if strings.TrimSpace(cfg.MatchingRule) != "" {
+		_, err = oci.ParseMatchingRule(cfg.MatchingRule)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidAdminAuth, err)
+		}
+	}
+
+	return nil
+}
+
+func validateAlarmConfig(cfg alarmConfig) error {
+	if cfg.WatchInterval < 0 {
+		return fmt.Errorf("%w: alarm.watchInterval must not be negative", errInvalidAlarm)
+	}
+
+	if cfg.SilencedTargetMin < 0 || cfg.SilencedTargetMin > 1 {
+		return fmt.Errorf(
+			"%w: alarm.silencedTargetMin %v must be within [0, 1]",
+			errInvalidAlarm,
+			cfg.SilencedTargetMin,
+		)
+	}
+
+	return nil
+}
+
+func validateSuppressConfig(cfg suppressConfig) error {
+	for _, setting := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"maintenanceInterval", cfg.MaintenanceInterval},
+		{"maintenanceLead", cfg.MaintenanceLead},
+		{"maintenanceGrace", cfg.MaintenanceGrace},
+	} {
+		if setting.value < 0 {
+			return fmt.Errorf(
+				"%w: suppression.%s must not be negative",
+				errInvalidSuppress,
+				setting.name,
+			)
+		}
+	}
+
+	return nil
+}
+
+func validateLogConfig(cfg logConfig) error {
+	switch cfg.Backend {
+	case logBackendZap, logBackendSlog:
+		return nil
+	default:
+		return fmt.Errorf("%w %q", errInvalidLogBackend, cfg.Backend)
+	}
+}
+
+func validateHealthConfig(cfg healthConfig) error {
+	for _, name := range cfg.Disable {
+		err := health.ValidateComponent(name)
+		if err != nil {
+			return fmt.Errorf("health.disable: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateHTTPConfig(cfg httpConfig) error {
+	err := metricshttp.ValidateNamespace(cfg.MetricsNamespace)
+	if err != nil {
+		return fmt.Errorf("http.metricsNamespace: %w", err)
+	}
+
+	err = metricshttp.ValidateStaticLabels(cfg.MetricsLabels)
+	if err != nil {
+		return fmt.Errorf("http.metricsLabels: %w", err)
+	}
+
+	err = listenerhttp.ValidateFallback(cfg.BindFallback)
+	if err != nil {
+		return fmt.Errorf("http.bindFallback: %w", err)
+	}
+
+	err = listenerhttp.ValidateSample(cfg.AccessLogSample)
+	if err != nil {
+		return fmt.Errorf("http.accessLogSample: %w", err)
+	}
+
+	for index, entry := range cfg.Listeners {
+		err = validateListenerConfig(index, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch cfg.Network {
+	case httpNetworkDual, httpNetworkTCP4, httpNetworkTCP6:
+		return nil
+	default:
+		return fmt.Errorf(
+			"%w: %q (supported: %s, %s, %s)",
+			errInvalidHTTPNetwork,
+			cfg.Network,
+			httpNetworkDual,
+			httpNetworkTCP4,
+			httpNetworkTCP6,
+		)
+	}
+}
+
+// validateListenerConfig rejects listener entries whose TLS or credential
+// settings are incomplete or contradictory.
+func validateListenerConfig(index int, cfg listenerConfig) error {
+	var problem string
+
+	switch {
+	case cfg.Bind == "":
+		problem = "bind is required"
+	case (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == ""):
+		problem = "tls.certFile and tls.keyFile must be set together"
+	case cfg.TLS.ClientCAFile != "" && cfg.TLS.CertFile == "":
+		problem = "tls.clientCAFile requires tls.certFile"
+	case cfg.Auth.BearerTokenFile != "" && (cfg.Auth.Username != "" || cfg.Auth.PasswordFile != ""):
+		problem = "auth.bearerTokenFile and auth.username are mutually exclusive"
+	case (cfg.Auth.Username == "") != (cfg.Auth.PasswordFile == ""):
+		problem = "auth.username and auth.passwordFile must be set together"
+	default:
+		return nil
+	}
+
+	return fmt.Errorf("%w: http.listeners[%d].%s", errInvalidHTTPListener, index, problem)
+}
+
+// listenNetwork maps the configured http.network onto the net.Listen network name.
+// "dual" uses "tcp", which binds both address families when the host supports it.
+func listenNetwork(network string) string {
+	if network == httpNetworkTCP4 || network == httpNetworkTCP6 {
+		return network
+	}
+
+	return "tcp"
+}
+
+// bindAddresses splits the comma-separated http.bind value into listener addresses.
+func bindAddresses(bind string) []string {
+	return splitList(bind)
+}
+
+// metricsListeners returns http.listeners, or plaintext listeners without
+// authentication on every http.bind address when the list is empty.
+func metricsListeners(cfg httpConfig) []listenerConfig {
+	if len(cfg.Listeners) > 0 {
+		return cfg.Listeners
+	}
+
+	addrs := bindAddresses(cfg.Bind)
+	listeners := make([]listenerConfig, 0, len(addrs))
+
+	for _, addr := range addrs {
+		listeners = append(listeners, listenerConfig{
+			Bind: addr,
+			TLS:  listenerhttp.TLS{CertFile: "", KeyFile: "", ClientCAFile: ""},
+			Auth: listenerAuthConfig{BearerTokenFile: "", Username: "", PasswordFile: ""},
+		})
+	}
+
+	return listeners
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0, 1)
+
+	for part := range strings.SplitSeq(value, ",") {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+
+	return items
+}
+
 func mergeControllerConfig(dst *controllerConfig, src controllerFileConfig) {
 	assignFloat(&dst.TargetStart, src.TargetStart)
 	assignFloat(&dst.TargetMin, src.TargetMin)
@@ -193,19 +1137,113 @@ func mergeControllerConfig(dst *controllerConfig, src controllerFileConfig) {
 	assignFloat(&dst.RelaxedThreshold, src.RelaxedThreshold)
 	assignFloat(&dst.SuppressThreshold, src.SuppressThreshold)
 	assignFloat(&dst.SuppressResume, src.SuppressResume)
+	assignInt(&dst.MaxChangesPerHour, src.MaxChangesPerHour)
+	assignFloat(&dst.OCPUSeconds.Start, src.OCPUSeconds.Start)
+	assignFloat(&dst.OCPUSeconds.Min, src.OCPUSeconds.Min)
+	assignFloat(&dst.OCPUSeconds.Max, src.OCPUSeconds.Max)
+	assignFloat(&dst.OCPUSeconds.Fallback, src.OCPUSeconds.Fallback)
+	assignString(&dst.Policy, src.Policy)
+	assignString(&dst.InitialState, src.InitialState)
+	assignFloat(&dst.PID.Proportional, src.PID.Proportional)
+	assignFloat(&dst.PID.Integral, src.PID.Integral)
+	assignFloat(&dst.PID.Derivative, src.PID.Derivative)
+	assignDuration(&dst.SuppressLearning.LearnFor, src.SuppressLearning.LearnFor)
+	assignFloat(&dst.SuppressLearning.Quantile, src.SuppressLearning.Quantile)
+	assignFloat(&dst.SuppressLearning.Headroom, src.SuppressLearning.Headroom)
+	assignFloat(&dst.SuppressLearning.ResumeGap, src.SuppressLearning.ResumeGap)
+	assignFloat(&dst.SuppressLearning.ThresholdMin, src.SuppressLearning.ThresholdMin)
+	assignFloat(&dst.SuppressLearning.ThresholdMax, src.SuppressLearning.ThresholdMax)
+	assignString(&dst.SuppressLearningFile, src.SuppressLearning.StateFile)
+
+	if src.Schedule != nil {
+		dst.Schedule = make([]adapt.ScheduleWindow, 0, len(src.Schedule))
+		for _, window := range src.Schedule {
+			dst.Schedule = append(dst.Schedule, adapt.ScheduleWindow{
+				Start:     time.Duration(window.Start),
+				End:       time.Duration(window.End),
+				TargetMax: window.TargetMax,
+				Location:  windowLocation(window.TimeZone, src.TimeZone),
+			})
+		}
+	}
+
+	if src.Blackout != nil {
+		dst.Blackout = make([]adapt.BlackoutWindow, 0, len(src.Blackout))
+		for _, window := range src.Blackout {
+			dst.Blackout = append(dst.Blackout, adapt.BlackoutWindow{
+				Start:    time.Duration(window.Start),
+				End:      time.Duration(window.End),
+				Location: windowLocation(window.TimeZone, src.TimeZone),
+			})
+		}
+	}
+}
+
+// deriveEstimatorInterval picks the sampler cadence when none is configured:
+// one second while fast-loop suppression can trigger and otherwise one
+// idleEstimatorSamplesPerStep-th of the slow-loop interval, between one and
+// fifteen seconds. A suppressThreshold of 1 only fires on a saturated host, so
+// it counts as suppression being off.
+func deriveEstimatorInterval(controller controllerConfig) time.Duration {
+	if controller.SuppressThreshold < 1 {
+		return suppressingEstimatorInterval
+	}
+
+	interval := controller.Interval / idleEstimatorSamplesPerStep
+
+	return min(max(interval, suppressingEstimatorInterval), idleEstimatorIntervalMax)
 }
 
 func mergeEstimatorConfig(dst *estimatorConfig, src estimatorFileConfig) {
 	assignDuration(&dst.Interval, src.Interval)
+	assignInt(&dst.RestartAfter, src.RestartAfter)
+	assignInt(&dst.Buffer, src.Buffer)
 }
 
 func mergePoolConfig(dst *poolConfig, src poolFileConfig) {
 	assignInt(&dst.Workers, src.Workers)
 	assignDuration(&dst.Quantum, src.Quantum)
+	assignDuration(&dst.Calibration, src.Calibration)
+	assignInt(&dst.RestartAfterMissedQuanta, src.RestartAfterMissedQuanta)
+
+	if src.StartFailurePolicy != nil {
+		dst.StartFailurePolicy = shape.StartFailurePolicy(*src.StartFailurePolicy)
+	}
 }
 
 func mergeHTTPConfig(dst *httpConfig, src httpFileConfig) {
 	assignString(&dst.Bind, src.Bind)
+	assignString(&dst.Network, src.Network)
+	assignBool(&dst.RuntimeMetrics, src.RuntimeMetrics)
+	assignString(&dst.MetricsNamespace, src.MetricsNamespace)
+	assignString(&dst.BindFallback, src.BindFallback)
+	assignDuration(&dst.ScrapeSampleInterval, src.ScrapeSampleInterval)
+	assignString(&dst.TextfileDir, src.TextfileDir)
+	assignDuration(&dst.TextfileInterval, src.TextfileInterval)
+	assignFloat(&dst.AccessLogSample, src.AccessLogSample)
+
+	if src.MetricsLabels != nil {
+		dst.MetricsLabels = src.MetricsLabels
+	}
+
+	if src.Listeners != nil {
+		dst.Listeners = make([]listenerConfig, 0, len(src.Listeners))
+		for _, entry := range src.Listeners {
+			dst.Listeners = append(dst.Listeners, listenerConfig{
+				Bind: strings.TrimSpace(entry.Bind),
+				TLS: listenerhttp.TLS{
+					CertFile:     strings.TrimSpace(entry.TLS.CertFile),
+					KeyFile:      strings.TrimSpace(entry.TLS.KeyFile),
+					ClientCAFile: strings.TrimSpace(entry.TLS.ClientCAFile),
+				},
+				Auth: listenerAuthConfig{
+					BearerTokenFile: strings.TrimSpace(entry.Auth.BearerTokenFile),
+					Username:        entry.Auth.Username,
+					PasswordFile:    strings.TrimSpace(entry.Auth.PasswordFile),
+				},
+			})
+		}
+	}
 }
 
 func mergeOCIConfig(dst *ociConfig, src ociFileConfig) {
@@ -213,38 +1251,220 @@ func mergeOCIConfig(dst *ociConfig, src ociFileConfig) {
 	assignString(&dst.Region, src.Region)
 	assignString(&dst.InstanceID, src.InstanceID)
 	assignBool(&dst.Offline, src.Offline)
+	assignBool(&dst.DisplayName, src.DisplayName)
+	assignBool(&dst.PreferInstanceRegion, src.PreferInstanceRegion)
+	assignInt(&dst.MonitoringBudget, src.MonitoringBudget)
+	assignInt(&dst.IMDSBudget, src.IMDSBudget)
+	assignDuration(&dst.MetadataRefresh, src.MetadataRefresh)
+	assignDuration(&dst.StatusMetadata, src.StatusMetadata)
+	assignString(&dst.ResourceGroup, src.ResourceGroup)
+
+	if src.QueryDimensions != nil {
+		dst.QueryDimensions = src.QueryDimensions
+	}
+}
+
+func mergeWebhookConfig(dst *webhookConfig, src webhookFileConfig) {
+	assignString(&dst.URL, src.URL)
+	assignDuration(&dst.Timeout, src.Timeout)
+}
+
+func mergeHistoryConfig(dst *historyConfig, src historyFileConfig) {
+	assignString(&dst.Path, src.Path)
+	assignString(&dst.KeyFile, src.KeyFile)
+	assignString(&dst.VaultSecretID, src.VaultSecretID)
 }
 
-func applyEnvOverrides(cfg *runtimeConfig) {
-	cfg.Controller.TargetStart = envFloat(envTargetStart, cfg.Controller.TargetStart)
-	cfg.Controller.TargetMin = envFloat(envTargetMin, cfg.Controller.TargetMin)
-	cfg.Controller.TargetMax = envFloat(envTargetMax, cfg.Controller.TargetMax)
-	cfg.Controller.StepUp = envFloat(envStepUp, cfg.Controller.StepUp)
-	cfg.Controller.StepDown = envFloat(envStepDown, cfg.Controller.StepDown)
-	cfg.Controller.FallbackTarget = envFloat(envFallbackTarget, cfg.Controller.FallbackTarget)
-	cfg.Controller.GoalLow = envFloat(envGoalLow, cfg.Controller.GoalLow)
-	cfg.Controller.GoalHigh = envFloat(envGoalHigh, cfg.Controller.GoalHigh)
-	cfg.Controller.RelaxedThreshold = envFloat(envRelaxedThreshold, cfg.Controller.RelaxedThreshold)
-	cfg.Controller.SuppressThreshold = envFloat(
+func mergeUpdateConfig(dst *updateConfig, src updateFileConfig) {
+	assignBool(&dst.Check, src.Check)
+	assignDuration(&dst.Interval, src.Interval)
+	assignString(&dst.Repository, src.Repository)
+}
+
+func mergeAdminConfig(dst *adminConfig, src adminFileConfig) {
+	assignString(&dst.DynamicGroupID, src.DynamicGroupID)
+	assignString(&dst.MatchingRule, src.MatchingRule)
+	assignString(&dst.IssuerKeysURL, src.IssuerKeysURL)
+	assignString(&dst.TenancyID, src.TenancyID)
+	assignString(&dst.SnapshotDir, src.SnapshotDir)
+	assignBool(&dst.AllowRemote, src.AllowRemote)
+}
+
+func mergeCanaryConfig(dst *canaryConfig, src canaryFileConfig) {
+	assignDuration(&dst.Observation, src.Observation)
+	assignString(&dst.StateFile, src.StateFile)
+}
+
+func mergeAlarmConfig(dst *alarmConfig, src alarmFileConfig) {
+	assignDuration(&dst.WatchInterval, src.WatchInterval)
+	assignFloat(&dst.SilencedTargetMin, src.SilencedTargetMin)
+}
+
+func mergeLogConfig(dst *logConfig, src logFileConfig) {
+	assignString(&dst.Backend, src.Backend)
+}
+
+func mergeHealthConfig(dst *healthConfig, src healthFileConfig) {
+	if src.Disable != nil {
+		dst.Disable = src.Disable
+	}
+}
+
+func mergeAuditConfig(dst *auditConfig, src auditFileConfig) {
+	assignString(&dst.Path, src.Path)
+	assignInt(&dst.Records, src.Records)
+}
+
+func mergeRunAsConfig(dst *runAsConfig, src runAsFileConfig) {
+	assignString(&dst.User, src.User)
+	assignString(&dst.Group, src.Group)
+}
+
+func mergeMemoryConfig(dst *memoryConfig, src memoryFileConfig) {
+	assignInt(&dst.LimitMiB, src.LimitMiB)
+	assignDuration(&dst.TrimInterval, src.TrimInterval)
+	assignInt(&dst.TrimAboveMiB, src.TrimAboveMiB)
+}
+
+func mergeSuppressConfig(dst *suppressConfig, src suppressFileConfig) {
+	assignString(&dst.File, src.File)
+	assignDuration(&dst.FileDuration, src.FileDuration)
+	assignDuration(&dst.MaintenanceInterval, src.MaintenanceInterval)
+	assignDuration(&dst.MaintenanceLead, src.MaintenanceLead)
+	assignDuration(&dst.MaintenanceGrace, src.MaintenanceGrace)
+}
+
+func mergeHookConfig(dst *hookConfig, src hookFileConfig) {
+	if src.Command != nil {
+		dst.Command = src.Command
+	}
+
+	assignDuration(&dst.Timeout, src.Timeout)
+}
+
+// applyEnvOverrides layers the environment overrides over cfg. Values that
+// do not parse keep the file or default value and are listed in
+// cfg.IgnoredEnv, unless SHAPER_STRICT_ENV turns them into an error.
+func applyEnvOverrides(cfg *runtimeConfig) error {
+	var env envReader
+
+	cfg.Controller.TargetStart = env.float(envTargetStart, cfg.Controller.TargetStart)
+	cfg.Controller.TargetMin = env.float(envTargetMin, cfg.Controller.TargetMin)
+	cfg.Controller.TargetMax = env.float(envTargetMax, cfg.Controller.TargetMax)
+	cfg.Controller.StepUp = env.float(envStepUp, cfg.Controller.StepUp)
+	cfg.Controller.StepDown = env.float(envStepDown, cfg.Controller.StepDown)
+	cfg.Controller.FallbackTarget = env.float(envFallbackTarget, cfg.Controller.FallbackTarget)
+	cfg.Controller.GoalLow = env.float(envGoalLow, cfg.Controller.GoalLow)
+	cfg.Controller.GoalHigh = env.float(envGoalHigh, cfg.Controller.GoalHigh)
+	cfg.Controller.RelaxedThreshold = env.float(
+		envRelaxedThreshold,
+		cfg.Controller.RelaxedThreshold,
+	)
+	cfg.Controller.SuppressThreshold = env.float(
 		envSuppressThreshold,
 		cfg.Controller.SuppressThreshold,
 	)
-	cfg.Controller.SuppressResume = envFloat(envSuppressResume, cfg.Controller.SuppressResume)
-	cfg.Controller.Interval = envDuration(envSlowInterval, cfg.Controller.Interval)
-	cfg.Controller.RelaxedInterval = envDuration(envRelaxedInterval, cfg.Controller.RelaxedInterval)
-	cfg.Estimator.Interval = envDuration(envFastInterval, cfg.Estimator.Interval)
-	cfg.Pool.Workers = envInt(envPoolWorkers, cfg.Pool.Workers)
+	cfg.Controller.SuppressResume = env.float(envSuppressResume, cfg.Controller.SuppressResume)
+	cfg.Controller.SuppressLearning.LearnFor = env.duration(
+		envSuppressLearnFor,
+		cfg.Controller.SuppressLearning.LearnFor,
+	)
+	cfg.Controller.SuppressLearningFile = envString(
+		envSuppressLearnFile,
+		cfg.Controller.SuppressLearningFile,
+	)
+	cfg.Controller.MaxChangesPerHour = env.int(
+		envMaxTargetChanges,
+		cfg.Controller.MaxChangesPerHour,
+	)
+	cfg.Controller.Interval = env.duration(envSlowInterval, cfg.Controller.Interval)
+	cfg.Controller.RelaxedInterval = env.duration(
+		envRelaxedInterval,
+		cfg.Controller.RelaxedInterval,
+	)
+	cfg.Controller.Policy = envString(envControllerPolicy, cfg.Controller.Policy)
+	cfg.Controller.InitialState = envString(envInitialState, cfg.Controller.InitialState)
+	cfg.Estimator.Interval = env.duration(envFastInterval, cfg.Estimator.Interval)
+	cfg.Estimator.RestartAfter = env.int(envEstimatorRestart, cfg.Estimator.RestartAfter)
+	cfg.Estimator.Buffer = env.int(envEstimatorBuffer, cfg.Estimator.Buffer)
+	cfg.Pool.Workers = env.int(envPoolWorkers, cfg.Pool.Workers)
+	cfg.Pool.StartFailurePolicy = shape.StartFailurePolicy(
+		envString(envPoolStartFailure, string(cfg.Pool.StartFailurePolicy)),
+	)
+	cfg.Pool.Calibration = env.duration(envPoolCalibration, cfg.Pool.Calibration)
+	cfg.Pool.RestartAfterMissedQuanta = env.int(
+		envPoolRestartMissed,
+		cfg.Pool.RestartAfterMissedQuanta,
+	)
 	cfg.HTTP.Bind = envString(envHTTPBind, cfg.HTTP.Bind)
+	cfg.HTTP.Network = envString(envHTTPNetwork, cfg.HTTP.Network)
+	cfg.HTTP.RuntimeMetrics = env.bool(envRuntimeMetrics, cfg.HTTP.RuntimeMetrics)
+	cfg.HTTP.MetricsNamespace = envString(envMetricsNamespace, cfg.HTTP.MetricsNamespace)
+	cfg.HTTP.MetricsLabels = env.dimensions(envMetricsLabels, cfg.HTTP.MetricsLabels)
+	cfg.HTTP.BindFallback = envString(envHTTPBindFallback, cfg.HTTP.BindFallback)
+	cfg.HTTP.ScrapeSampleInterval = env.duration(envScrapeSample, cfg.HTTP.ScrapeSampleInterval)
+	cfg.HTTP.AccessLogSample = env.float(envAccessLogSample, cfg.HTTP.AccessLogSample)
+	cfg.HTTP.TextfileDir = envString(envTextfileDir, cfg.HTTP.TextfileDir)
+	cfg.HTTP.TextfileInterval = env.duration(envTextfileInterval, cfg.HTTP.TextfileInterval)
 	cfg.OCI.CompartmentID = envString(envCompartmentID, cfg.OCI.CompartmentID)
 	cfg.OCI.Region = envString(envOCIRegion, cfg.OCI.Region)
 	cfg.OCI.InstanceID = envString(envInstanceID, cfg.OCI.InstanceID)
-	cfg.OCI.Offline = envBool(envOCIOffline, cfg.OCI.Offline)
+	cfg.OCI.Offline = env.bool(envOCIOffline, cfg.OCI.Offline)
+	cfg.OCI.DisplayName = env.bool(envOCIDisplayName, cfg.OCI.DisplayName)
+	cfg.OCI.PreferInstanceRegion = env.bool(envOCIPreferRegion, cfg.OCI.PreferInstanceRegion)
+	cfg.OCI.MonitoringBudget = env.int(envMonitoringBudget, cfg.OCI.MonitoringBudget)
+	cfg.OCI.IMDSBudget = env.int(envIMDSBudget, cfg.OCI.IMDSBudget)
+	cfg.OCI.MetadataRefresh = env.duration(envMetadataRefresh, cfg.OCI.MetadataRefresh)
+	cfg.OCI.StatusMetadata = env.duration(envStatusMetadata, cfg.OCI.StatusMetadata)
+	cfg.OCI.ResourceGroup = envString(envResourceGroup, cfg.OCI.ResourceGroup)
+	cfg.OCI.QueryDimensions = env.dimensions(envQueryDimensions, cfg.OCI.QueryDimensions)
+	cfg.Webhook.URL = envString(envWebhookURL, cfg.Webhook.URL)
+	cfg.Webhook.Timeout = env.duration(envWebhookTimeout, cfg.Webhook.Timeout)
+	cfg.History.Path = envString(envHistoryPath, cfg.History.Path)
+	cfg.History.KeyFile = envString(envHistoryKeyFile, cfg.History.KeyFile)
+	cfg.History.VaultSecretID = envString(envHistoryVaultKey, cfg.History.VaultSecretID)
+	cfg.Update.Check = env.bool(envUpdateCheck, cfg.Update.Check)
+	cfg.Update.Interval = env.duration(envUpdateInterval, cfg.Update.Interval)
+	cfg.Admin.DynamicGroupID = envString(envAdminGroup, cfg.Admin.DynamicGroupID)
+	cfg.Admin.MatchingRule = envString(envAdminRule, cfg.Admin.MatchingRule)
+	cfg.Admin.IssuerKeysURL = envString(envAdminIssuerKeys, cfg.Admin.IssuerKeysURL)
+	cfg.Admin.TenancyID = envString(envAdminTenancy, cfg.Admin.TenancyID)
+	cfg.Admin.SnapshotDir = envString(envAdminSnapshotDir, cfg.Admin.SnapshotDir)
+	cfg.Admin.AllowRemote = env.bool(envAdminAllowRemote, cfg.Admin.AllowRemote)
+	cfg.Canary.Observation = env.duration(envCanaryObservation, cfg.Canary.Observation)
+	cfg.Canary.StateFile = envString(envCanaryStateFile, cfg.Canary.StateFile)
+	cfg.Alarm.WatchInterval = env.duration(envAlarmWatch, cfg.Alarm.WatchInterval)
+	cfg.Alarm.SilencedTargetMin = env.float(envAlarmSilencedMin, cfg.Alarm.SilencedTargetMin)
+	cfg.Log.Backend = envString(envLogBackend, cfg.Log.Backend)
+
+	if disable := envString(envHealthDisable, ""); disable != "" {
+		cfg.Health.Disable = splitList(disable)
+	}
+
+	cfg.Audit.Path = envString(envAuditPath, cfg.Audit.Path)
+	cfg.Audit.Records = env.int(envAuditRecords, cfg.Audit.Records)
+	cfg.RunAs.User = envString(envRunAsUser, cfg.RunAs.User)
+	cfg.RunAs.Group = envString(envRunAsGroup, cfg.RunAs.Group)
+	cfg.Memory.LimitMiB = env.int(envMemoryLimit, cfg.Memory.LimitMiB)
+	cfg.Memory.TrimInterval = env.duration(envMemoryTrim, cfg.Memory.TrimInterval)
+	cfg.Memory.TrimAboveMiB = env.int(envMemoryTrimAbove, cfg.Memory.TrimAboveMiB)
+	cfg.Suppress.File = envString(envSuppressFile, cfg.Suppress.File)
+	cfg.Suppress.FileDuration = env.duration(envSuppressFileTTL, cfg.Suppress.FileDuration)
+	cfg.Suppress.MaintenanceInterval = env.duration(
+		envMaintenanceWatch,
+		cfg.Suppress.MaintenanceInterval,
+	)
+	cfg.Suppress.MaintenanceLead = env.duration(envMaintenanceLead, cfg.Suppress.MaintenanceLead)
+	cfg.Suppress.MaintenanceGrace = env.duration(envMaintenanceGrace, cfg.Suppress.MaintenanceGrace)
+	cfg.Hooks.PreApply.Command = envFields(envHookPre, cfg.Hooks.PreApply.Command)
+	cfg.Hooks.PreApply.Timeout = env.duration(envHookPreTimeout, cfg.Hooks.PreApply.Timeout)
+	cfg.Hooks.PostApply.Command = envFields(envHookPost, cfg.Hooks.PostApply.Command)
+	cfg.Hooks.PostApply.Timeout = env.duration(envHookPostTimeout, cfg.Hooks.PostApply.Timeout)
 
 	defaults := adapt.DefaultConfig()
 
-	if cfg.Pool.Workers <= 0 {
-		cfg.Pool.Workers = 1
-	}
+	// Zero leaves the worker count to the shape; see poolWorkers.
+	cfg.Pool.Workers = max(cfg.Pool.Workers, 0)
 
 	if cfg.Pool.Quantum <= 0 {
 		cfg.Pool.Quantum = shape.DefaultQuantum
@@ -259,26 +1479,198 @@ func applyEnvOverrides(cfg *runtimeConfig) {
 	}
 
 	if cfg.Estimator.Interval <= 0 {
-		cfg.Estimator.Interval = time.Second
+		cfg.Estimator.Interval = deriveEstimatorInterval(cfg.Controller)
+	}
+
+	if cfg.Estimator.Buffer <= 0 {
+		cfg.Estimator.Buffer = est.DefaultBuffer
+	}
+
+	if cfg.Webhook.Timeout <= 0 {
+		cfg.Webhook.Timeout = webhook.DefaultTimeout
+	}
+
+	if cfg.Update.Interval <= 0 {
+		cfg.Update.Interval = update.DefaultInterval
+	}
+
+	cfg.HTTP.Network = strings.ToLower(strings.TrimSpace(cfg.HTTP.Network))
+	if cfg.HTTP.Network == "" {
+		cfg.HTTP.Network = httpNetworkDual
 	}
+
+	cfg.HTTP.MetricsNamespace = strings.TrimSpace(cfg.HTTP.MetricsNamespace)
+	if cfg.HTTP.MetricsNamespace == "" {
+		cfg.HTTP.MetricsNamespace = metricshttp.DefaultNamespace
+	}
+
+	cfg.HTTP.BindFallback = strings.ToLower(strings.TrimSpace(cfg.HTTP.BindFallback))
+	if cfg.HTTP.BindFallback == "" {
+		cfg.HTTP.BindFallback = listenerhttp.FallbackNone
+	}
+
+	cfg.HTTP.ScrapeSampleInterval = max(cfg.HTTP.ScrapeSampleInterval, 0)
+
+	if cfg.HTTP.TextfileInterval <= 0 {
+		cfg.HTTP.TextfileInterval = metricshttp.DefaultTextfileInterval
+	}
+
+	cfg.Log.Backend = strings.ToLower(strings.TrimSpace(cfg.Log.Backend))
+	if cfg.Log.Backend == "" {
+		cfg.Log.Backend = logBackendZap
+	}
+
+	for index, name := range cfg.Health.Disable {
+		cfg.Health.Disable[index] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	return env.finish(cfg)
 }
 
 var lookupEnv = os.LookupEnv //nolint:gochecknoglobals // overridden in tests
 
-func parseFloatDefault(value string, fallback float64) float64 {
+// envReader reads typed environment overrides, recording the ones whose
+// values do not parse instead of failing on the first.
+type envReader struct {
+	invalid []string
+}
+
+// value returns the trimmed value of key, reporting false when it is unset
+// or blank.
+func (r *envReader) value(key string) (string, bool) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return "", false
+	}
+
 	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
+
+	return trimmed, trimmed != ""
+}
+
+func (r *envReader) reject(key string, err error) {
+	r.invalid = append(r.invalid, fmt.Sprintf("%s: %v", key, err))
+}
+
+func (r *envReader) float(key string, fallback float64) float64 {
+	value, ok := r.value(key)
+	if !ok {
 		return fallback
 	}
 
-	parsed, err := strconv.ParseFloat(trimmed, 64)
+	parsed, err := strconv.ParseFloat(value, 64)
 	if err != nil {
+		r.reject(key, err)
+
 		return fallback
 	}
 
 	return parsed
 }
 
+func (r *envReader) duration(key string, fallback time.Duration) time.Duration {
+	value, ok := r.value(key)
+	if !ok {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		r.reject(key, err)
+
+		return fallback
+	}
+
+	return duration
+}
+
+// int accepts zero, which the file configuration uses to disable a setting or
+// derive it, and rejects negative values.
+func (r *envReader) int(key string, fallback int) int {
+	value, ok := r.value(key)
+	if !ok {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.reject(key, err)
+
+		return fallback
+	}
+
+	if parsed < 0 {
+		r.reject(key, fmt.Errorf("%w, got %d", errEnvNegative, parsed))
+
+		return fallback
+	}
+
+	return parsed
+}
+
+func (r *envReader) bool(key string, fallback bool) bool {
+	value, ok := r.value(key)
+	if !ok {
+		return fallback
+	}
+
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "yes", "y":
+		return true
+	case "0", "f", "false", "no", "n":
+		return false
+	default:
+		r.reject(key, fmt.Errorf("%w, got %q", errEnvNotBool, value))
+
+		return fallback
+	}
+}
+
+// dimensions parses comma-separated name=value pairs, such as
+// availabilityDomain=AD-1,faultDomain=FAULT-DOMAIN-2.
+func (r *envReader) dimensions(key string, fallback map[string]string) map[string]string {
+	value, ok := r.value(key)
+	if !ok {
+		return fallback
+	}
+
+	dimensions := make(map[string]string)
+
+	for _, pair := range splitList(value) {
+		name, dimension, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+
+		if !found || name == "" {
+			r.reject(key, fmt.Errorf("%w, got %q", errEnvNotDimensions, pair))
+
+			return fallback
+		}
+
+		dimensions[name] = strings.TrimSpace(dimension)
+	}
+
+	return dimensions
+}
+
+// finish fails with every rejected override when SHAPER_STRICT_ENV is set, or
+// when its own value does not parse, and otherwise records them on cfg.
+func (r *envReader) finish(cfg *runtimeConfig) error {
+	rejected := len(r.invalid)
+	strict := r.bool(envStrictEnv, false) || len(r.invalid) > rejected
+
+	if len(r.invalid) == 0 {
+		return nil
+	}
+
+	if strict {
+		return fmt.Errorf("%w: %s", errInvalidEnv, strings.Join(r.invalid, "; "))
+	}
+
+	cfg.IgnoredEnv = r.invalid
+
+	return nil
+}
+
 func assignFloat(target *float64, value *float64) {
 	if value != nil {
 		*target = *value
@@ -309,51 +1701,61 @@ func assignBool(target *bool, value *bool) {
 	}
 }
 
-func envFloat(key string, fallback float64) float64 {
-	value, ok := lookupEnv(key)
-	if !ok {
-		return fallback
+func (o ociConfig) queryScope() oci.QueryScope {
+	return oci.QueryScope{
+		ResourceGroup: o.ResourceGroup,
+		Dimensions:    o.QueryDimensions,
 	}
-
-	return parseFloatDefault(value, fallback)
 }
 
-func envDuration(key string, fallback time.Duration) time.Duration {
-	value, ok := lookupEnv(key)
-	if !ok {
-		return fallback
-	}
+func (o ocpuSecondsConfig) enabled() bool {
+	return o.Start > 0 || o.Min > 0 || o.Max > 0 || o.Fallback > 0
+}
 
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return fallback
+// apply converts the configured OCPU-seconds per hour into host utilisation
+// ratios for a shape with ocpus OCPUs and overrides the matching targets. It
+// runs once at startup; the ratios are not recomputed when the shape is
+// resized, so a resize needs a restart to keep the intended absolute load.
+func (o ocpuSecondsConfig) apply(dst *controllerConfig, ocpus float64) error {
+	if ocpus <= 0 {
+		return fmt.Errorf("%w: %v", errInvalidOCPUCount, ocpus)
 	}
 
-	duration, err := time.ParseDuration(trimmed)
-	if err != nil {
-		return fallback
+	capacity := ocpus * secondsPerHour
+
+	for _, pair := range []struct {
+		seconds float64
+		target  *float64
+	}{
+		{o.Start, &dst.TargetStart},
+		{o.Min, &dst.TargetMin},
+		{o.Max, &dst.TargetMax},
+		{o.Fallback, &dst.FallbackTarget},
+	} {
+		if pair.seconds > 0 {
+			*pair.target = pair.seconds / capacity
+		}
 	}
 
-	return duration
+	return nil
 }
 
-func envInt(key string, fallback int) int {
-	value, ok := lookupEnv(key)
-	if !ok {
-		return fallback
-	}
+func (h hookConfig) enabled() bool {
+	return h.hook().Enabled()
+}
 
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return fallback
-	}
+func (h hookConfig) hook() hooks.Hook {
+	return hooks.Hook{Command: h.Command, Timeout: h.Timeout}
+}
 
-	parsed, err := strconv.Atoi(trimmed)
-	if err != nil || parsed <= 0 {
+// envFields splits the variable on whitespace into a command and its arguments.
+func envFields(key string, fallback []string) []string {
+	value := envString(key, "")
+	if value == "" {
 		return fallback
 	}
 
-	return parsed
+	return strings.Fields(value)
 }
 
 func envString(key, fallback string) string {
@@ -370,23 +1772,6 @@ func envString(key, fallback string) string {
 	return trimmed
 }
 
-func envBool(key string, fallback bool) bool {
-	value, ok := lookupEnv(key)
-	if !ok {
-		return fallback
-	}
-
-	trimmed := strings.TrimSpace(strings.ToLower(value))
-	switch trimmed {
-	case "1", "t", "true", "yes", "y":
-		return true
-	case "0", "f", "false", "no", "n":
-		return false
-	default:
-		return fallback
-	}
-}
-
 func runtimeToAdaptControllerConfig(cfg runtimeConfig) adapt.Config {
 	return adapt.Config{
 		ResourceID:        "",
@@ -404,6 +1789,13 @@ func runtimeToAdaptControllerConfig(cfg runtimeConfig) adapt.Config {
 		RelaxedThreshold:  cfg.Controller.RelaxedThreshold,
 		SuppressThreshold: cfg.Controller.SuppressThreshold,
 		SuppressResume:    cfg.Controller.SuppressResume,
+		MaxChangesPerHour: cfg.Controller.MaxChangesPerHour,
+		Policy:            cfg.Controller.Policy,
+		InitialState:      cfg.Controller.InitialState,
+		PID:               cfg.Controller.PID,
+		Schedule:          cfg.Controller.Schedule,
+		Blackout:          cfg.Controller.Blackout,
+		SuppressLearning:  cfg.Controller.SuppressLearning,
 	}
 }
 
@@ -429,6 +1821,20 @@ func mergeRuntimeConfigFile(cfg *runtimeConfig, path string) error {
 	mergePoolConfig(&cfg.Pool, fileCfg.Pool)
 	mergeHTTPConfig(&cfg.HTTP, fileCfg.HTTP)
 	mergeOCIConfig(&cfg.OCI, fileCfg.OCI)
+	mergeWebhookConfig(&cfg.Webhook, fileCfg.Webhook)
+	mergeHistoryConfig(&cfg.History, fileCfg.History)
+	mergeSuppressConfig(&cfg.Suppress, fileCfg.Suppress)
+	mergeHookConfig(&cfg.Hooks.PreApply, fileCfg.Hooks.PreApply)
+	mergeHookConfig(&cfg.Hooks.PostApply, fileCfg.Hooks.PostApply)
+	mergeUpdateConfig(&cfg.Update, fileCfg.Update)
+	mergeAdminConfig(&cfg.Admin, fileCfg.Admin)
+	mergeCanaryConfig(&cfg.Canary, fileCfg.Canary)
+	mergeAlarmConfig(&cfg.Alarm, fileCfg.Alarm)
+	mergeLogConfig(&cfg.Log, fileCfg.Log)
+	mergeHealthConfig(&cfg.Health, fileCfg.Health)
+	mergeAuditConfig(&cfg.Audit, fileCfg.Audit)
+	mergeRunAsConfig(&cfg.RunAs, fileCfg.RunAs)
+	mergeMemoryConfig(&cfg.Memory, fileCfg.Memory)
 
 	return nil
 }
@@ -6,12 +6,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
+	listenerhttp "oci-cpu-shaper/pkg/http/listener"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/oci"
 	"oci-cpu-shaper/pkg/shape"
+	"oci-cpu-shaper/pkg/suppress"
 )
 
 const (
@@ -39,6 +48,24 @@ func TestLoadConfigDefaultsWhenFileMissing(t *testing.T) {
 		t.Fatalf("unexpected estimator interval: %v", cfg.Estimator.Interval)
 	}
 
+	assertIntEqual(t, "restartAfter", cfg.Estimator.RestartAfter, est.DefaultRestartThreshold)
+	assertIntEqual(t, "estimatorBuffer", cfg.Estimator.Buffer, est.DefaultBuffer)
+	assertStringEqual(
+		t,
+		"metricsNamespace",
+		cfg.HTTP.MetricsNamespace,
+		metricshttp.DefaultNamespace,
+	)
+	assertStringEqual(t, "bindFallback", cfg.HTTP.BindFallback, listenerhttp.FallbackNone)
+	assertDurationEqual(t, "scrapeSampleInterval", cfg.HTTP.ScrapeSampleInterval, 0)
+	assertStringEqual(t, "textfileDir", cfg.HTTP.TextfileDir, "")
+	assertDurationEqual(
+		t,
+		"textfileInterval",
+		cfg.HTTP.TextfileInterval,
+		metricshttp.DefaultTextfileInterval,
+	)
+
 	if cfg.OCI.Offline {
 		t.Fatal("expected offline mode to default to false")
 	}
@@ -106,6 +133,10 @@ func TestLoadConfigSamplesMatchDefaults(t *testing.T) {
 func TestLoadConfigAppliesFileOverrides(t *testing.T) {
 	t.Parallel()
 
+	if minimalBuild {
+		t.Skip("testdata/config.yaml sets webhook.url, which minimal builds reject")
+	}
+
 	path := filepath.Join("testdata", "config.yaml")
 
 	cfg, err := loadConfig(path)
@@ -129,6 +160,12 @@ func TestLoadConfigAppliesFileOverrides(t *testing.T) {
 		t.Fatalf("expected http bind override, got %q", cfg.HTTP.Bind)
 	}
 
+	assertBoolEqual(t, "runtimeMetrics", cfg.HTTP.RuntimeMetrics, true)
+	assertStringEqual(t, "bindFallback", cfg.HTTP.BindFallback, listenerhttp.FallbackEphemeral)
+	assertDurationEqual(t, "scrapeSampleInterval", cfg.HTTP.ScrapeSampleInterval, 5*time.Second)
+	assertStringEqual(t, "textfileDir", cfg.HTTP.TextfileDir, "/var/lib/node_exporter/textfile")
+	assertDurationEqual(t, "textfileInterval", cfg.HTTP.TextfileInterval, 30*time.Second)
+
 	expectedCompartment := "ocid1.compartment.oc1..exampleuniqueID"
 	if cfg.OCI.CompartmentID != expectedCompartment {
 		t.Fatalf("expected compartment id %q, got %q", expectedCompartment, cfg.OCI.CompartmentID)
@@ -146,9 +183,44 @@ func TestLoadConfigAppliesFileOverrides(t *testing.T) {
 
 	assertFloatEqual(t, "suppressThreshold", cfg.Controller.SuppressThreshold, 0.9)
 	assertFloatEqual(t, "suppressResume", cfg.Controller.SuppressResume, 0.6)
+	assertIntEqual(t, "restartAfter", cfg.Estimator.RestartAfter, 8)
+	assertIntEqual(t, "estimatorBuffer", cfg.Estimator.Buffer, 32)
+	assertIntEqual(t, "maxChangesPerHour", cfg.Controller.MaxChangesPerHour, 4)
+	assertFloatEqual(t, "ocpuSecondsStart", cfg.Controller.OCPUSeconds.Start, 1800)
+	assertBoolEqual(t, "resolveDisplayName", cfg.OCI.DisplayName, true)
+	assertIntEqual(t, "monitoringDailyBudget", cfg.OCI.MonitoringBudget, 720)
+	assertStringEqual(
+		t,
+		"startFailurePolicy",
+		string(cfg.Pool.StartFailurePolicy),
+		string(shape.StartFailureFallback),
+	)
+	assertIntEqual(t, "imdsDailyBudget", cfg.OCI.IMDSBudget, defaultIMDSBudget)
+	assertStringEqual(t, "webhookURL", cfg.Webhook.URL, "https://automation.example.com/shaper")
+	assertDurationEqual(t, "webhookTimeout", cfg.Webhook.Timeout, 3*time.Second)
+	assertStringEqual(
+		t,
+		"historyPath",
+		cfg.History.Path,
+		"/var/lib/oci-cpu-shaper/history.bin",
+	)
+	assertStringEqual(t, "suppressFile", cfg.Suppress.File, "/run/shaper/suppress")
+	assertDurationEqual(t, "suppressFileDuration", cfg.Suppress.FileDuration, 90*time.Minute)
+	assertStringEqual(
+		t,
+		"preApplyCommand",
+		strings.Join(cfg.Hooks.PreApply.Command, " "),
+		"/usr/local/bin/notify-agent --phase pre",
+	)
+	assertDurationEqual(t, "preApplyTimeout", cfg.Hooks.PreApply.Timeout, 4*time.Second)
+	assertBoolEqual(t, "postApplyEnabled", cfg.Hooks.PostApply.enabled(), false)
 }
 
 func TestLoadConfigAppliesEnvOverrides(t *testing.T) {
+	if minimalBuild {
+		t.Skip("sets webhook.url, which minimal builds reject")
+	}
+
 	t.Setenv(envTargetStart, "0.33")
 	t.Setenv(envTargetMin, "0.20")
 	t.Setenv(envStepUp, "0.05")
@@ -163,6 +235,26 @@ func TestLoadConfigAppliesEnvOverrides(t *testing.T) {
 	t.Setenv(envOCIOffline, "true")
 	t.Setenv(envSuppressThreshold, "0.88")
 	t.Setenv(envSuppressResume, "0.51")
+	t.Setenv(envRuntimeMetrics, "yes")
+	t.Setenv(envOCIDisplayName, "1")
+	t.Setenv(envWebhookURL, " http://127.0.0.1:8080/hook ")
+	t.Setenv(envWebhookTimeout, "750ms")
+	t.Setenv(envHistoryPath, "/tmp/history.bin")
+	t.Setenv(envMaxTargetChanges, "6")
+	t.Setenv(envEstimatorRestart, "12")
+	t.Setenv(envEstimatorBuffer, "-1")
+	t.Setenv(envHTTPNetwork, " TCP6 ")
+	t.Setenv(envMetricsNamespace, " acme ")
+	t.Setenv(envHTTPBindFallback, " Retry ")
+	t.Setenv(envScrapeSample, "-1s")
+	t.Setenv(envTextfileDir, " /run/textfile ")
+	t.Setenv(envTextfileInterval, "-1s")
+	t.Setenv(envSuppressFile, "/tmp/suppress")
+	t.Setenv(envSuppressFileTTL, "15m")
+	t.Setenv(envHookPost, " /usr/local/bin/nginx-workers  --sync ")
+	t.Setenv(envHookPostTimeout, "2s")
+	t.Setenv(envIMDSBudget, "500")
+	t.Setenv(envPoolStartFailure, " ABORT ")
 
 	cfg, err := loadConfig("")
 	if err != nil {
@@ -176,13 +268,48 @@ func TestLoadConfigAppliesEnvOverrides(t *testing.T) {
 	assertDurationEqual(t, "relaxedInterval", cfg.Controller.RelaxedInterval, 12*time.Hour)
 	assertFloatEqual(t, "suppressThreshold", cfg.Controller.SuppressThreshold, 0.88)
 	assertFloatEqual(t, "suppressResume", cfg.Controller.SuppressResume, 0.51)
+	assertIntEqual(t, "maxChangesPerHour", cfg.Controller.MaxChangesPerHour, 6)
 	assertDurationEqual(t, "estimatorInterval", cfg.Estimator.Interval, 250*time.Millisecond)
+	assertIntEqual(t, "restartAfter", cfg.Estimator.RestartAfter, 12)
+	assertIntEqual(t, "estimatorBuffer", cfg.Estimator.Buffer, est.DefaultBuffer)
 	assertIntEqual(t, "workers", cfg.Pool.Workers, 4)
 	assertStringEqual(t, "httpBind", cfg.HTTP.Bind, ":9300")
+	assertStringEqual(t, "httpNetwork", cfg.HTTP.Network, httpNetworkTCP6)
+	assertStringEqual(t, "metricsNamespace", cfg.HTTP.MetricsNamespace, "acme")
+	assertStringEqual(t, "bindFallback", cfg.HTTP.BindFallback, listenerhttp.FallbackRetry)
+	assertDurationEqual(t, "scrapeSampleInterval", cfg.HTTP.ScrapeSampleInterval, 0)
+	assertStringEqual(t, "textfileDir", cfg.HTTP.TextfileDir, "/run/textfile")
+	assertDurationEqual(
+		t,
+		"textfileInterval",
+		cfg.HTTP.TextfileInterval,
+		metricshttp.DefaultTextfileInterval,
+	)
 	assertStringEqual(t, "compartmentID", cfg.OCI.CompartmentID, testCompartmentOverride)
 	assertStringEqual(t, "region", cfg.OCI.Region, testRegionOverride)
 	assertStringEqual(t, "instanceID", cfg.OCI.InstanceID, "ocid1.instance.oc1..override")
 	assertBoolEqual(t, "offline", cfg.OCI.Offline, true)
+	assertBoolEqual(t, "runtimeMetrics", cfg.HTTP.RuntimeMetrics, true)
+	assertBoolEqual(t, "resolveDisplayName", cfg.OCI.DisplayName, true)
+	assertStringEqual(t, "webhookURL", cfg.Webhook.URL, "http://127.0.0.1:8080/hook")
+	assertDurationEqual(t, "webhookTimeout", cfg.Webhook.Timeout, 750*time.Millisecond)
+	assertStringEqual(t, "historyPath", cfg.History.Path, "/tmp/history.bin")
+	assertStringEqual(t, "suppressFile", cfg.Suppress.File, "/tmp/suppress")
+	assertDurationEqual(t, "suppressFileDuration", cfg.Suppress.FileDuration, 15*time.Minute)
+	assertStringEqual(
+		t,
+		"postApplyCommand",
+		strings.Join(cfg.Hooks.PostApply.Command, "|"),
+		"/usr/local/bin/nginx-workers|--sync",
+	)
+	assertDurationEqual(t, "postApplyTimeout", cfg.Hooks.PostApply.Timeout, 2*time.Second)
+	assertIntEqual(t, "imdsDailyBudget", cfg.OCI.IMDSBudget, 500)
+	assertStringEqual(
+		t,
+		"startFailurePolicy",
+		string(cfg.Pool.StartFailurePolicy),
+		string(shape.StartFailureAbort),
+	)
 }
 
 func TestLoadConfigRejectsTargetsExceedingSuppressThreshold(t *testing.T) {
@@ -203,6 +330,597 @@ func TestLoadConfigRejectsTargetsExceedingSuppressThreshold(t *testing.T) {
 	}
 }
 
+func TestOCPUSecondsConfigApply(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig().Controller
+	seconds := ocpuSecondsConfig{Start: 1440, Fallback: 1080}
+
+	err := seconds.apply(&cfg, 1.5)
+	if err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+
+	assertFloatEqual(t, "targetStart", cfg.TargetStart, 1440.0/5400.0)
+	assertFloatEqual(t, "fallbackTarget", cfg.FallbackTarget, 0.2)
+
+	err = seconds.apply(&cfg, 0)
+	if !errors.Is(err, errInvalidOCPUCount) {
+		t.Fatalf("expected errInvalidOCPUCount, got %v", err)
+	}
+}
+
+func TestLoadConfigRejectsUnknownStartFailurePolicy(t *testing.T) {
+	t.Setenv(envPoolStartFailure, "retry")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, errInvalidStartFailurePolicy) {
+		t.Fatalf("expected errInvalidStartFailurePolicy, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", code)
+	}
+}
+
+func TestLoadConfigReadsRestartAfterMissedQuanta(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertIntEqual(
+		t,
+		"restartAfterMissedQuanta",
+		cfg.Pool.RestartAfterMissedQuanta,
+		shape.DefaultRestartAfterMissedQuanta,
+	)
+
+	path := filepath.Join(t.TempDir(), "pool.yaml")
+
+	err = os.WriteFile(path, []byte("pool:\n  restartAfterMissedQuanta: 250\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertIntEqual(t, "restartAfterMissedQuanta", cfg.Pool.RestartAfterMissedQuanta, 250)
+
+	t.Setenv(envPoolRestartMissed, "40")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertIntEqual(t, "restartAfterMissedQuanta", cfg.Pool.RestartAfterMissedQuanta, 40)
+
+	err = os.WriteFile(path, []byte("pool:\n  restartAfterMissedQuanta: -1\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv(envPoolRestartMissed, "")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, shape.ErrInvalidMissedQuanta) {
+		t.Fatalf("expected ErrInvalidMissedQuanta, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", code)
+	}
+}
+
+func TestLoadConfigParsesAlarmWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarm.yaml")
+
+	manifest := "alarm:\n  watchInterval: 15m\n  silencedTargetMin: 0.3\n"
+
+	err := os.WriteFile(path, []byte(manifest), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.Alarm.WatchInterval != 15*time.Minute || cfg.Alarm.SilencedTargetMin != 0.3 {
+		t.Fatalf("unexpected alarm config %+v", cfg.Alarm)
+	}
+
+	t.Setenv(envAlarmSilencedMin, "1.5")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, errInvalidAlarm) || exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected errInvalidAlarm, got %v", err)
+	}
+}
+
+func TestLoadConfigSelectsLogBackend(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.Log.Backend != logBackendZap {
+		t.Fatalf("expected the zap backend by default, got %q", cfg.Log.Backend)
+	}
+
+	path := filepath.Join(t.TempDir(), "log.yaml")
+
+	err = os.WriteFile(path, []byte("log:\n  backend: \" SLOG \"\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.Log.Backend != logBackendSlog {
+		t.Fatalf("expected the slog backend, got %q", cfg.Log.Backend)
+	}
+
+	t.Setenv(envLogBackend, "logrus")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, errInvalidLogBackend) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected errInvalidLogBackend, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesHealthDisable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.yaml")
+
+	err := os.WriteFile(path, []byte("health:\n  disable: [\" OCI \", guards]\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if !slices.Equal(cfg.Health.Disable, []string{health.ComponentOCI, health.ComponentGuards}) {
+		t.Fatalf("unexpected disabled components %v", cfg.Health.Disable)
+	}
+
+	t.Setenv(envHealthDisable, "metrics, webhook")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, health.ErrUnknownComponent) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected ErrUnknownComponent, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesAudit(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.Audit.Path != "" || cfg.Audit.Records != audit.DefaultRecords {
+		t.Fatalf("unexpected audit defaults %+v", cfg.Audit)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.yaml")
+
+	err = os.WriteFile(path, []byte("audit:\n  path: /run/audit.ring\n  records: 64\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.Audit.Path != "/run/audit.ring" || cfg.Audit.Records != 64 {
+		t.Fatalf("unexpected audit config %+v", cfg.Audit)
+	}
+
+	t.Setenv(envAuditPath, "/var/tmp/audit.ring")
+	t.Setenv(envAuditRecords, "100000")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, audit.ErrInvalidRecords) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected ErrInvalidRecords, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesRunAs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run-as.yaml")
+
+	err := os.WriteFile(path, []byte("runAs:\n  user: shaper\n  group: shaper\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.RunAs.User != "shaper" || cfg.RunAs.Group != "shaper" {
+		t.Fatalf("unexpected runAs config %+v", cfg.RunAs)
+	}
+
+	t.Setenv(envRunAsUser, "65532")
+	t.Setenv(envRunAsGroup, "65533")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.RunAs.User != "65532" || cfg.RunAs.Group != "65533" {
+		t.Fatalf("expected the environment to override runAs, got %+v", cfg.RunAs)
+	}
+}
+
+func TestLoadConfigParsesMaintenanceWatch(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(
+		t,
+		"maintenanceInterval",
+		cfg.Suppress.MaintenanceInterval,
+		suppress.DefaultMaintenanceInterval,
+	)
+	assertDurationEqual(
+		t,
+		"maintenanceLead",
+		cfg.Suppress.MaintenanceLead,
+		suppress.DefaultMaintenanceLead,
+	)
+	assertDurationEqual(
+		t,
+		"maintenanceGrace",
+		cfg.Suppress.MaintenanceGrace,
+		suppress.DefaultMaintenanceGrace,
+	)
+
+	path := filepath.Join(t.TempDir(), "maintenance.yaml")
+
+	manifest := "suppression:\n  maintenanceInterval: 5m\n  maintenanceLead: 1h\n" +
+		"  maintenanceGrace: 2h\n"
+
+	err = os.WriteFile(path, []byte(manifest), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(t, "maintenanceInterval", cfg.Suppress.MaintenanceInterval, 5*time.Minute)
+	assertDurationEqual(t, "maintenanceLead", cfg.Suppress.MaintenanceLead, time.Hour)
+	assertDurationEqual(t, "maintenanceGrace", cfg.Suppress.MaintenanceGrace, 2*time.Hour)
+
+	t.Setenv(envMaintenanceWatch, "0s")
+	t.Setenv(envMaintenanceGrace, "45m")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(t, "maintenanceInterval", cfg.Suppress.MaintenanceInterval, 0)
+	assertDurationEqual(t, "maintenanceGrace", cfg.Suppress.MaintenanceGrace, 45*time.Minute)
+
+	t.Setenv(envMaintenanceLead, "-1m")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, errInvalidSuppress) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected errInvalidSuppress, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesQueryScope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scope.yaml")
+	manifest := "oci:\n  resourceGroup: fleet\n  queryDimensions:\n" +
+		"    availabilityDomain: AD-1\n    faultDomain: FAULT-DOMAIN-2\n"
+
+	err := os.WriteFile(path, []byte(manifest), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertStringEqual(t, "resourceGroup", cfg.OCI.ResourceGroup, "fleet")
+
+	if len(cfg.OCI.QueryDimensions) != 2 ||
+		cfg.OCI.QueryDimensions["faultDomain"] != "FAULT-DOMAIN-2" {
+		t.Fatalf("unexpected dimensions %v", cfg.OCI.QueryDimensions)
+	}
+
+	t.Setenv(envResourceGroup, "other")
+	t.Setenv(envQueryDimensions, "faultDomain = FAULT-DOMAIN-3")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertStringEqual(t, "resourceGroup", cfg.OCI.ResourceGroup, "other")
+
+	if len(cfg.OCI.QueryDimensions) != 1 ||
+		cfg.OCI.QueryDimensions["faultDomain"] != "FAULT-DOMAIN-3" {
+		t.Fatalf("expected the environment to replace the dimensions, got %v",
+			cfg.OCI.QueryDimensions)
+	}
+
+	t.Setenv(envQueryDimensions, "faultDomain")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if len(cfg.IgnoredEnv) != 1 || len(cfg.OCI.QueryDimensions) != 2 {
+		t.Fatalf("expected the malformed override to be ignored, got %v", cfg.IgnoredEnv)
+	}
+
+	t.Setenv(envQueryDimensions, "resourceId=ocid1.instance.other")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, oci.ErrInvalidQueryScope) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected ErrInvalidQueryScope, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesSuppressLearning(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(t, "learnFor", cfg.Controller.SuppressLearning.LearnFor, 0)
+	assertFloatEqual(t, "quantile", cfg.Controller.SuppressLearning.Quantile, 0.95)
+	assertStringEqual(t, "stateFile", cfg.Controller.SuppressLearningFile, defaultSuppressLearnFile)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "learning.yaml")
+	manifest := "controller:\n  suppressLearning:\n    learnFor: 72h\n    quantile: 0.9\n" +
+		"    headroom: 0.05\n    resumeGap: 0.1\n    thresholdMin: 0.55\n" +
+		"    thresholdMax: 0.9\n    stateFile: " + filepath.Join(dir, "state.json") + "\n"
+
+	err = os.WriteFile(path, []byte(manifest), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	learning := cfg.Controller.SuppressLearning
+	assertDurationEqual(t, "learnFor", learning.LearnFor, 72*time.Hour)
+	assertFloatEqual(t, "quantile", learning.Quantile, 0.9)
+	assertFloatEqual(t, "headroom", learning.Headroom, 0.05)
+	assertFloatEqual(t, "resumeGap", learning.ResumeGap, 0.1)
+	assertFloatEqual(t, "thresholdMin", learning.ThresholdMin, 0.55)
+	assertFloatEqual(t, "thresholdMax", learning.ThresholdMax, 0.9)
+	assertStringEqual(
+		t,
+		"stateFile",
+		cfg.Controller.SuppressLearningFile,
+		filepath.Join(dir, "state.json"),
+	)
+
+	t.Setenv(envSuppressLearnFor, "24h")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(t, "learnFor", cfg.Controller.SuppressLearning.LearnFor, 24*time.Hour)
+
+	t.Setenv(envSuppressLearnFor, "0s")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertDurationEqual(t, "learnFor", cfg.Controller.SuppressLearning.LearnFor, 0)
+
+	t.Setenv(envSuppressLearnFor, "1h")
+
+	err = os.WriteFile(path, []byte(manifest+"  targetMax: 0.5\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, adapt.ErrInvalidConfig) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected thresholdMin below targetMax to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesDecisionPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+
+	manifest := `controller:
+  policy: schedule
+  pid:
+    proportional: 0.4
+    derivative: 0.1
+  schedule:
+    - start: "09:00"
+      end: "17:30"
+      targetMax: 0.22
+`
+
+	writeErr := os.WriteFile(path, []byte(manifest), 0o600)
+	if writeErr != nil {
+		t.Fatalf("write temp file: %v", writeErr)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertStringEqual(t, "policy", cfg.Controller.Policy, adapt.PolicySchedule)
+	assertFloatEqual(t, "pidProportional", cfg.Controller.PID.Proportional, 0.4)
+	assertFloatEqual(t, "pidIntegral", cfg.Controller.PID.Integral, adaptDefault().PID.Integral)
+	assertFloatEqual(t, "pidDerivative", cfg.Controller.PID.Derivative, 0.1)
+
+	want := []adapt.ScheduleWindow{
+		{Start: 9 * time.Hour, End: 17*time.Hour + 30*time.Minute, TargetMax: 0.22},
+	}
+	if !reflect.DeepEqual(cfg.Controller.Schedule, want) {
+		t.Fatalf("unexpected schedule %+v", cfg.Controller.Schedule)
+	}
+
+	t.Setenv(envControllerPolicy, "pid")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertStringEqual(t, "policy override", cfg.Controller.Policy, adapt.PolicyPID)
+
+	writeErr = os.WriteFile(path, []byte("controller:\n  schedule:\n    - start: 9am\n"), 0o600)
+	if writeErr != nil {
+		t.Fatalf("write temp file: %v", writeErr)
+	}
+
+	_, err = loadConfig(path)
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse error exit code for %v, got %d", err, code)
+	}
+
+	t.Setenv(envControllerPolicy, "bang-bang")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, adapt.ErrInvalidConfig) {
+		t.Fatalf("expected adapt.ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestLoadConfigReadsInitialState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "initial.yaml")
+
+	writeErr := os.WriteFile(path, []byte("controller:\n  initialState: normal\n"), 0o600)
+	if writeErr != nil {
+		t.Fatalf("write temp file: %v", writeErr)
+	}
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertStringEqual(t, "default initialState", cfg.Controller.InitialState, "fallback")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertStringEqual(t, "initialState", cfg.Controller.InitialState, "normal")
+	assertStringEqual(
+		t,
+		"adapt initialState",
+		runtimeToAdaptControllerConfig(cfg).InitialState,
+		"normal",
+	)
+
+	t.Setenv(envInitialState, "warm")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, adapt.ErrInvalidConfig) {
+		t.Fatalf("expected adapt.ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesBlackoutWindowsAndTimeZones(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blackout.yaml")
+
+	manifest := `controller:
+  timezone: Asia/Tokyo
+  schedule:
+    - start: "09:00"
+      end: "17:30"
+      targetMax: 0.22
+      timezone: Europe/Berlin
+  blackout:
+    - start: "01:00"
+      end: "03:30"
+    - start: "23:00"
+      end: "00:30"
+      timezone: UTC
+`
+
+	writeErr := os.WriteFile(path, []byte(manifest), 0o600)
+	if writeErr != nil {
+		t.Fatalf("write temp file: %v", writeErr)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	if len(cfg.Controller.Schedule) != 1 || len(cfg.Controller.Blackout) != 2 {
+		t.Fatalf("unexpected windows: schedule %+v, blackout %+v",
+			cfg.Controller.Schedule, cfg.Controller.Blackout)
+	}
+
+	scheduleZone := cfg.Controller.Schedule[0].Location.String()
+	assertStringEqual(t, "schedule zone", scheduleZone, "Europe/Berlin")
+
+	first := cfg.Controller.Blackout[0]
+	assertDurationEqual(t, "blackout start", first.Start, time.Hour)
+	assertDurationEqual(t, "blackout end", first.End, 3*time.Hour+30*time.Minute)
+	assertStringEqual(t, "controller zone", first.Location.String(), "Asia/Tokyo")
+	assertStringEqual(t, "window zone", cfg.Controller.Blackout[1].Location.String(), "UTC")
+
+	for _, manifest := range []string{
+		"controller:\n  timezone: Mars/Olympus\n",
+		"controller:\n  blackout:\n    - start: \"01:00\"\n      end: \"01:00\"\n",
+	} {
+		writeErr = os.WriteFile(path, []byte(manifest), 0o600)
+		if writeErr != nil {
+			t.Fatalf("write temp file: %v", writeErr)
+		}
+
+		_, err = loadConfig(path)
+		if code := exitCodeForConfigError(err); code != exitCodeParseError {
+			t.Fatalf("expected parse error exit code for %v, got %d", err, code)
+		}
+	}
+}
+
 func TestLoadConfigRejectsTargetsExceedingSuppressResume(t *testing.T) {
 	t.Setenv(envSuppressResume, "0.10")
 
@@ -314,9 +1032,7 @@ func adaptDefault() adapt.Config {
 	return adapt.DefaultConfig()
 }
 
-func TestParseFloatDefault(t *testing.T) {
-	t.Parallel()
-
+func TestEnvReaderFloat(t *testing.T) {
 	testCases := []struct {
 		name     string
 		input    string
@@ -343,61 +1059,84 @@ func TestParseFloatDefault(t *testing.T) {
 		},
 	}
 
+	key := "OCI_CPU_SHAPER_TEST_FLOAT"
+
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			t.Parallel()
+			t.Setenv(key, testCase.input)
 
-			if got := parseFloatDefault(testCase.input, testCase.fallback); got != testCase.want {
-				t.Fatalf("parseFloatDefault(%q)=%v want %v", testCase.input, got, testCase.want)
+			var env envReader
+
+			if got := env.float(key, testCase.fallback); got != testCase.want {
+				t.Fatalf("float(%q)=%v want %v", testCase.input, got, testCase.want)
 			}
 		})
 	}
 }
 
 func TestEnvDurationFallbacks(t *testing.T) {
+	var env envReader
+
 	keyInvalid := "OCI_CPU_SHAPER_TEST_DURATION_INVALID"
 	t.Setenv(keyInvalid, "invalid")
 
-	if got := envDuration(keyInvalid, 3*time.Second); got != 3*time.Second {
+	if got := env.duration(keyInvalid, 3*time.Second); got != 3*time.Second {
 		t.Fatalf("expected invalid duration to use fallback, got %v", got)
 	}
 
 	keyBlank := "OCI_CPU_SHAPER_TEST_DURATION_BLANK"
 	t.Setenv(keyBlank, "   ")
 
-	if got := envDuration(keyBlank, 2*time.Second); got != 2*time.Second {
+	if got := env.duration(keyBlank, 2*time.Second); got != 2*time.Second {
 		t.Fatalf("expected blank duration to use fallback, got %v", got)
 	}
 
 	keyValid := "OCI_CPU_SHAPER_TEST_DURATION_VALID"
 	t.Setenv(keyValid, "150ms")
 
-	if got := envDuration(keyValid, time.Second); got != 150*time.Millisecond {
+	if got := env.duration(keyValid, time.Second); got != 150*time.Millisecond {
 		t.Fatalf("expected valid duration 150ms, got %v", got)
 	}
+
+	if len(env.invalid) != 1 || !strings.HasPrefix(env.invalid[0], keyInvalid+": ") {
+		t.Fatalf("expected only the invalid duration to be recorded, got %v", env.invalid)
+	}
 }
 
-func TestEnvIntRejectsNonPositive(t *testing.T) {
+func TestEnvIntRejectsNegative(t *testing.T) {
+	var env envReader
+
 	keyNegative := "OCI_CPU_SHAPER_TEST_INT_NEGATIVE"
 	t.Setenv(keyNegative, "-5")
 
-	if got := envInt(keyNegative, 7); got != 7 {
+	if got := env.int(keyNegative, 7); got != 7 {
 		t.Fatalf("expected negative int to use fallback 7, got %d", got)
 	}
 
 	keyZero := "OCI_CPU_SHAPER_TEST_INT_ZERO"
 	t.Setenv(keyZero, "0")
 
-	if got := envInt(keyZero, 4); got != 4 {
-		t.Fatalf("expected zero int to use fallback 4, got %d", got)
+	if got := env.int(keyZero, 4); got != 0 {
+		t.Fatalf("expected zero int to be accepted, got %d", got)
 	}
 
 	keyValid := "OCI_CPU_SHAPER_TEST_INT_VALID"
 	t.Setenv(keyValid, " 5 ")
 
-	if got := envInt(keyValid, 1); got != 5 {
+	if got := env.int(keyValid, 1); got != 5 {
 		t.Fatalf("expected trimmed int 5, got %d", got)
 	}
+
+	keyMalformed := "OCI_CPU_SHAPER_TEST_INT_MALFORMED"
+	t.Setenv(keyMalformed, "five")
+
+	if got := env.int(keyMalformed, 3); got != 3 {
+		t.Fatalf("expected malformed int to use fallback 3, got %d", got)
+	}
+
+	if len(env.invalid) != 2 {
+		t.Fatalf("expected the negative and malformed ints to be recorded, got %v", env.invalid)
+	}
 }
 
 func TestEnvStringTrimsAndFallback(t *testing.T) {
@@ -417,31 +1156,98 @@ func TestEnvStringTrimsAndFallback(t *testing.T) {
 }
 
 func TestEnvBoolEvaluation(t *testing.T) {
+	var env envReader
+
 	missingKey := "OCI_CPU_SHAPER_TEST_BOOL_MISSING"
-	if got := envBool(missingKey, true); got != true {
+	if got := env.bool(missingKey, true); got != true {
 		t.Fatalf("expected missing env bool to return fallback, got %t", got)
 	}
 
 	keyTrue := "OCI_CPU_SHAPER_TEST_BOOL_TRUE"
 	t.Setenv(keyTrue, "Yes")
 
-	if got := envBool(keyTrue, false); !got {
+	if got := env.bool(keyTrue, false); !got {
 		t.Fatal("expected affirmative string to parse as true")
 	}
 
 	keyFalse := "OCI_CPU_SHAPER_TEST_BOOL_FALSE"
 	t.Setenv(keyFalse, "0")
 
-	if got := envBool(keyFalse, true); got {
+	if got := env.bool(keyFalse, true); got {
 		t.Fatal("expected zero to parse as false")
 	}
 
 	keyInvalid := "OCI_CPU_SHAPER_TEST_BOOL_INVALID"
 	t.Setenv(keyInvalid, "sometimes")
 
-	if got := envBool(keyInvalid, false); got {
+	if got := env.bool(keyInvalid, false); got {
 		t.Fatal("expected invalid bool to fall back to false")
 	}
+
+	if len(env.invalid) != 1 || !strings.Contains(env.invalid[0], `"sometimes"`) {
+		t.Fatalf("expected the invalid bool to be recorded, got %v", env.invalid)
+	}
+}
+
+func TestLoadConfigReportsMalformedEnv(t *testing.T) {
+	t.Setenv(envTargetStart, "0.2O")
+	t.Setenv(envFastInterval, "5")
+	t.Setenv(envOCIOffline, "maybe")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	assertFloatEqual(t, "targetStart", cfg.Controller.TargetStart, adaptDefault().TargetStart)
+
+	if len(cfg.IgnoredEnv) != 3 {
+		t.Fatalf("expected three ignored overrides, got %v", cfg.IgnoredEnv)
+	}
+
+	t.Setenv(envStrictEnv, "true")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, errInvalidEnv) || exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected errInvalidEnv, got %v", err)
+	}
+
+	for _, key := range []string{envTargetStart, envFastInterval, envOCIOffline} {
+		if !strings.Contains(err.Error(), key+": ") {
+			t.Fatalf("expected %s in %v", key, err)
+		}
+	}
+
+	t.Setenv(envTargetStart, "0.2")
+	t.Setenv(envFastInterval, "5s")
+	t.Setenv(envOCIOffline, "false")
+
+	_, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("expected well-formed overrides to load in strict mode, got %v", err)
+	}
+
+	t.Setenv(envStrictEnv, "strict")
+
+	_, err = loadConfig("")
+	if !errors.Is(err, errInvalidEnv) || !strings.Contains(err.Error(), envStrictEnv) {
+		t.Fatalf("expected a malformed %s to fail, got %v", envStrictEnv, err)
+	}
+}
+
+func TestStrictEnvAcceptsZeroCounts(t *testing.T) {
+	t.Setenv(envStrictEnv, "true")
+	t.Setenv(envPoolWorkers, "0")
+	t.Setenv(envMemoryLimit, "0")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("expected documented zero counts to load in strict mode, got %v", err)
+	}
+
+	if cfg.Pool.Workers != 0 || cfg.Memory.LimitMiB != 0 {
+		t.Fatalf("expected zero counts to apply, got %+v %+v", cfg.Pool, cfg.Memory)
+	}
 }
 
 func TestApplyEnvOverridesNormalisesValues(t *testing.T) {
@@ -457,12 +1263,15 @@ func TestApplyEnvOverridesNormalisesValues(t *testing.T) {
 	cfg.Controller.RelaxedInterval = 0
 	cfg.Estimator.Interval = 0
 
-	applyEnvOverrides(&cfg)
+	err := applyEnvOverrides(&cfg)
+	if err != nil {
+		t.Fatalf("applyEnvOverrides: %v", err)
+	}
 
 	defaults := adapt.DefaultConfig()
 
-	if cfg.Pool.Workers != 1 {
-		t.Fatalf("expected workers to normalise to 1, got %d", cfg.Pool.Workers)
+	if cfg.Pool.Workers != 0 {
+		t.Fatalf("expected workers to normalise to automatic, got %d", cfg.Pool.Workers)
 	}
 
 	if cfg.Pool.Quantum != shape.DefaultQuantum {
@@ -494,6 +1303,36 @@ func TestApplyEnvOverridesNormalisesValues(t *testing.T) {
 	}
 }
 
+func TestLoadConfigDerivesEstimatorInterval(t *testing.T) {
+	t.Setenv(envSuppressThreshold, "1")
+	t.Setenv(envSuppressResume, "0.95")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(t, "estimatorInterval", cfg.Estimator.Interval, 15*time.Second)
+
+	t.Setenv(envSlowInterval, "2m")
+
+	cfg, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(t, "estimatorInterval", cfg.Estimator.Interval, time.Second)
+
+	t.Setenv(envFastInterval, "3s")
+
+	cfg, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(t, "estimatorInterval", cfg.Estimator.Interval, 3*time.Second)
+}
+
 func assertFloatEqual(t *testing.T, name string, got, want float64) {
 	t.Helper()
 
@@ -533,3 +1372,241 @@ func assertBoolEqual(t *testing.T, name string, got, want bool) {
 		t.Fatalf("expected %s override %t, got %t", name, want, got)
 	}
 }
+
+func TestLoadConfigRejectsUnknownHTTPNetwork(t *testing.T) {
+	t.Setenv(envHTTPNetwork, "udp")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, errInvalidHTTPNetwork) {
+		t.Fatalf("expected invalid http network error, got %v", err)
+	}
+
+	if got := exitCodeForConfigError(err); got != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", got)
+	}
+}
+
+func TestLoadConfigRejectsInvalidMetricsNamespace(t *testing.T) {
+	t.Setenv(envMetricsNamespace, "acme-shaper")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, metricshttp.ErrInvalidNamespace) {
+		t.Fatalf("expected invalid metrics namespace error, got %v", err)
+	}
+
+	if got := exitCodeForConfigError(err); got != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", got)
+	}
+}
+
+func TestLoadConfigParsesMetricsLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.yaml")
+	manifest := "http:\n  metricsLabels:\n    team: infra\n    env: prod\n"
+
+	err := os.WriteFile(path, []byte(manifest), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if len(cfg.HTTP.MetricsLabels) != 2 || cfg.HTTP.MetricsLabels["team"] != "infra" {
+		t.Fatalf("unexpected metrics labels %v", cfg.HTTP.MetricsLabels)
+	}
+
+	t.Setenv(envMetricsLabels, "role = batch")
+
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if len(cfg.HTTP.MetricsLabels) != 1 || cfg.HTTP.MetricsLabels["role"] != "batch" {
+		t.Fatalf("expected the environment to replace the labels, got %v", cfg.HTTP.MetricsLabels)
+	}
+
+	t.Setenv(envMetricsLabels, "team-name=infra")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, metricshttp.ErrInvalidStaticLabel) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected ErrInvalidStaticLabel, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesHTTPListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listeners.yaml")
+
+	manifest := `http:
+  listeners:
+    - bind: 127.0.0.1:9108
+    - bind: " 10.0.0.5:9443 "
+      tls:
+        certFile: /etc/shaper/tls.crt
+        keyFile: /etc/shaper/tls.key
+        clientCAFile: /etc/shaper/ca.crt
+      auth:
+        username: prometheus
+        passwordFile: /etc/shaper/password
+`
+
+	writeErr := os.WriteFile(path, []byte(manifest), 0o600)
+	if writeErr != nil {
+		t.Fatalf("write temp file: %v", writeErr)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	want := []listenerConfig{
+		{Bind: "127.0.0.1:9108"},
+		{
+			Bind: "10.0.0.5:9443",
+			TLS: listenerhttp.TLS{
+				CertFile:     "/etc/shaper/tls.crt",
+				KeyFile:      "/etc/shaper/tls.key",
+				ClientCAFile: "/etc/shaper/ca.crt",
+			},
+			Auth: listenerAuthConfig{Username: "prometheus", PasswordFile: "/etc/shaper/password"},
+		},
+	}
+	if !reflect.DeepEqual(metricsListeners(cfg.HTTP), want) {
+		t.Fatalf("unexpected listeners %+v", cfg.HTTP.Listeners)
+	}
+}
+
+func TestLoadConfigRejectsInvalidHTTPListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listeners.yaml")
+
+	for _, entry := range []string{
+		"tls: {certFile: /tls.crt, keyFile: /tls.key}",
+		"bind: :9108\n      tls: {certFile: /tls.crt}",
+		"bind: :9108\n      tls: {clientCAFile: /ca.crt}",
+		"bind: :9108\n      auth: {bearerTokenFile: /token, username: prometheus}",
+		"bind: :9108\n      auth: {username: prometheus}",
+	} {
+		writeErr := os.WriteFile(path, []byte("http:\n  listeners:\n    - "+entry+"\n"), 0o600)
+		if writeErr != nil {
+			t.Fatalf("write temp file: %v", writeErr)
+		}
+
+		_, err := loadConfig(path)
+		if !errors.Is(err, errInvalidHTTPListener) {
+			t.Fatalf("expected errInvalidHTTPListener for %q, got %v", entry, err)
+		}
+
+		if got := exitCodeForConfigError(err); got != exitCodeParseError {
+			t.Fatalf("expected parse error exit code, got %d", got)
+		}
+	}
+}
+
+func TestMetricsListenersFallBackToBind(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.Bind = "127.0.0.1:9108, [::1]:9108"
+
+	want := []listenerConfig{{Bind: "127.0.0.1:9108"}, {Bind: "[::1]:9108"}}
+	if got := metricsListeners(cfg.HTTP); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected listeners %+v", got)
+	}
+}
+
+func TestLoadConfigRejectsUnknownBindFallback(t *testing.T) {
+	t.Setenv(envHTTPBindFallback, "random")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, listenerhttp.ErrUnknownFallback) {
+		t.Fatalf("expected unknown bind fallback error, got %v", err)
+	}
+
+	if got := exitCodeForConfigError(err); got != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", got)
+	}
+}
+
+func TestLoadConfigParsesAccessLogSample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access-log.yaml")
+
+	err := os.WriteFile(path, []byte("http:\n  accessLogSample: 0.25\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.HTTP.AccessLogSample != 0.25 {
+		t.Fatalf("expected access log sample 0.25, got %v", cfg.HTTP.AccessLogSample)
+	}
+
+	t.Setenv(envAccessLogSample, "1.5")
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, listenerhttp.ErrInvalidSample) ||
+		exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected ErrInvalidSample, got %v", err)
+	}
+}
+
+func TestListenNetworkAndBindAddresses(t *testing.T) {
+	t.Parallel()
+
+	assertStringEqual(t, "dual", listenNetwork(httpNetworkDual), "tcp")
+	assertStringEqual(t, "tcp4", listenNetwork(httpNetworkTCP4), "tcp4")
+	assertStringEqual(t, "tcp6", listenNetwork(httpNetworkTCP6), "tcp6")
+
+	addresses := bindAddresses("0.0.0.0:9108, [::]:9108,,")
+	if len(addresses) != 2 || addresses[0] != "0.0.0.0:9108" || addresses[1] != "[::]:9108" {
+		t.Fatalf("unexpected bind addresses %q", addresses)
+	}
+
+	if got := bindAddresses("  "); len(got) != 0 {
+		t.Fatalf("expected no addresses for blank bind, got %q", got)
+	}
+}
+
+func TestValidateBuildProfileRejectsExcludedSubsystems(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+
+	err := validateBuildProfile(cfg, true)
+	if err != nil {
+		t.Fatalf("expected the default config to suit a minimal build, got %v", err)
+	}
+
+	cfg.Admin.MatchingRule = "instance.id = 'ocid1.instance.oc1..example'"
+	cfg.Admin.SnapshotDir = "/var/lib/oci-cpu-shaper/snapshots"
+	cfg.Admin.AllowRemote = true
+	cfg.Webhook.URL = "https://automation.example.com/shaper"
+	cfg.Update.Check = true
+
+	err = validateBuildProfile(cfg, false)
+	if err != nil {
+		t.Fatalf("expected full builds to accept every subsystem, got %v", err)
+	}
+
+	err = validateBuildProfile(cfg, true)
+	if !errors.Is(err, errMinimalBuild) {
+		t.Fatalf("expected errMinimalBuild, got %v", err)
+	}
+
+	want := "admin.dynamicGroupId/admin.matchingRule, admin.snapshotDir, admin.allowRemote, " +
+		"webhook.url, update.check"
+	if !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("expected every excluded setting listed, got %v", err)
+	}
+
+	if exitCodeForConfigError(err) != exitCodeParseError {
+		t.Fatalf("expected exit code %d, got %d", exitCodeParseError, exitCodeForConfigError(err))
+	}
+}
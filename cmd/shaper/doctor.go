@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"oci-cpu-shaper/pkg/cgroup"
+	"oci-cpu-shaper/pkg/sched"
+)
+
+// runDoctor prints the host capabilities the shaper relies on so operators can
+// confirm a deployment before starting the controller.
+func runDoctor(deps runDeps) int {
+	writer := deps.stdout
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	writeSchedCapabilities(writer, probeScheduling())
+
+	root := strings.TrimSpace(deps.cgroupRoot)
+	if root == "" {
+		root = cgroup.DefaultRoot
+	}
+
+	manager, err := cgroup.OpenSelf(root)
+	if err != nil {
+		_, _ = fmt.Fprintf(writer, "cgroup.version: %s\n", cgroup.VersionUnknown)
+		_, _ = fmt.Fprintf(writer, "cgroup.error: %v\n", err)
+
+		return exitCodeRuntimeError
+	}
+
+	writeCgroupCapabilities(writer, manager.Capabilities())
+
+	return exitCodeSuccess
+}
+
+func writeCgroupCapabilities(writer io.Writer, caps cgroup.Capabilities) {
+	_, _ = fmt.Fprintf(writer, "cgroup.version: %s\n", caps.Version)
+	_, _ = fmt.Fprintf(writer, "cgroup.cpuWeight: %s\n", describeControl(caps.CPUWeight))
+	_, _ = fmt.Fprintf(writer, "cgroup.cpuQuota: %s\n", describeControl(caps.CPUQuota))
+	_, _ = fmt.Fprintf(writer, "cgroup.cpuUsage: %s\n", describeControl(caps.CPUUsage))
+}
+
+func writeSchedCapabilities(writer io.Writer, caps sched.Capabilities) {
+	_, _ = fmt.Fprintf(writer, "sched.platform: %s\n", caps.Platform)
+	_, _ = fmt.Fprintf(writer, "sched.schedIdle: %s\n", caps.SchedIdle)
+	_, _ = fmt.Fprintf(writer, "sched.nice: %s\n", caps.Nice)
+	_, _ = fmt.Fprintf(writer, "sched.affinity: %s\n", caps.Affinity)
+	_, _ = fmt.Fprintf(writer, "sched.capSysNice: %t\n", caps.CapSysNice)
+	_, _ = fmt.Fprintf(writer, "sched.seccomp: %s\n", caps.Seccomp)
+	_, _ = fmt.Fprintf(writer, "sched.noNewPrivs: %t\n", caps.NoNewPrivs)
+
+	if hint := caps.Hint(); hint != "" {
+		_, _ = fmt.Fprintf(writer, "sched.hint: %s\n", hint)
+	}
+}
+
+func describeControl(control cgroup.Control) string {
+	switch {
+	case !control.Available:
+		return control.File + " (missing)"
+	case control.Writable:
+		return control.File + " (writable)"
+	default:
+		return control.File + " (read-only)"
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/sched"
+)
+
+func writeDoctorFixture(t *testing.T, path, contents string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatalf("create fixture dir: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+}
+
+func runDoctorWithRoot(t *testing.T, root string) (int, string) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+
+	deps := defaultRunDeps()
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
+		panic("newLogger should not be called by doctor")
+	}
+	deps.loadConfig = func(string) (runtimeConfig, error) {
+		panic("loadConfig should not be called by doctor")
+	}
+	deps.stdout = &stdout
+	deps.cgroupRoot = root
+
+	exitCode := run(t.Context(), []string{"doctor"}, deps, io.Discard)
+
+	return exitCode, stdout.String()
+}
+
+func TestParseArgsDoctorSubcommand(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"doctor"})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+
+	if !opts.runDoctor {
+		t.Fatal("expected runDoctor to be true when doctor subcommand is provided")
+	}
+}
+
+func TestRunDoctorReportsCgroupV1Capabilities(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeDoctorFixture(t, filepath.Join(root, "cpu,cpuacct", "cpu.shares"), "1024\n")
+	writeDoctorFixture(t, filepath.Join(root, "cpu,cpuacct", "cpu.cfs_quota_us"), "-1\n")
+	writeDoctorFixture(t, filepath.Join(root, "cpu,cpuacct", "cpuacct.usage"), "0\n")
+
+	exitCode, output := runDoctorWithRoot(t, root)
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success exit code, got %d (output=%q)", exitCode, output)
+	}
+
+	for _, want := range []string{
+		"cgroup.version: v1\n",
+		"cgroup.cpuWeight: cpu.shares (writable)\n",
+		"cgroup.cpuQuota: cpu.cfs_quota_us (writable)\n",
+		"cgroup.cpuUsage: cpuacct.usage (writable)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in doctor output, got %q", want, output)
+		}
+	}
+}
+
+func TestRunDoctorReportsMissingV2Controls(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeDoctorFixture(t, filepath.Join(root, "cgroup.controllers"), "cpu\n")
+	writeDoctorFixture(t, filepath.Join(root, "cpu.stat"), "usage_usec 1\n")
+
+	exitCode, output := runDoctorWithRoot(t, root)
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success exit code, got %d (output=%q)", exitCode, output)
+	}
+
+	if !strings.Contains(output, "cgroup.version: v2\n") ||
+		!strings.Contains(output, "cgroup.cpuWeight: cpu.weight (missing)\n") {
+		t.Fatalf("unexpected doctor output %q", output)
+	}
+}
+
+func TestRunDoctorFailsWithoutHierarchy(t *testing.T) {
+	t.Parallel()
+
+	exitCode, output := runDoctorWithRoot(t, t.TempDir())
+	if exitCode != exitCodeRuntimeError {
+		t.Fatalf("expected runtime error exit code, got %d", exitCode)
+	}
+
+	if !strings.Contains(output, "cgroup.version: unknown\n") {
+		t.Fatalf("expected unknown version in output, got %q", output)
+	}
+}
+
+func TestRunDoctorReportsSchedulingCapabilities(t *testing.T) {
+	t.Parallel()
+
+	_, output := runDoctorWithRoot(t, t.TempDir())
+
+	for _, want := range []string{
+		"sched.platform: ",
+		"sched.schedIdle: ",
+		"sched.nice: ",
+		"sched.affinity: ",
+		"sched.capSysNice: ",
+		"sched.seccomp: ",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in doctor output, got %q", want, output)
+		}
+	}
+}
+
+func TestWriteSchedCapabilitiesIncludesHint(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+
+	writeSchedCapabilities(&output, sched.Capabilities{ //nolint:exhaustruct
+		Platform:  "linux/amd64",
+		SchedIdle: sched.Check{Status: sched.StatusDenied, Err: errStubSchedRefused},
+		Seccomp:   sched.SeccompDisabled,
+	})
+
+	for _, want := range []string{
+		"sched.platform: linux/amd64\n",
+		"sched.schedIdle: denied (operation not permitted)\n",
+		"sched.capSysNice: false\n",
+		"sched.hint: CAP_SYS_NICE is missing",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("expected %q in doctor output, got %q", want, output.String())
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/budget"
+	"oci-cpu-shaper/pkg/health"
+	"oci-cpu-shaper/pkg/shape"
+)
+
+// healthRegistryKey carries the component registry to the wiring helpers that
+// own a component, such as the API budget guards and the metrics listeners.
+type healthRegistryKey struct{}
+
+func withHealthRegistry(ctx context.Context, registry *health.Registry) context.Context {
+	return context.WithValue(ctx, healthRegistryKey{}, registry)
+}
+
+func healthRegistryFromContext(ctx context.Context) *health.Registry {
+	registry, _ := ctx.Value(healthRegistryKey{}).(*health.Registry)
+
+	return registry
+}
+
+// newHealthRegistry returns a registry that leaves the components listed in
+// health.disable out of the /healthz aggregate.
+func newHealthRegistry(cfg healthConfig) *health.Registry {
+	registry := health.NewRegistry()
+
+	for _, name := range cfg.Disable {
+		registry.Disable(name)
+	}
+
+	return registry
+}
+
+// registerControllerHealth reports the estimator and the OCI client through
+// the controller's last errors and the pool through its start outcome. The
+// noop mode runs none of them, and offline runs never call OCI.
+func registerControllerHealth(
+	registry *health.Registry,
+	mode string,
+	cfg runtimeConfig,
+	controller adapt.Controller,
+	pool poolStarter,
+) {
+	if strings.TrimSpace(mode) == modeNoop || controller == nil {
+		for _, name := range []string{
+			health.ComponentEstimator,
+			health.ComponentOCI,
+			health.ComponentPool,
+		} {
+			registry.Register(name, disabledCheck("noop mode"))
+		}
+
+		return
+	}
+
+	registry.Register(health.ComponentEstimator, errorCheck(controller.LastEstimatorError))
+
+	if cfg.OCI.Offline {
+		registry.Register(health.ComponentOCI, disabledCheck("offline mode"))
+	} else {
+		registry.Register(health.ComponentOCI, errorCheck(controller.LastError))
+	}
+
+	registry.Register(health.ComponentPool, poolCheck(pool))
+}
+
+func disabledCheck(reason string) health.Check {
+	return func() (health.Status, string) {
+		return health.StatusDisabled, reason
+	}
+}
+
+// errorCheck degrades a component while last returns an error.
+func errorCheck(last func() error) health.Check {
+	return func() (health.Status, string) {
+		err := last()
+		if err != nil {
+			return health.StatusDegraded, err.Error()
+		}
+
+		return health.StatusOK, ""
+	}
+}
+
+// poolCheck degrades the pool until it has started and when its workers run
+// without SCHED_IDLE.
+func poolCheck(pool poolStarter) health.Check {
+	if pool == nil {
+		return disabledCheck("no worker pool")
+	}
+
+	reporter, ok := pool.(startOutcomeReporter)
+	if !ok {
+		return func() (health.Status, string) { return health.StatusOK, "" }
+	}
+
+	return func() (health.Status, string) {
+		switch outcome := reporter.StartOutcome(); outcome {
+		case shape.StartOutcomeOK:
+			return health.StatusOK, ""
+		case "":
+			return health.StatusDegraded, "workers starting"
+		default:
+			return health.StatusDegraded, "workers run without sched_idle; start failure policy " +
+				outcome
+		}
+	}
+}
+
+// budgetCheck degrades the guards once a daily OCI API budget is spent.
+func budgetCheck(tracker *budget.Tracker) health.Check {
+	return func() (health.Status, string) {
+		var (
+			budgeted bool
+			spent    []string
+		)
+
+		for _, usage := range tracker.Snapshot() {
+			if usage.Limit <= 0 {
+				continue
+			}
+
+			budgeted = true
+
+			if usage.Remaining() == 0 {
+				spent = append(spent, fmt.Sprintf("%s %d/%d", usage.API, usage.Calls, usage.Limit))
+			}
+		}
+
+		switch {
+		case len(spent) > 0:
+			return health.StatusDegraded, "daily api budget spent: " + strings.Join(spent, ", ")
+		case !budgeted:
+			return health.StatusDisabled, "no api budget configured"
+		default:
+			return health.StatusOK, ""
+		}
+	}
+}
+
+// listenerHealthKey carries the metrics listener tracker to startMetricsServer.
+type listenerHealthKey struct{}
+
+// listenerHealth tracks which metrics listeners are still waiting to bind, for
+// example while http.bindFallback retries a taken port.
+type listenerHealth struct {
+	mu      sync.Mutex
+	waiting map[string]bool
+}
+
+func newListenerHealth() *listenerHealth {
+	return &listenerHealth{waiting: make(map[string]bool)}
+}
+
+func withListenerHealth(ctx context.Context, tracker *listenerHealth) context.Context {
+	return context.WithValue(ctx, listenerHealthKey{}, tracker)
+}
+
+func listenerHealthFromContext(ctx context.Context) *listenerHealth {
+	tracker, _ := ctx.Value(listenerHealthKey{}).(*listenerHealth)
+
+	return tracker
+}
+
+func (l *listenerHealth) binding(addr string) {
+	l.set(addr, true)
+}
+
+func (l *listenerHealth) bound(addr string) {
+	l.set(addr, false)
+}
+
+func (l *listenerHealth) set(addr string, waiting bool) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	l.waiting[addr] = waiting
+	l.mu.Unlock()
+}
+
+func (l *listenerHealth) check() (health.Status, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.waiting) == 0 {
+		return health.StatusDisabled, "no listener configured"
+	}
+
+	var waiting []string
+
+	for addr, pending := range l.waiting {
+		if pending {
+			waiting = append(waiting, addr)
+		}
+	}
+
+	if len(waiting) == 0 {
+		return health.StatusOK, ""
+	}
+
+	slices.Sort(waiting)
+
+	return health.StatusDegraded, "waiting to bind " + strings.Join(waiting, ", ")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/health"
+	listenerhttp "oci-cpu-shaper/pkg/http/listener"
+	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/shape"
+)
+
+func TestRegisterControllerHealthReportsComponents(t *testing.T) {
+	t.Parallel()
+
+	controller := &blockingController{
+		mode:    modeDryRun,
+		state:   adapt.StateFallback,
+		lastErr: errStubHealthOCI,
+		estErr:  nil,
+	}
+	pool := new(stubPoolStarter)
+
+	registry := newHealthRegistry(healthConfig{Disable: nil})
+	registerControllerHealth(registry, modeDryRun, defaultRuntimeConfig(), controller, pool)
+
+	report := registry.Report()
+	if report.Status != health.StatusDegraded ||
+		report.Components[health.ComponentEstimator].Status != health.StatusOK ||
+		report.Components[health.ComponentOCI].Detail != errStubHealthOCI.Error() ||
+		report.Components[health.ComponentPool].Detail != "workers starting" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	pool.outcome = string(shape.StartFailureFallback)
+	if detail := registry.Report().Components[health.ComponentPool].Detail; !strings.Contains(
+		detail, "start failure policy fallback") {
+		t.Fatalf("expected the start failure policy in the pool detail, got %q", detail)
+	}
+
+	pool.outcome = shape.StartOutcomeOK
+	controller.lastErr = nil
+
+	if report := registry.Report(); report.Status != health.StatusOK {
+		t.Fatalf("expected a healthy report once the pool started, got %+v", report)
+	}
+
+	registry = newHealthRegistry(healthConfig{Disable: []string{health.ComponentOCI}})
+	registerControllerHealth(registry, modeNoop, defaultRuntimeConfig(), controller, nil)
+
+	for _, name := range []string{health.ComponentEstimator, health.ComponentPool} {
+		if got := registry.Report().Components[name]; got.Detail != "noop mode" {
+			t.Fatalf("expected %s to be disabled by noop mode, got %+v", name, got)
+		}
+	}
+
+	got := registry.Report().Components[health.ComponentOCI]
+	if got.Status != health.StatusDisabled || got.Detail != "" {
+		t.Fatalf("expected oci to be disabled by configuration, got %+v", got)
+	}
+}
+
+func TestConfigureAPIBudgetDegradesGuardsOnceSpent(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.MonitoringBudget = 1
+	cfg.OCI.IMDSBudget = 0
+
+	registry := newHealthRegistry(healthConfig{Disable: nil})
+	ctx := withMetricsClientFactory(
+		withHealthRegistry(t.Context(), registry),
+		func(string, string) (oci.MetricsClient, error) {
+			return newStubMetricsClient(), nil
+		},
+	)
+
+	ctx, _ = configureAPIBudget(ctx, zap.NewNop(), cfg, newOfflineStubIMDS(), nil)
+
+	if got := registry.Report().Components[health.ComponentGuards]; got.Status != health.StatusOK {
+		t.Fatalf("expected healthy guards before any call, got %+v", got)
+	}
+
+	metricsClient, err := metricsClientFactoryFromContext(ctx)("ocid1.compartment", "region")
+	if err != nil {
+		t.Fatalf("factory returned error: %v", err)
+	}
+
+	_, _ = metricsClient.QueryP95CPU(context.Background(), "ocid1.instance")
+
+	got := registry.Report().Components[health.ComponentGuards]
+	if got.Status != health.StatusDegraded || !strings.Contains(got.Detail, "1/1") {
+		t.Fatalf("expected a spent budget to degrade the guards, got %+v", got)
+	}
+
+	cfg.OCI.MonitoringBudget = 0
+	registry = newHealthRegistry(healthConfig{Disable: nil})
+	ctx = withHealthRegistry(t.Context(), registry)
+	_, _ = configureAPIBudget(ctx, zap.NewNop(), cfg, nil, nil)
+
+	got = registry.Report().Components[health.ComponentGuards]
+	if got.Status != health.StatusDisabled {
+		t.Fatalf("expected guards without budgets to be disabled, got %+v", got)
+	}
+}
+
+func TestStartMetricsServerReportsListenerHealth(t *testing.T) {
+	t.Parallel()
+
+	listeners := newListenerHealth()
+	if status, _ := listeners.check(); status != health.StatusDisabled {
+		t.Fatalf("expected no listener to be disabled, got %q", status)
+	}
+
+	var config net.ListenConfig
+
+	taken, err := config.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { _ = taken.Close() })
+
+	ctx, cancel := context.WithCancel(withListenerHealth(t.Context(), listeners))
+	t.Cleanup(cancel)
+
+	err = startMetricsServer(
+		ctx,
+		zap.NewNop(),
+		"tcp",
+		taken.Addr().String(),
+		http.NewServeMux(),
+		nil,
+		listenerhttp.FallbackRetry,
+	)
+	if err != nil {
+		t.Fatalf("startMetricsServer: %v", err)
+	}
+
+	status, detail := listeners.check()
+	if status != health.StatusDegraded || detail != "waiting to bind "+taken.Addr().String() {
+		t.Fatalf("expected a retrying listener to degrade metrics, got %q %q", status, detail)
+	}
+
+	listeners.bound(taken.Addr().String())
+
+	if status, _ := listeners.check(); status != health.StatusOK {
+		t.Fatalf("expected a bound listener to be healthy, got %q", status)
+	}
+}
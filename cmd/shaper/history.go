@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"oci-cpu-shaper/pkg/history"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var (
+	errHistoryKeyLength     = errors.New("history key must be 32 raw or base64-encoded bytes")
+	errSecretReaderMissing  = errors.New("vault secret reader unavailable")
+	errHistoryKeyNotApplied = errors.New("history encryption requires history.path")
+)
+
+type secretReader interface {
+	SecretContent(ctx context.Context, secretOCID string) ([]byte, error)
+}
+
+//nolint:ireturn // factory returns interface so tests can substitute readers.
+func newInstancePrincipalSecretReader(region string) (secretReader, error) {
+	client, err := oci.NewInstancePrincipalVaultClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build vault client: %w", err)
+	}
+
+	return client, nil
+}
+
+// historyEncryptionOptions loads the history encryption key from the configured
+// key file or OCI Vault secret. It returns no options when neither is set.
+func historyEncryptionOptions(
+	ctx context.Context,
+	deps runDeps,
+	cfg runtimeConfig,
+) ([]history.Option, error) {
+	keyFile := strings.TrimSpace(cfg.History.KeyFile)
+	secretID := strings.TrimSpace(cfg.History.VaultSecretID)
+
+	if keyFile == "" && secretID == "" {
+		return nil, nil
+	}
+
+	if strings.TrimSpace(cfg.History.Path) == "" {
+		return nil, errHistoryKeyNotApplied
+	}
+
+	var (
+		material []byte
+		err      error
+	)
+
+	if keyFile != "" {
+		material, err = os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read history key file: %w", err)
+		}
+	} else {
+		material, err = readVaultSecret(ctx, deps, cfg.OCI.Region, secretID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := decodeHistoryKey(material)
+	if err != nil {
+		return nil, err
+	}
+
+	return []history.Option{history.WithEncryptionKey(key)}, nil
+}
+
+func readVaultSecret(
+	ctx context.Context,
+	deps runDeps,
+	region string,
+	secretID string,
+) ([]byte, error) {
+	if deps.newSecretReader == nil {
+		return nil, errSecretReaderMissing
+	}
+
+	reader, err := deps.newSecretReader(region)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := reader.SecretContent(ctx, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("read history key secret: %w", err)
+	}
+
+	return content, nil
+}
+
+// decodeHistoryKey accepts the key either as raw bytes or base64 text, so both
+// `head -c 32 /dev/urandom` and `openssl rand -base64 32` produce usable keys.
+func decodeHistoryKey(material []byte) ([]byte, error) {
+	if len(material) == history.KeySize {
+		return material, nil
+	}
+
+	trimmed := bytes.TrimSpace(material)
+	if len(trimmed) == history.KeySize {
+		return trimmed, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(trimmed))
+	if err != nil || len(decoded) != history.KeySize {
+		return nil, errHistoryKeyLength
+	}
+
+	return decoded, nil
+}
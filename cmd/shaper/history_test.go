@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubSecretReader struct {
+	content   []byte
+	requested string
+}
+
+func (s *stubSecretReader) SecretContent(_ context.Context, secretOCID string) ([]byte, error) {
+	s.requested = secretOCID
+
+	return s.content, nil
+}
+
+func TestHistoryEncryptionOptionsFromKeyFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+
+	cfg := defaultRuntimeConfig()
+	cfg.History.Path = filepath.Join(dir, "history.bin")
+
+	opts, err := historyEncryptionOptions(t.Context(), runDeps{}, cfg)
+	if err != nil || opts != nil {
+		t.Fatalf("expected no options without a key, got %v (err=%v)", opts, err)
+	}
+
+	for name, material := range map[string][]byte{
+		"raw":    key,
+		"base64": []byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+	} {
+		cfg.History.KeyFile = filepath.Join(dir, name+".key")
+
+		err = os.WriteFile(cfg.History.KeyFile, material, 0o600)
+		if err != nil {
+			t.Fatalf("write key file: %v", err)
+		}
+
+		opts, err = historyEncryptionOptions(t.Context(), runDeps{}, cfg)
+		if err != nil || len(opts) != 1 {
+			t.Fatalf("expected a %s key file to yield one option, got %v (err=%v)", name, opts, err)
+		}
+	}
+
+	err = os.WriteFile(cfg.History.KeyFile, []byte("too short"), 0o600)
+	if err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+
+	_, err = historyEncryptionOptions(t.Context(), runDeps{}, cfg)
+	if !errors.Is(err, errHistoryKeyLength) {
+		t.Fatalf("expected errHistoryKeyLength, got %v", err)
+	}
+
+	cfg.History.Path = ""
+
+	_, err = historyEncryptionOptions(t.Context(), runDeps{}, cfg)
+	if !errors.Is(err, errHistoryKeyNotApplied) {
+		t.Fatalf("expected errHistoryKeyNotApplied, got %v", err)
+	}
+}
+
+func TestHistoryEncryptionOptionsFromVault(t *testing.T) {
+	t.Parallel()
+
+	reader := &stubSecretReader{content: bytes.Repeat([]byte{9}, 32)}
+
+	var region string
+
+	deps := runDeps{
+		newSecretReader: func(r string) (secretReader, error) {
+			region = r
+
+			return reader, nil
+		},
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.History.Path = filepath.Join(t.TempDir(), "history.bin")
+	cfg.History.VaultSecretID = "ocid1.vaultsecret.oc1..history"
+	cfg.OCI.Region = stubRegion
+
+	opts, err := historyEncryptionOptions(t.Context(), deps, cfg)
+	if err != nil || len(opts) != 1 {
+		t.Fatalf("expected a vault key to yield one option, got %v (err=%v)", opts, err)
+	}
+
+	if reader.requested != cfg.History.VaultSecretID || region != stubRegion {
+		t.Fatalf("unexpected vault lookup %q in %q", reader.requested, region)
+	}
+
+	_, err = historyEncryptionOptions(t.Context(), runDeps{}, cfg)
+	if !errors.Is(err, errSecretReaderMissing) {
+		t.Fatalf("expected errSecretReaderMissing, got %v", err)
+	}
+}
+
+func TestLoadConfigRejectsConflictingHistoryKeys(t *testing.T) {
+	t.Setenv(envHistoryKeyFile, "/etc/oci-cpu-shaper/history.key")
+	t.Setenv(envHistoryVaultKey, "ocid1.vaultsecret.oc1..history")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, errHistoryKeyConflict) {
+		t.Fatalf("expected errHistoryKeyConflict, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse exit code, got %d", code)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/lifecycle"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+)
+
+// Shutdown stages, in the order they run once the run context ends.
+const (
+	stageController = "controller"
+	stagePool       = "pool"
+	stageEstimator  = "estimator"
+	stageMetrics    = "metrics"
+)
+
+// shutdownStageTimeout bounds the controller, pool, and estimator stages; the
+// metrics stage uses metricsShutdownTimeout.
+const shutdownStageTimeout = 5 * time.Second
+
+var errControllerStopTimeout = errors.New("controller did not stop within the shutdown timeout")
+
+// subsystems holds the contexts the worker pool, the estimator, and the
+// metrics servers run on. They carry the run context's values but not its
+// cancellation, so each is stopped by its own shutdown stage after the
+// controller instead of dying alongside it.
+type subsystems struct {
+	pool          context.Context //nolint:containedctx // stopped by its shutdown stage
+	estimator     context.Context //nolint:containedctx // stopped by its shutdown stage
+	metrics       context.Context //nolint:containedctx // stopped by its shutdown stage
+	stopPool      context.CancelFunc
+	stopEstimator context.CancelFunc
+	stopMetrics   context.CancelFunc
+	servers       sync.WaitGroup
+
+	mu     sync.Mutex
+	staged *stagedEstimator
+}
+
+type subsystemsKey struct{}
+
+// withSubsystems returns ctx carrying new subsystem contexts derived from it.
+func withSubsystems(ctx context.Context) (context.Context, *subsystems) {
+	//nolint:exhaustruct // the contexts are derived from the returned context
+	started := &subsystems{}
+	ctx = context.WithValue(ctx, subsystemsKey{}, started)
+	base := context.WithoutCancel(ctx)
+
+	started.pool, started.stopPool = context.WithCancel(base)
+	started.estimator, started.stopEstimator = context.WithCancel(base)
+	started.metrics, started.stopMetrics = context.WithCancel(base)
+
+	return ctx, started
+}
+
+func subsystemsFromContext(ctx context.Context) *subsystems {
+	started, _ := ctx.Value(subsystemsKey{}).(*subsystems)
+
+	return started
+}
+
+// close stops every subsystem at once, for runs that end before shutdown.
+func (s *subsystems) close() {
+	s.stopPool()
+	s.stopEstimator()
+	s.stopMetrics()
+}
+
+// trackServer counts a metrics server until the returned function reports
+// that it shut down, so the metrics stage can wait for in-flight scrapes.
+func (s *subsystems) trackServer() func() {
+	if s == nil {
+		return func() {}
+	}
+
+	s.servers.Add(1)
+
+	return s.servers.Done
+}
+
+// estimatorSource runs estimator on the estimator context, or returns it
+// unchanged without subsystems.
+//
+//nolint:ireturn // the controller accepts any adapt.Estimator
+func (s *subsystems) estimatorSource(estimator *est.Supervisor) adapt.Estimator {
+	if s == nil {
+		return estimator
+	}
+
+	staged := &stagedEstimator{
+		Supervisor: estimator,
+		ctx:        s.estimator,
+		started:    atomic.Bool{},
+		done:       make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.staged = staged
+	s.mu.Unlock()
+
+	return staged
+}
+
+// stagedEstimator keeps sampling after the controller stops until the
+// estimator stage cancels its context. Embedding the supervisor keeps the
+// optional methods the controller looks for.
+type stagedEstimator struct {
+	*est.Supervisor
+
+	ctx     context.Context //nolint:containedctx // the estimator stage cancels it
+	started atomic.Bool
+	done    chan struct{}
+}
+
+// Run forwards observations to the controller while runCtx lasts and drains
+// the rest until the estimator stops.
+func (e *stagedEstimator) Run(runCtx context.Context) <-chan est.Observation {
+	if !e.started.CompareAndSwap(false, true) {
+		return e.Supervisor.Run(e.ctx)
+	}
+
+	observations := e.Supervisor.Run(e.ctx)
+	forwarded := make(chan est.Observation, cap(observations))
+
+	go func() {
+		defer close(e.done)
+		defer close(forwarded)
+
+		for observation := range observations {
+			select {
+			case forwarded <- observation:
+			case <-runCtx.Done():
+			}
+		}
+	}()
+
+	return forwarded
+}
+
+func (e *stagedEstimator) wait(ctx context.Context) error {
+	if e == nil || !e.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for estimator: %w", ctx.Err())
+	}
+}
+
+// runController runs controller until ctx ends or it fails, then stops the
+// controller, drains the worker pool, stops the estimator, and finally shuts
+// the metrics servers down, so the last scrape sees the final state. It
+// returns the controller's result.
+func runController(
+	ctx context.Context,
+	logger *zap.Logger,
+	controller adapt.Controller,
+	pool poolStarter,
+	started *subsystems,
+	stopTextfile func(),
+) error {
+	var runErr error
+
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		runErr = controller.Run(ctx)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+
+	manager := lifecycle.NewManager(zapslog.NewLibraryLogger(logger))
+	manager.Add(stageController, shutdownStageTimeout, func(stageCtx context.Context) error {
+		select {
+		case <-finished:
+			return nil
+		case <-stageCtx.Done():
+			return fmt.Errorf("wait for controller: %w", stageCtx.Err())
+		}
+	})
+	manager.Add(stagePool, shutdownStageTimeout, func(stageCtx context.Context) error {
+		started.stopPool()
+
+		drainer, ok := pool.(poolDrainer)
+		if !ok {
+			return nil
+		}
+
+		return drainer.Wait(stageCtx) //nolint:wrapcheck // the manager names the stage
+	})
+	manager.Add(stageEstimator, shutdownStageTimeout, func(stageCtx context.Context) error {
+		started.stopEstimator()
+
+		started.mu.Lock()
+		staged := started.staged
+		started.mu.Unlock()
+
+		return staged.wait(stageCtx)
+	})
+	manager.Add(stageMetrics, metricsShutdownTimeout, func(stageCtx context.Context) error {
+		stopTextfile()
+		started.stopMetrics()
+
+		drained := make(chan struct{})
+
+		go func() {
+			started.servers.Wait()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+			return nil
+		case <-stageCtx.Done():
+			return fmt.Errorf("wait for metrics servers: %w", stageCtx.Err())
+		}
+	})
+
+	_ = manager.Shutdown(context.WithoutCancel(ctx))
+
+	select {
+	case <-finished:
+		return runErr
+	default:
+		return errControllerStopTimeout
+	}
+}
+
+type poolDrainer interface {
+	Wait(ctx context.Context) error
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/est"
+)
+
+var errDrainFailed = errors.New("drain failed")
+
+func TestRunControllerStopsSubsystemsInOrder(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	ctx, started := withSubsystems(ctx)
+
+	defer started.close()
+
+	pool := &drainingPool{started: started, drainErr: errDrainFailed}
+
+	done := started.trackServer()
+	go func() {
+		<-started.metrics.Done()
+		done()
+	}()
+
+	textfileStopped := false
+	stopTextfile := func() {
+		textfileStopped = started.pool.Err() != nil && started.estimator.Err() != nil
+	}
+
+	cancel()
+
+	err := runController(
+		ctx, logger, &blockingController{mode: "test"}, pool, started, stopTextfile,
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the controller's cancellation, got %v", err)
+	}
+
+	if !pool.metricsAlive || !pool.estimatorAlive {
+		t.Fatal("expected the estimator and metrics to outlive the pool drain")
+	}
+
+	if !textfileStopped {
+		t.Fatal("expected the textfile to be flushed after the pool and estimator stopped")
+	}
+
+	var stages []string
+
+	for _, entry := range observed.All() {
+		if entry.Message == "shutdown stage complete" || entry.Message == "shutdown stage failed" {
+			stages = append(stages, fieldString(entry.Context, "stage"))
+		}
+	}
+
+	want := []string{stageController, stagePool, stageEstimator, stageMetrics}
+	if len(stages) != len(want) {
+		t.Fatalf("expected stages %v, got %v", want, stages)
+	}
+
+	for index := range want {
+		if stages[index] != want[index] {
+			t.Fatalf("expected stages %v, got %v", want, stages)
+		}
+	}
+
+	failed := observed.FilterMessage("shutdown stage failed").All()
+	if len(failed) != 1 || fieldString(failed[0].Context, "stage") != stagePool {
+		t.Fatalf("expected only the pool stage to fail, got %+v", failed)
+	}
+}
+
+func TestStagedEstimatorDrainsAfterController(t *testing.T) {
+	t.Parallel()
+
+	ctx, started := withSubsystems(t.Context())
+	defer started.close()
+
+	supervisor := est.NewSupervisor(func() *est.Sampler {
+		return est.NewSampler(new(countingSource), time.Millisecond)
+	}, time.Millisecond)
+
+	staged, ok := subsystemsFromContext(ctx).estimatorSource(supervisor).(*stagedEstimator)
+	if !ok {
+		t.Fatal("expected a staged estimator with subsystems in the context")
+	}
+
+	err := staged.wait(t.Context())
+	if err != nil {
+		t.Fatalf("expected an unstarted estimator to need no wait, got %v", err)
+	}
+
+	runCtx, stopRun := context.WithCancel(t.Context())
+	observations := staged.Run(runCtx)
+
+	select {
+	case <-observations:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected observations while the controller runs")
+	}
+
+	stopRun()
+
+	waitCtx, cancelWait := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancelWait()
+
+	err = staged.wait(waitCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the estimator to keep running after the controller, got %v", err)
+	}
+
+	started.stopEstimator()
+
+	err = staged.wait(t.Context())
+	if err != nil {
+		t.Fatalf("expected the estimator to stop with its stage, got %v", err)
+	}
+}
+
+func TestSubsystemsWithoutContext(t *testing.T) {
+	t.Parallel()
+
+	started := subsystemsFromContext(t.Context())
+	if started != nil {
+		t.Fatalf("expected no subsystems, got %+v", started)
+	}
+
+	started.trackServer()()
+
+	supervisor := est.NewSupervisor(nil, time.Second)
+	if source := started.estimatorSource(supervisor); source != supervisor {
+		t.Fatalf("expected the supervisor unchanged, got %T", source)
+	}
+}
+
+// drainingPool records which subsystems were still running when it drained.
+type drainingPool struct {
+	stubPoolStarter
+
+	started        *subsystems
+	drainErr       error
+	estimatorAlive bool
+	metricsAlive   bool
+}
+
+func (p *drainingPool) Wait(context.Context) error {
+	p.estimatorAlive = p.started.estimator.Err() == nil
+	p.metricsAlive = p.started.metrics.Err() == nil
+
+	return p.drainErr
+}
+
+type countingSource struct {
+	snapshot est.Snapshot
+}
+
+func (s *countingSource) Snapshot(context.Context) (est.Snapshot, error) {
+	s.snapshot.Idle += 5
+	s.snapshot.Total += 10
+
+	return s.snapshot, nil
+}
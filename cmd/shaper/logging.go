@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/logging"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+// newSlogLogger builds the log.backend=slog logger: the daemon keeps its zap
+// call sites, but records are written by slog's JSON handler on stderr with the
+// keys, level names, sampling, caller, and stack traces of cfg.
+func newSlogLogger(cfg zap.Config) *zap.Logger {
+	handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       zapslog.Level(cfg.Level.Level()),
+		ReplaceAttr: zapslog.ReplaceAttr,
+	})
+
+	core := zapslog.NewCore(handler, cfg.Level)
+	if cfg.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(
+			core,
+			time.Second,
+			cfg.Sampling.Initial,
+			cfg.Sampling.Thereafter,
+		)
+	}
+
+	return zap.New(
+		core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zap.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+}
+
+// loggerReceiver is implemented by library types that accept a logging.Logger:
+// the adaptive controller, the worker pool, and the OCI Monitoring client.
+type loggerReceiver interface {
+	SetLogger(logger logging.Logger)
+}
+
+// withLibraryLogging hands logger to every Monitoring client built from ctx,
+// including clients rebuilt after a metadata change.
+func withLibraryLogging(ctx context.Context, logger *zap.Logger) context.Context {
+	factory := metricsClientFactoryFromContext(ctx)
+	library := zapslog.NewLibraryLogger(logger)
+
+	return withMetricsClientFactory(
+		ctx,
+		func(compartmentID, region string) (oci.MetricsClient, error) {
+			client, err := factory(compartmentID, region)
+			if receiver, ok := client.(loggerReceiver); ok && err == nil {
+				receiver.SetLogger(library)
+			}
+
+			return client, err
+		},
+	)
+}
+
+// configureLibraryLogging routes controller and worker pool diagnostics, such as
+// fallback and suppression transitions, into the daemon log.
+func configureLibraryLogging(logger *zap.Logger, controller adapt.Controller, pool poolStarter) {
+	library := zapslog.NewLibraryLogger(logger)
+
+	for _, component := range []any{controller, pool} {
+		if receiver, ok := component.(loggerReceiver); ok {
+			receiver.SetLogger(library)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"runtime"
 	"slices"
 	"strings"
 	"time"
@@ -17,12 +19,31 @@ import (
 	"go.uber.org/zap"
 	"oci-cpu-shaper/internal/buildinfo"
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+	"oci-cpu-shaper/pkg/canary"
+	"oci-cpu-shaper/pkg/cgroup"
 	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
+	"oci-cpu-shaper/pkg/history"
+	"oci-cpu-shaper/pkg/hooks"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	indexhttp "oci-cpu-shaper/pkg/http/index"
+	listenerhttp "oci-cpu-shaper/pkg/http/listener"
 	metricshttp "oci-cpu-shaper/pkg/http/metrics"
 	statushttp "oci-cpu-shaper/pkg/http/status"
+	"oci-cpu-shaper/pkg/http/webhook"
 	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/logging"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/memtrim"
+	"oci-cpu-shaper/pkg/metadata"
 	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/remoteconfig"
+	"oci-cpu-shaper/pkg/sched"
+	"oci-cpu-shaper/pkg/selftest"
 	"oci-cpu-shaper/pkg/shape"
+	"oci-cpu-shaper/pkg/suppress"
+	"oci-cpu-shaper/pkg/verify"
 )
 
 const (
@@ -36,12 +57,23 @@ const (
 
 	offlineInstanceFallback = "offline-instance"
 
-	exitCodeSuccess      = 0
-	exitCodeRuntimeError = 1
-	exitCodeParseError   = 2
+	exitCodeSuccess          = 0
+	exitCodeRuntimeError     = 1
+	exitCodeParseError       = 2
+	exitCodeOCIAuthError     = 3
+	exitCodeIMDSUnreachable  = 4
+	exitCodePoolStartError   = 5
+	exitCodeMetricsBindError = 6
+	exitCodeRestartRequested = 7
 
 	metricsReadHeaderTimeout = 5 * time.Second
 	metricsShutdownTimeout   = 5 * time.Second
+
+	fallbackCPUWeight = 1
+
+	// staleIntervals is how many sampling intervals a gauge may miss before
+	// the exporter reports it as NaN.
+	staleIntervals = 3
 )
 
 func main() {
@@ -54,7 +86,7 @@ func main() {
 var exitProcess = os.Exit //nolint:gochecknoglobals // replaceable for tests
 
 type runDeps struct {
-	newLogger     func(level string) (*zap.Logger, error)
+	newLogger     func(level, backend string) (*zap.Logger, error)
 	newIMDS       func() imds.Client
 	newController func(
 		ctx context.Context,
@@ -69,19 +101,134 @@ type runDeps struct {
 	startMetricsServer func(
 		ctx context.Context,
 		logger *zap.Logger,
+		network string,
 		addr string,
 		handler http.Handler,
+		tlsConfig *tls.Config,
+		bindFallback string,
 	) error
-	versionWriter io.Writer
+	versionWriter          io.Writer
+	stdout                 io.Writer
+	cgroupRoot             string
+	newDisplayNameResolver func(region string) (displayNameResolver, error)
+	newAlarmManager        func(region string) (alarmManager, error)
+	newSecretReader        func(region string) (secretReader, error)
+	newDynamicGroupReader  func(region string) (dynamicGroupReader, error)
+	newObjectReader        func(region string) (remoteconfig.ObjectReader, error)
+	newStatusWriter        func(region string) (metadata.StatusWriter, error)
+	remoteConfigClient     *http.Client
+}
+
+type dynamicGroupReader interface {
+	DynamicGroupMatchingRule(ctx context.Context, dynamicGroupOCID string) (string, error)
+}
+
+type displayNameResolver interface {
+	InstanceDisplayName(ctx context.Context, instanceOCID string) (string, error)
+}
+
+type resourceIdentifier interface {
+	ResourceID() string
+}
+
+type hookErrorReporter interface {
+	SetHookErrorHandler(handler func(err error))
 }
 
 type poolStarter interface {
-	Start(ctx context.Context)
+	Start(ctx context.Context) error
 	Workers() int
 	Quantum() time.Duration
 	SetWorkerStartErrorHandler(handler func(err error))
 }
 
+type decisionPublisher interface {
+	SetDecisionObserver(observer adapt.DecisionObserver)
+}
+
+type controllerConfigReporter interface {
+	Config() adapt.Config
+}
+
+type estimatorRestarter interface {
+	SetEstimatorRestartHandler(handler func(failures int, lastErr error)) bool
+}
+
+type samplerSupervisor interface {
+	SetSamplerRestartHandler(handler func(reason string)) bool
+}
+
+type windDownPlanner interface {
+	SetWindDown(deadline time.Time, ramp time.Duration)
+}
+
+type observationDropper interface {
+	SetObservationDropHandler(handler func()) bool
+}
+
+type hostCPUSampler interface {
+	SampleHostCPU(ctx context.Context) (est.Observation, error)
+}
+
+type startOutcomeReporter interface {
+	StartOutcome() string
+}
+
+type workerPolicyReporter interface {
+	WorkerPolicies() map[string]int
+}
+
+type workerTimingReporter interface {
+	SleepOvershoot() time.Duration
+	EffectiveQuantum() time.Duration
+}
+
+type workerHeartbeatReporter interface {
+	HeartbeatAge() time.Duration
+	WorkerRestarts() uint64
+}
+
+type idleReporter interface {
+	SetIdleHandler(handler func(status adapt.IdleStatus))
+}
+
+type blackoutReporter interface {
+	SetBlackoutHandler(handler func(active bool))
+}
+
+type burstReporter interface {
+	SetBurstHandler(handler func(status adapt.BurstStatus))
+}
+
+type suppressionEpisodeReporter interface {
+	SetSuppressionEpisodeHandler(handler func(episode adapt.SuppressionEpisode))
+}
+
+type errorReporter interface {
+	SetErrorHandler(handler func(component string, err error))
+}
+
+type clockSkewReporter interface {
+	SetClockSkewHandler(handler func(skew time.Duration)) bool
+}
+
+type tokenExpiryReporter interface {
+	SetTokenExpiryHandler(handler func(expiry time.Time)) bool
+}
+
+type queryWindowReporter interface {
+	SetQueryWindowHandler(handler func(window oci.QueryWindow)) bool
+}
+
+type pauseReporter interface {
+	SetPauseHandler(handler func(gap time.Duration))
+}
+
+type selfLoadExcluder interface {
+	SetSelfLoadMeter(meter adapt.BusyMeter)
+	SetSelfLoadHandler(handler func(share float64))
+}
+
 type metricsClientFactory func(compartmentID, region string) (oci.MetricsClient, error)
 
 type metricsClientFactoryKey struct{}
@@ -116,7 +263,13 @@ var (
 	)
 	errControllerRegionRequired = errors.New("controller factory: OCI region is required")
 	errMetricsDelegateNil       = errors.New("metrics client: nil delegate")
+	errNetworkMetricsMissing    = errors.New("metrics client: network totals unsupported")
 	errMetricsContextRequired   = errors.New("metrics server: context is required")
+
+	errOCIAuthFailed     = errors.New("oci authentication failed")
+	errIMDSUnreachable   = errors.New("imds unreachable")
+	errPoolStartFailed   = errors.New("worker pool start failed")
+	errMetricsBindFailed = errors.New("metrics endpoint bind failed")
 )
 
 func buildMetricsExporter(deps runDeps) *metricshttp.Exporter {
@@ -138,28 +291,668 @@ func configureMetrics(
 	exporter *metricshttp.Exporter,
 	pool poolStarter,
 	controller adapt.Controller,
+	admin http.Handler,
 ) error {
 	if exporter == nil {
 		return nil
 	}
 
+	err := exporter.SetNamespace(cfg.HTTP.MetricsNamespace)
+	if err != nil {
+		return fmt.Errorf("configure metrics exporter: %w", err)
+	}
+
+	err = exporter.SetStaticLabels(cfg.HTTP.MetricsLabels)
+	if err != nil {
+		return fmt.Errorf("configure metrics exporter: %w", err)
+	}
+
+	exporter.SetRuntimeMetricsEnabled(cfg.HTTP.RuntimeMetrics)
+	exporter.SetStaleAfter(
+		metricshttp.MetricOCIP95,
+		staleIntervals*max(cfg.Controller.Interval, cfg.Controller.RelaxedInterval),
+	)
+	exporter.SetStaleAfter(metricshttp.MetricHostCPU, staleIntervals*cfg.Estimator.Interval)
+
 	if pool != nil {
 		exporter.SetWorkerCount(pool.Workers())
 		exporter.SetDutyCycle(pool.Quantum())
 	}
 
+	reportControllerBand(controller, exporter)
+
 	if deps.startMetricsServer == nil {
 		return nil
 	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/metrics", exporter)
+	shared := http.NewServeMux()
 
 	if controller != nil {
-		mux.Handle("/healthz", statushttp.NewHandler(controller))
+		healthz := statushttp.NewHandler(controller)
+		if registry := healthRegistryFromContext(ctx); registry != nil {
+			healthz.SetComponents(registry)
+		}
+
+		shared.Handle("/healthz", healthz)
+	}
+
+	listeners := newListenerHealth()
+	healthRegistryFromContext(ctx).Register(health.ComponentMetrics, listeners.check)
+	ctx = withListenerHealth(ctx, listeners)
+
+	if admin != nil {
+		shared.Handle(adminhttp.Prefix, admin)
+	}
+
+	shared.Handle("/", landingPage(deps, controller, admin))
+
+	network := listenNetwork(cfg.HTTP.Network)
+
+	for index, entry := range metricsListeners(cfg.HTTP) {
+		access := listenerhttp.AccessLog{
+			Logger:   zapslog.NewLibraryLogger(logger),
+			Sample:   cfg.HTTP.AccessLogSample,
+			Listener: entry.Bind,
+		}
+
+		handler, tlsConfig, err := listenerHandler(entry, exporter, shared, access)
+		if err != nil {
+			return fmt.Errorf("%w: http.listeners[%d]: %w", errInvalidHTTPListener, index, err)
+		}
+
+		err = deps.startMetricsServer(
+			ctx, logger, network, entry.Bind, handler, tlsConfig, cfg.HTTP.BindFallback,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
-	return deps.startMetricsServer(ctx, logger, cfg.HTTP.Bind, mux)
+	return nil
+}
+
+// landingPage lists the endpoints the metrics listener serves, with the build,
+// at /.
+func landingPage(
+	deps runDeps,
+	controller adapt.Controller,
+	admin http.Handler,
+) *indexhttp.Handler {
+	info := buildinfo.Current()
+	if deps.currentBuildInfo != nil {
+		info = deps.currentBuildInfo()
+	}
+
+	links := []indexhttp.Link{{Path: "/metrics", Description: "Prometheus metrics"}}
+
+	if controller != nil {
+		links = append(links, indexhttp.Link{
+			Path:        "/healthz",
+			Description: "controller state and component health as JSON",
+		})
+	}
+
+	if admin != nil {
+		links = append(links, indexhttp.Link{
+			Path:        adminhttp.Prefix + "history",
+			Description: "recorded targets and OCI P95 as JSON, subject to admin access",
+		})
+	}
+
+	return indexhttp.NewHandler(indexhttp.Build{
+		Version: info.Version,
+		Commit:  info.GitCommit,
+		Date:    info.BuildDate,
+	}, links)
+}
+
+// reportControllerBand exports the goal band, target bounds, and suppression
+// threshold the controller currently operates with.
+func reportControllerBand(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	reporter, ok := controller.(controllerConfigReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	active := reporter.Config()
+	exporter.SetControllerBand(metricshttp.ControllerBand{
+		GoalLow:           active.GoalLow,
+		GoalHigh:          active.GoalHigh,
+		TargetMin:         active.TargetMin,
+		TargetMax:         active.TargetMax,
+		SuppressThreshold: active.SuppressThreshold,
+	})
+}
+
+// listenerHandler serves the exporter on /metrics behind the listener's
+// credentials and every other route from shared, logs the requests access
+// samples, and loads the listener's TLS configuration, which is nil for
+// plaintext.
+func listenerHandler(
+	entry listenerConfig,
+	exporter http.Handler,
+	shared http.Handler,
+	access listenerhttp.AccessLog,
+) (http.Handler, *tls.Config, error) {
+	var (
+		auth listenerhttp.Auth
+		err  error
+	)
+
+	if entry.Auth.BearerTokenFile != "" {
+		auth.BearerToken, err = listenerhttp.ReadSecret(entry.Auth.BearerTokenFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth.bearerTokenFile: %w", err)
+		}
+	}
+
+	if entry.Auth.Username != "" {
+		auth.Username = entry.Auth.Username
+
+		auth.Password, err = listenerhttp.ReadSecret(entry.Auth.PasswordFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth.passwordFile: %w", err)
+		}
+	}
+
+	tlsConfig, err := entry.TLS.Config()
+	if err != nil {
+		return nil, nil, fmt.Errorf("tls: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", auth.Wrap(exporter))
+	mux.Handle("/", shared)
+
+	return access.Wrap(mux), tlsConfig, nil
+}
+
+// decisionWebhook delivers each decision to webhook.url; drainWebhook waits
+// for its in-flight deliveries at shutdown.
+type decisionWebhook interface {
+	adapt.DecisionObserver
+	WaitTimeout(timeout time.Duration) bool
+}
+
+// drainWebhook waits for in-flight webhook deliveries, including the final
+// decision, for at most one delivery timeout before the process exits.
+func drainWebhook(logger *zap.Logger, notifier decisionWebhook, timeout time.Duration) {
+	if notifier == nil {
+		return
+	}
+
+	if timeout <= 0 {
+		timeout = webhook.DefaultTimeout
+	}
+
+	if !notifier.WaitTimeout(timeout) {
+		logger.Warn(
+			"decision webhook deliveries still pending at shutdown",
+			zap.Duration("timeout", timeout),
+		)
+	}
+}
+
+// configureEstimatorRestartLog logs a single entry each time the estimator
+// recreates its sampling source after consecutive failures.
+func configureEstimatorRestartLog(logger *zap.Logger, controller adapt.Controller) {
+	restarter, ok := controller.(estimatorRestarter)
+	if !ok {
+		return
+	}
+
+	restarter.SetEstimatorRestartHandler(func(failures int, lastErr error) {
+		logger.Info(
+			"estimator source recreated after consecutive sampling failures",
+			zap.Int("failures", failures),
+			zap.Error(lastErr),
+		)
+	})
+}
+
+// configureSamplerSupervision warns and counts each time the estimator
+// supervisor replaces a sampler that closed its channel or fell silent.
+func configureSamplerSupervision(
+	logger *zap.Logger,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) {
+	supervisor, ok := controller.(samplerSupervisor)
+	if !ok {
+		return
+	}
+
+	supervisor.SetSamplerRestartHandler(func(reason string) {
+		logger.Warn("estimator sampler restarted", zap.String("reason", reason))
+
+		if exporter != nil {
+			exporter.ObserveEstimatorRestart(reason)
+		}
+	})
+}
+
+// configureObservationDrops counts the host CPU observations the estimator
+// drops because the controller fell behind.
+func configureObservationDrops(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	dropper, ok := controller.(observationDropper)
+	if !ok || exporter == nil {
+		return
+	}
+
+	dropper.SetObservationDropHandler(exporter.ObserveDroppedObservation)
+}
+
+// configureScrapeSampling lets /metrics scrapes refresh host_cpu_percent with
+// an on-demand sample, at most once per http.scrapeSampleInterval.
+func configureScrapeSampling(
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+	interval time.Duration,
+) {
+	sampler, ok := controller.(hostCPUSampler)
+	if !ok || exporter == nil || interval <= 0 {
+		return
+	}
+
+	exporter.SetScrapeSampler(func(ctx context.Context) (float64, error) {
+		observation, err := sampler.SampleHostCPU(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("scrape sample: %w", err)
+		}
+
+		return observation.Utilisation, nil
+	}, interval)
+}
+
+// configureTextfile writes the series into the node_exporter textfile
+// collector directory http.textfileDir every http.textfileInterval. Write
+// failures only warn, so the directory may appear after startup. The returned
+// func stops the writer and waits for it to remove the file.
+func configureTextfile(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg httpConfig,
+	exporter *metricshttp.Exporter,
+) func() {
+	if exporter == nil || cfg.TextfileDir == "" {
+		return func() {}
+	}
+
+	writer := metricshttp.NewTextfileWriter(exporter, cfg.TextfileDir)
+	writer.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	logger.Info("writing metrics textfile",
+		zap.String("path", writer.Path()),
+		zap.Duration("interval", cfg.TextfileInterval))
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		writer.Run(ctx, cfg.TextfileInterval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// configureWindDown plans the controller's final steps when --shutdown-after
+// bounds the run, so the duty cycle ramps to zero before the deadline instead
+// of stopping mid-cycle.
+func configureWindDown(ctx context.Context, controller adapt.Controller, window time.Duration) {
+	planner, ok := controller.(windDownPlanner)
+	if !ok || window <= 0 {
+		return
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+
+	planner.SetWindDown(deadline, adapt.WindDownRamp(window))
+}
+
+// configureClockSkewLog warns when the local clock drifts from OCI Monitoring
+// far enough for query windows to be shifted, and notes when it recovers.
+func configureClockSkewLog(logger *zap.Logger, controller adapt.Controller) {
+	reporter, ok := controller.(clockSkewReporter)
+	if !ok {
+		return
+	}
+
+	reporter.SetClockSkewHandler(func(skew time.Duration) {
+		if skew == 0 {
+			logger.Info("local clock back in line with oci monitoring")
+
+			return
+		}
+
+		logger.Warn(
+			"local clock skewed from oci monitoring; shifting query window",
+			zap.Duration("skew", skew),
+		)
+	})
+}
+
+// configurePauseLog warns when the controller detects that the process was
+// suspended and discards the state gathered before the pause.
+func configurePauseLog(logger *zap.Logger, controller adapt.Controller) {
+	reporter, ok := controller.(pauseReporter)
+	if !ok {
+		return
+	}
+
+	reporter.SetPauseHandler(func(gap time.Duration) {
+		logger.Warn(
+			"process resumed after a pause; re-baselining controller",
+			zap.Duration("gap", gap),
+		)
+	})
+}
+
+// configureIdleReport exports whether the instance is idle by OCI's definition
+// and logs each change, since the CPU P95 alone does not decide reclamation.
+func configureIdleReport(
+	logger *zap.Logger,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) {
+	reporter, ok := controller.(idleReporter)
+	if !ok {
+		return
+	}
+
+	reporter.SetIdleHandler(func(status adapt.IdleStatus) {
+		if exporter != nil {
+			exporter.SetOCIIdle(status.Idle)
+		}
+
+		fields := []zap.Field{
+			zap.Bool("idle", status.Idle),
+			zap.Float64("cpuP95", status.CPUP95),
+			zap.Bool("networkKnown", status.NetworkKnown),
+		}
+		if status.NetworkKnown {
+			fields = append(fields, zap.Float64("networkRatio", status.NetworkRatio))
+		}
+
+		if status.Idle {
+			logger.Warn("instance idle by oci definition; eligible for reclamation", fields...)
+
+			return
+		}
+
+		logger.Info("instance not idle by oci definition", fields...)
+	})
+}
+
+// configureBlackoutReport exports whether a controller.blackout window holds
+// the workers idle; the controller logs each window itself.
+func configureBlackoutReport(
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+	windows []adapt.BlackoutWindow,
+) {
+	reporter, ok := controller.(blackoutReporter)
+	if !ok || exporter == nil || len(windows) == 0 {
+		return
+	}
+
+	exporter.SetBlackout(false)
+	reporter.SetBlackoutHandler(exporter.SetBlackout)
+}
+
+// configureBurstReport exports the burst credit estimate of burstable shapes;
+// the controller itself warns when its target will exhaust the credits.
+func configureBurstReport(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	reporter, ok := controller.(burstReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	reporter.SetBurstHandler(func(status adapt.BurstStatus) {
+		exporter.SetBurstCredits(metricshttp.BurstCredits{
+			Credits:    status.Credits,
+			Draining:   status.Draining,
+			ThrottleIn: status.ThrottleIn,
+		})
+	})
+}
+
+// configureSuppressionEpisodeReport exports the count, duration, and
+// duty-cycle deficit of every suppression episode that ends.
+func configureSuppressionEpisodeReport(
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) {
+	reporter, ok := controller.(suppressionEpisodeReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	reporter.SetSuppressionEpisodeHandler(func(episode adapt.SuppressionEpisode) {
+		exporter.ObserveSuppressionEpisode(episode.Duration(episode.End), episode.Deficit)
+	})
+}
+
+// configureErrorReport exports the latest OCI query and host CPU observation
+// error with its code, so alerts can tell a persistent authentication failure
+// from a single transient 503.
+func configureErrorReport(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	reporter, ok := controller.(errorReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	reporter.SetErrorHandler(func(component string, err error) {
+		exporter.ObserveError(component, oci.ErrorCode(err), time.Now())
+	})
+}
+
+// configureTokenExpiryReport exports when the instance principal security
+// token expires, which the Monitoring client renews ahead of each query.
+func configureTokenExpiryReport(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	reporter, ok := controller.(tokenExpiryReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	reporter.SetTokenExpiryHandler(exporter.SetTokenExpiry)
+}
+
+// configureQueryWindowReport exports the window of the latest Monitoring query,
+// so the seven-day truncation can be checked against the alarm's window(7d).
+func configureQueryWindowReport(controller adapt.Controller, exporter *metricshttp.Exporter) {
+	reporter, ok := controller.(queryWindowReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	reporter.SetQueryWindowHandler(func(window oci.QueryWindow) {
+		exporter.SetQueryWindow(window.Start, window.End)
+	})
+}
+
+// configureSelfLoadExclusion lets the controller discount the worker pool's own
+// busy time from host utilisation, so the shaper does not suppress itself, and
+// exports the share it subtracts.
+func configureSelfLoadExclusion(
+	controller adapt.Controller,
+	pool poolStarter,
+	exporter *metricshttp.Exporter,
+) {
+	excluder, ok := controller.(selfLoadExcluder)
+	if !ok {
+		return
+	}
+
+	meter, ok := pool.(adapt.BusyMeter)
+	if !ok {
+		return
+	}
+
+	excluder.SetSelfLoadMeter(meter)
+
+	if exporter != nil {
+		excluder.SetSelfLoadHandler(exporter.SetSelfCPU)
+	}
+}
+
+// reportWorkerTiming exports how far the workers' idle sleeps overrun, which
+// shows whether the host coalesces timers, and the duty cycle they stretch
+// low targets to.
+func reportWorkerTiming(pool poolStarter, exporter *metricshttp.Exporter) {
+	reporter, ok := pool.(workerTimingReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	exporter.SetSleepOvershootSource(reporter.SleepOvershoot)
+	exporter.SetEffectiveQuantumSource(reporter.EffectiveQuantum)
+}
+
+// reportWorkerHeartbeats exports how long the least recently active worker has
+// gone without completing a cycle and how many stalled workers were replaced.
+func reportWorkerHeartbeats(pool poolStarter, exporter *metricshttp.Exporter) {
+	reporter, ok := pool.(workerHeartbeatReporter)
+	if !ok || exporter == nil {
+		return
+	}
+
+	exporter.SetHeartbeatAgeSource(reporter.HeartbeatAge)
+	exporter.SetWorkerRestartsSource(reporter.WorkerRestarts)
+}
+
+// lowerCgroupWeight drops the shaper's own cgroup to the minimum CPU weight when
+// workers fall back from SCHED_IDLE to nice 19.
+//
+//nolint:gochecknoglobals // test seam for the cgroup filesystem.
+var lowerCgroupWeight = func() error {
+	manager, err := cgroup.OpenSelf(cgroup.DefaultRoot)
+	if err != nil {
+		return fmt.Errorf("open cgroup: %w", err)
+	}
+
+	return manager.SetCPUWeight(fallbackCPUWeight) //nolint:wrapcheck // already descriptive
+}
+
+// probeScheduling reports which scheduling calls the host permits, for doctor
+// and for explaining a pool that started without SCHED_IDLE.
+//
+//nolint:gochecknoglobals // test seam for the scheduling syscalls.
+var probeScheduling = sched.Probe
+
+// reportPoolStartOutcome exports how the worker pool handled SCHED_IDLE at start
+// and completes the fallback policy by lowering the cgroup CPU weight.
+func reportPoolStartOutcome(
+	logger *zap.Logger,
+	pool poolStarter,
+	exporter *metricshttp.Exporter,
+) {
+	reporter, ok := pool.(startOutcomeReporter)
+	if !ok {
+		return
+	}
+
+	outcome := reporter.StartOutcome()
+
+	var policies map[string]int
+	if policyReporter, ok := pool.(workerPolicyReporter); ok {
+		policies = policyReporter.WorkerPolicies()
+	}
+
+	if exporter != nil {
+		exporter.SetPoolStartOutcome(outcome)
+		exporter.SetWorkerPolicies(policies)
+	}
+
+	if outcome == shape.StartOutcomeOK || outcome == "" {
+		return
+	}
+
+	caps := probeScheduling()
+
+	logger.Warn(
+		"worker pool started without sched_idle",
+		zap.String("policy", outcome),
+		zap.Any("workerPolicies", policies),
+		zap.String("platform", caps.Platform),
+		zap.Stringer("schedIdle", caps.SchedIdle),
+		zap.Bool("capSysNice", caps.CapSysNice),
+		zap.String("seccomp", caps.Seccomp),
+		zap.String("hint", caps.Hint()),
+	)
+
+	if outcome != string(shape.StartFailureFallback) {
+		return
+	}
+
+	err := lowerCgroupWeight()
+	if err != nil {
+		logger.Warn("failed to lower cgroup cpu weight", zap.Error(err))
+	}
+}
+
+// enrichInstanceIdentity resolves the instance display name through the Compute API
+// when enabled, exposing it on /metrics and returning a logger annotated with it.
+// Lookup failures are logged and leave the OCID as the only identifier.
+func enrichInstanceIdentity(
+	ctx context.Context,
+	deps runDeps,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) *zap.Logger {
+	if !cfg.OCI.DisplayName || cfg.OCI.Offline || deps.newDisplayNameResolver == nil {
+		return logger
+	}
+
+	identifier, ok := controller.(resourceIdentifier)
+	if !ok {
+		return logger
+	}
+
+	instanceID := strings.TrimSpace(identifier.ResourceID())
+	if instanceID == "" {
+		return logger
+	}
+
+	displayName := ""
+
+	resolver, err := deps.newDisplayNameResolver(cfg.OCI.Region)
+	if err == nil {
+		displayName, err = resolver.InstanceDisplayName(ctx, instanceID)
+	}
+
+	if err != nil {
+		logger.Warn("failed to resolve instance display name", zap.Error(err))
+	}
+
+	if exporter != nil {
+		exporter.SetInstanceInfo(instanceID, displayName)
+	}
+
+	if displayName == "" {
+		return logger
+	}
+
+	return logger.With(zap.String("displayName", displayName))
+}
+
+//nolint:ireturn // factory returns interface so tests can substitute resolvers.
+func newInstancePrincipalDisplayNameResolver(region string) (displayNameResolver, error) {
+	client, err := oci.NewInstancePrincipalComputeClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build compute client: %w", err)
+	}
+
+	return client, nil
 }
 
 // run orchestrates CLI initialization before handing execution to the controller.
@@ -186,62 +979,234 @@ func run(
 
 		_, _ = fmt.Fprintf(writer, "%+v\n", info)
 
-		return exitCodeSuccess
+		return exitCodeSuccess
+	}
+
+	if opts.runDoctor {
+		return runDoctor(deps)
+	}
+
+	if opts.runSelfTest {
+		return runSelfTest(ctx, deps, selftest.Options{}) //nolint:exhaustruct // library defaults
+	}
+
+	if opts.alarmArgs != nil {
+		return runAlarm(ctx, deps, opts, stderr)
+	}
+
+	if opts.statusArgs != nil {
+		return runStatus(ctx, deps, opts, stderr)
+	}
+
+	configPath, remoteConfig, exitCode, configFetched := resolveConfigPathOrExit(
+		ctx,
+		deps,
+		opts,
+		stderr,
+	)
+	if !configFetched {
+		return exitCode
+	}
+
+	cfg, exitCode, configLoaded := loadRuntimeConfigOrExit(deps, configPath, stderr)
+	if !configLoaded {
+		return exitCode
+	}
+
+	logger, exitCode, loggerReady := buildLoggerOrExit(deps, opts.logLevel, cfg.Log, stderr)
+	if !loggerReady {
+		return exitCode
+	}
+
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	ctx, cancel := applyShutdownTimer(ctx, opts.shutdownAfter)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// A changed remote configuration stops the run so the supervisor restarts
+	// the daemon with it.
+	ctx, restart := context.WithCancelCause(ctx)
+	defer restart(nil)
+
+	runCtx := ctx
+
+	info := deps.currentBuildInfo()
+	logStartup(logger, info, opts)
+	logIgnoredEnv(logger, cfg.IgnoredEnv)
+
+	runAs, dropRequested, err := resolveRunAs(cfg.RunAs)
+	if err != nil {
+		logger.Error("failed to resolve runAs identity", zap.Error(err))
+
+		return exitCodeParseError
+	}
+
+	configureRemoteConfigRefresh(ctx, logger, deps, remoteConfig, opts.configRefresh, restart)
+
+	imdsClient := deps.newIMDS()
+
+	metricsExporter := buildMetricsExporter(deps)
+	ctx = withLibraryLogging(ctx, logger)
+	ctx = withQueryScope(ctx, cfg.OCI.queryScope())
+	ctx = withHealthRegistry(ctx, newHealthRegistry(cfg.Health))
+	ctx, imdsClient = configureAPIBudget(ctx, logger, cfg, imdsClient, metricsExporter)
+
+	monitoring := metadata.NewMonitoringClients(
+		metadata.ClientFactory(metricsClientFactoryFromContext(ctx)),
+	)
+	ctx = withMetricsClientFactory(ctx, monitoring.Build)
+
+	var rollout *canary.Rollout
+
+	ctx, opts.mode, rollout = configureCanary(ctx, logger, cfg, opts.mode, metricsExporter)
+
+	cfg = reconcileRegion(ctx, logger, cfg, imdsClient, opts.mode)
+
+	cfg, _, metadataErr := prepareRunMetadata(ctx, cfg, imdsClient, opts.mode)
+	if metadataErr != nil {
+		logger.Error("failed to resolve oci metadata", zap.Error(metadataErr))
+
+		return exitCodeForRunError(metadataErr)
+	}
+
+	historyOpts, err := historyEncryptionOptions(ctx, deps, cfg)
+	if err != nil {
+		logger.Error("failed to load history encryption key", zap.Error(err))
+
+		return exitCodeRuntimeError
+	}
+
+	historyStore, err := history.Open(cfg.History.Path, historyOpts...)
+	if err != nil {
+		logger.Error("failed to open history store", zap.Error(err))
+
+		return exitCodeRuntimeError
+	}
+
+	historyStore.SetErrorHandler(func(err error) {
+		logger.Warn("history record not persisted", zap.Error(err))
+	})
+
+	defer func() {
+		_ = historyStore.Close()
+	}()
+
+	// State transitions are logged so shaperctl verify can check them.
+	recorder := verify.NewTransitionLogger(
+		zapslog.NewLibraryLogger(logger),
+		history.NewRecorder(metricsExporter, historyStore),
+	)
+
+	// The pool, the estimator, and the metrics servers outlive ctx and are
+	// stopped in order after the controller.
+	ctx, started := withSubsystems(ctx)
+	defer started.close()
+
+	controller, pool, buildErr := deps.newController(ctx, opts.mode, cfg, imdsClient, recorder)
+	if buildErr != nil {
+		code := exitCodeForRunError(buildErr)
+
+		logger.Error("failed to build controller", zap.Error(buildErr))
+
+		return code
+	}
+
+	if rollout != nil {
+		go rollout.Run(ctx, controller)
+	}
+
+	registerControllerHealth(healthRegistryFromContext(ctx), opts.mode, cfg, controller, pool)
+
+	configureSuppression(ctx, cfg, controller)
+
+	admin, err := configureAdminAPI(
+		ctx, deps, logger, cfg, controller, metricsExporter, historyStore,
+	)
+	if err != nil {
+		logger.Error("failed to configure admin api", zap.Error(err))
+
+		return exitCodeForConfigError(err)
+	}
+
+	err = configureMetrics(
+		started.metrics, deps, logger, cfg, metricsExporter, pool, controller, admin,
+	)
+	if err != nil {
+		logger.Error("failed to start metrics server", zap.Error(err))
+
+		return exitCodeForRunError(err)
 	}
 
-	cfg, exitCode, configLoaded := loadRuntimeConfigOrExit(deps, opts.configPath, stderr)
-	if !configLoaded {
-		return exitCode
-	}
+	stopTextfile := configureTextfile(started.metrics, logger, cfg.HTTP, metricsExporter)
+	defer stopTextfile()
 
-	logger, exitCode, loggerReady := buildLoggerOrExit(deps, opts.logLevel, stderr)
-	if !loggerReady {
-		return exitCode
-	}
+	logger = enrichInstanceIdentity(ctx, deps, logger, cfg, controller, metricsExporter)
 
-	defer func() {
-		_ = logger.Sync()
-	}()
+	notifier, err := configureWebhook(logger, cfg, controller)
+	if err != nil {
+		logger.Error("failed to configure decision webhook", zap.Error(err))
 
-	ctx, cancel := applyShutdownTimer(ctx, opts.shutdownAfter)
-	if cancel != nil {
-		defer cancel()
+		return exitCodeParseError
 	}
 
-	info := deps.currentBuildInfo()
-	logStartup(logger, info, opts)
+	stopAudit := configureAudit(logger, cfg.Audit, info, opts.mode, controller, notifier)
 
-	imdsClient := deps.newIMDS()
+	err = configureMemory(ctx, logger, cfg.Memory, metricsExporter)
+	if err != nil {
+		logger.Error("failed to configure memory trimming", zap.Error(err))
 
-	metricsExporter := buildMetricsExporter(deps)
+		return exitCodeParseError
+	}
 
-	cfg, _, metadataErr := prepareRunMetadata(ctx, cfg, imdsClient, opts.mode)
-	if metadataErr != nil {
-		logger.Error("failed to resolve oci metadata", zap.Error(metadataErr))
+	err = configureUpdateCheck(ctx, logger, cfg, info, metricsExporter)
+	if err != nil {
+		logger.Error("failed to configure update check", zap.Error(err))
 
-		return exitCodeRuntimeError
+		return exitCodeParseError
 	}
 
-	controller, pool, buildErr := deps.newController(
+	configureEstimatorRestartLog(logger, controller)
+	configureSamplerSupervision(logger, controller, metricsExporter)
+	configureObservationDrops(controller, metricsExporter)
+	configureScrapeSampling(controller, metricsExporter, cfg.HTTP.ScrapeSampleInterval)
+	configureWindDown(ctx, controller, opts.shutdownAfter)
+	configureClockSkewLog(logger, controller)
+	configurePauseLog(logger, controller)
+	configureLibraryLogging(logger, controller, pool)
+	configureIdleReport(logger, controller, metricsExporter)
+	configureBurstReport(controller, metricsExporter)
+	configureSuppressionEpisodeReport(controller, metricsExporter)
+	configureErrorReport(controller, metricsExporter)
+	configureTokenExpiryReport(controller, metricsExporter)
+	configureQueryWindowReport(controller, metricsExporter)
+	configureBlackoutReport(controller, metricsExporter, cfg.Controller.Blackout)
+	configureSelfLoadExclusion(controller, pool, metricsExporter)
+
+	stopSuppressLearning := configureSuppressLearning(
 		ctx,
-		opts.mode,
-		cfg,
-		imdsClient,
+		logger,
+		cfg.Controller,
+		controller,
 		metricsExporter,
 	)
-	if buildErr != nil {
-		code := exitCodeForConfigError(buildErr)
 
-		logger.Error("failed to build controller", zap.Error(buildErr))
-
-		return code
-	}
-
-	err = configureMetrics(ctx, deps, logger, cfg, metricsExporter, pool, controller)
-	if err != nil {
-		logger.Error("failed to start metrics server", zap.Error(err))
-
-		return exitCodeRuntimeError
+	if strings.TrimSpace(opts.mode) != modeNoop {
+		configureMetadataWatch(ctx, logger, cfg, imdsClient, metricsExporter, monitoring)
+		configureMaintenanceWatch(ctx, logger, cfg, imdsClient, controller)
+		configureAlarmSilenceWatch(
+			ctx,
+			logger,
+			deps,
+			cfg,
+			imdsClient,
+			controller,
+			metricsExporter,
+		)
+		configureStatusMetadata(ctx, logger, deps, cfg, controller)
 	}
 
 	if pool != nil {
@@ -253,7 +1218,40 @@ func run(
 			logger.Warn("worker failed to enter sched_idle", zap.Error(err))
 		})
 
-		pool.Start(ctx)
+		if reporter, ok := pool.(hookErrorReporter); ok {
+			reporter.SetHookErrorHandler(func(err error) {
+				logger.Warn("target hook failed", zap.Error(err))
+			})
+		}
+
+		err = pool.Start(started.pool)
+		if err != nil {
+			logger.Error("failed to start worker pool", zap.Error(err))
+
+			return exitCodeForRunError(fmt.Errorf("%w: %w", errPoolStartFailed, err))
+		}
+
+		reportPoolStartOutcome(logger, pool, metricsExporter)
+		reportWorkerTiming(pool, metricsExporter)
+		reportWorkerHeartbeats(pool, metricsExporter)
+	}
+
+	err = switchToRunAs(logger, runAs, dropRequested)
+	if err != nil {
+		logger.Error("failed to drop privileges", zap.Error(err))
+
+		return exitCodeRuntimeError
+	}
+
+	if pool != nil {
+		calibratePool(
+			ctx,
+			logger,
+			pool,
+			est.FileSource{Path: ""},
+			cfg.Pool.Calibration,
+			metricsExporter,
+		)
 	}
 
 	logIMDSMetadata(
@@ -267,7 +1265,36 @@ func run(
 		cfg.OCI.Offline,
 	)
 
-	return handleControllerRunResult(logger, controller.Run(ctx))
+	code := handleControllerRunResult(
+		logger,
+		runController(ctx, logger, controller, pool, started, stopTextfile),
+	)
+
+	stopSuppressLearning()
+	drainWebhook(logger, notifier, cfg.Webhook.Timeout)
+	stopAudit(code)
+
+	if code == exitCodeSuccess {
+		reportShutdownSummary(logger, controller, opts.summaryFile)
+	}
+
+	return exitCodeForRestart(runCtx, code)
+}
+
+// configureSuppression starts the signal-file watcher when suppress.file is
+// set and the controller accepts external suppression requests.
+func configureSuppression(ctx context.Context, cfg runtimeConfig, controller adapt.Controller) {
+	requester, ok := controller.(suppress.Requester)
+	if !ok {
+		return
+	}
+
+	path := strings.TrimSpace(cfg.Suppress.File)
+	if path == "" {
+		return
+	}
+
+	go suppress.NewFileWatcher(path, cfg.Suppress.FileDuration, requester).Run(ctx)
 }
 
 func handleControllerRunResult(logger *zap.Logger, runErr error) int {
@@ -294,8 +1321,37 @@ func handleControllerRunResult(logger *zap.Logger, runErr error) int {
 	}
 }
 
+// exitCodeForRunError maps typed startup failures onto dedicated exit codes so
+// supervisors can distinguish credential, metadata, worker, and listener
+// problems from generic runtime errors.
+func exitCodeForRunError(err error) int {
+	switch {
+	case errors.Is(err, errOCIAuthFailed):
+		return exitCodeOCIAuthError
+	case errors.Is(err, errIMDSUnreachable):
+		return exitCodeIMDSUnreachable
+	case errors.Is(err, errPoolStartFailed):
+		return exitCodePoolStartError
+	case errors.Is(err, errMetricsBindFailed):
+		return exitCodeMetricsBindError
+	default:
+		return exitCodeForConfigError(err)
+	}
+}
+
 func exitCodeForConfigError(err error) int {
-	if errors.Is(err, adapt.ErrInvalidConfig) {
+	if errors.Is(err, adapt.ErrInvalidConfig) || errors.Is(err, errInvalidHTTPNetwork) ||
+		errors.Is(err, errInvalidStartFailurePolicy) || errors.Is(err, errHistoryKeyConflict) ||
+		errors.Is(err, errInvalidAdminAuth) || errors.Is(err, remoteconfig.ErrInvalidURL) ||
+		errors.Is(err, remoteconfig.ErrCacheUnset) || errors.Is(err, errInvalidAlarm) ||
+		errors.Is(err, errInvalidLogBackend) || errors.Is(err, metricshttp.ErrInvalidNamespace) ||
+		errors.Is(err, metricshttp.ErrInvalidStaticLabel) ||
+		errors.Is(err, errInvalidHTTPListener) || errors.Is(err, listenerhttp.ErrUnknownFallback) ||
+		errors.Is(err, health.ErrUnknownComponent) || errors.Is(err, audit.ErrInvalidRecords) ||
+		errors.Is(err, errInvalidSuppress) || errors.Is(err, errInvalidEnv) ||
+		errors.Is(err, oci.ErrInvalidQueryScope) || errors.Is(err, listenerhttp.ErrInvalidSample) ||
+		errors.Is(err, shape.ErrInvalidMissedQuanta) || errors.Is(err, memtrim.ErrInvalidConfig) ||
+		errors.Is(err, errMinimalBuild) {
 		return exitCodeParseError
 	}
 
@@ -315,7 +1371,7 @@ func writeError(dst io.Writer, err error, code int) int {
 	return code
 }
 
-func newLogger(level string) (*zap.Logger, error) {
+func newLogger(level, backend string) (*zap.Logger, error) {
 	if level == "" {
 		level = defaultLogLevel
 	}
@@ -332,6 +1388,14 @@ func newLogger(level string) (*zap.Logger, error) {
 	cfg.EncoderConfig.LevelKey = "level"
 	cfg.EncoderConfig.CallerKey = "caller"
 
+	switch backend {
+	case "", logBackendZap:
+	case logBackendSlog:
+		return newSlogLogger(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w %q", errInvalidLogBackend, backend)
+	}
+
 	logger, err := cfg.Build()
 	if err != nil {
 		return nil, fmt.Errorf("build zap logger: %w", err)
@@ -342,10 +1406,17 @@ func newLogger(level string) (*zap.Logger, error) {
 
 type options struct {
 	configPath    string
+	configCache   string
+	configRefresh time.Duration
 	logLevel      string
 	mode          string
 	shutdownAfter time.Duration
+	summaryFile   string
 	showVersion   bool
+	runDoctor     bool
+	runSelfTest   bool
+	alarmArgs     []string
+	statusArgs    []string
 }
 
 func parseArgs(args []string) (options, error) {
@@ -359,11 +1430,29 @@ func parseArgs(args []string) (options, error) {
 		false,
 		"Print build information and exit",
 	)
+	flagSet.BoolVar(
+		&opts.runSelfTest,
+		"self-test",
+		false,
+		"Run one control cycle against in-process fake OCI services and exit",
+	)
 	flagSet.StringVar(
 		&opts.configPath,
 		"config",
 		defaultConfigPath,
-		"Path to the shaper configuration file",
+		"Path or https:// / os://<namespace>/<bucket>/<object> URL of the configuration file",
+	)
+	flagSet.StringVar(
+		&opts.configCache,
+		"config-cache",
+		remoteconfig.DefaultCachePath,
+		"Local copy of a remote --config, used when the remote is unreachable",
+	)
+	flagSet.DurationVar(
+		&opts.configRefresh,
+		"config-refresh",
+		remoteconfig.DefaultRefreshInterval,
+		"How often a remote --config is re-fetched (0 disables)",
 	)
 	flagSet.StringVar(
 		&opts.logLevel,
@@ -383,6 +1472,12 @@ func parseArgs(args []string) (options, error) {
 		0,
 		"Gracefully stop the controller after the provided duration (0 disables the timer)",
 	)
+	flagSet.StringVar(
+		&opts.summaryFile,
+		"summary-file",
+		"",
+		"Also write the shutdown summary to this file as JSON",
+	)
 
 	err := flagSet.Parse(args)
 	if err != nil {
@@ -399,6 +1494,32 @@ func parseArgs(args []string) (options, error) {
 		return opts, nil
 	}
 
+	if slices.Contains(flagSet.Args(), "doctor") {
+		opts.runDoctor = true
+
+		return opts, nil
+	}
+
+	if rest := flagSet.Args(); len(rest) > 0 && rest[0] == "self-test" {
+		opts.runSelfTest = true
+	}
+
+	if opts.runSelfTest {
+		return opts, nil
+	}
+
+	if rest := flagSet.Args(); len(rest) > 0 && rest[0] == "alarm" {
+		opts.alarmArgs = append([]string{}, rest[1:]...)
+
+		return opts, nil
+	}
+
+	if rest := flagSet.Args(); len(rest) > 0 && rest[0] == "status" {
+		opts.statusArgs = append([]string{}, rest[1:]...)
+
+		return opts, nil
+	}
+
 	normErr := normalizeOptions(&opts)
 	if normErr != nil {
 		return options{}, normErr
@@ -442,6 +1563,10 @@ func normalizeOptions(opts *options) error {
 		return fmt.Errorf("%w: %v", errInvalidShutdownAfter, opts.shutdownAfter)
 	}
 
+	if opts.configRefresh < 0 {
+		return fmt.Errorf("%w: %v", errInvalidConfigRefresh, opts.configRefresh)
+	}
+
 	return nil
 }
 
@@ -471,9 +1596,10 @@ func loadRuntimeConfigOrExit(
 func buildLoggerOrExit(
 	deps runDeps,
 	level string,
+	logCfg logConfig,
 	stderr io.Writer,
 ) (*zap.Logger, int, bool) {
-	logger, loggerErr := deps.newLogger(level)
+	logger, loggerErr := deps.newLogger(level, logCfg.Backend)
 	if loggerErr != nil {
 		exitCode := writeError(
 			stderr,
@@ -553,43 +1679,132 @@ func buildAdaptiveController(
 		return nil, nil, err
 	}
 
-	pool, err := shape.NewPool(cfg.Pool.Workers, cfg.Pool.Quantum)
+	if cfg.Controller.OCPUSeconds.enabled() {
+		err = resolveOCPUSecondsTargets(ctx, &cfg.Controller, imdsClient)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Without the shape the worker count falls back to the host CPU count, the
+	// idle status follows the CPU P95 alone, and no burst credits are estimated.
+	var shapeCfg imds.ShapeConfig
+
+	if !offline && imdsClient != nil {
+		fetched, shapeErr := imdsClient.ShapeConfig(ctx)
+		if shapeErr == nil {
+			shapeCfg = fetched
+		}
+	}
+
+	pool, err := shape.NewPool(poolWorkers(cfg.Pool.Workers, shapeCfg), cfg.Pool.Quantum)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build worker pool: %w: %w", errPoolStartFailed, err)
+	}
+
+	pool.SetStartFailurePolicy(cfg.Pool.StartFailurePolicy)
+	// Per-OCPU workers leave the SMT siblings idle, so their duty cycle is
+	// scaled to keep the target a share of the whole host.
+	pool.SetHostRelativeTarget(cfg.Pool.Workers <= 0)
+
+	err = pool.SetRestartAfterMissedQuanta(cfg.Pool.RestartAfterMissedQuanta)
 	if err != nil {
 		return nil, nil, fmt.Errorf("build worker pool: %w", err)
 	}
 
-	sampler := est.NewSampler(nil, cfg.Estimator.Interval)
+	estimator := est.NewSupervisor(func() *est.Sampler {
+		sampler := est.NewSampler(nil, cfg.Estimator.Interval)
+		sampler.SetRestartThreshold(cfg.Estimator.RestartAfter)
+
+		return sampler
+	}, cfg.Estimator.Interval)
+	estimator.SetBuffer(cfg.Estimator.Buffer)
 
-	controllerCfg := adapt.Config{
-		ResourceID:        instanceID,
-		Mode:              mode,
-		TargetStart:       cfg.Controller.TargetStart,
-		TargetMin:         cfg.Controller.TargetMin,
-		TargetMax:         cfg.Controller.TargetMax,
-		StepUp:            cfg.Controller.StepUp,
-		StepDown:          cfg.Controller.StepDown,
-		FallbackTarget:    cfg.Controller.FallbackTarget,
-		GoalLow:           cfg.Controller.GoalLow,
-		GoalHigh:          cfg.Controller.GoalHigh,
-		Interval:          cfg.Controller.Interval,
-		RelaxedInterval:   cfg.Controller.RelaxedInterval,
-		RelaxedThreshold:  cfg.Controller.RelaxedThreshold,
-		SuppressThreshold: cfg.Controller.SuppressThreshold,
-		SuppressResume:    cfg.Controller.SuppressResume,
+	source := subsystemsFromContext(ctx).estimatorSource(estimator)
+
+	controllerCfg := runtimeToAdaptControllerConfig(cfg)
+	controllerCfg.ResourceID = instanceID
+	controllerCfg.Mode = mode
+	controllerCfg.NetworkBandwidthGbps = shapeCfg.NetworkingBandwidthInGbps
+	controllerCfg.BurstBaseline, _ = shapeCfg.BaselineFraction()
+
+	var (
+		actuator adapt.DutyCycler = pool
+		starter  poolStarter      = pool
+	)
+
+	if cfg.Hooks.PreApply.enabled() || cfg.Hooks.PostApply.enabled() {
+		hooked := hooks.NewActuator(pool, cfg.Hooks.PreApply.hook(), cfg.Hooks.PostApply.hook())
+		actuator = hooked
+		starter = hookedPool{Pool: pool, actuator: hooked}
+	}
+
+	if gate := canaryGateFromContext(ctx); gate != nil {
+		actuator = gate.Wrap(actuator)
 	}
 
 	controller, err := adapt.NewAdaptiveController(
 		controllerCfg,
 		metricsClient,
-		sampler,
-		pool,
+		source,
+		actuator,
 		recorder,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("build adaptive controller: %w", err)
 	}
 
-	return controller, pool, nil
+	return controller, starter, nil
+}
+
+// poolWorkers returns the configured worker count or, when pool.workers is
+// unset, one worker per OCPU of the shape, falling back to the host CPU count
+// when IMDS did not report the shape.
+func poolWorkers(configured int, shapeCfg imds.ShapeConfig) int {
+	if configured > 0 {
+		return configured
+	}
+
+	hostCPUs := max(runtime.NumCPU(), 1)
+
+	workers := shape.WorkersForShape(shapeCfg.OCPUs, shapeCfg.ThreadsPerCore, hostCPUs)
+	if workers == 0 {
+		return hostCPUs
+	}
+
+	return workers
+}
+
+// resolveOCPUSecondsTargets converts OCPU-seconds-per-hour targets into ratios
+// using the OCPU count reported by IMDS for the running shape.
+func resolveOCPUSecondsTargets(
+	ctx context.Context,
+	cfg *controllerConfig,
+	imdsClient imds.Client,
+) error {
+	shapeCfg, err := imdsClient.ShapeConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve ocpu count: %w: %w", errIMDSUnreachable, err)
+	}
+
+	err = cfg.OCPUSeconds.apply(cfg, shapeCfg.OCPUs)
+	if err != nil {
+		return fmt.Errorf("convert ocpu-seconds targets: %w: %w", adapt.ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// hookedPool pairs the worker pool with its hook-wrapped actuator so run can
+// log hook failures alongside worker start errors.
+type hookedPool struct {
+	*shape.Pool
+
+	actuator *hooks.Actuator
+}
+
+func (p hookedPool) SetHookErrorHandler(handler func(err error)) {
+	p.actuator.SetErrorHandler(handler)
 }
 
 func resolveInstanceID(
@@ -609,7 +1824,7 @@ func resolveInstanceID(
 
 	fetchedID, err := imdsClient.InstanceID(ctx)
 	if err != nil {
-		return "", fmt.Errorf("lookup instance ocid: %w", err)
+		return "", fmt.Errorf("lookup instance ocid: %w: %w", errIMDSUnreachable, err)
 	}
 
 	return strings.TrimSpace(fetchedID), nil
@@ -641,7 +1856,11 @@ func resolveCompartmentAndRegion(
 	if metadata.CompartmentID == "" {
 		compartmentID, err := imdsClient.CompartmentID(ctx)
 		if err != nil {
-			return ociMetadata{}, fmt.Errorf("lookup compartment ocid: %w", err)
+			return ociMetadata{}, fmt.Errorf(
+				"lookup compartment ocid: %w: %w",
+				errIMDSUnreachable,
+				err,
+			)
 		}
 
 		metadata.CompartmentID = strings.TrimSpace(compartmentID)
@@ -650,7 +1869,11 @@ func resolveCompartmentAndRegion(
 	if metadata.Region == "" {
 		region, err := imdsClient.Region(ctx)
 		if err != nil {
-			return ociMetadata{}, fmt.Errorf("lookup instance region: %w", err)
+			return ociMetadata{}, fmt.Errorf(
+				"lookup instance region: %w: %w",
+				errIMDSUnreachable,
+				err,
+			)
 		}
 
 		metadata.Region = strings.TrimSpace(region)
@@ -709,6 +1932,17 @@ func applyShutdownTimer(
 	return newCtx, cancel
 }
 
+// logIgnoredEnv warns about each environment override that did not parse and
+// left the file or default value in place.
+func logIgnoredEnv(logger *zap.Logger, ignored []string) {
+	for _, override := range ignored {
+		logger.Warn(
+			"ignoring malformed environment override; set SHAPER_STRICT_ENV to fail instead",
+			zap.String("override", override),
+		)
+	}
+}
+
 func logStartup(logger *zap.Logger, info buildinfo.Info, opts options) {
 	fields := []zap.Field{
 		zap.String("version", info.Version),
@@ -725,6 +1959,38 @@ func logStartup(logger *zap.Logger, info buildinfo.Info, opts options) {
 	logger.Info("starting oci-cpu-shaper", fields...)
 }
 
+// withQueryScope narrows the queries of every Monitoring client built from ctx,
+// including clients rebuilt after a metadata change, to scope.
+func withQueryScope(ctx context.Context, scope oci.QueryScope) context.Context {
+	if scope.ResourceGroup == "" && len(scope.Dimensions) == 0 {
+		return ctx
+	}
+
+	factory := metricsClientFactoryFromContext(ctx)
+
+	return withMetricsClientFactory(
+		ctx,
+		func(compartmentID, region string) (oci.MetricsClient, error) {
+			client, err := factory(compartmentID, region)
+			if err != nil {
+				return nil, err
+			}
+
+			scoper, ok := client.(queryScoper)
+			if !ok {
+				return client, nil
+			}
+
+			err = scoper.SetQueryScope(scope)
+			if err != nil {
+				return nil, fmt.Errorf("scope monitoring queries: %w", err)
+			}
+
+			return client, nil
+		},
+	)
+}
+
 //nolint:ireturn // helper returns MetricsClient interface for dependency substitution.
 func createMetricsClient(
 	ctx context.Context,
@@ -741,7 +2007,7 @@ func createMetricsClient(
 
 	metricsClient, err := factory(compartmentID, region)
 	if err != nil {
-		return nil, fmt.Errorf("build monitoring client: %w", err)
+		return nil, fmt.Errorf("build monitoring client: %w: %w", errOCIAuthFailed, err)
 	}
 
 	return metricsClient, nil
@@ -750,8 +2016,11 @@ func createMetricsClient(
 func startMetricsServer(
 	ctx context.Context,
 	logger *zap.Logger,
+	network string,
 	addr string,
 	handler http.Handler,
+	tlsConfig *tls.Config,
+	bindFallback string,
 ) error {
 	trimmed := strings.TrimSpace(addr)
 	if trimmed == "" || handler == nil {
@@ -766,23 +2035,59 @@ func startMetricsServer(
 		logger = zap.NewNop()
 	}
 
-	var listenCfg net.ListenConfig
-
-	listener, err := listenCfg.Listen(ctx, "tcp", trimmed)
-	if err != nil {
-		return fmt.Errorf("listen metrics endpoint %q: %w", trimmed, err)
+	if network == "" {
+		network = "tcp"
 	}
 
-	server := &http.Server{ //nolint:exhaustruct // only security-critical timeout configured here
+	server := &http.Server{ //nolint:exhaustruct // only security-critical settings configured here
 		ReadHeaderTimeout: metricsReadHeaderTimeout,
+		TLSConfig:         tlsConfig,
 	}
 	server.Addr = trimmed
 	server.Handler = handler
 
+	listeners := listenerHealthFromContext(ctx)
+	listeners.binding(trimmed)
+
+	binder := listenerhttp.Binder{
+		Network:    network,
+		Addr:       trimmed,
+		Fallback:   bindFallback,
+		Logger:     zapslog.NewLibraryLogger(logger),
+		Backoff:    0,
+		MaxBackoff: 0,
+	}
+
+	err := binder.Bind(ctx, func(listener net.Listener) {
+		listeners.bound(trimmed)
+
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+
+		go func() {
+			err := server.Serve(listener)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Warn("metrics server serve", zap.Error(err))
+			}
+		}()
+	})
+	if err != nil {
+		return fmt.Errorf("listen metrics endpoint: %w: %w", errMetricsBindFailed, err)
+	}
+
+	done := subsystemsFromContext(ctx).trackServer()
+
 	go func() {
+		defer done()
+
 		<-ctx.Done()
 
-		shutdownCtx, cancel := context.WithTimeout(ctx, metricsShutdownTimeout)
+		// In-flight scrapes may finish after ctx ends.
+		shutdownCtx, cancel := context.WithTimeout(
+			context.WithoutCancel(ctx),
+			metricsShutdownTimeout,
+		)
 		defer cancel()
 
 		err := server.Shutdown(shutdownCtx)
@@ -791,13 +2096,6 @@ func startMetricsServer(
 		}
 	}()
 
-	go func() {
-		err := server.Serve(listener)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Warn("metrics server serve", zap.Error(err))
-		}
-	}()
-
 	return nil
 }
 
@@ -805,6 +2103,26 @@ type p95CPUQuerier interface {
 	QueryP95CPU(ctx context.Context, resourceID string, last7d bool) (float32, error)
 }
 
+type clockSkewTracker interface {
+	SetClockSkewHandler(handler func(skew time.Duration))
+}
+
+type tokenExpiryTracker interface {
+	SetTokenExpiryHandler(handler func(expiry time.Time))
+}
+
+type queryWindowTracker interface {
+	SetQueryWindowHandler(handler func(window oci.QueryWindow))
+}
+
+type queryScoper interface {
+	SetQueryScope(scope oci.QueryScope) error
+}
+
+type networkBytesQuerier interface {
+	QueryNetworkBytes7d(ctx context.Context, resourceID string) (oci.NetworkTotals, error)
+}
+
 type instancePrincipalMetricsClient struct {
 	client p95CPUQuerier
 }
@@ -825,6 +2143,114 @@ func (m *instancePrincipalMetricsClient) QueryP95CPU(
 	return float64(value), nil
 }
 
+// QueryNetworkBytes7d forwards to the delegate when it reports network totals.
+func (m *instancePrincipalMetricsClient) QueryNetworkBytes7d(
+	ctx context.Context,
+	resourceID string,
+) (oci.NetworkTotals, error) {
+	querier, ok := m.client.(networkBytesQuerier)
+	if !ok {
+		return oci.NetworkTotals{}, errNetworkMetricsMissing
+	}
+
+	totals, err := querier.QueryNetworkBytes7d(ctx, resourceID)
+	if err != nil {
+		return oci.NetworkTotals{}, fmt.Errorf("query network bytes: %w", err)
+	}
+
+	return totals, nil
+}
+
+// QueryStep forwards to the delegate, which batches the step's queries when it
+// supports them.
+func (m *instancePrincipalMetricsClient) QueryStep(
+	ctx context.Context,
+	resourceID string,
+	query oci.StepQuery,
+) (oci.StepMetrics, error) {
+	if m == nil || m.client == nil {
+		return oci.StepMetrics{}, errMetricsDelegateNil
+	}
+
+	batch, ok := m.client.(oci.StepMetricsClient)
+	if !ok {
+		result, err := oci.QueryStepSerially(ctx, m, resourceID, query)
+
+		return result, err //nolint:wrapcheck // the individual queries wrap their errors
+	}
+
+	result, err := batch.QueryStep(ctx, resourceID, query)
+	if err != nil {
+		return oci.StepMetrics{}, fmt.Errorf("query step metrics: %w", err)
+	}
+
+	return result, nil
+}
+
+// SetLogger forwards logger to the delegate when it accepts one.
+func (m *instancePrincipalMetricsClient) SetLogger(logger logging.Logger) {
+	if m == nil {
+		return
+	}
+
+	if receiver, ok := m.client.(loggerReceiver); ok {
+		receiver.SetLogger(logger)
+	}
+}
+
+// SetClockSkewHandler forwards handler to the delegate when it tracks clock skew.
+func (m *instancePrincipalMetricsClient) SetClockSkewHandler(handler func(skew time.Duration)) {
+	if m == nil {
+		return
+	}
+
+	if tracker, ok := m.client.(clockSkewTracker); ok {
+		tracker.SetClockSkewHandler(handler)
+	}
+}
+
+// SetTokenExpiryHandler forwards handler to the delegate when it tracks the
+// expiry of its security token.
+func (m *instancePrincipalMetricsClient) SetTokenExpiryHandler(
+	handler func(expiry time.Time),
+) {
+	if m == nil {
+		return
+	}
+
+	if tracker, ok := m.client.(tokenExpiryTracker); ok {
+		tracker.SetTokenExpiryHandler(handler)
+	}
+}
+
+// SetQueryWindowHandler forwards handler to the delegate when it reports the
+// window of its Monitoring queries.
+func (m *instancePrincipalMetricsClient) SetQueryWindowHandler(
+	handler func(window oci.QueryWindow),
+) {
+	if m == nil {
+		return
+	}
+
+	if tracker, ok := m.client.(queryWindowTracker); ok {
+		tracker.SetQueryWindowHandler(handler)
+	}
+}
+
+// SetQueryScope forwards scope to the delegate when it supports scoped queries.
+func (m *instancePrincipalMetricsClient) SetQueryScope(scope oci.QueryScope) error {
+	if m == nil {
+		return errMetricsDelegateNil
+	}
+
+	scoper, ok := m.client.(queryScoper)
+	if !ok {
+		return nil
+	}
+
+	return scoper.SetQueryScope(scope) //nolint:wrapcheck // transparent decorator
+}
+
 //nolint:ireturn // factory returns interface to support substitutable IMDS clients.
 func defaultIMDSFactory() imds.Client {
 	endpoint := strings.TrimSpace(os.Getenv(imdsEndpointEnv))
@@ -853,6 +2279,10 @@ func logIMDSMetadata(
 		zap.Bool("offline", offline),
 	}
 
+	if introspector, ok := controller.(adapt.Introspector); ok {
+		fields = append(fields, zap.Float64("controllerTarget", introspector.Target()))
+	}
+
 	trimmedOverride := strings.TrimSpace(overrideInstanceID)
 	trimmedCompartment := strings.TrimSpace(overrideCompartmentID)
 	trimmedRegion := strings.TrimSpace(overrideRegion)
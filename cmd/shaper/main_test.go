@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -13,6 +14,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -22,9 +25,17 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 	"oci-cpu-shaper/internal/buildinfo"
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
+	"oci-cpu-shaper/pkg/history"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+	listenerhttp "oci-cpu-shaper/pkg/http/listener"
 	metricshttp "oci-cpu-shaper/pkg/http/metrics"
 	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/logging"
 	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/sched"
+	"oci-cpu-shaper/pkg/shape"
 )
 
 var (
@@ -36,13 +47,16 @@ var (
 	errStubPrincipal     = errors.New("stub: principal client")
 	errStubQueryFailure  = errors.New("stub: query failure")
 	errFailingWriter     = errors.New("failing writer: write failed")
+	errStubSample        = errors.New("stub: sample failure")
 	errMetricsServerBoom = errors.New("metrics server start failure")
+	errStubSchedRefused  = errors.New("operation not permitted")
 )
 
 const (
 	maxUint32         = ^uint32(0)
 	stubCompartmentID = "ocid1.compartment.oc1..test"
 	stubRegion        = "us-ashburn-1"
+	stubInstanceID    = "ocid1.instance.oc1..stub"
 	imdsAuthHeaderKey = "Authorization"
 	imdsAuthHeaderVal = "Bearer Oracle"
 	metricsServerWait = time.Second
@@ -137,7 +151,7 @@ func TestParseArgsRejectsUnknownMode(t *testing.T) {
 func TestNewLoggerRejectsInvalidLevel(t *testing.T) {
 	t.Parallel()
 
-	_, err := newLogger("not-a-level")
+	_, err := newLogger("not-a-level", logBackendZap)
 	if err == nil {
 		t.Fatal("expected error when creating logger with invalid level")
 	}
@@ -146,7 +160,7 @@ func TestNewLoggerRejectsInvalidLevel(t *testing.T) {
 func TestNewLoggerAppliesLevel(t *testing.T) {
 	t.Parallel()
 
-	logger, err := newLogger("debug")
+	logger, err := newLogger("debug", logBackendZap)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -160,6 +174,24 @@ func TestNewLoggerAppliesLevel(t *testing.T) {
 	}
 }
 
+func TestNewLoggerSelectsBackend(t *testing.T) {
+	t.Parallel()
+
+	logger, err := newLogger("warn", logBackendSlog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if logger.Core().Enabled(zap.InfoLevel) || !logger.Core().Enabled(zap.WarnLevel) {
+		t.Fatal("expected the slog backend to apply the warn level")
+	}
+
+	_, err = newLogger("info", "logrus")
+	if !errors.Is(err, errInvalidLogBackend) {
+		t.Fatalf("expected errInvalidLogBackend, got %v", err)
+	}
+}
+
 func TestParseArgsTrimSpaces(t *testing.T) {
 	t.Parallel()
 
@@ -366,7 +398,7 @@ func assertRunVersionPrints(t *testing.T, args []string, info buildinfo.Info) {
 	var stdout bytes.Buffer
 
 	deps := defaultRunDeps()
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		panic("newLogger should not be called when printing version")
 	}
 	deps.loadConfig = func(string) (runtimeConfig, error) {
@@ -420,7 +452,7 @@ func TestRunSuccessfulPath(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "test-commit", "2024-05-01")
 	}
-	deps.newLogger = func(level string) (*zap.Logger, error) {
+	deps.newLogger = func(level, _ string) (*zap.Logger, error) {
 		if level != "debug" {
 			t.Fatalf("expected log level \"debug\", got %q", level)
 		}
@@ -433,7 +465,9 @@ func TestRunSuccessfulPath(t *testing.T) {
 	pool := new(stubPoolStarter)
 
 	deps.loadConfig = loadConfigStub()
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 
@@ -487,15 +521,20 @@ func TestRunAppliesShutdownAfter(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "test-commit", "2024-05-01")
 	}
-	deps.newLogger = func(level string) (*zap.Logger, error) {
+	deps.newLogger = func(level, _ string) (*zap.Logger, error) {
 		if level != defaultLogLevel {
 			t.Fatalf("expected default log level %q, got %q", defaultLogLevel, level)
 		}
 
 		return logger, nil
 	}
+	deps.newIMDS = func() imds.Client {
+		return newOfflineStubIMDS()
+	}
 	deps.loadConfig = loadConfigStub()
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 
@@ -595,7 +634,7 @@ func TestRunReturnsLoggerConfigurationError(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("", "", "")
 	}
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		return nil, errStubLoggerBoom
 	}
 
@@ -623,12 +662,14 @@ func TestRunHandlesControllerError(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "", "")
 	}
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		return logger, nil
 	}
 
 	deps.loadConfig = loadConfigStub()
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 
@@ -674,16 +715,19 @@ func TestRunHandlesControllerFactoryError(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "", "")
 	}
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		return zap.NewNop(), nil
 	}
 	deps.loadConfig = func(string) (runtimeConfig, error) {
 		cfg := defaultRuntimeConfig()
 		cfg.OCI.CompartmentID = stubCompartmentID
+		cfg.OCI.Region = stubRegion
 
 		return cfg, nil
 	}
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 	deps.newController = func(
@@ -712,7 +756,7 @@ func TestRunReturnsRuntimeErrorWhenMetricsServerFails(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "test-commit", "2024-05-01")
 	}
-	deps.newLogger = func(level string) (*zap.Logger, error) {
+	deps.newLogger = func(level, _ string) (*zap.Logger, error) {
 		if level != defaultLogLevel {
 			t.Fatalf("expected default log level %q, got %q", defaultLogLevel, level)
 		}
@@ -734,7 +778,9 @@ func TestRunReturnsRuntimeErrorWhenMetricsServerFails(t *testing.T) {
 	) (adapt.Controller, poolStarter, error) {
 		return ctrl, nil, nil
 	}
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return errMetricsServerBoom
 	}
 
@@ -754,7 +800,7 @@ func TestRunReturnsRuntimeErrorWhenMetricsServerFails(t *testing.T) {
 }
 
 //nolint:funlen // coverage-focused test exercises multiple failure branches
-func TestRunReturnsRuntimeErrorWhenMetadataResolutionFails(t *testing.T) {
+func TestRunReturnsIMDSExitCodeWhenMetadataResolutionFails(t *testing.T) {
 	t.Parallel()
 
 	core, observed := observer.New(zap.ErrorLevel)
@@ -764,7 +810,7 @@ func TestRunReturnsRuntimeErrorWhenMetadataResolutionFails(t *testing.T) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "test-commit", "2024-05-01")
 	}
-	deps.newLogger = func(level string) (*zap.Logger, error) {
+	deps.newLogger = func(level, _ string) (*zap.Logger, error) {
 		if level != defaultLogLevel {
 			t.Fatalf("expected default log level %q, got %q", defaultLogLevel, level)
 		}
@@ -778,7 +824,9 @@ func TestRunReturnsRuntimeErrorWhenMetadataResolutionFails(t *testing.T) {
 
 		return cfg, nil
 	}
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 
@@ -812,8 +860,8 @@ func TestRunReturnsRuntimeErrorWhenMetadataResolutionFails(t *testing.T) {
 	}
 
 	exitCode := run(t.Context(), nil, deps, io.Discard)
-	if exitCode != exitCodeRuntimeError {
-		t.Fatalf("expected runtime error exit code, got %d", exitCode)
+	if exitCode != exitCodeIMDSUnreachable {
+		t.Fatalf("expected imds unreachable exit code, got %d", exitCode)
 	}
 
 	if controllerCalled {
@@ -880,7 +928,7 @@ func TestRunExposesMetricsOffline(t *testing.T) {
 			"duty_cycle_ms 2.000",
 			"host_cpu_percent 50.00",
 			"oci_p95 0.280000",
-			"oci_last_success_epoch 1700000100",
+			fmt.Sprintf("oci_last_success_epoch %d", offlineFetchedAt.Unix()),
 		},
 	)
 
@@ -904,6 +952,20 @@ func TestRunExposesMetricsOffline(t *testing.T) {
 		)
 	}
 
+	expectComponentHealth(t, snapshot, health.ComponentEstimator, health.Component{
+		Status: health.StatusDegraded,
+		Detail: expectedEstimator,
+	})
+	// Offline runs never call OCI, so its errors do not gate the process.
+	expectComponentHealth(t, snapshot, health.ComponentOCI, health.Component{
+		Status: health.StatusDisabled,
+		Detail: "offline mode",
+	})
+
+	if snapshot.Status != health.StatusDegraded {
+		t.Fatalf("expected a degraded process, got %q", snapshot.Status)
+	}
+
 	cancel()
 
 	exitCode := <-exitCh
@@ -917,12 +979,17 @@ var (
 	errStubHealthEstimator = errors.New("estimator stalled")
 )
 
+// offlineFetchedAt stays recent so the exporter does not expire the stub P95.
+//
+//nolint:gochecknoglobals // shared between the offline deps and their assertions.
+var offlineFetchedAt = time.Now()
+
 //nolint:funlen // helper configures run dependencies and keeps test setup readable.
 func newOfflineRunDeps(t *testing.T, serverCh chan<- *httptest.Server) runDeps {
 	t.Helper()
 
 	deps := defaultRunDeps()
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		return zap.NewNop(), nil
 	}
 	deps.loadConfig = func(string) (runtimeConfig, error) {
@@ -937,7 +1004,14 @@ func newOfflineRunDeps(t *testing.T, serverCh chan<- *httptest.Server) runDeps {
 
 		return cfg, nil
 	}
-	deps.startMetricsServer = func(ctx context.Context, _ *zap.Logger, _ string, handler http.Handler) error {
+	deps.startMetricsServer = func(
+		ctx context.Context,
+		_ *zap.Logger,
+		_, _ string,
+		handler http.Handler,
+		_ *tls.Config,
+		_ string,
+	) error {
 		server := httptest.NewServer(handler)
 
 		serverCh <- server
@@ -964,7 +1038,7 @@ func newOfflineRunDeps(t *testing.T, serverCh chan<- *httptest.Server) runDeps {
 			recorder.SetState(adapt.StateNormal.String())
 			recorder.SetTarget(cfg.Controller.TargetStart)
 			recorder.ObserveHostCPU(0.5)
-			recorder.ObserveOCIP95(0.28, time.Unix(1_700_000_100, 0))
+			recorder.ObserveOCIP95(0.28, offlineFetchedAt)
 		}
 
 		pool := new(stubPoolStarter)
@@ -995,9 +1069,24 @@ func expectMetricsSnippets(t *testing.T, output string, snippets []string) {
 }
 
 type healthSnapshot struct {
-	State          string `json:"state"`
-	LastOCIError   string `json:"ociError"`
-	EstimatorError string `json:"estimatorError"`
+	State          string                      `json:"state"`
+	LastOCIError   string                      `json:"ociError"`
+	EstimatorError string                      `json:"estimatorError"`
+	Status         health.Status               `json:"status"`
+	Components     map[string]health.Component `json:"components"`
+}
+
+func expectComponentHealth(
+	t *testing.T,
+	snapshot healthSnapshot,
+	name string,
+	expected health.Component,
+) {
+	t.Helper()
+
+	if got := snapshot.Components[name]; got != expected {
+		t.Fatalf("expected %s health %+v, got %+v", name, expected, got)
+	}
 }
 
 func fetchHealthSnapshot(
@@ -1148,6 +1237,74 @@ func TestDefaultControllerFactoryBuildsAdaptiveController(t *testing.T) {
 	}
 }
 
+func TestDefaultControllerFactoryWrapsPoolWithHooks(t *testing.T) {
+	t.Parallel()
+
+	fakeMetrics := newStubMetricsClient()
+	ctx := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) {
+			return fakeMetrics, nil
+		},
+	)
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Offline = true
+	cfg.Pool.Workers = 1
+	cfg.Hooks.PostApply.Command = []string{"true"}
+
+	_, pool, err := defaultControllerFactory(ctx, modeDryRun, cfg, newOfflineStubIMDS(), nil)
+	if err != nil {
+		t.Fatalf("defaultControllerFactory returned error: %v", err)
+	}
+
+	if _, ok := pool.(hookErrorReporter); !ok {
+		t.Fatalf("expected hook-wrapped pool when a hook is configured, got %T", pool)
+	}
+}
+
+func TestDefaultControllerFactoryConvertsOCPUSecondsTargets(t *testing.T) {
+	t.Parallel()
+
+	ctx := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) {
+			return newStubMetricsClient(), nil
+		},
+	)
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Offline = true
+	cfg.Pool.Workers = 1
+	cfg.Controller.OCPUSeconds = ocpuSecondsConfig{Start: 1800, Max: 2520}
+
+	imdsClient := newOfflineStubIMDS()
+	imdsClient.shape = imds.ShapeConfig{OCPUs: 2}
+	imdsClient.shapeErr = nil
+
+	controller, _, err := defaultControllerFactory(ctx, modeDryRun, cfg, imdsClient, nil)
+	if err != nil {
+		t.Fatalf("defaultControllerFactory returned error: %v", err)
+	}
+
+	reporter, ok := controller.(controllerConfigReporter)
+	if !ok {
+		t.Fatalf("expected adaptive controller, got %T", controller)
+	}
+
+	active := reporter.Config()
+	assertFloatEqual(t, "targetStart", active.TargetStart, 0.25)
+	assertFloatEqual(t, "targetMax", active.TargetMax, 0.35)
+	assertFloatEqual(t, "targetMin", active.TargetMin, adapt.DefaultConfig().TargetMin)
+
+	imdsClient.shapeErr = errShapeDown
+
+	_, _, err = defaultControllerFactory(ctx, modeDryRun, cfg, imdsClient, nil)
+	if exitCodeForRunError(err) != exitCodeIMDSUnreachable {
+		t.Fatalf("expected imds exit code when the ocpu count is unavailable, got %v", err)
+	}
+}
+
 func TestDefaultControllerFactoryErrorsOnMissingCompartmentID(t *testing.T) {
 	t.Parallel()
 
@@ -1307,6 +1464,52 @@ func TestBuildAdaptiveControllerOfflineSkipsExternalDependencies(t *testing.T) {
 	}
 }
 
+func TestBuildAdaptiveControllerDerivesWorkersFromShape(t *testing.T) {
+	t.Parallel()
+
+	ctx := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) {
+			return newStubMetricsClient(), nil
+		},
+	)
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.CompartmentID = testCompartmentOverride
+	cfg.OCI.Region = stubRegion
+	cfg.OCI.InstanceID = "ocid1.instance.oc1..shape"
+
+	imdsClient := newOfflineStubIMDS()
+	imdsClient.shape = stubShapeConfig(1, 16)
+	imdsClient.shape.ThreadsPerCore = 2
+	imdsClient.shapeErr = nil
+
+	_, pool, err := buildAdaptiveController(ctx, modeDryRun, cfg, imdsClient, nil)
+	if err != nil {
+		t.Fatalf("buildAdaptiveController returned error: %v", err)
+	}
+
+	if pool.Workers() != 1 || imdsClient.shapeCalls != 1 {
+		t.Fatalf(
+			"expected one worker per OCPU from one shape lookup, got %d workers and %d calls",
+			pool.Workers(),
+			imdsClient.shapeCalls,
+		)
+	}
+}
+
+func TestPoolWorkersFallsBackToHostCPUs(t *testing.T) {
+	t.Parallel()
+
+	if got := poolWorkers(3, stubShapeConfig(1, 16)); got != 3 {
+		t.Fatalf("expected the configured worker count, got %d", got)
+	}
+
+	if got := poolWorkers(0, stubShapeConfig(0, 0)); got != runtime.NumCPU() {
+		t.Fatalf("expected %d workers without a shape, got %d", runtime.NumCPU(), got)
+	}
+}
+
 func TestBuildAdaptiveControllerRequiresCompartmentID(t *testing.T) {
 	t.Parallel()
 
@@ -1494,6 +1697,27 @@ func TestLogIMDSMetadataEmitsDetails(t *testing.T) {
 	requireLogFieldFloat(t, entry, "shapeMemoryGB", 64)
 }
 
+func TestLogIMDSMetadataIncludesIntrospectedTarget(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+
+	controller := adapt.NewNoopController(modeNoop)
+	logIMDSMetadata(context.Background(), logger, nil, controller, "", "", "", true)
+
+	entry := requireSingleDebugEntry(t, observed)
+	requireLogFieldFloat(t, entry, "controllerTarget", 0)
+
+	observed.TakeAll()
+	logIMDSMetadata(context.Background(), logger, nil, new(stubController), "", "", "", true)
+
+	entry = requireSingleDebugEntry(t, observed)
+	if _, ok := entry.ContextMap()["controllerTarget"]; ok {
+		t.Fatalf("expected controllerTarget to be omitted, got %+v", entry.ContextMap())
+	}
+}
+
 func TestLogIMDSMetadataWarnsOnFailures(t *testing.T) {
 	t.Parallel()
 
@@ -1753,6 +1977,10 @@ func TestResolveCompartmentAndRegionPropagatesErrors(t *testing.T) {
 }
 
 func TestMainIntegratesDefaultDependencies(t *testing.T) {
+	if minimalBuild {
+		t.Skip("testdata/config.yaml sets webhook.url, which minimal builds reject")
+	}
+
 	server := newIPv4TestServer(
 		t,
 		http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
@@ -1783,6 +2011,7 @@ func TestMainIntegratesDefaultDependencies(t *testing.T) {
 	t.Cleanup(server.Close)
 
 	t.Setenv(imdsEndpointEnv, server.URL+"/opc/v2")
+	t.Setenv(envHistoryPath, filepath.Join(t.TempDir(), "history.bin"))
 
 	originalArgs := os.Args
 	os.Args = []string{
@@ -1900,11 +2129,13 @@ func runShutdownScenario(t *testing.T, runErr error, reason string) {
 	deps.currentBuildInfo = func() buildinfo.Info {
 		return stubBuildInfo("test-version", "", "")
 	}
-	deps.newLogger = func(string) (*zap.Logger, error) {
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
 		return logger, nil
 	}
 	deps.loadConfig = loadConfigStub()
-	deps.startMetricsServer = func(context.Context, *zap.Logger, string, http.Handler) error {
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
 		return nil
 	}
 	deps.newController = func(
@@ -2145,13 +2376,18 @@ func newIPv4TestServer(t *testing.T, handler http.Handler) *httptest.Server {
 }
 
 type stubPoolStarter struct {
+	startErr   error
+	outcome    string
+	policies   map[string]int
 	startCount int
 	workers    int
 	quantum    time.Duration
 }
 
-func (s *stubPoolStarter) Start(context.Context) {
+func (s *stubPoolStarter) Start(context.Context) error {
 	s.startCount++
+
+	return s.startErr
 }
 
 func (s *stubPoolStarter) Workers() int {
@@ -2172,6 +2408,14 @@ func (s *stubPoolStarter) Quantum() time.Duration {
 
 func (*stubPoolStarter) SetWorkerStartErrorHandler(func(error)) {}
 
+func (s *stubPoolStarter) StartOutcome() string {
+	return s.outcome
+}
+
+func (s *stubPoolStarter) WorkerPolicies() map[string]int {
+	return s.policies
+}
+
 type stubMetricsAdapter struct{}
 
 func newStubMetricsClient() *stubMetricsAdapter {
@@ -2734,6 +2978,99 @@ func TestExitCodeForConfigError(t *testing.T) {
 	}
 }
 
+func TestExitCodeForRunError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "oci auth",
+			err: fmt.Errorf(
+				"build monitoring client: %w: %w",
+				errOCIAuthFailed,
+				errStubControllerRun,
+			),
+			want: exitCodeOCIAuthError,
+		},
+		{
+			name: "imds unreachable",
+			err: fmt.Errorf(
+				"lookup instance region: %w: %w",
+				errIMDSUnreachable,
+				errStubQueryFailure,
+			),
+			want: exitCodeIMDSUnreachable,
+		},
+		{
+			name: "pool start",
+			err:  fmt.Errorf("build worker pool: %w", errPoolStartFailed),
+			want: exitCodePoolStartError,
+		},
+		{
+			name: "metrics bind",
+			err:  fmt.Errorf("listen metrics endpoint %q: %w", ":9108", errMetricsBindFailed),
+			want: exitCodeMetricsBindError,
+		},
+		{
+			name: "invalid config",
+			err:  fmt.Errorf("build adaptive controller: %w", adapt.ErrInvalidConfig),
+			want: exitCodeParseError,
+		},
+		{
+			name: "generic runtime error",
+			err:  errStubControllerRun,
+			want: exitCodeRuntimeError,
+		},
+	}
+
+	for _, tc := range testCases {
+		testCase := tc
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := exitCodeForRunError(testCase.err); got != testCase.want {
+				t.Fatalf("expected %d, got %d", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestStartMetricsServerReturnsBindError(t *testing.T) {
+	t.Parallel()
+
+	var listenCfg net.ListenConfig
+
+	listener, err := listenCfg.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve listener: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	err = startMetricsServer(
+		t.Context(),
+		zap.NewNop(),
+		"tcp",
+		listener.Addr().String(),
+		http.NotFoundHandler(),
+		nil,
+		"",
+	)
+	if !errors.Is(err, errMetricsBindFailed) {
+		t.Fatalf("expected metrics bind failure, got %v", err)
+	}
+
+	if got := exitCodeForRunError(err); got != exitCodeMetricsBindError {
+		t.Fatalf("expected metrics bind exit code, got %d", got)
+	}
+}
+
 func TestWriteErrorHandlesScenarios(t *testing.T) {
 	t.Parallel()
 
@@ -2787,15 +3124,146 @@ func TestWriteErrorHandlesScenarios(t *testing.T) {
 	})
 }
 
+func TestConfigureMetricsStartsListenerPerBindAddress(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.Bind = " 127.0.0.1:9108, ,[::1]:9108 "
+	cfg.HTTP.Network = httpNetworkTCP6
+	cfg.HTTP.BindFallback = listenerhttp.FallbackRetry
+
+	type bind struct{ network, addr, fallback string }
+
+	var (
+		binds []bind
+		deps  runDeps
+	)
+
+	deps.startMetricsServer = func(
+		_ context.Context,
+		_ *zap.Logger,
+		network, addr string,
+		_ http.Handler,
+		_ *tls.Config,
+		fallback string,
+	) error {
+		binds = append(binds, bind{network: network, addr: addr, fallback: fallback})
+
+		if len(binds) == 2 {
+			return errMetricsServerBoom
+		}
+
+		return nil
+	}
+
+	err := configureMetrics(
+		context.Background(),
+		deps,
+		zap.NewNop(),
+		cfg,
+		metricshttp.NewExporter(),
+		nil,
+		nil,
+		nil,
+	)
+	if !errors.Is(err, errMetricsServerBoom) {
+		t.Fatalf("expected second bind failure to propagate, got %v", err)
+	}
+
+	want := []bind{{"tcp6", "127.0.0.1:9108", "retry"}, {"tcp6", "[::1]:9108", "retry"}}
+	if len(binds) != len(want) || binds[0] != want[0] || binds[1] != want[1] {
+		t.Fatalf("unexpected binds %+v", binds)
+	}
+}
+
+func TestStartMetricsServerHonoursNetwork(t *testing.T) {
+	t.Parallel()
+
+	err := startMetricsServer(
+		t.Context(),
+		zap.NewNop(),
+		httpNetworkTCP4,
+		"[::1]:0",
+		http.NotFoundHandler(),
+		nil,
+		"",
+	)
+	if !errors.Is(err, errMetricsBindFailed) {
+		t.Fatalf("expected tcp4 listener to reject an IPv6 address, got %v", err)
+	}
+
+	err = startMetricsServer(
+		t.Context(),
+		zap.NewNop(),
+		httpNetworkTCP4,
+		"127.0.0.1:0",
+		http.NotFoundHandler(),
+		nil,
+		"",
+	)
+	if err != nil {
+		t.Fatalf("expected tcp4 listener on loopback, got %v", err)
+	}
+}
+
+func TestStartMetricsServerAppliesBindFallback(t *testing.T) {
+	t.Parallel()
+
+	var listenCfg net.ListenConfig
+
+	taken, err := listenCfg.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = taken.Close()
+	})
+
+	addr := taken.Addr().String()
+
+	err = startMetricsServer(
+		t.Context(),
+		zap.NewNop(),
+		"tcp",
+		addr,
+		http.NotFoundHandler(),
+		nil,
+		listenerhttp.FallbackNone,
+	)
+	if !errors.Is(err, errMetricsBindFailed) {
+		t.Fatalf("expected bind failure without a fallback, got %v", err)
+	}
+
+	for _, fallback := range []string{listenerhttp.FallbackEphemeral, listenerhttp.FallbackRetry} {
+		err = startMetricsServer(
+			t.Context(),
+			zap.NewNop(),
+			"tcp",
+			addr,
+			http.NotFoundHandler(),
+			nil,
+			fallback,
+		)
+		if err != nil {
+			t.Fatalf("expected %s fallback to keep startup going, got %v", fallback, err)
+		}
+	}
+}
+
 func TestStartMetricsServerSkipsWhenAddressOrHandlerMissing(t *testing.T) {
 	t.Parallel()
 
-	err := startMetricsServer(context.Background(), zap.NewNop(), "   ", http.NewServeMux())
+	err := startMetricsServer(
+		context.Background(), zap.NewNop(), "tcp", "   ", http.NewServeMux(), nil, "",
+	)
 	if err != nil {
 		t.Fatalf("expected trimmed empty address to skip, got %v", err)
 	}
 
-	err = startMetricsServer(context.Background(), zap.NewNop(), testMetricsBind, nil)
+	err = startMetricsServer(
+		context.Background(), zap.NewNop(), "tcp", testMetricsBind, nil, nil, "",
+	)
 	if err != nil {
 		t.Fatalf("expected nil handler to skip, got %v", err)
 	}
@@ -2806,7 +3274,15 @@ func TestStartMetricsServerRequiresContext(t *testing.T) {
 
 	var nilContext context.Context
 
-	err := startMetricsServer(nilContext, zap.NewNop(), testMetricsBind, http.NewServeMux())
+	err := startMetricsServer(
+		nilContext,
+		zap.NewNop(),
+		"tcp",
+		testMetricsBind,
+		http.NewServeMux(),
+		nil,
+		"",
+	)
 	if !errors.Is(err, errMetricsContextRequired) {
 		t.Fatalf("expected errMetricsContextRequired, got %v", err)
 	}
@@ -2831,7 +3307,7 @@ func TestStartMetricsServerServesRequests(t *testing.T) {
 		_, _ = w.Write([]byte("ok"))
 	})
 
-	err := startMetricsServer(ctx, nil, addr, mux)
+	err := startMetricsServer(ctx, nil, "", addr, mux, nil, "")
 	if err != nil {
 		t.Fatalf("startMetricsServer returned error: %v", err)
 	}
@@ -2878,20 +3354,186 @@ func TestStartMetricsServerServesRequests(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 }
 
-func TestConfigureMetricsHandlesNilExporter(t *testing.T) {
+func TestStartMetricsServerServesTLS(t *testing.T) {
 	t.Parallel()
 
-	cfg := defaultRuntimeConfig()
-
-	var deps runDeps
+	// The httptest certificate is valid for 127.0.0.1 and trusted by its client.
+	certified := httptest.NewUnstartedServer(http.NotFoundHandler())
+	certified.StartTLS()
+	t.Cleanup(certified.Close)
 
-	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, nil, nil, nil)
-	if err != nil {
-		t.Fatalf("configureMetrics returned error: %v", err)
+	addr := freeTCPAddress(t)
+	tlsConfig := &tls.Config{
+		Certificates: certified.TLS.Certificates,
+		MinVersion:   tls.VersionTLS12,
 	}
-}
 
-func TestConfigureMetricsSkipsServerWhenMissing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	err := startMetricsServer(t.Context(), nil, "", addr, mux, tlsConfig, "")
+	if err != nil {
+		t.Fatalf("startMetricsServer returned error: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		t.Context(), http.MethodGet, "https://"+addr+"/metrics", nil,
+	)
+	if err != nil {
+		t.Fatalf("build http request: %v", err)
+	}
+
+	resp, err := certified.Client().Do(req)
+	if err != nil {
+		t.Fatalf("expected TLS metrics request to succeed: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("unexpected response status %d over tls %v", resp.StatusCode, resp.TLS != nil)
+	}
+}
+
+//nolint:funlen // the listeners are checked route by route in one flow.
+func TestConfigureMetricsAppliesListenerAuth(t *testing.T) {
+	t.Parallel()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.Bind = "ignored:9108"
+	cfg.HTTP.Listeners = []listenerConfig{
+		{Bind: "127.0.0.1:9108"},
+		{Bind: "10.0.0.5:9108", Auth: listenerAuthConfig{BearerTokenFile: tokenFile}},
+	}
+
+	handlers := make(map[string]http.Handler)
+
+	var deps runDeps
+
+	deps.startMetricsServer = func(
+		_ context.Context,
+		_ *zap.Logger,
+		_, addr string,
+		handler http.Handler,
+		tlsConfig *tls.Config,
+		_ string,
+	) error {
+		if tlsConfig != nil {
+			t.Fatalf("expected plaintext listener on %s", addr)
+		}
+
+		handlers[addr] = handler
+
+		return nil
+	}
+
+	admin := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err = configureMetrics(
+		t.Context(), deps, zap.NewNop(), cfg, metricshttp.NewExporter(), nil, nil, admin,
+	)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+
+	if len(handlers) != 2 {
+		t.Fatalf("expected the listeners to replace http.bind, got %v", handlers)
+	}
+
+	status := func(addr, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handlers[addr].ServeHTTP(recorder, req)
+
+		return recorder.Code
+	}
+
+	checks := []struct {
+		addr, path, token string
+		want              int
+	}{
+		{"127.0.0.1:9108", "/metrics", "", http.StatusOK},
+		{"10.0.0.5:9108", "/metrics", "", http.StatusUnauthorized},
+		{"10.0.0.5:9108", "/metrics", "wrong", http.StatusUnauthorized},
+		{"10.0.0.5:9108", "/metrics", "s3cret", http.StatusOK},
+		{"10.0.0.5:9108", adminhttp.Prefix, "", http.StatusNoContent},
+		{"10.0.0.5:9108", "/missing", "", http.StatusNotFound},
+	}
+
+	for _, check := range checks {
+		got := status(check.addr, check.path, check.token)
+		if got != check.want {
+			t.Fatalf("%s%s with token %q: status %d, want %d",
+				check.addr, check.path, check.token, got, check.want)
+		}
+	}
+}
+
+func TestConfigureMetricsRejectsUnreadableListenerSecrets(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	var deps runDeps
+
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
+		t.Fatal("expected no listener to start")
+
+		return nil
+	}
+
+	for _, entry := range []listenerConfig{
+		{Bind: testMetricsBind, Auth: listenerAuthConfig{BearerTokenFile: missing}},
+		{Bind: testMetricsBind, Auth: listenerAuthConfig{Username: "u", PasswordFile: missing}},
+		{Bind: testMetricsBind, TLS: listenerhttp.TLS{CertFile: missing, KeyFile: missing}},
+	} {
+		cfg := defaultRuntimeConfig()
+		cfg.HTTP.Listeners = []listenerConfig{entry}
+
+		err := configureMetrics(
+			t.Context(), deps, zap.NewNop(), cfg, metricshttp.NewExporter(), nil, nil, nil,
+		)
+		if !errors.Is(err, errInvalidHTTPListener) {
+			t.Fatalf("expected errInvalidHTTPListener for %+v, got %v", entry, err)
+		}
+
+		if got := exitCodeForRunError(err); got != exitCodeParseError {
+			t.Fatalf("expected exit code %d, got %d", exitCodeParseError, got)
+		}
+	}
+}
+
+func TestConfigureMetricsHandlesNilExporter(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+
+	var deps runDeps
+
+	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+}
+
+func TestConfigureMetricsSkipsServerWhenMissing(t *testing.T) {
 	t.Parallel()
 
 	exporter := metricshttp.NewExporter()
@@ -2901,7 +3543,7 @@ func TestConfigureMetricsSkipsServerWhenMissing(t *testing.T) {
 
 	var deps runDeps
 
-	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, pool, nil)
+	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, pool, nil, nil)
 	if err != nil {
 		t.Fatalf("configureMetrics returned error: %v", err)
 	}
@@ -2918,6 +3560,65 @@ func TestConfigureMetricsSkipsServerWhenMissing(t *testing.T) {
 	if !bytes.Contains(snapshot, []byte("duty_cycle_ms 150.000")) {
 		t.Fatalf("expected duty cycle metric, got %s", snapshot)
 	}
+
+	if bytes.Contains(snapshot, []byte("go_goroutines")) {
+		t.Fatalf("expected runtime metrics to stay disabled by default, got %s", snapshot)
+	}
+}
+
+func TestConfigureMetricsEnablesRuntimeMetrics(t *testing.T) {
+	t.Parallel()
+
+	exporter := metricshttp.NewExporter()
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.RuntimeMetrics = true
+
+	var deps runDeps
+
+	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !bytes.Contains(snapshot, []byte("go_goroutines")) {
+		t.Fatalf("expected runtime metrics when enabled, got %s", snapshot)
+	}
+}
+
+func TestConfigureMetricsAppliesStaticLabels(t *testing.T) {
+	t.Parallel()
+
+	exporter := metricshttp.NewExporter()
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.MetricsLabels = map[string]string{"team": "infra"}
+
+	var deps runDeps
+
+	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !bytes.Contains(snapshot, []byte("shaper_target_ratio{team=\"infra\"} ")) {
+		t.Fatalf("expected the static labels on every series, got %s", snapshot)
+	}
+
+	cfg.HTTP.MetricsLabels = map[string]string{"__name__": "x"}
+
+	err = configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, nil, nil, nil)
+	if !errors.Is(err, metricshttp.ErrInvalidStaticLabel) {
+		t.Fatalf("expected ErrInvalidStaticLabel, got %v", err)
+	}
 }
 
 //nolint:cyclop,funlen // comprehensive test covers handler wiring and response validation.
@@ -2948,7 +3649,14 @@ func TestConfigureMetricsRegistersHandlers(t *testing.T) {
 
 	var deps runDeps
 
-	deps.startMetricsServer = func(ctx context.Context, logger *zap.Logger, addr string, handler http.Handler) error {
+	deps.startMetricsServer = func(
+		ctx context.Context,
+		logger *zap.Logger,
+		network, addr string,
+		handler http.Handler,
+		_ *tls.Config,
+		_ string,
+	) error {
 		if ctx == nil {
 			t.Fatal("expected context to be forwarded")
 		}
@@ -2957,6 +3665,10 @@ func TestConfigureMetricsRegistersHandlers(t *testing.T) {
 			t.Fatal("expected logger to be forwarded")
 		}
 
+		if network != "tcp" {
+			t.Fatalf("expected dual-stack tcp network, got %q", network)
+		}
+
 		capturedAddr = addr
 		capturedHandler = handler
 		startInvocations++
@@ -2964,9 +3676,26 @@ func TestConfigureMetricsRegistersHandlers(t *testing.T) {
 		return nil
 	}
 
+	store, err := history.Open("")
+	if err != nil {
+		t.Fatalf("open history store: %v", err)
+	}
+
+	admin := adminhttp.NewHandler()
+	admin.Handle(adminhttp.Prefix+"history", adminhttp.NewHistoryHandler(store))
+
 	logger := zap.NewNop()
 
-	err := configureMetrics(context.Background(), deps, logger, cfg, exporter, pool, controller)
+	err = configureMetrics(
+		context.Background(),
+		deps,
+		logger,
+		cfg,
+		exporter,
+		pool,
+		controller,
+		admin,
+	)
 	if err != nil {
 		t.Fatalf("configureMetrics returned error: %v", err)
 	}
@@ -3016,6 +3745,25 @@ func TestConfigureMetricsRegistersHandlers(t *testing.T) {
 	if !bytes.Contains(healthBody, []byte(errStubQueryFailure.Error())) {
 		t.Fatalf("expected estimator error in health response, got %s", healthBody)
 	}
+
+	indexRecorder := httptest.NewRecorder()
+	capturedHandler.ServeHTTP(indexRecorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, link := range []string{`href="/metrics"`, `href="/healthz"`, `href="/admin/history"`} {
+		if !strings.Contains(indexRecorder.Body.String(), link) {
+			t.Fatalf("expected the landing page to link %s, got %s", link, indexRecorder.Body)
+		}
+	}
+
+	historyRecorder := httptest.NewRecorder()
+	capturedHandler.ServeHTTP(
+		historyRecorder,
+		httptest.NewRequest(http.MethodGet, "/admin/history?since=1h", nil),
+	)
+
+	if historyRecorder.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected history status 200, got %d", historyRecorder.Result().StatusCode)
+	}
 }
 
 func TestConfigureMetricsWithoutController(t *testing.T) {
@@ -3029,13 +3777,20 @@ func TestConfigureMetricsWithoutController(t *testing.T) {
 
 	var deps runDeps
 
-	deps.startMetricsServer = func(_ context.Context, _ *zap.Logger, _ string, handler http.Handler) error {
+	deps.startMetricsServer = func(
+		_ context.Context,
+		_ *zap.Logger,
+		_, _ string,
+		handler http.Handler,
+		_ *tls.Config,
+		_ string,
+	) error {
 		capturedHandler = handler
 
 		return nil
 	}
 
-	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, nil, nil)
+	err := configureMetrics(context.Background(), deps, zap.NewNop(), cfg, exporter, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("configureMetrics returned error: %v", err)
 	}
@@ -3051,4 +3806,1450 @@ func TestConfigureMetricsWithoutController(t *testing.T) {
 	if recorder.Result().StatusCode != http.StatusNotFound {
 		t.Fatalf("expected 404 for missing health handler, got %d", recorder.Result().StatusCode)
 	}
+
+	recorder = httptest.NewRecorder()
+	capturedHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := recorder.Body.String()
+	if !strings.Contains(body, `href="/metrics"`) || strings.Contains(body, `href="/healthz"`) {
+		t.Fatalf("expected the landing page to link only /metrics, got %s", body)
+	}
+
+	if !strings.Contains(body, "Version "+buildinfo.Current().Version) {
+		t.Fatalf("expected the build version on the landing page, got %s", body)
+	}
+}
+
+func TestConfigureMetricsLogsSampledRequests(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.HTTP.Bind = testMetricsBind
+	cfg.HTTP.AccessLogSample = 1
+
+	var (
+		capturedHandler http.Handler
+		deps            runDeps
+	)
+
+	deps.startMetricsServer = func(
+		_ context.Context,
+		_ *zap.Logger,
+		_, _ string,
+		handler http.Handler,
+		_ *tls.Config,
+		_ string,
+	) error {
+		capturedHandler = handler
+
+		return nil
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	err := configureMetrics(
+		context.Background(),
+		deps,
+		zap.New(core),
+		cfg,
+		metricshttp.NewExporter(),
+		nil,
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/wp-login.php", nil)
+	request.RemoteAddr = "198.51.100.4:40000"
+	capturedHandler.ServeHTTP(httptest.NewRecorder(), request)
+
+	entries := logs.FilterMessage("http request").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one access log entry, got %v", logs.All())
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["path"] != "/wp-login.php" || fields["remote"] != "198.51.100.4:40000" ||
+		fields["status"] != int64(http.StatusNotFound) || fields["listener"] != testMetricsBind {
+		t.Fatalf("unexpected access log fields %v", fields)
+	}
+}
+
+type publishingController struct {
+	stubController
+
+	observer adapt.DecisionObserver
+}
+
+func (c *publishingController) SetDecisionObserver(observer adapt.DecisionObserver) {
+	c.observer = observer
+}
+
+type identifiedController struct {
+	stubController
+
+	resourceID string
+}
+
+func (c *identifiedController) ResourceID() string {
+	return c.resourceID
+}
+
+type stubDisplayNameResolver struct {
+	name      string
+	err       error
+	requested string
+}
+
+func (r *stubDisplayNameResolver) InstanceDisplayName(
+	_ context.Context,
+	instanceOCID string,
+) (string, error) {
+	r.requested = instanceOCID
+
+	return r.name, r.err
+}
+
+func TestEnrichInstanceIdentitySkipsWhenDisabled(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	deps := runDeps{
+		newDisplayNameResolver: func(string) (displayNameResolver, error) {
+			called = true
+
+			return new(stubDisplayNameResolver), nil
+		},
+	}
+	exporter := metricshttp.NewExporter()
+	controller := &identifiedController{resourceID: stubInstanceID}
+
+	cfg := defaultRuntimeConfig()
+
+	enrichInstanceIdentity(t.Context(), deps, zap.NewNop(), cfg, controller, exporter)
+
+	if called {
+		t.Fatal("expected resolver not to be built when display name lookup is disabled")
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if bytes.Contains(snapshot, []byte("shaper_instance_info")) {
+		t.Fatalf("expected instance info to stay hidden, got %s", snapshot)
+	}
+}
+
+func TestEnrichInstanceIdentityAttachesDisplayName(t *testing.T) {
+	t.Parallel()
+
+	resolver := &stubDisplayNameResolver{name: "web-01"}
+
+	var region string
+
+	deps := runDeps{
+		newDisplayNameResolver: func(r string) (displayNameResolver, error) {
+			region = r
+
+			return resolver, nil
+		},
+	}
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.DisplayName = true
+	cfg.OCI.Region = stubRegion
+
+	core, observed := observer.New(zap.InfoLevel)
+	exporter := metricshttp.NewExporter()
+	controller := &identifiedController{resourceID: stubInstanceID}
+
+	logger := enrichInstanceIdentity(t.Context(), deps, zap.New(core), cfg, controller, exporter)
+	logger.Info("probe")
+
+	if region != stubRegion {
+		t.Fatalf("expected resolver region %q, got %q", stubRegion, region)
+	}
+
+	if resolver.requested != stubInstanceID {
+		t.Fatalf("expected lookup for %q, got %q", stubInstanceID, resolver.requested)
+	}
+
+	entries := observed.FilterMessage("probe").All()
+	if len(entries) != 1 || entries[0].ContextMap()["displayName"] != "web-01" {
+		t.Fatalf("expected displayName field on logger, got %+v", observed.All())
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	want := fmt.Sprintf(
+		"shaper_instance_info{instance_id=%q,display_name=\"web-01\"} 1",
+		stubInstanceID,
+	)
+	if !bytes.Contains(snapshot, []byte(want)) {
+		t.Fatalf("expected %q in metrics, got %s", want, snapshot)
+	}
+}
+
+func TestEnrichInstanceIdentityWarnsOnLookupFailure(t *testing.T) {
+	t.Parallel()
+
+	deps := runDeps{
+		newDisplayNameResolver: func(string) (displayNameResolver, error) {
+			return &stubDisplayNameResolver{err: errStubQueryFailure}, nil
+		},
+	}
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.DisplayName = true
+
+	core, observed := observer.New(zap.WarnLevel)
+	logger := zap.New(core)
+	exporter := metricshttp.NewExporter()
+	controller := &identifiedController{resourceID: stubInstanceID}
+
+	enriched := enrichInstanceIdentity(t.Context(), deps, logger, cfg, controller, exporter)
+	if enriched != logger {
+		t.Fatal("expected original logger when lookup fails")
+	}
+
+	if observed.FilterMessage("failed to resolve instance display name").Len() != 1 {
+		t.Fatalf("expected warning for failed lookup, got %+v", observed.All())
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !bytes.Contains(snapshot, []byte(`display_name=""`)) {
+		t.Fatalf("expected empty display name label after failure, got %s", snapshot)
+	}
+}
+
+func TestEnrichInstanceIdentitySkipsOfflineAndAnonymousControllers(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	deps := runDeps{
+		newDisplayNameResolver: func(string) (displayNameResolver, error) {
+			called = true
+
+			return new(stubDisplayNameResolver), nil
+		},
+	}
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.DisplayName = true
+
+	enrichInstanceIdentity(t.Context(), deps, zap.NewNop(), cfg, new(stubController), nil)
+	enrichInstanceIdentity(t.Context(), deps, zap.NewNop(), cfg, new(identifiedController), nil)
+
+	cfg.OCI.Offline = true
+	enrichInstanceIdentity(
+		t.Context(),
+		deps,
+		zap.NewNop(),
+		cfg,
+		&identifiedController{resourceID: stubInstanceID},
+		nil,
+	)
+
+	if called {
+		t.Fatal("expected resolver not to be built for offline or anonymous controllers")
+	}
+}
+
+type restartingController struct {
+	stubController
+
+	handler func(failures int, lastErr error)
+}
+
+func (r *restartingController) SetEstimatorRestartHandler(
+	handler func(failures int, lastErr error),
+) bool {
+	r.handler = handler
+
+	return true
+}
+
+func TestConfigureEstimatorRestartLogEmitsRecoveryEntry(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := &restartingController{stubController: stubController{mode: modeEnforce}}
+
+	configureEstimatorRestartLog(zap.New(core), controller)
+
+	if controller.handler == nil {
+		t.Fatal("expected restart handler to be installed")
+	}
+
+	controller.handler(5, errStubQueryFailure)
+
+	entries := logs.FilterMessageSnippet("estimator source recreated").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected a single recovery log entry, got %d", len(entries))
+	}
+
+	if got := entries[0].ContextMap()["failures"]; got != int64(5) {
+		t.Fatalf("expected failures field 5, got %v", got)
+	}
+
+	configureEstimatorRestartLog(zap.NewNop(), &stubController{mode: modeDryRun})
+}
+
+type skewReportingController struct {
+	stubController
+
+	handler func(skew time.Duration)
+}
+
+func (s *skewReportingController) SetClockSkewHandler(handler func(skew time.Duration)) bool {
+	s.handler = handler
+
+	return true
+}
+
+func TestConfigureClockSkewLogWarnsAndRecovers(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := &skewReportingController{stubController: stubController{mode: modeEnforce}}
+
+	configureClockSkewLog(zap.New(core), controller)
+
+	if controller.handler == nil {
+		t.Fatal("expected skew handler to be installed")
+	}
+
+	controller.handler(-10 * time.Minute)
+	controller.handler(0)
+
+	warnings := logs.FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warnings) != 1 || warnings[0].ContextMap()["skew"] != -10*time.Minute {
+		t.Fatalf("expected a single skew warning, got %+v", warnings)
+	}
+
+	if logs.FilterMessageSnippet("back in line").Len() != 1 {
+		t.Fatal("expected a recovery entry once the skew clears")
+	}
+}
+
+type pauseReportingController struct {
+	stubController
+
+	handler func(gap time.Duration)
+}
+
+func (p *pauseReportingController) SetPauseHandler(handler func(gap time.Duration)) {
+	p.handler = handler
+}
+
+func TestConfigurePauseLogWarns(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := &pauseReportingController{stubController: stubController{mode: modeEnforce}}
+
+	configurePauseLog(zap.New(core), controller)
+
+	if controller.handler == nil {
+		t.Fatal("expected pause handler to be installed")
+	}
+
+	controller.handler(90 * time.Minute)
+
+	warnings := logs.FilterMessageSnippet("resumed after a pause").All()
+	if len(warnings) != 1 || warnings[0].ContextMap()["gap"] != 90*time.Minute {
+		t.Fatalf("expected a single pause warning, got %+v", warnings)
+	}
+}
+
+func TestConfigureLibraryLoggingRoutesPoolDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	pool, err := shape.NewPool(1, 0)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	configureLibraryLogging(zap.New(core), &stubController{mode: modeEnforce}, pool)
+	pool.SetTarget(0.5)
+
+	entries := logs.FilterMessage("duty-cycle target updated").All()
+	if len(entries) != 1 || entries[0].ContextMap()["target"] != 0.5 {
+		t.Fatalf("expected the pool to log through zap, got %+v", entries)
+	}
+}
+
+type loggerReceivingMetricsClient struct {
+	*instancePrincipalMetricsClient
+
+	logger logging.Logger
+}
+
+func (l *loggerReceivingMetricsClient) SetLogger(logger logging.Logger) {
+	l.logger = logger
+}
+
+func TestWithLibraryLoggingInjectsMonitoringClients(t *testing.T) {
+	t.Parallel()
+
+	client := &loggerReceivingMetricsClient{instancePrincipalMetricsClient: nil, logger: nil}
+	ctx := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) { return client, nil },
+	)
+
+	ctx = withLibraryLogging(ctx, zap.NewNop())
+
+	built, err := metricsClientFactoryFromContext(ctx)("ocid1.compartment", "us-ashburn-1")
+	if err != nil || built != client {
+		t.Fatalf("expected the wrapped factory to return the client, got %v (%v)", built, err)
+	}
+
+	if client.logger == nil {
+		t.Fatal("expected the monitoring client to receive the library logger")
+	}
+}
+
+type scopedMetricsClient struct {
+	*instancePrincipalMetricsClient
+
+	scope oci.QueryScope
+	err   error
+}
+
+func (s *scopedMetricsClient) SetQueryScope(scope oci.QueryScope) error {
+	s.scope = scope
+
+	return s.err
+}
+
+func TestWithQueryScopeScopesMonitoringClients(t *testing.T) {
+	t.Parallel()
+
+	scope := oci.QueryScope{ResourceGroup: "fleet", Dimensions: nil}
+	client := &scopedMetricsClient{instancePrincipalMetricsClient: nil}
+	base := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) { return client, nil },
+	)
+
+	if withQueryScope(base, oci.QueryScope{ResourceGroup: "", Dimensions: nil}) != base {
+		t.Fatal("expected an empty scope to leave the factory alone")
+	}
+
+	factory := metricsClientFactoryFromContext(withQueryScope(base, scope))
+
+	built, err := factory("ocid1.compartment", "us-ashburn-1")
+	if err != nil || built != client || client.scope.ResourceGroup != "fleet" {
+		t.Fatalf("expected the client to be scoped, got %v (%v)", client.scope, err)
+	}
+
+	client.err = oci.ErrInvalidQueryScope
+
+	_, err = factory("ocid1.compartment", "us-ashburn-1")
+	if !errors.Is(err, oci.ErrInvalidQueryScope) {
+		t.Fatalf("expected the scope error, got %v", err)
+	}
+
+	plain := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) {
+			return oci.NewStaticMetricsClient(0.3), nil
+		},
+	)
+
+	_, err = metricsClientFactoryFromContext(withQueryScope(plain, scope))("c", "r")
+	if err != nil {
+		t.Fatalf("expected clients without scope support to pass through, got %v", err)
+	}
+
+	failing := withMetricsClientFactory(
+		context.Background(),
+		func(string, string) (oci.MetricsClient, error) { return nil, errMetricsDelegateNil },
+	)
+
+	_, err = metricsClientFactoryFromContext(withQueryScope(failing, scope))("c", "r")
+	if !errors.Is(err, errMetricsDelegateNil) {
+		t.Fatalf("expected the build error, got %v", err)
+	}
+}
+
+type scopingQuerier struct {
+	skewTrackingQuerier
+
+	scope oci.QueryScope
+}
+
+func (s *scopingQuerier) SetQueryScope(scope oci.QueryScope) error {
+	s.scope = scope
+
+	return nil
+}
+
+func TestInstancePrincipalMetricsClientForwardsQueryScope(t *testing.T) {
+	t.Parallel()
+
+	querier := new(scopingQuerier)
+	scope := oci.QueryScope{ResourceGroup: "fleet", Dimensions: nil}
+
+	err := (&instancePrincipalMetricsClient{client: querier}).SetQueryScope(scope)
+	if err != nil || querier.scope.ResourceGroup != "fleet" {
+		t.Fatalf("expected the scope to reach the delegate, got %v (%v)", querier.scope, err)
+	}
+
+	err = (&instancePrincipalMetricsClient{client: new(skewTrackingQuerier)}).SetQueryScope(scope)
+	if err != nil {
+		t.Fatalf("expected delegates without scope support to be skipped, got %v", err)
+	}
+
+	var unset *instancePrincipalMetricsClient
+
+	err = unset.SetQueryScope(scope)
+	if !errors.Is(err, errMetricsDelegateNil) {
+		t.Fatalf("expected errMetricsDelegateNil, got %v", err)
+	}
+}
+
+type idleReportingController struct {
+	stubController
+
+	handler func(status adapt.IdleStatus)
+}
+
+func (i *idleReportingController) SetIdleHandler(handler func(status adapt.IdleStatus)) {
+	i.handler = handler
+}
+
+func TestConfigureIdleReportExportsAndLogs(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := &idleReportingController{stubController: stubController{mode: modeEnforce}}
+	exporter := metricshttp.NewExporter()
+
+	configureIdleReport(zap.New(core), controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected idle handler to be installed")
+	}
+
+	controller.handler(adapt.IdleStatus{Idle: true, CPUP95: 0.12})
+	controller.handler(adapt.IdleStatus{CPUP95: 0.12, NetworkRatio: 0.3, NetworkKnown: true})
+
+	warnings := logs.FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warnings) != 1 || warnings[0].ContextMap()["idle"] != true {
+		t.Fatalf("expected a single idle warning, got %+v", warnings)
+	}
+
+	recovered := logs.FilterMessage("instance not idle by oci definition").All()
+	if len(recovered) != 1 || recovered[0].ContextMap()["networkRatio"] != 0.3 {
+		t.Fatalf("expected a not-idle entry with the network ratio, got %+v", recovered)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_oci_idle 0\n") {
+		t.Fatalf("expected exporter to track the latest idle status, got %s", data)
+	}
+}
+
+type droppingController struct {
+	stubController
+
+	handler func()
+}
+
+func (d *droppingController) SetObservationDropHandler(handler func()) bool {
+	d.handler = handler
+
+	return true
+}
+
+func TestConfigureObservationDropsCountsDrops(t *testing.T) {
+	t.Parallel()
+
+	controller := new(droppingController)
+	exporter := metricshttp.NewExporter()
+
+	configureObservationDrops(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected drop handler to be installed")
+	}
+
+	controller.handler()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(data), "estimator_dropped_observations_total 1\n") {
+		t.Fatalf("expected the dropped observation to be counted, got %s", data)
+	}
+
+	withoutExporter := new(droppingController)
+	configureObservationDrops(withoutExporter, nil)
+
+	if withoutExporter.handler != nil {
+		t.Fatal("expected no drop handler without an exporter")
+	}
+}
+
+type samplingController struct {
+	stubController
+
+	observation est.Observation
+	err         error
+}
+
+func (s *samplingController) SampleHostCPU(context.Context) (est.Observation, error) {
+	return s.observation, s.err
+}
+
+func TestConfigureScrapeSamplingRefreshesHostCPU(t *testing.T) {
+	t.Parallel()
+
+	controller := new(samplingController)
+	controller.observation.Utilisation = 0.42
+	exporter := metricshttp.NewExporter()
+	exporter.ObserveHostCPU(0.1)
+
+	scrape := func() string {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		return recorder.Body.String()
+	}
+
+	configureScrapeSampling(controller, exporter, 0)
+
+	if body := scrape(); !strings.Contains(body, "host_cpu_percent 10.00\n") {
+		t.Fatalf("expected no scrape sampling without an interval, got %s", body)
+	}
+
+	configureScrapeSampling(controller, exporter, time.Nanosecond)
+
+	if body := scrape(); !strings.Contains(body, "host_cpu_percent 42.00\n") {
+		t.Fatalf("expected the scrape to sample the host, got %s", body)
+	}
+
+	controller.err = errStubSample
+	controller.observation.Utilisation = 0.9
+	time.Sleep(time.Millisecond)
+
+	if body := scrape(); !strings.Contains(body, "host_cpu_percent 42.00\n") {
+		t.Fatalf("expected a failed sample to keep the value, got %s", body)
+	}
+
+	configureScrapeSampling(new(stubController), nil, time.Second)
+}
+
+func TestConfigureTextfileWritesUntilStopped(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	dir := t.TempDir()
+	exporter := metricshttp.NewExporter()
+	path := filepath.Join(dir, metricshttp.TextfileName)
+
+	var cfg httpConfig
+
+	configureTextfile(t.Context(), zap.New(core), cfg, exporter)()
+
+	cfg.TextfileDir = dir
+	cfg.TextfileInterval = time.Millisecond
+
+	configureTextfile(t.Context(), zap.New(core), cfg, nil)()
+
+	stop := configureTextfile(t.Context(), zap.New(core), cfg, exporter)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the textfile to be written: %v", err)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+
+	_, err := os.Stat(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected stopping to remove the textfile, got %v", err)
+	}
+
+	if logs.FilterMessage("writing metrics textfile").Len() != 1 {
+		t.Fatalf("expected one textfile log entry, got %+v", logs.All())
+	}
+}
+
+type blackoutController struct {
+	stubController
+
+	handler func(active bool)
+}
+
+func (b *blackoutController) SetBlackoutHandler(handler func(active bool)) {
+	b.handler = handler
+}
+
+func TestConfigureBlackoutReportExportsWindowState(t *testing.T) {
+	t.Parallel()
+
+	controller := new(blackoutController)
+	exporter := metricshttp.NewExporter()
+
+	configureBlackoutReport(controller, exporter, nil)
+
+	if controller.handler != nil {
+		t.Fatal("expected no blackout handler without windows")
+	}
+
+	windows := []adapt.BlackoutWindow{{Start: time.Hour, End: 2 * time.Hour, Location: nil}}
+	configureBlackoutReport(controller, exporter, windows)
+
+	render := func() string {
+		t.Helper()
+
+		data, err := exporter.Render()
+		if err != nil {
+			t.Fatalf("render metrics: %v", err)
+		}
+
+		return string(data)
+	}
+
+	if body := render(); !strings.Contains(body, "shaper_blackout_active 0\n") {
+		t.Fatalf("expected the blackout gauge to start at 0, got %s", body)
+	}
+
+	controller.handler(true)
+
+	if body := render(); !strings.Contains(body, "shaper_blackout_active 1\n") {
+		t.Fatalf("expected the blackout gauge to follow the controller, got %s", body)
+	}
+}
+
+type windDownController struct {
+	stubController
+
+	deadline time.Time
+	ramp     time.Duration
+}
+
+func (w *windDownController) SetWindDown(deadline time.Time, ramp time.Duration) {
+	w.deadline = deadline
+	w.ramp = ramp
+}
+
+func TestConfigureWindDownPlansRampBeforeDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+
+	controller := new(windDownController)
+	configureWindDown(ctx, controller, time.Minute)
+
+	deadline, _ := ctx.Deadline()
+	if !controller.deadline.Equal(deadline) || controller.ramp != 6*time.Second {
+		t.Fatalf(
+			"expected a 6s ramp before %v, got %v before %v",
+			deadline,
+			controller.ramp,
+			controller.deadline,
+		)
+	}
+
+	unbounded := new(windDownController)
+	configureWindDown(t.Context(), unbounded, time.Minute)
+	configureWindDown(ctx, unbounded, 0)
+
+	if !unbounded.deadline.IsZero() {
+		t.Fatal("expected no wind-down without --shutdown-after and a deadline")
+	}
+}
+
+type burstReportingController struct {
+	stubController
+
+	handler func(status adapt.BurstStatus)
+}
+
+func (b *burstReportingController) SetBurstHandler(handler func(status adapt.BurstStatus)) {
+	b.handler = handler
+}
+
+func TestConfigureBurstReportExportsCredits(t *testing.T) {
+	t.Parallel()
+
+	controller := new(burstReportingController)
+	exporter := metricshttp.NewExporter()
+
+	configureBurstReport(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected burst handler to be installed")
+	}
+
+	controller.handler(adapt.BurstStatus{
+		Baseline:            0.125,
+		Credits:             0.5,
+		Utilisation:         0.25,
+		Draining:            true,
+		ThrottleIn:          time.Hour,
+		TargetAboveBaseline: true,
+	})
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_burst_credits_ratio 0.5000\n",
+		"shaper_burst_throttle_projected_seconds 3600\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in %s", want, data)
+		}
+	}
+
+	withoutExporter := new(burstReportingController)
+	configureBurstReport(withoutExporter, nil)
+
+	if withoutExporter.handler != nil {
+		t.Fatal("expected no burst handler without an exporter")
+	}
+}
+
+type episodeReportingController struct {
+	stubController
+
+	handler func(episode adapt.SuppressionEpisode)
+}
+
+func (e *episodeReportingController) SetSuppressionEpisodeHandler(
+	handler func(episode adapt.SuppressionEpisode),
+) {
+	e.handler = handler
+}
+
+func TestConfigureSuppressionEpisodeReportExportsEpisodes(t *testing.T) {
+	t.Parallel()
+
+	controller := new(episodeReportingController)
+	exporter := metricshttp.NewExporter()
+
+	configureSuppressionEpisodeReport(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected suppression episode handler to be installed")
+	}
+
+	start := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	controller.handler(adapt.SuppressionEpisode{
+		Start:        start,
+		End:          start.Add(10 * time.Minute),
+		PeakHostLoad: 0.9,
+		Deficit:      3 * time.Minute,
+		Sources:      []string{adapt.SuppressionSourceHostLoad},
+	})
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_suppression_episodes_total 1\n",
+		"shaper_suppression_deficit_seconds_total 180.000\n",
+		"shaper_suppression_episode_duration_seconds_sum 600.000\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in %s", want, data)
+		}
+	}
+
+	withoutExporter := new(episodeReportingController)
+	configureSuppressionEpisodeReport(withoutExporter, nil)
+
+	if withoutExporter.handler != nil {
+		t.Fatal("expected no suppression episode handler without an exporter")
+	}
+}
+
+type errorReportingController struct {
+	stubController
+
+	handler func(component string, err error)
+}
+
+func (c *errorReportingController) SetErrorHandler(handler func(component string, err error)) {
+	c.handler = handler
+}
+
+func TestConfigureErrorReportExportsLastErrors(t *testing.T) {
+	t.Parallel()
+
+	controller := new(errorReportingController)
+	exporter := metricshttp.NewExporter()
+
+	configureErrorReport(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected error handler to be installed")
+	}
+
+	controller.handler(adapt.ErrorComponentOCI, fmt.Errorf("query: %w", oci.ErrNoMetricsData))
+	controller.handler(adapt.ErrorComponentEstimator, os.ErrNotExist)
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_last_error_info{component=\"estimator\",code=\"NotFound\"} 1\n",
+		"shaper_last_error_info{component=\"oci\",code=\"NoData\"} 1\n",
+		"shaper_last_error_timestamp_seconds{component=\"oci\"} ",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in %s", want, data)
+		}
+	}
+
+	withoutExporter := new(errorReportingController)
+	configureErrorReport(withoutExporter, nil)
+
+	if withoutExporter.handler != nil {
+		t.Fatal("expected no error handler without an exporter")
+	}
+}
+
+type selfLoadController struct {
+	stubController
+
+	meter   adapt.BusyMeter
+	handler func(share float64)
+}
+
+func (c *selfLoadController) SetSelfLoadMeter(meter adapt.BusyMeter) { c.meter = meter }
+
+func (c *selfLoadController) SetSelfLoadHandler(handler func(share float64)) {
+	c.handler = handler
+}
+
+func TestConfigureSelfLoadExclusionUsesPoolBusyTime(t *testing.T) {
+	t.Parallel()
+
+	pool, err := shape.NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	controller := new(selfLoadController)
+	exporter := metricshttp.NewExporter()
+
+	configureSelfLoadExclusion(controller, hookedPool{Pool: pool, actuator: nil}, exporter)
+
+	if controller.meter == nil || controller.handler == nil {
+		t.Fatal("expected the pool to be installed as the self load meter")
+	}
+
+	controller.handler(0.2)
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_self_cpu_percent 20.00\n") {
+		t.Fatalf("expected the subtracted share to be exported, got %s", data)
+	}
+
+	withoutPool := new(selfLoadController)
+	configureSelfLoadExclusion(withoutPool, nil, exporter)
+
+	if withoutPool.meter != nil {
+		t.Fatal("expected no meter without a worker pool")
+	}
+}
+
+type skewTrackingQuerier struct {
+	handler func(skew time.Duration)
+}
+
+func (s *skewTrackingQuerier) QueryP95CPU(context.Context, string, bool) (float32, error) {
+	return 0, nil
+}
+
+func (s *skewTrackingQuerier) SetClockSkewHandler(handler func(skew time.Duration)) {
+	s.handler = handler
+}
+
+func TestInstancePrincipalMetricsClientForwardsSkewHandler(t *testing.T) {
+	t.Parallel()
+
+	querier := new(skewTrackingQuerier)
+	client := &instancePrincipalMetricsClient{client: querier}
+
+	client.SetClockSkewHandler(func(time.Duration) {})
+
+	if querier.handler == nil {
+		t.Fatal("expected skew handler to reach the delegate")
+	}
+}
+
+type tokenTrackingQuerier struct {
+	skewTrackingQuerier
+
+	handler func(expiry time.Time)
+}
+
+func (s *tokenTrackingQuerier) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	s.handler = handler
+}
+
+func TestInstancePrincipalMetricsClientForwardsTokenExpiryHandler(t *testing.T) {
+	t.Parallel()
+
+	querier := new(tokenTrackingQuerier)
+	client := &instancePrincipalMetricsClient{client: querier}
+
+	client.SetTokenExpiryHandler(func(time.Time) {})
+
+	if querier.handler == nil {
+		t.Fatal("expected token expiry handler to reach the delegate")
+	}
+
+	var unset *instancePrincipalMetricsClient
+
+	unset.SetTokenExpiryHandler(func(time.Time) {})
+}
+
+type tokenReportingController struct {
+	stubController
+
+	handler func(expiry time.Time)
+}
+
+func (c *tokenReportingController) SetTokenExpiryHandler(handler func(expiry time.Time)) bool {
+	c.handler = handler
+
+	return true
+}
+
+func TestConfigureTokenExpiryReportExportsExpiry(t *testing.T) {
+	t.Parallel()
+
+	controller := &tokenReportingController{stubController: stubController{mode: modeEnforce}}
+	exporter := metricshttp.NewExporter()
+
+	configureTokenExpiryReport(controller, nil)
+
+	if controller.handler != nil {
+		t.Fatal("expected no handler without an exporter")
+	}
+
+	configureTokenExpiryReport(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected token expiry handler to be installed")
+	}
+
+	controller.handler(time.Now().Add(time.Hour))
+
+	body, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(body), "shaper_oci_token_expiry_seconds 3") {
+		t.Fatalf("expected the time until expiry to be exported, got:\n%s", body)
+	}
+}
+
+type windowTrackingQuerier struct {
+	skewTrackingQuerier
+
+	handler func(window oci.QueryWindow)
+}
+
+func (s *windowTrackingQuerier) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	s.handler = handler
+}
+
+func TestInstancePrincipalMetricsClientForwardsQueryWindowHandler(t *testing.T) {
+	t.Parallel()
+
+	querier := new(windowTrackingQuerier)
+	client := &instancePrincipalMetricsClient{client: querier}
+
+	client.SetQueryWindowHandler(func(oci.QueryWindow) {})
+
+	if querier.handler == nil {
+		t.Fatal("expected query window handler to reach the delegate")
+	}
+
+	var unset *instancePrincipalMetricsClient
+
+	unset.SetQueryWindowHandler(func(oci.QueryWindow) {})
+}
+
+type windowReportingController struct {
+	stubController
+
+	handler func(window oci.QueryWindow)
+}
+
+func (c *windowReportingController) SetQueryWindowHandler(
+	handler func(window oci.QueryWindow),
+) bool {
+	c.handler = handler
+
+	return true
+}
+
+func TestConfigureQueryWindowReportExportsWindow(t *testing.T) {
+	t.Parallel()
+
+	controller := &windowReportingController{stubController: stubController{mode: modeEnforce}}
+	exporter := metricshttp.NewExporter()
+
+	configureQueryWindowReport(controller, nil)
+
+	if controller.handler != nil {
+		t.Fatal("expected no handler without an exporter")
+	}
+
+	configureQueryWindowReport(controller, exporter)
+
+	if controller.handler == nil {
+		t.Fatal("expected query window handler to be installed")
+	}
+
+	end := time.Unix(1_700_604_800, 0)
+	controller.handler(oci.QueryWindow{Start: end.Add(-7 * 24 * time.Hour), End: end})
+
+	body, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(body), "shaper_oci_query_window_seconds 604800\n") {
+		t.Fatalf("expected the window length to be exported, got:\n%s", body)
+	}
+}
+
+type configuredController struct {
+	stubController
+
+	cfg adapt.Config
+}
+
+func (c *configuredController) Config() adapt.Config { return c.cfg }
+
+func TestConfigureMetricsExportsControllerBand(t *testing.T) {
+	t.Parallel()
+
+	exporter := metricshttp.NewExporter()
+	active := adapt.DefaultConfig()
+	active.GoalHigh = 0.31
+	controller := &configuredController{
+		stubController: stubController{mode: modeEnforce},
+		cfg:            active,
+	}
+
+	var deps runDeps
+
+	err := configureMetrics(
+		context.Background(),
+		deps,
+		zap.NewNop(),
+		defaultRuntimeConfig(),
+		exporter,
+		nil,
+		controller,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("configureMetrics returned error: %v", err)
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_goal_low_ratio 0.230000",
+		"shaper_goal_high_ratio 0.310000",
+		"shaper_suppress_threshold_ratio 0.850000",
+	} {
+		if !bytes.Contains(snapshot, []byte(want)) {
+			t.Fatalf("expected %q in metrics, got %s", want, snapshot)
+		}
+	}
+}
+
+type suppressibleStubController struct {
+	stubController
+
+	requests chan string
+}
+
+func (c *suppressibleStubController) RequestSuppression(source string, _ time.Time) {
+	c.requests <- source
+}
+
+func (c *suppressibleStubController) SuppressionRequests() map[string]time.Time {
+	return map[string]time.Time{}
+}
+
+func (c *suppressibleStubController) SuppressionEpisodes() []adapt.SuppressionEpisode {
+	return nil
+}
+
+func TestConfigureSuppressionWatchesSignalFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "suppress")
+
+	err := os.WriteFile(path, nil, 0o600)
+	if err != nil {
+		t.Fatalf("write signal file: %v", err)
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.Suppress.File = path
+
+	controller := &suppressibleStubController{requests: make(chan string, 1)}
+
+	configureSuppression(t.Context(), cfg, controller)
+
+	select {
+	case source := <-controller.requests:
+		if source != "file" {
+			t.Fatalf("expected file source, got %q", source)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the signal file to request suppression")
+	}
+}
+
+func TestRunExitsWhenPoolStartAborts(t *testing.T) {
+	t.Parallel()
+
+	deps := defaultRunDeps()
+	deps.loadConfig = loadConfigStub()
+	deps.newLogger = func(string, string) (*zap.Logger, error) { return zap.NewNop(), nil }
+	deps.newController = func(
+		context.Context,
+		string,
+		runtimeConfig,
+		imds.Client,
+		adapt.MetricsRecorder,
+	) (adapt.Controller, poolStarter, error) {
+		pool := &stubPoolStarter{startErr: shape.ErrStartAborted}
+
+		return &stubController{mode: modeEnforce}, pool, nil
+	}
+	deps.startMetricsServer = nil
+
+	exitCode := run(t.Context(), []string{"--mode", modeEnforce}, deps, io.Discard)
+	if exitCode != exitCodePoolStartError {
+		t.Fatalf("expected pool start exit code, got %d", exitCode)
+	}
+}
+
+//nolint:paralleltest // swaps the package-level cgroup and scheduling seams.
+func TestReportPoolStartOutcomeLowersCgroupWeightOnFallback(t *testing.T) {
+	original := lowerCgroupWeight
+	originalProbe := probeScheduling
+
+	t.Cleanup(func() {
+		lowerCgroupWeight = original
+		probeScheduling = originalProbe
+	})
+
+	probeScheduling = func() sched.Capabilities {
+		return sched.Capabilities{ //nolint:exhaustruct
+			Platform:  "linux/arm64",
+			SchedIdle: sched.Check{Status: sched.StatusDenied, Err: errStubSchedRefused},
+			Seccomp:   sched.SeccompFilter,
+		}
+	}
+
+	var lowered int
+
+	lowerCgroupWeight = func() error {
+		lowered++
+
+		return nil
+	}
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	exporter := metricshttp.NewExporter()
+
+	reportPoolStartOutcome(
+		zap.New(core),
+		&stubPoolStarter{
+			outcome:  string(shape.StartFailureFallback),
+			policies: map[string]int{shape.WorkerPolicyNice: 2},
+		},
+		exporter,
+	)
+	reportPoolStartOutcome(
+		zap.New(core),
+		&stubPoolStarter{outcome: shape.StartOutcomeOK},
+		nil,
+	)
+
+	if lowered != 1 {
+		t.Fatalf("expected cgroup weight to be lowered once, got %d", lowered)
+	}
+
+	if logs.FilterMessageSnippet("without sched_idle").Len() != 1 {
+		t.Fatalf("expected a single degraded start warning, got %+v", logs.All())
+	}
+
+	fields := logs.FilterMessageSnippet("without sched_idle").All()[0].ContextMap()
+	if fields["schedIdle"] != "denied (operation not permitted)" ||
+		fields["seccomp"] != sched.SeccompFilter || fields["platform"] != "linux/arm64" ||
+		!strings.Contains(fmt.Sprint(fields["hint"]), "seccomp") {
+		t.Fatalf("expected scheduling capabilities on the warning, got %v", fields)
+	}
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !bytes.Contains(snapshot, []byte(`shaper_pool_start_outcome{outcome="fallback"} 1`)) {
+		t.Fatalf("expected pool start outcome metric, got %s", snapshot)
+	}
+
+	if !bytes.Contains(snapshot, []byte(`shaper_worker_sched_policy{policy="nice"} 2`)) {
+		t.Fatalf("expected worker policy metric, got %s", snapshot)
+	}
+}
+
+func TestReportWorkerTimingExportsPoolTiming(t *testing.T) {
+	t.Parallel()
+
+	pool, err := shape.NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	pool.SetTarget(0.02)
+
+	exporter := metricshttp.NewExporter()
+
+	reportWorkerTiming(new(stubPoolStarter), exporter)
+	reportWorkerTiming(hookedPool{Pool: pool, actuator: nil}, nil)
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if strings.Contains(string(snapshot), "shaper_worker_sleep_overshoot_seconds") {
+		t.Fatalf("expected no overshoot series without a pool, got:\n%s", snapshot)
+	}
+
+	reportWorkerTiming(hookedPool{Pool: pool, actuator: nil}, exporter)
+
+	snapshot, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_worker_sleep_overshoot_seconds 0.000000",
+		"shaper_worker_effective_quantum_seconds 0.010000",
+	} {
+		if !strings.Contains(string(snapshot), want) {
+			t.Fatalf("expected %q to be exported, got:\n%s", want, snapshot)
+		}
+	}
+}
+
+func TestReportWorkerHeartbeatsExportsPoolLiveness(t *testing.T) {
+	t.Parallel()
+
+	pool, err := shape.NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	exporter := metricshttp.NewExporter()
+
+	reportWorkerHeartbeats(new(stubPoolStarter), exporter)
+	reportWorkerHeartbeats(hookedPool{Pool: pool, actuator: nil}, nil)
+
+	snapshot, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if strings.Contains(string(snapshot), "shaper_worker_heartbeat_age_seconds") {
+		t.Fatalf("expected no heartbeat series without a pool, got:\n%s", snapshot)
+	}
+
+	reportWorkerHeartbeats(hookedPool{Pool: pool, actuator: nil}, exporter)
+
+	snapshot, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_worker_heartbeat_age_seconds 0.000000",
+		"shaper_worker_restarts_total 0",
+	} {
+		if !strings.Contains(string(snapshot), want) {
+			t.Fatalf("expected %q to be exported, got:\n%s", want, snapshot)
+		}
+	}
+}
+
+func TestLogIgnoredEnvWarnsPerOverride(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zap.WarnLevel)
+
+	logIgnoredEnv(zap.New(core), []string{
+		"SHAPER_TARGET_START: bad float",
+		"OCI_OFFLINE: bad bool",
+	})
+
+	entries := observed.FilterMessageSnippet("ignoring malformed environment override").All()
+	if len(entries) != 2 ||
+		entries[0].ContextMap()["override"] != "SHAPER_TARGET_START: bad float" {
+		t.Fatalf("expected one warning per ignored override, got %v", observed.All())
+	}
 }
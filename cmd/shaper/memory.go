@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/memtrim"
+)
+
+// configureMemory applies memory.limitMiB as the Go soft memory limit and
+// starts the periodic trim of memory.trimInterval.
+func configureMemory(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg memoryConfig,
+	exporter *metricshttp.Exporter,
+) error {
+	trimmer, err := memtrim.New(cfg.trimConfig())
+	if err != nil {
+		return fmt.Errorf("build memory trimmer: %w", err)
+	}
+
+	previous, applied := trimmer.ApplyLimit()
+	if applied {
+		logger.Info(
+			"go memory limit applied",
+			zap.Int("limitMiB", cfg.LimitMiB),
+			zap.Int64("previousBytes", previous),
+		)
+	}
+
+	go trimmer.Run(ctx, newMemoryReporter(logger, exporter))
+
+	return nil
+}
+
+// newMemoryReporter exports each resident set check and logs the trims at
+// debug level. A platform without a readable resident set is reported once,
+// after which Run stops.
+func newMemoryReporter(
+	logger *zap.Logger,
+	exporter *metricshttp.Exporter,
+) func(status memtrim.Status, err error) {
+	return func(status memtrim.Status, err error) {
+		if err != nil {
+			if errors.Is(err, memtrim.ErrUnsupported) {
+				logger.Warn("memory trimming disabled", zap.Error(err))
+
+				return
+			}
+
+			logger.Warn("memory check failed", zap.Error(err))
+
+			return
+		}
+
+		if exporter != nil {
+			exporter.SetMemoryStatus(status.RSS, status.Trims)
+		}
+
+		if status.Trimmed {
+			logger.Debug(
+				"returned freed memory to the os",
+				zap.Int64("rssBytes", status.RSS),
+				zap.Int64("freedBytes", status.Freed),
+			)
+		}
+	}
+}
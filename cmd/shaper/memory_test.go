@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/memtrim"
+)
+
+var errStubStatm = errors.New("stub: statm unreadable")
+
+func TestLoadConfigParsesMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.yaml")
+
+	err := os.WriteFile(
+		path,
+		[]byte("memory:\n  limitMiB: 96\n  trimInterval: 10m\n  trimAboveMiB: 64\n"),
+		0o600,
+	)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv(envMemoryTrimAbove, "80")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertIntEqual(t, "limitMiB", cfg.Memory.LimitMiB, 96)
+	assertDurationEqual(t, "trimInterval", cfg.Memory.TrimInterval, 10*time.Minute)
+	assertIntEqual(t, "trimAboveMiB", cfg.Memory.TrimAboveMiB, 80)
+
+	if cfg.Memory.trimConfig() != (memtrim.Config{
+		Limit:     96 << 20,
+		Interval:  10 * time.Minute,
+		TrimAbove: 80 << 20,
+	}) {
+		t.Fatalf("unexpected trim config %+v", cfg.Memory.trimConfig())
+	}
+
+	err = os.WriteFile(path, []byte("memory:\n  trimInterval: -1m\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err = loadConfig(path)
+	if !errors.Is(err, memtrim.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse error exit code, got %d", code)
+	}
+}
+
+func TestMemoryReporterExportsChecks(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	exporter := metricshttp.NewExporter()
+	report := newMemoryReporter(zap.New(core), exporter)
+
+	report(memtrim.Status{RSS: 48 << 20, Trimmed: true, Freed: 8 << 20, Trims: 2}, nil)
+	report(memtrim.Status{RSS: 40 << 20, Trimmed: false, Freed: 0, Trims: 2}, nil)
+	report(memtrim.Status{}, errStubStatm)
+	report(memtrim.Status{}, memtrim.ErrUnsupported)
+
+	if trims := logs.FilterMessage("returned freed memory to the os").Len(); trims != 1 {
+		t.Fatalf("expected one trim logged, got %d", trims)
+	}
+
+	if failures := logs.FilterMessage("memory check failed").Len(); failures != 1 {
+		t.Fatalf("expected one failed check logged, got %d", failures)
+	}
+
+	if disabled := logs.FilterMessage("memory trimming disabled").Len(); disabled != 1 {
+		t.Fatalf("expected the unsupported platform logged, got %d", disabled)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_process_resident_memory_bytes 41943040\n") ||
+		!strings.Contains(string(data), "shaper_memory_trims_total 2\n") {
+		t.Fatalf("expected the latest check exported, got %s", data)
+	}
+
+	newMemoryReporter(zap.NewNop(), nil)(memtrim.Status{}, nil)
+}
+
+//nolint:paralleltest // changes the process-wide memory limit
+func TestConfigureMemoryAppliesTheLimit(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() { debug.SetMemoryLimit(previous) })
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	err := configureMemory(t.Context(), zap.New(core), memoryConfig{
+		LimitMiB:     1 << 20,
+		TrimInterval: 0,
+		TrimAboveMiB: 0,
+	}, nil)
+	if err != nil {
+		t.Fatalf("configureMemory: %v", err)
+	}
+
+	if limit := debug.SetMemoryLimit(-1); limit != 1<<40 {
+		t.Fatalf("expected a 1 TiB limit, got %d", limit)
+	}
+
+	if logs.FilterMessage("go memory limit applied").Len() != 1 {
+		t.Fatalf("expected the limit logged, got %v", logs.All())
+	}
+
+	err = configureMemory(t.Context(), zap.NewNop(), memoryConfig{
+		LimitMiB:     -1,
+		TrimInterval: 0,
+		TrimAboveMiB: 0,
+	}, nil)
+	if !errors.Is(err, memtrim.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/metadata"
+	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/suppress"
+)
+
+// configureMetadataWatch starts the periodic IMDS metadata audit for online
+// runs. A non-positive oci.metadataRefreshInterval disables it.
+func configureMetadataWatch(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	imdsClient imds.Client,
+	exporter *metricshttp.Exporter,
+	clients *metadata.MonitoringClients,
+) {
+	if cfg.OCI.Offline || imdsClient == nil || cfg.OCI.MetadataRefresh <= 0 {
+		return
+	}
+
+	watcher := metadata.NewWatcher(imdsClient, clients)
+	watcher.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	watcher.SetChangeHandler(metadataChangeHandler(logger, cfg, exporter))
+
+	go watcher.Run(ctx, cfg.OCI.MetadataRefresh)
+}
+
+// reconcileRegion compares oci.region with the region IMDS reports for the
+// instance, since Monitoring answers queries sent to the wrong region with no
+// data rather than an error. A mismatch is logged and, with
+// oci.preferInstanceRegion, the OCI clients are built for the instance's
+// region instead. Offline and noop runs, and runs that leave oci.region to
+// IMDS, skip the check.
+func reconcileRegion(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	imdsClient imds.Client,
+	mode string,
+) runtimeConfig {
+	configured := strings.TrimSpace(cfg.OCI.Region)
+	if cfg.OCI.Offline || imdsClient == nil || configured == "" ||
+		strings.TrimSpace(mode) == modeNoop {
+		return cfg
+	}
+
+	check, err := metadata.CheckRegion(ctx, imdsClient, configured)
+	if err != nil {
+		logger.Warn("failed to compare oci.region with the instance region", zap.Error(err))
+
+		return cfg
+	}
+
+	if !check.Mismatch() {
+		return cfg
+	}
+
+	fields := []zap.Field{
+		zap.String("configuredRegion", check.Configured),
+		zap.String("instanceRegion", check.Instance()),
+	}
+
+	if !cfg.OCI.PreferInstanceRegion {
+		logger.Warn(
+			"oci.region differs from the instance region; Monitoring queries may return no data",
+			fields...,
+		)
+
+		return cfg
+	}
+
+	logger.Warn("oci.region differs from the instance region; using the instance region", fields...)
+
+	cfg.OCI.Region = check.Instance()
+
+	return cfg
+}
+
+// configureMaintenanceWatch pauses shaping around the maintenance IMDS
+// announces for online runs. A non-positive suppression.maintenanceInterval
+// disables it, as does a controller without external suppression.
+func configureMaintenanceWatch(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	imdsClient imds.Client,
+	controller adapt.Controller,
+) {
+	if cfg.OCI.Offline || cfg.Suppress.MaintenanceInterval <= 0 {
+		return
+	}
+
+	reader, ok := imdsClient.(imds.MaintenanceReader)
+	if !ok {
+		return
+	}
+
+	target, ok := controller.(suppress.Requester)
+	if !ok {
+		return
+	}
+
+	watcher := suppress.NewMaintenanceWatcher(
+		reader,
+		target,
+		cfg.Suppress.MaintenanceInterval,
+		cfg.Suppress.MaintenanceLead,
+		cfg.Suppress.MaintenanceGrace,
+	)
+	watcher.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	go watcher.Run(ctx)
+}
+
+// metadataChangeHandler counts each change and warns when a resize leaves the
+// startup OCPU-seconds conversion stale.
+func metadataChangeHandler(
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	exporter *metricshttp.Exporter,
+) func(metadata.Change) {
+	return func(change metadata.Change) {
+		if exporter != nil {
+			exporter.ObserveMetadataChange(change.Field)
+		}
+
+		if change.Field == metadata.FieldOCPUs && cfg.Controller.OCPUSeconds.enabled() {
+			logger.Warn(
+				"ocpu count changed; restart to recompute ocpuSecondsPerHour targets",
+				zap.String("previous", change.Previous),
+				zap.String("current", change.Current),
+			)
+		}
+	}
+}
+
+// configureStatusMetadata starts writing the mode, state, and target into the
+// instance's custom metadata every oci.statusMetadataInterval. A missing
+// compute client only warns: the daemon shapes the same way without it.
+func configureStatusMetadata(
+	ctx context.Context,
+	logger *zap.Logger,
+	deps runDeps,
+	cfg runtimeConfig,
+	controller adapt.Controller,
+) {
+	if cfg.OCI.StatusMetadata <= 0 || cfg.OCI.Offline || deps.newStatusWriter == nil {
+		return
+	}
+
+	identifier, ok := controller.(resourceIdentifier)
+	if !ok {
+		return
+	}
+
+	instanceID := strings.TrimSpace(identifier.ResourceID())
+	if instanceID == "" {
+		return
+	}
+
+	writer, err := deps.newStatusWriter(cfg.OCI.Region)
+	if err != nil {
+		logger.Warn("failed to build compute client; instance status metadata disabled",
+			zap.Error(err))
+
+		return
+	}
+
+	publisher := metadata.NewStatusPublisher(writer, instanceID, controllerStatus(controller))
+	publisher.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	go publisher.Run(ctx, cfg.OCI.StatusMetadata)
+}
+
+// controllerStatus reads the status StatusPublisher writes from controller.
+func controllerStatus(controller adapt.Controller) func() metadata.Status {
+	introspector, _ := controller.(adapt.Introspector)
+
+	return func() metadata.Status {
+		status := metadata.Status{
+			Mode:   controller.Mode(),
+			State:  controller.State().String(),
+			Target: 0,
+		}
+
+		if introspector != nil {
+			status.Target = introspector.Target()
+		}
+
+		return status
+	}
+}
+
+//nolint:ireturn // factory returns interface so tests can substitute writers.
+func newInstancePrincipalStatusWriter(region string) (metadata.StatusWriter, error) {
+	client, err := oci.NewInstancePrincipalComputeClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build compute client: %w", err)
+	}
+
+	return client, nil
+}
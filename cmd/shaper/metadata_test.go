@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/metadata"
+	"oci-cpu-shaper/pkg/suppress"
+)
+
+func TestMetadataChangeHandlerCountsAndWarnsOnResize(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	exporter := metricshttp.NewExporter()
+	resize := metadata.Change{Field: metadata.FieldOCPUs, Previous: "1", Current: "2"}
+
+	metadataChangeHandler(zap.New(core), defaultRuntimeConfig(), exporter)(resize)
+
+	if logs.Len() != 0 {
+		t.Fatalf("expected no restart hint without ocpuSecondsPerHour, got %d logs", logs.Len())
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.Controller.OCPUSeconds.Max = 1800
+
+	handler := metadataChangeHandler(zap.New(core), cfg, exporter)
+	handler(resize)
+	handler(metadata.Change{Field: metadata.FieldRegion, Previous: "phx", Current: "iad"})
+
+	hints := logs.FilterMessageSnippet("restart to recompute").All()
+	if len(hints) != 1 || hints[0].ContextMap()["current"] != "2" {
+		t.Fatalf("expected one restart hint for the resize, got %+v", hints)
+	}
+
+	assertRenderContains(t, exporter, "shaper_metadata_changes_total{field=\"ocpus\"} 2\n")
+}
+
+func TestLoadConfigAppliesMetadataRefreshOverride(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(
+		t,
+		"metadataRefresh",
+		cfg.OCI.MetadataRefresh,
+		metadata.DefaultRefreshInterval,
+	)
+
+	t.Setenv(envMetadataRefresh, "0s")
+
+	cfg, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(t, "metadataRefresh", cfg.OCI.MetadataRefresh, 0)
+	assertDurationEqual(t, "statusMetadata", cfg.OCI.StatusMetadata, 0)
+
+	t.Setenv(envStatusMetadata, "15m")
+
+	cfg, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertDurationEqual(t, "statusMetadata", cfg.OCI.StatusMetadata, 15*time.Minute)
+}
+
+func TestReconcileRegionWarnsAndOptionallyPrefersInstanceRegion(t *testing.T) {
+	t.Parallel()
+
+	client := newOfflineStubIMDS()
+	client.region, client.regionErr = "iad", nil
+	client.canonicalRegion, client.canonicalRegionErr = "us-ashburn-1", nil
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Region = "IAD"
+
+	core, logs := observer.New(zapcore.WarnLevel)
+
+	got := reconcileRegion(t.Context(), zap.New(core), cfg, client, modeEnforce)
+	if got.OCI.Region != "IAD" || logs.Len() != 0 {
+		t.Fatalf("expected a matching short code to pass, got %q (%+v)", got.OCI.Region, logs.All())
+	}
+
+	cfg.OCI.Region = "us-phoenix-1"
+
+	got = reconcileRegion(t.Context(), zap.New(core), cfg, client, modeDryRun)
+	if got.OCI.Region != "us-phoenix-1" {
+		t.Fatalf("expected the configured region kept, got %q", got.OCI.Region)
+	}
+
+	warned := logs.FilterMessageSnippet("Monitoring queries may return no data").All()
+	if len(warned) != 1 || warned[0].ContextMap()["instanceRegion"] != "us-ashburn-1" {
+		t.Fatalf("expected one mismatch warning naming the instance region, got %+v", logs.All())
+	}
+
+	cfg.OCI.PreferInstanceRegion = true
+
+	got = reconcileRegion(t.Context(), zap.New(core), cfg, client, modeEnforce)
+	if got.OCI.Region != "us-ashburn-1" ||
+		logs.FilterMessageSnippet("using the instance region").Len() != 1 {
+		t.Fatalf("expected the instance region preferred, got %q", got.OCI.Region)
+	}
+
+	calls := client.regionCalls
+
+	offline := cfg
+	offline.OCI.Offline = true
+	reconcileRegion(t.Context(), zap.New(core), offline, client, modeEnforce)
+
+	unset := cfg
+	unset.OCI.Region = " "
+	reconcileRegion(t.Context(), zap.New(core), unset, client, modeEnforce)
+
+	reconcileRegion(t.Context(), zap.New(core), cfg, client, modeNoop)
+	reconcileRegion(t.Context(), zap.New(core), cfg, nil, modeEnforce)
+
+	if client.regionCalls != calls {
+		t.Fatal("expected offline, noop, and IMDS-resolved runs to skip the check")
+	}
+
+	client.canonicalRegionErr = errRegionDown
+
+	got = reconcileRegion(t.Context(), zap.New(core), cfg, client, modeEnforce)
+	if got.OCI.Region != "us-phoenix-1" ||
+		logs.FilterMessageSnippet("failed to compare oci.region").Len() != 1 {
+		t.Fatalf("expected a failed lookup to keep the configured region, got %q", got.OCI.Region)
+	}
+}
+
+func TestLoadConfigAppliesPreferInstanceRegionOverride(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertBoolEqual(t, "preferInstanceRegion", cfg.OCI.PreferInstanceRegion, false)
+
+	t.Setenv(envOCIPreferRegion, "true")
+
+	cfg, err = loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	assertBoolEqual(t, "preferInstanceRegion", cfg.OCI.PreferInstanceRegion, true)
+}
+
+type introspectedController struct {
+	identifiedController
+
+	target float64
+}
+
+func (c *introspectedController) Target() float64  { return c.target }
+func (c *introspectedController) LastP95() float64 { return 0 }
+
+type stubStatusWriter struct {
+	writes chan map[string]string
+}
+
+func (s *stubStatusWriter) SetInstanceMetadata(
+	_ context.Context,
+	instanceOCID string,
+	values map[string]string,
+) (bool, error) {
+	values["instance"] = instanceOCID
+	s.writes <- values
+
+	return true, nil
+}
+
+func TestConfigureStatusMetadataPublishesControllerStatus(t *testing.T) {
+	t.Parallel()
+
+	writer := &stubStatusWriter{writes: make(chan map[string]string, 1)}
+
+	var region string
+
+	deps := runDeps{
+		newStatusWriter: func(r string) (metadata.StatusWriter, error) {
+			region = r
+
+			return writer, nil
+		},
+	}
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Region = stubRegion
+	cfg.OCI.StatusMetadata = time.Hour
+
+	controller := &introspectedController{
+		identifiedController: identifiedController{
+			stubController: stubController{mode: modeEnforce, state: adapt.StateFallback},
+			resourceID:     stubInstanceID,
+		},
+		target: 0.3,
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	configureStatusMetadata(ctx, zap.NewNop(), deps, cfg, controller)
+
+	values := <-writer.writes
+	if region != stubRegion || values["instance"] != stubInstanceID ||
+		values[metadata.StatusKeyMode] != modeEnforce ||
+		values[metadata.StatusKeyState] != "fallback" ||
+		values[metadata.StatusKeyTarget] != "0.300" {
+		t.Fatalf("unexpected status write in %q: %v", region, values)
+	}
+}
+
+func TestConfigureStatusMetadataSkipsWithoutWriter(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	calls := 0
+	deps := runDeps{
+		newStatusWriter: func(string) (metadata.StatusWriter, error) {
+			calls++
+
+			return nil, errStubControllerRun
+		},
+	}
+	controller := &identifiedController{resourceID: stubInstanceID}
+
+	cfg := defaultRuntimeConfig()
+	configureStatusMetadata(t.Context(), zap.New(core), deps, cfg, controller)
+
+	cfg.OCI.StatusMetadata = time.Hour
+	configureStatusMetadata(t.Context(), zap.New(core), deps, cfg, new(stubController))
+	configureStatusMetadata(t.Context(), zap.New(core), deps, cfg, new(identifiedController))
+
+	if calls != 0 {
+		t.Fatalf("expected no compute client without an interval and instance, got %d", calls)
+	}
+
+	configureStatusMetadata(t.Context(), zap.New(core), deps, cfg, controller)
+
+	if calls != 1 || logs.FilterMessageSnippet("instance status metadata disabled").Len() != 1 {
+		t.Fatalf("expected one disabled warning, got %d calls and %v", calls, logs.All())
+	}
+}
+
+type maintenanceIMDSClient struct {
+	stubIMDSClient
+
+	due time.Time
+}
+
+func (c *maintenanceIMDSClient) MaintenanceRebootDue(context.Context) (time.Time, error) {
+	return c.due, nil
+}
+
+func TestConfigureMaintenanceWatchSuppressesAroundMaintenance(t *testing.T) {
+	t.Parallel()
+
+	client := &maintenanceIMDSClient{due: time.Now().Add(time.Minute)}
+	controller := &suppressibleStubController{requests: make(chan string, 1)}
+
+	cfg := defaultRuntimeConfig()
+	cfg.OCI.Offline = true
+
+	configureMaintenanceWatch(t.Context(), zap.NewNop(), cfg, client, controller)
+
+	cfg.OCI.Offline = false
+	configureMaintenanceWatch(t.Context(), zap.NewNop(), cfg, new(stubIMDSClient), controller)
+	configureMaintenanceWatch(t.Context(), zap.NewNop(), cfg, client, new(stubController))
+
+	select {
+	case source := <-controller.requests:
+		t.Fatalf("expected no watch offline or without support, got request from %q", source)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	configureMaintenanceWatch(ctx, zap.NewNop(), cfg, client, controller)
+
+	select {
+	case source := <-controller.requests:
+		if source != suppress.SourceMaintenance {
+			t.Fatalf("expected a %q request, got %q", suppress.SourceMaintenance, source)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected imminent maintenance to request suppression")
+	}
+}
@@ -15,7 +15,12 @@ import (
 func buildInstancePrincipalMetricsClient(compartmentID, region string) (oci.MetricsClient, error) {
 	endpoint := strings.TrimSpace(os.Getenv(e2eclient.MonitoringEndpointEnv))
 	if endpoint != "" {
-		client, err := e2eclient.NewMonitoringClient(endpoint)
+		scale, err := e2eclient.TimeScaleFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("build e2e monitoring client: %w", err)
+		}
+
+		client, err := e2eclient.NewMonitoringClientWithClock(endpoint, scale.Now)
 		if err != nil {
 			return nil, fmt.Errorf("build e2e monitoring client: %w", err)
 		}
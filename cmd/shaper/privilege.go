@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"oci-cpu-shaper/pkg/privilege"
+)
+
+// dropPrivileges switches the process to the runAs identity.
+//
+//nolint:gochecknoglobals // test seam for the credential syscalls.
+var dropPrivileges = privilege.Drop
+
+// resolveRunAs looks up the runAs identity before any privileged setup, so an
+// unknown user fails the start instead of leaving the daemon running as root.
+// It reports false when runAs.user is unset.
+func resolveRunAs(cfg runAsConfig) (privilege.Identity, bool, error) {
+	if strings.TrimSpace(cfg.User) == "" {
+		return privilege.Identity{}, false, nil
+	}
+
+	identity, err := privilege.Resolve(cfg.User, cfg.Group)
+	if err != nil {
+		return privilege.Identity{}, false, fmt.Errorf("resolve runAs identity: %w", err)
+	}
+
+	return identity, true, nil
+}
+
+// switchToRunAs drops root once the listeners are bound and the worker pool
+// has lowered its priority. Files the daemon writes later, such as the
+// textfile collector output and the suppression learning state, must be
+// writable by the new identity.
+func switchToRunAs(logger *zap.Logger, identity privilege.Identity, enabled bool) error {
+	if !enabled {
+		return nil
+	}
+
+	err := dropPrivileges(identity)
+	if err != nil {
+		return fmt.Errorf("drop privileges: %w", err)
+	}
+
+	logger.Info("dropped privileges", zap.Int("uid", identity.UID), zap.Int("gid", identity.GID))
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/privilege"
+)
+
+var errStubSetuidDenied = errors.New("setuid denied")
+
+func TestResolveRunAs(t *testing.T) {
+	t.Parallel()
+
+	_, enabled, err := resolveRunAs(runAsConfig{User: " ", Group: "root"})
+	if err != nil || enabled {
+		t.Fatalf("expected an unset user to keep the identity, got %v (err %v)", enabled, err)
+	}
+
+	identity, enabled, err := resolveRunAs(runAsConfig{User: "4242424", Group: "4242425"})
+	if err != nil || !enabled {
+		t.Fatalf("resolveRunAs: %v", err)
+	}
+
+	if identity != (privilege.Identity{UID: 4242424, GID: 4242425}) {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+
+	_, _, err = resolveRunAs(runAsConfig{User: "no-such-shaper-user", Group: ""})
+	if !errors.Is(err, privilege.ErrUnknownUser) {
+		t.Fatalf("expected ErrUnknownUser, got %v", err)
+	}
+}
+
+func TestSwitchToRunAsDropsPrivileges(t *testing.T) {
+	original := dropPrivileges
+
+	t.Cleanup(func() {
+		dropPrivileges = original
+	})
+
+	var dropped []privilege.Identity
+
+	dropPrivileges = func(identity privilege.Identity) error {
+		dropped = append(dropped, identity)
+
+		return nil
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	identity := privilege.Identity{UID: 65532, GID: 65532}
+
+	err := switchToRunAs(zap.New(core), identity, false)
+	if err != nil || len(dropped) != 0 {
+		t.Fatalf("expected no switch without runAs, got %v (err %v)", dropped, err)
+	}
+
+	err = switchToRunAs(zap.New(core), identity, true)
+	if err != nil || len(dropped) != 1 || dropped[0] != identity {
+		t.Fatalf("expected a switch to %+v, got %v (err %v)", identity, dropped, err)
+	}
+
+	if logs.FilterMessage("dropped privileges").Len() != 1 {
+		t.Fatalf("expected the switch to be logged, got %v", logs.All())
+	}
+
+	dropPrivileges = func(privilege.Identity) error {
+		return errStubSetuidDenied
+	}
+
+	err = switchToRunAs(zap.New(core), identity, true)
+	if !errors.Is(err, errStubSetuidDenied) {
+		t.Fatalf("expected the drop failure, got %v", err)
+	}
+}
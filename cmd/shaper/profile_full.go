@@ -0,0 +1,7 @@
+//go:build !minimal
+
+package main
+
+// minimalBuild reports whether the binary was built with the minimal tag; see
+// profile_minimal.go.
+const minimalBuild = false
@@ -0,0 +1,54 @@
+//go:build minimal
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/internal/buildinfo"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/history"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+)
+
+// minimalBuild reports whether the binary was built with the minimal tag,
+// which keeps the shaping loop and leaves out the admin API, the decision
+// webhook, and the release update check. loadConfig rejects their settings
+// through validateBuildProfile, so the stand-ins below are never asked to do
+// any work.
+const minimalBuild = true
+
+//nolint:ireturn // matches the factory in admin_auth.go
+func newInstancePrincipalDynamicGroupReader(string) (dynamicGroupReader, error) {
+	return nil, errMinimalBuild
+}
+
+//nolint:ireturn // matches admin_api.go
+func configureAdminAPI(
+	context.Context,
+	runDeps,
+	*zap.Logger,
+	runtimeConfig,
+	adapt.Controller,
+	*metricshttp.Exporter,
+	*history.Store,
+) (http.Handler, error) {
+	return nil, nil
+}
+
+//nolint:ireturn // matches webhook.go
+func configureWebhook(*zap.Logger, runtimeConfig, adapt.Controller) (decisionWebhook, error) {
+	return nil, nil
+}
+
+func configureUpdateCheck(
+	context.Context,
+	*zap.Logger,
+	runtimeConfig,
+	buildinfo.Info,
+	*metricshttp.Exporter,
+) error {
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build minimal
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/internal/buildinfo"
+)
+
+func TestMinimalBuildRejectsExcludedSettings(t *testing.T) {
+	t.Setenv(envWebhookURL, "https://automation.example.com/shaper")
+
+	_, err := loadConfig("")
+	if !errors.Is(err, errMinimalBuild) {
+		t.Fatalf("expected errMinimalBuild, got %v", err)
+	}
+}
+
+func TestMinimalBuildLeavesOptionalSubsystemsOut(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+
+	admin, err := configureAdminAPI(t.Context(), runDeps{}, zap.NewNop(), cfg, controller, nil, nil)
+	if err != nil || admin != nil {
+		t.Fatalf("expected no admin api, got %v (%v)", admin, err)
+	}
+
+	notifier, err := configureWebhook(zap.NewNop(), cfg, controller)
+	if err != nil || notifier != nil || controller.observer != nil {
+		t.Fatalf("expected no webhook, got %v (%v)", notifier, err)
+	}
+
+	err = configureUpdateCheck(t.Context(), zap.NewNop(), cfg, buildinfo.Current(), nil)
+	if err != nil {
+		t.Fatalf("configureUpdateCheck returned error: %v", err)
+	}
+
+	_, err = newInstancePrincipalDynamicGroupReader("us-ashburn-1")
+	if !errors.Is(err, errMinimalBuild) {
+		t.Fatalf("expected errMinimalBuild, got %v", err)
+	}
+}
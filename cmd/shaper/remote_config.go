@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/remoteconfig"
+)
+
+var (
+	errObjectReaderMissing  = errors.New("object storage reader unavailable")
+	errInvalidConfigRefresh = errors.New("invalid config-refresh interval (must be >=0)")
+	// errRestartRequested is the cancellation cause recorded when a changed
+	// remote configuration stops the run.
+	errRestartRequested = errors.New("restart requested to apply remote configuration")
+)
+
+//nolint:ireturn // factory returns interface so tests can substitute readers.
+func newInstancePrincipalObjectReader(region string) (remoteconfig.ObjectReader, error) {
+	client, err := oci.NewInstancePrincipalObjectStorageClient(region)
+	if err != nil {
+		return nil, fmt.Errorf("build object storage client: %w", err)
+	}
+
+	return client, nil
+}
+
+func newRemoteConfigSource(
+	deps runDeps,
+	location string,
+	cachePath string,
+) (*remoteconfig.Source, error) {
+	if !remoteconfig.IsObjectStorage(location) {
+		source, err := remoteconfig.NewHTTPSSource(location, cachePath, deps.remoteConfigClient)
+
+		return source, err //nolint:wrapcheck // already descriptive
+	}
+
+	ref, err := remoteconfig.ParseObjectURL(location)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already descriptive
+	}
+
+	if deps.newObjectReader == nil {
+		return nil, errObjectReaderMissing
+	}
+
+	reader, err := deps.newObjectReader(envString(envOCIRegion, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := remoteconfig.NewObjectSource(ref, cachePath, reader)
+
+	return source, err //nolint:wrapcheck // already descriptive
+}
+
+// resolveConfigPathOrExit fetches a remote --config into the local cache and
+// returns the path the configuration should be loaded from. Local paths are
+// returned unchanged with a nil source.
+func resolveConfigPathOrExit(
+	ctx context.Context,
+	deps runDeps,
+	opts options,
+	stderr io.Writer,
+) (string, *remoteconfig.Source, int, bool) {
+	if !remoteconfig.IsRemote(opts.configPath) {
+		return opts.configPath, nil, exitCodeSuccess, true
+	}
+
+	source, err := newRemoteConfigSource(deps, opts.configPath, opts.configCache)
+	if err == nil {
+		err = source.Sync(ctx)
+	}
+
+	if err != nil {
+		exitCode := writeError(
+			stderr,
+			fmt.Errorf("failed to fetch remote configuration %q: %w", opts.configPath, err),
+			exitCodeForConfigError(err),
+		)
+
+		return "", nil, exitCode, false
+	}
+
+	return source.CachePath(), source, exitCodeSuccess, true
+}
+
+// configureRemoteConfigRefresh reports a stale cached configuration and starts
+// the periodic re-fetch of a remote --config. A valid change stops the run
+// through restart with errRestartRequested as the cause.
+func configureRemoteConfigRefresh(
+	ctx context.Context,
+	logger *zap.Logger,
+	deps runDeps,
+	source *remoteconfig.Source,
+	interval time.Duration,
+	restart context.CancelCauseFunc,
+) {
+	if source == nil {
+		return
+	}
+
+	staleErr := source.StaleErr()
+	if staleErr != nil {
+		logger.Warn(
+			"remote configuration unreachable; using cached copy",
+			zap.String("config", source.Location()),
+			zap.String("cache", source.CachePath()),
+			zap.Error(staleErr),
+		)
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	source.SetLogger(zapslog.NewLibraryLogger(logger))
+
+	validate := func(path string) error {
+		_, err := deps.loadConfig(path)
+
+		return err
+	}
+
+	go func() {
+		if source.Watch(ctx, interval, validate) {
+			restart(errRestartRequested)
+		}
+	}()
+}
+
+// exitCodeForRestart reports exitCodeRestartRequested when a clean run was
+// stopped to apply a changed remote configuration.
+func exitCodeForRestart(ctx context.Context, code int) int {
+	if code == exitCodeSuccess && errors.Is(context.Cause(ctx), errRestartRequested) {
+		return exitCodeRestartRequested
+	}
+
+	return code
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+	"oci-cpu-shaper/pkg/remoteconfig"
+)
+
+// remoteConfigServer serves a configuration document with an ETag and honours
+// If-None-Match.
+type remoteConfigServer struct {
+	mu          sync.Mutex
+	content     string
+	etag        string
+	notModified int
+}
+
+func (s *remoteConfigServer) set(content, etag string) {
+	s.mu.Lock()
+	s.content, s.etag = content, etag
+	s.mu.Unlock()
+}
+
+func (s *remoteConfigServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		writer.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	writer.Header().Set("ETag", s.etag)
+	_, _ = io.WriteString(writer, s.content)
+}
+
+func newIPv4TLSTestServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(handler)
+
+	var lc net.ListenConfig
+
+	listener, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen tcp4: %v", err)
+	}
+
+	server.Listener = listener
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+type stubObjectReader struct {
+	ref oci.ObjectRef
+}
+
+func (s *stubObjectReader) GetObject(
+	_ context.Context,
+	ref oci.ObjectRef,
+	_ string,
+) ([]byte, string, error) {
+	s.ref = ref
+
+	return []byte("controller: {}\n"), "abc", nil
+}
+
+func TestNewRemoteConfigSourceReadsObjectStorageInRegion(t *testing.T) {
+	t.Setenv(envOCIRegion, "us-ashburn-1")
+
+	reader := new(stubObjectReader)
+
+	var region string
+
+	deps := runDeps{ //nolint:exhaustruct
+		newObjectReader: func(r string) (remoteconfig.ObjectReader, error) {
+			region = r
+
+			return reader, nil
+		},
+	}
+
+	source, err := newRemoteConfigSource(
+		deps,
+		"os://tenancy/configs/shaper/prod.yaml",
+		filepath.Join(t.TempDir(), "config.yaml"),
+	)
+	if err != nil {
+		t.Fatalf("newRemoteConfigSource: %v", err)
+	}
+
+	err = source.Sync(t.Context())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	want := oci.ObjectRef{Namespace: "tenancy", Bucket: "configs", Name: "shaper/prod.yaml"}
+	if reader.ref != want || region != "us-ashburn-1" {
+		t.Fatalf("unexpected object request %+v in %q", reader.ref, region)
+	}
+
+	_, err = newRemoteConfigSource(deps, "os://tenancy/configs", "/tmp/cache.yaml")
+	if !errors.Is(err, remoteconfig.ErrInvalidURL) {
+		t.Fatalf("expected remoteconfig.ErrInvalidURL, got %v", err)
+	}
+
+	if code := exitCodeForConfigError(err); code != exitCodeParseError {
+		t.Fatalf("expected parse exit code, got %d", code)
+	}
+
+	_, err = newRemoteConfigSource(
+		runDeps{}, //nolint:exhaustruct
+		"os://tenancy/configs/a",
+		"/tmp/cache.yaml",
+	)
+	if !errors.Is(err, errObjectReaderMissing) {
+		t.Fatalf("expected errObjectReaderMissing, got %v", err)
+	}
+}
+
+func TestRunRestartsWhenRemoteConfigChanges(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zap.InfoLevel)
+	handler := new(remoteConfigServer)
+	handler.set("controller: {}\n", `"v1"`)
+
+	server := newIPv4TLSTestServer(t, handler)
+	cache := filepath.Join(t.TempDir(), "config.yaml")
+
+	deps := defaultRunDeps()
+	deps.remoteConfigClient = server.Client()
+	deps.newLogger = func(string, string) (*zap.Logger, error) { return zap.New(core), nil }
+	deps.startMetricsServer = func(
+		context.Context, *zap.Logger, string, string, http.Handler, *tls.Config, string,
+	) error {
+		return nil
+	}
+
+	var (
+		loadedMu sync.Mutex
+		loaded   []string
+	)
+
+	stub := loadConfigStub()
+	deps.loadConfig = func(path string) (runtimeConfig, error) {
+		loadedMu.Lock()
+		loaded = append(loaded, path)
+		loadedMu.Unlock()
+
+		return stub(path)
+	}
+	deps.newController = func(
+		context.Context, string, runtimeConfig, imds.Client, adapt.MetricsRecorder,
+	) (adapt.Controller, poolStarter, error) {
+		handler.set("controller:\n  targetMax: 0.5\n", `"v2"`)
+
+		return new(blockingController), nil, nil
+	}
+
+	exitCode := run(
+		t.Context(),
+		[]string{
+			"--mode", "noop",
+			"--config", server.URL + "/config.yaml",
+			"--config-cache", cache,
+			"--config-refresh", "10ms",
+			"--shutdown-after", "10s",
+		},
+		deps,
+		io.Discard,
+	)
+	if exitCode != exitCodeRestartRequested {
+		t.Fatalf("expected restart exit code, got %d", exitCode)
+	}
+
+	if observed.FilterMessage("remote configuration changed; restarting to apply").Len() != 1 {
+		t.Fatalf("expected restart log entry, got %+v", observed.All())
+	}
+
+	cached, _ := os.ReadFile(cache)
+	if string(cached) != "controller:\n  targetMax: 0.5\n" {
+		t.Fatalf("expected the new configuration to be cached, got %q", cached)
+	}
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+
+	if len(loaded) < 2 || loaded[0] != cache {
+		t.Fatalf("expected the cache to be loaded and the candidate validated, got %v", loaded)
+	}
+}
+
+func TestParseArgsRejectsNegativeConfigRefresh(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseArgs([]string{"--config-refresh", "-1s"})
+	if !errors.Is(err, errInvalidConfigRefresh) {
+		t.Fatalf("expected errInvalidConfigRefresh, got %v", err)
+	}
+
+	opts, err := parseArgs(nil)
+	if err != nil || opts.configRefresh != remoteconfig.DefaultRefreshInterval ||
+		opts.configCache != remoteconfig.DefaultCachePath {
+		t.Fatalf("unexpected remote config defaults %+v (%v)", opts, err)
+	}
+}
@@ -3,53 +3,56 @@
 package main
 
 import (
-	"context"
+	"fmt"
 	"os"
-	"sync/atomic"
 
-	"go.uber.org/zap"
 	"oci-cpu-shaper/internal/buildinfo"
 	"oci-cpu-shaper/internal/e2eclient"
-	"oci-cpu-shaper/pkg/adapt"
 	metricshttp "oci-cpu-shaper/pkg/http/metrics"
-	"oci-cpu-shaper/pkg/imds"
 )
 
-var e2eLogger atomic.Pointer[zap.Logger]
-
 func defaultRunDeps() runDeps {
 	deps := runDeps{
-		newLogger: newLogger,
-		newIMDS:   defaultIMDSFactory,
-		newController: func(
-			ctx context.Context,
-			mode string,
-			cfg runtimeConfig,
-			imdsClient imds.Client,
-			recorder adapt.MetricsRecorder,
-		) (adapt.Controller, poolStarter, error) {
-			logger := e2eLogger.Load()
-			if logger != nil && recorder != nil {
-				recorder = e2eclient.NewLoggingRecorder(logger, recorder)
-			}
-
-			return defaultControllerFactory(ctx, mode, cfg, imdsClient, recorder)
-		},
-		currentBuildInfo:   buildinfo.Current,
-		loadConfig:         loadConfig,
-		newMetricsExporter: metricshttp.NewExporter,
-		startMetricsServer: startMetricsServer,
-		versionWriter:      os.Stdout,
+		newLogger:              newLogger,
+		newIMDS:                defaultIMDSFactory,
+		newController:          defaultControllerFactory,
+		currentBuildInfo:       buildinfo.Current,
+		loadConfig:             loadConfig,
+		newMetricsExporter:     metricshttp.NewExporter,
+		startMetricsServer:     startMetricsServer,
+		versionWriter:          os.Stdout,
+		stdout:                 os.Stdout,
+		newDisplayNameResolver: newInstancePrincipalDisplayNameResolver,
+		newAlarmManager:        newInstancePrincipalAlarmManager,
+		newSecretReader:        newInstancePrincipalSecretReader,
+		newDynamicGroupReader:  newInstancePrincipalDynamicGroupReader,
+		newObjectReader:        newInstancePrincipalObjectReader,
+		newStatusWriter:        newInstancePrincipalStatusWriter,
 	}
 
-	deps.newLogger = func(level string) (*zap.Logger, error) {
-		logger, err := newLogger(level)
-		if err == nil {
-			e2eLogger.Store(logger)
+	deps.loadConfig = func(path string) (runtimeConfig, error) {
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return cfg, err
 		}
 
-		return logger, err
+		return compressIntervals(cfg)
 	}
 
 	return deps
 }
+
+// compressIntervals shortens the scheduling intervals by e2eclient.TimeScaleEnv so
+// e2e tests can observe relaxed-interval and multi-day behaviour in seconds.
+func compressIntervals(cfg runtimeConfig) (runtimeConfig, error) {
+	scale, err := e2eclient.TimeScaleFromEnv()
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", e2eclient.TimeScaleEnv, err)
+	}
+
+	cfg.Controller.Interval = scale.Compress(cfg.Controller.Interval)
+	cfg.Controller.RelaxedInterval = scale.Compress(cfg.Controller.RelaxedInterval)
+	cfg.Estimator.Interval = scale.Compress(cfg.Estimator.Interval)
+
+	return cfg, nil
+}
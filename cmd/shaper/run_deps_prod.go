@@ -11,13 +11,20 @@ import (
 
 func defaultRunDeps() runDeps {
 	return runDeps{
-		newLogger:          newLogger,
-		newIMDS:            defaultIMDSFactory,
-		newController:      defaultControllerFactory,
-		currentBuildInfo:   buildinfo.Current,
-		loadConfig:         loadConfig,
-		newMetricsExporter: metricshttp.NewExporter,
-		startMetricsServer: startMetricsServer,
-		versionWriter:      os.Stdout,
+		newLogger:              newLogger,
+		newIMDS:                defaultIMDSFactory,
+		newController:          defaultControllerFactory,
+		currentBuildInfo:       buildinfo.Current,
+		loadConfig:             loadConfig,
+		newMetricsExporter:     metricshttp.NewExporter,
+		startMetricsServer:     startMetricsServer,
+		versionWriter:          os.Stdout,
+		stdout:                 os.Stdout,
+		newDisplayNameResolver: newInstancePrincipalDisplayNameResolver,
+		newAlarmManager:        newInstancePrincipalAlarmManager,
+		newSecretReader:        newInstancePrincipalSecretReader,
+		newDynamicGroupReader:  newInstancePrincipalDynamicGroupReader,
+		newObjectReader:        newInstancePrincipalObjectReader,
+		newStatusWriter:        newInstancePrincipalStatusWriter,
 	}
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"oci-cpu-shaper/pkg/selftest"
+)
+
+// runSelfTest runs one control cycle against in-process fake OCI services and
+// prints each check, so a new install can be validated without credentials.
+func runSelfTest(ctx context.Context, deps runDeps, opts selftest.Options) int {
+	writer := deps.stdout
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	report := selftest.Run(ctx, opts)
+
+	for _, check := range report.Checks {
+		switch {
+		case errors.Is(check.Err, selftest.ErrSkipped):
+			_, _ = fmt.Fprintf(writer, "selftest.%s: skipped\n", check.Name)
+		case check.Err != nil:
+			_, _ = fmt.Fprintf(writer, "selftest.%s: FAIL %v\n", check.Name, check.Err)
+		default:
+			_, _ = fmt.Fprintf(writer, "selftest.%s: ok %s\n", check.Name, check.Detail)
+		}
+	}
+
+	if !report.Passed() {
+		_, _ = fmt.Fprintln(writer, "selftest: failed")
+
+		return exitCodeRuntimeError
+	}
+
+	_, _ = fmt.Fprintln(writer, "selftest: passed")
+
+	return exitCodeSuccess
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/selftest"
+)
+
+var errResolverDown = errors.New("resolver down")
+
+func TestParseArgsSelfTest(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{"--self-test"}, {"self-test"}} {
+		opts, err := parseArgs(args)
+		if err != nil {
+			t.Fatalf("parseArgs(%v) returned error: %v", args, err)
+		}
+
+		if !opts.runSelfTest {
+			t.Fatalf("expected runSelfTest for %v", args)
+		}
+	}
+
+	for _, args := range [][]string{{"alarm", "self-test"}, {"status", "self-test"}} {
+		opts, err := parseArgs(args)
+		if err != nil {
+			t.Fatalf("parseArgs(%v) returned error: %v", args, err)
+		}
+
+		if opts.runSelfTest {
+			t.Fatalf("expected a trailing self-test argument to be left to %s", args[0])
+		}
+	}
+}
+
+func TestRunSelfTestPassesWithoutConfig(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+
+	deps := defaultRunDeps()
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
+		panic("newLogger should not be called by self-test")
+	}
+	deps.loadConfig = func(string) (runtimeConfig, error) {
+		panic("loadConfig should not be called by self-test")
+	}
+	deps.stdout = &stdout
+
+	exitCode := run(t.Context(), []string{"--self-test"}, deps, io.Discard)
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success exit code, got %d (output=%q)", exitCode, stdout.String())
+	}
+
+	output := stdout.String()
+	for _, want := range []string{
+		"selftest.dns: ok localhost resolves to ",
+		"selftest.monitoring: ok p95 0.3000 over tls\n",
+		"selftest.metrics: ok scraped ",
+		"selftest: passed\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in self-test output, got %q", want, output)
+		}
+	}
+}
+
+func TestRunSelfTestReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+
+	deps := defaultRunDeps()
+	deps.stdout = &stdout
+
+	exitCode := runSelfTest(t.Context(), deps, selftest.Options{
+		Timeout: 0,
+		LookupHost: func(context.Context, string) ([]string, error) {
+			return nil, errResolverDown
+		},
+		SystemCertPool: nil,
+	})
+	if exitCode != exitCodeRuntimeError {
+		t.Fatalf("expected runtime error exit code, got %d", exitCode)
+	}
+
+	output := stdout.String()
+	for _, want := range []string{
+		"selftest.dns: FAIL resolve localhost: resolver down\n",
+		"selftest.certs: ok system trust store loaded\n",
+		"selftest.imds: skipped\n",
+		"selftest: failed\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in self-test output, got %q", want, output)
+		}
+	}
+}
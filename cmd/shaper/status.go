@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+)
+
+const (
+	defaultStatusURL     = "http://127.0.0.1:9108"
+	defaultStatusTimeout = 5 * time.Second
+)
+
+var (
+	errStatusTimeout = errors.New("status timeout must be greater than zero")
+	errStatusHTTP    = errors.New("unexpected status")
+)
+
+type statusOptions struct {
+	url     string
+	timeout time.Duration
+}
+
+func parseStatusArgs(args []string) (statusOptions, error) {
+	var opts statusOptions
+
+	flagSet := flag.NewFlagSet("shaper status", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&opts.url, "url", defaultStatusURL, "Base URL of the shaper metrics listener")
+	flagSet.DurationVar(
+		&opts.timeout,
+		"timeout",
+		defaultStatusTimeout,
+		"Timeout for the admin API request",
+	)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return statusOptions{}, fmt.Errorf("parse status arguments: %w", err)
+	}
+
+	if opts.timeout <= 0 {
+		return statusOptions{}, errStatusTimeout
+	}
+
+	opts.url = strings.TrimRight(opts.url, "/")
+
+	return opts, nil
+}
+
+// runStatus fetches the OCI P95 history from a running shaper's admin API and
+// prints its latest reading, range, trend, and a sparkline.
+func runStatus(ctx context.Context, deps runDeps, opts options, stderr io.Writer) int {
+	statusOpts, err := parseStatusArgs(opts.statusArgs)
+	if err != nil {
+		return writeError(stderr, err, exitCodeParseError)
+	}
+
+	response, err := fetchP95History(ctx, statusOpts)
+	if err != nil {
+		return writeError(stderr, err, exitCodeRuntimeError)
+	}
+
+	writer := deps.stdout
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	writeP95Summary(writer, adapt.SummarizeP95(response.Samples))
+
+	return exitCodeSuccess
+}
+
+func fetchP95History(ctx context.Context, opts statusOptions) (adminhttp.P95Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	endpoint := opts.url + adminhttp.Prefix + "p95"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return adminhttp.P95Response{}, fmt.Errorf("build request for %s: %w", endpoint, err)
+	}
+
+	//nolint:gosec // the URL comes from the operator's own flag
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return adminhttp.P95Response{}, fmt.Errorf("fetch %s: %w", endpoint, err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return adminhttp.P95Response{}, fmt.Errorf(
+			"fetch %s: %w %s",
+			endpoint,
+			errStatusHTTP,
+			response.Status,
+		)
+	}
+
+	var payload adminhttp.P95Response
+
+	err = json.NewDecoder(response.Body).Decode(&payload)
+	if err != nil {
+		return adminhttp.P95Response{}, fmt.Errorf("decode %s: %w", endpoint, err)
+	}
+
+	return payload, nil
+}
+
+func writeP95Summary(writer io.Writer, summary adapt.P95Summary) {
+	_, _ = fmt.Fprintf(writer, "p95.samples: %d\n", summary.Samples)
+
+	if summary.Samples == 0 {
+		_, _ = fmt.Fprintln(writer, "p95.trend: no successful OCI queries yet")
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(writer, "p95.latest: %.3f (%s)\n",
+		summary.Latest.P95, summary.Latest.Timestamp.UTC().Format(time.RFC3339))
+	_, _ = fmt.Fprintf(writer, "p95.range: %.3f..%.3f\n", summary.Min, summary.Max)
+	_, _ = fmt.Fprintf(writer, "p95.trend: %s (%+.3f)\n", summary.Direction, summary.Change)
+	_, _ = fmt.Fprintf(writer, "p95.sparkline: %s\n", summary.Sparkline)
+}
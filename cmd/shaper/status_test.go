@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+)
+
+func runStatusAgainst(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	deps := defaultRunDeps()
+	deps.newLogger = func(string, string) (*zap.Logger, error) {
+		panic("newLogger should not be called by status")
+	}
+	deps.loadConfig = func(string) (runtimeConfig, error) {
+		panic("loadConfig should not be called by status")
+	}
+	deps.stdout = &stdout
+
+	exitCode := run(t.Context(), append([]string{"status"}, args...), deps, &stderr)
+
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func TestRunStatusPrintsP95Trend(t *testing.T) {
+	t.Parallel()
+
+	stamp := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/admin/p95" {
+				http.NotFound(writer, request)
+
+				return
+			}
+
+			_ = json.NewEncoder(writer).Encode(adminhttp.P95Response{
+				Now: stamp,
+				Samples: []adapt.P95Sample{
+					{Timestamp: stamp.Add(-time.Hour), P95: 0.28},
+					{Timestamp: stamp, P95: 0.20},
+				},
+			})
+		},
+	))
+	t.Cleanup(server.Close)
+
+	exitCode, output, _ := runStatusAgainst(t, "--url", server.URL+"/")
+	if exitCode != exitCodeSuccess {
+		t.Fatalf("expected success, got %d", exitCode)
+	}
+
+	for _, line := range []string{
+		"p95.samples: 2\n",
+		"p95.latest: 0.200 (2024-06-03T12:00:00Z)\n",
+		"p95.range: 0.200..0.280\n",
+		"p95.trend: falling (-0.080)\n",
+		"p95.sparkline: █▁\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("expected %q in output:\n%s", line, output)
+		}
+	}
+}
+
+func TestRunStatusReportsEmptyHistoryAndFailures(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/admin/p95" {
+				http.NotFound(writer, request)
+
+				return
+			}
+
+			_, _ = writer.Write([]byte(`{"samples":[]}`))
+		},
+	))
+	t.Cleanup(server.Close)
+
+	exitCode, output, _ := runStatusAgainst(t, "--url", server.URL)
+	if exitCode != exitCodeSuccess ||
+		output != "p95.samples: 0\np95.trend: no successful OCI queries yet\n" {
+		t.Fatalf("unexpected empty history result %d:\n%s", exitCode, output)
+	}
+
+	exitCode, _, stderr := runStatusAgainst(t, "--url", server.URL+"/missing")
+	if exitCode != exitCodeRuntimeError || !strings.Contains(stderr, "404") {
+		t.Fatalf("expected a runtime error for a 404, got %d: %s", exitCode, stderr)
+	}
+
+	exitCode, _, _ = runStatusAgainst(t, "--timeout", "0")
+	if exitCode != exitCodeParseError {
+		t.Fatalf("expected a parse error for a zero timeout, got %d", exitCode)
+	}
+}
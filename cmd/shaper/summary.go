@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+type summaryReporter interface {
+	Summary() adapt.RunSummary
+}
+
+// shutdownSummary is the --summary-file form of adapt.RunSummary.
+type shutdownSummary struct {
+	UptimeSeconds    float64            `json:"uptimeSeconds"`
+	Steps            int                `json:"steps"`
+	OCIErrors        int                `json:"ociErrors"`
+	StateSeconds     map[string]float64 `json:"stateSeconds"`
+	FinalTarget      float64            `json:"finalTarget"`
+	AverageDutyCycle float64            `json:"averageDutyCycle"`
+}
+
+// summaryStates fixes the order in which per-state durations are logged.
+//
+//nolint:gochecknoglobals // fixed list of controller states
+var summaryStates = []adapt.State{adapt.StateNormal, adapt.StateFallback, adapt.StateSuppressed}
+
+// reportShutdownSummary logs a digest of the controller run and, when path is
+// set, writes it there as JSON. Failing to write the file only warns because
+// the run itself has already finished.
+func reportShutdownSummary(logger *zap.Logger, controller adapt.Controller, path string) {
+	reporter, ok := controller.(summaryReporter)
+	if !ok {
+		return
+	}
+
+	summary := reporter.Summary()
+
+	fields := []zap.Field{
+		zap.Duration("uptime", summary.Uptime),
+		zap.Int("steps", summary.Steps),
+		zap.Int("ociErrors", summary.OCIErrors),
+	}
+	for _, state := range summaryStates {
+		fields = append(fields, zap.Duration(state.String()+"Time", summary.StateDurations[state]))
+	}
+
+	fields = append(fields,
+		zap.Float64("finalTarget", summary.FinalTarget),
+		zap.Float64("averageDutyCycle", summary.AverageDutyCycle),
+	)
+
+	logger.Info("shutdown summary", fields...)
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return
+	}
+
+	err := writeShutdownSummary(path, summary)
+	if err != nil {
+		logger.Warn("failed to write shutdown summary", zap.String("path", path), zap.Error(err))
+	}
+}
+
+func writeShutdownSummary(path string, summary adapt.RunSummary) error {
+	states := make(map[string]float64, len(summary.StateDurations))
+	for state, duration := range summary.StateDurations {
+		states[state.String()] = duration.Seconds()
+	}
+
+	data, err := json.MarshalIndent(shutdownSummary{
+		UptimeSeconds:    summary.Uptime.Seconds(),
+		Steps:            summary.Steps,
+		OCIErrors:        summary.OCIErrors,
+		StateSeconds:     states,
+		FinalTarget:      summary.FinalTarget,
+		AverageDutyCycle: summary.AverageDutyCycle,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode shutdown summary: %w", err)
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0o600)
+	if err != nil {
+		return fmt.Errorf("write shutdown summary: %w", err)
+	}
+
+	return nil
+}
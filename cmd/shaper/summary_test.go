@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+type summarizingController struct {
+	*stubController
+
+	summary adapt.RunSummary
+}
+
+func (c summarizingController) Summary() adapt.RunSummary {
+	return c.summary
+}
+
+func TestReportShutdownSummaryLogsAndWritesFile(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	path := filepath.Join(t.TempDir(), "summary.json")
+	controller := summarizingController{
+		stubController: new(stubController),
+		summary: adapt.RunSummary{
+			Uptime:    time.Hour,
+			Steps:     12,
+			OCIErrors: 2,
+			StateDurations: map[adapt.State]time.Duration{
+				adapt.StateNormal:   45 * time.Minute,
+				adapt.StateFallback: 15 * time.Minute,
+			},
+			FinalTarget:      0.25,
+			AverageDutyCycle: 0.2,
+		},
+	}
+
+	reportShutdownSummary(zap.New(core), controller, path)
+
+	entries := logs.FilterMessage("shutdown summary").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one summary entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["steps"] != int64(12) || fields["fallbackTime"] != 15*time.Minute ||
+		fields["suppressedTime"] != time.Duration(0) || fields["averageDutyCycle"] != 0.2 {
+		t.Fatalf("unexpected summary fields %v", fields)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read summary file: %v", err)
+	}
+
+	var written shutdownSummary
+
+	err = json.Unmarshal(data, &written)
+	if err != nil {
+		t.Fatalf("decode summary file: %v", err)
+	}
+
+	if written.UptimeSeconds != 3600 || written.OCIErrors != 2 ||
+		written.StateSeconds["normal"] != 2700 || written.FinalTarget != 0.25 {
+		t.Fatalf("unexpected summary file %+v", written)
+	}
+}
+
+func TestReportShutdownSummaryWarnsWhenFileUnwritable(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := summarizingController{stubController: new(stubController)} //nolint:exhaustruct
+	path := filepath.Join(t.TempDir(), "missing", "summary.json")
+
+	reportShutdownSummary(zap.New(core), controller, path)
+
+	if logs.FilterMessage("shutdown summary").Len() != 1 ||
+		logs.FilterMessage("failed to write shutdown summary").Len() != 1 {
+		t.Fatalf("unexpected log entries %+v", logs.All())
+	}
+}
+
+func TestReportShutdownSummarySkipsControllersWithoutSummary(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	reportShutdownSummary(zap.New(core), new(stubController), "")
+
+	if logs.Len() != 0 {
+		t.Fatalf("expected no log entries, got %+v", logs.All())
+	}
+}
+
+func TestParseArgsAcceptsSummaryFile(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"--shutdown-after", "1m", "--summary-file", "/tmp/run.json"})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+
+	if opts.summaryFile != "/tmp/run.json" {
+		t.Fatalf("expected summary file to be parsed, got %q", opts.summaryFile)
+	}
+}
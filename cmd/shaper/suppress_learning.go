@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/learnstate"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+)
+
+// configureSuppressLearning resumes the learning of suppression thresholds
+// from controller.suppressLearning.stateFile, saves its progress there every
+// learnstate.DefaultSaveInterval and on the returned stop, and re-exports the
+// controller band once the thresholds are learned. A missing or unreadable
+// file restarts learning.
+func configureSuppressLearning(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg controllerConfig,
+	controller adapt.Controller,
+	exporter *metricshttp.Exporter,
+) func() {
+	learner, ok := controller.(learnstate.Learner)
+	if !ok || cfg.SuppressLearning.LearnFor <= 0 {
+		return func() {}
+	}
+
+	path := strings.TrimSpace(cfg.SuppressLearningFile)
+	store := learnstate.NewStore(path, zapslog.NewLibraryLogger(logger))
+
+	if store.Restore(learner) {
+		reportControllerBand(controller, exporter)
+	}
+
+	learner.SetSuppressLearningHandler(func(adapt.SuppressLearningState) {
+		reportControllerBand(controller, exporter)
+		store.Save(learner)
+	})
+
+	if learner.SuppressLearning().Learned() {
+		return func() {}
+	}
+
+	logger.Info("learning suppression thresholds from background load",
+		zap.Duration("learnFor", cfg.SuppressLearning.LearnFor),
+		zap.String("stateFile", path))
+
+	return store.Start(ctx, learner, learnstate.DefaultSaveInterval)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/learnstate"
+)
+
+type learningStubController struct {
+	stubController
+
+	mu       sync.Mutex
+	state    adapt.SuppressLearningState
+	restored []adapt.SuppressLearningState
+	handler  func(state adapt.SuppressLearningState)
+}
+
+func (c *learningStubController) SuppressLearning() adapt.SuppressLearningState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+func (c *learningStubController) RestoreSuppressLearning(state adapt.SuppressLearningState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = state
+	c.restored = append(c.restored, state)
+}
+
+func (c *learningStubController) SetSuppressLearningHandler(
+	handler func(state adapt.SuppressLearningState),
+) {
+	c.handler = handler
+}
+
+func (c *learningStubController) Config() adapt.Config {
+	cfg := adapt.DefaultConfig()
+	cfg.SuppressThreshold = c.SuppressLearning().Threshold
+
+	return cfg
+}
+
+func (c *learningStubController) learn(threshold float64) {
+	c.mu.Lock()
+	c.state.Observed = time.Hour
+	c.state.Threshold = threshold
+	c.state.Resume = threshold - 0.15
+	state := c.state
+	c.mu.Unlock()
+
+	c.handler(state)
+}
+
+func learningConfig(path string) controllerConfig {
+	cfg := defaultRuntimeConfig().Controller
+	cfg.SuppressLearning.LearnFor = time.Hour
+	cfg.SuppressLearningFile = path
+
+	return cfg
+}
+
+func TestConfigureSuppressLearningSavesProgressAndLearnedThresholds(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state", "suppress-learning.json")
+	controller := &learningStubController{
+		state: adapt.SuppressLearningState{
+			Observed:  time.Minute,
+			Histogram: []uint64{1, 2},
+			Threshold: 0,
+			Resume:    0,
+		},
+	}
+	exporter := metricshttp.NewExporter()
+
+	stop := configureSuppressLearning(
+		t.Context(),
+		zap.NewNop(),
+		learningConfig(path),
+		controller,
+		exporter,
+	)
+
+	if len(controller.restored) != 0 {
+		t.Fatalf("expected nothing to restore without a state file, got %v", controller.restored)
+	}
+
+	stop()
+
+	saved, err := learnstate.Read(path)
+	if err != nil {
+		t.Fatalf("learnstate.Read: %v", err)
+	}
+
+	if saved.Observed != time.Minute || len(saved.Histogram) != 2 || saved.Learned() {
+		t.Fatalf("expected the progress to be saved on stop, got %+v", saved)
+	}
+
+	controller.learn(0.9)
+
+	saved, err = learnstate.Read(path)
+	if err != nil {
+		t.Fatalf("learnstate.Read: %v", err)
+	}
+
+	if saved.Threshold != 0.9 {
+		t.Fatalf("expected the learned threshold to be saved, got %+v", saved)
+	}
+
+	body, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(body), "shaper_suppress_threshold_ratio 0.900000") {
+		t.Fatalf("expected the learned threshold to be exported, got:\n%s", body)
+	}
+}
+
+func TestConfigureSuppressLearningRestoresState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "suppress-learning.json")
+
+	err := learnstate.Write(path, adapt.SuppressLearningState{
+		Observed:  2 * time.Hour,
+		Histogram: []uint64{3},
+		Threshold: 0.7,
+		Resume:    0.55,
+	})
+	if err != nil {
+		t.Fatalf("learnstate.Write: %v", err)
+	}
+
+	controller := new(learningStubController)
+	exporter := metricshttp.NewExporter()
+
+	stop := configureSuppressLearning(
+		t.Context(),
+		zap.NewNop(),
+		learningConfig(path),
+		controller,
+		exporter,
+	)
+	stop()
+
+	if len(controller.restored) != 1 || controller.restored[0].Observed != 2*time.Hour {
+		t.Fatalf("expected the saved state to be restored, got %v", controller.restored)
+	}
+
+	body, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("render metrics: %v", err)
+	}
+
+	if !strings.Contains(string(body), "shaper_suppress_threshold_ratio 0.700000") {
+		t.Fatalf("expected the restored threshold to be exported, got:\n%s", body)
+	}
+}
+
+func TestConfigureSuppressLearningWarnsAboutUnusableState(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suppress-learning.json")
+
+	err := os.WriteFile(path, []byte("{"), 0o600)
+	if err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	controller := new(learningStubController)
+
+	configureSuppressLearning(t.Context(), zap.New(core), learningConfig(path), controller, nil)
+
+	if len(controller.restored) != 0 {
+		t.Fatalf("expected a corrupt state file not to be restored, got %v", controller.restored)
+	}
+
+	if logs.FilterMessage("failed to read suppression learning state; learning afresh").Len() != 1 {
+		t.Fatalf("expected a warning about the corrupt state, got %v", logs.All())
+	}
+
+	// A state file that cannot be replaced only warns.
+	blocked := filepath.Join(dir, "blocked")
+
+	err = os.MkdirAll(filepath.Join(blocked, "entry"), 0o750)
+	if err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	stop := configureSuppressLearning(
+		t.Context(),
+		zap.New(core),
+		learningConfig(blocked),
+		controller,
+		nil,
+	)
+	stop()
+
+	if logs.FilterMessage("failed to save suppression learning state").Len() != 1 {
+		t.Fatalf("expected a warning about the failed save, got %v", logs.All())
+	}
+}
+
+func TestConfigureSuppressLearningSkipsWithoutLearning(t *testing.T) {
+	t.Parallel()
+
+	cfg := learningConfig("")
+	controller := new(learningStubController)
+
+	configureSuppressLearning(t.Context(), zap.NewNop(), cfg, new(stubController), nil)()
+
+	cfg.SuppressLearning.LearnFor = 0
+	configureSuppressLearning(t.Context(), zap.NewNop(), cfg, controller, nil)()
+
+	if controller.handler != nil {
+		t.Fatal("expected no handler while learning is disabled")
+	}
+
+	// Without a state file learning still runs, only without persistence.
+	cfg.SuppressLearning.LearnFor = time.Hour
+	configureSuppressLearning(t.Context(), zap.NewNop(), cfg, controller, nil)()
+	controller.learn(0.8)
+
+	// Thresholds learned in a previous run need no further saves.
+	configureSuppressLearning(t.Context(), zap.NewNop(), cfg, controller, nil)()
+}
@@ -0,0 +1,73 @@
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/internal/buildinfo"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/update"
+)
+
+// configureUpdateCheck starts the opt-in periodic comparison of the running
+// version against the latest GitHub release.
+func configureUpdateCheck(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	info buildinfo.Info,
+	exporter *metricshttp.Exporter,
+) error {
+	if !cfg.Update.Check {
+		return nil
+	}
+
+	checker, err := update.NewChecker(cfg.Update.Repository, info.Version, nil)
+	if err != nil {
+		return fmt.Errorf("build update checker: %w", err)
+	}
+
+	go checker.Run(ctx, cfg.Update.Interval, newUpdateReporter(logger, exporter))
+
+	return nil
+}
+
+// newUpdateReporter exports each release check and logs a newer release once
+// per version, so a daily check does not repeat the hint.
+func newUpdateReporter(
+	logger *zap.Logger,
+	exporter *metricshttp.Exporter,
+) func(result update.Result, err error) {
+	var announced string
+
+	return func(result update.Result, err error) {
+		if err != nil {
+			logger.Warn("release check failed", zap.Error(err))
+
+			return
+		}
+
+		if exporter != nil {
+			exporter.SetUpdateStatus(metricshttp.UpdateStatus{
+				Available:     result.Available,
+				LatestVersion: result.Latest,
+			})
+		}
+
+		if !result.Available || result.Latest == announced {
+			return
+		}
+
+		announced = result.Latest
+
+		logger.Info(
+			"newer release available",
+			zap.String("currentVersion", result.Current),
+			zap.String("latestVersion", result.Latest),
+			zap.String("releaseURL", result.URL),
+		)
+	}
+}
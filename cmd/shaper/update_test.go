@@ -0,0 +1,94 @@
+//go:build !minimal
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/internal/buildinfo"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/update"
+)
+
+var errStubReleaseCheck = errors.New("stub: release check failed")
+
+func TestUpdateReporterLogsEachReleaseOnce(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	exporter := metricshttp.NewExporter()
+	report := newUpdateReporter(zap.New(core), exporter)
+
+	result := update.Result{
+		Current:    "v1.3.0",
+		Latest:     "v1.4.0",
+		URL:        "https://example.com/v1.4.0",
+		Comparable: true,
+		Available:  true,
+	}
+
+	report(result, nil)
+	report(result, nil)
+	report(update.Result{}, errStubReleaseCheck)
+
+	if hints := logs.FilterMessage("newer release available").Len(); hints != 1 {
+		t.Fatalf("expected one update hint, got %d", hints)
+	}
+
+	if failures := logs.FilterMessage("release check failed").Len(); failures != 1 {
+		t.Fatalf("expected one failure warning, got %d", failures)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_update_available{latest_version=\"v1.4.0\"} 1\n") {
+		t.Fatalf("expected update gauge in output, got %s", data)
+	}
+}
+
+func TestConfigureUpdateCheckIsOptIn(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	assertDurationEqual(t, "updateInterval", cfg.Update.Interval, 24*time.Hour)
+
+	cfg.Update.Repository = "not-a-repository"
+
+	err := configureUpdateCheck(t.Context(), zap.NewNop(), cfg, buildinfo.Current(), nil)
+	if err != nil {
+		t.Fatalf("expected disabled check to skip validation, got %v", err)
+	}
+
+	cfg.Update.Check = true
+
+	err = configureUpdateCheck(t.Context(), zap.NewNop(), cfg, buildinfo.Current(), nil)
+	if err == nil {
+		t.Fatal("expected an invalid repository to be rejected")
+	}
+}
+
+func TestLoadConfigAppliesUpdateOverrides(t *testing.T) {
+	t.Setenv(envUpdateCheck, "true")
+	t.Setenv(envUpdateInterval, "6h")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	if !cfg.Update.Check {
+		t.Fatal("expected update check to be enabled from the environment")
+	}
+
+	assertDurationEqual(t, "updateInterval", cfg.Update.Interval, 6*time.Hour)
+	assertStringEqual(t, "updateRepository", cfg.Update.Repository, update.DefaultRepository)
+}
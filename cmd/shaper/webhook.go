@@ -0,0 +1,47 @@
+//go:build !minimal
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/http/webhook"
+)
+
+// configureWebhook attaches the decision webhook and returns its notifier so
+// shutdown can flush in-flight deliveries; the notifier is nil when disabled.
+//
+//nolint:ireturn // minimal builds return a nil webhook
+func configureWebhook(
+	logger *zap.Logger,
+	cfg runtimeConfig,
+	controller adapt.Controller,
+) (decisionWebhook, error) {
+	endpoint := strings.TrimSpace(cfg.Webhook.URL)
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	publisher, ok := controller.(decisionPublisher)
+	if !ok {
+		logger.Debug("controller does not publish decisions; webhook disabled")
+
+		return nil, nil
+	}
+
+	notifier, err := webhook.NewNotifier(endpoint, nil, cfg.Webhook.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("build decision webhook: %w", err)
+	}
+
+	notifier.SetErrorHandler(func(err error) {
+		logger.Warn("decision webhook delivery failed", zap.Error(err))
+	})
+
+	publisher.SetDecisionObserver(notifier)
+
+	return notifier, nil
+}
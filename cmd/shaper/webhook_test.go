@@ -0,0 +1,143 @@
+//go:build !minimal
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/http/webhook"
+)
+
+func TestConfigureWebhookSkipsWhenURLEmpty(t *testing.T) {
+	t.Parallel()
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+
+	notifier, err := configureWebhook(zap.NewNop(), defaultRuntimeConfig(), controller)
+	if err != nil {
+		t.Fatalf("configureWebhook returned error: %v", err)
+	}
+
+	if controller.observer != nil || notifier != nil {
+		t.Fatal("expected no observer when webhook URL is empty")
+	}
+}
+
+func TestConfigureWebhookAttachesNotifier(t *testing.T) {
+	t.Parallel()
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+	cfg := defaultRuntimeConfig()
+	cfg.Webhook.URL = " https://automation.example.com/shaper "
+
+	notifier, err := configureWebhook(zap.NewNop(), cfg, controller)
+	if err != nil {
+		t.Fatalf("configureWebhook returned error: %v", err)
+	}
+
+	if attached, ok := controller.observer.(*webhook.Notifier); !ok || attached != notifier {
+		t.Fatalf("expected the returned webhook notifier as observer, got %T", controller.observer)
+	}
+}
+
+func TestConfigureWebhookRejectsInvalidURL(t *testing.T) {
+	t.Parallel()
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+	cfg := defaultRuntimeConfig()
+	cfg.Webhook.URL = "ftp://automation.example.com/shaper"
+
+	_, err := configureWebhook(zap.NewNop(), cfg, controller)
+	if err == nil {
+		t.Fatal("expected error for unsupported webhook scheme")
+	}
+
+	if controller.observer != nil {
+		t.Fatal("expected observer to remain unset after failure")
+	}
+}
+
+func TestConfigureWebhookIgnoresNonPublishingController(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultRuntimeConfig()
+	cfg.Webhook.URL = "https://automation.example.com/shaper"
+
+	notifier, err := configureWebhook(zap.NewNop(), cfg, &stubController{mode: modeDryRun})
+	if err != nil || notifier != nil {
+		t.Fatalf("expected webhook to be skipped, got %v (%v)", notifier, err)
+	}
+}
+
+func TestDrainWebhookFlushesFinalDecision(t *testing.T) {
+	t.Parallel()
+
+	delivered := make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+
+		delivered <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+	cfg := defaultRuntimeConfig()
+	cfg.Webhook.URL = server.URL
+
+	notifier, err := configureWebhook(zap.NewNop(), cfg, controller)
+	if err != nil {
+		t.Fatalf("configureWebhook returned error: %v", err)
+	}
+
+	controller.observer.ObserveDecision(adapt.Decision{}) //nolint:exhaustruct
+
+	drainWebhook(zap.NewNop(), notifier, time.Second)
+
+	select {
+	case <-delivered:
+	default:
+		t.Fatal("expected the final decision to be delivered before drainWebhook returned")
+	}
+}
+
+func TestDrainWebhookGivesUpAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	controller := &publishingController{stubController: stubController{mode: modeEnforce}}
+	cfg := defaultRuntimeConfig()
+	cfg.Webhook.URL = server.URL
+
+	notifier, err := configureWebhook(zap.NewNop(), cfg, controller)
+	if err != nil {
+		t.Fatalf("configureWebhook returned error: %v", err)
+	}
+
+	controller.observer.ObserveDecision(adapt.Decision{}) //nolint:exhaustruct
+
+	core, logs := observer.New(zap.WarnLevel)
+
+	drainWebhook(zap.New(core), notifier, 20*time.Millisecond)
+
+	if logs.FilterMessageSnippet("still pending at shutdown").Len() != 1 {
+		t.Fatalf("expected a pending delivery warning, got %+v", logs.All())
+	}
+
+	drainWebhook(zap.NewNop(), nil, time.Millisecond)
+}
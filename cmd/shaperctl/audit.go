@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/audit"
+)
+
+var errUnknownAuditAction = errors.New("unknown audit action")
+
+// runAudit dispatches the audit subcommands; dump is the only one.
+func runAudit(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "dump" {
+		action := ""
+		if len(args) > 0 {
+			action = args[0]
+		}
+
+		return fmt.Errorf("%w %q; available: dump", errUnknownAuditAction, action)
+	}
+
+	return runAuditDump(args[1:], out)
+}
+
+// runAuditDump prints the records of an audit ring oldest first, one per line
+// or as a JSON array. It reads the file directly, so it works on the ring a
+// crashed shaper left behind.
+func runAuditDump(args []string, out io.Writer) error {
+	flags := clitools.NewFlagSet("shaperctl audit dump")
+	path := flags.String("file", audit.DefaultPath, "Audit ring file written by shaper")
+	asJSON := flags.Bool("json", false, "Print the records as a JSON array")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse flags: %w", err)
+	}
+
+	records, err := audit.ReadFile(*path)
+	if err != nil {
+		return fmt.Errorf("dump audit ring: %w", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(records)
+		if err != nil {
+			return fmt.Errorf("write audit records: %w", err)
+		}
+
+		return nil
+	}
+
+	return writeAuditRecords(out, records)
+}
+
+func writeAuditRecords(out io.Writer, records []audit.Record) error {
+	var builder strings.Builder
+
+	for _, record := range records {
+		_, _ = fmt.Fprintf(&builder, "%d %s %s",
+			record.Sequence, record.Timestamp.UTC().Format(time.RFC3339Nano), record.Kind)
+
+		keys := make([]string, 0, len(record.Fields))
+		for key := range record.Fields {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(&builder, " %s=%v", key, record.Fields[key])
+		}
+
+		builder.WriteByte('\n')
+	}
+
+	// The ring ends with a stop record only when the last run shut down.
+	if len(records) > 0 && records[len(records)-1].Kind != audit.KindStop {
+		builder.WriteString("# no stop record after the last entry; " +
+			"the last run did not exit cleanly\n")
+	}
+
+	_, err := io.WriteString(out, builder.String())
+	if err != nil {
+		return fmt.Errorf("write audit records: %w", err)
+	}
+
+	return nil
+}
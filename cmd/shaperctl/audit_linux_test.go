@@ -0,0 +1,131 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/audit"
+)
+
+func writeAuditRing(t *testing.T, kinds ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+
+	ring, err := audit.Open(path, 8)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	for index, kind := range kinds {
+		err = ring.Append(audit.Record{
+			Sequence:  0,
+			Timestamp: at.Add(time.Duration(index) * time.Minute),
+			Kind:      kind,
+			Fields:    map[string]any{"state": "normal", "mode": "enforce"},
+		})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	err = ring.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	return path
+}
+
+func TestAuditDumpPrintsRecordsOldestFirst(t *testing.T) {
+	t.Parallel()
+
+	path := writeAuditRing(t, audit.KindStart, audit.KindDecision, audit.KindStop)
+
+	var out bytes.Buffer
+
+	err := dispatch([]string{"audit", "dump", "-file", path}, &out)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	want := "1 2026-03-01T12:00:00Z start mode=enforce state=normal\n" +
+		"2 2026-03-01T12:01:00Z decision mode=enforce state=normal\n" +
+		"3 2026-03-01T12:02:00Z stop mode=enforce state=normal\n"
+	if out.String() != want {
+		t.Fatalf("unexpected dump:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestAuditDumpFlagsMissingStopRecord(t *testing.T) {
+	t.Parallel()
+
+	path := writeAuditRing(t, audit.KindStart, audit.KindDecision)
+
+	var out bytes.Buffer
+
+	err := runAudit([]string{"dump", "-file", path}, &out)
+	if err != nil {
+		t.Fatalf("runAudit: %v", err)
+	}
+
+	if !strings.HasSuffix(out.String(), "did not exit cleanly\n") {
+		t.Fatalf("expected unclean exit note, got %q", out.String())
+	}
+}
+
+func TestAuditDumpWritesJSON(t *testing.T) {
+	t.Parallel()
+
+	path := writeAuditRing(t, audit.KindStart)
+
+	var out bytes.Buffer
+
+	err := runAudit([]string{"dump", "-json", "-file", path}, &out)
+	if err != nil {
+		t.Fatalf("runAudit: %v", err)
+	}
+
+	var records []audit.Record
+
+	err = json.Unmarshal(out.Bytes(), &records)
+	if err != nil {
+		t.Fatalf("decode dump: %v", err)
+	}
+
+	if len(records) != 1 || records[0].Kind != audit.KindStart || records[0].Sequence != 1 {
+		t.Fatalf("unexpected records %+v", records)
+	}
+}
+
+func TestAuditRejectsUnknownActionsAndBadFiles(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{nil, {"tail"}} {
+		err := runAudit(args, &bytes.Buffer{})
+		if !errors.Is(err, errUnknownAuditAction) {
+			t.Fatalf("expected unknown action error for %v, got %v", args, err)
+		}
+	}
+
+	err := runAudit([]string{"dump", "-bogus"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected flag parse error")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	err = runAudit([]string{"dump", "-file", missing}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected missing ring error")
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+)
+
+// targetTolerance is how far two replayed targets may differ and still count
+// as the same, absorbing float noise from different step sizes.
+const targetTolerance = 1e-9
+
+const diffHeader = "time                  p95     state       current  proposed  delta\n"
+
+var (
+	errDiffConfigArgs = errors.New("expected exactly one proposed configuration file")
+	errNoDecisions    = errors.New("audit ring holds no decision records")
+	errWindowTime     = errors.New("window time must use HH:MM")
+)
+
+// diffControllerFile is the controller section of the shaper configuration
+// file, limited to the settings the slow-loop decision depends on. Values
+// left out keep the controller defaults, as in the daemon.
+type diffControllerFile struct {
+	TargetStart       *float64           `yaml:"targetStart"`
+	TargetMin         *float64           `yaml:"targetMin"`
+	TargetMax         *float64           `yaml:"targetMax"`
+	StepUp            *float64           `yaml:"stepUp"`
+	StepDown          *float64           `yaml:"stepDown"`
+	FallbackTarget    *float64           `yaml:"fallbackTarget"`
+	GoalLow           *float64           `yaml:"goalLow"`
+	GoalHigh          *float64           `yaml:"goalHigh"`
+	Interval          *time.Duration     `yaml:"interval"`
+	RelaxedInterval   *time.Duration     `yaml:"relaxedInterval"`
+	RelaxedThreshold  *float64           `yaml:"relaxedThreshold"`
+	MaxChangesPerHour *int               `yaml:"maxChangesPerHour"`
+	Policy            *string            `yaml:"policy"`
+	InitialState      *string            `yaml:"initialState"`
+	PID               diffPIDFile        `yaml:"pid"`
+	Schedule          []diffScheduleFile `yaml:"schedule"`
+	Blackout          []diffBlackoutFile `yaml:"blackout"`
+	TimeZone          string             `yaml:"timezone"`
+}
+
+type diffPIDFile struct {
+	Proportional *float64 `yaml:"proportional"`
+	Integral     *float64 `yaml:"integral"`
+	Derivative   *float64 `yaml:"derivative"`
+}
+
+type diffScheduleFile struct {
+	Start     string  `yaml:"start"`
+	End       string  `yaml:"end"`
+	TargetMax float64 `yaml:"targetMax"`
+	TimeZone  string  `yaml:"timezone"`
+}
+
+type diffBlackoutFile struct {
+	Start    string `yaml:"start"`
+	End      string `yaml:"end"`
+	TimeZone string `yaml:"timezone"`
+}
+
+// runDiffConfig replays the decision history in the audit ring through the
+// current and a proposed configuration and prints where their targets part.
+func runDiffConfig(args []string, out io.Writer) error {
+	flags := clitools.NewFlagSet("shaperctl diff-config")
+	current := flags.String("config", defaultBundleConfig, "Configuration the shaper runs with")
+	path := flags.String("file", audit.DefaultPath, "Audit ring file written by shaper")
+	all := flags.Bool("all", false, "Print every decision, not only those whose targets differ")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse flags: %w", err)
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w; usage: shaperctl diff-config [flags] new.yaml", errDiffConfigArgs)
+	}
+
+	// The daemon runs on defaults without a configuration file, so a missing
+	// current file is not an error; a missing proposal is.
+	currentCfg, err := loadDiffConfig(*current, true)
+	if err != nil {
+		return err
+	}
+
+	proposedCfg, err := loadDiffConfig(flags.Arg(0), false)
+	if err != nil {
+		return err
+	}
+
+	records, err := audit.ReadFile(*path)
+	if err != nil {
+		return fmt.Errorf("read audit ring: %w", err)
+	}
+
+	steps := audit.ReplaySteps(records)
+	if len(steps) == 0 {
+		return fmt.Errorf("%w in %s", errNoDecisions, *path)
+	}
+
+	before, err := adapt.Replay(currentCfg, steps)
+	if err != nil {
+		return fmt.Errorf("replay current configuration: %w", err)
+	}
+
+	after, err := adapt.Replay(proposedCfg, steps)
+	if err != nil {
+		return fmt.Errorf("replay proposed configuration: %w", err)
+	}
+
+	return writeConfigDiff(out, steps, before, after, *all)
+}
+
+func writeConfigDiff(
+	out io.Writer,
+	steps []adapt.ReplayStep,
+	before, after []adapt.ReplayOutcome,
+	all bool,
+) error {
+	var (
+		builder             strings.Builder
+		differing           int
+		sumBefore, sumAfter float64
+		largest             float64
+		largestAt           time.Time
+		listed              bool
+	)
+
+	for index, step := range steps {
+		delta := after[index].Target - before[index].Target
+		sumBefore += before[index].Target
+		sumAfter += after[index].Target
+
+		differs := math.Abs(delta) > targetTolerance
+		if differs {
+			differing++
+
+			if math.Abs(delta) > math.Abs(largest) {
+				largest, largestAt = delta, step.Timestamp
+			}
+		}
+
+		if !differs && !all {
+			continue
+		}
+
+		if !listed {
+			builder.WriteString(diffHeader)
+
+			listed = true
+		}
+
+		_, _ = fmt.Fprintf(&builder, "%-20s  %.4f  %-10s  %.4f   %.4f    %+.4f\n",
+			step.Timestamp.UTC().Format(time.RFC3339), step.P95,
+			before[index].State, before[index].Target, after[index].Target, delta)
+	}
+
+	if listed {
+		builder.WriteByte('\n')
+	}
+
+	count := float64(len(steps))
+	_, _ = fmt.Fprintf(&builder, "%d of %d decisions would have set a different target\n",
+		differing, len(steps))
+	_, _ = fmt.Fprintf(&builder, "mean target: current %.4f, proposed %.4f\n",
+		sumBefore/count, sumAfter/count)
+
+	if differing > 0 {
+		_, _ = fmt.Fprintf(&builder, "largest difference: %+.4f at %s\n",
+			largest, largestAt.UTC().Format(time.RFC3339))
+	}
+
+	_, err := io.WriteString(out, builder.String())
+	if err != nil {
+		return fmt.Errorf("write config diff: %w", err)
+	}
+
+	return nil
+}
+
+// loadDiffConfig reads the controller section of the configuration file at
+// path over the controller defaults. Environment overrides are not applied.
+func loadDiffConfig(path string, optional bool) (adapt.Config, error) {
+	cfg := adapt.DefaultConfig()
+
+	data, err := os.ReadFile(path) //nolint:gosec // the operator names the file
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+
+		return adapt.Config{}, fmt.Errorf("read config file %q: %w", path, err)
+	}
+
+	var file struct {
+		Controller diffControllerFile `yaml:"controller"`
+	}
+
+	err = yaml.Unmarshal(data, &file)
+	if err != nil {
+		return adapt.Config{}, fmt.Errorf("decode config file %q: %w", path, err)
+	}
+
+	err = file.Controller.apply(&cfg)
+	if err != nil {
+		return adapt.Config{}, fmt.Errorf("config file %q: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (f diffControllerFile) apply(cfg *adapt.Config) error {
+	assign(&cfg.TargetStart, f.TargetStart)
+	assign(&cfg.TargetMin, f.TargetMin)
+	assign(&cfg.TargetMax, f.TargetMax)
+	assign(&cfg.StepUp, f.StepUp)
+	assign(&cfg.StepDown, f.StepDown)
+	assign(&cfg.FallbackTarget, f.FallbackTarget)
+	assign(&cfg.GoalLow, f.GoalLow)
+	assign(&cfg.GoalHigh, f.GoalHigh)
+	assign(&cfg.Interval, f.Interval)
+	assign(&cfg.RelaxedInterval, f.RelaxedInterval)
+	assign(&cfg.RelaxedThreshold, f.RelaxedThreshold)
+	assign(&cfg.MaxChangesPerHour, f.MaxChangesPerHour)
+	assign(&cfg.Policy, f.Policy)
+	assign(&cfg.InitialState, f.InitialState)
+	assign(&cfg.PID.Proportional, f.PID.Proportional)
+	assign(&cfg.PID.Integral, f.PID.Integral)
+	assign(&cfg.PID.Derivative, f.PID.Derivative)
+
+	for _, window := range f.Schedule {
+		start, end, location, err := parseWindow(
+			window.Start, window.End, window.TimeZone, f.TimeZone,
+		)
+		if err != nil {
+			return fmt.Errorf("controller.schedule: %w", err)
+		}
+
+		cfg.Schedule = append(cfg.Schedule, adapt.ScheduleWindow{
+			Start:     start,
+			End:       end,
+			TargetMax: window.TargetMax,
+			Location:  location,
+		})
+	}
+
+	for _, window := range f.Blackout {
+		start, end, location, err := parseWindow(
+			window.Start, window.End, window.TimeZone, f.TimeZone,
+		)
+		if err != nil {
+			return fmt.Errorf("controller.blackout: %w", err)
+		}
+
+		cfg.Blackout = append(cfg.Blackout, adapt.BlackoutWindow{
+			Start:    start,
+			End:      end,
+			Location: location,
+		})
+	}
+
+	return nil
+}
+
+func assign[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// parseWindow reads the HH:MM bounds of a daily window and its time zone: the
+// window's own, else the controller's, else nil for local time.
+func parseWindow(start, end, zone, controllerZone string) (
+	time.Duration,
+	time.Duration,
+	*time.Location,
+	error,
+) {
+	startOffset, err := parseTimeOfDay(start)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	endOffset, err := parseTimeOfDay(end)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if strings.TrimSpace(zone) == "" {
+		zone = controllerZone
+	}
+
+	if strings.TrimSpace(zone) == "" {
+		return startOffset, endOffset, nil, nil
+	}
+
+	location, err := time.LoadLocation(strings.TrimSpace(zone))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("time zone %q: %w", zone, err)
+	}
+
+	return startOffset, endOffset, location, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%w, got %q", errWindowTime, value)
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
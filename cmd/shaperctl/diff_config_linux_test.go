@@ -0,0 +1,217 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+)
+
+// writeDecisionRing records one normal decision per P95, an hour apart.
+func writeDecisionRing(t *testing.T, p95s ...float64) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+
+	ring, err := audit.Open(path, 8)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	for index, p95 := range p95s {
+		ring.ObserveDecision(adapt.Decision{
+			StepID:       "",
+			Timestamp:    at.Add(time.Duration(index) * time.Hour),
+			ResourceID:   "",
+			Mode:         "enforce",
+			State:        adapt.StateNormal,
+			P95:          p95,
+			Target:       0.25,
+			NextInterval: time.Hour,
+			Err:          nil,
+		})
+	}
+
+	err = ring.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	return path
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+func TestDiffConfigPrintsDifferingTargets(t *testing.T) {
+	t.Parallel()
+
+	ring := writeDecisionRing(t, 0.1, 0.25, 0.35)
+	current := writeConfigFile(t, "controller:\n  stepUp: 0.02\n")
+	proposed := writeConfigFile(t, "controller:\n  stepUp: 0.05\n  goalHigh: 0.4\n")
+
+	var out bytes.Buffer
+
+	err := dispatch([]string{"diff-config", "-file", ring, "-config", current, proposed}, &out)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	// The first step raises the target by each step size; the second sits in
+	// both goal bands; the third is above the current band only.
+	want := diffHeader +
+		"2026-03-01T12:00:00Z  0.1000  normal      0.2700   0.3000    +0.0300\n" +
+		"2026-03-01T13:00:00Z  0.2500  normal      0.2700   0.3000    +0.0300\n" +
+		"2026-03-01T14:00:00Z  0.3500  normal      0.2600   0.3000    +0.0400\n" +
+		"\n" +
+		"3 of 3 decisions would have set a different target\n" +
+		"mean target: current 0.2667, proposed 0.3000\n" +
+		"largest difference: +0.0400 at 2026-03-01T14:00:00Z\n"
+	if out.String() != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestDiffConfigReportsIdenticalConfigs(t *testing.T) {
+	t.Parallel()
+
+	ring := writeDecisionRing(t, 0.1, 0.2)
+	proposed := writeConfigFile(t, `controller:
+  policy: schedule
+  timezone: UTC
+  schedule:
+    - start: "02:00"
+      end: "03:00"
+      targetMax: 0.3
+  blackout:
+    - start: "04:00"
+      end: "05:00"
+      timezone: Europe/Berlin
+`)
+
+	var out bytes.Buffer
+
+	err := runDiffConfig([]string{
+		"-file", ring, "-config", filepath.Join(t.TempDir(), "missing.yaml"), proposed,
+	}, &out)
+	if err != nil {
+		t.Fatalf("runDiffConfig: %v", err)
+	}
+
+	want := "0 of 2 decisions would have set a different target\n" +
+		"mean target: current 0.2800, proposed 0.2800\n"
+	if out.String() != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+
+	err = runDiffConfig([]string{"-all", "-file", ring, "-config", proposed, proposed}, &out)
+	if err != nil {
+		t.Fatalf("runDiffConfig: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "2026-03-01T13:00:00Z  0.2000  normal      0.2900") {
+		t.Fatalf("expected -all to list unchanged decisions, got:\n%s", out.String())
+	}
+}
+
+func TestDiffConfigRejectsBadInput(t *testing.T) {
+	t.Parallel()
+
+	ring := writeDecisionRing(t, 0.1)
+	empty := writeDecisionRing(t)
+	valid := writeConfigFile(t, "controller:\n  stepUp: 0.05\n")
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"no proposal":      {args: []string{"-file", ring}, want: errDiffConfigArgs.Error()},
+		"unknown flag":     {args: []string{"-bogus"}, want: "parse flags"},
+		"missing proposal": {args: []string{"-file", ring, missing}, want: "read config file"},
+		"missing ring":     {args: []string{"-file", missing, valid}, want: "read audit ring"},
+		"no decisions":     {args: []string{"-file", empty, valid}, want: errNoDecisions.Error()},
+		"malformed yaml": {
+			args: []string{"-file", ring, writeConfigFile(t, "controller: [")},
+			want: "decode config file",
+		},
+		"invalid proposal": {
+			args: []string{"-file", ring, "-config", valid, invalidConfig(t)},
+			want: "replay proposed configuration",
+		},
+		"invalid current": {
+			args: []string{"-file", ring, "-config", invalidConfig(t), valid},
+			want: "replay current configuration",
+		},
+		"bad window time": {
+			args: []string{"-file", ring, windowConfig(t, "25:00", "")},
+			want: errWindowTime.Error(),
+		},
+		"bad window zone": {
+			args: []string{"-file", ring, windowConfig(t, "01:00", "Mars/Base")},
+			want: "time zone",
+		},
+		"bad blackout time": {
+			args: []string{"-file", ring, blackoutConfig(t)},
+			want: "controller.blackout",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := runDiffConfig(test.args, &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("expected an error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+
+	err := runDiffConfig([]string{"-file", ring, "-config", valid, invalidConfig(t)}, nil)
+	if !errors.Is(err, adapt.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig for the proposal, got %v", err)
+	}
+}
+
+func invalidConfig(t *testing.T) string {
+	t.Helper()
+
+	return writeConfigFile(t, "controller:\n  targetMin: 0.5\n  targetMax: 0.3\n")
+}
+
+func windowConfig(t *testing.T, start, zone string) string {
+	t.Helper()
+
+	return writeConfigFile(t, "controller:\n  schedule:\n    - start: \""+start+
+		"\"\n      end: \"02:00\"\n      targetMax: 0.3\n      timezone: \""+zone+"\"\n")
+}
+
+func blackoutConfig(t *testing.T) string {
+	t.Helper()
+
+	return writeConfigFile(t,
+		"controller:\n  blackout:\n    - start: \"01:00\"\n      end: \"noon\"\n")
+}
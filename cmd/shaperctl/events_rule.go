@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+const (
+	defaultEventsRuleTimeout = time.Minute
+	eventsRuleNamePrefix     = "oci-cpu-shaper-events-"
+	// eventsRuleNameSuffix is how many trailing OCID characters name the rule,
+	// keeping the rules of instances that share a compartment apart.
+	eventsRuleNameSuffix = 12
+	imdsEndpointEnv      = "OCI_CPU_SHAPER_IMDS_ENDPOINT"
+)
+
+var (
+	errEventsRuleTopics = errors.New(
+		"no topic to route events to; pass --topic or give the guardrail alarm a destination",
+	)
+	errEventsRuleTimeout    = errors.New("timeout must be greater than zero")
+	errEventsRuleFleetName  = errors.New("--name cannot be combined with --inventory")
+	errEventsRuleFleetScope = errors.New(
+		"inventory instance needs a compartment and region; set them in the inventory " +
+			"or pass --compartment and --region",
+	)
+)
+
+type eventRuleManager interface {
+	EnsureRule(ctx context.Context, rule oci.EventRule) (oci.EventRuleResult, error)
+}
+
+type guardrailFinder interface {
+	FindGuardrailAlarm(
+		ctx context.Context,
+		compartmentID string,
+		instanceID string,
+	) (oci.GuardrailAlarm, error)
+}
+
+// Seams so tests can avoid IMDS and the OCI APIs.
+//
+//nolint:gochecknoglobals // test seams
+var (
+	newEventsIMDS = func() imds.Client {
+		var opts []imds.Option
+
+		endpoint := strings.TrimSpace(os.Getenv(imdsEndpointEnv))
+		if endpoint != "" {
+			opts = append(opts, imds.WithBaseURL(endpoint))
+		}
+
+		return imds.NewClient(nil, opts...)
+	}
+	newEventRuleManager = func(region string) (eventRuleManager, error) {
+		client, err := oci.NewInstancePrincipalEventsClient(region)
+		if err != nil {
+			return nil, fmt.Errorf("build events client: %w", err)
+		}
+
+		return client, nil
+	}
+	newGuardrailFinder = func(region string) (guardrailFinder, error) {
+		client, err := oci.NewInstancePrincipalAlarmClient(region)
+		if err != nil {
+			return nil, fmt.Errorf("build alarm client: %w", err)
+		}
+
+		return client, nil
+	}
+)
+
+type eventsRuleOptions struct {
+	compartmentID string
+	instanceID    string
+	region        string
+	topics        string
+	name          string
+	eventTypes    string
+	timeout       time.Duration
+	dryRun        bool
+	inventory     string
+	concurrency   int
+}
+
+// runEventsRule creates or updates an Events rule that forwards the instance's
+// maintenance, instance action, and termination events to Notifications
+// topics, so a reclamation stop or scheduled maintenance pages the same people
+// as the P95 guardrail alarm. Without --topic the rule routes to the guardrail
+// alarm's destinations.
+func runEventsRule(args []string, out io.Writer) error {
+	opts, err := parseEventsRuleArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.inventory != "" {
+		return runFleetEventsRule(opts, out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	err = resolveEventsRuleScope(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	return ensureEventsRule(ctx, opts, out)
+}
+
+// runFleetEventsRule ensures the rule of every instance in the --inventory
+// file and prints one line per instance name. IMDS only describes the local
+// instance, so each one needs its compartment and region from the inventory or
+// the flags.
+func runFleetEventsRule(opts eventsRuleOptions, out io.Writer) error {
+	instances, err := clitools.LoadInventory(
+		opts.inventory,
+		clitools.Options{Region: opts.region, CompartmentID: opts.compartmentID}, //nolint:exhaustruct
+	)
+	if err != nil {
+		return err //nolint:wrapcheck // inventory errors name the file
+	}
+
+	report := clitools.RunFleet(
+		context.Background(),
+		instances,
+		opts.concurrency,
+		func(ctx context.Context, instance clitools.Instance) clitools.FleetResult {
+			if instance.CompartmentID == "" || instance.Region == "" {
+				return clitools.FleetResult{Text: "", Value: nil, Err: errEventsRuleFleetScope}
+			}
+
+			scoped := opts
+			scoped.instanceID = instance.InstanceID
+			scoped.compartmentID = instance.CompartmentID
+			scoped.region = instance.Region
+
+			ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+
+			var lines bytes.Buffer
+
+			err := ensureEventsRule(ctx, scoped, &lines)
+			text := strings.ReplaceAll(strings.TrimSpace(lines.String()), "\n", "; ")
+
+			return clitools.FleetResult{Text: text, Value: nil, Err: err}
+		},
+	)
+
+	err = report.Print(out, clitools.OutputText)
+	if err != nil {
+		return fmt.Errorf("print report: %w", err)
+	}
+
+	return report.Err()
+}
+
+// ensureEventsRule creates or updates the rule for the instance opts names.
+func ensureEventsRule(ctx context.Context, opts eventsRuleOptions, out io.Writer) error {
+	condition, err := oci.InstanceEventCondition(opts.instanceID, splitFlagList(opts.eventTypes))
+	if err != nil {
+		return fmt.Errorf("build event condition: %w", err)
+	}
+
+	if opts.name == "" {
+		opts.name = eventsRuleNamePrefix +
+			opts.instanceID[max(0, len(opts.instanceID)-eventsRuleNameSuffix):]
+	}
+
+	_, _ = fmt.Fprintf(out, "rule: %s\ncondition: %s\n", opts.name, condition)
+
+	topics := splitFlagList(opts.topics)
+
+	switch {
+	case len(topics) > 0:
+	case opts.dryRun:
+		topics = []string{"<guardrail alarm destinations>"}
+	default:
+		topics, err = guardrailTopics(ctx, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "topics: %s\n", strings.Join(topics, ","))
+
+	if opts.dryRun {
+		return nil
+	}
+
+	manager, err := newEventRuleManager(opts.region)
+	if err != nil {
+		return err
+	}
+
+	result, err := manager.EnsureRule(ctx, oci.EventRule{
+		CompartmentID: opts.compartmentID,
+		DisplayName:   opts.name,
+		Description:   "Instance maintenance and reclamation events for " + opts.instanceID,
+		Condition:     condition,
+		TopicIDs:      topics,
+	})
+	if err != nil {
+		return fmt.Errorf("ensure events rule: %w", err)
+	}
+
+	action := "updated"
+	if result.Created {
+		action = "created"
+	}
+
+	_, _ = fmt.Fprintf(out, "%s: %s (%s)\n", action, result.ID, result.State)
+
+	return nil
+}
+
+func parseEventsRuleArgs(args []string) (eventsRuleOptions, error) {
+	var opts eventsRuleOptions
+
+	flags := clitools.NewFlagSet("shaperctl events-rule")
+	flags.StringVar(&opts.compartmentID, "compartment", "", "Compartment OCID for the rule")
+	flags.StringVar(&opts.instanceID, "instance", "", "Instance OCID whose events are matched")
+	flags.StringVar(&opts.region, "region", "", "OCI region for the API endpoints")
+	flags.StringVar(
+		&opts.topics,
+		"topic",
+		"",
+		"Comma-separated topic OCIDs; defaults to the guardrail alarm's destinations",
+	)
+	flags.StringVar(
+		&opts.name,
+		"name",
+		"",
+		"Rule display name; defaults to "+eventsRuleNamePrefix+"<instance OCID suffix>",
+	)
+	flags.StringVar(
+		&opts.eventTypes,
+		"event-types",
+		strings.Join(oci.InstanceEventTypes(), ","),
+		"Comma-separated event types the rule matches",
+	)
+	flags.DurationVar(&opts.timeout, "timeout", defaultEventsRuleTimeout, "Overall timeout")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Print the rule without creating it")
+	flags.StringVar(
+		&opts.inventory,
+		"inventory",
+		"",
+		"YAML inventory of instances to create rules for instead of --instance",
+	)
+	flags.IntVar(
+		&opts.concurrency,
+		"concurrency",
+		clitools.DefaultConcurrency,
+		"Inventory instances to work on at once",
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return eventsRuleOptions{}, fmt.Errorf("parse flags: %w", err)
+	}
+
+	if opts.timeout <= 0 {
+		return eventsRuleOptions{}, errEventsRuleTimeout
+	}
+
+	if opts.concurrency <= 0 {
+		return eventsRuleOptions{}, clitools.ErrConcurrencyInvalid
+	}
+
+	if opts.inventory != "" && strings.TrimSpace(opts.name) != "" {
+		return eventsRuleOptions{}, errEventsRuleFleetName
+	}
+
+	opts.compartmentID = strings.TrimSpace(opts.compartmentID)
+	opts.instanceID = strings.TrimSpace(opts.instanceID)
+	opts.region = strings.TrimSpace(opts.region)
+	opts.name = strings.TrimSpace(opts.name)
+
+	return opts, nil
+}
+
+// resolveEventsRuleScope fills the instance, compartment, and region left
+// unset on the command line from IMDS, as when run on the instance itself.
+func resolveEventsRuleScope(ctx context.Context, opts *eventsRuleOptions) error {
+	if opts.instanceID != "" && opts.compartmentID != "" && opts.region != "" {
+		return nil
+	}
+
+	client := newEventsIMDS()
+
+	for _, lookup := range []struct {
+		name  string
+		value *string
+		fetch func(context.Context) (string, error)
+	}{
+		{"instance ocid", &opts.instanceID, client.InstanceID},
+		{"compartment ocid", &opts.compartmentID, client.CompartmentID},
+		{"region", &opts.region, client.Region},
+	} {
+		if *lookup.value != "" {
+			continue
+		}
+
+		value, err := lookup.fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("lookup %s from imds: %w", lookup.name, err)
+		}
+
+		*lookup.value = strings.TrimSpace(value)
+	}
+
+	return nil
+}
+
+func guardrailTopics(ctx context.Context, opts eventsRuleOptions) ([]string, error) {
+	finder, err := newGuardrailFinder(opts.region)
+	if err != nil {
+		return nil, err
+	}
+
+	alarm, err := finder.FindGuardrailAlarm(ctx, opts.compartmentID, opts.instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("find guardrail alarm topics: %w", err)
+	}
+
+	if len(alarm.Destinations) == 0 {
+		return nil, errEventsRuleTopics
+	}
+
+	return alarm.Destinations, nil
+}
+
+func splitFlagList(value string) []string {
+	var items []string
+
+	for item := range strings.SplitSeq(value, ",") {
+		trimmed := strings.TrimSpace(item)
+		if trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+
+	return items
+}
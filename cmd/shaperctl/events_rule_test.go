@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+const (
+	eventsTestInstance    = "ocid1.instance.oc1..aaaaexampleinstance"
+	eventsTestCompartment = "ocid1.compartment.oc1..example"
+	eventsTestTopic       = "ocid1.onstopic.oc1..ops"
+)
+
+var errEventsStub = errors.New("stub failure")
+
+type stubEventRuleManager struct {
+	rule   oci.EventRule
+	result oci.EventRuleResult
+	err    error
+}
+
+func (s *stubEventRuleManager) EnsureRule(
+	_ context.Context,
+	rule oci.EventRule,
+) (oci.EventRuleResult, error) {
+	s.rule = rule
+
+	return s.result, s.err
+}
+
+type stubGuardrailFinder struct {
+	alarm oci.GuardrailAlarm
+	err   error
+}
+
+func (s stubGuardrailFinder) FindGuardrailAlarm(
+	_ context.Context,
+	_ string,
+	_ string,
+) (oci.GuardrailAlarm, error) {
+	return s.alarm, s.err
+}
+
+// stubEventsOCI replaces the OCI seams for the test and returns the manager
+// that records the ensured rule.
+func stubEventsOCI(
+	t *testing.T,
+	manager *stubEventRuleManager,
+	finder stubGuardrailFinder,
+) {
+	t.Helper()
+
+	previousManager, previousFinder := newEventRuleManager, newGuardrailFinder
+	newEventRuleManager = func(string) (eventRuleManager, error) { return manager, nil }
+	newGuardrailFinder = func(string) (guardrailFinder, error) { return finder, nil }
+
+	t.Cleanup(func() {
+		newEventRuleManager, newGuardrailFinder = previousManager, previousFinder
+	})
+}
+
+func newEventsIMDSServer(t *testing.T) {
+	t.Helper()
+
+	values := map[string]string{
+		"/instance/id":            eventsTestInstance,
+		"/instance/compartmentId": eventsTestCompartment,
+		"/instance/region":        "eu-frankfurt-1\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := values[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(value))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv(imdsEndpointEnv, server.URL)
+}
+
+//nolint:paralleltest // replaces the OCI seams and sets the IMDS endpoint
+func TestEventsRuleResolvesScopeAndGuardrailTopics(t *testing.T) {
+	newEventsIMDSServer(t)
+
+	manager := &stubEventRuleManager{
+		rule:   oci.EventRule{},
+		result: oci.EventRuleResult{ID: "ocid1.eventrule.oc1..new", State: "ACTIVE", Created: true},
+		err:    nil,
+	}
+	stubEventsOCI(t, manager, stubGuardrailFinder{
+		alarm: oci.GuardrailAlarm{Destinations: []string{eventsTestTopic}}, //nolint:exhaustruct
+		err:   nil,
+	})
+
+	var out bytes.Buffer
+
+	err := dispatch([]string{"events-rule"}, &out)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if manager.rule.CompartmentID != eventsTestCompartment ||
+		manager.rule.DisplayName != "oci-cpu-shaper-events-mpleinstance" ||
+		len(manager.rule.TopicIDs) != 1 || manager.rule.TopicIDs[0] != eventsTestTopic ||
+		!strings.Contains(manager.rule.Condition, `"resourceId":"`+eventsTestInstance+`"`) ||
+		!strings.Contains(manager.rule.Condition, oci.EventTypeMaintenanceReminder) {
+		t.Fatalf("unexpected rule %+v", manager.rule)
+	}
+
+	if !strings.HasSuffix(out.String(), "topics: "+eventsTestTopic+
+		"\ncreated: ocid1.eventrule.oc1..new (ACTIVE)\n") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+//nolint:paralleltest // replaces the OCI seams
+func TestEventsRuleUsesFlags(t *testing.T) {
+	manager := &stubEventRuleManager{
+		rule: oci.EventRule{},
+		result: oci.EventRuleResult{
+			ID:      "ocid1.eventrule.oc1..old",
+			State:   "UPDATING",
+			Created: false,
+		},
+		err: nil,
+	}
+	stubEventsOCI(t, manager, stubGuardrailFinder{alarm: oci.GuardrailAlarm{}, err: errEventsStub})
+
+	var out bytes.Buffer
+
+	err := runEventsRule([]string{
+		"-instance", eventsTestInstance,
+		"-compartment", eventsTestCompartment,
+		"-region", "us-ashburn-1",
+		"-topic", "a, b",
+		"-name", "custom",
+		"-event-types", "com.example.one",
+	}, &out)
+	if err != nil {
+		t.Fatalf("runEventsRule: %v", err)
+	}
+
+	if manager.rule.DisplayName != "custom" || strings.Join(manager.rule.TopicIDs, ",") != "a,b" ||
+		!strings.Contains(manager.rule.Condition, `["com.example.one"]`) {
+		t.Fatalf("unexpected rule %+v", manager.rule)
+	}
+
+	if !strings.Contains(out.String(), "updated: ocid1.eventrule.oc1..old (UPDATING)") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+//nolint:paralleltest // replaces the OCI seams
+func TestEventsRuleDryRunCallsNoOCI(t *testing.T) {
+	manager := &stubEventRuleManager{rule: oci.EventRule{}, result: oci.EventRuleResult{}, err: nil}
+	stubEventsOCI(t, manager, stubGuardrailFinder{alarm: oci.GuardrailAlarm{}, err: errEventsStub})
+
+	var out bytes.Buffer
+
+	err := runEventsRule([]string{
+		"-instance", "short",
+		"-compartment", eventsTestCompartment,
+		"-region", "us-ashburn-1",
+		"-dry-run",
+	}, &out)
+	if err != nil {
+		t.Fatalf("runEventsRule: %v", err)
+	}
+
+	if manager.rule.DisplayName != "" {
+		t.Fatalf("expected no rule to be ensured, got %+v", manager.rule)
+	}
+
+	if !strings.HasPrefix(out.String(), "rule: oci-cpu-shaper-events-short\n") ||
+		!strings.HasSuffix(out.String(), "topics: <guardrail alarm destinations>\n") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+//nolint:paralleltest // replaces the OCI seams and sets the IMDS endpoint
+func TestEventsRuleReportsFailures(t *testing.T) {
+	scope := []string{
+		"-instance", eventsTestInstance,
+		"-compartment", eventsTestCompartment,
+		"-region", "us-ashburn-1",
+	}
+
+	testCases := []struct {
+		name    string
+		args    []string
+		manager *stubEventRuleManager
+		finder  stubGuardrailFinder
+		want    error
+	}{
+		{
+			name:    "no guardrail topics",
+			args:    scope,
+			manager: &stubEventRuleManager{},
+			finder:  stubGuardrailFinder{},
+			want:    errEventsRuleTopics,
+		},
+		{
+			name:    "guardrail lookup",
+			args:    scope,
+			manager: &stubEventRuleManager{},
+			finder:  stubGuardrailFinder{err: errEventsStub},
+			want:    errEventsStub,
+		},
+		{
+			name:    "ensure",
+			args:    append([]string{"-topic", eventsTestTopic}, scope...),
+			manager: &stubEventRuleManager{err: errEventsStub},
+			finder:  stubGuardrailFinder{},
+			want:    errEventsStub,
+		},
+		{
+			name:    "timeout",
+			args:    []string{"-timeout", "0s"},
+			manager: &stubEventRuleManager{},
+			finder:  stubGuardrailFinder{},
+			want:    errEventsRuleTimeout,
+		},
+	}
+
+	for _, testCase := range testCases {
+		stubEventsOCI(t, testCase.manager, testCase.finder)
+
+		err := runEventsRule(testCase.args, &bytes.Buffer{})
+		if !errors.Is(err, testCase.want) {
+			t.Fatalf("%s: expected %v, got %v", testCase.name, testCase.want, err)
+		}
+	}
+
+	err := runEventsRule(append([]string{"-event-types", " "}, scope...), &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected missing event types to fail")
+	}
+
+	err = runEventsRule([]string{"-bogus"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected flag parse error")
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	t.Setenv(imdsEndpointEnv, server.URL)
+
+	err = runEventsRule([]string{"-timeout", "2s"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "lookup instance ocid from imds") {
+		t.Fatalf("expected imds lookup failure, got %v", err)
+	}
+}
+
+//nolint:paralleltest // replaces the OCI seams
+func TestEventsRuleEnsuresRulesForAnInventory(t *testing.T) {
+	manager := &stubEventRuleManager{
+		rule:   oci.EventRule{},
+		result: oci.EventRuleResult{ID: "ocid1.eventrule.oc1..new", State: "ACTIVE", Created: true},
+		err:    nil,
+	}
+	stubEventsOCI(t, manager, stubGuardrailFinder{alarm: oci.GuardrailAlarm{}, err: errEventsStub})
+
+	inventory := filepath.Join(t.TempDir(), "fleet.yaml")
+
+	err := os.WriteFile(inventory, []byte(`instances:
+  - name: web
+    ocid: ocid1.instance.oc1..web
+  - name: elsewhere
+    ocid: ocid1.instance.oc1..elsewhere
+    region: ""
+    compartment: ocid1.compartment.oc1..other
+`), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var out bytes.Buffer
+
+	err = runEventsRule([]string{
+		"-inventory", inventory,
+		"-compartment", eventsTestCompartment,
+		"-region", "us-ashburn-1",
+		"-topic", eventsTestTopic,
+		"-event-types", "com.example.one",
+		"-concurrency", "1",
+	}, &out)
+	if err != nil {
+		t.Fatalf("runEventsRule: %v", err)
+	}
+
+	// One instance at a time, so the last rule is the one listed last.
+	if manager.rule.CompartmentID != "ocid1.compartment.oc1..other" {
+		t.Fatalf("expected the inventory compartment, got %+v", manager.rule)
+	}
+
+	if !strings.HasPrefix(out.String(), "elsewhere: rule: oci-cpu-shaper-events-1..elsewhere; ") ||
+		!strings.Contains(out.String(), "\nweb: rule: oci-cpu-shaper-events-nce.oc1..web; ") ||
+		!strings.Contains(out.String(), "; created: ocid1.eventrule.oc1..new (ACTIVE)\n") {
+		t.Fatalf("unexpected report %q", out.String())
+	}
+
+	err = runEventsRule([]string{"-inventory", inventory, "-dry-run"}, &bytes.Buffer{})
+	if !errors.Is(err, errEventsRuleFleetScope) {
+		t.Fatalf("expected the missing scope reported, got %v", err)
+	}
+
+	err = runEventsRule([]string{"-inventory", inventory, "-name", "shared"}, &bytes.Buffer{})
+	if !errors.Is(err, errEventsRuleFleetName) {
+		t.Fatalf("expected errEventsRuleFleetName, got %v", err)
+	}
+
+	err = runEventsRule([]string{"-inventory", inventory, "-concurrency", "0"}, &bytes.Buffer{})
+	if !errors.Is(err, clitools.ErrConcurrencyInvalid) {
+		t.Fatalf("expected ErrConcurrencyInvalid, got %v", err)
+	}
+
+	err = runEventsRule([]string{"-inventory", inventory + ".missing"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "read inventory") {
+		t.Fatalf("expected the inventory error, got %v", err)
+	}
+}
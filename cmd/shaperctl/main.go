@@ -0,0 +1,65 @@
+// Command shaperctl bundles operator tasks that talk to a running shaper or
+// its files, such as capturing a support bundle, or describe it, such as
+// printing the controller state machine, or check it, such as verifying the
+// controller transitions after a deployment.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	errMissingCommand = errors.New("command is required")
+	errUnknownCommand = errors.New("unknown command")
+)
+
+// command runs one shaperctl subcommand with its remaining arguments.
+type command func(args []string, out io.Writer) error
+
+//nolint:gochecknoglobals // subcommand registry
+var commands = map[string]command{
+	"audit":          runAudit,
+	"diff-config":    runDiffConfig,
+	"events-rule":    runEventsRule,
+	"statechart":     runStatechart,
+	"support-bundle": runSupportBundle,
+	"verify":         runVerify,
+}
+
+func main() {
+	err := dispatch(os.Args[1:], os.Stdout)
+	if err != nil {
+		log.Printf("error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func dispatch(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w; available: %s", errMissingCommand, commandNames())
+	}
+
+	run, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w %q; available: %s", errUnknownCommand, args[0], commandNames())
+	}
+
+	return run(args[1:], out)
+}
+
+func commandNames() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
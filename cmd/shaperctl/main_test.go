@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDispatchRejectsMissingAndUnknownCommands(t *testing.T) {
+	t.Parallel()
+
+	err := dispatch(nil, &bytes.Buffer{})
+	if !errors.Is(err, errMissingCommand) || !strings.Contains(err.Error(), "support-bundle") {
+		t.Fatalf("expected missing command error listing commands, got %v", err)
+	}
+
+	err = dispatch([]string{"frobnicate"}, &bytes.Buffer{})
+	if !errors.Is(err, errUnknownCommand) {
+		t.Fatalf("expected unknown command error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+const (
+	formatMermaid = "mermaid"
+	formatDOT     = "dot"
+)
+
+var errUnknownFormat = errors.New("unknown format")
+
+// runStatechart prints the controller state machine as a Mermaid or Graphviz
+// diagram, generated from the definition the controller tests check against.
+func runStatechart(args []string, out io.Writer) error {
+	flags := clitools.NewFlagSet("shaperctl statechart")
+	format := flags.String(
+		"format",
+		formatMermaid,
+		"Diagram format: "+formatMermaid+" or "+formatDOT,
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse flags: %w", err)
+	}
+
+	chart := adapt.ControllerStatechart()
+
+	var diagram string
+
+	switch *format {
+	case formatMermaid:
+		diagram = chart.Mermaid()
+	case formatDOT:
+		diagram = chart.DOT()
+	default:
+		return fmt.Errorf(
+			"%w %q; available: %s, %s",
+			errUnknownFormat,
+			*format,
+			formatDOT,
+			formatMermaid,
+		)
+	}
+
+	_, err = io.WriteString(out, diagram)
+	if err != nil {
+		return fmt.Errorf("write statechart: %w", err)
+	}
+
+	return nil
+}
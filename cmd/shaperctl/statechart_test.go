@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+func TestStatechartPrintsMermaidAndDOT(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	err := dispatch([]string{"statechart"}, &out)
+	if err != nil {
+		t.Fatalf("statechart: %v", err)
+	}
+
+	if out.String() != adapt.ControllerStatechart().Mermaid() {
+		t.Fatalf("expected Mermaid by default, got:\n%s", out.String())
+	}
+
+	out.Reset()
+
+	err = dispatch([]string{"statechart", "-format", "dot"}, &out)
+	if err != nil || !strings.HasPrefix(out.String(), "digraph controller {") {
+		t.Fatalf("expected a DOT digraph, got %v:\n%s", err, out.String())
+	}
+
+	err = dispatch([]string{"statechart", "-format", "svg"}, &out)
+	if !errors.Is(err, errUnknownFormat) {
+		t.Fatalf("expected errUnknownFormat, got %v", err)
+	}
+}
+
+// TestStatechartDocsMatchController keeps the diagram in §9.15 in step with
+// the controller; regenerate it with `shaperctl statechart` when this fails.
+func TestStatechartDocsMatchController(t *testing.T) {
+	t.Parallel()
+
+	docs, err := os.ReadFile("../../docs/09-cli.md")
+	if err != nil {
+		t.Fatalf("read docs: %v", err)
+	}
+
+	block := "```mermaid\n" + adapt.ControllerStatechart().Mermaid() + "```\n"
+	if !strings.Contains(string(docs), block) {
+		t.Fatalf("docs/09-cli.md does not contain the current statechart:\n%s", block)
+	}
+}
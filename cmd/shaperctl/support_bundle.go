@@ -0,0 +1,400 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/internal/clitools"
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+)
+
+const (
+	defaultBundleURL     = "http://127.0.0.1:9108"
+	defaultBundleConfig  = "/etc/oci-cpu-shaper/config.yaml"
+	defaultBundleUnit    = "oci-cpu-shaper"
+	defaultBundleLines   = 1000
+	defaultBundleTimeout = 10 * time.Second
+
+	// maxLogTailBytes bounds how much of a log file is read to find its tail.
+	maxLogTailBytes = 4 << 20
+	bundleFileMode  = 0o600
+)
+
+var (
+	errNegativeLogLines = errors.New("log-lines must not be negative")
+	errUnexpectedStatus = errors.New("unexpected status")
+)
+
+// bundleEndpoints lists the listener paths copied into the bundle and the file
+// names they are stored under.
+//
+//nolint:gochecknoglobals // fixed endpoint table
+var bundleEndpoints = []struct {
+	path string
+	name string
+}{
+	{path: "/metrics", name: "metrics.txt"},
+	{path: "/healthz", name: "healthz.json"},
+	{path: "/admin/history", name: "history.json"},
+	{path: "/debug/controller", name: "controller.json"},
+}
+
+// Seams so tests can avoid journald and a wall clock.
+//
+//nolint:gochecknoglobals // test seams
+var (
+	journalTail = func(ctx context.Context, unit string, lines int) ([]byte, error) {
+		//nolint:gosec // unit and line count come from the operator's own flags
+		cmd := exec.CommandContext(
+			ctx,
+			"journalctl",
+			"--unit", unit,
+			"--lines", strconv.Itoa(lines),
+			"--no-pager",
+			"--output", "short-iso",
+		)
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("run journalctl: %w", err)
+		}
+
+		return output, nil
+	}
+	bundleNow = time.Now
+)
+
+type bundleConfig struct {
+	url      string
+	config   string
+	logFile  string
+	unit     string
+	logLines int
+	out      string
+	timeout  time.Duration
+	snapshot bool
+}
+
+// bundleEntry is a file added to the bundle.
+type bundleEntry struct {
+	name string
+	data []byte
+}
+
+// bundle accumulates entries and the items that could not be collected, so a
+// partially reachable daemon still yields a useful archive.
+type bundle struct {
+	entries []bundleEntry
+	skipped []string
+}
+
+func (b *bundle) add(name string, data []byte) {
+	b.entries = append(b.entries, bundleEntry{name: name, data: data})
+}
+
+func (b *bundle) skip(item string, err error) {
+	b.skipped = append(b.skipped, fmt.Sprintf("%s: %v", item, err))
+}
+
+func parseBundleConfig(args []string) (bundleConfig, error) {
+	var cfg bundleConfig
+
+	flags := clitools.NewFlagSet("shaperctl support-bundle")
+	flags.StringVar(&cfg.url, "url", defaultBundleURL, "Base URL of the shaper metrics listener")
+	flags.StringVar(&cfg.config, "config", defaultBundleConfig, "Shaper configuration file")
+	flags.StringVar(
+		&cfg.logFile,
+		"log-file",
+		"",
+		"Log file to tail; defaults to the journal of -unit",
+	)
+	flags.StringVar(&cfg.unit, "unit", defaultBundleUnit, "systemd unit whose journal is tailed")
+	flags.IntVar(&cfg.logLines, "log-lines", defaultBundleLines, "Number of log lines to include")
+	flags.StringVar(
+		&cfg.out,
+		"out",
+		"",
+		"Archive path; defaults to shaper-support-<timestamp>.tar.gz in the working directory",
+	)
+	flags.DurationVar(&cfg.timeout, "timeout", defaultBundleTimeout, "Timeout for each request")
+	flags.BoolVar(
+		&cfg.snapshot,
+		"snapshot",
+		true,
+		"Request /admin/snapshot first so the daemon flushes its state to disk",
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return bundleConfig{}, fmt.Errorf("parse flags: %w", err)
+	}
+
+	if cfg.logLines < 0 {
+		return bundleConfig{}, errNegativeLogLines
+	}
+
+	if cfg.timeout <= 0 {
+		return bundleConfig{}, clitools.ErrTimeoutInvalid
+	}
+
+	cfg.url = strings.TrimRight(cfg.url, "/")
+
+	return cfg, nil
+}
+
+// runSupportBundle gathers the configuration, a log tail, the listener's
+// metrics, health, history, and controller documents, and an on-demand state
+// snapshot into one gzipped tarball. Items that cannot be collected are listed
+// in skipped.txt instead of failing the command.
+func runSupportBundle(args []string, out io.Writer) error {
+	cfg, err := parseBundleConfig(args)
+	if err != nil {
+		return err
+	}
+
+	now := bundleNow().UTC()
+	prefix := "shaper-support-" + now.Format("20060102T150405Z")
+
+	path := cfg.out
+	if path == "" {
+		path = prefix + ".tar.gz"
+	}
+
+	client := &http.Client{Timeout: cfg.timeout} //nolint:exhaustruct
+
+	var collected bundle
+
+	if cfg.snapshot {
+		collectSnapshot(client, cfg.url, &collected)
+	}
+
+	for _, endpoint := range bundleEndpoints {
+		data, err := fetch(client, http.MethodGet, cfg.url+endpoint.path)
+		if err != nil {
+			collected.skip(endpoint.path, err)
+
+			continue
+		}
+
+		collected.add(endpoint.name, data)
+	}
+
+	collectConfig(cfg.config, &collected)
+	collectLogs(cfg, &collected)
+
+	if len(collected.skipped) > 0 {
+		collected.add("skipped.txt", []byte(strings.Join(collected.skipped, "\n")+"\n"))
+	}
+
+	err = writeBundle(path, prefix, now, collected.entries)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		out,
+		"wrote support bundle %s (%d files, %d items skipped)\n",
+		path,
+		len(collected.entries),
+		len(collected.skipped),
+	)
+	if err != nil {
+		return fmt.Errorf("print result: %w", err)
+	}
+
+	return nil
+}
+
+func collectSnapshot(client *http.Client, baseURL string, collected *bundle) {
+	data, err := fetch(client, http.MethodPost, baseURL+adminhttp.Prefix+"snapshot")
+	if err != nil {
+		collected.skip(adminhttp.Prefix+"snapshot", err)
+
+		return
+	}
+
+	collected.add("snapshot-response.json", data)
+
+	var response adminhttp.SnapshotResponse
+
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		collected.skip("snapshot file", fmt.Errorf("decode snapshot response: %w", err))
+
+		return
+	}
+
+	// The snapshot is written by the daemon, so it is only readable here when
+	// shaperctl runs on the same host.
+	snapshot, err := os.ReadFile(response.Path)
+	if err != nil {
+		collected.skip("snapshot file", err)
+
+		return
+	}
+
+	collected.add(filepath.Base(response.Path), snapshot)
+}
+
+func collectConfig(path string, collected *bundle) {
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		collected.skip("config", err)
+
+		return
+	}
+
+	collected.add("config.yaml", data)
+}
+
+func collectLogs(cfg bundleConfig, collected *bundle) {
+	if cfg.logLines == 0 {
+		return
+	}
+
+	if cfg.logFile != "" {
+		data, err := tailFile(cfg.logFile, cfg.logLines)
+		if err != nil {
+			collected.skip("log file", err)
+
+			return
+		}
+
+		collected.add("shaper.log", data)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	data, err := journalTail(ctx, cfg.unit, cfg.logLines)
+	if err != nil {
+		collected.skip("journal", err)
+
+		return
+	}
+
+	collected.add("journal.log", data)
+}
+
+func fetch(client *http.Client, method, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(context.Background(), method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, url, err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", url, err)
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("%w %s", errUnexpectedStatus, response.Status)
+	}
+
+	return data, nil
+}
+
+// tailFile returns the last lines of path, reading at most maxLogTailBytes
+// from its end.
+func tailFile(path string, lines int) ([]byte, error) {
+	file, err := os.Open(path) //nolint:gosec // operator-supplied log path
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat log file: %w", err)
+	}
+
+	offset := max(info.Size()-maxLogTailBytes, 0)
+
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("read log file: %w", err)
+	}
+
+	trimmed := bytes.TrimRight(data, "\n")
+	for index := len(trimmed) - 1; index >= 0; index-- {
+		if trimmed[index] != '\n' {
+			continue
+		}
+
+		lines--
+		if lines == 0 {
+			return data[index+1:], nil
+		}
+	}
+
+	return data, nil
+}
+
+func writeBundle(path, prefix string, modTime time.Time, entries []bundleEntry) error {
+	var buffer bytes.Buffer
+
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+
+	for _, entry := range entries {
+		err := archive.WriteHeader(&tar.Header{ //nolint:exhaustruct
+			Typeflag: tar.TypeReg,
+			Name:     prefix + "/" + entry.name,
+			Mode:     bundleFileMode,
+			Size:     int64(len(entry.data)),
+			ModTime:  modTime,
+		})
+		if err != nil {
+			return fmt.Errorf("write %s header: %w", entry.name, err)
+		}
+
+		_, err = archive.Write(entry.data)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", entry.name, err)
+		}
+	}
+
+	err := archive.Close()
+	if err != nil {
+		return fmt.Errorf("close tar archive: %w", err)
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return fmt.Errorf("close gzip stream: %w", err)
+	}
+
+	err = os.WriteFile(path, buffer.Bytes(), bundleFileMode)
+	if err != nil {
+		return fmt.Errorf("write support bundle: %w", err)
+	}
+
+	return nil
+}
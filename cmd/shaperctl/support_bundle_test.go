@@ -0,0 +1,234 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	adminhttp "oci-cpu-shaper/pkg/http/admin"
+)
+
+var errNoJournal = errors.New("stub: journalctl not available")
+
+func newBundleServer(t *testing.T, snapshotPath string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(writer, "shaper_target_ratio 0.2500\n")
+	})
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(writer, `{"state":"normal"}`)
+	})
+	mux.HandleFunc("/admin/snapshot", func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		_ = json.NewEncoder(writer).Encode(adminhttp.SnapshotResponse{
+			Timestamp: time.Now(),
+			Path:      snapshotPath,
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("open gzip: %v", err)
+	}
+
+	archive := tar.NewReader(gz)
+	files := make(map[string]string)
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			t.Fatalf("read %s: %v", header.Name, err)
+		}
+
+		files[header.Name] = string(content)
+	}
+}
+
+//nolint:paralleltest // replaces the journal and clock seams
+func TestSupportBundleCollectsListenerStateAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "snapshot-20240501T120000.000Z.json")
+	configPath := filepath.Join(dir, "config.yaml")
+	logPath := filepath.Join(dir, "shaper.log")
+	out := filepath.Join(dir, "bundle.tar.gz")
+
+	for path, content := range map[string]string{
+		snapshotPath: `{"metrics":""}`,
+		configPath:   "controller:\n  targetStart: 0.25\n",
+		logPath:      "one\ntwo\nthree\n",
+	} {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	previousNow := bundleNow
+	bundleNow = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	t.Cleanup(func() { bundleNow = previousNow })
+
+	server := newBundleServer(t, snapshotPath)
+
+	var stdout bytes.Buffer
+
+	err := dispatch([]string{
+		"support-bundle",
+		"-url", server.URL + "/",
+		"-config", configPath,
+		"-log-file", logPath,
+		"-log-lines", "2",
+		"-out", out,
+	}, &stdout)
+	if err != nil {
+		t.Fatalf("support-bundle: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "wrote support bundle "+out) {
+		t.Fatalf("expected summary line, got %q", stdout.String())
+	}
+
+	files := readBundle(t, out)
+	prefix := "shaper-support-20240501T120000Z/"
+
+	for name, want := range map[string]string{
+		"metrics.txt":                        "shaper_target_ratio 0.2500\n",
+		"healthz.json":                       `{"state":"normal"}`,
+		"config.yaml":                        "controller:\n  targetStart: 0.25\n",
+		"shaper.log":                         "two\nthree\n",
+		"snapshot-20240501T120000.000Z.json": `{"metrics":""}`,
+	} {
+		if got, ok := files[prefix+name]; !ok || got != want {
+			t.Fatalf("expected %s to hold %q, got %q (present %v)", name, want, got, ok)
+		}
+	}
+
+	skipped := files[prefix+"skipped.txt"]
+	if !strings.Contains(skipped, "/admin/history: unexpected status 404") ||
+		!strings.Contains(skipped, "/debug/controller: unexpected status 404") {
+		t.Fatalf("expected unavailable endpoints to be listed as skipped, got %q", skipped)
+	}
+}
+
+//nolint:paralleltest // replaces the journal seam
+func TestSupportBundleFallsBackToJournal(t *testing.T) {
+	previousJournal := journalTail
+	journalTail = func(_ context.Context, unit string, lines int) ([]byte, error) {
+		if unit != "custom.service" || lines != defaultBundleLines {
+			return nil, errNoJournal
+		}
+
+		return []byte("journal line\n"), nil
+	}
+
+	t.Cleanup(func() { journalTail = previousJournal })
+
+	out := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	server := newBundleServer(t, "")
+
+	err := dispatch([]string{
+		"support-bundle",
+		"-url", server.URL,
+		"-config", "",
+		"-unit", "custom.service",
+		"-snapshot=false",
+		"-out", out,
+	}, io.Discard)
+	if err != nil {
+		t.Fatalf("support-bundle: %v", err)
+	}
+
+	files := readBundle(t, out)
+
+	var journal string
+
+	for name, content := range files {
+		if strings.HasSuffix(name, "/journal.log") {
+			journal = content
+		}
+
+		if strings.Contains(name, "snapshot") {
+			t.Fatalf("expected no snapshot with -snapshot=false, got %s", name)
+		}
+	}
+
+	if journal != "journal line\n" {
+		t.Fatalf("expected the journal tail, got %q", journal)
+	}
+}
+
+func TestParseBundleConfigValidatesFlags(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseBundleConfig([]string{"-log-lines", "-1"})
+	if !errors.Is(err, errNegativeLogLines) {
+		t.Fatalf("expected negative log lines to be rejected, got %v", err)
+	}
+
+	_, err = parseBundleConfig([]string{"-timeout", "0s"})
+	if err == nil {
+		t.Fatal("expected a zero timeout to be rejected")
+	}
+}
+
+func TestTailFileReturnsWholeShortFiles(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "short.log")
+
+	err := os.WriteFile(path, []byte("only\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	data, err := tailFile(path, 10)
+	if err != nil || string(data) != "only\n" {
+		t.Fatalf("expected the whole file, got %q (%v)", data, err)
+	}
+
+	_, err = tailFile(filepath.Join(t.TempDir(), "missing.log"), 10)
+	if err == nil {
+		t.Fatal("expected a missing log file to fail")
+	}
+}
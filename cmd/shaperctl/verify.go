@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/internal/clitools"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/verify"
+)
+
+// verifyPoll is how often verify checks again while -wait has time left.
+const verifyPoll = time.Second
+
+var errNothingToVerify = errors.New("at least one of -expect or -state is required")
+
+//nolint:gochecknoglobals // test seams for the clock
+var (
+	verifyNow   = time.Now
+	verifySleep = time.Sleep
+)
+
+type verifyConfig struct {
+	url       string
+	namespace string
+	logFile   string
+	unit      string
+	logLines  int
+	expect    []verify.Transition
+	state     string
+	wait      time.Duration
+	timeout   time.Duration
+}
+
+func parseVerifyConfig(args []string) (verifyConfig, error) {
+	var cfg verifyConfig
+
+	flags := clitools.NewFlagSet("shaperctl verify")
+	flags.StringVar(&cfg.url, "url", defaultBundleURL, "Base URL of the shaper metrics listener")
+	flags.StringVar(
+		&cfg.namespace,
+		"namespace",
+		metricshttp.DefaultNamespace,
+		"Metric namespace the shaper exports (http.metricsNamespace)",
+	)
+	flags.StringVar(
+		&cfg.logFile,
+		"log-file",
+		"",
+		"Log file to read; defaults to the journal of -unit",
+	)
+	flags.StringVar(&cfg.unit, "unit", defaultBundleUnit, "systemd unit whose journal is read")
+	flags.IntVar(&cfg.logLines, "log-lines", defaultBundleLines, "Number of log lines to search")
+	flags.Func("expect", "Controller transition that must be logged, as from→to; repeatable",
+		func(value string) error {
+			transition, err := verify.ParseTransition(value)
+			if err != nil {
+				return err //nolint:wrapcheck // the flag package names the flag
+			}
+
+			cfg.expect = append(cfg.expect, transition)
+
+			return nil
+		})
+	flags.StringVar(&cfg.state, "state", "", "Controller state the metrics must export")
+	flags.DurationVar(&cfg.wait, "wait", 0, "Keep checking for this long before failing")
+	flags.DurationVar(&cfg.timeout, "timeout", defaultBundleTimeout, "Timeout for each request")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return verifyConfig{}, fmt.Errorf("parse flags: %w", err)
+	}
+
+	if len(cfg.expect) == 0 && cfg.state == "" {
+		return verifyConfig{}, errNothingToVerify
+	}
+
+	if cfg.logLines < 0 {
+		return verifyConfig{}, errNegativeLogLines
+	}
+
+	if cfg.timeout <= 0 {
+		return verifyConfig{}, clitools.ErrTimeoutInvalid
+	}
+
+	cfg.url = strings.TrimRight(cfg.url, "/")
+
+	return cfg, nil
+}
+
+// runVerify checks a running shaper: every -expect transition must appear in
+// its log and its metrics must export the -state controller state. With -wait
+// the checks repeat until they pass or the time runs out, so a pipeline can
+// run it right after installing the shaper.
+func runVerify(args []string, out io.Writer) error {
+	cfg, err := parseVerifyConfig(args)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: cfg.timeout} //nolint:exhaustruct
+	deadline := verifyNow().Add(cfg.wait)
+
+	for {
+		err = checkShaper(cfg, client)
+		if err == nil || !verifyNow().Before(deadline) {
+			break
+		}
+
+		verifySleep(verifyPoll)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(out, verifySummary(cfg))
+	if err != nil {
+		return fmt.Errorf("print result: %w", err)
+	}
+
+	return nil
+}
+
+func checkShaper(cfg verifyConfig, client *http.Client) error {
+	if len(cfg.expect) > 0 {
+		logs, err := readVerifyLog(cfg)
+		if err != nil {
+			return err
+		}
+
+		transitions, err := verify.Transitions(bytes.NewReader(logs))
+		if err != nil {
+			return fmt.Errorf("parse shaper log: %w", err)
+		}
+
+		err = verify.RequireTransitions(transitions, cfg.expect...)
+		if err != nil {
+			return err //nolint:wrapcheck // verify errors name the missing transitions
+		}
+	}
+
+	if cfg.state == "" {
+		return nil
+	}
+
+	metrics, err := fetch(client, http.MethodGet, cfg.url+"/metrics")
+	if err != nil {
+		return fmt.Errorf("fetch metrics: %w", err)
+	}
+
+	//nolint:wrapcheck // verify errors name the expected and exported states
+	return verify.RequireState(metrics, cfg.namespace, cfg.state)
+}
+
+func readVerifyLog(cfg verifyConfig) ([]byte, error) {
+	if cfg.logFile != "" {
+		return tailFile(cfg.logFile, cfg.logLines)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	logs, err := journalTail(ctx, cfg.unit, cfg.logLines)
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+
+	return logs, nil
+}
+
+func verifySummary(cfg verifyConfig) string {
+	var checks []string
+
+	if len(cfg.expect) > 0 {
+		names := make([]string, 0, len(cfg.expect))
+		for _, transition := range cfg.expect {
+			names = append(names, transition.String())
+		}
+
+		checks = append(checks, "logged "+strings.Join(names, ", "))
+	}
+
+	if cfg.state != "" {
+		checks = append(checks, "state "+cfg.state)
+	}
+
+	return "verified: " + strings.Join(checks, "; ") + "\n"
+}
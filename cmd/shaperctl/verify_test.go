@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/verify"
+)
+
+const (
+	fallbackLine = `{"message":"controller state transition","from":"","to":"fallback"}` + "\n"
+	normalLine   = `{"message":"controller state transition",` +
+		`"from":"fallback","to":"normal"}` + "\n"
+)
+
+func newStateServer(t *testing.T, state string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/metrics" {
+				http.NotFound(writer, request)
+
+				return
+			}
+
+			_, _ = io.WriteString(writer, "shaper_state{state=\""+state+"\"} 1\n")
+		},
+	))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func writeLog(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "shaper.log")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+func TestVerifyChecksTransitionsAndState(t *testing.T) {
+	t.Parallel()
+
+	server := newStateServer(t, "normal")
+	log := writeLog(t, fallbackLine+"{\"message\":\"other\"}\n"+normalLine)
+
+	var out bytes.Buffer
+
+	err := dispatch([]string{
+		"verify", "-url", server.URL + "/", "-log-file", log,
+		"--expect", "→fallback", "--expect", "fallback->normal", "-state", "normal",
+	}, &out)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	want := "verified: logged →fallback, fallback→normal; state normal\n"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+
+	err = runVerify([]string{"-url", server.URL, "-state", "normal"}, &out)
+	if err != nil || out.String() != "verified: state normal\n" {
+		t.Fatalf("expected a state-only check to pass, got %q, %v", out.String(), err)
+	}
+
+	err = runVerify([]string{"-url", server.URL, "-state", "suppressed"}, io.Discard)
+	if !errors.Is(err, verify.ErrStateMismatch) {
+		t.Fatalf("expected ErrStateMismatch, got %v", err)
+	}
+
+	err = runVerify([]string{"-log-file", log, "-expect", "normal→suppressed"}, io.Discard)
+	if !errors.Is(err, verify.ErrTransitionMissing) {
+		t.Fatalf("expected ErrTransitionMissing, got %v", err)
+	}
+}
+
+//nolint:paralleltest // replaces the journal and clock seams
+func TestVerifyWaitsForTheJournal(t *testing.T) {
+	previousJournal, previousNow, previousSleep := journalTail, verifyNow, verifySleep
+
+	t.Cleanup(func() {
+		journalTail, verifyNow, verifySleep = previousJournal, previousNow, previousSleep
+	})
+
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	journal := "2026-03-01T12:00:00+0000 host shaper[1]: " + fallbackLine
+
+	journalTail = func(_ context.Context, unit string, lines int) ([]byte, error) {
+		if unit != "custom.service" || lines != 50 {
+			return nil, errNoJournal
+		}
+
+		return []byte(journal), nil
+	}
+	verifyNow = func() time.Time { return now }
+	verifySleep = func(delay time.Duration) {
+		now = now.Add(delay)
+		journal += normalLine
+	}
+
+	var out bytes.Buffer
+
+	err := runVerify([]string{
+		"-unit", "custom.service", "-log-lines", "50", "-wait", "5s",
+		"-expect", "fallback→normal",
+	}, &out)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if !now.Equal(time.Date(2026, time.March, 1, 12, 0, 1, 0, time.UTC)) {
+		t.Fatalf("expected one poll before the transition appeared, clock at %s", now)
+	}
+
+	err = runVerify([]string{"-wait", "2s", "-expect", "normal→suppressed"}, io.Discard)
+	if !errors.Is(err, errNoJournal) || !strings.Contains(err.Error(), "read journal") {
+		t.Fatalf("expected the journal error after waiting, got %v", err)
+	}
+}
+
+func TestVerifyRejectsBadInput(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "missing.log")
+	refused := "http://127.0.0.1:1"
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"nothing to check": {args: nil, want: errNothingToVerify.Error()},
+		"bad expectation":  {args: []string{"-expect", "normal"}, want: "from→to"},
+		"negative lines":   {args: []string{"-state", "up", "-log-lines", "-1"}, want: "negative"},
+		"zero timeout":     {args: []string{"-state", "up", "-timeout", "0s"}, want: "timeout"},
+		"missing log file": {args: []string{"-log-file", missing, "-expect", "→up"}, want: "open"},
+		"metrics refused":  {args: []string{"-url", refused, "-state", "up"}, want: "fetch"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := runVerify(test.args, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("expected an error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+}
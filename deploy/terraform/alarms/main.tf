@@ -13,10 +13,31 @@ provider "oci" {
   region = var.region
 }
 
+# Looked up only when instance_display_name is not set, so callers without
+# read access to the instance can still apply the module.
+data "oci_core_instance" "guarded" {
+  count       = var.instance_display_name == null ? 1 : 0
+  instance_id = var.instance_ocid
+}
+
 locals {
   alarm_display_name = coalesce(var.display_name, "oci-cpu-shaper-p95-guard")
   metric_compartment = coalesce(var.metric_compartment_ocid, var.compartment_ocid)
 
+  instance_name = coalesce(
+    var.instance_display_name,
+    try(data.oci_core_instance.guarded[0].display_name, null),
+    var.instance_ocid,
+  )
+
+  alarm_body = replace(
+    replace(var.alarm_body, "{instance_name}", local.instance_name),
+    "{instance_ocid}",
+    var.instance_ocid,
+  )
+
+  # Must stay equal, up to whitespace, to oci.GuardrailQuery; a unit test in
+  # pkg/oci compares them.
   alarm_query = "CpuUtilization[1m]{resourceId=\"${var.instance_ocid}\"}.window(7d).percentile(0.95) < 20"
 
   default_freeform_tags = {
@@ -37,7 +58,9 @@ resource "oci_monitoring_alarm" "p95_guardrail" {
   freeform_tags = merge(local.default_freeform_tags, var.freeform_tags)
   defined_tags  = var.defined_tags
 
-  body             = var.alarm_body
-  pending_duration = var.pending_duration
-  resolution       = var.resolution
+  body                         = local.alarm_body
+  message_format               = var.message_format
+  repeat_notification_duration = var.repeat_notification_duration
+  pending_duration             = var.pending_duration
+  resolution                   = var.resolution
 }
@@ -19,6 +19,12 @@ variable "instance_ocid" {
   type        = string
 }
 
+variable "instance_display_name" {
+  description = "Optional instance name substituted for {instance_name} in alarm_body. Defaults to the instance's display name."
+  type        = string
+  default     = null
+}
+
 variable "display_name" {
   description = "Optional display name for the alarm."
   type        = string
@@ -39,6 +45,11 @@ variable "severity" {
   description = "Alarm severity reported when the guardrail fires."
   type        = string
   default     = "CRITICAL"
+
+  validation {
+    condition     = contains(["CRITICAL", "ERROR", "WARNING", "INFO"], var.severity)
+    error_message = "severity must be one of CRITICAL, ERROR, WARNING, or INFO."
+  }
 }
 
 variable "is_enabled" {
@@ -48,9 +59,33 @@ variable "is_enabled" {
 }
 
 variable "alarm_body" {
-  description = "Body text included in alarm notifications."
+  description = "Body text included in alarm notifications. {instance_name} and {instance_ocid} are replaced with the guarded instance's name and OCID."
+  type        = string
+  default     = "Always Free reclaim guardrail breached on {instance_name}. Investigate CpuUtilization and raise the controller duty cycle if required."
+}
+
+variable "message_format" {
+  description = "Format of the notification message: RAW, PRETTY_JSON, or ONS_OPTIMIZED."
   type        = string
-  default     = "Always Free reclaim guardrail breached. Investigate CpuUtilization and raise the controller duty cycle if required."
+  default     = "RAW"
+
+  validation {
+    condition     = contains(["RAW", "PRETTY_JSON", "ONS_OPTIMIZED"], var.message_format)
+    error_message = "message_format must be one of RAW, PRETTY_JSON, or ONS_OPTIMIZED."
+  }
+}
+
+variable "repeat_notification_duration" {
+  description = "Optional ISO-8601 duration after which notifications repeat while the alarm keeps firing, for example PT4H. Unset sends one notification per firing."
+  type        = string
+  default     = null
+
+  validation {
+    condition = var.repeat_notification_duration == null || can(
+      regex("^P(T[0-9]+[HM]|[0-9]+D)$", var.repeat_notification_duration)
+    )
+    error_message = "repeat_notification_duration must be an ISO-8601 duration in minutes, hours, or days, such as PT30M, PT4H, or P1D."
+  }
 }
 
 variable "pending_duration" {
@@ -5,6 +5,7 @@ go 1.24.10
 require (
 	github.com/oracle/oci-go-sdk/v65 v65.104.1
 	go.uber.org/zap v1.27.0
+	golang.org/x/sys v0.38.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -14,7 +15,6 @@ require (
 	github.com/stretchr/objx v0.5.3 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/net v0.46.0 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
-	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/net v0.46.0 // indirect
 )
@@ -5,13 +5,16 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/oracle/oci-go-sdk/v65/common"
-	"github.com/oracle/oci-go-sdk/v65/common/auth"
 	"github.com/oracle/oci-go-sdk/v65/monitoring"
+
+	"oci-cpu-shaper/internal/clitools"
+	"oci-cpu-shaper/pkg/oci"
 )
 
 const (
@@ -28,24 +31,27 @@ const (
 var (
 	errCompartmentRequired = errors.New("compartment OCID is required")
 	errInstanceRequired    = errors.New("instance OCID is required")
-	errRegionRequired      = errors.New("region is required")
-	errTimeoutInvalid      = errors.New("timeout must be greater than zero")
 	errGuardrailMissing    = errors.New(
 		"no Always Free P95 alarm matched the expected configuration",
 	)
 )
 
 type config struct {
-	CompartmentID       string
+	clitools.Options
+
 	MetricCompartmentID string
-	InstanceID          string
-	Region              string
 	RequireDestinations bool
-	Timeout             time.Duration
 	ExpectedPending     string
 	ExpectedResolution  string
 }
 
+// guardResult is the -output=json document.
+type guardResult struct {
+	Compartment      string `json:"compartment"`
+	Instance         string `json:"instance"`
+	GuardrailPresent bool   `json:"guardrailPresent"`
+}
+
 func main() {
 	if code := run(os.Args[1:]); code != exitOK {
 		os.Exit(code)
@@ -60,38 +66,97 @@ func run(args []string) int {
 		return exitUsage
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
-	defer cancel()
+	if cfg.Inventory != "" {
+		return runFleet(cfg, os.Stdout)
+	}
 
-	provider, err := auth.InstancePrincipalConfigurationProvider()
+	guardPresent, err := checkInstance(context.Background(), cfg)
 	if err != nil {
-		fmt.Fprintf(
-			os.Stderr,
-			"alarmguard: failed to initialise instance principal provider: %v\n",
-			err,
-		)
+		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", err)
 
 		return exitError
 	}
 
-	client, err := monitoring.NewMonitoringClientWithConfigurationProvider(provider)
+	err = clitools.Print(
+		os.Stdout,
+		cfg.Output,
+		guardText(cfg.InstanceID, guardPresent),
+		guardResult{
+			Compartment:      cfg.CompartmentID,
+			Instance:         cfg.InstanceID,
+			GuardrailPresent: guardPresent,
+		},
+	)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "alarmguard: failed to create monitoring client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", err)
 
 		return exitError
 	}
 
-	client.SetRegion(cfg.Region)
+	if !guardPresent {
+		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", errGuardrailMissing)
+
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runFleet checks the guardrail of every instance in the -inventory file and
+// prints one report keyed by instance name. It fails unless every instance has
+// its guardrail.
+func runFleet(cfg config, out io.Writer) int {
+	instances, err := clitools.LoadInventory(cfg.Inventory, cfg.Options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", err)
+
+		return exitUsage
+	}
+
+	report := clitools.RunFleet(
+		context.Background(),
+		instances,
+		cfg.Concurrency,
+		func(ctx context.Context, instance clitools.Instance) clitools.FleetResult {
+			scoped := cfg
+			scoped.Options = cfg.Options.For(instance)
+
+			if scoped.CompartmentID == "" {
+				return clitools.FleetResult{Text: "", Value: nil, Err: errCompartmentRequired}
+			}
+
+			present, err := checkInstance(ctx, scoped)
+			if err != nil {
+				return clitools.FleetResult{Text: "", Value: nil, Err: err}
+			}
+
+			result := clitools.FleetResult{
+				Text: guardText(scoped.InstanceID, present),
+				Value: guardResult{
+					Compartment:      scoped.CompartmentID,
+					Instance:         scoped.InstanceID,
+					GuardrailPresent: present,
+				},
+				Err: nil,
+			}
+			if !present {
+				result.Err = errGuardrailMissing
+			}
 
-	guardPresent, err := findGuardrail(ctx, client, cfg)
+			return result
+		},
+	)
+
+	err = report.Print(out, cfg.Output)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", err)
 
 		return exitError
 	}
 
-	if !guardPresent {
-		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", errGuardrailMissing)
+	err = report.Err()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "alarmguard: %v\n", err)
 
 		return exitError
 	}
@@ -99,17 +164,60 @@ func run(args []string) int {
 	return exitOK
 }
 
+// checkInstance reports whether the guardrail alarm of the instance cfg
+// selects exists, within -timeout.
+func checkInstance(ctx context.Context, cfg config) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := newMonitoringClient(cfg.Options)
+	if err != nil {
+		return false, err
+	}
+
+	return findGuardrail(ctx, client, cfg)
+}
+
+//nolint:gochecknoglobals // test seam for injecting fake clients
+var newMonitoringClient = func(opts clitools.Options) (monitoringClient, error) {
+	provider, err := opts.Provider()
+	if err != nil {
+		return nil, fmt.Errorf("select auth provider: %w", err)
+	}
+
+	region, err := opts.ResolveRegion(provider)
+	if err != nil {
+		return nil, fmt.Errorf("resolve region: %w", err)
+	}
+
+	client, err := monitoring.NewMonitoringClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create monitoring client: %w", err)
+	}
+
+	client.SetRegion(region)
+
+	return client, nil
+}
+
+func guardText(instanceID string, present bool) string {
+	if present {
+		return "guardrail alarm present for " + instanceID
+	}
+
+	return "guardrail alarm missing for " + instanceID
+}
+
 func parseConfig(args []string) (config, error) {
 	cfg := config{ //nolint:exhaustruct
 		RequireDestinations: true,
-		Timeout:             defaultTimeout,
 		ExpectedPending:     defaultPendingDuration,
 		ExpectedResolution:  defaultResolution,
 	}
 
 	var metricCompartment string
 
-	flagSet := flag.NewFlagSet("alarmguard", flag.ContinueOnError)
+	flagSet := clitools.NewFlagSet("alarmguard")
 	registerFlags(flagSet, &cfg, &metricCompartment)
 
 	err := flagSet.Parse(args)
@@ -131,16 +239,15 @@ func parseConfig(args []string) (config, error) {
 
 func (c config) validate() error {
 	switch {
+	case c.Inventory != "":
+		// Each inventory instance is checked for its compartment instead.
+		return c.Options.Validate() //nolint:wrapcheck // shared flag errors are self-describing
 	case c.CompartmentID == "":
 		return errCompartmentRequired
 	case c.InstanceID == "":
 		return errInstanceRequired
-	case c.Region == "":
-		return errRegionRequired
-	case c.Timeout <= 0:
-		return errTimeoutInvalid
 	default:
-		return nil
+		return c.Options.Validate() //nolint:wrapcheck // shared flag errors are self-describing
 	}
 }
 
@@ -276,31 +383,10 @@ func resolutionMatches(actual *string, expected string) bool {
 	return strings.EqualFold(*actual, expected)
 }
 
+// queryMatches reports whether query is the Always Free guardrail condition
+// for instanceID; see oci.GuardrailQuery.
 func queryMatches(query, instanceID string) bool {
-	if query == "" {
-		return false
-	}
-
-	normalized := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(query, " ", ""), "\n", ""))
-	expectedResource := fmt.Sprintf("resourceid=\"%s\"", strings.ToLower(instanceID))
-
-	if !strings.Contains(normalized, "cpuutilization[1m]{") {
-		return false
-	}
-
-	if !strings.Contains(normalized, expectedResource) {
-		return false
-	}
-
-	if !strings.Contains(normalized, ".window(7d).") {
-		return false
-	}
-
-	if !strings.Contains(normalized, ".percentile(0.95)") {
-		return false
-	}
-
-	return strings.Contains(normalized, "<20")
+	return oci.GuardrailQuery(instanceID).Matches(query)
 }
 
 func stringValue(ptr *string) string {
@@ -323,37 +409,13 @@ type monitoringClient interface {
 }
 
 func registerFlags(flagSet *flag.FlagSet, cfg *config, metricCompartment *string) {
-	flagSet.SetOutput(os.Stderr)
-	flagSet.StringVar(
-		&cfg.CompartmentID,
-		"compartment",
-		"",
-		"Compartment OCID that should contain the guardrail alarm.",
-	)
+	cfg.Register(flagSet, defaultTimeout)
 	flagSet.StringVar(
 		metricCompartment,
 		"metric-compartment",
 		"",
 		"Optional compartment OCID for the alarm's metric scope (defaults to skipping the check).",
 	)
-	flagSet.StringVar(
-		&cfg.InstanceID,
-		"instance",
-		"",
-		"Instance OCID protected by the Always Free guardrail.",
-	)
-	flagSet.StringVar(
-		&cfg.Region,
-		"region",
-		"",
-		"OCI region identifier (for example, us-phoenix-1).",
-	)
-	flagSet.DurationVar(
-		&cfg.Timeout,
-		"timeout",
-		defaultTimeout,
-		"Overall timeout for the alarm verification call.",
-	)
 	flagSet.BoolVar(
 		&cfg.RequireDestinations,
 		"require-destinations",
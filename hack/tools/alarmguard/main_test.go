@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/monitoring"
+
+	"oci-cpu-shaper/internal/clitools"
 )
 
 const guardrailQuery = "CpuUtilization[1m]{resourceId=\"ocid1.instance.oc1..guard\"}.window(7d).percentile(0.95) < 20"
@@ -139,8 +144,8 @@ func TestParseConfigValidationErrors(t *testing.T) {
 			"-region", "us-ashburn-1",
 			"-timeout", "0s",
 		})
-		if !errors.Is(err, errTimeoutInvalid) {
-			t.Fatalf("expected errTimeoutInvalid, got %v", err)
+		if !errors.Is(err, clitools.ErrTimeoutInvalid) {
+			t.Fatalf("expected ErrTimeoutInvalid, got %v", err)
 		}
 	})
 }
@@ -166,7 +171,9 @@ func TestSummaryAndDetailMatches(t *testing.T) {
 	}
 
 	cfg := config{ //nolint:exhaustruct
-		InstanceID:          "ocid1.instance.oc1..guard",
+		Options: clitools.Options{ //nolint:exhaustruct
+			InstanceID: "ocid1.instance.oc1..guard",
+		},
 		MetricCompartmentID: "ocid1.compartment.oc1..metrics",
 		RequireDestinations: true,
 		ExpectedPending:     "PT1H",
@@ -272,8 +279,10 @@ func guardrailFixtures() (monitoring.AlarmSummary, monitoring.Alarm, config) {
 	}
 
 	cfg := config{ //nolint:exhaustruct
-		CompartmentID:       "ocid1.compartment.oc1..root",
-		InstanceID:          "ocid1.instance.oc1..guard",
+		Options: clitools.Options{ //nolint:exhaustruct
+			CompartmentID: "ocid1.compartment.oc1..root",
+			InstanceID:    "ocid1.instance.oc1..guard",
+		},
 		MetricCompartmentID: "ocid1.compartment.oc1..metrics",
 		RequireDestinations: true,
 		ExpectedPending:     "PT1H",
@@ -282,3 +291,83 @@ func guardrailFixtures() (monitoring.AlarmSummary, monitoring.Alarm, config) {
 
 	return summary, detail, cfg
 }
+
+//nolint:paralleltest // replaces the monitoring client seam
+func TestRunFleetReportsEveryInstance(t *testing.T) {
+	summary, detail, cfg := guardrailFixtures()
+
+	previous := newMonitoringClient
+
+	t.Cleanup(func() { newMonitoringClient = previous })
+
+	var regions []string
+
+	newMonitoringClient = func(opts clitools.Options) (monitoringClient, error) {
+		regions = append(regions, opts.Region)
+
+		return fakeClient{
+			listFn: func(
+				context.Context,
+				monitoring.ListAlarmsRequest,
+			) (monitoring.ListAlarmsResponse, error) {
+				//nolint:exhaustruct
+				return monitoring.ListAlarmsResponse{Items: []monitoring.AlarmSummary{summary}}, nil
+			},
+			getFn: func(
+				context.Context,
+				monitoring.GetAlarmRequest,
+			) (monitoring.GetAlarmResponse, error) {
+				return monitoring.GetAlarmResponse{Alarm: detail}, nil //nolint:exhaustruct
+			},
+		}, nil
+	}
+
+	inventory := filepath.Join(t.TempDir(), "fleet.yaml")
+
+	err := os.WriteFile(inventory, []byte(`instances:
+  - name: guarded
+    ocid: ocid1.instance.oc1..guard
+    region: us-phoenix-1
+  - name: bare
+    ocid: ocid1.instance.oc1..bare
+    region: us-phoenix-1
+`), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg.Inventory = inventory
+	cfg.InstanceID = ""
+	cfg.Timeout = time.Second
+	cfg.Concurrency = 1
+	cfg.Output = clitools.OutputText
+
+	var out bytes.Buffer
+
+	if code := runFleet(cfg, &out); code != exitError {
+		t.Fatalf("expected exit %d with one guardrail missing, got %d", exitError, code)
+	}
+
+	want := "bare: error: " + errGuardrailMissing.Error() + "\n" +
+		"guarded: guardrail alarm present for ocid1.instance.oc1..guard\n"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+
+	if len(regions) != 2 || regions[0] != "us-phoenix-1" {
+		t.Fatalf("expected a client per instance in its region, got %v", regions)
+	}
+
+	parsed, err := parseConfig([]string{"-inventory", inventory})
+	if err != nil || parsed.Inventory != inventory ||
+		parsed.Concurrency != clitools.DefaultConcurrency {
+		t.Fatalf("expected -inventory to stand in for -instance and -compartment, got %+v, %v",
+			parsed, err)
+	}
+
+	cfg.Inventory = filepath.Join(t.TempDir(), "missing.yaml")
+
+	if code := runFleet(cfg, &out); code != exitUsage {
+		t.Fatalf("expected exit %d for an unreadable inventory, got %d", exitUsage, code)
+	}
+}
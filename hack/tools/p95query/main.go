@@ -3,30 +3,50 @@ package main
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"oci-cpu-shaper/internal/clitools"
 	"oci-cpu-shaper/pkg/oci"
 )
 
-const defaultTimeout = 30 * time.Second
+const (
+	defaultTimeout = 30 * time.Second
+	defaultBackoff = 2 * time.Second
+
+	// exitBelowThreshold is returned when -fail-below is set and the P95 is under it, so cron
+	// jobs can tell a reclamation risk apart from a failed query (exit code 1).
+	exitBelowThreshold = 2
+)
 
 var (
 	errMissingInstance    = errors.New("instance OCID is required")
 	errMissingCompartment = errors.New("compartment OCID is required")
+	errNegativeRetries    = errors.New("retries must not be negative")
+	errBelowThreshold     = errors.New("P95 CPU utilisation below threshold")
 )
 
 type queryConfig struct {
-	instanceID    string
-	compartmentID string
-	region        string
-	last7d        bool
-	timeout       time.Duration
-	allowEmpty    bool
+	clitools.Options
+
+	last7d     bool
+	allowEmpty bool
+	retries    int
+	backoff    time.Duration
+	failBelow  float64
+}
+
+// queryResult is the -output=json document.
+type queryResult struct {
+	Instance       string  `json:"instance"`
+	Window         string  `json:"window"`
+	P95            float32 `json:"p95"`
+	FailBelow      float64 `json:"failBelow,omitempty"`
+	BelowThreshold bool    `json:"belowThreshold"`
 }
 
 func main() {
@@ -35,7 +55,12 @@ func main() {
 		logFatal(err)
 	}
 
-	err = runQuery(cfg)
+	err = runQuery(cfg, os.Stdout)
+	if errors.Is(err, errBelowThreshold) {
+		log.Printf("error: %v", err)
+		os.Exit(exitBelowThreshold)
+	}
+
 	if err != nil {
 		logFatal(err)
 	}
@@ -46,86 +71,221 @@ type metricsQuerier interface {
 }
 
 //nolint:gochecknoglobals // test seam for injecting fake clients
-var newMetricsClient = func(
-	compartmentID, region string,
-) (metricsQuerier, error) {
-	return oci.NewInstancePrincipalClient(compartmentID, region)
+var newMetricsClient = func(opts clitools.Options) (metricsQuerier, error) {
+	provider, err := opts.Provider()
+	if err != nil {
+		return nil, fmt.Errorf("select auth provider: %w", err)
+	}
+
+	region, err := opts.ResolveRegion(provider)
+	if err != nil {
+		return nil, fmt.Errorf("resolve region: %w", err)
+	}
+
+	return oci.NewClientWithProvider(provider, opts.CompartmentID, region)
 }
 
 func parseConfig(args []string) (queryConfig, error) {
 	var cfg queryConfig
 
-	flags := flag.NewFlagSet("p95query", flag.ContinueOnError)
-	flags.SetOutput(io.Discard)
+	flags := clitools.NewFlagSet("p95query")
+	cfg.Register(flags, defaultTimeout)
 
-	flags.StringVar(&cfg.instanceID, "instance", "", "OCID of the compute instance to query")
-	flags.StringVar(
-		&cfg.compartmentID,
-		"compartment",
-		"",
-		"Compartment OCID scoped for Monitoring queries",
-	)
-	flags.StringVar(&cfg.region, "region", "", "OCI region override (optional)")
 	flags.BoolVar(
 		&cfg.last7d,
 		"last7d",
 		true,
 		"Query the trailing seven days instead of the last 24 hours",
 	)
-	flags.DurationVar(
-		&cfg.timeout,
-		"timeout",
-		defaultTimeout,
-		"Timeout for the Monitoring API request",
-	)
 	flags.BoolVar(
 		&cfg.allowEmpty,
 		"allow-empty",
 		false,
 		"Exit successfully when Monitoring returns no datapoints",
 	)
+	flags.IntVar(
+		&cfg.retries,
+		"retries",
+		0,
+		"Additional attempts after a failed Monitoring request",
+	)
+	flags.DurationVar(
+		&cfg.backoff,
+		"backoff",
+		defaultBackoff,
+		"Delay before the first retry; doubles for each further retry",
+	)
+	flags.Float64Var(
+		&cfg.failBelow,
+		"fail-below",
+		0,
+		"Exit with code 2 when the P95 (percent) is below this threshold, e.g. 20; 0 disables",
+	)
 
 	err := flags.Parse(args)
 	if err != nil {
 		return queryConfig{}, fmt.Errorf("parse flags: %w", err)
 	}
 
+	err = cfg.Validate()
+	if err != nil {
+		return queryConfig{}, fmt.Errorf("validate flags: %w", err)
+	}
+
+	if cfg.retries < 0 {
+		return queryConfig{}, errNegativeRetries
+	}
+
 	return cfg, nil
 }
 
-func runQuery(cfg queryConfig) error {
-	if cfg.instanceID == "" {
+func runQuery(cfg queryConfig, out io.Writer) error {
+	if cfg.Inventory != "" {
+		return runFleetQuery(cfg, out)
+	}
+
+	if cfg.InstanceID == "" {
 		return errMissingInstance
 	}
 
-	if cfg.compartmentID == "" {
+	if cfg.CompartmentID == "" {
 		return errMissingCompartment
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
-	defer cancel()
+	result, err := queryInstance(context.Background(), cfg, cfg.Options)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		log.Printf("no metrics returned for %s", cfg.InstanceID)
 
-	client, err := newMetricsClient(cfg.compartmentID, cfg.region)
+		return nil
+	}
+
+	err = clitools.Print(out, cfg.Output, result.text(), result)
 	if err != nil {
-		return fmt.Errorf("build instance principal client: %w", err)
+		return fmt.Errorf("print result: %w", err)
 	}
 
-	value, err := client.QueryP95CPU(ctx, cfg.instanceID, cfg.last7d)
+	if result.BelowThreshold {
+		return fmt.Errorf("%w: %.2f%% < %.2f%%", errBelowThreshold, result.P95, cfg.failBelow)
+	}
+
+	return nil
+}
+
+// runFleetQuery queries every instance of the -inventory file and prints one
+// report keyed by instance name. A failed query fails the run; otherwise
+// instances below -fail-below return errBelowThreshold naming them.
+func runFleetQuery(cfg queryConfig, out io.Writer) error {
+	instances, err := clitools.LoadInventory(cfg.Inventory, cfg.Options)
 	if err != nil {
-		if errors.Is(err, oci.ErrNoMetricsData) && cfg.allowEmpty {
-			log.Printf("no metrics returned for %s", cfg.instanceID)
+		return err //nolint:wrapcheck // inventory errors name the file
+	}
 
-			return nil
-		}
+	report := clitools.RunFleet(
+		context.Background(),
+		instances,
+		cfg.Concurrency,
+		func(ctx context.Context, instance clitools.Instance) clitools.FleetResult {
+			if instance.CompartmentID == "" {
+				return clitools.FleetResult{Text: "", Value: nil, Err: errMissingCompartment}
+			}
+
+			result, err := queryInstance(ctx, cfg, cfg.For(instance))
+			if err != nil {
+				return clitools.FleetResult{Text: "", Value: nil, Err: err}
+			}
+
+			if result == nil {
+				return clitools.FleetResult{Text: "no metrics returned", Value: nil, Err: nil}
+			}
+
+			return clitools.FleetResult{Text: result.text(), Value: result, Err: nil}
+		},
+	)
+
+	err = report.Print(out, cfg.Output)
+	if err != nil {
+		return fmt.Errorf("print report: %w", err)
+	}
 
+	err = report.Err()
+	if err != nil {
 		return fmt.Errorf("query P95 CPU: %w", err)
 	}
 
-	log.Printf("P95 CPU utilisation for %s: %.2f%%", cfg.instanceID, value)
+	var below []string
+
+	for _, instance := range instances {
+		result, ok := report[instance.Name].Value.(*queryResult)
+		if ok && result.BelowThreshold {
+			below = append(below, instance.Name)
+		}
+	}
+
+	if len(below) > 0 {
+		return fmt.Errorf("%w (%.2f%%): %s", errBelowThreshold, cfg.failBelow,
+			strings.Join(below, ", "))
+	}
 
 	return nil
 }
 
+// queryInstance queries the P95 of the instance opts selects. A nil result
+// without an error means Monitoring returned no datapoints and -allow-empty
+// is set.
+func queryInstance(
+	ctx context.Context,
+	cfg queryConfig,
+	opts clitools.Options,
+) (*queryResult, error) {
+	client, err := newMetricsClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("build monitoring client: %w", err)
+	}
+
+	policy := oci.RetryPolicy{Retries: cfg.retries, Backoff: cfg.backoff}
+
+	value, err := oci.Retry(
+		ctx,
+		policy,
+		func(ctx context.Context) (float32, error) {
+			attemptCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+
+			value, err := client.QueryP95CPU(attemptCtx, opts.InstanceID, cfg.last7d)
+
+			return value, err //nolint:wrapcheck // wrapped once retries are exhausted
+		},
+	)
+	if err != nil {
+		if errors.Is(err, oci.ErrNoMetricsData) && cfg.allowEmpty {
+			return nil, nil //nolint:nilnil // no datapoints is not an error with -allow-empty
+		}
+
+		return nil, fmt.Errorf("query P95 CPU: %w", err)
+	}
+
+	result := queryResult{
+		Instance:       opts.InstanceID,
+		Window:         "24h",
+		P95:            value,
+		FailBelow:      cfg.failBelow,
+		BelowThreshold: cfg.failBelow > 0 && float64(value) < cfg.failBelow,
+	}
+	if cfg.last7d {
+		result.Window = "7d"
+	}
+
+	return &result, nil
+}
+
+func (r queryResult) text() string {
+	return fmt.Sprintf("P95 CPU utilisation for %s: %.2f%%", r.Instance, r.P95)
+}
+
 func logFatal(err error) {
 	log.Printf("error: %v", err)
 	os.Exit(1)
@@ -3,13 +3,18 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"oci-cpu-shaper/internal/clitools"
 	"oci-cpu-shaper/pkg/oci"
 )
 
@@ -52,7 +57,7 @@ func withMetricsClient(t *testing.T, client metricsQuerier, execute func()) {
 	metricsClientMutex.Lock()
 
 	previousFactory := newMetricsClient
-	newMetricsClient = func(string, string) (metricsQuerier, error) {
+	newMetricsClient = func(clitools.Options) (metricsQuerier, error) {
 		return client, nil
 	}
 
@@ -98,8 +103,8 @@ func TestParseConfigUsesDefaults(t *testing.T) {
 		t.Fatalf("expected last7d default true, got %v", cfg.last7d)
 	}
 
-	if cfg.timeout != defaultTimeout {
-		t.Fatalf("expected default timeout, got %v", cfg.timeout)
+	if cfg.Timeout != defaultTimeout {
+		t.Fatalf("expected default timeout, got %v", cfg.Timeout)
 	}
 
 	if cfg.allowEmpty {
@@ -122,20 +127,20 @@ func TestParseConfigParsesFlags(t *testing.T) {
 		t.Fatalf("parseConfig returned error: %v", err)
 	}
 
-	if cfg.instanceID != "ocid1.instance.oc1..exampleuniqueID" {
-		t.Fatalf("unexpected instance ID: %s", cfg.instanceID)
+	if cfg.InstanceID != "ocid1.instance.oc1..exampleuniqueID" {
+		t.Fatalf("unexpected instance ID: %s", cfg.InstanceID)
 	}
 
-	if cfg.compartmentID != "ocid1.compartment.oc1..exampleuniqueID" {
-		t.Fatalf("unexpected compartment ID: %s", cfg.compartmentID)
+	if cfg.CompartmentID != "ocid1.compartment.oc1..exampleuniqueID" {
+		t.Fatalf("unexpected compartment ID: %s", cfg.CompartmentID)
 	}
 
-	if cfg.region != "us-phoenix-1" {
-		t.Fatalf("unexpected region: %s", cfg.region)
+	if cfg.Region != "us-phoenix-1" {
+		t.Fatalf("unexpected region: %s", cfg.Region)
 	}
 
-	if cfg.timeout != 45*time.Second {
-		t.Fatalf("unexpected timeout: %v", cfg.timeout)
+	if cfg.Timeout != 45*time.Second {
+		t.Fatalf("unexpected timeout: %v", cfg.Timeout)
 	}
 
 	if !cfg.allowEmpty {
@@ -150,14 +155,12 @@ func TestParseConfigParsesFlags(t *testing.T) {
 func TestRunQueryRequiresInstanceID(t *testing.T) {
 	t.Parallel()
 
-	err := runQuery(queryConfig{
-		instanceID:    "",
-		compartmentID: "",
-		region:        "",
-		last7d:        true,
-		timeout:       defaultTimeout,
-		allowEmpty:    false,
-	})
+	err := runQuery(queryConfig{ //nolint:exhaustruct
+		Options: clitools.Options{ //nolint:exhaustruct
+			Timeout: defaultTimeout,
+		},
+		last7d: true,
+	}, io.Discard)
 	if !errors.Is(err, errMissingInstance) {
 		t.Fatalf("expected errMissingInstance, got %v", err)
 	}
@@ -166,20 +169,19 @@ func TestRunQueryRequiresInstanceID(t *testing.T) {
 func TestRunQueryRequiresCompartmentID(t *testing.T) {
 	t.Parallel()
 
-	err := runQuery(queryConfig{
-		instanceID:    "ocid1.instance",
-		compartmentID: "",
-		region:        "",
-		last7d:        true,
-		timeout:       defaultTimeout,
-		allowEmpty:    false,
-	})
+	err := runQuery(queryConfig{ //nolint:exhaustruct
+		Options: clitools.Options{ //nolint:exhaustruct
+			InstanceID: "ocid1.instance",
+			Timeout:    defaultTimeout,
+		},
+		last7d: true,
+	}, io.Discard)
 	if !errors.Is(err, errMissingCompartment) {
 		t.Fatalf("expected errMissingCompartment, got %v", err)
 	}
 }
 
-func TestRunQueryLogsValue(t *testing.T) {
+func TestRunQueryPrintsValue(t *testing.T) {
 	t.Parallel()
 
 	client := &fakeMetricsClient{ //nolint:exhaustruct
@@ -187,22 +189,22 @@ func TestRunQueryLogsValue(t *testing.T) {
 	}
 
 	withMetricsClient(t, client, func() {
-		output := captureLogs(t, func() {
-			err := runQuery(queryConfig{
-				instanceID:    "ocid1.instance",
-				compartmentID: "ocid1.compartment",
-				region:        "",
-				last7d:        true,
-				timeout:       time.Second,
-				allowEmpty:    false,
-			})
-			if err != nil {
-				t.Fatalf("runQuery returned error: %v", err)
-			}
-		})
+		var output bytes.Buffer
+
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       time.Second,
+			},
+			last7d: true,
+		}, &output)
+		if err != nil {
+			t.Fatalf("runQuery returned error: %v", err)
+		}
 
-		if !strings.Contains(output, "P95 CPU utilisation for ocid1.instance: 12.50%") {
-			t.Fatalf("unexpected log output: %q", output)
+		if output.String() != "P95 CPU utilisation for ocid1.instance: 12.50%\n" {
+			t.Fatalf("unexpected output: %q", output.String())
 		}
 
 		client.mu.Lock()
@@ -227,14 +229,15 @@ func TestRunQueryAllowsEmptyResults(t *testing.T) {
 
 	withMetricsClient(t, client, func() {
 		output := captureLogs(t, func() {
-			err := runQuery(queryConfig{
-				instanceID:    "ocid1.instance",
-				compartmentID: "ocid1.compartment",
-				region:        "",
-				last7d:        true,
-				timeout:       defaultTimeout,
-				allowEmpty:    true,
-			})
+			err := runQuery(queryConfig{ //nolint:exhaustruct
+				Options: clitools.Options{ //nolint:exhaustruct
+					InstanceID:    "ocid1.instance",
+					CompartmentID: "ocid1.compartment",
+					Timeout:       defaultTimeout,
+				},
+				last7d:     true,
+				allowEmpty: true,
+			}, io.Discard)
 			if err != nil {
 				t.Fatalf("runQuery returned error: %v", err)
 			}
@@ -254,14 +257,14 @@ func TestRunQueryWrapsQueryErrors(t *testing.T) {
 	}
 
 	withMetricsClient(t, client, func() {
-		err := runQuery(queryConfig{
-			instanceID:    "ocid1.instance",
-			compartmentID: "ocid1.compartment",
-			region:        "",
-			last7d:        true,
-			timeout:       defaultTimeout,
-			allowEmpty:    false,
-		})
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       defaultTimeout,
+			},
+			last7d: true,
+		}, io.Discard)
 		if err == nil || !strings.Contains(err.Error(), "query P95 CPU: boom") {
 			t.Fatalf("expected wrapped error, got %v", err)
 		}
@@ -274,7 +277,7 @@ func TestRunQueryWrapsClientErrors(t *testing.T) {
 	metricsClientMutex.Lock()
 
 	previousFactory := newMetricsClient
-	newMetricsClient = func(string, string) (metricsQuerier, error) {
+	newMetricsClient = func(clitools.Options) (metricsQuerier, error) {
 		return nil, errFactoryFailure
 	}
 
@@ -284,16 +287,280 @@ func TestRunQueryWrapsClientErrors(t *testing.T) {
 		metricsClientMutex.Unlock()
 	}()
 
-	err := runQuery(queryConfig{
-		instanceID:    "ocid1.instance",
-		compartmentID: "ocid1.compartment",
-		region:        "",
-		last7d:        true,
-		timeout:       defaultTimeout,
-		allowEmpty:    false,
-	})
+	err := runQuery(queryConfig{ //nolint:exhaustruct
+		Options: clitools.Options{ //nolint:exhaustruct
+			InstanceID:    "ocid1.instance",
+			CompartmentID: "ocid1.compartment",
+			Timeout:       defaultTimeout,
+		},
+		last7d: true,
+	}, io.Discard)
 	if err == nil ||
-		!strings.Contains(err.Error(), "build instance principal client: factory failure") {
+		!strings.Contains(err.Error(), "build monitoring client: factory failure") {
 		t.Fatalf("expected client factory error, got %v", err)
 	}
 }
+
+type flakyMetricsClient struct {
+	mu    sync.Mutex
+	errs  []error
+	value float32
+	calls int
+}
+
+func (f *flakyMetricsClient) QueryP95CPU(context.Context, string, bool) (float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+
+		return 0, err
+	}
+
+	return f.value, nil
+}
+
+func TestParseConfigParsesRetryFlags(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := parseConfig([]string{
+		"-retries", "3",
+		"-backoff", "500ms",
+		"-fail-below", "20",
+	})
+	if err != nil {
+		t.Fatalf("parseConfig returned error: %v", err)
+	}
+
+	if cfg.retries != 3 || cfg.backoff != 500*time.Millisecond || cfg.failBelow != 20 {
+		t.Fatalf("unexpected retry settings: %+v", cfg)
+	}
+
+	_, err = parseConfig([]string{"-retries", "-1"})
+	if !errors.Is(err, errNegativeRetries) {
+		t.Fatalf("expected errNegativeRetries, got %v", err)
+	}
+}
+
+func TestRunQueryRetriesTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	client := &flakyMetricsClient{ //nolint:exhaustruct
+		errs:  []error{errQueryFailure, errQueryFailure},
+		value: 31,
+	}
+
+	withMetricsClient(t, client, func() {
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       time.Second,
+			},
+			retries:   2,
+			backoff:   time.Millisecond,
+			failBelow: 20,
+		}, io.Discard)
+		if err != nil {
+			t.Fatalf("runQuery returned error: %v", err)
+		}
+	})
+
+	if client.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", client.calls)
+	}
+}
+
+func TestRunQueryReportsExhaustedRetries(t *testing.T) {
+	t.Parallel()
+
+	client := &flakyMetricsClient{ //nolint:exhaustruct
+		errs: []error{errQueryFailure, errQueryFailure},
+	}
+
+	withMetricsClient(t, client, func() {
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       time.Second,
+			},
+			retries: 1,
+			backoff: time.Millisecond,
+		}, io.Discard)
+		if !errors.Is(err, oci.ErrRetriesExhausted) || !errors.Is(err, errQueryFailure) {
+			t.Fatalf("expected exhausted retries, got %v", err)
+		}
+	})
+}
+
+func TestRunQueryFailsBelowThreshold(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeMetricsClient{ //nolint:exhaustruct
+		values: []float32{12.5},
+	}
+
+	withMetricsClient(t, client, func() {
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       time.Second,
+			},
+			last7d:    true,
+			failBelow: 20,
+		}, io.Discard)
+		if !errors.Is(err, errBelowThreshold) {
+			t.Fatalf("expected errBelowThreshold, got %v", err)
+		}
+	})
+}
+
+func TestRunQueryPrintsJSON(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeMetricsClient{ //nolint:exhaustruct
+		values: []float32{12.5},
+	}
+
+	withMetricsClient(t, client, func() {
+		var output bytes.Buffer
+
+		err := runQuery(queryConfig{ //nolint:exhaustruct
+			Options: clitools.Options{ //nolint:exhaustruct
+				InstanceID:    "ocid1.instance",
+				CompartmentID: "ocid1.compartment",
+				Timeout:       time.Second,
+				Output:        clitools.OutputJSON,
+			},
+			failBelow: 20,
+		}, &output)
+		if !errors.Is(err, errBelowThreshold) {
+			t.Fatalf("expected errBelowThreshold, got %v", err)
+		}
+
+		var result queryResult
+
+		err = json.Unmarshal(output.Bytes(), &result)
+		if err != nil {
+			t.Fatalf("decode output %q: %v", output.String(), err)
+		}
+
+		if result.P95 != 12.5 || result.Window != "24h" || !result.BelowThreshold {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+}
+
+func TestParseConfigRejectsUnknownOutput(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseConfig([]string{"-output", "yaml"})
+	if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Fatalf("expected unknown output error, got %v", err)
+	}
+}
+
+// fleetMetricsClient answers each instance OCID with its own value, failing
+// for OCIDs without one.
+type fleetMetricsClient struct {
+	values map[string]float32
+}
+
+func (f fleetMetricsClient) QueryP95CPU(
+	_ context.Context,
+	instanceOCID string,
+	_ bool,
+) (float32, error) {
+	value, ok := f.values[instanceOCID]
+	if !ok {
+		return 0, errQueryFailure
+	}
+
+	return value, nil
+}
+
+func TestRunQueryReportsFleetByName(t *testing.T) {
+	t.Parallel()
+
+	inventory := filepath.Join(t.TempDir(), "fleet.yaml")
+
+	err := os.WriteFile(inventory, []byte(`instances:
+  - name: web
+    ocid: ocid1.instance.web
+  - name: batch
+    ocid: ocid1.instance.batch
+  - name: broken
+    ocid: ocid1.instance.broken
+`), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	client := fleetMetricsClient{values: map[string]float32{
+		"ocid1.instance.web":   42,
+		"ocid1.instance.batch": 12.5,
+	}}
+
+	cfg := queryConfig{ //nolint:exhaustruct
+		Options: clitools.Options{ //nolint:exhaustruct
+			CompartmentID: "ocid1.compartment",
+			Timeout:       time.Second,
+			Inventory:     inventory,
+			Concurrency:   2,
+		},
+		failBelow: 20,
+	}
+
+	withMetricsClient(t, client, func() {
+		var output bytes.Buffer
+
+		err := runQuery(cfg, &output)
+		if !errors.Is(err, errQueryFailure) || !strings.Contains(err.Error(), "broken: ") {
+			t.Fatalf("expected the failed instance named, got %v", err)
+		}
+
+		want := "batch: P95 CPU utilisation for ocid1.instance.batch: 12.50%\n" +
+			"broken: error: query P95 CPU: boom\n" +
+			"web: P95 CPU utilisation for ocid1.instance.web: 42.00%\n"
+		if output.String() != want {
+			t.Fatalf("expected %q, got %q", want, output.String())
+		}
+
+		client.values["ocid1.instance.broken"] = 30
+		cfg.Output = clitools.OutputJSON
+
+		output.Reset()
+
+		err = runQuery(cfg, &output)
+		if !errors.Is(err, errBelowThreshold) || !strings.HasSuffix(err.Error(), ": batch") {
+			t.Fatalf("expected errBelowThreshold naming batch, got %v", err)
+		}
+
+		var report map[string]struct {
+			Result queryResult `json:"result"`
+		}
+
+		err = json.Unmarshal(output.Bytes(), &report)
+		if err != nil {
+			t.Fatalf("decode output %q: %v", output.String(), err)
+		}
+
+		if len(report) != 3 || report["web"].Result.P95 != 42 ||
+			!report["batch"].Result.BelowThreshold {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	cfg.Inventory = filepath.Join(t.TempDir(), "missing.yaml")
+
+	err = runQuery(cfg, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "read inventory") {
+		t.Fatalf("expected the inventory error, got %v", err)
+	}
+}
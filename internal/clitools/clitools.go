@@ -0,0 +1,174 @@
+// Package clitools holds the flag parsing, OCI authentication, region resolution, and output
+// helpers shared by the operator tools under hack/tools and cmd.
+package clitools
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+// Authentication modes accepted by the -auth flag.
+const (
+	AuthInstancePrincipal = "instance_principal"
+	AuthConfigFile        = "config_file"
+)
+
+// EnvRegion is consulted when -region is not supplied, matching the shaper's own override.
+const EnvRegion = "OCI_REGION"
+
+var (
+	// ErrRegionUnresolved is returned when neither -region, OCI_REGION, nor the
+	// authentication provider yields a region.
+	ErrRegionUnresolved = errors.New("region is required")
+	// ErrTimeoutInvalid is returned by Validate when -timeout is not positive.
+	ErrTimeoutInvalid = errors.New("timeout must be greater than zero")
+
+	errUnknownAuth = errors.New("unknown auth mode")
+)
+
+// Provider constructors are variables so tests can avoid real credentials.
+//
+//nolint:gochecknoglobals // test seams
+var (
+	instancePrincipalProvider = auth.InstancePrincipalConfigurationProvider
+	configFileProvider        = common.CustomProfileConfigProvider
+)
+
+// Options carries the flags every tool understands.
+type Options struct {
+	CompartmentID string
+	InstanceID    string
+	Region        string
+	Auth          string
+	ConfigFile    string
+	Profile       string
+	Timeout       time.Duration
+	Output        string
+	// Inventory names a fleet inventory file (see LoadInventory); tools that
+	// accept one operate on every instance it lists instead of -instance.
+	Inventory   string
+	Concurrency int
+}
+
+// NewFlagSet returns a flag set that reports parse errors to the caller instead of exiting.
+func NewFlagSet(name string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	return flagSet
+}
+
+// Register binds the shared flags to flagSet, using defaultTimeout for -timeout.
+func (o *Options) Register(flagSet *flag.FlagSet, defaultTimeout time.Duration) {
+	flagSet.StringVar(&o.CompartmentID, "compartment", "", "Compartment OCID to operate on")
+	flagSet.StringVar(&o.InstanceID, "instance", "", "OCID of the compute instance")
+	flagSet.StringVar(
+		&o.Region,
+		"region",
+		"",
+		"OCI region identifier; defaults to $"+EnvRegion+" or the auth provider's region",
+	)
+	flagSet.StringVar(
+		&o.Auth,
+		"auth",
+		AuthInstancePrincipal,
+		"Authentication mode: "+AuthInstancePrincipal+" or "+AuthConfigFile,
+	)
+	flagSet.StringVar(
+		&o.ConfigFile,
+		"oci-config",
+		"",
+		"OCI config file for -auth="+AuthConfigFile+" (defaults to ~/.oci/config)",
+	)
+	flagSet.StringVar(
+		&o.Profile,
+		"oci-profile",
+		"DEFAULT",
+		"Profile within the OCI config file",
+	)
+	flagSet.DurationVar(&o.Timeout, "timeout", defaultTimeout, "Timeout for OCI API requests")
+	flagSet.StringVar(
+		&o.Output,
+		"output",
+		OutputText,
+		"Result format: "+OutputText+" or "+OutputJSON,
+	)
+	flagSet.StringVar(
+		&o.Inventory,
+		"inventory",
+		"",
+		"YAML inventory of instances to operate on instead of -instance",
+	)
+	flagSet.IntVar(
+		&o.Concurrency,
+		"concurrency",
+		DefaultConcurrency,
+		"Inventory instances to operate on at once",
+	)
+}
+
+// Validate checks the shared flags. Tool-specific requirements, such as a mandatory
+// instance OCID, remain with each tool.
+func (o Options) Validate() error {
+	switch o.Auth {
+	case AuthInstancePrincipal, AuthConfigFile:
+	default:
+		return fmt.Errorf("%w %q", errUnknownAuth, o.Auth)
+	}
+
+	if o.Timeout <= 0 {
+		return ErrTimeoutInvalid
+	}
+
+	if o.Concurrency <= 0 {
+		return ErrConcurrencyInvalid
+	}
+
+	return validateOutput(o.Output)
+}
+
+// Provider builds the SDK configuration provider selected by -auth.
+//
+//nolint:ireturn // the SDK consumes providers through its interface
+func (o Options) Provider() (common.ConfigurationProvider, error) {
+	switch o.Auth {
+	case AuthConfigFile:
+		return configFileProvider(o.ConfigFile, o.Profile), nil
+	case AuthInstancePrincipal:
+		provider, err := instancePrincipalProvider()
+		if err != nil {
+			return nil, fmt.Errorf("initialise instance principal provider: %w", err)
+		}
+
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("%w %q", errUnknownAuth, o.Auth)
+	}
+}
+
+// ResolveRegion returns -region, then $OCI_REGION, then the region reported by provider.
+func (o Options) ResolveRegion(provider common.ConfigurationProvider) (string, error) {
+	if region := strings.TrimSpace(o.Region); region != "" {
+		return region, nil
+	}
+
+	if region := strings.TrimSpace(os.Getenv(EnvRegion)); region != "" {
+		return region, nil
+	}
+
+	if provider != nil {
+		region, err := provider.Region()
+		if err == nil && strings.TrimSpace(region) != "" {
+			return strings.TrimSpace(region), nil
+		}
+	}
+
+	return "", ErrRegionUnresolved
+}
@@ -0,0 +1,200 @@
+package clitools //nolint:testpackage // tests swap the provider seams
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+var errNoRegion = errors.New("no region")
+
+type regionProvider struct {
+	common.ConfigurationProvider
+
+	region string
+}
+
+func (p regionProvider) Region() (string, error) {
+	if p.region == "" {
+		return "", errNoRegion
+	}
+
+	return p.region, nil
+}
+
+func TestRegisterParsesSharedFlags(t *testing.T) {
+	t.Parallel()
+
+	var opts Options
+
+	flagSet := NewFlagSet("test")
+	opts.Register(flagSet, time.Minute)
+
+	err := flagSet.Parse([]string{
+		"-compartment", "ocid1.compartment",
+		"-instance", "ocid1.instance",
+		"-auth", AuthConfigFile,
+		"-oci-config", "/etc/oci/config",
+		"-oci-profile", "SHAPER",
+		"-output", OutputJSON,
+	})
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+
+	want := Options{
+		CompartmentID: "ocid1.compartment",
+		InstanceID:    "ocid1.instance",
+		Region:        "",
+		Auth:          AuthConfigFile,
+		ConfigFile:    "/etc/oci/config",
+		Profile:       "SHAPER",
+		Timeout:       time.Minute,
+		Output:        OutputJSON,
+		Inventory:     "",
+		Concurrency:   DefaultConcurrency,
+	}
+	if opts != want {
+		t.Fatalf("unexpected options %+v", opts)
+	}
+
+	err = opts.Validate()
+	if err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+}
+
+func TestValidateRejectsUnknownValues(t *testing.T) {
+	t.Parallel()
+
+	valid := Options{ //nolint:exhaustruct
+		Auth:        AuthInstancePrincipal,
+		Timeout:     time.Second,
+		Output:      OutputText,
+		Concurrency: 1,
+	}
+
+	badAuth := valid
+	badAuth.Auth = "api_key"
+
+	if err := badAuth.Validate(); !errors.Is(err, errUnknownAuth) {
+		t.Fatalf("expected errUnknownAuth, got %v", err)
+	}
+
+	badTimeout := valid
+	badTimeout.Timeout = 0
+
+	if err := badTimeout.Validate(); !errors.Is(err, ErrTimeoutInvalid) {
+		t.Fatalf("expected ErrTimeoutInvalid, got %v", err)
+	}
+
+	badConcurrency := valid
+	badConcurrency.Concurrency = 0
+
+	if err := badConcurrency.Validate(); !errors.Is(err, ErrConcurrencyInvalid) {
+		t.Fatalf("expected ErrConcurrencyInvalid, got %v", err)
+	}
+
+	badOutput := valid
+	badOutput.Output = "yaml"
+
+	if err := badOutput.Validate(); !errors.Is(err, errUnknownOutput) {
+		t.Fatalf("expected errUnknownOutput, got %v", err)
+	}
+}
+
+//nolint:paralleltest // swaps package-level provider seams
+func TestProviderSelectsAuthMode(t *testing.T) {
+	previousInstance, previousFile := instancePrincipalProvider, configFileProvider
+
+	t.Cleanup(func() {
+		instancePrincipalProvider, configFileProvider = previousInstance, previousFile
+	})
+
+	var gotPath, gotProfile string
+
+	configFileProvider = func(path, profile string) common.ConfigurationProvider {
+		gotPath, gotProfile = path, profile
+
+		return regionProvider{region: "eu-frankfurt-1"} //nolint:exhaustruct
+	}
+	instancePrincipalProvider = func() (common.ConfigurationProvider, error) {
+		return nil, errNoRegion
+	}
+
+	provider, err := Options{ //nolint:exhaustruct
+		Auth:       AuthConfigFile,
+		ConfigFile: "/etc/oci/config",
+		Profile:    "SHAPER",
+	}.Provider()
+	if err != nil || provider == nil {
+		t.Fatalf("expected config file provider, got %v (%v)", provider, err)
+	}
+
+	if gotPath != "/etc/oci/config" || gotProfile != "SHAPER" {
+		t.Fatalf("unexpected config file arguments %q %q", gotPath, gotProfile)
+	}
+
+	_, err = Options{Auth: AuthInstancePrincipal}.Provider() //nolint:exhaustruct
+	if !errors.Is(err, errNoRegion) {
+		t.Fatalf("expected instance principal error, got %v", err)
+	}
+}
+
+//nolint:paralleltest // mutates OCI_REGION
+func TestResolveRegionPrecedence(t *testing.T) {
+	provider := regionProvider{region: "eu-frankfurt-1"} //nolint:exhaustruct
+
+	t.Setenv(EnvRegion, "")
+
+	region, err := Options{}.ResolveRegion(provider) //nolint:exhaustruct
+	if err != nil || region != "eu-frankfurt-1" {
+		t.Fatalf("expected provider region, got %q (%v)", region, err)
+	}
+
+	t.Setenv(EnvRegion, "us-phoenix-1")
+
+	region, _ = Options{}.ResolveRegion(provider) //nolint:exhaustruct
+	if region != "us-phoenix-1" {
+		t.Fatalf("expected env region, got %q", region)
+	}
+
+	region, _ = Options{Region: "us-ashburn-1"}.ResolveRegion(provider) //nolint:exhaustruct
+	if region != "us-ashburn-1" {
+		t.Fatalf("expected flag region, got %q", region)
+	}
+
+	t.Setenv(EnvRegion, "")
+
+	_, err = Options{}.ResolveRegion(regionProvider{}) //nolint:exhaustruct
+	if !errors.Is(err, ErrRegionUnresolved) {
+		t.Fatalf("expected ErrRegionUnresolved, got %v", err)
+	}
+}
+
+func TestPrintFormats(t *testing.T) {
+	t.Parallel()
+
+	var text bytes.Buffer
+
+	err := Print(&text, OutputText, "p95 12.50%", map[string]float64{"p95": 12.5})
+	if err != nil || text.String() != "p95 12.50%\n" {
+		t.Fatalf("unexpected text output %q (%v)", text.String(), err)
+	}
+
+	var jsonOut bytes.Buffer
+
+	err = Print(&jsonOut, OutputJSON, "ignored", map[string]float64{"p95": 12.5})
+	if err != nil || !strings.Contains(jsonOut.String(), `"p95": 12.5`) {
+		t.Fatalf("unexpected json output %q (%v)", jsonOut.String(), err)
+	}
+
+	err = Print(&jsonOut, "yaml", "", nil)
+	if !errors.Is(err, errUnknownOutput) {
+		t.Fatalf("expected errUnknownOutput, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package clitools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConcurrency bounds how many inventory instances a tool works on at once.
+const DefaultConcurrency = 4
+
+var (
+	// ErrInventoryInvalid is returned by LoadInventory for an inventory with no
+	// instances, an instance without an OCID, or two instances sharing a name.
+	ErrInventoryInvalid = errors.New("invalid inventory")
+	// ErrConcurrencyInvalid is returned by Validate when -concurrency is not positive.
+	ErrConcurrencyInvalid = errors.New("concurrency must be greater than zero")
+)
+
+// Instance is one entry of a fleet inventory file:
+//
+//	instances:
+//	  - name: web-1
+//	    ocid: ocid1.instance.oc1.phx.example
+//	    region: us-phoenix-1
+//	    compartment: ocid1.compartment.oc1..example
+//
+// Name defaults to the OCID, and an empty region or compartment to the value of
+// -region or -compartment.
+type Instance struct {
+	Name          string `yaml:"name"`
+	InstanceID    string `yaml:"ocid"`
+	Region        string `yaml:"region"`
+	CompartmentID string `yaml:"compartment"`
+}
+
+type inventoryFile struct {
+	Instances []Instance `yaml:"instances"`
+}
+
+// LoadInventory reads the inventory file at path, filling the region and
+// compartment an entry leaves empty from defaults.
+func LoadInventory(path string, defaults Options) ([]Instance, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // operator-supplied path
+	if err != nil {
+		return nil, fmt.Errorf("read inventory: %w", err)
+	}
+
+	var file inventoryFile
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(&file)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse inventory %s: %w", path, err)
+	}
+
+	if len(file.Instances) == 0 {
+		return nil, fmt.Errorf("%w: %s lists no instances", ErrInventoryInvalid, path)
+	}
+
+	seen := make(map[string]bool, len(file.Instances))
+
+	for index := range file.Instances {
+		instance := &file.Instances[index]
+		instance.Name = strings.TrimSpace(instance.Name)
+		instance.InstanceID = strings.TrimSpace(instance.InstanceID)
+		instance.Region = strings.TrimSpace(instance.Region)
+		instance.CompartmentID = strings.TrimSpace(instance.CompartmentID)
+
+		if instance.InstanceID == "" {
+			return nil, fmt.Errorf("%w: instance %d has no ocid", ErrInventoryInvalid, index+1)
+		}
+
+		if instance.Name == "" {
+			instance.Name = instance.InstanceID
+		}
+
+		if seen[instance.Name] {
+			return nil, fmt.Errorf(
+				"%w: name %q is listed twice", ErrInventoryInvalid, instance.Name,
+			)
+		}
+
+		seen[instance.Name] = true
+
+		if instance.Region == "" {
+			instance.Region = defaults.Region
+		}
+
+		if instance.CompartmentID == "" {
+			instance.CompartmentID = defaults.CompartmentID
+		}
+	}
+
+	return file.Instances, nil
+}
+
+// For returns a copy of o scoped to instance, so per-instance clients can be
+// built with the shared authentication flags.
+func (o Options) For(instance Instance) Options {
+	o.InstanceID = instance.InstanceID
+	o.Region = instance.Region
+	o.CompartmentID = instance.CompartmentID
+
+	return o
+}
+
+// FleetResult is the outcome of a tool for one inventory instance: the text
+// line and JSON value it would print on its own, or the error it failed with.
+type FleetResult struct {
+	Text  string
+	Value any
+	Err   error
+}
+
+// MarshalJSON renders the result as {"result": Value, "error": "..."}, leaving
+// out whichever is empty.
+func (r FleetResult) MarshalJSON() ([]byte, error) {
+	document := struct {
+		Result any    `json:"result,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}{Result: r.Value, Error: ""}
+
+	if r.Err != nil {
+		document.Error = r.Err.Error()
+	}
+
+	return json.Marshal(document) //nolint:wrapcheck // called by the json package
+}
+
+// FleetReport holds the FleetResult of every inventory instance keyed by its
+// name.
+type FleetReport map[string]FleetResult
+
+// RunFleet calls run for every instance with at most concurrency calls in
+// flight and collects the results by instance name.
+func RunFleet(
+	ctx context.Context,
+	instances []Instance,
+	concurrency int,
+	run func(ctx context.Context, instance Instance) FleetResult,
+) FleetReport {
+	results := make([]FleetResult, len(instances))
+	slots := make(chan struct{}, max(1, concurrency))
+
+	var group sync.WaitGroup
+
+	for index, instance := range instances {
+		group.Add(1)
+
+		slots <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-slots }()
+
+			results[index] = run(ctx, instance)
+		}()
+	}
+
+	group.Wait()
+
+	report := make(FleetReport, len(instances))
+
+	for index, instance := range instances {
+		report[instance.Name] = results[index]
+	}
+
+	return report
+}
+
+// Err joins the errors of the failed instances, each prefixed with its name,
+// or returns nil when every instance succeeded.
+func (r FleetReport) Err() error {
+	var errs []error
+
+	for _, name := range r.names() {
+		if r[name].Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, r[name].Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Print writes the report to w: one "name: text" line per instance, sorted by
+// name, for OutputText, or a JSON object keyed by name for OutputJSON.
+func (r FleetReport) Print(w io.Writer, format string) error {
+	lines := make([]string, 0, len(r))
+
+	for _, name := range r.names() {
+		text := r[name].Text
+		if r[name].Err != nil {
+			text = "error: " + r[name].Err.Error()
+		}
+
+		lines = append(lines, name+": "+text)
+	}
+
+	return Print(w, format, strings.Join(lines, "\n"), map[string]FleetResult(r))
+}
+
+func (r FleetReport) names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,195 @@
+package clitools //nolint:testpackage // shares the package's test helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var errFleetFailure = errors.New("query failed")
+
+func writeInventory(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "inventory.yaml")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadInventoryFillsDefaults(t *testing.T) {
+	t.Parallel()
+
+	path := writeInventory(t, `instances:
+  - name: web-1
+    ocid: ocid1.instance.web
+    region: eu-frankfurt-1
+    compartment: ocid1.compartment.web
+  - ocid: " ocid1.instance.db "
+`)
+
+	instances, err := LoadInventory(path, Options{ //nolint:exhaustruct
+		Region:        "us-phoenix-1",
+		CompartmentID: "ocid1.compartment.default",
+	})
+	if err != nil {
+		t.Fatalf("LoadInventory: %v", err)
+	}
+
+	want := []Instance{
+		{
+			Name:          "web-1",
+			InstanceID:    "ocid1.instance.web",
+			Region:        "eu-frankfurt-1",
+			CompartmentID: "ocid1.compartment.web",
+		},
+		{
+			Name:          "ocid1.instance.db",
+			InstanceID:    "ocid1.instance.db",
+			Region:        "us-phoenix-1",
+			CompartmentID: "ocid1.compartment.default",
+		},
+	}
+	if len(instances) != len(want) || instances[0] != want[0] || instances[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, instances)
+	}
+
+	scoped := Options{Auth: AuthConfigFile, InstanceID: "ignored"}.For(instances[1]) //nolint:exhaustruct
+	if scoped.Auth != AuthConfigFile || scoped.InstanceID != "ocid1.instance.db" ||
+		scoped.Region != "us-phoenix-1" || scoped.CompartmentID != "ocid1.compartment.default" {
+		t.Fatalf("expected options scoped to the instance, got %+v", scoped)
+	}
+}
+
+func TestLoadInventoryRejectsInvalidFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"empty": {content: "", want: "lists no instances"},
+		"no ocid": {
+			content: "instances:\n  - name: web-1\n",
+			want:    "instance 1 has no ocid",
+		},
+		"duplicate name": {
+			content: "instances:\n  - ocid: a\n  - name: a\n    ocid: b\n",
+			want:    "twice",
+		},
+		"unknown field": {
+			content: "instances:\n  - ocid: a\n    zone: x\n",
+			want:    "parse inventory",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadInventory(writeInventory(t, test.content), Options{}) //nolint:exhaustruct
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("expected an error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+
+	_, err := LoadInventory(filepath.Join(t.TempDir(), "missing.yaml"), Options{}) //nolint:exhaustruct
+	if err == nil || !strings.Contains(err.Error(), "read inventory") {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}
+
+func TestRunFleetBoundsConcurrencyAndReportsByName(t *testing.T) {
+	t.Parallel()
+
+	instances := []Instance{
+		{Name: "web-2", InstanceID: "ocid2", Region: "", CompartmentID: ""},
+		{Name: "db", InstanceID: "ocid1", Region: "", CompartmentID: ""},
+		{Name: "web-1", InstanceID: "ocid3", Region: "", CompartmentID: ""},
+	}
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+
+	report := RunFleet(
+		t.Context(),
+		instances,
+		2,
+		func(_ context.Context, instance Instance) FleetResult {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			if instance.Name == "db" {
+				return FleetResult{Text: "", Value: nil, Err: errFleetFailure}
+			}
+
+			return FleetResult{
+				Text:  "ok " + instance.InstanceID,
+				Value: instance.InstanceID,
+				Err:   nil,
+			}
+		},
+	)
+
+	if peak > 2 {
+		t.Fatalf("expected at most two instances at once, got %d", peak)
+	}
+
+	err := report.Err()
+	if !errors.Is(err, errFleetFailure) || err.Error() != "db: query failed" {
+		t.Fatalf("expected the failure named by instance, got %v", err)
+	}
+
+	var text bytes.Buffer
+
+	err = report.Print(&text, OutputText)
+	if err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+
+	want := "db: error: query failed\nweb-1: ok ocid3\nweb-2: ok ocid2\n"
+	if text.String() != want {
+		t.Fatalf("expected %q, got %q", want, text.String())
+	}
+
+	var document bytes.Buffer
+
+	err = report.Print(&document, OutputJSON)
+	if err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+
+	for _, fragment := range []string{
+		`"db": {` + "\n" + `    "error": "query failed"`,
+		`"web-1": {` + "\n" + `    "result": "ocid3"`,
+	} {
+		if !strings.Contains(document.String(), fragment) {
+			t.Fatalf("expected %q in %s", fragment, document.String())
+		}
+	}
+
+	if (FleetReport{}).Err() != nil {
+		t.Fatal("expected no error from an empty report")
+	}
+}
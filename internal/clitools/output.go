@@ -0,0 +1,51 @@
+package clitools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Result formats accepted by the -output flag.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+var errUnknownOutput = errors.New("unknown output format")
+
+func validateOutput(format string) error {
+	switch format {
+	case OutputText, OutputJSON:
+		return nil
+	default:
+		return fmt.Errorf("%w %q", errUnknownOutput, format)
+	}
+}
+
+// Print writes a command result to w: the text line for OutputText, or value as an indented
+// JSON document for OutputJSON so scripts can consume it.
+func Print(w io.Writer, format, text string, value any) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		err := encoder.Encode(value)
+		if err != nil {
+			return fmt.Errorf("encode result: %w", err)
+		}
+
+		return nil
+	case OutputText, "":
+		_, err := fmt.Fprintln(w, text)
+		if err != nil {
+			return fmt.Errorf("write result: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w %q", errUnknownOutput, format)
+	}
+}
@@ -38,22 +38,39 @@ type monitoringPayload struct {
 //
 //nolint:ireturn // tests rely on the MetricsClient interface for controller wiring.
 func NewMonitoringClient(endpoint string) (oci.MetricsClient, error) {
+	return NewMonitoringClientWithClock(endpoint, time.Now)
+}
+
+// NewMonitoringClientWithClock behaves like NewMonitoringClient but stamps each query
+// with now, letting time-compressed runs report virtual query times to the server.
+//
+//nolint:ireturn // tests rely on the MetricsClient interface for controller wiring.
+func NewMonitoringClientWithClock(
+	endpoint string,
+	now func() time.Time,
+) (oci.MetricsClient, error) {
 	trimmed := strings.TrimSpace(endpoint)
 	if trimmed == "" {
 		return nil, errMonitoringEndpointRequired
 	}
 
+	if now == nil {
+		now = time.Now
+	}
+
 	return &monitoringClient{
 		endpoint: trimmed,
 		http: &http.Client{ //nolint:exhaustruct // only timeout customised for tests
 			Timeout: defaultHTTPTimeout,
 		},
+		now: now,
 	}, nil
 }
 
 type monitoringClient struct {
 	endpoint string
 	http     *http.Client
+	now      func() time.Time
 }
 
 func (c *monitoringClient) QueryP95CPU(ctx context.Context, resourceID string) (float64, error) {
@@ -68,6 +85,11 @@ func (c *monitoringClient) QueryP95CPU(ctx context.Context, resourceID string) (
 
 	query := url.Values{}
 	query.Set("resource", resourceID)
+
+	if c.now != nil {
+		query.Set(MonitoringTimeParam, c.now().UTC().Format(time.RFC3339Nano))
+	}
+
 	req.URL.RawQuery = query.Encode()
 
 	resp, err := c.http.Do(req)
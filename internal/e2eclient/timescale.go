@@ -0,0 +1,87 @@
+package e2eclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TimeScaleEnv compresses time for e2e builds of the daemon. A value of N
+	// shortens every scheduling interval N times and advances the virtual clock
+	// reported to the fake Monitoring server N times faster than wall time, so
+	// multi-day behaviour can be exercised in seconds.
+	TimeScaleEnv = "OCI_CPU_SHAPER_E2E_TIME_SCALE"
+
+	// MonitoringTimeParam carries the virtual query time to the fake Monitoring server.
+	MonitoringTimeParam = "at"
+
+	// minCompressedDuration keeps compressed tickers from spinning.
+	minCompressedDuration = time.Millisecond
+)
+
+var errInvalidTimeScale = errors.New("time scale: must be a finite number >= 1")
+
+// TimeScale is an env-driven clock shim: the Go runtime ignores LD_PRELOAD
+// based tools such as libfaketime, so e2e builds scale intervals explicitly.
+type TimeScale struct {
+	factor float64
+	origin time.Time
+	start  time.Time
+}
+
+// NewTimeScale returns a TimeScale whose virtual clock starts at origin and
+// runs factor times faster than wall time.
+func NewTimeScale(factor float64, origin time.Time) (TimeScale, error) {
+	if math.IsNaN(factor) || math.IsInf(factor, 0) || factor < 1 {
+		return TimeScale{}, fmt.Errorf("%w: %v", errInvalidTimeScale, factor)
+	}
+
+	return TimeScale{factor: factor, origin: origin, start: time.Now()}, nil
+}
+
+// TimeScaleFromEnv parses TimeScaleEnv. An unset variable yields a disabled
+// TimeScale that leaves durations and the clock untouched.
+func TimeScaleFromEnv() (TimeScale, error) {
+	raw := strings.TrimSpace(os.Getenv(TimeScaleEnv))
+	if raw == "" {
+		return TimeScale{}, nil
+	}
+
+	factor, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return TimeScale{}, fmt.Errorf("%w: %q", errInvalidTimeScale, raw)
+	}
+
+	return NewTimeScale(factor, time.Now())
+}
+
+// Enabled reports whether time is compressed.
+func (s TimeScale) Enabled() bool {
+	return s.factor > 1
+}
+
+// Compress shortens a virtual duration to the wall-clock duration that
+// elapses while the virtual clock advances by d.
+func (s TimeScale) Compress(duration time.Duration) time.Duration {
+	if !s.Enabled() || duration <= 0 {
+		return duration
+	}
+
+	return max(time.Duration(float64(duration)/s.factor), minCompressedDuration)
+}
+
+// Now returns the virtual time, or the wall clock when compression is disabled.
+func (s TimeScale) Now() time.Time {
+	if !s.Enabled() {
+		return time.Now()
+	}
+
+	elapsed := time.Since(s.start)
+
+	return s.origin.Add(time.Duration(float64(elapsed) * s.factor))
+}
@@ -0,0 +1,62 @@
+//nolint:testpackage // white-box tests exercise internal seams for coverage.
+package e2eclient
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeScaleFromEnv(t *testing.T) {
+	t.Setenv(TimeScaleEnv, "")
+
+	scale, err := TimeScaleFromEnv()
+	if err != nil || scale.Enabled() {
+		t.Fatalf("expected disabled scale without env, got %+v (err=%v)", scale, err)
+	}
+
+	if got := scale.Compress(time.Hour); got != time.Hour {
+		t.Fatalf("expected disabled scale to keep durations, got %s", got)
+	}
+
+	for _, raw := range []string{"fast", "0.5", "NaN"} {
+		t.Setenv(TimeScaleEnv, raw)
+
+		_, err = TimeScaleFromEnv()
+		if !errors.Is(err, errInvalidTimeScale) {
+			t.Fatalf("expected errInvalidTimeScale for %q, got %v", raw, err)
+		}
+	}
+
+	t.Setenv(TimeScaleEnv, "3600")
+
+	scale, err = TimeScaleFromEnv()
+	if err != nil || !scale.Enabled() {
+		t.Fatalf("expected enabled scale, got %+v (err=%v)", scale, err)
+	}
+}
+
+func TestTimeScaleCompressesDurationsAndClock(t *testing.T) {
+	t.Parallel()
+
+	origin := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	scale, err := NewTimeScale(3600, origin)
+	if err != nil {
+		t.Fatalf("NewTimeScale returned error: %v", err)
+	}
+
+	if got := scale.Compress(6 * time.Hour); got != 6*time.Second {
+		t.Fatalf("expected 6h to compress to 6s, got %s", got)
+	}
+
+	if got := scale.Compress(time.Second); got != minCompressedDuration {
+		t.Fatalf("expected compression floor of %s, got %s", minCompressedDuration, got)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if advanced := scale.Now().Sub(origin); advanced < 36*time.Second {
+		t.Fatalf("expected virtual clock to run 3600x faster, advanced %s", advanced)
+	}
+}
@@ -0,0 +1,120 @@
+// Package logtest provides an in-memory logging.Logger for package tests.
+package logtest
+
+import (
+	"sync"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// Log levels recorded in Entry.Level.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+var _ logging.Logger = (*Recorder)(nil)
+
+// Entry is one recorded report.
+type Entry struct {
+	Level   string
+	Message string
+	Fields  map[string]any
+}
+
+// Line formats the entry as "level: message".
+func (e Entry) Line() string {
+	return e.Level + ": " + e.Message
+}
+
+// Recorder keeps every report in memory. The zero value is ready to use and
+// safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Debug records a debug report.
+func (r *Recorder) Debug(msg string, keysAndValues ...any) {
+	r.record(LevelDebug, msg, keysAndValues)
+}
+
+// Info records an info report.
+func (r *Recorder) Info(msg string, keysAndValues ...any) {
+	r.record(LevelInfo, msg, keysAndValues)
+}
+
+// Warn records a warning.
+func (r *Recorder) Warn(msg string, keysAndValues ...any) {
+	r.record(LevelWarn, msg, keysAndValues)
+}
+
+// Error records an error report.
+func (r *Recorder) Error(msg string, keysAndValues ...any) {
+	r.record(LevelError, msg, keysAndValues)
+}
+
+// Entries returns every report, oldest first.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+
+	return entries
+}
+
+// Messages returns the message of every report, oldest first.
+func (r *Recorder) Messages() []string {
+	entries := r.Entries()
+	messages := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+
+	return messages
+}
+
+// Lines returns "level: message" for every report above debug, oldest first:
+// what an operator sees at the default level.
+func (r *Recorder) Lines() []string {
+	var lines []string
+
+	for _, entry := range r.Entries() {
+		if entry.Level != LevelDebug {
+			lines = append(lines, entry.Line())
+		}
+	}
+
+	return lines
+}
+
+// Count returns how many of Lines equal line.
+func (r *Recorder) Count(line string) int {
+	total := 0
+
+	for _, recorded := range r.Lines() {
+		if recorded == line {
+			total++
+		}
+	}
+
+	return total
+}
+
+func (r *Recorder) record(level, msg string, keysAndValues []any) {
+	fields := make(map[string]any, len(keysAndValues)/2)
+
+	for index := 0; index+1 < len(keysAndValues); index += 2 {
+		key, _ := keysAndValues[index].(string)
+		fields[key] = keysAndValues[index+1]
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, Entry{Level: level, Message: msg, Fields: fields})
+	r.mu.Unlock()
+}
@@ -0,0 +1,71 @@
+package logtest_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+func TestRecorderKeepsReportsInOrder(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+
+	logger.Debug("tuned", "step", 1)
+	logger.Info("started", "stage", "pool")
+	logger.Warn("slow")
+	logger.Error("failed", "error", "boom", "dangling")
+	logger.Warn("slow")
+
+	messages := []string{"tuned", "started", "slow", "failed", "slow"}
+	if !slices.Equal(logger.Messages(), messages) {
+		t.Fatalf("expected messages %v, got %v", messages, logger.Messages())
+	}
+
+	want := []string{"info: started", "warn: slow", "error: failed", "warn: slow"}
+	if !slices.Equal(logger.Lines(), want) {
+		t.Fatalf("expected lines %v without debug, got %v", want, logger.Lines())
+	}
+
+	if logger.Count("warn: slow") != 2 || logger.Count("debug: tuned") != 0 {
+		t.Fatalf("unexpected counts for %v", logger.Lines())
+	}
+
+	entries := logger.Entries()
+	if entries[0].Level != logtest.LevelDebug || entries[0].Fields["step"] != 1 ||
+		entries[1].Fields["stage"] != "pool" || len(entries[3].Fields) != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	entries[0].Message = "changed"
+	if logger.Messages()[0] != "tuned" {
+		t.Fatal("expected Entries to return a copy")
+	}
+}
+
+func TestRecorderIsSafeForConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logger logtest.Recorder
+		group  sync.WaitGroup
+	)
+
+	for range 8 {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			logger.Info("tick")
+		}()
+	}
+
+	group.Wait()
+
+	if logger.Count("info: tick") != 8 {
+		t.Fatalf("expected eight reports, got %v", logger.Lines())
+	}
+}
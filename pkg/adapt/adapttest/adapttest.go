@@ -0,0 +1,226 @@
+// Package adapttest provides scripted fakes for the interfaces the adaptive
+// controller depends on, so custom policies and embedders can drive
+// adapt.AdaptiveController deterministically in tests. Every fake is safe for
+// concurrent use because the controller calls them from its own goroutines.
+package adapttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oci-cpu-shaper/pkg/est"
+)
+
+// ErrNoResults is returned by a MetricsClient that was given no results.
+var ErrNoResults = errors.New("adapttest: no results scripted")
+
+// Result is one scripted Monitoring response.
+type Result struct {
+	Value float64
+	Err   error
+}
+
+// MetricsClient implements oci.MetricsClient by replaying Results in order.
+// Once the script is exhausted the last Result is repeated.
+type MetricsClient struct {
+	mu      sync.Mutex
+	results []Result
+	calls   int
+}
+
+// NewMetricsClient returns a MetricsClient that answers with results.
+func NewMetricsClient(results ...Result) *MetricsClient {
+	return &MetricsClient{
+		mu:      sync.Mutex{},
+		results: append([]Result(nil), results...),
+		calls:   0,
+	}
+}
+
+// QueryP95CPU returns the next scripted Result, or the context error when ctx
+// is already done.
+func (m *MetricsClient) QueryP95CPU(ctx context.Context, _ string) (float64, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, fmt.Errorf("query p95 context: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.results) == 0 {
+		return 0, ErrNoResults
+	}
+
+	index := min(m.calls, len(m.results)-1)
+	m.calls++
+
+	return m.results[index].Value, m.results[index].Err
+}
+
+// Calls reports how many queries reached the script.
+func (m *MetricsClient) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls
+}
+
+// Estimator implements adapt.Estimator. Run delivers the scripted
+// observations on a buffered channel and closes it. The zero value delivers
+// nothing.
+type Estimator struct {
+	observations []est.Observation
+	consumed     atomic.Int32
+}
+
+// NewEstimator returns an Estimator that emits observations once.
+func NewEstimator(observations ...est.Observation) *Estimator {
+	return &Estimator{
+		observations: append([]est.Observation(nil), observations...),
+		consumed:     atomic.Int32{},
+	}
+}
+
+// Run returns a closed channel pre-filled with the scripted observations.
+func (e *Estimator) Run(context.Context) <-chan est.Observation {
+	observations := make(chan est.Observation, len(e.observations))
+	for _, observation := range e.observations {
+		observations <- observation
+
+		e.consumed.Add(1)
+	}
+
+	close(observations)
+
+	return observations
+}
+
+// Consumed reports how many observations Run has emitted.
+func (e *Estimator) Consumed() int {
+	return int(e.consumed.Load())
+}
+
+// DutyCycler implements adapt.DutyCycler and records every target it is set
+// to.
+type DutyCycler struct {
+	mu     sync.Mutex
+	target float64
+	calls  []float64
+}
+
+// NewDutyCycler returns a DutyCycler with a zero target.
+func NewDutyCycler() *DutyCycler {
+	return &DutyCycler{mu: sync.Mutex{}, target: 0, calls: nil}
+}
+
+// SetTarget records target.
+func (d *DutyCycler) SetTarget(target float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.target = target
+	d.calls = append(d.calls, target)
+}
+
+// Target returns the last target set.
+func (d *DutyCycler) Target() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.target
+}
+
+// Calls returns every target set so far, oldest first.
+func (d *DutyCycler) Calls() []float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]float64(nil), d.calls...)
+}
+
+// RecorderSnapshot holds the latest value and call count for each signal a
+// Recorder has seen.
+type RecorderSnapshot struct {
+	Mode         string
+	ModeCalls    int
+	State        string
+	StateCalls   int
+	Target       float64
+	TargetCalls  int
+	OCIP95       float64
+	OCIFetchedAt time.Time
+	OCICalls     int
+	HostCPU      float64
+	HostCalls    int
+}
+
+// Recorder implements adapt.MetricsRecorder and keeps the latest value of
+// every signal.
+type Recorder struct {
+	mu       sync.Mutex
+	snapshot RecorderSnapshot
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+// SetMode records the controller mode.
+func (r *Recorder) SetMode(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot.Mode = mode
+	r.snapshot.ModeCalls++
+}
+
+// SetState records the controller state.
+func (r *Recorder) SetState(state string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot.State = state
+	r.snapshot.StateCalls++
+}
+
+// SetTarget records the applied target.
+func (r *Recorder) SetTarget(target float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot.Target = target
+	r.snapshot.TargetCalls++
+}
+
+// ObserveOCIP95 records a successful Monitoring P95.
+func (r *Recorder) ObserveOCIP95(value float64, fetchedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot.OCIP95 = value
+	r.snapshot.OCIFetchedAt = fetchedAt
+	r.snapshot.OCICalls++
+}
+
+// ObserveHostCPU records a host utilisation sample.
+func (r *Recorder) ObserveHostCPU(utilisation float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot.HostCPU = utilisation
+	r.snapshot.HostCalls++
+}
+
+// Snapshot returns a copy of everything recorded so far.
+func (r *Recorder) Snapshot() RecorderSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.snapshot
+}
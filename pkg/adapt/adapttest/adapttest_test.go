@@ -0,0 +1,159 @@
+package adapttest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var (
+	_ oci.MetricsClient     = (*adapttest.MetricsClient)(nil)
+	_ adapt.Estimator       = (*adapttest.Estimator)(nil)
+	_ adapt.DutyCycler      = (*adapttest.DutyCycler)(nil)
+	_ adapt.MetricsRecorder = (*adapttest.Recorder)(nil)
+
+	errMonitoringDown = errors.New("test: monitoring down")
+)
+
+func TestMetricsClientReplaysScriptAndRepeatsLast(t *testing.T) {
+	t.Parallel()
+
+	client := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.2, Err: nil},
+		adapttest.Result{Value: 0, Err: errMonitoringDown},
+	)
+
+	value, err := client.QueryP95CPU(context.Background(), "ocid1.instance")
+	if err != nil || value != 0.2 {
+		t.Fatalf("expected first result 0.2, got %v (err %v)", value, err)
+	}
+
+	for range 2 {
+		_, err = client.QueryP95CPU(context.Background(), "ocid1.instance")
+		if !errors.Is(err, errMonitoringDown) {
+			t.Fatalf("expected the last result to repeat, got %v", err)
+		}
+	}
+
+	if client.Calls() != 3 {
+		t.Fatalf("expected 3 calls, got %d", client.Calls())
+	}
+}
+
+func TestMetricsClientWithoutScriptOrContext(t *testing.T) {
+	t.Parallel()
+
+	_, err := adapttest.NewMetricsClient().QueryP95CPU(context.Background(), "ocid1.instance")
+	if !errors.Is(err, adapttest.ErrNoResults) {
+		t.Fatalf("expected ErrNoResults, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := adapttest.NewMetricsClient(adapttest.Result{Value: 0.3, Err: nil})
+
+	_, err = client.QueryP95CPU(ctx, "ocid1.instance")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if client.Calls() != 0 {
+		t.Fatalf("expected a cancelled query to skip the script, got %d calls", client.Calls())
+	}
+}
+
+func TestEstimatorEmitsObservationsOnce(t *testing.T) {
+	t.Parallel()
+
+	estimator := adapttest.NewEstimator(
+		est.Observation{Utilisation: 0.4},
+		est.Observation{Utilisation: 0.6},
+	)
+
+	var got []float64
+	for observation := range estimator.Run(context.Background()) {
+		got = append(got, observation.Utilisation)
+	}
+
+	if !slices.Equal(got, []float64{0.4, 0.6}) || estimator.Consumed() != 2 {
+		t.Fatalf("unexpected observations %v (consumed %d)", got, estimator.Consumed())
+	}
+
+	var zero adapttest.Estimator
+	if _, open := <-zero.Run(context.Background()); open {
+		t.Fatal("expected the zero Estimator to close its channel at once")
+	}
+}
+
+func TestControllerDrivesFakes(t *testing.T) {
+	t.Parallel()
+
+	cfg := adapt.DefaultConfig()
+	cfg.ResourceID = "ocid1.instance.oc1..adapttest"
+	cfg.Interval = time.Millisecond
+	cfg.RelaxedInterval = time.Millisecond
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.2, Err: nil})
+	estimator := adapttest.NewEstimator(est.Observation{
+		Timestamp:    time.Unix(1_700_000_000, 0),
+		Utilisation:  0.1,
+		BusyJiffies:  10,
+		TotalJiffies: 100,
+		Err:          nil,
+	})
+	shaper := adapttest.NewDutyCycler()
+	recorder := adapttest.NewRecorder()
+
+	controller, err := adapt.NewAdaptiveController(cfg, metrics, estimator, shaper, recorder)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- controller.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for recorder.Snapshot().OCICalls == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("controller never recorded a P95")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+
+	runErr := <-errCh
+	if runErr != nil && !errors.Is(runErr, context.Canceled) {
+		t.Fatalf("Run: %v", runErr)
+	}
+
+	snapshot := recorder.Snapshot()
+	if snapshot.Mode != cfg.Mode || snapshot.ModeCalls == 0 {
+		t.Fatalf("expected mode %q to be recorded, got %+v", cfg.Mode, snapshot)
+	}
+
+	if snapshot.OCIP95 != 0.2 || snapshot.OCIFetchedAt.IsZero() || snapshot.State == "" {
+		t.Fatalf("unexpected recorder snapshot %+v", snapshot)
+	}
+
+	calls := shaper.Calls()
+	if len(calls) == 0 || calls[len(calls)-1] != shaper.Target() {
+		t.Fatalf("expected the last recorded target to be current, got %v", calls)
+	}
+
+	if snapshot.TargetCalls == 0 || snapshot.Target != shaper.Target() {
+		t.Fatalf("expected recorder target %v to match %v", snapshot.Target, shaper.Target())
+	}
+}
@@ -0,0 +1,155 @@
+package adapt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BlackoutWindow is a daily time-of-day window during which the controller
+// does no shaping at all, for example while nightly backups run. Start and End
+// are wall-clock offsets from midnight in Location, or in the process's local
+// time zone when Location is nil; windows whose End precedes Start wrap past
+// midnight.
+type BlackoutWindow struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+func (w BlackoutWindow) contains(now time.Time) bool {
+	return withinDaily(w.Start, w.End, sinceMidnight(inLocation(now, w.Location)))
+}
+
+// next returns the first Start or End boundary after now, on the wall clock
+// of the window's time zone so daylight saving changes move it with the clock.
+func (w BlackoutWindow) next(now time.Time) time.Time {
+	local := inLocation(now, w.Location)
+
+	var earliest time.Time
+
+	for _, boundary := range []time.Duration{w.Start, w.End} {
+		for days := range 2 {
+			at := time.Date(
+				local.Year(), local.Month(), local.Day()+days,
+				int(boundary/time.Hour), int(boundary%time.Hour/time.Minute),
+				int(boundary%time.Minute/time.Second), 0, local.Location(),
+			)
+
+			if at.After(now) {
+				if earliest.IsZero() || at.Before(earliest) {
+					earliest = at
+				}
+
+				break
+			}
+		}
+	}
+
+	return earliest
+}
+
+// inLocation moves now into location. A nil location keeps the zone of the
+// controller clock, which is the process's local time zone.
+func inLocation(now time.Time, location *time.Location) time.Time {
+	if location == nil {
+		return now
+	}
+
+	return now.In(location)
+}
+
+// SetBlackoutHandler installs a callback invoked whenever a blackout window
+// starts or ends. A nil handler disables notifications.
+func (c *AdaptiveController) SetBlackoutHandler(handler func(active bool)) {
+	c.mu.Lock()
+	c.blackoutHandler = handler
+	c.mu.Unlock()
+}
+
+// BlackoutActive reports whether a Config.Blackout window currently holds the
+// target at zero.
+func (c *AdaptiveController) BlackoutActive() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.blackout
+}
+
+// enforceBlackouts holds the target at zero inside Config.Blackout windows
+// until ctx is done, waking at each window boundary.
+func (c *AdaptiveController) enforceBlackouts(ctx context.Context) {
+	if len(c.cfg.Blackout) == 0 {
+		return
+	}
+
+	for {
+		now := c.now()
+
+		var (
+			active bool
+			wake   time.Time
+		)
+
+		for _, window := range c.cfg.Blackout {
+			active = active || window.contains(now)
+
+			if next := window.next(now); wake.IsZero() || next.Before(wake) {
+				wake = next
+			}
+		}
+
+		c.setBlackout(active)
+
+		if !sleepUntil(ctx, c.now, wake) {
+			return
+		}
+	}
+}
+
+// setBlackout enters or leaves a blackout. Entering drops the target to zero
+// at once; leaving restores the desired target unless suppression still holds
+// the shaper at zero, without spending the change budget.
+func (c *AdaptiveController) setBlackout(active bool) {
+	c.mu.Lock()
+
+	if active == c.blackout {
+		c.mu.Unlock()
+
+		return
+	}
+
+	c.blackout = active
+	c.hasPending = false
+
+	if active {
+		c.logger.Info("blackout window started; workers idle", "target", c.desired)
+		c.setTargetLocked(0)
+	} else {
+		c.logger.Info("blackout window ended; resuming shaping", "target", c.desired)
+		c.applySuppressionTargetsLocked(true)
+	}
+
+	handler := c.blackoutHandler
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(active)
+	}
+}
+
+func validateBlackoutWindows(cfg Config) error {
+	for index, window := range cfg.Blackout {
+		if window.Start < 0 || window.Start >= day || window.End < 0 || window.End >= day ||
+			window.Start == window.End {
+			return fmt.Errorf(
+				"%w: controller.blackout[%d]: %w",
+				ErrInvalidConfig,
+				index,
+				errWindowBounds,
+			)
+		}
+	}
+
+	return nil
+}
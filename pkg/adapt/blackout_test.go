@@ -0,0 +1,161 @@
+//nolint:testpackage // tests drive unexported blackout helpers
+package adapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestBlackoutWindowFollowsItsTimeZone(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	window := BlackoutWindow{Start: 23 * time.Hour, End: time.Hour, Location: zone}
+
+	testCases := []struct {
+		name   string
+		now    time.Time
+		active bool
+		next   time.Time
+	}{
+		{
+			name:   "before start",
+			now:    time.Date(2024, 6, 3, 20, 30, 0, 0, time.UTC),
+			active: false,
+			next:   time.Date(2024, 6, 3, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "before midnight",
+			now:    time.Date(2024, 6, 3, 21, 30, 0, 0, time.UTC),
+			active: true,
+			next:   time.Date(2024, 6, 3, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "after midnight",
+			now:    time.Date(2024, 6, 3, 22, 30, 0, 0, time.UTC),
+			active: true,
+			next:   time.Date(2024, 6, 3, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "after end",
+			now:    time.Date(2024, 6, 3, 23, 0, 0, 0, time.UTC),
+			active: false,
+			next:   time.Date(2024, 6, 4, 21, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		requireEqual(t, testCase.name+" active", window.contains(testCase.now), testCase.active)
+
+		next := window.next(testCase.now)
+		if !next.Equal(testCase.next) {
+			t.Fatalf("%s: expected next boundary %s, got %s", testCase.name, testCase.next, next)
+		}
+	}
+
+	// Without a location the window follows the controller clock's zone.
+	local := BlackoutWindow{Start: 22 * time.Hour, End: 23 * time.Hour, Location: nil}
+	requireEqual(t, "clock zone", local.contains(time.Date(2024, 6, 3, 22, 30, 0, 0, zone)), true)
+}
+
+//nolint:funlen // one scenario covers entering, holding, and leaving a blackout
+func TestBlackoutHoldsTargetAtZero(t *testing.T) {
+	t.Parallel()
+
+	shaper := adapttest.NewDutyCycler()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.1, Err: nil}),
+		nil,
+		shaper,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	var notified []bool
+
+	controller.SetBlackoutHandler(func(active bool) { notified = append(notified, active) })
+
+	controller.step(t.Context())
+
+	desired := controller.Target()
+
+	controller.setBlackout(true)
+	controller.setBlackout(true)
+
+	requireEqual(t, "active", controller.BlackoutActive(), true)
+	requireEqual(t, "blackout target", shaper.Target(), 0.0)
+
+	// Steps, alarm silences, and lifted suppression must not raise the target.
+	controller.step(t.Context())
+	controller.SetAlarmSilence(time.Now().Add(time.Hour), 0.3)
+	controller.RequestSuppression("test", time.Now().Add(time.Hour))
+	controller.RequestSuppression("test", time.Time{})
+
+	requireEqual(t, "held target", controller.Target(), 0.0)
+
+	if controller.desired <= desired {
+		t.Fatalf("expected the policy to keep stepping the desired target, got %.3f after %.3f",
+			controller.desired, desired)
+	}
+
+	controller.setBlackout(false)
+
+	requireEqual(t, "active after end", controller.BlackoutActive(), false)
+	// The alarm silence floor applies again once the blackout ends.
+	requireFloatApprox(t, "restored target", controller.Target(), max(controller.desired, 0.3))
+	requireEqual(t, "notifications", len(notified), 2)
+	requireEqual(t, "entered", notified[0], true)
+	requireEqual(t, "left", notified[1], false)
+}
+
+func TestEnforceBlackoutsAppliesCurrentWindow(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.Blackout = []BlackoutWindow{
+		{Start: 11 * time.Hour, End: 13 * time.Hour, Location: time.UTC},
+		{Start: 20 * time.Hour, End: 21 * time.Hour, Location: time.UTC},
+	}
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	controller.now = func() time.Time { return time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC) }
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		controller.enforceBlackouts(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !controller.BlackoutActive() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the current window to start a blackout")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	requireEqual(t, "target", controller.Target(), 0.0)
+
+	cancel()
+	<-done
+}
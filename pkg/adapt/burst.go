@@ -0,0 +1,143 @@
+package adapt
+
+import "time"
+
+const (
+	// BurstCreditWindow is how long a burstable instance with a full credit
+	// balance is assumed to run at 100% before OCI clamps it to its baseline.
+	// OCI does not publish the balance, so credits are an estimate.
+	BurstCreditWindow = time.Hour
+
+	// burstMaxGap is the longest gap between host observations that is still
+	// accounted; longer gaps (pauses, sampler restarts) are skipped.
+	burstMaxGap = time.Minute
+)
+
+// BurstStatus estimates the CPU credit balance of a burstable shape, which
+// earns credits while utilisation is below its baseline and spends them above
+// it. Once the balance is empty OCI clamps the instance to the baseline, which
+// on a BASELINE_1_8 shape keeps the CPU P95 below IdleThreshold.
+type BurstStatus struct {
+	// Baseline is the shape's baseline OCPU fraction.
+	Baseline float64
+	// Credits is the estimated balance as a fraction of a full one.
+	Credits float64
+	// Utilisation is the smoothed host utilisation the projection uses.
+	Utilisation float64
+	// Draining reports whether Utilisation exceeds Baseline.
+	Draining bool
+	// ThrottleIn is the projected time until the balance is empty at the
+	// current Utilisation; zero while not Draining or once the balance is
+	// spent.
+	ThrottleIn time.Duration
+	// TargetAboveBaseline reports whether the applied target alone exceeds
+	// Baseline, so the shaper's own load will eventually clamp the instance.
+	TargetAboveBaseline bool
+}
+
+// SetBurstHandler installs a callback invoked from the estimator goroutine
+// after each host observation while Config.BurstBaseline is set. A nil
+// handler disables notifications.
+func (c *AdaptiveController) SetBurstHandler(handler func(status BurstStatus)) {
+	c.mu.Lock()
+	c.burstHandler = handler
+	c.mu.Unlock()
+}
+
+// BurstStatus returns the latest burst credit estimate and whether one has
+// been computed yet.
+func (c *AdaptiveController) BurstStatus() (BurstStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.burst, c.burstKnown
+}
+
+func (c *AdaptiveController) burstTracked() bool {
+	return c.cfg.BurstBaseline > 0 && c.cfg.BurstBaseline < 1
+}
+
+// updateBurstLocked integrates the credit balance over the time since the
+// previous observation. The balance starts full because OCI grants launch
+// credits and the real balance is unknown.
+func (c *AdaptiveController) updateBurstLocked(at time.Time, utilisation float64) {
+	if !c.burstTracked() {
+		return
+	}
+
+	baseline := c.cfg.BurstBaseline
+	capacity := (1 - baseline) * BurstCreditWindow.Seconds()
+
+	if !c.burstKnown {
+		c.burst = BurstStatus{
+			Baseline:            baseline,
+			Credits:             1,
+			Utilisation:         utilisation,
+			Draining:            false,
+			ThrottleIn:          0,
+			TargetAboveBaseline: false,
+		}
+		c.burstKnown = true
+	} else if gap := at.Sub(c.burstAt); gap > 0 && gap <= burstMaxGap {
+		balance := c.burst.Credits*capacity + (baseline-utilisation)*gap.Seconds()
+		c.burst.Credits = clamp(balance/capacity, 0, 1)
+		c.burst.Utilisation += (utilisation - c.burst.Utilisation) / float64(hostLoadSmoothing)
+	}
+
+	c.burstAt = at
+	c.burst.Draining = c.burst.Utilisation > baseline
+	c.burst.ThrottleIn = burstThrottleIn(c.burst.Credits*capacity, c.burst.Utilisation-baseline)
+
+	above := c.target > baseline
+	if above != c.burst.TargetAboveBaseline {
+		c.logBurstTransitionLocked(above, capacity)
+	}
+
+	c.burst.TargetAboveBaseline = above
+	c.burstChanged = true
+}
+
+func (c *AdaptiveController) logBurstTransitionLocked(above bool, capacity float64) {
+	if !above {
+		c.logger.Info("target back within the burst baseline",
+			"target", c.target, "baseline", c.cfg.BurstBaseline)
+
+		return
+	}
+
+	throttleIn := burstThrottleIn(c.burst.Credits*capacity, c.target-c.cfg.BurstBaseline)
+	c.logger.Warn(
+		"target exceeds the burst baseline; synthetic load will exhaust cpu credits "+
+			"and oci will clamp the instance to its baseline",
+		"target", c.target,
+		"baseline", c.cfg.BurstBaseline,
+		"credits", c.burst.Credits,
+		"throttleIn", throttleIn.String(),
+	)
+}
+
+// burstThrottleIn returns how long balance OCPU-seconds last at excess
+// utilisation above the baseline.
+func burstThrottleIn(balance, excess float64) time.Duration {
+	if excess <= 0 {
+		return 0
+	}
+
+	return time.Duration(balance / excess * float64(time.Second))
+}
+
+// notifyBurst delivers the latest burst status to the handler.
+func (c *AdaptiveController) notifyBurst() {
+	c.mu.Lock()
+	handler := c.burstHandler
+	status := c.burst
+	changed := c.burstChanged
+	c.burstChanged = false
+	c.mu.Unlock()
+
+	if !changed || handler == nil {
+		return
+	}
+
+	handler(status)
+}
@@ -0,0 +1,137 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func newBurstController(t *testing.T, baseline float64) *AdaptiveController {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.BurstBaseline = baseline
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	return controller
+}
+
+func TestBurstCreditsDrainAndProjectThrottle(t *testing.T) {
+	t.Parallel()
+
+	controller := newBurstController(t, 0.125)
+	logger := new(logtest.Recorder)
+	controller.SetLogger(logger)
+
+	var statuses []BurstStatus
+	controller.SetBurstHandler(func(status BurstStatus) { statuses = append(statuses, status) })
+
+	// 0.5 above the baseline drains a full balance of 0.875 h in 6300 s.
+	feedObservation(controller, 0, 0.625, nil)
+
+	status, ok := controller.BurstStatus()
+	requireEqual(t, "burst known", ok, true)
+	requireEqual(t, "draining", status.Draining, true)
+	requireEqual(t, "target above baseline", status.TargetAboveBaseline, true)
+	requireFloatApprox(t, "initial credits", status.Credits, 1)
+	requireEqual(t, "initial projection", status.ThrottleIn, 6300*time.Second)
+
+	feedObservation(controller, 10, 0.625, nil)
+
+	status, _ = controller.BurstStatus()
+	requireFloatApprox(t, "credits after 10 s", status.Credits, 3145.0/3150.0)
+	requireEqual(t, "projection after 10 s", status.ThrottleIn, 6290*time.Second)
+
+	// A gap longer than burstMaxGap is not accounted.
+	feedObservation(controller, 600, 0.625, nil)
+
+	status, _ = controller.BurstStatus()
+	requireFloatApprox(t, "credits after a gap", status.Credits, 3145.0/3150.0)
+
+	for ts := int64(610); ts <= 7000; ts += 10 {
+		feedObservation(controller, ts, 0.625, nil)
+	}
+
+	status, _ = controller.BurstStatus()
+	requireFloatApprox(t, "spent credits", status.Credits, 0)
+	requireEqual(t, "clamped projection", status.ThrottleIn, time.Duration(0))
+	requireEqual(t, "still draining", status.Draining, true)
+
+	requireEqual(t, "handler calls", len(statuses), 643)
+
+	warnings := slices.DeleteFunc(logger.Lines(), func(entry string) bool {
+		return !strings.HasPrefix(entry, "warn: ")
+	})
+	requireEqual(t, "warnings", len(warnings), 1)
+}
+
+func TestBurstCreditsRefillBelowBaseline(t *testing.T) {
+	t.Parallel()
+
+	controller := newBurstController(t, 0.5)
+	logger := new(logtest.Recorder)
+	controller.SetLogger(logger)
+
+	controller.mu.Lock()
+	controller.burst = BurstStatus{
+		Baseline:            0.5,
+		Credits:             0.5,
+		Utilisation:         0.1,
+		Draining:            false,
+		ThrottleIn:          0,
+		TargetAboveBaseline: true,
+	}
+	controller.burstKnown = true
+	controller.burstAt = time.Unix(0, 0)
+	controller.mu.Unlock()
+
+	// 0.4 below the baseline earns 16 s of credit over 40 s out of 1800 s.
+	feedObservation(controller, 40, 0.1, nil)
+
+	status, _ := controller.BurstStatus()
+	requireFloatApprox(t, "refilled credits", status.Credits, 0.5+16.0/1800.0)
+	requireEqual(t, "not draining", status.Draining, false)
+	requireEqual(t, "no projection", status.ThrottleIn, time.Duration(0))
+	requireEqual(t, "target within baseline", status.TargetAboveBaseline, false)
+	requireEqual(t, "recovery logged", slices.Contains(
+		logger.Lines(),
+		"info: target back within the burst baseline",
+	), true)
+}
+
+func TestBurstTrackingNeedsBaseline(t *testing.T) {
+	t.Parallel()
+
+	for _, baseline := range []float64{0, 1, -0.5} {
+		controller := newBurstController(t, baseline)
+
+		calls := 0
+		controller.SetBurstHandler(func(BurstStatus) { calls++ })
+
+		feedObservation(controller, 0, 0.9, nil)
+		feedObservation(controller, 10, 0.9, nil)
+
+		if _, ok := controller.BurstStatus(); ok || calls != 0 {
+			t.Fatalf(
+				"baseline %v: expected no burst estimate, got %d handler calls",
+				baseline,
+				calls,
+			)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"strings"
 	"sync"
 	"time"
 
 	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/logging"
 	"oci-cpu-shaper/pkg/oci"
 )
 
@@ -48,6 +50,14 @@ type Controller interface {
 	LastEstimatorError() error
 }
 
+// Introspector is implemented by controllers that report the target they
+// apply and the last successful OCI P95, letting callers read both through a
+// Controller without depending on a concrete type.
+type Introspector interface {
+	Target() float64
+	LastP95() float64
+}
+
 // DutyCycler is implemented by the shape worker pool.
 type DutyCycler interface {
 	SetTarget(target float64)
@@ -63,6 +73,42 @@ type MetricsRecorder interface {
 	ObserveHostCPU(utilisation float64)
 }
 
+// Decision summarises the outcome of a single slow-loop controller step.
+// StepID identifies the step in the controller's logs, the Monitoring requests
+// it issued (as their opc-request-id), and the audit trail.
+type Decision struct {
+	StepID       string
+	Timestamp    time.Time
+	ResourceID   string
+	Mode         string
+	State        State
+	P95          float64
+	Target       float64
+	NextInterval time.Duration
+	Err          error
+}
+
+// DecisionObserver receives the outcome of each slow-loop step. Implementations must
+// not block for long because they run on the controller goroutine.
+type DecisionObserver interface {
+	ObserveDecision(decision Decision)
+}
+
+// DecisionObservers fans each decision out to every observer in order, so
+// several consumers can share SetDecisionObserver.
+type DecisionObservers []DecisionObserver
+
+// ObserveDecision implements DecisionObserver.
+func (o DecisionObservers) ObserveDecision(decision Decision) {
+	for _, observer := range o {
+		observer.ObserveDecision(decision)
+	}
+}
+
+// Logger receives controller diagnostics such as fallback and suppression
+// transitions; see logging.Logger.
+type Logger = logging.Logger
+
 // Estimator exposes the observation stream produced by pkg/est.
 type Estimator interface {
 	Run(ctx context.Context) <-chan est.Observation
@@ -85,6 +131,35 @@ type Config struct {
 	RelaxedThreshold  float64
 	SuppressThreshold float64
 	SuppressResume    float64
+	// MaxChangesPerHour caps how many target changes the controller applies within
+	// a sliding hour. Zero disables the limit.
+	MaxChangesPerHour int
+	// NetworkBandwidthGbps is the shape's network bandwidth, used to turn the
+	// seven-day network totals into the ratio OCI compares with IdleThreshold.
+	// Zero skips the network check so idle status follows the CPU P95 alone.
+	NetworkBandwidthGbps float64
+	// BurstBaseline is the baseline OCPU fraction of a burstable shape (0.125
+	// for BASELINE_1_8, 0.5 for BASELINE_1_2). Values outside (0, 1) disable
+	// the burst credit estimate (see BurstStatus).
+	BurstBaseline float64
+	// Policy selects the slow-loop decision policy: PolicyStep (default),
+	// PolicyPID, or PolicySchedule.
+	Policy string
+	// PID tunes PolicyPID. Zero proportional and integral gains take the defaults.
+	PID PIDGains
+	// InitialState is the state a new controller starts in: "fallback"
+	// (default) runs at FallbackTarget until the first successful query, and
+	// "normal" applies TargetStart at once, for hosts that restart often and
+	// whose baseline is known.
+	InitialState string
+	// Schedule lists the daily windows PolicySchedule caps the target in.
+	Schedule []ScheduleWindow
+	// Blackout lists the daily windows in which the target is held at zero
+	// whatever the policy, suppression, or alarm silence ask for.
+	Blackout []BlackoutWindow
+	// SuppressLearning replaces SuppressThreshold and SuppressResume with
+	// values learned from the host's background load (see SuppressLearning).
+	SuppressLearning SuppressLearning
 }
 
 // DefaultConfig mirrors the initial implementation plan for control loop cadence.
@@ -104,25 +179,39 @@ const (
 	defaultSuppressResume  = 0.70
 	hostLoadSmoothing      = 5
 	suppressResumeScale    = 0.8
+	changeRateWindow       = time.Hour
 )
 
 func DefaultConfig() Config {
 	return Config{
-		ResourceID:        "",
-		Mode:              defaultModeLabel,
-		TargetStart:       defaultTargetStart,
-		TargetMin:         defaultTargetMin,
-		TargetMax:         defaultTargetMax,
-		StepUp:            defaultStepUp,
-		StepDown:          defaultStepDown,
-		FallbackTarget:    defaultFallbackTarget,
-		GoalLow:           defaultGoalLow,
-		GoalHigh:          defaultGoalHigh,
-		Interval:          time.Hour,
-		RelaxedInterval:   defaultRelaxedInterval,
-		RelaxedThreshold:  defaultRelaxedThresh,
-		SuppressThreshold: defaultSuppressThresh,
-		SuppressResume:    defaultSuppressResume,
+		ResourceID:           "",
+		Mode:                 defaultModeLabel,
+		TargetStart:          defaultTargetStart,
+		TargetMin:            defaultTargetMin,
+		TargetMax:            defaultTargetMax,
+		StepUp:               defaultStepUp,
+		StepDown:             defaultStepDown,
+		FallbackTarget:       defaultFallbackTarget,
+		GoalLow:              defaultGoalLow,
+		GoalHigh:             defaultGoalHigh,
+		Interval:             time.Hour,
+		RelaxedInterval:      defaultRelaxedInterval,
+		RelaxedThreshold:     defaultRelaxedThresh,
+		SuppressThreshold:    defaultSuppressThresh,
+		SuppressResume:       defaultSuppressResume,
+		MaxChangesPerHour:    0,
+		NetworkBandwidthGbps: 0,
+		BurstBaseline:        0,
+		Policy:               PolicyStep,
+		PID: PIDGains{
+			Proportional: defaultPIDProportional,
+			Integral:     defaultPIDIntegral,
+			Derivative:   0,
+		},
+		InitialState:     StateFallback.String(),
+		Schedule:         nil,
+		Blackout:         nil,
+		SuppressLearning: DefaultSuppressLearning(),
 	}
 }
 
@@ -131,15 +220,19 @@ var (
 	errDutyCyclerRequired    = errors.New("adapt: duty cycler is required")
 	// ErrInvalidConfig signals that the supplied controller configuration is invalid.
 	ErrInvalidConfig = errors.New("adapt: invalid config")
+	// ErrOnDemandUnsupported signals an estimator that cannot sample on demand.
+	ErrOnDemandUnsupported = errors.New("adapt: estimator cannot sample on demand")
 )
 
 // AdaptiveController orchestrates the normal/fallback state machine.
 type AdaptiveController struct {
-	cfg       Config
-	metrics   oci.MetricsClient
-	shaper    DutyCycler
-	estimator Estimator
-	recorder  MetricsRecorder
+	cfg        Config
+	metrics    oci.MetricsClient
+	shaper     DutyCycler
+	estimator  Estimator
+	recorder   MetricsRecorder
+	now        func() time.Time
+	configured Policy
 
 	mu         sync.Mutex
 	state      State
@@ -148,14 +241,83 @@ type AdaptiveController struct {
 	target     float64
 	desired    float64
 	lastP95    float64
+	p95History []P95Sample
 	lastErr    error
 	lastEstErr error
 	hostLoad   float64
 	interval   time.Duration
 	mode       string
+	observer   DecisionObserver
+	policy     Policy
+
+	changes       []time.Time
+	pendingTarget float64
+	hasPending    bool
+	// fallbackFrom is the desired target when the controller entered
+	// fallback; returning to it on recovery bypasses the change budget.
+	fallbackFrom float64
+
+	holds        map[string]time.Time
+	externalHold bool
+
+	stepRequests chan chan Decision
+
+	networkAt    time.Time
+	networkRatio float64
+	networkKnown bool
+	idle         IdleStatus
+	idleKnown    bool
+	idleChanged  bool
+	idleHandler  func(status IdleStatus)
+
+	burst        BurstStatus
+	burstAt      time.Time
+	burstKnown   bool
+	burstChanged bool
+	burstHandler func(status BurstStatus)
+
+	pauseHandler func(gap time.Duration)
+
+	silencedUntil time.Time
+	silenceFloor  float64
+
+	windDownAt      time.Time
+	windDownRamp    time.Duration
+	windingDown     bool
+	windDownCeiling float64
+
+	blackout        bool
+	blackoutHandler func(active bool)
+
+	selfLoad        BusyMeter
+	selfBusy        time.Duration
+	selfShare       float64
+	selfLoadHandler func(share float64)
+
+	errorHandler func(component string, err error)
+
+	learnHist     []uint64
+	learnObserved time.Duration
+	learnAt       time.Time
+	learned       bool
+	learnChanged  bool
+	learnHandler  func(state SuppressLearningState)
+
+	episodes       []SuppressionEpisode
+	episode        SuppressionEpisode
+	episodeAt      time.Time
+	inEpisode      bool
+	episodesEnded  []SuppressionEpisode
+	episodeHandler func(episode SuppressionEpisode)
+
+	logger Logger
+	stats  runStats
 }
 
-var _ Controller = (*AdaptiveController)(nil)
+var (
+	_ Controller   = (*AdaptiveController)(nil)
+	_ Introspector = (*AdaptiveController)(nil)
+)
 
 // NewAdaptiveController wires together the OCI metrics client, estimator and shaper.
 func NewAdaptiveController(
@@ -178,20 +340,36 @@ func NewAdaptiveController(
 		return nil, err
 	}
 
+	policy, err := NewPolicy(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	state, target := initialTarget(normalized)
+
 	controller := new(AdaptiveController)
 	controller.cfg = normalized
 	controller.metrics = metrics
 	controller.shaper = shaper
 	controller.estimator = estimator
 	controller.recorder = recorder
-	controller.state = StateFallback
-	controller.slowState = StateFallback
-	controller.target = normalized.FallbackTarget
-	controller.desired = normalized.FallbackTarget
+	controller.state = state
+	controller.slowState = state
+	controller.target = target
+	controller.desired = target
 	controller.interval = normalized.Interval
 	controller.mode = mode
+	controller.now = time.Now
+	controller.stepRequests = make(chan chan Decision)
+	controller.configured = policy
+	controller.policy = policy
+	controller.logger = logging.Nop()
+
+	if normalized.SuppressLearning.LearnFor > 0 {
+		controller.learnHist = make([]uint64, SuppressLearningBins)
+	}
 
-	shaper.SetTarget(normalized.FallbackTarget)
+	shaper.SetTarget(target)
 
 	if recorder != nil {
 		recorder.SetMode(mode)
@@ -208,9 +386,18 @@ func (c *AdaptiveController) Run(ctx context.Context) error {
 		go c.consumeEstimator(ctx, c.estimator.Run(ctx))
 	}
 
+	go c.windDown(ctx)
+	go c.enforceBlackouts(ctx)
+
+	c.mu.Lock()
+	c.stats.begin(c.now(), c.state, c.target)
+	c.mu.Unlock()
+
 	ticker := time.NewTicker(c.interval)
 	defer ticker.Stop()
 
+	lastTick := c.now()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -221,15 +408,38 @@ func (c *AdaptiveController) Run(ctx context.Context) error {
 
 			return nil
 		case <-ticker.C:
+			paused := c.detectPause(lastTick, c.now(), c.interval)
+
 			nextInterval := c.step(ctx)
 			if nextInterval <= 0 {
 				nextInterval = c.cfg.Interval
 			}
 
-			if nextInterval != c.interval {
+			// After a pause the ticker keeps its pre-pause phase, so restart
+			// the cadence from the fresh step.
+			if paused || nextInterval != c.interval {
 				ticker.Reset(nextInterval)
 			}
 
+			lastTick = c.now()
+
+			c.mu.Lock()
+			c.interval = nextInterval
+			c.mu.Unlock()
+		case reply := <-c.stepRequests:
+			nextInterval, decision := c.stepDecision(ctx)
+			reply <- decision
+
+			if nextInterval <= 0 {
+				nextInterval = c.cfg.Interval
+			}
+
+			// A forced step restarts the cadence so the next scheduled query
+			// is a full interval away.
+			ticker.Reset(nextInterval)
+
+			lastTick = c.now()
+
 			c.mu.Lock()
 			c.interval = nextInterval
 			c.mu.Unlock()
@@ -237,6 +447,26 @@ func (c *AdaptiveController) Run(ctx context.Context) error {
 	}
 }
 
+// RequestStep runs an immediate Monitoring query and control step on the Run
+// goroutine and returns its decision. It blocks until Run picks the request
+// up, so callers should bound ctx.
+func (c *AdaptiveController) RequestStep(ctx context.Context) (Decision, error) {
+	reply := make(chan Decision, 1)
+
+	select {
+	case c.stepRequests <- reply:
+	case <-ctx.Done():
+		return Decision{}, fmt.Errorf("request step: %w", ctx.Err())
+	}
+
+	select {
+	case decision := <-reply:
+		return decision, nil
+	case <-ctx.Done():
+		return Decision{}, fmt.Errorf("await step: %w", ctx.Err())
+	}
+}
+
 // State returns the current controller state.
 func (c *AdaptiveController) State() State {
 	c.mu.Lock()
@@ -277,6 +507,179 @@ func (c *AdaptiveController) LastEstimatorError() error {
 	return c.lastEstErr
 }
 
+// CurrentObservation returns the latest host utilisation sample when the
+// estimator supports on-demand reads (see est.Sampler.Current).
+func (c *AdaptiveController) CurrentObservation() (est.Observation, bool) {
+	current, ok := c.estimator.(interface {
+		Current() (est.Observation, bool)
+	})
+	if !ok {
+		return est.Observation{}, false
+	}
+
+	return current.Current()
+}
+
+// SampleHostCPU takes an on-demand host utilisation sample from the estimator
+// (see est.Sampler.SampleNow) without feeding it to the control loop. It fails
+// with ErrOnDemandUnsupported when the estimator cannot sample on demand.
+func (c *AdaptiveController) SampleHostCPU(ctx context.Context) (est.Observation, error) {
+	sampler, ok := c.estimator.(interface {
+		SampleNow(ctx context.Context) (est.Observation, error)
+	})
+	if !ok {
+		return est.Observation{}, ErrOnDemandUnsupported
+	}
+
+	observation, err := sampler.SampleNow(ctx)
+	if err != nil {
+		return est.Observation{}, fmt.Errorf("sample host cpu: %w", err)
+	}
+
+	return observation, nil
+}
+
+// SetEstimatorRestartHandler forwards handler to the estimator when it supports
+// warm restarts (see est.Sampler.SetRestartHandler). It reports whether the
+// estimator accepted the handler.
+func (c *AdaptiveController) SetEstimatorRestartHandler(
+	handler func(failures int, lastErr error),
+) bool {
+	restarter, ok := c.estimator.(interface {
+		SetRestartHandler(handler func(failures int, lastErr error))
+	})
+	if !ok {
+		return false
+	}
+
+	restarter.SetRestartHandler(handler)
+
+	return true
+}
+
+// SetSamplerRestartHandler forwards handler to the estimator when it replaces
+// dead samplers (see est.Supervisor.SetSamplerRestartHandler). It reports
+// whether the estimator accepted the handler.
+func (c *AdaptiveController) SetSamplerRestartHandler(handler func(reason string)) bool {
+	supervisor, ok := c.estimator.(interface {
+		SetSamplerRestartHandler(handler func(reason string))
+	})
+	if !ok {
+		return false
+	}
+
+	supervisor.SetSamplerRestartHandler(handler)
+
+	return true
+}
+
+// SetObservationDropHandler forwards handler to the estimator when it drops
+// observations the controller falls behind on (see est.Supervisor.SetDropHandler).
+// It reports whether the estimator accepted the handler.
+func (c *AdaptiveController) SetObservationDropHandler(handler func()) bool {
+	dropper, ok := c.estimator.(interface {
+		SetDropHandler(handler func())
+	})
+	if !ok {
+		return false
+	}
+
+	dropper.SetDropHandler(handler)
+
+	return true
+}
+
+// RequestSuppression holds the controller in the suppressed state until the
+// supplied time on behalf of source, independently of the estimator. Each
+// source keeps a single request; a zero or past until clears it. Suppression
+// lasts while any source's request is outstanding.
+func (c *AdaptiveController) RequestSuppression(source string, until time.Time) {
+	defer c.notifySuppressionEpisodes()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.holds == nil {
+		c.holds = make(map[string]time.Time)
+	}
+
+	if until.IsZero() {
+		delete(c.holds, source)
+	} else {
+		c.holds[source] = until
+	}
+
+	previouslySuppressed := c.suppressedLocked()
+	c.refreshExternalHoldLocked()
+	c.applySuppressionTargetsLocked(previouslySuppressed)
+	c.updateEffectiveStateLocked()
+}
+
+// SuppressionRequests returns the outstanding external suppression requests
+// keyed by source.
+func (c *AdaptiveController) SuppressionRequests() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshExternalHoldLocked()
+
+	requests := make(map[string]time.Time, len(c.holds))
+	for source, until := range c.holds {
+		requests[source] = until
+	}
+
+	return requests
+}
+
+// SetClockSkewHandler forwards handler to the metrics client when it tracks
+// clock skew against OCI Monitoring (see oci.Client.SetClockSkewHandler). It
+// reports whether the metrics client accepted the handler.
+func (c *AdaptiveController) SetClockSkewHandler(handler func(skew time.Duration)) bool {
+	tracker, ok := c.metrics.(interface {
+		SetClockSkewHandler(handler func(skew time.Duration))
+	})
+	if !ok {
+		return false
+	}
+
+	tracker.SetClockSkewHandler(handler)
+
+	return true
+}
+
+// SetTokenExpiryHandler forwards handler to the metrics client when it tracks
+// the expiry of its instance principal security token (see
+// oci.Client.SetTokenExpiryHandler). It reports whether the metrics client
+// accepted the handler.
+func (c *AdaptiveController) SetTokenExpiryHandler(handler func(expiry time.Time)) bool {
+	tracker, ok := c.metrics.(interface {
+		SetTokenExpiryHandler(handler func(expiry time.Time))
+	})
+	if !ok {
+		return false
+	}
+
+	tracker.SetTokenExpiryHandler(handler)
+
+	return true
+}
+
+// SetQueryWindowHandler forwards handler to the metrics client when it reports
+// the window of its Monitoring queries (see oci.Client.SetQueryWindowHandler).
+// It reports whether the metrics client accepted the handler.
+func (c *AdaptiveController) SetQueryWindowHandler(handler func(window oci.QueryWindow)) bool {
+	tracker, ok := c.metrics.(interface {
+		SetQueryWindowHandler(handler func(window oci.QueryWindow))
+	})
+	if !ok {
+		return false
+	}
+
+	tracker.SetQueryWindowHandler(handler)
+
+	return true
+}
+
 // Mode returns the configured controller mode label.
 func (c *AdaptiveController) Mode() string {
 	c.mu.Lock()
@@ -285,6 +688,67 @@ func (c *AdaptiveController) Mode() string {
 	return c.mode
 }
 
+// SetMode replaces the mode label reported in decisions and metrics, for
+// example when a canary configuration is promoted from dry-run to enforce.
+func (c *AdaptiveController) SetMode(mode string) {
+	trimmed := strings.TrimSpace(mode)
+	if trimmed == "" {
+		return
+	}
+
+	c.mu.Lock()
+	c.mode = trimmed
+	c.mu.Unlock()
+
+	if c.recorder != nil {
+		c.recorder.SetMode(trimmed)
+	}
+}
+
+// Config returns the normalised configuration the controller operates with,
+// including suppression thresholds learned since it started.
+func (c *AdaptiveController) Config() Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cfg
+}
+
+// ResourceID returns the instance OCID the controller queries OCI Monitoring for.
+func (c *AdaptiveController) ResourceID() string {
+	return c.cfg.ResourceID
+}
+
+// SetDecisionObserver installs an observer notified after every slow-loop step.
+//
+// A nil observer disables notifications.
+func (c *AdaptiveController) SetDecisionObserver(observer DecisionObserver) {
+	c.mu.Lock()
+	c.observer = observer
+	c.mu.Unlock()
+}
+
+// SetLogger routes controller diagnostics to logger. A nil logger discards
+// them, which is the default.
+func (c *AdaptiveController) SetLogger(logger Logger) {
+	c.mu.Lock()
+	c.logger = logging.OrNop(logger)
+	c.mu.Unlock()
+}
+
+// SetPolicy replaces the slow-loop decision policy from the next step onwards,
+// so alternative policies can be tried without changing the state machine. A
+// nil policy restores the one selected by Config.Policy.
+func (c *AdaptiveController) SetPolicy(policy Policy) {
+	if policy == nil {
+		policy = c.configured
+	}
+
+	c.mu.Lock()
+	c.policy = policy
+	c.mu.Unlock()
+}
+
 func (c *AdaptiveController) consumeEstimator(ctx context.Context, ch <-chan est.Observation) {
 	for {
 		select {
@@ -296,15 +760,28 @@ func (c *AdaptiveController) consumeEstimator(ctx context.Context, ch <-chan est
 			}
 
 			c.handleObservation(observation)
+			c.notifySelfLoad()
 		}
 	}
 }
 
 func (c *AdaptiveController) handleObservation(observation est.Observation) {
+	defer c.notifyBurst()
+	defer c.notifySuppressLearning()
+	defer c.notifySuppressionEpisodes()
+
+	if observation.Err != nil {
+		defer c.notifyError(ErrorComponentEstimator, observation.Err)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if observation.Err != nil {
+		if c.lastEstErr == nil {
+			c.logger.Warn("host cpu observation failed", "error", observation.Err)
+		}
+
 		c.lastEstErr = observation.Err
 		c.updateEffectiveStateLocked()
 
@@ -313,16 +790,22 @@ func (c *AdaptiveController) handleObservation(observation est.Observation) {
 
 	c.lastEstErr = nil
 
+	c.flushPendingTargetLocked()
+	c.expireExternalHoldLocked()
+
+	utilisation := clamp(observation.Utilisation, 0, 1)
+	c.updateBurstLocked(observation.Timestamp, utilisation)
+
 	if c.cfg.SuppressThreshold <= 0 {
 		return
 	}
 
-	utilisation := clamp(observation.Utilisation, 0, 1)
 	if c.recorder != nil {
 		c.recorder.ObserveHostCPU(utilisation)
 	}
 
-	c.updateHostLoadLocked(utilisation)
+	c.updateHostLoadLocked(c.excludeSelfLoadLocked(observation, utilisation))
+	c.learnHostLoadLocked(observation.Timestamp)
 	previouslySuppressed := c.transitionSuppressionLocked()
 	c.applySuppressionTargetsLocked(previouslySuppressed)
 	c.updateEffectiveStateLocked()
@@ -339,12 +822,16 @@ func (c *AdaptiveController) updateHostLoadLocked(utilisation float64) {
 }
 
 func (c *AdaptiveController) transitionSuppressionLocked() bool {
-	previous := c.suppressed
+	previous := c.suppressedLocked()
 
 	if !c.suppressed && c.hostLoad >= c.cfg.SuppressThreshold {
 		c.suppressed = true
+		c.logger.Info("host load above suppress threshold; suppressing workers",
+			"hostLoad", c.hostLoad, "threshold", c.cfg.SuppressThreshold)
 	} else if c.suppressed && c.hostLoad <= c.cfg.SuppressResume {
 		c.suppressed = false
+		c.logger.Info("host load below resume threshold; lifting suppression",
+			"hostLoad", c.hostLoad, "resume", c.cfg.SuppressResume)
 	}
 
 	return previous
@@ -352,80 +839,261 @@ func (c *AdaptiveController) transitionSuppressionLocked() bool {
 
 func (c *AdaptiveController) applySuppressionTargetsLocked(previouslySuppressed bool) {
 	switch {
-	case c.suppressed:
+	case c.suppressedLocked():
 		c.applyTargetLocked(0)
 	case previouslySuppressed:
-		restore := c.desired
-		if restore == 0 {
-			restore = c.cfg.TargetStart
-		}
+		c.restoreTargetLocked(c.resumeTargetLocked())
+	}
+}
 
-		restore = clamp(restore, c.cfg.TargetMin, c.cfg.TargetMax)
-		c.applyTargetLocked(restore)
+// resumeTargetLocked returns the target lifting suppression restores: the
+// desired target, or TargetStart before the first step.
+func (c *AdaptiveController) resumeTargetLocked() float64 {
+	restore := c.desired
+	if restore == 0 {
+		restore = c.cfg.TargetStart
 	}
+
+	return clamp(restore, c.cfg.TargetMin, c.cfg.TargetMax)
 }
 
 func (c *AdaptiveController) step(ctx context.Context) time.Duration {
-	p95, err := c.metrics.QueryP95CPU(ctx, c.cfg.ResourceID)
+	nextInterval, _ := c.stepDecision(ctx)
+
+	return nextInterval
+}
+
+// stepDecision evaluates one slow-loop step under a fresh step ID and notifies
+// the decision, idle, suppression episode, and error handlers outside the
+// lock.
+func (c *AdaptiveController) stepDecision(ctx context.Context) (time.Duration, Decision) {
+	ctx = logging.WithStepID(ctx, newStepID())
+
+	nextInterval, decision := c.evaluate(ctx)
+	c.publishDecision(decision)
+	c.notifyIdle()
+	c.notifySuppressionEpisodes()
+
+	if decision.Err != nil && ctx.Err() == nil {
+		c.notifyError(ErrorComponentOCI, decision.Err)
+	}
+
+	return nextInterval, decision
+}
+
+func (c *AdaptiveController) publishDecision(decision Decision) {
+	c.mu.Lock()
+	observer := c.observer
+	c.mu.Unlock()
+
+	if observer == nil {
+		return
+	}
+
+	observer.ObserveDecision(decision)
+}
+
+// newStepID returns a random identifier for one slow-loop step.
+func newStepID() string {
+	return fmt.Sprintf("%016x", rand.Uint64()) //nolint:gosec // correlation needs no secrecy
+}
+
+func (c *AdaptiveController) decisionLocked(
+	stepID string,
+	p95 float64,
+	nextInterval time.Duration,
+	err error,
+) Decision {
+	return Decision{
+		StepID:       stepID,
+		Timestamp:    c.now(),
+		ResourceID:   c.cfg.ResourceID,
+		Mode:         c.mode,
+		State:        c.state,
+		P95:          p95,
+		Target:       c.target,
+		NextInterval: nextInterval,
+		Err:          err,
+	}
+}
+
+func (c *AdaptiveController) evaluate(ctx context.Context) (time.Duration, Decision) {
+	started := c.now()
+
+	p95, err := c.queryMetrics(ctx)
+	if c.detectPause(started, c.now(), c.cfg.Interval) {
+		// The process was suspended mid-query, so the answer predates the
+		// pause; decide on a fresh one instead.
+		p95, err = c.queryMetrics(ctx)
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if err != nil {
-		c.slowState = StateFallback
-		c.lastErr = err
-		fallback := clamp(c.cfg.FallbackTarget, c.cfg.TargetMin, c.cfg.TargetMax)
+	c.expireExternalHoldLocked()
+
+	// A blackout holds the target at zero like suppression, so the policy
+	// keeps stepping the desired target rather than the zero applied one.
+	suppressed := c.suppressedLocked() || c.blackout
+	result := decideStep(c.cfg, c.policy, stepInput{
+		Now:        c.now(),
+		P95:        p95,
+		Err:        err,
+		Target:     c.target,
+		Desired:    c.desired,
+		Suppressed: suppressed,
+		Pending:    c.hasPending,
+	})
+
+	logger := logging.WithStep(ctx, c.logger)
+
+	c.logQueryOutcomeLocked(logger, err, result.Desired)
+	c.stats.observeStep(err)
+	c.slowState = result.SlowState
+
+	recovered := err == nil && c.lastErr != nil
+	if err != nil && c.lastErr == nil {
+		c.fallbackFrom = c.desired
+	}
+
+	c.lastErr = err
+
+	if err == nil {
+		c.lastP95 = p95
+		c.recordP95Locked(p95)
+		c.updateIdleLocked(p95)
 
-		c.desired = fallback
-		if !c.suppressed {
-			c.applyTargetLocked(fallback)
+		if c.recorder != nil {
+			c.recorder.ObserveOCIP95(p95, time.Now())
 		}
+	} else {
+		p95 = 0
+	}
 
-		c.updateEffectiveStateLocked()
+	// While suppressed the shaper already sits at zero; the desired target is
+	// restored when suppression lifts.
+	c.desired = result.Desired
 
-		return c.cfg.Interval
+	switch {
+	case suppressed:
+	case recovered && result.Effective <= c.fallbackFrom:
+		c.restoreTargetLocked(result.Effective)
+	default:
+		c.applyTargetLocked(result.Effective)
 	}
 
-	c.slowState = StateNormal
-	c.lastErr = nil
+	c.updateEffectiveStateLocked()
+	logger.Debug(
+		"controller step",
+		"p95", p95,
+		"target", c.target,
+		"desired", c.desired,
+		"state", c.state.String(),
+		"nextInterval", result.NextInterval,
+	)
+
+	return result.NextInterval, c.decisionLocked(
+		logging.StepID(ctx), p95, result.NextInterval, err,
+	)
+}
 
-	c.lastP95 = p95
-	if c.recorder != nil {
-		c.recorder.ObserveOCIP95(p95, time.Now())
+// logQueryOutcomeLocked reports the first failed query of a run of failures and
+// the recovery that ends it, rather than every failed step.
+func (c *AdaptiveController) logQueryOutcomeLocked(
+	logger Logger,
+	err error,
+	desired float64,
+) {
+	switch {
+	case err != nil && c.lastErr == nil:
+		logger.Warn("oci metrics query failed; holding fallback target",
+			"error", err, "target", desired)
+	case err == nil && c.lastErr != nil:
+		logger.Info("oci metrics query recovered; resuming policy", "target", desired)
 	}
+}
+
+// applyTargetLocked moves the shaper to target, subject to the hourly change
+// budget. Reductions always apply so the host is never kept busier than asked;
+// increases beyond the budget are held as a pending target that later calls
+// either replace or, when the target returns to the applied value, discard.
+// A muted guardrail alarm raises the target to its floor first (see
+// SetAlarmSilence).
+func (c *AdaptiveController) applyTargetLocked(target float64) {
+	target = c.floorTargetLocked(target)
 
-	nextTarget := c.target
-	if c.suppressed {
-		nextTarget = c.desired
+	if c.cfg.MaxChangesPerHour <= 0 {
+		c.setTargetLocked(target)
+
+		return
 	}
 
-	if nextTarget == 0 {
-		nextTarget = c.cfg.TargetStart
+	c.hasPending = false
+
+	if target == c.target {
+		return
 	}
 
-	if p95 < c.cfg.GoalLow {
-		nextTarget += c.cfg.StepUp
-	} else if p95 > c.cfg.GoalHigh {
-		nextTarget -= c.cfg.StepDown
+	now := c.now()
+	c.pruneChangesLocked(now)
+
+	if target > c.target && len(c.changes) >= c.cfg.MaxChangesPerHour {
+		c.pendingTarget = target
+		c.hasPending = true
+		c.logger.Debug("target increase deferred by change budget",
+			"target", target, "applied", c.target, "maxChangesPerHour", c.cfg.MaxChangesPerHour)
+
+		return
 	}
 
-	nextTarget = clamp(nextTarget, c.cfg.TargetMin, c.cfg.TargetMax)
+	c.changes = append(c.changes, now)
+	c.setTargetLocked(target)
+}
+
+// restoreTargetLocked returns the shaper to the target it held before
+// suppression or fallback took it away. The change budget limits policy-driven
+// steps only, so a restore neither waits for budget nor spends it; otherwise a
+// host suppressed while the budget was used up would sit at zero for an hour.
+func (c *AdaptiveController) restoreTargetLocked(target float64) {
+	target = c.floorTargetLocked(target)
+	c.hasPending = false
 
-	c.desired = nextTarget
-	if !c.suppressed {
-		c.applyTargetLocked(nextTarget)
+	if target == c.target {
+		return
 	}
 
-	c.updateEffectiveStateLocked()
+	c.setTargetLocked(target)
+}
 
-	if p95 >= c.cfg.RelaxedThreshold {
-		return c.cfg.RelaxedInterval
+func (c *AdaptiveController) flushPendingTargetLocked() {
+	if !c.hasPending {
+		return
 	}
 
-	return c.cfg.Interval
+	c.applyTargetLocked(c.pendingTarget)
 }
 
-func (c *AdaptiveController) applyTargetLocked(target float64) {
+func (c *AdaptiveController) pruneChangesLocked(now time.Time) {
+	c.changes = pruneChanges(c.changes, now)
+}
+
+// pruneChanges drops the target changes older than the change budget window.
+func pruneChanges(changes []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-changeRateWindow)
+
+	kept := changes[:0]
+	for _, changedAt := range changes {
+		if changedAt.After(cutoff) {
+			kept = append(kept, changedAt)
+		}
+	}
+
+	return kept
+}
+
+func (c *AdaptiveController) setTargetLocked(target float64) {
+	target = c.ceilTargetLocked(target)
+	c.stats.observeTarget(c.now(), target)
 	c.target = target
 	c.shaper.SetTarget(target)
 
@@ -434,22 +1102,66 @@ func (c *AdaptiveController) applyTargetLocked(target float64) {
 	}
 }
 
-func (c *AdaptiveController) updateEffectiveStateLocked() {
-	if c.suppressed {
-		c.state = StateSuppressed
-		if c.recorder != nil {
-			c.recorder.SetState(c.state.String())
+// suppressedLocked reports whether either the estimator or an external request
+// currently holds the controller suppressed.
+func (c *AdaptiveController) suppressedLocked() bool {
+	return c.suppressed || c.externalHold
+}
+
+// refreshExternalHoldLocked drops expired suppression requests and recomputes
+// whether any remain outstanding.
+func (c *AdaptiveController) refreshExternalHoldLocked() {
+	now := c.now()
+
+	for source, until := range c.holds {
+		if !now.Before(until) {
+			delete(c.holds, source)
 		}
+	}
+
+	c.externalHold = len(c.holds) > 0
+}
+
+func (c *AdaptiveController) expireExternalHoldLocked() {
+	if !c.externalHold {
+		return
+	}
 
+	previouslySuppressed := c.suppressedLocked()
+	c.refreshExternalHoldLocked()
+
+	if c.externalHold {
 		return
 	}
 
+	c.applySuppressionTargetsLocked(previouslySuppressed)
+	c.updateEffectiveStateLocked()
+}
+
+func (c *AdaptiveController) updateEffectiveStateLocked() {
 	c.state = c.slowState
+	if c.suppressedLocked() {
+		c.state = StateSuppressed
+	}
+
+	c.stats.observeState(c.now(), c.state)
+	c.trackSuppressionEpisodeLocked()
+
 	if c.recorder != nil {
 		c.recorder.SetState(c.state.String())
 	}
 }
 
+// initialTarget returns the state a new controller starts in and the target
+// it applies until its first step.
+func initialTarget(cfg Config) (State, float64) {
+	if cfg.InitialState == StateNormal.String() {
+		return StateNormal, clamp(cfg.TargetStart, cfg.TargetMin, cfg.TargetMax)
+	}
+
+	return StateFallback, clamp(cfg.FallbackTarget, cfg.TargetMin, cfg.TargetMax)
+}
+
 func clamp(value, lower, upper float64) float64 {
 	if value < lower {
 		return lower
@@ -467,7 +1179,10 @@ type NoopController struct {
 	mode string
 }
 
-var _ Controller = (*NoopController)(nil)
+var (
+	_ Controller   = (*NoopController)(nil)
+	_ Introspector = (*NoopController)(nil)
+)
 
 // NewNoopController builds a controller that immediately returns without work.
 func NewNoopController(mode string) *NoopController {
@@ -494,6 +1209,12 @@ func (n *NoopController) LastError() error { return nil }
 // LastEstimatorError implements the Controller interface.
 func (n *NoopController) LastEstimatorError() error { return nil }
 
+// Target implements the Introspector interface; noop runs never apply load.
+func (n *NoopController) Target() float64 { return 0 }
+
+// LastP95 implements the Introspector interface; noop runs never query OCI.
+func (n *NoopController) LastP95() float64 { return 0 }
+
 func normalizeConfig(cfg Config) (Config, string, error) {
 	normalized, mode := coerceConfig(cfg)
 
@@ -531,6 +1252,12 @@ func coerceConfig(cfg Config) (Config, string) {
 
 	cfg.SuppressThreshold = clamp(cfg.SuppressThreshold, 0, 1)
 	cfg.SuppressResume = clamp(cfg.SuppressResume, 0, 1)
+	cfg.MaxChangesPerHour = max(cfg.MaxChangesPerHour, 0)
+	cfg.Policy = normalizePolicyName(cfg.Policy)
+	cfg.InitialState = strings.ToLower(strings.TrimSpace(cfg.InitialState))
+	cfg.PID.Proportional = ensureFloat(cfg.PID.Proportional, defaults.PID.Proportional)
+	cfg.PID.Integral = ensureFloat(cfg.PID.Integral, defaults.PID.Integral)
+	cfg.SuppressLearning = coerceSuppressLearning(cfg.SuppressLearning)
 
 	if cfg.SuppressResume >= cfg.SuppressThreshold && cfg.SuppressThreshold > 0 {
 		cfg.SuppressResume = math.Max(cfg.SuppressThreshold*suppressResumeScale, 0)
@@ -545,6 +1272,39 @@ func coerceConfig(cfg Config) (Config, string) {
 }
 
 func validateControllerConfig(cfg Config) error {
+	err := validatePolicyConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = validateBlackoutWindows(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = validateSuppressLearning(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.InitialState != "" && cfg.InitialState != StateFallback.String() &&
+		cfg.InitialState != StateNormal.String() {
+		return fmt.Errorf(
+			"%w: controller.initialState must be fallback or normal, got %q",
+			ErrInvalidConfig,
+			cfg.InitialState,
+		)
+	}
+
+	if cfg.TargetMin > cfg.TargetMax {
+		return fmt.Errorf(
+			"%w: controller.targetMin (%.2f) must not exceed controller.targetMax (%.2f)",
+			ErrInvalidConfig,
+			cfg.TargetMin,
+			cfg.TargetMax,
+		)
+	}
+
 	thresholds := []struct {
 		name  string
 		value float64
@@ -2,32 +2,32 @@
 package adapt
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"testing"
 	"time"
 
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/adapt/adapttest"
 	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/logging"
+	"oci-cpu-shaper/pkg/oci"
 )
 
 var (
-	errNoResultsConfigured  = errors.New("test: no results configured")
 	errOCIDown              = errors.New("test: oci down")
 	errEstimatorObservation = errors.New("test: estimator observation failure")
 )
 
-type metricResult struct {
-	value float64
-	err   error
-}
-
 type controllerScenario struct {
 	name         string
-	results      []metricResult
+	results      []adapttest.Result
 	expectations []stepExpectation
 }
 
@@ -41,70 +41,16 @@ type controllerStepper interface {
 	step(ctx context.Context) time.Duration
 }
 
-type fakeMetrics struct {
-	results   []metricResult
-	callIndex int
-	mu        sync.Mutex
-}
-
-func newFakeMetrics(results []metricResult) *fakeMetrics {
-	copied := make([]metricResult, len(results))
-	copy(copied, results)
-
-	return &fakeMetrics{results: copied, callIndex: 0, mu: sync.Mutex{}}
-}
-
-func (f *fakeMetrics) QueryP95CPU(ctx context.Context, _ string) (float64, error) {
-	if len(f.results) == 0 {
-		return 0, errNoResultsConfigured
-	}
-
-	err := ctx.Err()
-	if err != nil {
-		return 0, fmt.Errorf("query p95 context: %w", err)
-	}
-
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	if f.callIndex >= len(f.results) {
-		last := f.results[len(f.results)-1]
-
-		return last.value, last.err
-	}
-
-	result := f.results[f.callIndex]
-	f.callIndex++
-
-	return result.value, result.err
-}
-
-type fakeShaper struct {
-	target float64
-	calls  []float64
-}
-
-func newFakeShaper() *fakeShaper {
-	return &fakeShaper{target: 0, calls: make([]float64, 0)}
-}
-
-func (f *fakeShaper) SetTarget(v float64) {
-	f.target = v
-	f.calls = append(f.calls, v)
-}
-
-func (f *fakeShaper) Target() float64 { return f.target }
-
 func TestControllerStateTransitions(t *testing.T) {
 	t.Parallel()
 
 	scenarios := []controllerScenario{
 		{
 			name: "success then fallback recovery",
-			results: []metricResult{
-				{value: 0.20, err: nil},
-				{value: 0, err: errOCIDown},
-				{value: 0.29, err: nil},
+			results: []adapttest.Result{
+				{Value: 0.20, Err: nil},
+				{Value: 0, Err: errOCIDown},
+				{Value: 0.29, Err: nil},
 			},
 			expectations: []stepExpectation{
 				{state: StateNormal, target: 0.27, nextInterval: time.Hour},
@@ -114,9 +60,9 @@ func TestControllerStateTransitions(t *testing.T) {
 		},
 		{
 			name: "clamps within bounds",
-			results: []metricResult{
-				{value: 0.10, err: nil},
-				{value: 0.50, err: nil},
+			results: []adapttest.Result{
+				{Value: 0.10, Err: nil},
+				{Value: 0.50, Err: nil},
 			},
 			expectations: []stepExpectation{
 				{state: StateNormal, target: 0.27, nextInterval: time.Hour},
@@ -144,14 +90,14 @@ func TestControllerCpuUtilisationAcrossOCPUs(t *testing.T) {
 	// bursts.
 	highUtilisationScenario := controllerScenario{
 		name: "baseline ocpu burst",
-		results: []metricResult{
-			{value: 0.15, err: nil},
-			{value: 0.32, err: nil},
-			{value: 0.34, err: nil},
-			{value: 0.36, err: nil},
-			{value: 0.38, err: nil},
-			{value: 0.40, err: nil},
-			{value: 0.45, err: nil},
+		results: []adapttest.Result{
+			{Value: 0.15, Err: nil},
+			{Value: 0.32, Err: nil},
+			{Value: 0.34, Err: nil},
+			{Value: 0.36, Err: nil},
+			{Value: 0.38, Err: nil},
+			{Value: 0.40, Err: nil},
+			{Value: 0.45, Err: nil},
 		},
 		expectations: []stepExpectation{
 			{state: StateNormal, target: 0.27, nextInterval: time.Hour},
@@ -178,7 +124,7 @@ func TestControllerCpuUtilisationAcrossOCPUs(t *testing.T) {
 		t.Run(shapeCase.name, func(t *testing.T) {
 			t.Parallel()
 
-			results := append([]metricResult(nil), highUtilisationScenario.results...)
+			results := append([]adapttest.Result(nil), highUtilisationScenario.results...)
 			expectations := append([]stepExpectation(nil), highUtilisationScenario.expectations...)
 
 			scenario := controllerScenario{
@@ -195,8 +141,8 @@ func TestControllerCpuUtilisationAcrossOCPUs(t *testing.T) {
 func runControllerScenario(t *testing.T, scenario controllerScenario) {
 	t.Helper()
 
-	metrics := newFakeMetrics(scenario.results)
-	shaper := newFakeShaper()
+	metrics := adapttest.NewMetricsClient(scenario.results...)
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 	cfg.Interval = time.Hour
 	cfg.RelaxedInterval = 6 * time.Hour
@@ -254,8 +200,8 @@ func runControllerScenario(t *testing.T, scenario controllerScenario) {
 func TestAdaptiveControllerRecordsLastError(t *testing.T) {
 	t.Parallel()
 
-	metrics := newFakeMetrics([]metricResult{{value: 0, err: errOCIDown}})
-	shaper := newFakeShaper()
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0, Err: errOCIDown})
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 
 	controller, err := NewAdaptiveController(cfg, metrics, nil, shaper, nil)
@@ -279,8 +225,8 @@ func TestAdaptiveControllerRecordsLastError(t *testing.T) {
 func TestConsumeEstimatorSuppression(t *testing.T) {
 	t.Parallel()
 
-	metrics := newFakeMetrics([]metricResult{{value: 0.25, err: nil}})
-	shaper := newFakeShaper()
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil})
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 	cfg.SuppressThreshold = 0.8
 	cfg.SuppressResume = 0.5
@@ -320,19 +266,129 @@ func TestConsumeEstimatorSuppression(t *testing.T) {
 		)
 	}
 
-	if len(shaper.calls) < 2 {
+	if len(shaper.Calls()) < 2 {
 		t.Fatalf(
 			"expected shaper to be called for suppression transitions, got %d calls",
-			len(shaper.calls),
+			len(shaper.Calls()),
+		)
+	}
+}
+
+func TestTargetChangeRateLimitCoalescesFlaps(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.10, Err: nil})
+	shaper := adapttest.NewDutyCycler()
+	cfg := DefaultConfig()
+	cfg.SuppressThreshold = 0.8
+	cfg.SuppressResume = 0.5
+	cfg.MaxChangesPerHour = 2
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	// Each flap spends budget on the drop; the restore is exempt so the host
+	// never idles at zero once suppression lifts.
+	feedObservation(controller, 0, 0.95, nil)
+	requireFloatApprox(t, "suppressed target", controller.Target(), 0)
+
+	for i := 0; i < 10 && controller.State() == StateSuppressed; i++ {
+		feedObservation(controller, int64(1+i), 0.05, nil)
+	}
+
+	requireFloatApprox(t, "restored target", controller.Target(), cfg.FallbackTarget)
+
+	for i := 0; i < 10 && controller.State() != StateSuppressed; i++ {
+		feedObservation(controller, int64(20+i), 0.99, nil)
+	}
+
+	requireFloatApprox(t, "second suppression", controller.Target(), 0)
+
+	for i := 0; i < 10 && controller.State() == StateSuppressed; i++ {
+		feedObservation(controller, int64(40+i), 0.05, nil)
+	}
+
+	requireFloatApprox(t, "restore despite spent budget", controller.Target(), cfg.FallbackTarget)
+
+	callsBefore := len(shaper.Calls())
+
+	controller.step(context.Background())
+	requireFloatApprox(t, "slow-loop increase held", controller.Target(), cfg.FallbackTarget)
+	requireEqual(t, "shaper calls while budget exhausted", len(shaper.Calls()), callsBefore)
+
+	now = now.Add(changeRateWindow + time.Second)
+
+	feedObservation(controller, 100, 0.05, nil)
+	requireFloatApprox(t, "pending target applied", controller.Target(), 0.27)
+}
+
+func TestTargetChangeRateLimitExemptsFallbackRecovery(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.10, Err: nil},
+		adapttest.Result{Value: 0, Err: errOCIDown},
+		adapttest.Result{Value: 0.10, Err: nil},
+	)
+	shaper := adapttest.NewDutyCycler()
+	cfg := DefaultConfig()
+	cfg.FallbackTarget = cfg.TargetMin
+	cfg.MaxChangesPerHour = 1
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	controller.step(context.Background())
+
+	healthy := controller.Target()
+	if healthy <= cfg.FallbackTarget {
+		t.Fatalf("expected a healthy target above the fallback, got %.2f", healthy)
+	}
+
+	controller.step(context.Background())
+	requireFloatApprox(t, "fallback target", controller.Target(), cfg.FallbackTarget)
+
+	controller.step(context.Background())
+
+	if controller.Target() <= cfg.FallbackTarget || controller.Target() > healthy+cfg.StepUp {
+		t.Fatalf(
+			"expected recovery to leave the fallback despite the budget, got %.2f",
+			controller.Target(),
 		)
 	}
 }
 
+func TestTargetChangeRateLimitDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	requireEqual(t, "default max changes", cfg.MaxChangesPerHour, 0)
+
+	cfg.MaxChangesPerHour = -3
+
+	normalized, _, err := normalizeConfig(cfg)
+	if err != nil {
+		t.Fatalf("normalizeConfig: %v", err)
+	}
+
+	requireEqual(t, "normalized max changes", normalized.MaxChangesPerHour, 0)
+}
+
 func TestConsumeEstimatorHandlesErrors(t *testing.T) {
 	t.Parallel()
 
-	metrics := newFakeMetrics([]metricResult{{value: 0.25, err: nil}})
-	shaper := newFakeShaper()
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil})
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 
 	controller, err := NewAdaptiveController(cfg, metrics, nil, shaper, nil)
@@ -365,26 +421,24 @@ func TestConsumeEstimatorHandlesErrors(t *testing.T) {
 func TestAdaptiveControllerRunLifecycle(t *testing.T) {
 	t.Parallel()
 
-	metrics := newFakeMetrics([]metricResult{{value: 0.24, err: nil}, {value: 0.26, err: nil}})
-	shaper := newFakeShaper()
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.24, Err: nil},
+		adapttest.Result{Value: 0.26, Err: nil},
+	)
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 	cfg.Interval = 5 * time.Millisecond
 	cfg.RelaxedInterval = 10 * time.Millisecond
 	cfg.Mode = "  enforce  "
 	cfg.ResourceID = "resource"
 
-	estimator := &fakeEstimator{
-		observations: []est.Observation{
-			{
-				Timestamp:    time.Unix(0, 0),
-				Utilisation:  0.5,
-				BusyJiffies:  0,
-				TotalJiffies: 0,
-				Err:          nil,
-			},
-		},
-		consumed: atomic.Int32{},
-	}
+	estimator := adapttest.NewEstimator(est.Observation{
+		Timestamp:    time.Unix(0, 0),
+		Utilisation:  0.5,
+		BusyJiffies:  0,
+		TotalJiffies: 0,
+		Err:          nil,
+	})
 
 	controller, err := NewAdaptiveController(cfg, metrics, estimator, shaper, nil)
 	if err != nil {
@@ -418,17 +472,58 @@ func TestAdaptiveControllerRunLifecycle(t *testing.T) {
 		t.Fatalf("expected last p95 to be recorded")
 	}
 
-	if estimator.consumed.Load() == 0 {
+	if estimator.Consumed() == 0 {
 		t.Fatalf("expected estimator observations to be consumed")
 	}
 }
 
+func TestAdaptiveControllerRequestStepRunsImmediately(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.24, Err: nil})
+	cfg := DefaultConfig()
+	cfg.ResourceID = "resource"
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, adapttest.NewDutyCycler(), nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	pending, cancelPending := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelPending()
+
+	_, err = controller.RequestStep(pending)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error while Run is idle, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = controller.Run(ctx)
+	}()
+
+	decision, err := controller.RequestStep(ctx)
+	if err != nil {
+		t.Fatalf("RequestStep: %v", err)
+	}
+
+	if decision.State != StateNormal || math.Abs(decision.P95-0.24) > 1e-9 {
+		t.Fatalf("unexpected forced decision: %+v", decision)
+	}
+
+	if controller.LastP95() != decision.P95 {
+		t.Fatalf("expected forced step to record p95, got %.2f", controller.LastP95())
+	}
+}
+
 func TestAdaptiveControllerEmitsMetricsSignals(t *testing.T) {
 	t.Parallel()
 
-	recorder := newStubMetricsRecorder()
-	metrics := newFakeMetrics([]metricResult{{value: 0.20, err: nil}})
-	shaper := newFakeShaper()
+	recorder := adapttest.NewRecorder()
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.20, Err: nil})
+	shaper := adapttest.NewDutyCycler()
 	cfg := DefaultConfig()
 	cfg.Mode = "  enforce  "
 
@@ -437,15 +532,17 @@ func TestAdaptiveControllerEmitsMetricsSignals(t *testing.T) {
 		t.Fatalf("NewAdaptiveController: %v", err)
 	}
 
-	requirePositiveInt(t, "modeCalls", recorder.modeCalls)
-	requireEqual(t, "mode", recorder.mode, "enforce")
-	requireEqual(t, "initialState", recorder.state, StateFallback.String())
-	requireFloatApprox(t, "initialTarget", recorder.target, cfg.FallbackTarget)
+	signals := recorder.Snapshot()
+	requirePositiveInt(t, "modeCalls", signals.ModeCalls)
+	requireEqual(t, "mode", signals.Mode, "enforce")
+	requireEqual(t, "initialState", signals.State, StateFallback.String())
+	requireFloatApprox(t, "initialTarget", signals.Target, cfg.FallbackTarget)
 
 	feedObservation(controller, 0, 0.75, nil)
 
-	requirePositiveInt(t, "hostCalls", recorder.hostCalls)
-	requireFloatApprox(t, "hostUtilisation", recorder.host, 0.75)
+	signals = recorder.Snapshot()
+	requirePositiveInt(t, "hostCalls", signals.HostCalls)
+	requireFloatApprox(t, "hostUtilisation", signals.HostCPU, 0.75)
 
 	stepper, ok := any(controller).(controllerStepper)
 	if !ok {
@@ -454,69 +551,191 @@ func TestAdaptiveControllerEmitsMetricsSignals(t *testing.T) {
 
 	stepper.step(context.Background())
 
-	requirePositiveInt(t, "ociCalls", recorder.ociCalls)
-	requireFloatApprox(t, "ociValue", recorder.ociValue, 0.20)
-	requireNotZeroTime(t, "ociTime", recorder.ociTime)
-	requireEqual(t, "stateAfterStep", recorder.state, StateNormal.String())
-	requireFloatApprox(t, "targetAfterStep", recorder.target, shaper.Target())
+	signals = recorder.Snapshot()
+	requirePositiveInt(t, "ociCalls", signals.OCICalls)
+	requireFloatApprox(t, "ociValue", signals.OCIP95, 0.20)
+	requireNotZeroTime(t, "ociTime", signals.OCIFetchedAt)
+	requireEqual(t, "stateAfterStep", signals.State, StateNormal.String())
+	requireFloatApprox(t, "targetAfterStep", signals.Target, shaper.Target())
 }
 
-type stubMetricsRecorder struct {
-	mu          sync.Mutex
-	mode        string
-	modeCalls   int
-	state       string
-	stateCalls  int
-	target      float64
-	targetCalls int
-	ociValue    float64
-	ociTime     time.Time
-	ociCalls    int
-	host        float64
-	hostCalls   int
+func TestAdaptiveControllerPublishesDecisions(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.29, Err: nil},
+		adapttest.Result{Value: 0, Err: errOCIDown},
+	)
+	shaper := adapttest.NewDutyCycler()
+	cfg := DefaultConfig()
+	cfg.ResourceID = "ocid1.instance.oc1..decision"
+	cfg.Mode = "enforce"
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if got := controller.ResourceID(); got != cfg.ResourceID {
+		t.Fatalf("expected resource ID %q, got %q", cfg.ResourceID, got)
+	}
+
+	fixed := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return fixed }
+
+	observer := new(recordingDecisionObserver)
+	controller.SetDecisionObserver(observer)
+
+	controller.step(context.Background())
+	controller.step(context.Background())
+
+	decisions := observer.snapshot()
+	if len(decisions) != 2 {
+		t.Fatalf("expected two decisions, got %d", len(decisions))
+	}
+
+	first := decisions[0]
+	requireEqual(t, "timestamp", first.Timestamp, fixed)
+	requireEqual(t, "resourceID", first.ResourceID, cfg.ResourceID)
+	requireEqual(t, "mode", first.Mode, "enforce")
+	requireEqual(t, "state", first.State, StateNormal)
+	requireFloatApprox(t, "p95", first.P95, 0.29)
+	requireFloatApprox(t, "target", first.Target, 0.25)
+	requireEqual(t, "nextInterval", first.NextInterval, cfg.RelaxedInterval)
+
+	if first.Err != nil {
+		t.Fatalf("expected no error on successful step, got %v", first.Err)
+	}
+
+	second := decisions[1]
+	requireEqual(t, "fallbackState", second.State, StateFallback)
+	requireEqual(t, "fallbackInterval", second.NextInterval, cfg.Interval)
+
+	if !errors.Is(second.Err, errOCIDown) {
+		t.Fatalf("expected fallback decision to carry errOCIDown, got %v", second.Err)
+	}
+
+	controller.SetDecisionObserver(nil)
+	controller.step(context.Background())
+
+	if len(observer.snapshot()) != 2 {
+		t.Fatal("expected observer removal to stop notifications")
+	}
 }
 
-func newStubMetricsRecorder() *stubMetricsRecorder { return new(stubMetricsRecorder) }
+// stepTrackingMetrics records the step ID each query was issued under.
+type stepTrackingMetrics struct {
+	*adapttest.MetricsClient
+
+	steps []string
+}
 
-func (s *stubMetricsRecorder) SetMode(mode string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *stepTrackingMetrics) QueryP95CPU(ctx context.Context, resourceID string) (float64, error) {
+	s.steps = append(s.steps, logging.StepID(ctx))
 
-	s.mode = mode
-	s.modeCalls++
+	return s.MetricsClient.QueryP95CPU(ctx, resourceID)
 }
 
-func (s *stubMetricsRecorder) SetState(state string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func TestAdaptiveControllerTagsEachStepWithAnID(t *testing.T) {
+	t.Parallel()
+
+	metrics := &stepTrackingMetrics{
+		MetricsClient: adapttest.NewMetricsClient(
+			adapttest.Result{Value: 0.29, Err: nil},
+			adapttest.Result{Value: 0, Err: errOCIDown},
+		),
+		steps: nil,
+	}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(), metrics, nil, adapttest.NewDutyCycler(), nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	var buffer bytes.Buffer
 
-	s.state = state
-	s.stateCalls++
+	controller.SetLogger(slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       slog.LevelDebug,
+		ReplaceAttr: nil,
+	})))
+
+	observer := new(recordingDecisionObserver)
+	controller.SetDecisionObserver(observer)
+
+	controller.step(context.Background())
+	controller.step(context.Background())
+
+	decisions := observer.snapshot()
+	if len(decisions) != 2 || len(decisions[0].StepID) != 16 ||
+		decisions[0].StepID == decisions[1].StepID {
+		t.Fatalf("expected two decisions with distinct step IDs, got %+v", decisions)
+	}
+
+	if len(metrics.steps) != 2 || metrics.steps[0] != decisions[0].StepID ||
+		metrics.steps[1] != decisions[1].StepID {
+		t.Fatalf("expected each query issued under its step ID, got %v", metrics.steps)
+	}
+
+	for _, want := range []string{
+		"msg=\"controller step\" step=" + decisions[0].StepID,
+		"msg=\"oci metrics query failed; holding fallback target\" step=" + decisions[1].StepID,
+		"msg=\"controller step\" step=" + decisions[1].StepID,
+	} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Fatalf("expected %q in the log, got %s", want, buffer.String())
+		}
+	}
 }
 
-func (s *stubMetricsRecorder) SetTarget(target float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func TestDecisionObserversFanOutInOrder(t *testing.T) {
+	t.Parallel()
+
+	first := new(recordingDecisionObserver)
+	second := new(recordingDecisionObserver)
+	decision := Decision{
+		StepID:       "",
+		Timestamp:    time.Unix(0, 0),
+		ResourceID:   "ocid1.instance",
+		Mode:         "dry-run",
+		State:        StateNormal,
+		P95:          0.2,
+		Target:       0.3,
+		NextInterval: time.Minute,
+		Err:          nil,
+	}
+
+	DecisionObservers{first, second}.ObserveDecision(decision)
 
-	s.target = target
-	s.targetCalls++
+	for name, observer := range map[string]*recordingDecisionObserver{
+		"first":  first,
+		"second": second,
+	} {
+		if got := observer.snapshot(); len(got) != 1 || got[0] != decision {
+			t.Fatalf("expected %s observer to receive %+v, got %+v", name, decision, got)
+		}
+	}
+}
+
+type recordingDecisionObserver struct {
+	mu        sync.Mutex
+	decisions []Decision
 }
 
-func (s *stubMetricsRecorder) ObserveOCIP95(value float64, fetchedAt time.Time) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (r *recordingDecisionObserver) ObserveDecision(decision Decision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	s.ociValue = value
-	s.ociTime = fetchedAt
-	s.ociCalls++
+	r.decisions = append(r.decisions, decision)
 }
 
-func (s *stubMetricsRecorder) ObserveHostCPU(utilisation float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (r *recordingDecisionObserver) snapshot() []Decision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	s.host = utilisation
-	s.hostCalls++
+	return append([]Decision(nil), r.decisions...)
 }
 
 func requireEqual[T comparable](t *testing.T, name string, got, want T) {
@@ -730,6 +949,36 @@ func TestNormalizeConfigValidatesThresholds(t *testing.T) {
 	}
 }
 
+func TestAdaptiveControllerStartsInConfiguredState(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.TargetStart = 0.33
+	cfg.InitialState = " Normal "
+
+	shaper := adapttest.NewDutyCycler()
+	recorder := adapttest.NewRecorder()
+
+	controller, err := NewAdaptiveController(
+		cfg, adapttest.NewMetricsClient(), nil, shaper, recorder,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	requireEqual(t, "state", controller.State(), StateNormal)
+	requireFloatApprox(t, "target", controller.Target(), 0.33)
+	requireFloatApprox(t, "shaper target", shaper.Target(), 0.33)
+	requireEqual(t, "recorded state", recorder.Snapshot().State, "normal")
+
+	cfg.InitialState = "suppressed"
+
+	_, _, err = normalizeConfig(cfg)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig for an unknown initial state, got %v", err)
+	}
+}
+
 func feedObservation(controller *AdaptiveController, ts int64, utilisation float64, err error) {
 	controller.handleObservation(est.Observation{
 		Timestamp:    time.Unix(ts, 0),
@@ -740,20 +989,501 @@ func feedObservation(controller *AdaptiveController, ts int64, utilisation float
 	})
 }
 
-type fakeEstimator struct {
-	observations []est.Observation
-	consumed     atomic.Int32
+type snapshotEstimator struct {
+	adapttest.Estimator
+
+	current est.Observation
+}
+
+func (s *snapshotEstimator) Current() (est.Observation, bool) {
+	return s.current, true
 }
 
-func (f *fakeEstimator) Run(context.Context) <-chan est.Observation {
-	observationsCh := make(chan est.Observation, len(f.observations))
-	for _, observation := range f.observations {
-		observationsCh <- observation
+func TestAdaptiveControllerCurrentObservation(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.ResourceID = "ocid1.instance.oc1..snapshot"
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		new(adapttest.Estimator),
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if _, ok := controller.CurrentObservation(); ok {
+		t.Fatal("expected no snapshot from an estimator without on-demand reads")
+	}
 
-		f.consumed.Add(1)
+	estimator := new(snapshotEstimator)
+	estimator.current = est.Observation{
+		Timestamp:    time.Unix(42, 0),
+		Utilisation:  0.37,
+		BusyJiffies:  37,
+		TotalJiffies: 100,
+		Err:          nil,
 	}
 
-	close(observationsCh)
+	controller, err = NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		estimator,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
 
-	return observationsCh
+	observation, ok := controller.CurrentObservation()
+	if !ok || observation != estimator.current {
+		t.Fatalf("expected snapshot %+v, got %+v (ok=%v)", estimator.current, observation, ok)
+	}
+}
+
+type onDemandEstimator struct {
+	adapttest.Estimator
+
+	sample est.Observation
+	err    error
+}
+
+func (o *onDemandEstimator) SampleNow(context.Context) (est.Observation, error) {
+	return o.sample, o.err
+}
+
+func TestAdaptiveControllerSampleHostCPU(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		new(adapttest.Estimator),
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	_, err = controller.SampleHostCPU(t.Context())
+	if !errors.Is(err, ErrOnDemandUnsupported) {
+		t.Fatalf("expected ErrOnDemandUnsupported, got %v", err)
+	}
+
+	estimator := new(onDemandEstimator)
+	estimator.sample.Utilisation = 0.42
+
+	controller, err = NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		estimator,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	observation, err := controller.SampleHostCPU(t.Context())
+	if err != nil || observation.Utilisation != 0.42 {
+		t.Fatalf("expected utilisation 0.42, got %+v (err=%v)", observation, err)
+	}
+
+	estimator.err = errEstimatorObservation
+
+	_, err = controller.SampleHostCPU(t.Context())
+	if !errors.Is(err, errEstimatorObservation) {
+		t.Fatalf("expected the estimator error, got %v", err)
+	}
+}
+
+type restartableEstimator struct {
+	adapttest.Estimator
+
+	handler func(failures int, lastErr error)
+}
+
+func (r *restartableEstimator) SetRestartHandler(handler func(failures int, lastErr error)) {
+	r.handler = handler
+}
+
+func TestAdaptiveControllerForwardsEstimatorRestartHandler(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil})
+	estimator := new(restartableEstimator)
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		estimator,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	var calls int
+
+	if !controller.SetEstimatorRestartHandler(func(int, error) { calls++ }) {
+		t.Fatal("expected restartable estimator to accept the handler")
+	}
+
+	estimator.handler(5, errEstimatorObservation)
+	requireEqual(t, "restart handler calls", calls, 1)
+
+	plain, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		new(adapttest.Estimator),
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if plain.SetEstimatorRestartHandler(func(int, error) {}) {
+		t.Fatal("expected estimator without restart support to reject the handler")
+	}
+}
+
+type droppingEstimator struct {
+	adapttest.Estimator
+
+	handler func()
+}
+
+func (d *droppingEstimator) SetDropHandler(handler func()) {
+	d.handler = handler
+}
+
+func TestAdaptiveControllerForwardsObservationDropHandler(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil})
+	estimator := new(droppingEstimator)
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		estimator,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	var calls int
+
+	if !controller.SetObservationDropHandler(func() { calls++ }) {
+		t.Fatal("expected dropping estimator to accept the handler")
+	}
+
+	estimator.handler()
+	requireEqual(t, "drop handler calls", calls, 1)
+
+	plain, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		new(adapttest.Estimator),
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if plain.SetObservationDropHandler(func() {}) {
+		t.Fatal("expected estimator without drop reporting to reject the handler")
+	}
+}
+
+func TestAdaptiveControllerConfigReturnsNormalisedValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.GoalLow = 0
+	cfg.MaxChangesPerHour = -1
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil}),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	active := controller.Config()
+	requireFloatApprox(t, "goal low", active.GoalLow, DefaultConfig().GoalLow)
+	requireEqual(t, "max changes", active.MaxChangesPerHour, 0)
+}
+
+func TestRequestSuppressionHoldsUntilExpiry(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil})
+	shaper := adapttest.NewDutyCycler()
+
+	controller, err := NewAdaptiveController(DefaultConfig(), metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	controller.RequestSuppression("api", now.Add(30*time.Minute))
+	controller.RequestSuppression("file", now.Add(time.Hour))
+
+	if controller.State() != StateSuppressed {
+		t.Fatalf("expected suppressed state after external request, got %v", controller.State())
+	}
+
+	requireFloatApprox(t, "suppressed target", controller.Target(), 0)
+
+	controller.RequestSuppression("file", time.Time{})
+
+	if got := controller.SuppressionRequests(); len(got) != 1 {
+		t.Fatalf("expected only the api request to remain, got %v", got)
+	}
+
+	now = now.Add(20 * time.Minute)
+	feedObservation(controller, 0, 0.1, nil)
+
+	if controller.State() != StateSuppressed {
+		t.Fatalf("expected suppression to hold before expiry, got %v", controller.State())
+	}
+
+	now = now.Add(10 * time.Minute)
+	feedObservation(controller, 1, 0.1, nil)
+
+	if controller.State() != StateFallback {
+		t.Fatalf("expected fallback state after expiry, got %v", controller.State())
+	}
+
+	requireFloatApprox(t, "restored target", controller.Target(), DefaultConfig().FallbackTarget)
+
+	if got := controller.SuppressionRequests(); len(got) != 0 {
+		t.Fatalf("expected expired requests to be dropped, got %v", got)
+	}
+}
+
+type skewTrackingMetrics struct {
+	*adapttest.MetricsClient
+
+	handler func(skew time.Duration)
+}
+
+func (s *skewTrackingMetrics) SetClockSkewHandler(handler func(skew time.Duration)) {
+	s.handler = handler
+}
+
+func TestSetClockSkewHandlerForwardsToMetricsClient(t *testing.T) {
+	t.Parallel()
+
+	metrics := &skewTrackingMetrics{MetricsClient: adapttest.NewMetricsClient()}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if !controller.SetClockSkewHandler(func(time.Duration) {}) || metrics.handler == nil {
+		t.Fatal("expected skew-tracking metrics client to accept the handler")
+	}
+
+	plain, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if plain.SetClockSkewHandler(func(time.Duration) {}) {
+		t.Fatal("expected metrics client without skew tracking to reject the handler")
+	}
+}
+
+type tokenTrackingMetrics struct {
+	*adapttest.MetricsClient
+
+	handler func(expiry time.Time)
+}
+
+func (s *tokenTrackingMetrics) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	s.handler = handler
+}
+
+func TestSetTokenExpiryHandlerForwardsToMetricsClient(t *testing.T) {
+	t.Parallel()
+
+	metrics := &tokenTrackingMetrics{MetricsClient: adapttest.NewMetricsClient()}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if !controller.SetTokenExpiryHandler(func(time.Time) {}) || metrics.handler == nil {
+		t.Fatal("expected token-tracking metrics client to accept the handler")
+	}
+
+	plain, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if plain.SetTokenExpiryHandler(func(time.Time) {}) {
+		t.Fatal("expected metrics client without token tracking to reject the handler")
+	}
+}
+
+type windowTrackingMetrics struct {
+	*adapttest.MetricsClient
+
+	handler func(window oci.QueryWindow)
+}
+
+func (s *windowTrackingMetrics) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	s.handler = handler
+}
+
+func TestSetQueryWindowHandlerForwardsToMetricsClient(t *testing.T) {
+	t.Parallel()
+
+	metrics := &windowTrackingMetrics{MetricsClient: adapttest.NewMetricsClient()}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if !controller.SetQueryWindowHandler(func(oci.QueryWindow) {}) || metrics.handler == nil {
+		t.Fatal("expected window-tracking metrics client to accept the handler")
+	}
+
+	plain, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if plain.SetQueryWindowHandler(func(oci.QueryWindow) {}) {
+		t.Fatal("expected metrics client without window tracking to reject the handler")
+	}
+}
+
+func TestAdaptiveControllerSetModeUpdatesRecorder(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.Mode = "dry-run"
+	recorder := adapttest.NewRecorder()
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		recorder,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	controller.SetMode(" ")
+	controller.SetMode("enforce")
+
+	signals := recorder.Snapshot()
+	if controller.Mode() != "enforce" || signals.Mode != "enforce" || signals.ModeCalls != 2 {
+		t.Fatalf(
+			"expected promotion to enforce, got %q (recorder %q after %d calls)",
+			controller.Mode(),
+			signals.Mode,
+			signals.ModeCalls,
+		)
+	}
+}
+
+func TestAdaptiveControllerLogsFallbackTransitionsOnce(t *testing.T) {
+	t.Parallel()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(
+			adapttest.Result{Value: 0.3},
+			adapttest.Result{Err: errOCIDown},
+			adapttest.Result{Err: errOCIDown},
+			adapttest.Result{Value: 0.3},
+		),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	logger := new(logtest.Recorder)
+	controller.SetLogger(logger)
+
+	for range 4 {
+		controller.step(context.Background())
+	}
+
+	want := []string{
+		"warn: oci metrics query failed; holding fallback target",
+		"info: oci metrics query recovered; resuming policy",
+	}
+
+	if fmt.Sprint(logger.Lines()) != fmt.Sprint(want) {
+		t.Fatalf("unexpected log entries %q", logger.Lines())
+	}
 }
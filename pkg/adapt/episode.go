@@ -0,0 +1,185 @@
+package adapt
+
+import (
+	"slices"
+	"time"
+)
+
+// SuppressionEpisodeHistorySize is the number of finished suppression
+// episodes the controller keeps for SuppressionEpisodes.
+const SuppressionEpisodeHistorySize = 32
+
+// SuppressionSourceHostLoad names the estimator's host load check among the
+// sources of a SuppressionEpisode; external requests use their own source.
+const SuppressionSourceHostLoad = "host-load"
+
+// SuppressionEpisode summarises one stretch the controller spent suppressed,
+// by host load or external requests, from entering the suppressed state to
+// lifting it.
+type SuppressionEpisode struct {
+	// Start is when suppression began; End is when it lifted and is zero
+	// while the episode is ongoing.
+	Start time.Time
+	End   time.Time
+	// PeakHostLoad is the highest smoothed host load seen during the episode.
+	PeakHostLoad float64
+	// Deficit is the duty-cycle time the workers gave up: the episode's
+	// duration weighted by the target suppression displaced, so ten minutes
+	// held off a 0.3 target lose three minutes.
+	Deficit time.Duration
+	// Sources lists what held the controller suppressed during the episode,
+	// SuppressionSourceHostLoad or an external request source, sorted.
+	Sources []string
+}
+
+// Ongoing reports whether the episode has not ended yet.
+func (e SuppressionEpisode) Ongoing() bool {
+	return e.End.IsZero()
+}
+
+// Duration returns how long the episode lasted, measuring an ongoing one up
+// to now.
+func (e SuppressionEpisode) Duration(now time.Time) time.Duration {
+	end := e.End
+	if e.Ongoing() {
+		end = now
+	}
+
+	return max(0, end.Sub(e.Start))
+}
+
+// SetSuppressionEpisodeHandler installs a callback invoked once for every
+// suppression episode that ends, outside the controller lock. A nil handler
+// disables notifications.
+func (c *AdaptiveController) SetSuppressionEpisodeHandler(
+	handler func(episode SuppressionEpisode),
+) {
+	c.mu.Lock()
+	c.episodeHandler = handler
+	c.mu.Unlock()
+}
+
+// SuppressionEpisodes returns the most recent suppression episodes, oldest
+// first: up to SuppressionEpisodeHistorySize finished ones followed by the
+// ongoing episode, if any.
+func (c *AdaptiveController) SuppressionEpisodes() []SuppressionEpisode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	episodes := make([]SuppressionEpisode, 0, len(c.episodes)+1)
+	for _, episode := range c.episodes {
+		episodes = append(episodes, copyEpisode(episode))
+	}
+
+	if c.inEpisode {
+		ongoing := copyEpisode(c.episode)
+		ongoing.Deficit += c.episodeDeficitLocked(c.now())
+		episodes = append(episodes, ongoing)
+	}
+
+	return episodes
+}
+
+// trackSuppressionEpisodeLocked opens, extends, or closes the current episode
+// after the effective suppression state was recomputed.
+func (c *AdaptiveController) trackSuppressionEpisodeLocked() {
+	now := c.now()
+	suppressed := c.suppressedLocked()
+
+	if !c.inEpisode {
+		if !suppressed {
+			return
+		}
+
+		c.inEpisode = true
+		c.episode = SuppressionEpisode{
+			Start:        now,
+			End:          time.Time{},
+			PeakHostLoad: 0,
+			Deficit:      0,
+			Sources:      nil,
+		}
+		c.episodeAt = now
+	}
+
+	c.accrueEpisodeLocked(now)
+
+	if suppressed {
+		c.addEpisodeSourcesLocked()
+
+		return
+	}
+
+	c.episode.End = now
+	c.inEpisode = false
+
+	if len(c.episodes) == SuppressionEpisodeHistorySize {
+		c.episodes = append(c.episodes[:0], c.episodes[1:]...)
+	}
+
+	c.episodes = append(c.episodes, c.episode)
+
+	if c.episodeHandler != nil && len(c.episodesEnded) < SuppressionEpisodeHistorySize {
+		c.episodesEnded = append(c.episodesEnded, copyEpisode(c.episode))
+	}
+}
+
+// accrueEpisodeLocked folds the time since the last update into the ongoing
+// episode's deficit and tracks its peak host load.
+func (c *AdaptiveController) accrueEpisodeLocked(now time.Time) {
+	c.episode.PeakHostLoad = max(c.episode.PeakHostLoad, c.hostLoad)
+	c.episode.Deficit += c.episodeDeficitLocked(now)
+
+	if now.After(c.episodeAt) {
+		c.episodeAt = now
+	}
+}
+
+// episodeDeficitLocked returns the duty-cycle time given up between the last
+// episode update and now at the target a resume would restore.
+func (c *AdaptiveController) episodeDeficitLocked(now time.Time) time.Duration {
+	elapsed := now.Sub(c.episodeAt)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return time.Duration(float64(elapsed) * c.resumeTargetLocked())
+}
+
+func (c *AdaptiveController) addEpisodeSourcesLocked() {
+	sources := c.episode.Sources
+	if c.suppressed {
+		sources = append(sources, SuppressionSourceHostLoad)
+	}
+
+	for source := range c.holds {
+		sources = append(sources, source)
+	}
+
+	slices.Sort(sources)
+	c.episode.Sources = slices.Compact(sources)
+}
+
+// notifySuppressionEpisodes delivers the episodes that ended since the last
+// call to the handler.
+func (c *AdaptiveController) notifySuppressionEpisodes() {
+	c.mu.Lock()
+	handler := c.episodeHandler
+	ended := c.episodesEnded
+	c.episodesEnded = nil
+	c.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+
+	for _, episode := range ended {
+		handler(episode)
+	}
+}
+
+func copyEpisode(episode SuppressionEpisode) SuppressionEpisode {
+	episode.Sources = slices.Clone(episode.Sources)
+
+	return episode
+}
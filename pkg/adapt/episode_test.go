@@ -0,0 +1,146 @@
+//nolint:testpackage // tests drive the controller's unexported clock
+package adapt
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func newEpisodeController(t *testing.T) *AdaptiveController {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.SuppressThreshold = 0.8
+	cfg.SuppressResume = 0.5
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil}),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	return controller
+}
+
+func TestSuppressionEpisodesTrackExternalHolds(t *testing.T) {
+	t.Parallel()
+
+	controller := newEpisodeController(t)
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+	controller.now = func() time.Time { return now }
+
+	var ended []SuppressionEpisode
+
+	controller.SetSuppressionEpisodeHandler(func(episode SuppressionEpisode) {
+		ended = append(ended, episode)
+	})
+
+	if episodes := controller.SuppressionEpisodes(); len(episodes) != 0 {
+		t.Fatalf("expected no episodes before suppression, got %+v", episodes)
+	}
+
+	controller.RequestSuppression("file", start.Add(time.Hour))
+	controller.RequestSuppression("api", start.Add(time.Hour))
+
+	now = start.Add(10 * time.Minute)
+
+	resume := clamp(DefaultConfig().TargetStart, DefaultConfig().TargetMin,
+		DefaultConfig().TargetMax)
+	episodes := controller.SuppressionEpisodes()
+
+	requireEqual(t, "ongoing episodes", len(episodes), 1)
+	requireEqual(t, "ongoing", episodes[0].Ongoing(), true)
+	requireEqual(t, "ongoing duration", episodes[0].Duration(now), 10*time.Minute)
+	requireEqual(t, "ongoing deficit", episodes[0].Deficit,
+		time.Duration(float64(10*time.Minute)*resume))
+
+	now = start.Add(20 * time.Minute)
+	controller.RequestSuppression("file", time.Time{})
+
+	if len(ended) != 0 {
+		t.Fatalf("expected the episode to continue while a request remains, got %+v", ended)
+	}
+
+	now = start.Add(30 * time.Minute)
+	controller.RequestSuppression("api", time.Time{})
+
+	requireEqual(t, "ended episodes", len(ended), 1)
+
+	episode := ended[0]
+	if !episode.Start.Equal(start) || !episode.End.Equal(now) || episode.Ongoing() {
+		t.Fatalf("unexpected episode bounds %+v", episode)
+	}
+
+	requireEqual(t, "duration", episode.Duration(now.Add(time.Hour)), 30*time.Minute)
+	requireEqual(t, "deficit", episode.Deficit, time.Duration(float64(30*time.Minute)*resume))
+
+	if !slices.Equal(episode.Sources, []string{"api", "file"}) {
+		t.Fatalf("expected both request sources, got %v", episode.Sources)
+	}
+
+	episodes = controller.SuppressionEpisodes()
+	requireEqual(t, "finished episodes", len(episodes), 1)
+
+	episodes[0].Sources[0] = "changed"
+	requireEqual(t, "copy", controller.SuppressionEpisodes()[0].Sources[0], "api")
+}
+
+func TestSuppressionEpisodesTrackHostLoad(t *testing.T) {
+	t.Parallel()
+
+	controller := newEpisodeController(t)
+
+	feedObservation(controller, 0, 0.9, nil)
+	feedObservation(controller, 1, 0.95, nil)
+
+	for second := int64(2); second < 10 && controller.State() == StateSuppressed; second++ {
+		feedObservation(controller, second, 0.1, nil)
+	}
+
+	episodes := controller.SuppressionEpisodes()
+	requireEqual(t, "episodes", len(episodes), 1)
+
+	episode := episodes[0]
+	requireFloatApprox(t, "peak host load", episode.PeakHostLoad, 0.91)
+
+	if episode.Ongoing() || !slices.Equal(episode.Sources, []string{SuppressionSourceHostLoad}) {
+		t.Fatalf("expected a finished host load episode, got %+v", episode)
+	}
+}
+
+func TestSuppressionEpisodesKeepRecentHistory(t *testing.T) {
+	t.Parallel()
+
+	controller := newEpisodeController(t)
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+	controller.now = func() time.Time { return now }
+
+	for index := range SuppressionEpisodeHistorySize + 2 {
+		now = start.Add(time.Duration(index) * time.Hour)
+		controller.RequestSuppression("api", now.Add(time.Hour))
+
+		now = now.Add(time.Minute)
+		controller.RequestSuppression("api", time.Time{})
+	}
+
+	controller.RequestSuppression("api", now.Add(time.Hour))
+
+	episodes := controller.SuppressionEpisodes()
+	requireEqual(t, "length", len(episodes), SuppressionEpisodeHistorySize+1)
+
+	if !episodes[0].Start.Equal(start.Add(2 * time.Hour)) {
+		t.Fatalf("expected the oldest episodes to be dropped, got %s", episodes[0].Start)
+	}
+
+	requireEqual(t, "latest ongoing", episodes[len(episodes)-1].Ongoing(), true)
+}
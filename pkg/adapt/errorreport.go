@@ -0,0 +1,33 @@
+package adapt
+
+// Components the controller reports errors for through SetErrorHandler.
+const (
+	// ErrorComponentOCI identifies failed OCI Monitoring queries.
+	ErrorComponentOCI = "oci"
+	// ErrorComponentEstimator identifies failed host CPU observations.
+	ErrorComponentEstimator = "estimator"
+)
+
+// SetErrorHandler installs a callback invoked with every failed OCI query and
+// host CPU observation, tagged with ErrorComponentOCI or
+// ErrorComponentEstimator. Unlike the logs, which only report the first
+// failure of a run, the handler sees each one. It runs on the controller or
+// estimator goroutine and must not block. A nil handler disables
+// notifications.
+func (c *AdaptiveController) SetErrorHandler(handler func(component string, err error)) {
+	c.mu.Lock()
+	c.errorHandler = handler
+	c.mu.Unlock()
+}
+
+func (c *AdaptiveController) notifyError(component string, err error) {
+	c.mu.Lock()
+	handler := c.errorHandler
+	c.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+
+	handler(component, err)
+}
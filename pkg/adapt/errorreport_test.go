@@ -0,0 +1,81 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+)
+
+type reportedError struct {
+	component string
+	err       error
+}
+
+func TestAdaptiveControllerReportsEveryError(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0, Err: errOCIDown},
+		adapttest.Result{Value: 0, Err: errOCIDown},
+		adapttest.Result{Value: 0.3, Err: nil},
+	)
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	// Without a handler errors are only logged.
+	controller.handleObservation(est.Observation{ //nolint:exhaustruct
+		Timestamp: time.Unix(1_700_000_000, 0),
+		Err:       errEstimatorObservation,
+	})
+
+	var reported []reportedError
+
+	controller.SetErrorHandler(func(component string, err error) {
+		reported = append(reported, reportedError{component: component, err: err})
+	})
+
+	for range 3 {
+		controller.step(t.Context())
+	}
+
+	controller.handleObservation(est.Observation{ //nolint:exhaustruct
+		Timestamp: time.Unix(1_700_000_001, 0),
+		Err:       errEstimatorObservation,
+	})
+
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	controller.step(canceled)
+
+	if len(reported) != 3 {
+		t.Fatalf("expected two OCI errors and one estimator error, got %v", reported)
+	}
+
+	for index, component := range []string{
+		ErrorComponentOCI,
+		ErrorComponentOCI,
+		ErrorComponentEstimator,
+	} {
+		if reported[index].component != component {
+			t.Fatalf("expected %s error at %d, got %v", component, index, reported)
+		}
+	}
+
+	if !errors.Is(reported[2].err, errEstimatorObservation) {
+		t.Fatalf("expected the estimator error to be reported, got %v", reported[2].err)
+	}
+}
@@ -0,0 +1,135 @@
+package adapt
+
+import (
+	"context"
+	"time"
+
+	"oci-cpu-shaper/pkg/oci"
+)
+
+const (
+	// IdleThreshold is the seven-day utilisation below which OCI treats an Always
+	// Free instance as idle, applied to both the CPU P95 and network usage.
+	IdleThreshold = 0.20
+
+	networkWindowSeconds = 7 * 24 * 60 * 60
+	bitsPerByte          = 8
+	bitsPerGigabit       = 1e9
+)
+
+// IdleStatus reports whether the instance meets OCI's reclamation criteria.
+// CPU alone does not decide reclamation: an instance with a low CPU P95 but
+// network usage above IdleThreshold is not idle.
+type IdleStatus struct {
+	Idle   bool
+	CPUP95 float64
+	// NetworkRatio is the busier of the inbound and outbound seven-day totals as
+	// a fraction of the shape's bandwidth. It is only meaningful when
+	// NetworkKnown is set.
+	NetworkRatio float64
+	NetworkKnown bool
+}
+
+// SetIdleHandler installs a callback invoked from the controller goroutine
+// whenever the idle status changes, including the first successful step. A nil
+// handler disables notifications.
+func (c *AdaptiveController) SetIdleHandler(handler func(status IdleStatus)) {
+	c.mu.Lock()
+	c.idleHandler = handler
+	c.mu.Unlock()
+}
+
+// IdleStatus returns the idle status computed by the last successful step and
+// whether one has been computed yet.
+func (c *AdaptiveController) IdleStatus() (IdleStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.idle, c.idleKnown
+}
+
+// queryMetrics fetches the CPU P95 together with, when due, the seven-day
+// network totals. Clients that batch their queries answer both in one round
+// trip instead of serialized calls.
+func (c *AdaptiveController) queryMetrics(ctx context.Context) (float64, error) {
+	now := c.now()
+	network := c.networkDue(now)
+
+	result, err := oci.QueryStep(ctx, c.metrics, c.cfg.ResourceID, oci.StepQuery{Network: network})
+	if err != nil {
+		return 0, err //nolint:wrapcheck // the step records the client error verbatim
+	}
+
+	if network {
+		c.recordNetwork(now, result.Network, result.NetworkErr)
+	}
+
+	return result.P95CPU, nil
+}
+
+// networkDue reports whether the network totals should be refreshed. They are
+// only queried when the shape bandwidth is known and, because totals move
+// slowly, at most once per relaxed interval.
+func (c *AdaptiveController) networkDue(now time.Time) bool {
+	if c.cfg.NetworkBandwidthGbps <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.networkAt.IsZero() || now.Sub(c.networkAt) >= c.cfg.RelaxedInterval
+}
+
+func (c *AdaptiveController) recordNetwork(now time.Time, totals oci.NetworkTotals, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.networkAt = now
+	if err != nil {
+		c.networkKnown = false
+
+		return
+	}
+
+	capacity := c.cfg.NetworkBandwidthGbps * bitsPerGigabit * networkWindowSeconds
+	c.networkRatio = max(totals.BytesIn, totals.BytesOut) * bitsPerByte / capacity
+	c.networkKnown = true
+}
+
+// updateIdleLocked recomputes the idle status from the latest P95 and network
+// ratio and records whether it changed since the last step.
+func (c *AdaptiveController) updateIdleLocked(p95 float64) {
+	status := IdleStatus{
+		Idle:         p95 < IdleThreshold,
+		CPUP95:       p95,
+		NetworkRatio: c.networkRatio,
+		NetworkKnown: c.networkKnown,
+	}
+	if status.NetworkKnown && status.NetworkRatio >= IdleThreshold {
+		status.Idle = false
+	}
+
+	if !c.idleKnown || status.Idle != c.idle.Idle {
+		c.idleChanged = true
+	}
+
+	c.idle = status
+	c.idleKnown = true
+}
+
+// notifyIdle delivers a pending idle status change to the handler.
+func (c *AdaptiveController) notifyIdle() {
+	c.mu.Lock()
+	handler := c.idleHandler
+	status := c.idle
+	changed := c.idleChanged
+	c.idleChanged = false
+	c.mu.Unlock()
+
+	if !changed || handler == nil {
+		return
+	}
+
+	handler(status)
+}
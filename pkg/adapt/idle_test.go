@@ -0,0 +1,109 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+type networkMetrics struct {
+	*adapttest.MetricsClient
+
+	totals oci.NetworkTotals
+	calls  int
+}
+
+func (n *networkMetrics) QueryNetworkBytes7d(context.Context, string) (oci.NetworkTotals, error) {
+	n.calls++
+
+	return n.totals, nil
+}
+
+func TestIdleStatusCombinesCPUAndNetwork(t *testing.T) {
+	t.Parallel()
+
+	// 1 Gbps over seven days carries 75.6 TB; 10 TB out is ~13% of it.
+	metrics := &networkMetrics{
+		MetricsClient: adapttest.NewMetricsClient(adapttest.Result{Value: 0.12, Err: nil}),
+		totals:        oci.NetworkTotals{BytesIn: 1e12, BytesOut: 10e12},
+	}
+	cfg := DefaultConfig()
+	cfg.NetworkBandwidthGbps = 1
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, adapttest.NewDutyCycler(), nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	var updates []IdleStatus
+
+	controller.SetIdleHandler(func(status IdleStatus) {
+		updates = append(updates, status)
+	})
+
+	controller.step(context.Background())
+
+	requireEqual(t, "updates after first step", len(updates), 1)
+	requireEqual(t, "idle with low cpu and network", updates[0].Idle, true)
+	requireEqual(t, "network known", updates[0].NetworkKnown, true)
+	requireFloatApprox(t, "network ratio", updates[0].NetworkRatio, 10e12*8/(1e9*604800))
+
+	// Unchanged status is not re-announced and totals are not re-queried
+	// within the relaxed interval.
+	controller.step(context.Background())
+	requireEqual(t, "updates without change", len(updates), 1)
+	requireEqual(t, "network queries within relaxed interval", metrics.calls, 1)
+
+	metrics.totals.BytesIn = 20e12
+	now = now.Add(cfg.RelaxedInterval)
+
+	controller.step(context.Background())
+	requireEqual(t, "network queries after relaxed interval", metrics.calls, 2)
+	requireEqual(t, "updates after network rises", len(updates), 2)
+	requireEqual(t, "busy network keeps instance alive", updates[1].Idle, false)
+
+	status, ok := controller.IdleStatus()
+	if !ok || status.Idle {
+		t.Fatalf("expected non-idle status to be recorded, got %+v (known=%v)", status, ok)
+	}
+}
+
+func TestIdleStatusFallsBackToCPUWithoutBandwidth(t *testing.T) {
+	t.Parallel()
+
+	metrics := &networkMetrics{
+		MetricsClient: adapttest.NewMetricsClient(adapttest.Result{Value: 0.25, Err: nil}),
+		totals:        oci.NetworkTotals{BytesIn: 0, BytesOut: 0},
+	}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	if _, ok := controller.IdleStatus(); ok {
+		t.Fatal("expected no idle status before the first step")
+	}
+
+	controller.step(context.Background())
+
+	status, ok := controller.IdleStatus()
+	if !ok || status.Idle || status.NetworkKnown {
+		t.Fatalf("expected cpu-only non-idle status, got %+v (known=%v)", status, ok)
+	}
+
+	requireEqual(t, "network queries without bandwidth", metrics.calls, 0)
+}
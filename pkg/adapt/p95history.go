@@ -0,0 +1,118 @@
+package adapt
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// P95HistorySize is the number of OCI P95 readings the controller keeps for
+// P95History, two days at the default hourly interval.
+const P95HistorySize = 48
+
+// Trend directions reported by SummarizeP95.
+const (
+	TrendRising  = "rising"
+	TrendFalling = "falling"
+	TrendFlat    = "flat"
+)
+
+// trendTolerance is the P95 change, as a fraction of the shape, below which
+// a trend is flat.
+const trendTolerance = 0.005
+
+// sparkTicks are the block characters a sparkline draws, lowest first.
+const sparkTicks = "▁▂▃▄▅▆▇█"
+
+// P95Sample is one successful OCI P95 reading and the time it was taken.
+type P95Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	P95       float64   `json:"p95"`
+}
+
+// P95Summary condenses a P95 history into the figures shaper status prints.
+type P95Summary struct {
+	Samples   int
+	Latest    P95Sample
+	Min       float64
+	Max       float64
+	Change    float64
+	Direction string
+	Sparkline string
+}
+
+// P95History returns the most recent successful OCI P95 readings, oldest
+// first, up to P95HistorySize of them.
+func (c *AdaptiveController) P95History() []P95Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]P95Sample(nil), c.p95History...)
+}
+
+func (c *AdaptiveController) recordP95Locked(p95 float64) {
+	if len(c.p95History) == P95HistorySize {
+		c.p95History = append(c.p95History[:0], c.p95History[1:]...)
+	}
+
+	c.p95History = append(c.p95History, P95Sample{Timestamp: c.now(), P95: p95})
+}
+
+// SummarizeP95 reports the latest reading, the range, a sparkline, and the
+// change from the first to the last of samples, which must be oldest first.
+// Changes within half a percentage point count as flat.
+func SummarizeP95(samples []P95Sample) P95Summary {
+	var summary P95Summary
+
+	summary.Direction = TrendFlat
+	if len(samples) == 0 {
+		return summary
+	}
+
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, sample.P95)
+	}
+
+	summary.Samples = len(samples)
+	summary.Latest = samples[len(samples)-1]
+	summary.Min = slices.Min(values)
+	summary.Max = slices.Max(values)
+	summary.Change = summary.Latest.P95 - samples[0].P95
+	summary.Sparkline = Sparkline(values)
+
+	switch {
+	case summary.Change > trendTolerance:
+		summary.Direction = TrendRising
+	case summary.Change < -trendTolerance:
+		summary.Direction = TrendFalling
+	}
+
+	return summary
+}
+
+// Sparkline renders values as a row of block characters scaled between their
+// minimum and maximum; a constant series renders at the lowest level.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	ticks := []rune(sparkTicks)
+	low := slices.Min(values)
+	spread := slices.Max(values) - low
+
+	var builder strings.Builder
+
+	for _, value := range values {
+		level := 0
+		if spread > 0 {
+			level = int(math.Round((value - low) / spread * float64(len(ticks)-1)))
+		}
+
+		builder.WriteRune(ticks[level])
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,88 @@
+//nolint:testpackage // tests drive the controller's unexported clock
+package adapt
+
+import (
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestP95HistoryKeepsRecentSuccessfulReadings(t *testing.T) {
+	t.Parallel()
+
+	results := make([]adapttest.Result, 0, P95HistorySize+3)
+	results = append(results, adapttest.Result{Value: 0, Err: errOCIDown})
+
+	for index := range P95HistorySize + 2 {
+		results = append(results, adapttest.Result{Value: float64(index) / 100, Err: nil})
+	}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(results...),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	start := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	steps := 0
+	controller.now = func() time.Time { return start.Add(time.Duration(steps) * time.Hour) }
+
+	for range results {
+		controller.step(t.Context())
+		steps++
+	}
+
+	history := controller.P95History()
+	requireEqual(t, "length", len(history), P95HistorySize)
+	requireFloatApprox(t, "oldest", history[0].P95, 0.02)
+	requireFloatApprox(t, "newest", history[len(history)-1].P95, float64(P95HistorySize+1)/100)
+
+	if !history[0].Timestamp.Equal(start.Add(3 * time.Hour)) {
+		t.Fatalf("unexpected oldest timestamp %s", history[0].Timestamp)
+	}
+
+	history[0].P95 = 1
+	requireFloatApprox(t, "copy", controller.P95History()[0].P95, 0.02)
+}
+
+func TestSummarizeP95ReportsTrend(t *testing.T) {
+	t.Parallel()
+
+	stamp := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	samples := []P95Sample{
+		{Timestamp: stamp, P95: 0.20},
+		{Timestamp: stamp.Add(time.Hour), P95: 0.25},
+		{Timestamp: stamp.Add(2 * time.Hour), P95: 0.22},
+		{Timestamp: stamp.Add(3 * time.Hour), P95: 0.28},
+	}
+
+	summary := SummarizeP95(samples)
+	requireEqual(t, "samples", summary.Samples, 4)
+	requireEqual(t, "direction", summary.Direction, TrendRising)
+	requireEqual(t, "sparkline", summary.Sparkline, "▁▅▃█")
+	requireFloatApprox(t, "min", summary.Min, 0.20)
+	requireFloatApprox(t, "max", summary.Max, 0.28)
+	requireFloatApprox(t, "change", summary.Change, 0.08)
+
+	if !summary.Latest.Timestamp.Equal(samples[3].Timestamp) {
+		t.Fatalf("unexpected latest sample %+v", summary.Latest)
+	}
+
+	samples[3].P95 = 0.1
+	requireEqual(t, "falling", SummarizeP95(samples).Direction, TrendFalling)
+
+	samples[3].P95 = 0.203
+	requireEqual(t, "flat", SummarizeP95(samples).Direction, TrendFlat)
+
+	empty := SummarizeP95(nil)
+	requireEqual(t, "empty samples", empty.Samples, 0)
+	requireEqual(t, "empty direction", empty.Direction, TrendFlat)
+	requireEqual(t, "constant", Sparkline([]float64{0.3, 0.3}), "▁▁")
+	requireEqual(t, "no values", Sparkline(nil), "")
+}
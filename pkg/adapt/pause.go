@@ -0,0 +1,40 @@
+package adapt
+
+import (
+	"time"
+
+	"oci-cpu-shaper/pkg/est"
+)
+
+// SetPauseHandler installs a callback invoked from the controller goroutine when
+// the wall clock jumps far past the scheduled interval, meaning the process was
+// suspended (for example, its VM was paused or live-migrated). The handler
+// receives the observed gap. A nil handler disables notifications.
+func (c *AdaptiveController) SetPauseHandler(handler func(gap time.Duration)) {
+	c.mu.Lock()
+	c.pauseHandler = handler
+	c.mu.Unlock()
+}
+
+// detectPause re-baselines the controller when the wall clock advanced more
+// than est.PauseGapFactor intervals between last and now, and reports whether
+// it did.
+func (c *AdaptiveController) detectPause(last, now time.Time, interval time.Duration) bool {
+	if !est.Paused(last, now, interval) {
+		return false
+	}
+
+	c.mu.Lock()
+	// Host load smoothed before the pause no longer describes the host, and
+	// the estimator's next window starts after it.
+	c.hostLoad = 0
+	c.rebaselineSelfLoadLocked()
+	handler := c.pauseHandler
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(now.Round(0).Sub(last.Round(0)))
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+// pausingMetrics advances the controller clock during its first query, as if
+// the process had been suspended while the request was in flight.
+type pausingMetrics struct {
+	*adapttest.MetricsClient
+
+	pause func()
+	calls int
+}
+
+func (p *pausingMetrics) QueryP95CPU(ctx context.Context, resourceID string) (float64, error) {
+	p.calls++
+	if p.calls == 1 {
+		p.pause()
+	}
+
+	return p.MetricsClient.QueryP95CPU(ctx, resourceID)
+}
+
+func TestEvaluateRequeriesAfterPauseMidQuery(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	metrics := &pausingMetrics{
+		MetricsClient: adapttest.NewMetricsClient(
+			adapttest.Result{Value: 0.1},
+			adapttest.Result{Value: 0.35},
+		),
+		pause: func() { now = now.Add(6 * time.Hour) },
+	}
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		metrics,
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	controller.now = func() time.Time { return now }
+	controller.hostLoad = 0.8
+
+	var gaps []time.Duration
+
+	controller.SetPauseHandler(func(gap time.Duration) {
+		gaps = append(gaps, gap)
+	})
+
+	_, decision := controller.evaluate(context.Background())
+
+	requireEqual(t, "queries", metrics.calls, 2)
+	requireFloatApprox(t, "decision uses the post-pause p95", decision.P95, 0.35)
+	requireEqual(t, "pause notifications", len(gaps), 1)
+	requireEqual(t, "pause gap", gaps[0], 6*time.Hour)
+	requireFloatApprox(t, "host load re-baselined", controller.hostLoad, 0)
+
+	_, decision = controller.evaluate(context.Background())
+
+	requireEqual(t, "queries without a pause", metrics.calls, 3)
+	requireEqual(t, "pause notifications without a pause", len(gaps), 1)
+	requireFloatApprox(t, "p95 without a pause", decision.P95, 0.35)
+}
+
+func TestDetectPauseIgnoresJitter(t *testing.T) {
+	t.Parallel()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.3}),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	last := time.Unix(1_700_000_000, 0)
+
+	testCases := []struct {
+		now      time.Time
+		interval time.Duration
+		paused   bool
+	}{
+		{now: last.Add(2 * time.Minute), interval: time.Minute},
+		{now: last.Add(4 * time.Minute), interval: time.Minute, paused: true},
+		// Short intervals need at least est.MinPauseGap before a pause counts.
+		{now: last.Add(500 * time.Millisecond), interval: time.Millisecond},
+	}
+
+	for _, testCase := range testCases {
+		got := controller.detectPause(last, testCase.now, testCase.interval)
+		requireEqual(t, testCase.now.Sub(last).String(), got, testCase.paused)
+	}
+}
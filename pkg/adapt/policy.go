@@ -0,0 +1,267 @@
+package adapt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Policy names accepted by Config.Policy.
+const (
+	PolicyStep     = "step"
+	PolicyPID      = "pid"
+	PolicySchedule = "schedule"
+)
+
+var (
+	errWindowBounds = errors.New("start and end must fall within a day and differ")
+	errWindowTarget = errors.New("targetMax must lie between targetMin and targetMax")
+)
+
+const (
+	defaultPIDProportional = 0.5
+	defaultPIDIntegral     = 0.2
+	day                    = 24 * time.Hour
+)
+
+// PolicyInput carries the signals a Policy sees on each successful slow-loop step.
+type PolicyInput struct {
+	// Now is the controller clock at the time of the step.
+	Now time.Time
+	// P95 is the OCI Monitoring seven-day CPU P95 as a 0–1 ratio.
+	P95 float64
+	// Target is the target the policy adjusts from: the applied target, or the
+	// desired one while suppression or the change budget holds it back.
+	Target float64
+}
+
+// PolicyDecision is what a Policy asks the controller to do next. The controller
+// clamps Target to [TargetMin, TargetMax] and falls back to Config.Interval when
+// NextInterval is not positive.
+type PolicyDecision struct {
+	Target       float64
+	NextInterval time.Duration
+}
+
+// Policy turns a slow-loop observation into the next target and query cadence.
+// Suppression, fallback, and the hourly change budget stay with the controller,
+// so policies only decide where the target should head. Decide is called from
+// the controller goroutine and never concurrently, so implementations may keep
+// state between calls.
+type Policy interface {
+	Decide(input PolicyInput) PolicyDecision
+}
+
+// PIDGains holds the coefficients of PIDPolicy.
+type PIDGains struct {
+	Proportional float64
+	Integral     float64
+	Derivative   float64
+}
+
+// ScheduleWindow caps the target during a daily time-of-day window expressed as
+// offsets from midnight in Location, or in the process's local time zone when
+// Location is nil. Windows whose End precedes Start wrap past midnight.
+type ScheduleWindow struct {
+	Start     time.Duration
+	End       time.Duration
+	TargetMax float64
+	Location  *time.Location
+}
+
+func (w ScheduleWindow) contains(offset time.Duration) bool {
+	return withinDaily(w.Start, w.End, offset)
+}
+
+// withinDaily reports whether offset from midnight falls in [start, end),
+// wrapping past midnight when end precedes start.
+func withinDaily(start, end, offset time.Duration) bool {
+	if start <= end {
+		return offset >= start && offset < end
+	}
+
+	return offset >= start || offset < end
+}
+
+// NewPolicy builds the policy selected by cfg.Policy.
+//
+//nolint:ireturn // the policy is chosen at runtime.
+func NewPolicy(cfg Config) (Policy, error) {
+	switch cfg.Policy {
+	case PolicyStep, "":
+		return NewStepPolicy(cfg), nil
+	case PolicyPID:
+		return NewPIDPolicy(cfg), nil
+	case PolicySchedule:
+		return NewSchedulePolicy(NewStepPolicy(cfg), cfg.Schedule), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported controller.policy %q", ErrInvalidConfig, cfg.Policy)
+	}
+}
+
+// StepPolicy nudges the target by a fixed step whenever the P95 leaves the goal
+// band and relaxes the cadence while the P95 is comfortably above it.
+type StepPolicy struct {
+	cfg Config
+}
+
+// NewStepPolicy returns the default step policy.
+func NewStepPolicy(cfg Config) *StepPolicy {
+	return &StepPolicy{cfg: cfg}
+}
+
+// Decide implements Policy.
+func (p *StepPolicy) Decide(input PolicyInput) PolicyDecision {
+	target := input.Target
+
+	if input.P95 < p.cfg.GoalLow {
+		target += p.cfg.StepUp
+	} else if input.P95 > p.cfg.GoalHigh {
+		target -= p.cfg.StepDown
+	}
+
+	return PolicyDecision{Target: target, NextInterval: relaxedInterval(p.cfg, input.P95)}
+}
+
+// PIDPolicy steers the P95 towards the middle of the goal band with an
+// incremental PID controller. It emits a target change per step rather than an
+// absolute target, so the controller's clamping cannot cause integral windup.
+type PIDPolicy struct {
+	cfg      Config
+	setpoint float64
+	previous [2]float64
+	samples  int
+}
+
+// NewPIDPolicy returns a PID policy using cfg.PID gains.
+func NewPIDPolicy(cfg Config) *PIDPolicy {
+	return &PIDPolicy{cfg: cfg, setpoint: (cfg.GoalLow + cfg.GoalHigh) / 2}
+}
+
+// Decide implements Policy.
+func (p *PIDPolicy) Decide(input PolicyInput) PolicyDecision {
+	current := p.setpoint - input.P95
+	delta := p.cfg.PID.Integral * current
+
+	if p.samples > 0 {
+		delta += p.cfg.PID.Proportional * (current - p.previous[0])
+	}
+
+	if p.samples > 1 {
+		delta += p.cfg.PID.Derivative * (current - 2*p.previous[0] + p.previous[1])
+	}
+
+	p.previous[1] = p.previous[0]
+	p.previous[0] = current
+	p.samples++
+
+	return PolicyDecision{
+		Target:       input.Target + delta,
+		NextInterval: relaxedInterval(p.cfg, input.P95),
+	}
+}
+
+// SchedulePolicy wraps another policy and caps its target during the configured
+// daily windows, for example to keep synthetic load low during business hours.
+// It shortens the next interval so the controller wakes at the next window
+// boundary.
+type SchedulePolicy struct {
+	base    Policy
+	windows []ScheduleWindow
+}
+
+// NewSchedulePolicy wraps base with the supplied windows.
+func NewSchedulePolicy(base Policy, windows []ScheduleWindow) *SchedulePolicy {
+	return &SchedulePolicy{base: base, windows: append([]ScheduleWindow(nil), windows...)}
+}
+
+// Decide implements Policy.
+func (p *SchedulePolicy) Decide(input PolicyInput) PolicyDecision {
+	decision := p.base.Decide(input)
+
+	for _, window := range p.windows {
+		offset := sinceMidnight(inLocation(input.Now, window.Location))
+		if window.contains(offset) {
+			decision.Target = math.Min(decision.Target, window.TargetMax)
+		}
+
+		for _, boundary := range []time.Duration{window.Start, window.End} {
+			until := (boundary - offset + day) % day
+			if until == 0 {
+				until = day
+			}
+
+			if decision.NextInterval <= 0 || until < decision.NextInterval {
+				decision.NextInterval = until
+			}
+		}
+	}
+
+	return decision
+}
+
+func sinceMidnight(now time.Time) time.Duration {
+	hour, minute, second := now.Clock()
+
+	return time.Duration(hour)*time.Hour +
+		time.Duration(minute)*time.Minute +
+		time.Duration(second)*time.Second +
+		time.Duration(now.Nanosecond())
+}
+
+func relaxedInterval(cfg Config, p95 float64) time.Duration {
+	if p95 >= cfg.RelaxedThreshold {
+		return cfg.RelaxedInterval
+	}
+
+	return cfg.Interval
+}
+
+func normalizePolicyName(name string) string {
+	trimmed := strings.ToLower(strings.TrimSpace(name))
+	if trimmed == "" {
+		return PolicyStep
+	}
+
+	return trimmed
+}
+
+func validatePolicyConfig(cfg Config) error {
+	_, err := NewPolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	gains := cfg.PID
+	if gains.Proportional < 0 || gains.Integral < 0 || gains.Derivative < 0 {
+		return fmt.Errorf("%w: controller.pid gains must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.Policy == PolicySchedule && len(cfg.Schedule) == 0 {
+		return fmt.Errorf("%w: controller.schedule requires at least one window", ErrInvalidConfig)
+	}
+
+	for index, window := range cfg.Schedule {
+		err = validateScheduleWindow(cfg, window)
+		if err != nil {
+			return fmt.Errorf("%w: controller.schedule[%d]: %w", ErrInvalidConfig, index, err)
+		}
+	}
+
+	return nil
+}
+
+func validateScheduleWindow(cfg Config, window ScheduleWindow) error {
+	if window.Start < 0 || window.Start >= day || window.End < 0 || window.End >= day ||
+		window.Start == window.End {
+		return errWindowBounds
+	}
+
+	if window.TargetMax < cfg.TargetMin || window.TargetMax > cfg.TargetMax {
+		return errWindowTarget
+	}
+
+	return nil
+}
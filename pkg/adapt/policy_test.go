@@ -0,0 +1,184 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+type fixedPolicy struct {
+	decision PolicyDecision
+	inputs   []PolicyInput
+}
+
+func (f *fixedPolicy) Decide(input PolicyInput) PolicyDecision {
+	f.inputs = append(f.inputs, input)
+
+	return f.decision
+}
+
+func TestPIDPolicyConvergesTowardsGoalBand(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	policy := NewPIDPolicy(cfg)
+
+	// Model the P95 as the target plus a steady 0.02 of tenant load.
+	target := cfg.TargetStart
+
+	for range 200 {
+		decision := policy.Decide(PolicyInput{Now: time.Time{}, P95: target + 0.02, Target: target})
+		target = clamp(decision.Target, cfg.TargetMin, cfg.TargetMax)
+	}
+
+	requireFloatApprox(t, "converged target", target, (cfg.GoalLow+cfg.GoalHigh)/2-0.02)
+}
+
+func TestPIDPolicyRelaxesAboveThreshold(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	decision := NewPIDPolicy(cfg).Decide(PolicyInput{Now: time.Time{}, P95: 0.35, Target: 0.3})
+
+	requireEqual(t, "next interval", decision.NextInterval, cfg.RelaxedInterval)
+
+	if decision.Target >= 0.3 {
+		t.Fatalf("expected a high P95 to lower the target, got %.3f", decision.Target)
+	}
+}
+
+func TestSchedulePolicyCapsTargetInsideWindow(t *testing.T) {
+	t.Parallel()
+
+	base := &fixedPolicy{decision: PolicyDecision{Target: 0.35, NextInterval: time.Hour}}
+	policy := NewSchedulePolicy(base, []ScheduleWindow{
+		{Start: 9 * time.Hour, End: 17 * time.Hour, TargetMax: 0.22},
+		{Start: 22 * time.Hour, End: 2 * time.Hour, TargetMax: 0.3},
+	})
+
+	midnight := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		at       time.Duration
+		target   float64
+		interval time.Duration
+	}{
+		{name: "business hours", at: 10 * time.Hour, target: 0.22, interval: time.Hour},
+		{
+			name:     "before window",
+			at:       8*time.Hour + 40*time.Minute,
+			target:   0.35,
+			interval: 20 * time.Minute,
+		},
+		{
+			name:     "wrapped window",
+			at:       time.Hour + 30*time.Minute,
+			target:   0.3,
+			interval: 30 * time.Minute,
+		},
+		{name: "evening", at: 19 * time.Hour, target: 0.35, interval: time.Hour},
+	}
+
+	for _, testCase := range testCases {
+		decision := policy.Decide(PolicyInput{
+			Now:    midnight.Add(testCase.at),
+			P95:    0.2,
+			Target: 0.3,
+		})
+
+		requireFloatApprox(t, testCase.name+" target", decision.Target, testCase.target)
+		requireEqual(t, testCase.name+" interval", decision.NextInterval, testCase.interval)
+	}
+}
+
+func TestSchedulePolicyUsesWindowTimeZone(t *testing.T) {
+	t.Parallel()
+
+	base := &fixedPolicy{decision: PolicyDecision{Target: 0.35, NextInterval: time.Hour}}
+	tokyo := time.FixedZone("JST", 9*60*60)
+	policy := NewSchedulePolicy(base, []ScheduleWindow{
+		{Start: 9 * time.Hour, End: 17 * time.Hour, TargetMax: 0.22, Location: tokyo},
+	})
+
+	// 01:30 UTC is 10:30 in Tokyo, inside the window.
+	decision := policy.Decide(PolicyInput{
+		Now:    time.Date(2024, 6, 3, 1, 30, 0, 0, time.UTC),
+		P95:    0.2,
+		Target: 0.3,
+	})
+
+	requireFloatApprox(t, "target", decision.Target, 0.22)
+	requireEqual(t, "interval", decision.NextInterval, time.Hour)
+
+	decision = policy.Decide(PolicyInput{
+		Now:    time.Date(2024, 6, 3, 10, 30, 0, 0, time.UTC),
+		P95:    0.2,
+		Target: 0.3,
+	})
+
+	requireFloatApprox(t, "target outside", decision.Target, 0.35)
+}
+
+func TestNewPolicyValidatesSelection(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+
+	cfg.Policy = " PID "
+	requireEqual(t, "normalised policy", normalizePolicyName(cfg.Policy), PolicyPID)
+
+	for _, mutate := range []func(*Config){
+		func(c *Config) { c.Policy = "bang-bang" },
+		func(c *Config) { c.Policy = PolicySchedule },
+		func(c *Config) { c.PID.Derivative = -1 },
+		func(c *Config) {
+			c.Policy = PolicySchedule
+			c.Schedule = []ScheduleWindow{{Start: time.Hour, End: time.Hour, TargetMax: 0.3}}
+		},
+		func(c *Config) {
+			c.Policy = PolicySchedule
+			c.Schedule = []ScheduleWindow{{Start: time.Hour, End: 2 * time.Hour, TargetMax: 0.1}}
+		},
+		func(c *Config) { c.Blackout = []BlackoutWindow{{Start: time.Hour, End: time.Hour}} },
+		func(c *Config) { c.Blackout = []BlackoutWindow{{Start: -time.Hour, End: time.Hour}} },
+	} {
+		invalid := DefaultConfig()
+		mutate(&invalid)
+
+		err := ValidateConfig(invalid)
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("expected ErrInvalidConfig for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestAdaptiveControllerDelegatesToPolicy(t *testing.T) {
+	t.Parallel()
+
+	metrics := adapttest.NewMetricsClient(adapttest.Result{Value: 0.1, Err: nil})
+	shaper := adapttest.NewDutyCycler()
+
+	controller, err := NewAdaptiveController(DefaultConfig(), metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	policy := &fixedPolicy{decision: PolicyDecision{Target: 0.9, NextInterval: 0}}
+	controller.SetPolicy(policy)
+
+	next := controller.step(t.Context())
+
+	requireEqual(t, "policy calls", len(policy.inputs), 1)
+	requireFloatApprox(t, "policy p95", policy.inputs[0].P95, 0.1)
+	requireFloatApprox(t, "clamped target", shaper.Target(), DefaultConfig().TargetMax)
+	requireEqual(t, "fallback interval", next, time.Hour)
+
+	controller.SetPolicy(nil)
+	controller.step(t.Context())
+
+	requireEqual(t, "policy calls after reset", len(policy.inputs), 1)
+}
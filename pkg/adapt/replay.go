@@ -0,0 +1,221 @@
+package adapt
+
+import (
+	"errors"
+	"time"
+)
+
+// errReplayedFailure stands in for the query error of a failed recorded step.
+var errReplayedFailure = errors.New("adapt: recorded query failed")
+
+// ReplayStep is one recorded slow-loop step fed to Replay.
+type ReplayStep struct {
+	Timestamp time.Time
+	// P95 is the seven-day CPU P95 the step decided from.
+	P95 float64
+	// Failed marks a step whose OCI query failed; it replays as fallback.
+	Failed bool
+	// Suppressed marks a step taken while the fast loop held the shaper at
+	// zero for host load. Host load is not recorded, so it is taken as is.
+	Suppressed bool
+	// Restart marks the first step after the shaper restarted, which starts
+	// over from the fallback target with a fresh policy.
+	Restart bool
+}
+
+// ReplayOutcome is what a replayed step did.
+type ReplayOutcome struct {
+	// Target is the applied target after the step.
+	Target float64
+	// Desired is the target the controller headed for; it differs from
+	// Target while suppression, a blackout, or the change budget hold it back.
+	Desired      float64
+	State        State
+	NextInterval time.Duration
+}
+
+// Replay runs steps through the decision path of a controller configured with
+// cfg and returns the outcome of each, so a proposed configuration can be
+// compared with the running one on the P95 history it actually saw. Blackout
+// windows and the hourly change budget follow the step timestamps; the alarm
+// floor, external holds, and fast-loop suppression transitions between steps
+// are outside the record and are not replayed.
+func Replay(cfg Config, steps []ReplayStep) ([]ReplayOutcome, error) {
+	normalized, _, err := normalizeConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var replay replayer
+
+	replay.cfg = normalized
+	outcomes := make([]ReplayOutcome, 0, len(steps))
+
+	for index, step := range steps {
+		if index == 0 || step.Restart {
+			err = replay.reset()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		outcomes = append(outcomes, replay.step(step))
+	}
+
+	return outcomes, nil
+}
+
+// replayer holds the controller state Replay carries between steps.
+type replayer struct {
+	cfg           Config
+	policy        Policy
+	target        float64
+	desired       float64
+	pendingTarget float64
+	pending       bool
+	changes       []time.Time
+	held          bool
+	failed        bool
+	fallbackFrom  float64
+}
+
+func (r *replayer) reset() error {
+	policy, err := NewPolicy(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	// A new controller runs at its initial target until its first step.
+	_, target := initialTarget(r.cfg)
+
+	*r = replayer{
+		cfg:           r.cfg,
+		policy:        policy,
+		target:        target,
+		desired:       target,
+		pendingTarget: 0,
+		pending:       false,
+		changes:       nil,
+		held:          false,
+		failed:        false,
+		fallbackFrom:  0,
+	}
+
+	return nil
+}
+
+func (r *replayer) step(step ReplayStep) ReplayOutcome {
+	held := step.Suppressed
+
+	for _, window := range r.cfg.Blackout {
+		if window.contains(step.Timestamp) {
+			held = true
+		}
+	}
+
+	r.betweenSteps(step, held)
+
+	var err error
+	if step.Failed {
+		err = errReplayedFailure
+	}
+
+	result := decideStep(r.cfg, r.policy, stepInput{
+		Now:        step.Timestamp,
+		P95:        step.P95,
+		Err:        err,
+		Target:     r.target,
+		Desired:    r.desired,
+		Suppressed: held,
+		Pending:    r.pending,
+	})
+
+	recovered := !step.Failed && r.failed
+	if step.Failed && !r.failed {
+		r.fallbackFrom = r.desired
+	}
+
+	r.failed = step.Failed
+	r.desired = result.Desired
+
+	switch {
+	case held:
+	case recovered && result.Effective <= r.fallbackFrom:
+		r.restore(result.Effective)
+	default:
+		r.apply(step.Timestamp, result.Effective)
+	}
+
+	state := result.SlowState
+	if step.Suppressed {
+		state = StateSuppressed
+	}
+
+	return ReplayOutcome{
+		Target:       r.target,
+		Desired:      r.desired,
+		State:        state,
+		NextInterval: result.NextInterval,
+	}
+}
+
+// betweenSteps stands in for the fast loop, which between two steps applies
+// a held-back increase once the budget allows, drops the target to zero when
+// suppression or a blackout starts, and restores the desired target when they
+// end.
+func (r *replayer) betweenSteps(step ReplayStep, held bool) {
+	if r.pending {
+		r.apply(step.Timestamp, r.pendingTarget)
+	}
+
+	switch {
+	case held && !r.held && step.Suppressed:
+		r.apply(step.Timestamp, 0)
+	case held && !r.held:
+		r.pending = false
+		r.target = 0
+	case !held && r.held:
+		restore := r.desired
+		if restore == 0 {
+			restore = r.cfg.TargetStart
+		}
+
+		r.restore(clamp(restore, r.cfg.TargetMin, r.cfg.TargetMax))
+	}
+
+	r.held = held
+}
+
+// restore mirrors restoreTargetLocked, which spends no change budget.
+func (r *replayer) restore(target float64) {
+	r.pending = false
+	r.target = target
+}
+
+// apply mirrors applyTargetLocked: reductions always apply and increases wait
+// while the hourly change budget is spent.
+func (r *replayer) apply(now time.Time, target float64) {
+	if r.cfg.MaxChangesPerHour <= 0 {
+		r.target = target
+
+		return
+	}
+
+	r.pending = false
+
+	if target == r.target {
+		return
+	}
+
+	r.changes = pruneChanges(r.changes, now)
+
+	if target > r.target && len(r.changes) >= r.cfg.MaxChangesPerHour {
+		r.pendingTarget = target
+		r.pending = true
+
+		return
+	}
+
+	r.changes = append(r.changes, now)
+	r.target = target
+}
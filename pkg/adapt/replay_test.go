@@ -0,0 +1,193 @@
+//nolint:testpackage // tests compare Replay with the controller's unexported steps
+package adapt
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+)
+
+// replayScenario is a generated configuration and recorded step history.
+type replayScenario struct {
+	Config Config
+	Steps  []ReplayStep
+}
+
+// Generate implements quick.Generator.
+func (replayScenario) Generate(r *rand.Rand, size int) reflect.Value {
+	scenario := replayScenario{Config: genConfig(r), Steps: make([]ReplayStep, 1+r.Intn(size+1))}
+	now := time.Unix(1_700_000_000, 0)
+
+	for index := range scenario.Steps {
+		now = now.Add(time.Duration(1 + r.Int63n(int64(2*time.Hour))))
+		scenario.Steps[index] = ReplayStep{
+			Timestamp:  now,
+			P95:        r.Float64(),
+			Failed:     r.Intn(5) == 0,
+			Suppressed: false,
+			Restart:    false,
+		}
+	}
+
+	return reflect.ValueOf(scenario)
+}
+
+func TestReplayMatchesController(t *testing.T) {
+	t.Parallel()
+
+	checkProperty(t, func(scenario replayScenario) bool {
+		outcomes, err := Replay(scenario.Config, scenario.Steps)
+		if err != nil {
+			t.Fatalf("Replay: %v", err)
+		}
+
+		metrics := new(scriptedMetrics)
+
+		controller, err := NewAdaptiveController(
+			scenario.Config, metrics, nil, adapttest.NewDutyCycler(), nil,
+		)
+		if err != nil {
+			t.Fatalf("NewAdaptiveController: %v", err)
+		}
+
+		var now time.Time
+
+		controller.now = func() time.Time { return now }
+
+		for index, step := range scenario.Steps {
+			now = step.Timestamp
+			metrics.value, metrics.err = step.P95, nil
+
+			if step.Failed {
+				metrics.err = errOCIDown
+			}
+
+			// An idle host sample lets the fast loop release a held-back
+			// increase, as it would have between two real steps.
+			controller.handleObservation(est.Observation{ //nolint:exhaustruct
+				Timestamp:   now,
+				Utilisation: 0,
+			})
+
+			_, decision := controller.stepDecision(context.Background())
+			if decision.Target != outcomes[index].Target ||
+				decision.State != outcomes[index].State ||
+				controller.desired != outcomes[index].Desired {
+				t.Logf("step %d: controller %+v desired %v, replay %+v",
+					index, decision, controller.desired, outcomes[index])
+
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// lowP95Step is a successful step offset into 1 March 2026 whose P95 lies
+// below the default goal band, so each one asks for a step up.
+func lowP95Step(offset time.Duration, suppressed, restart bool) ReplayStep {
+	return ReplayStep{
+		Timestamp:  time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC).Add(offset),
+		P95:        0.1,
+		Failed:     false,
+		Suppressed: suppressed,
+		Restart:    restart,
+	}
+}
+
+func TestReplayHoldsTargetWhileSuppressedOrBlackedOut(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.Blackout = []BlackoutWindow{
+		{Start: 2 * time.Hour, End: 3 * time.Hour, Location: time.UTC},
+	}
+
+	// Suppressed, then blacked out, then free, then after a restart.
+	steps := []ReplayStep{
+		lowP95Step(0, false, false),
+		lowP95Step(time.Hour, true, false),
+		lowP95Step(150*time.Minute, false, false),
+		lowP95Step(4*time.Hour, false, false),
+		lowP95Step(5*time.Hour, false, true),
+	}
+
+	outcomes, err := Replay(cfg, steps)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	want := []struct {
+		target, desired float64
+		state           State
+	}{
+		{target: 0.27, desired: 0.27, state: StateNormal},
+		{target: 0, desired: 0.29, state: StateSuppressed},
+		{target: 0, desired: 0.31, state: StateNormal},
+		{target: 0.33, desired: 0.33, state: StateNormal},
+		{target: 0.27, desired: 0.27, state: StateNormal},
+	}
+
+	for index, outcome := range outcomes {
+		if math.Abs(outcome.Target-want[index].target) > 1e-9 ||
+			math.Abs(outcome.Desired-want[index].desired) > 1e-9 ||
+			outcome.State != want[index].state {
+			t.Fatalf("step %d: expected %+v, got %+v", index, want[index], outcome)
+		}
+	}
+}
+
+func TestReplaySpendsChangeBudget(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.MaxChangesPerHour = 1
+
+	steps := []ReplayStep{
+		lowP95Step(0, false, false),
+		lowP95Step(10*time.Minute, false, false),
+		lowP95Step(20*time.Minute, true, false),
+		lowP95Step(90*time.Minute, false, false),
+	}
+
+	outcomes, err := Replay(cfg, steps)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	// The second increase waits for budget; suppression drops the target at
+	// once and lifting it restores the desired target before the next step.
+	want := []float64{0.27, 0.27, 0, 0.33}
+	for index, outcome := range outcomes {
+		if math.Abs(outcome.Target-want[index]) > 1e-9 {
+			t.Fatalf("step %d: expected target %v, got %+v", index, want[index], outcome)
+		}
+	}
+}
+
+func TestReplayRejectsInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.TargetMin = 0.5
+	cfg.TargetMax = 0.3
+
+	_, err := Replay(cfg, []ReplayStep{{
+		Timestamp:  time.Time{},
+		P95:        0.2,
+		Failed:     false,
+		Suppressed: false,
+		Restart:    false,
+	}})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+}
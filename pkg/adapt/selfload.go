@@ -0,0 +1,91 @@
+package adapt
+
+import (
+	"time"
+
+	"oci-cpu-shaper/pkg/est"
+)
+
+// BusyMeter reports the cumulative CPU time the shaper's own workers have spent
+// busy. shape.Pool implements it.
+type BusyMeter interface {
+	BusyTime() time.Duration
+}
+
+// SetSelfLoadMeter makes suppression decisions discount the CPU time reported by
+// meter, so the workers' intentional load does not count as host contention. A
+// nil meter disables the correction.
+func (c *AdaptiveController) SetSelfLoadMeter(meter BusyMeter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.selfLoad = meter
+	c.selfShare = 0
+	c.rebaselineSelfLoadLocked()
+}
+
+// rebaselineSelfLoadLocked starts the next correction window now.
+func (c *AdaptiveController) rebaselineSelfLoadLocked() {
+	if c.selfLoad != nil {
+		c.selfBusy = c.selfLoad.BusyTime()
+	}
+}
+
+// SetSelfLoadHandler installs a callback invoked from the estimator goroutine
+// with the share of host CPU attributed to the workers and subtracted from each
+// observation. A nil handler disables notifications.
+func (c *AdaptiveController) SetSelfLoadHandler(handler func(share float64)) {
+	c.mu.Lock()
+	c.selfLoadHandler = handler
+	c.mu.Unlock()
+}
+
+// SelfLoadShare returns the share of host CPU most recently attributed to the
+// workers.
+func (c *AdaptiveController) SelfLoadShare() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.selfShare
+}
+
+// excludeSelfLoadLocked subtracts the workers' busy time over the observation
+// window from utilisation. /proc/stat counts every CPU, so the busy time is
+// converted to jiffies and divided by the window's total.
+func (c *AdaptiveController) excludeSelfLoadLocked(
+	observation est.Observation,
+	utilisation float64,
+) float64 {
+	if c.selfLoad == nil {
+		return utilisation
+	}
+
+	busy := c.selfLoad.BusyTime()
+	delta := busy - c.selfBusy
+	c.selfBusy = busy
+
+	if delta <= 0 || observation.TotalJiffies == 0 {
+		c.selfShare = 0
+
+		return utilisation
+	}
+
+	jiffies := delta.Seconds() * est.UserHZ
+	c.selfShare = clamp(jiffies/float64(observation.TotalJiffies), 0, utilisation)
+
+	return utilisation - c.selfShare
+}
+
+func (c *AdaptiveController) notifySelfLoad() {
+	c.mu.Lock()
+	handler := c.selfLoadHandler
+	share := c.selfShare
+	enabled := c.selfLoad != nil
+	c.mu.Unlock()
+
+	if !enabled || handler == nil {
+		return
+	}
+
+	handler(share)
+}
@@ -0,0 +1,105 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+)
+
+type fakeBusyMeter struct {
+	busy time.Duration
+}
+
+func (m *fakeBusyMeter) BusyTime() time.Duration {
+	return m.busy
+}
+
+func newSelfLoadController(t *testing.T) *AdaptiveController {
+	t.Helper()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	return controller
+}
+
+// busyObservation reports utilisation over one second of a four-CPU host.
+func busyObservation(utilisation float64) est.Observation {
+	return est.Observation{ //nolint:exhaustruct
+		Timestamp:    time.Unix(1_700_000_000, 0),
+		Utilisation:  utilisation,
+		TotalJiffies: 4 * est.UserHZ,
+	}
+}
+
+func TestAdaptiveControllerExcludesOwnBusyTimeFromSuppression(t *testing.T) {
+	t.Parallel()
+
+	meter := new(fakeBusyMeter)
+	controller := newSelfLoadController(t)
+	controller.SetSelfLoadMeter(meter)
+
+	var reported []float64
+
+	controller.SetSelfLoadHandler(func(share float64) { reported = append(reported, share) })
+
+	// Two of the four CPUs were spent by the workers, so only 45% of the
+	// observed 95% is host load.
+	meter.busy += 2 * time.Second
+	controller.handleObservation(busyObservation(0.95))
+	controller.notifySelfLoad()
+
+	if controller.State() == StateSuppressed {
+		t.Fatal("expected the workers' own load not to trigger suppression")
+	}
+
+	if share := controller.SelfLoadShare(); math.Abs(share-0.5) > 1e-9 {
+		t.Fatalf("expected self load share 0.5, got %v", share)
+	}
+
+	if len(reported) != 1 || math.Abs(reported[0]-0.5) > 1e-9 {
+		t.Fatalf("unexpected reported shares %v", reported)
+	}
+
+	uncorrected := newSelfLoadController(t)
+	uncorrected.handleObservation(busyObservation(0.95))
+
+	if uncorrected.State() != StateSuppressed {
+		t.Fatal("expected the same observation to suppress without the correction")
+	}
+}
+
+func TestAdaptiveControllerSelfLoadShareIsBoundedByUtilisation(t *testing.T) {
+	t.Parallel()
+
+	meter := &fakeBusyMeter{busy: time.Minute}
+	controller := newSelfLoadController(t)
+	controller.SetSelfLoadMeter(meter)
+
+	controller.handleObservation(busyObservation(0.3))
+
+	if share := controller.SelfLoadShare(); share != 0 {
+		t.Fatalf("expected busy time before the meter was installed to be ignored, got %v", share)
+	}
+
+	// More busy time than the window holds, for example across a discarded
+	// sample, never drives utilisation negative.
+	meter.busy += 10 * time.Second
+	controller.handleObservation(busyObservation(0.3))
+
+	if share := controller.SelfLoadShare(); share != 0.3 {
+		t.Fatalf("expected share clamped to utilisation, got %v", share)
+	}
+}
@@ -0,0 +1,52 @@
+package adapt
+
+import "time"
+
+// SetAlarmSilence records that the guardrail alarm is suppressed until until;
+// a zero until clears it. While the alarm is muted the shaper is the only
+// protection against reclamation, so a positive floor keeps the target at or
+// above that level, bounded by TargetMax, until the silence ends. A floor above
+// the applied target takes effect at once without spending the change budget.
+func (c *AdaptiveController) SetAlarmSilence(until time.Time, floor float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.silencedUntil = until
+	c.silenceFloor = floor
+
+	if c.suppressedLocked() {
+		return
+	}
+
+	if raised := c.floorTargetLocked(c.target); raised > c.target {
+		c.restoreTargetLocked(raised)
+	}
+}
+
+// AlarmSilencedUntil returns when the guardrail alarm silence recorded by
+// SetAlarmSilence ends, or the zero time when the alarm is not silenced.
+func (c *AdaptiveController) AlarmSilencedUntil() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.alarmSilencedLocked() {
+		return time.Time{}
+	}
+
+	return c.silencedUntil
+}
+
+func (c *AdaptiveController) alarmSilencedLocked() bool {
+	return !c.silencedUntil.IsZero() && c.now().Before(c.silencedUntil)
+}
+
+// floorTargetLocked raises target to the silence floor while the guardrail
+// alarm is muted. Zero targets pass through so suppression still releases the
+// host to its own workload.
+func (c *AdaptiveController) floorTargetLocked(target float64) float64 {
+	if c.silenceFloor <= 0 || target <= 0 || !c.alarmSilencedLocked() {
+		return target
+	}
+
+	return max(target, min(c.silenceFloor, c.cfg.TargetMax))
+}
@@ -0,0 +1,98 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestAlarmSilenceRaisesTargetToFloorUntilItEnds(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.5},
+		adapttest.Result{Value: 0.5},
+	)
+	shaper := adapttest.NewDutyCycler()
+
+	controller, err := NewAdaptiveController(DefaultConfig(), metrics, nil, shaper, nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	controller.now = func() time.Time { return now }
+
+	until := now.Add(time.Hour)
+	controller.SetAlarmSilence(until, 0.3)
+
+	requireFloatApprox(t, "floor applied immediately", shaper.Target(), 0.3)
+	requireEqual(t, "silenced until", controller.AlarmSilencedUntil(), until)
+
+	// A P95 above goalHigh steps the policy target down to 0.29, below the floor.
+	controller.evaluate(context.Background())
+	requireFloatApprox(t, "policy target held at the floor", controller.Target(), 0.3)
+
+	now = until
+
+	requireEqual(t, "silence expired", controller.AlarmSilencedUntil(), time.Time{})
+
+	controller.evaluate(context.Background())
+	requireFloatApprox(t, "policy resumes after the silence", controller.Target(), 0.29)
+}
+
+func TestAlarmSilenceFloorKeepsHigherTargetsAndSuppression(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	controller.SetAlarmSilence(time.Now().Add(time.Hour), 0.22)
+	requireFloatApprox(t, "higher target kept", controller.Target(), cfg.TargetStart)
+
+	controller.SetAlarmSilence(time.Now().Add(time.Hour), 0.9)
+	requireFloatApprox(t, "floor bounded by targetMax", controller.Target(), cfg.TargetMax)
+
+	controller.mu.Lock()
+	zero := controller.floorTargetLocked(0)
+	controller.mu.Unlock()
+
+	requireFloatApprox(t, "suppressed zero target passes through", zero, 0)
+}
+
+func TestAlarmSilenceWithoutFloorKeepsTarget(t *testing.T) {
+	t.Parallel()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	before := controller.Target()
+	controller.SetAlarmSilence(time.Now().Add(time.Hour), 0)
+
+	requireFloatApprox(t, "target unchanged", controller.Target(), before)
+
+	if controller.AlarmSilencedUntil().IsZero() {
+		t.Fatal("expected the silence to be recorded")
+	}
+}
@@ -0,0 +1,125 @@
+package adapt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Events that move the effective controller state. The slow loop raises the
+// query events, the estimator the host load ones, and RequestSuppression or
+// the expiry of its requests the hold ones.
+const (
+	EventQuerySucceeded = "query succeeded"
+	EventQueryFailed    = "query failed"
+	EventHostLoadHigh   = "host load high"
+	EventHostLoadCooled = "host load cooled"
+	EventHoldRequested  = "hold requested"
+	EventHoldReleased   = "hold released"
+)
+
+// Transition is one edge of the controller state machine: the state moves
+// From To when Event occurs while Guard holds.
+type Transition struct {
+	From  State
+	To    State
+	Event string
+	Guard string
+}
+
+// Statechart describes a state machine: its states, the state it starts in,
+// and the transitions between them.
+type Statechart struct {
+	Initial     State
+	States      []State
+	Transitions []Transition
+}
+
+// ControllerStatechart returns the state machine the AdaptiveController
+// implements for the effective state reported by State. Tests drive the
+// controller through every transition, so the chart fails them when the
+// code gains a state or edge it does not list.
+func ControllerStatechart() Statechart {
+	const (
+		notHeld      = "not suppressed"
+		loadHigh     = "host load >= suppressThreshold"
+		cooledOK     = "host load <= suppressResume, no hold, last query succeeded"
+		cooledFailed = "host load <= suppressResume, no hold, last query failed"
+		releasedOK   = "no load suppression, last query succeeded"
+		releasedFail = "no load suppression, last query failed"
+	)
+
+	return Statechart{
+		Initial: StateFallback,
+		States:  []State{StateNormal, StateFallback, StateSuppressed},
+		Transitions: []Transition{
+			{From: StateFallback, To: StateNormal, Event: EventQuerySucceeded, Guard: notHeld},
+			{From: StateNormal, To: StateFallback, Event: EventQueryFailed, Guard: notHeld},
+			{From: StateNormal, To: StateSuppressed, Event: EventHostLoadHigh, Guard: loadHigh},
+			{From: StateFallback, To: StateSuppressed, Event: EventHostLoadHigh, Guard: loadHigh},
+			{From: StateNormal, To: StateSuppressed, Event: EventHoldRequested, Guard: ""},
+			{From: StateFallback, To: StateSuppressed, Event: EventHoldRequested, Guard: ""},
+			{From: StateSuppressed, To: StateNormal, Event: EventHostLoadCooled, Guard: cooledOK},
+			{
+				From:  StateSuppressed,
+				To:    StateFallback,
+				Event: EventHostLoadCooled,
+				Guard: cooledFailed,
+			},
+			{From: StateSuppressed, To: StateNormal, Event: EventHoldReleased, Guard: releasedOK},
+			{
+				From:  StateSuppressed,
+				To:    StateFallback,
+				Event: EventHoldReleased,
+				Guard: releasedFail,
+			},
+		},
+	}
+}
+
+// DOT renders the chart as a Graphviz digraph.
+func (s Statechart) DOT() string {
+	var builder strings.Builder
+
+	builder.WriteString("digraph controller {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  start [shape=point];\n")
+
+	for _, state := range s.States {
+		fmt.Fprintf(&builder, "  %s [shape=box, style=rounded];\n", state)
+	}
+
+	fmt.Fprintf(&builder, "  start -> %s;\n", s.Initial)
+
+	for _, transition := range s.Transitions {
+		fmt.Fprintf(&builder, "  %s -> %s [label=%q];\n",
+			transition.From, transition.To, transition.label())
+	}
+
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// Mermaid renders the chart as a Mermaid stateDiagram-v2.
+func (s Statechart) Mermaid() string {
+	var builder strings.Builder
+
+	builder.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&builder, "    [*] --> %s\n", s.Initial)
+
+	for _, transition := range s.Transitions {
+		fmt.Fprintf(&builder, "    %s --> %s: %s\n",
+			transition.From, transition.To, transition.label())
+	}
+
+	return builder.String()
+}
+
+// label renders the event and, when present, its guard in brackets.
+func (t Transition) label() string {
+	if t.Guard == "" {
+		return t.Event
+	}
+
+	return t.Event + " [" + t.Guard + "]"
+}
@@ -0,0 +1,162 @@
+//nolint:testpackage // tests drive unexported controller steps
+package adapt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestControllerStatechartListsEveryState(t *testing.T) {
+	t.Parallel()
+
+	chart := ControllerStatechart()
+
+	var states []State
+	for state := State(0); state.String() != "unknown"; state++ {
+		states = append(states, state)
+	}
+
+	requireEqual(t, "state count", len(chart.States), len(states))
+
+	for index, state := range states {
+		requireEqual(t, "state", chart.States[index], state)
+	}
+}
+
+// TestControllerStatechartMatchesController walks the controller through every
+// transition it can take and requires the chart to list exactly those.
+//
+//nolint:funlen // one walk covers every edge of the chart
+func TestControllerStatechartMatchesController(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.SuppressThreshold = 0.8
+	cfg.SuppressResume = 0.5
+
+	metrics := adapttest.NewMetricsClient(
+		adapttest.Result{Value: 0.25, Err: nil},
+		adapttest.Result{Value: 0, Err: errOCIDown},
+		adapttest.Result{Value: 0.25, Err: nil},
+	)
+
+	controller, err := NewAdaptiveController(cfg, metrics, nil, adapttest.NewDutyCycler(), nil)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	chart := ControllerStatechart()
+	requireEqual(t, "initial state", controller.State(), chart.Initial)
+
+	var (
+		observed []Transition
+		sequence int64
+	)
+
+	apply := func(event string, action func()) {
+		t.Helper()
+
+		from := controller.State()
+		action()
+
+		to := controller.State()
+		if from == to {
+			t.Fatalf("%s left the controller in %s", event, from)
+		}
+
+		observed = append(observed, Transition{From: from, To: to, Event: event, Guard: ""})
+	}
+
+	sample := func(utilisation float64) {
+		feedObservation(controller, sequence, utilisation, nil)
+		sequence++
+	}
+
+	step := func() { controller.step(t.Context()) }
+	// The host load is smoothed, so crossing a threshold takes a few samples.
+	loadHigh := func() {
+		for range hostLoadSmoothing * 2 {
+			sample(0.95)
+		}
+	}
+	cool := func() {
+		for range hostLoadSmoothing * 2 {
+			sample(0.05)
+		}
+	}
+	hold := func() { controller.RequestSuppression("test", now.Add(time.Hour)) }
+	release := func() { controller.RequestSuppression("test", time.Time{}) }
+
+	apply(EventQuerySucceeded, step)
+	apply(EventQueryFailed, step)
+	apply(EventHostLoadHigh, loadHigh)
+	apply(EventHostLoadCooled, cool)
+	apply(EventHoldRequested, hold)
+	apply(EventHoldReleased, release)
+	apply(EventQuerySucceeded, step)
+	apply(EventHostLoadHigh, loadHigh)
+	apply(EventHostLoadCooled, cool)
+	apply(EventHoldRequested, hold)
+	apply(EventHoldReleased, release)
+
+	// Guards are conditions, not observations, so edges compare without them.
+	edges := make(map[Transition]bool, len(chart.Transitions))
+	for _, transition := range chart.Transitions {
+		transition.Guard = ""
+		edges[transition] = false
+	}
+
+	for _, transition := range observed {
+		if _, ok := edges[transition]; !ok {
+			t.Fatalf("controller took %s -> %s on %q, which the statechart does not list",
+				transition.From, transition.To, transition.Event)
+		}
+
+		edges[transition] = true
+	}
+
+	for transition, seen := range edges {
+		if !seen {
+			t.Fatalf("statechart lists %s -> %s on %q, which the controller never took",
+				transition.From, transition.To, transition.Event)
+		}
+	}
+}
+
+func TestStatechartRendersDOTAndMermaid(t *testing.T) {
+	t.Parallel()
+
+	chart := Statechart{
+		Initial: StateFallback,
+		States:  []State{StateNormal, StateFallback},
+		Transitions: []Transition{
+			{From: StateFallback, To: StateNormal, Event: EventQuerySucceeded, Guard: "ok"},
+			{From: StateNormal, To: StateFallback, Event: EventQueryFailed, Guard: ""},
+		},
+	}
+
+	dot := chart.DOT()
+	for _, want := range []string{
+		"digraph controller {",
+		"  normal [shape=box, style=rounded];",
+		"  start -> fallback;",
+		`  fallback -> normal [label="query succeeded [ok]"];`,
+		`  normal -> fallback [label="query failed"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected DOT output to contain %q:\n%s", want, dot)
+		}
+	}
+
+	mermaid := "stateDiagram-v2\n" +
+		"    [*] --> fallback\n" +
+		"    fallback --> normal: query succeeded [ok]\n" +
+		"    normal --> fallback: query failed\n"
+	requireEqual(t, "mermaid", chart.Mermaid(), mermaid)
+}
@@ -0,0 +1,77 @@
+package adapt
+
+import "time"
+
+// stepInput is the controller state a slow-loop step decides from. Keeping the
+// decision free of locks, clocks, and side effects lets its invariants be
+// checked over generated scenarios.
+type stepInput struct {
+	Now time.Time
+	P95 float64
+	Err error
+	// Target is the applied target and Desired the one the controller heads
+	// for once suppression or the change budget stop holding it back.
+	Target     float64
+	Desired    float64
+	Suppressed bool
+	Pending    bool
+}
+
+// stepResult is what a slow-loop step asks the controller to do.
+type stepResult struct {
+	// Desired always lies within [TargetMin, TargetMax].
+	Desired float64
+	// Effective is the target the shaper should run at: Desired, or 0 while
+	// suppressed.
+	Effective    float64
+	SlowState    State
+	NextInterval time.Duration
+}
+
+// decideStep computes one slow-loop step. A failed query falls back to
+// FallbackTarget; otherwise policy moves the target from the applied value, or
+// from the desired one while it is being held back.
+func decideStep(cfg Config, policy Policy, input stepInput) stepResult {
+	if input.Err != nil {
+		desired := clamp(cfg.FallbackTarget, cfg.TargetMin, cfg.TargetMax)
+
+		return stepResult{
+			Desired:      desired,
+			Effective:    effectiveTarget(desired, input.Suppressed),
+			SlowState:    StateFallback,
+			NextInterval: cfg.Interval,
+		}
+	}
+
+	base := input.Target
+	if input.Suppressed || input.Pending {
+		base = input.Desired
+	}
+
+	if base == 0 {
+		base = cfg.TargetStart
+	}
+
+	outcome := policy.Decide(PolicyInput{Now: input.Now, P95: input.P95, Target: base})
+	desired := clamp(outcome.Target, cfg.TargetMin, cfg.TargetMax)
+
+	nextInterval := outcome.NextInterval
+	if nextInterval <= 0 {
+		nextInterval = cfg.Interval
+	}
+
+	return stepResult{
+		Desired:      desired,
+		Effective:    effectiveTarget(desired, input.Suppressed),
+		SlowState:    StateNormal,
+		NextInterval: nextInterval,
+	}
+}
+
+func effectiveTarget(desired float64, suppressed bool) float64 {
+	if suppressed {
+		return 0
+	}
+
+	return desired
+}
@@ -0,0 +1,304 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+)
+
+// propertyRuns is the number of generated scenarios each property is checked
+// against.
+const propertyRuns = 2000
+
+var policyNames = []string{PolicyStep, PolicyPID, PolicySchedule} //nolint:gochecknoglobals
+
+// genConfig draws controller configurations that pass validation.
+func genConfig(r *rand.Rand) Config {
+	for {
+		cfg := DefaultConfig()
+		cfg.TargetMin = 0.01 + r.Float64()*0.4
+		cfg.TargetMax = cfg.TargetMin + r.Float64()*(0.9-cfg.TargetMin)
+		cfg.TargetStart = r.Float64() * 0.9
+		cfg.FallbackTarget = r.Float64() * 0.9
+		cfg.StepUp = 0.001 + r.Float64()*0.2
+		cfg.StepDown = 0.001 + r.Float64()*0.2
+		cfg.GoalLow = 0.05 + r.Float64()*0.4
+		cfg.GoalHigh = cfg.GoalLow + r.Float64()*(0.9-cfg.GoalLow)
+		cfg.SuppressThreshold = 0.91 + r.Float64()*0.09
+		cfg.SuppressResume = 0.9 + r.Float64()*(cfg.SuppressThreshold-0.9)
+		cfg.MaxChangesPerHour = r.Intn(4)
+		cfg.Policy = policyNames[r.Intn(len(policyNames))]
+		cfg.InitialState = []string{"fallback", "normal"}[r.Intn(2)]
+		cfg.PID = PIDGains{
+			Proportional: r.Float64(),
+			Integral:     r.Float64(),
+			Derivative:   r.Float64() * 0.5,
+		}
+
+		if cfg.Policy == PolicySchedule {
+			start := time.Duration(r.Int63n(int64(day)))
+			cfg.Schedule = []ScheduleWindow{{
+				Start:     start,
+				End:       (start + time.Duration(1+r.Int63n(int64(day-1)))) % day,
+				TargetMax: cfg.TargetMin + r.Float64()*(cfg.TargetMax-cfg.TargetMin),
+			}}
+		}
+
+		normalized, _, err := normalizeConfig(cfg)
+		if err == nil {
+			return normalized
+		}
+	}
+}
+
+func genTime(r *rand.Rand) time.Time {
+	return time.Unix(1_700_000_000+r.Int63n(int64(30*day/time.Second)), 0)
+}
+
+func newTestPolicy(t *testing.T, cfg Config) Policy {
+	t.Helper()
+
+	policy, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	return policy
+}
+
+// stepScenario is a generated configuration and step input.
+type stepScenario struct {
+	Config  Config
+	Input   stepInput
+	History []float64
+	Other   float64
+}
+
+// Generate implements quick.Generator.
+func (stepScenario) Generate(r *rand.Rand, _ int) reflect.Value {
+	scenario := stepScenario{
+		Config: genConfig(r),
+		Input: stepInput{
+			Now:        genTime(r),
+			P95:        r.Float64(),
+			Err:        nil,
+			Target:     r.Float64(),
+			Desired:    r.Float64(),
+			Suppressed: r.Intn(4) == 0,
+			Pending:    r.Intn(4) == 0,
+		},
+		History: make([]float64, r.Intn(5)),
+		Other:   r.Float64(),
+	}
+
+	if r.Intn(5) == 0 {
+		scenario.Input.Err = errOCIDown
+	}
+
+	if r.Intn(5) == 0 {
+		scenario.Input.Target = 0
+	}
+
+	for index := range scenario.History {
+		scenario.History[index] = r.Float64()
+	}
+
+	return reflect.ValueOf(scenario)
+}
+
+func checkProperty(t *testing.T, property any) {
+	t.Helper()
+
+	err := quick.Check(property, &quick.Config{MaxCount: propertyRuns}) //nolint:exhaustruct
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecideStepKeepsTargetWithinBounds(t *testing.T) {
+	t.Parallel()
+
+	checkProperty(t, func(scenario stepScenario) bool {
+		cfg := scenario.Config
+		result := decideStep(cfg, newTestPolicy(t, cfg), scenario.Input)
+
+		return result.Desired >= cfg.TargetMin && result.Desired <= cfg.TargetMax &&
+			result.NextInterval > 0
+	})
+}
+
+func TestDecideStepSuppressionForcesZero(t *testing.T) {
+	t.Parallel()
+
+	checkProperty(t, func(scenario stepScenario) bool {
+		cfg := scenario.Config
+		result := decideStep(cfg, newTestPolicy(t, cfg), scenario.Input)
+
+		if scenario.Input.Suppressed {
+			return result.Effective == 0
+		}
+
+		return result.Effective == result.Desired
+	})
+}
+
+func TestDecideStepRespondsMonotonicallyToP95(t *testing.T) {
+	t.Parallel()
+
+	checkProperty(t, func(scenario stepScenario) bool {
+		cfg := scenario.Config
+		input := scenario.Input
+		input.Err = nil
+
+		low, high := math.Min(input.P95, scenario.Other), math.Max(input.P95, scenario.Other)
+
+		// Both policies see the same history, so stateful policies such as PID
+		// differ only in the final observation.
+		decide := func(p95 float64) float64 {
+			policy := newTestPolicy(t, cfg)
+			for _, previous := range scenario.History {
+				policy.Decide(PolicyInput{Now: input.Now, P95: previous, Target: input.Target})
+			}
+
+			input.P95 = p95
+
+			return decideStep(cfg, policy, input).Desired
+		}
+
+		return decide(low) >= decide(high)
+	})
+}
+
+// simulation drives a real controller through generated slow-loop steps,
+// estimator observations, suppression requests, and clock jumps.
+type simulation struct {
+	Config Config
+	Events []simEvent
+}
+
+type simEvent struct {
+	Kind    int
+	Value   float64
+	Failed  bool
+	Advance time.Duration
+}
+
+const (
+	simStep = iota
+	simObservation
+	simHold
+	simRelease
+	simEventKinds
+)
+
+// Generate implements quick.Generator.
+func (simulation) Generate(r *rand.Rand, size int) reflect.Value {
+	sim := simulation{Config: genConfig(r), Events: make([]simEvent, 1+r.Intn(size+1))}
+
+	for index := range sim.Events {
+		sim.Events[index] = simEvent{
+			Kind:    r.Intn(simEventKinds),
+			Value:   r.Float64(),
+			Failed:  r.Intn(6) == 0,
+			Advance: time.Duration(r.Int63n(int64(2 * time.Hour))),
+		}
+	}
+
+	return reflect.ValueOf(sim)
+}
+
+// scriptedMetrics answers each query with the value set before the step.
+type scriptedMetrics struct {
+	value float64
+	err   error
+}
+
+func (s *scriptedMetrics) QueryP95CPU(context.Context, string) (float64, error) {
+	return s.value, s.err
+}
+
+func TestControllerSimulationInvariants(t *testing.T) {
+	t.Parallel()
+
+	checkProperty(t, func(sim simulation) bool {
+		metrics := new(scriptedMetrics)
+		shaper := adapttest.NewDutyCycler()
+
+		controller, err := NewAdaptiveController(sim.Config, metrics, nil, shaper, nil)
+		if err != nil {
+			t.Fatalf("NewAdaptiveController: %v", err)
+		}
+
+		now := time.Unix(1_700_000_000, 0)
+		controller.now = func() time.Time { return now }
+
+		for _, event := range sim.Events {
+			now = now.Add(event.Advance)
+			applyEvent(controller, metrics, event, now)
+
+			if !simulationInvariantsHold(controller, shaper) {
+				t.Logf("invariant broken after %+v", event)
+
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func applyEvent(
+	controller *AdaptiveController,
+	metrics *scriptedMetrics,
+	event simEvent,
+	now time.Time,
+) {
+	switch event.Kind {
+	case simStep:
+		metrics.value, metrics.err = event.Value, nil
+		if event.Failed {
+			metrics.err = errOCIDown
+		}
+
+		controller.step(context.Background())
+	case simObservation:
+		controller.handleObservation(est.Observation{ //nolint:exhaustruct
+			Timestamp:   now,
+			Utilisation: event.Value,
+		})
+	case simHold:
+		controller.RequestSuppression("maintenance", now.Add(event.Advance))
+	case simRelease:
+		controller.RequestSuppression("maintenance", time.Time{})
+	}
+}
+
+// simulationInvariantsHold checks that the shaper mirrors the applied target,
+// that suppression keeps it at zero, and that an applied target otherwise stays
+// within bounds; zero is the only value outside them, reached only while
+// suppressed because restores bypass the change budget.
+func simulationInvariantsHold(controller *AdaptiveController, shaper *adapttest.DutyCycler) bool {
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	cfg := controller.cfg
+	target := controller.target
+
+	switch {
+	case shaper.Target() != target:
+		return false
+	case controller.suppressedLocked():
+		return target == 0 && controller.state == StateSuppressed
+	default:
+		return target >= cfg.TargetMin && target <= cfg.TargetMax &&
+			controller.desired >= cfg.TargetMin && controller.desired <= cfg.TargetMax
+	}
+}
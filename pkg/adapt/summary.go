@@ -0,0 +1,115 @@
+package adapt
+
+import "time"
+
+// RunSummary digests a controller run for the shutdown report.
+type RunSummary struct {
+	// Uptime is measured from the start of Run.
+	Uptime time.Duration
+	// Steps counts slow-loop steps, including forced ones, and OCIErrors the
+	// steps whose Monitoring query failed.
+	Steps     int
+	OCIErrors int
+	// StateDurations holds the time spent in each effective state.
+	StateDurations map[State]time.Duration
+	FinalTarget    float64
+	// AverageDutyCycle is the applied target weighted by how long it was held.
+	AverageDutyCycle float64
+}
+
+// runStats accumulates a RunSummary as the controller changes state and target.
+type runStats struct {
+	started     time.Time
+	steps       int
+	ociErrors   int
+	state       State
+	stateSince  time.Time
+	inState     map[State]time.Duration
+	target      float64
+	targetSince time.Time
+	// dutySeconds integrates the applied target over time.
+	dutySeconds float64
+}
+
+func (s *runStats) begin(now time.Time, state State, target float64) {
+	*s = runStats{
+		started:     now,
+		state:       state,
+		stateSince:  now,
+		inState:     make(map[State]time.Duration),
+		target:      target,
+		targetSince: now,
+	}
+}
+
+func (s *runStats) running() bool {
+	return !s.started.IsZero()
+}
+
+func (s *runStats) observeStep(err error) {
+	s.steps++
+	if err != nil {
+		s.ociErrors++
+	}
+}
+
+func (s *runStats) observeState(now time.Time, state State) {
+	if !s.running() || state == s.state {
+		return
+	}
+
+	s.inState[s.state] += elapsed(s.stateSince, now)
+	s.state, s.stateSince = state, now
+}
+
+func (s *runStats) observeTarget(now time.Time, target float64) {
+	if !s.running() || target == s.target {
+		return
+	}
+
+	s.dutySeconds += s.target * elapsed(s.targetSince, now).Seconds()
+	s.target, s.targetSince = target, now
+}
+
+func (s *runStats) summary(now time.Time, target float64) RunSummary {
+	summary := RunSummary{
+		Uptime:           0,
+		Steps:            s.steps,
+		OCIErrors:        s.ociErrors,
+		StateDurations:   make(map[State]time.Duration, len(s.inState)+1),
+		FinalTarget:      target,
+		AverageDutyCycle: target,
+	}
+
+	if !s.running() {
+		return summary
+	}
+
+	for state, duration := range s.inState {
+		summary.StateDurations[state] = duration
+	}
+
+	summary.StateDurations[s.state] += elapsed(s.stateSince, now)
+	summary.Uptime = elapsed(s.started, now)
+
+	if summary.Uptime > 0 {
+		duty := s.dutySeconds + s.target*elapsed(s.targetSince, now).Seconds()
+		summary.AverageDutyCycle = duty / summary.Uptime.Seconds()
+	}
+
+	return summary
+}
+
+// elapsed ignores backwards clock steps so durations never go negative.
+func elapsed(from, to time.Time) time.Duration {
+	return max(to.Sub(from), 0)
+}
+
+// Summary reports how the controller has run so far. Before Run starts it
+// carries only the current target.
+func (c *AdaptiveController) Summary() RunSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats.summary(c.now(), c.target)
+}
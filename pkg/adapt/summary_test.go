@@ -0,0 +1,92 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestAdaptiveControllerSummaryAccountsStatesAndDutyCycle(t *testing.T) {
+	t.Parallel()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(
+			adapttest.Result{Value: 0.05},
+			adapttest.Result{Err: errOCIDown},
+		),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	controller.now = func() time.Time { return now }
+
+	// Run starts the accounting; the loop itself is driven by hand below.
+	controller.mu.Lock()
+	controller.stats.begin(now, controller.state, controller.target)
+	controller.mu.Unlock()
+
+	fallback := controller.Target()
+
+	now = now.Add(10 * time.Minute)
+	controller.step(context.Background())
+
+	normal := controller.Target()
+	if normal == fallback {
+		t.Fatalf("expected the successful step to move the target off %v", fallback)
+	}
+
+	now = now.Add(30 * time.Minute)
+	controller.step(context.Background())
+
+	now = now.Add(20 * time.Minute)
+	summary := controller.Summary()
+
+	if summary.Uptime != time.Hour || summary.Steps != 2 || summary.OCIErrors != 1 {
+		t.Fatalf("unexpected counters %+v", summary)
+	}
+
+	if summary.StateDurations[StateFallback] != 30*time.Minute ||
+		summary.StateDurations[StateNormal] != 30*time.Minute {
+		t.Fatalf("unexpected state durations %v", summary.StateDurations)
+	}
+
+	if summary.FinalTarget != fallback {
+		t.Fatalf("expected final target %v, got %v", fallback, summary.FinalTarget)
+	}
+
+	want := (fallback*30 + normal*30) / 60
+	if math.Abs(summary.AverageDutyCycle-want) > 1e-9 {
+		t.Fatalf("expected average duty cycle %v, got %v", want, summary.AverageDutyCycle)
+	}
+}
+
+func TestAdaptiveControllerSummaryBeforeRun(t *testing.T) {
+	t.Parallel()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	summary := controller.Summary()
+	if summary.Uptime != 0 || summary.Steps != 0 || len(summary.StateDurations) != 0 ||
+		summary.FinalTarget != controller.Target() {
+		t.Fatalf("unexpected summary before run %+v", summary)
+	}
+}
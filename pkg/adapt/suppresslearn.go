@@ -0,0 +1,291 @@
+package adapt
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SuppressLearningBins is the number of equal-width host load buckets the
+	// learned distribution keeps, giving a resolution of one percentage point.
+	SuppressLearningBins = 100
+
+	// suppressLearnMaxGap is the longest gap between host observations that
+	// still counts towards SuppressLearning.LearnFor; longer gaps (pauses,
+	// sampler restarts) are skipped.
+	suppressLearnMaxGap = time.Minute
+
+	defaultLearnQuantile     = 0.95
+	defaultLearnHeadroom     = 0.10
+	defaultLearnResumeGap    = 0.15
+	defaultLearnThresholdMin = 0.60
+	defaultLearnThresholdMax = 0.95
+)
+
+// SuppressLearning replaces the fixed SuppressThreshold and SuppressResume with
+// values learned from the host's own background load. The controller records
+// the distribution of the smoothed host load, without the workers' share,
+// until LearnFor of observations are in, and then suppresses once the load
+// climbs Headroom above its Quantile, bounded by ThresholdMin and
+// ThresholdMax, resuming ResumeGap below that. Until then the configured
+// thresholds apply.
+type SuppressLearning struct {
+	// LearnFor is how much observed host time the distribution covers before
+	// the thresholds are set. Zero disables learning.
+	LearnFor     time.Duration
+	Quantile     float64
+	Headroom     float64
+	ResumeGap    float64
+	ThresholdMin float64
+	ThresholdMax float64
+}
+
+// DefaultSuppressLearning returns the learning parameters used when only
+// LearnFor is configured.
+func DefaultSuppressLearning() SuppressLearning {
+	return SuppressLearning{
+		LearnFor:     0,
+		Quantile:     defaultLearnQuantile,
+		Headroom:     defaultLearnHeadroom,
+		ResumeGap:    defaultLearnResumeGap,
+		ThresholdMin: defaultLearnThresholdMin,
+		ThresholdMax: defaultLearnThresholdMax,
+	}
+}
+
+// SuppressLearningState is the progress of the learning, kept so it resumes
+// across restarts. Threshold and Resume are zero until Observed reaches
+// SuppressLearning.LearnFor.
+type SuppressLearningState struct {
+	Observed  time.Duration `json:"observed"`
+	Histogram []uint64      `json:"histogram"`
+	Threshold float64       `json:"threshold,omitempty"`
+	Resume    float64       `json:"resume,omitempty"`
+}
+
+// Learned reports whether the state holds learned thresholds.
+func (s SuppressLearningState) Learned() bool {
+	return s.Threshold > 0
+}
+
+func coerceSuppressLearning(learning SuppressLearning) SuppressLearning {
+	defaults := DefaultSuppressLearning()
+
+	learning.Quantile = ensureFloat(learning.Quantile, defaults.Quantile)
+	learning.ResumeGap = ensureFloat(learning.ResumeGap, defaults.ResumeGap)
+	learning.ThresholdMin = ensureFloat(learning.ThresholdMin, defaults.ThresholdMin)
+	learning.ThresholdMax = ensureFloat(learning.ThresholdMax, defaults.ThresholdMax)
+
+	return learning
+}
+
+// validateSuppressLearning rejects parameters that could learn a threshold or
+// resume level at or below the targets, which would suppress the workers on
+// their own load.
+func validateSuppressLearning(cfg Config) error {
+	learning := cfg.SuppressLearning
+	if learning.LearnFor <= 0 {
+		return nil
+	}
+
+	switch {
+	case learning.Quantile <= 0 || learning.Quantile > 1:
+		return fmt.Errorf(
+			"%w: controller.suppressLearning.quantile (%.2f) must be within (0, 1]",
+			ErrInvalidConfig,
+			learning.Quantile,
+		)
+	case learning.Headroom < 0 || learning.ResumeGap <= 0:
+		return fmt.Errorf(
+			"%w: controller.suppressLearning.headroom must not be negative and resumeGap "+
+				"must be positive",
+			ErrInvalidConfig,
+		)
+	case learning.ThresholdMin > learning.ThresholdMax || learning.ThresholdMax > 1:
+		return fmt.Errorf(
+			"%w: controller.suppressLearning.thresholdMin (%.2f) must not exceed "+
+				"thresholdMax (%.2f), which must not exceed 1",
+			ErrInvalidConfig,
+			learning.ThresholdMin,
+			learning.ThresholdMax,
+		)
+	}
+
+	lowestResume := learning.ThresholdMin - learning.ResumeGap
+
+	for _, bound := range []struct {
+		name  string
+		value float64
+	}{
+		{"controller.targetMax", cfg.TargetMax},
+		{"controller.fallbackTarget", cfg.FallbackTarget},
+		{"controller.goalHigh", cfg.GoalHigh},
+	} {
+		if lowestResume <= bound.value {
+			return fmt.Errorf(
+				"%w: controller.suppressLearning.thresholdMin minus resumeGap (%.2f) must be "+
+					"greater than %s (%.2f)",
+				ErrInvalidConfig,
+				lowestResume,
+				bound.name,
+				bound.value,
+			)
+		}
+	}
+
+	return nil
+}
+
+// learnedThresholds returns the threshold and resume level histogram implies
+// under learning, and the background load quantile they derive from.
+func learnedThresholds(
+	learning SuppressLearning,
+	histogram []uint64,
+) (float64, float64, float64) {
+	var total uint64
+	for _, count := range histogram {
+		total += count
+	}
+
+	quantile := 0.0
+
+	if total > 0 {
+		rank := learning.Quantile * float64(total)
+
+		var seen uint64
+
+		for bin, count := range histogram {
+			seen += count
+			if float64(seen) >= rank {
+				quantile = float64(bin+1) / float64(len(histogram))
+
+				break
+			}
+		}
+	}
+
+	threshold := clamp(quantile+learning.Headroom, learning.ThresholdMin, learning.ThresholdMax)
+
+	return threshold, threshold - learning.ResumeGap, quantile
+}
+
+// SetSuppressLearningHandler installs a callback invoked from the estimator
+// goroutine once the suppression thresholds have been learned. A nil handler
+// disables notifications.
+func (c *AdaptiveController) SetSuppressLearningHandler(
+	handler func(state SuppressLearningState),
+) {
+	c.mu.Lock()
+	c.learnHandler = handler
+	c.mu.Unlock()
+}
+
+// SuppressLearning returns the current learning progress. The histogram is
+// empty while SuppressLearning.LearnFor is zero.
+func (c *AdaptiveController) SuppressLearning() SuppressLearningState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.learnStateLocked()
+}
+
+// RestoreSuppressLearning resumes learning from state, as saved by a previous
+// run. A state whose histogram does not have SuppressLearningBins buckets is
+// ignored. When state already covers LearnFor the thresholds are derived
+// under the current parameters and applied at once, without notifying the
+// handler.
+func (c *AdaptiveController) RestoreSuppressLearning(state SuppressLearningState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cfg.SuppressLearning.LearnFor <= 0 || len(state.Histogram) != SuppressLearningBins {
+		return
+	}
+
+	copy(c.learnHist, state.Histogram)
+	c.learnObserved = max(state.Observed, 0)
+	c.learnAt = time.Time{}
+	c.learned = false
+
+	if c.learnObserved >= c.cfg.SuppressLearning.LearnFor {
+		c.applyLearnedThresholdsLocked()
+	}
+}
+
+func (c *AdaptiveController) learnStateLocked() SuppressLearningState {
+	state := SuppressLearningState{
+		Observed:  c.learnObserved,
+		Histogram: append([]uint64(nil), c.learnHist...),
+		Threshold: 0,
+		Resume:    0,
+	}
+
+	if c.learned {
+		state.Threshold = c.cfg.SuppressThreshold
+		state.Resume = c.cfg.SuppressResume
+	}
+
+	return state
+}
+
+// learnHostLoadLocked adds the smoothed host load to the distribution and
+// applies the learned thresholds once it covers LearnFor.
+func (c *AdaptiveController) learnHostLoadLocked(at time.Time) {
+	if c.cfg.SuppressLearning.LearnFor <= 0 || c.learned {
+		return
+	}
+
+	if !c.learnAt.IsZero() {
+		gap := at.Sub(c.learnAt)
+		if gap > 0 && gap <= suppressLearnMaxGap {
+			c.learnObserved += gap
+		}
+	}
+
+	c.learnAt = at
+
+	bin := int(c.hostLoad * SuppressLearningBins)
+	c.learnHist[min(max(bin, 0), SuppressLearningBins-1)]++
+
+	if c.learnObserved < c.cfg.SuppressLearning.LearnFor {
+		return
+	}
+
+	c.applyLearnedThresholdsLocked()
+	c.learnChanged = true
+}
+
+func (c *AdaptiveController) applyLearnedThresholdsLocked() {
+	threshold, resume, quantile := learnedThresholds(c.cfg.SuppressLearning, c.learnHist)
+
+	c.cfg.SuppressThreshold = threshold
+	c.cfg.SuppressResume = resume
+	c.learned = true
+
+	c.logger.Info("suppression thresholds learned from background load",
+		"quantile", c.cfg.SuppressLearning.Quantile,
+		"backgroundLoad", quantile,
+		"threshold", threshold,
+		"resume", resume,
+		"observed", c.learnObserved)
+}
+
+func (c *AdaptiveController) notifySuppressLearning() {
+	c.mu.Lock()
+	handler := c.learnHandler
+	changed := c.learnChanged
+	c.learnChanged = false
+
+	var state SuppressLearningState
+	if changed {
+		state = c.learnStateLocked()
+	}
+
+	c.mu.Unlock()
+
+	if !changed || handler == nil {
+		return
+	}
+
+	handler(state)
+}
@@ -0,0 +1,199 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+	"oci-cpu-shaper/pkg/est"
+)
+
+func newLearningController(t *testing.T, learnFor time.Duration) *AdaptiveController {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.SuppressLearning.LearnFor = learnFor
+
+	controller, err := NewAdaptiveController(
+		cfg,
+		adapttest.NewMetricsClient(),
+		nil,
+		adapttest.NewDutyCycler(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	return controller
+}
+
+func hostObservation(at time.Time, utilisation float64) est.Observation {
+	return est.Observation{ //nolint:exhaustruct
+		Timestamp:    at,
+		Utilisation:  utilisation,
+		TotalJiffies: 4 * est.UserHZ,
+	}
+}
+
+func TestAdaptiveControllerLearnsSuppressionThresholds(t *testing.T) {
+	t.Parallel()
+
+	controller := newLearningController(t, 10*time.Second)
+
+	var learned []SuppressLearningState
+
+	controller.SetSuppressLearningHandler(func(state SuppressLearningState) {
+		learned = append(learned, state)
+	})
+
+	start := time.Unix(1_700_000_000, 0)
+
+	// A chronically busy host: 80% would sit just below the fixed 85%
+	// threshold and keep tripping it.
+	for second := range 6 {
+		at := start.Add(time.Duration(second) * time.Second)
+		controller.handleObservation(hostObservation(at, 0.8))
+	}
+
+	// A gap longer than a minute does not count as observed time.
+	start = start.Add(time.Hour)
+
+	for second := range 5 {
+		at := start.Add(time.Duration(second) * time.Second)
+		controller.handleObservation(hostObservation(at, 0.8))
+	}
+
+	if len(learned) != 0 || controller.SuppressLearning().Learned() {
+		t.Fatalf("expected learning to continue across the gap, got %v", learned)
+	}
+
+	controller.handleObservation(hostObservation(start.Add(5*time.Second), 0.8))
+
+	if len(learned) != 1 {
+		t.Fatalf("expected a single learning notification, got %d", len(learned))
+	}
+
+	state := learned[0]
+	if state.Observed != 10*time.Second || len(state.Histogram) != SuppressLearningBins {
+		t.Fatalf("unexpected learning state %+v", state)
+	}
+
+	if math.Abs(state.Threshold-0.91) > 1e-9 || math.Abs(state.Resume-0.76) > 1e-9 {
+		t.Fatalf("expected threshold 0.91 and resume 0.76, got %v and %v",
+			state.Threshold, state.Resume)
+	}
+
+	active := controller.Config()
+	if active.SuppressThreshold != state.Threshold || active.SuppressResume != state.Resume {
+		t.Fatalf("expected the learned thresholds to apply, got %v and %v",
+			active.SuppressThreshold, active.SuppressResume)
+	}
+
+	controller.handleObservation(hostObservation(start.Add(6*time.Second), 0.2))
+
+	if len(learned) != 1 || controller.SuppressLearning().Observed != 10*time.Second {
+		t.Fatal("expected learning to stop once the thresholds are set")
+	}
+}
+
+func TestAdaptiveControllerRestoresSuppressLearning(t *testing.T) {
+	t.Parallel()
+
+	histogram := make([]uint64, SuppressLearningBins)
+	histogram[10] = 100
+
+	controller := newLearningController(t, time.Hour)
+	controller.RestoreSuppressLearning(SuppressLearningState{
+		Observed:  time.Minute,
+		Histogram: histogram[:10],
+		Threshold: 0,
+		Resume:    0,
+	})
+
+	if controller.SuppressLearning().Observed != 0 {
+		t.Fatal("expected a histogram of the wrong size to be ignored")
+	}
+
+	controller.RestoreSuppressLearning(SuppressLearningState{
+		Observed:  time.Minute,
+		Histogram: histogram,
+		Threshold: 0,
+		Resume:    0,
+	})
+
+	if state := controller.SuppressLearning(); state.Learned() || state.Histogram[10] != 100 {
+		t.Fatalf("expected partial progress to resume, got %+v", state)
+	}
+
+	controller.RestoreSuppressLearning(SuppressLearningState{
+		Observed:  2 * time.Hour,
+		Histogram: histogram,
+		Threshold: 0.99,
+		Resume:    0.9,
+	})
+
+	// A near-idle host learns the lower bound, whatever the saved thresholds.
+	state := controller.SuppressLearning()
+	if !state.Learned() || state.Threshold != 0.6 || math.Abs(state.Resume-0.45) > 1e-9 {
+		t.Fatalf("expected thresholds 0.6 and 0.45, got %+v", state)
+	}
+
+	disabled := newLearningController(t, 0)
+	disabled.RestoreSuppressLearning(state)
+
+	if got := disabled.SuppressLearning(); got.Learned() || len(got.Histogram) != 0 {
+		t.Fatalf("expected restore to be ignored without learning, got %+v", got)
+	}
+}
+
+func TestLearnedThresholdsWithoutSamplesUseLowerBound(t *testing.T) {
+	t.Parallel()
+
+	threshold, resume, quantile := learnedThresholds(
+		DefaultSuppressLearning(),
+		make([]uint64, SuppressLearningBins),
+	)
+	if threshold != 0.6 || math.Abs(resume-0.45) > 1e-9 || quantile != 0 {
+		t.Fatalf("unexpected thresholds %v, %v from quantile %v", threshold, resume, quantile)
+	}
+}
+
+func TestValidateConfigRejectsInvalidSuppressLearning(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]func(*SuppressLearning){
+		"quantile":  func(l *SuppressLearning) { l.Quantile = 1.5 },
+		"headroom":  func(l *SuppressLearning) { l.Headroom = -0.1 },
+		"resumeGap": func(l *SuppressLearning) { l.ResumeGap = -0.1 },
+		"bounds":    func(l *SuppressLearning) { l.ThresholdMin, l.ThresholdMax = 0.9, 0.8 },
+		"maximum":   func(l *SuppressLearning) { l.ThresholdMax = 1.2 },
+		"targets":   func(l *SuppressLearning) { l.ThresholdMin = 0.5 },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultConfig()
+			cfg.SuppressLearning.LearnFor = time.Hour
+			mutate(&cfg.SuppressLearning)
+
+			err := ValidateConfig(cfg)
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.SuppressLearning.Quantile = 1.5
+
+	err := ValidateConfig(cfg)
+	if err != nil {
+		t.Fatalf("expected disabled learning to skip validation, got %v", err)
+	}
+}
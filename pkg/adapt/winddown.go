@@ -0,0 +1,118 @@
+package adapt
+
+import (
+	"context"
+	"time"
+)
+
+// Wind-down planning for runs with a known end. WindDownSteps reductions take
+// the target to zero one step before the deadline, so the final interval runs
+// with idle workers and the last metrics and history samples show the run
+// finished at zero rather than cut off mid-cycle.
+const (
+	WindDownSteps = 10
+	// WindDownShare is the fraction of the run spent ramping down, bounded
+	// by MaxWindDownRamp for long runs.
+	WindDownShare   = 0.1
+	MaxWindDownRamp = 5 * time.Minute
+)
+
+// WindDownRamp returns the ramp length planned for a run lasting window.
+func WindDownRamp(window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	return min(time.Duration(float64(window)*WindDownShare), MaxWindDownRamp)
+}
+
+// SetWindDown plans the end of the run: from ramp before deadline the
+// controller lowers the target in WindDownSteps equal reductions from the value
+// it held when the ramp began, reaching zero one step before deadline. While
+// winding down no step, restore, or silence floor raises the target above the
+// ramp. It must be called before Run; a zero deadline or ramp disables it.
+func (c *AdaptiveController) SetWindDown(deadline time.Time, ramp time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.windDownAt = deadline
+	c.windDownRamp = ramp
+}
+
+// windDown applies the planned ramp until it completes or ctx is done.
+func (c *AdaptiveController) windDown(ctx context.Context) {
+	c.mu.Lock()
+	deadline := c.windDownAt
+	ramp := c.windDownRamp
+	c.mu.Unlock()
+
+	if deadline.IsZero() || ramp <= 0 {
+		return
+	}
+
+	step := ramp / WindDownSteps
+	start := deadline.Add(-ramp)
+
+	if !sleepUntil(ctx, c.now, start) {
+		return
+	}
+
+	c.mu.Lock()
+	base := c.target
+	c.windingDown = true
+	c.windDownCeiling = base
+	c.logger.Info("winding down before shutdown",
+		"deadline", deadline, "ramp", ramp, "target", base)
+	c.mu.Unlock()
+
+	for index := 1; index <= WindDownSteps; index++ {
+		c.mu.Lock()
+		c.windDownCeiling = base * float64(WindDownSteps-index) / WindDownSteps
+		c.hasPending = false
+
+		if c.target > c.windDownCeiling {
+			c.setTargetLocked(c.windDownCeiling)
+		}
+
+		c.mu.Unlock()
+
+		if index == WindDownSteps {
+			break
+		}
+
+		if !sleepUntil(ctx, c.now, start.Add(time.Duration(index)*step)) {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.logger.Info("wind-down complete; workers idle until shutdown", "deadline", deadline)
+	c.mu.Unlock()
+}
+
+// ceilTargetLocked caps target at the wind-down ramp once it has begun, and at
+// zero during a blackout window.
+func (c *AdaptiveController) ceilTargetLocked(target float64) float64 {
+	if c.blackout {
+		return 0
+	}
+
+	if !c.windingDown {
+		return target
+	}
+
+	return min(target, c.windDownCeiling)
+}
+
+// sleepUntil waits until now reaches at and reports false if ctx ends first.
+func sleepUntil(ctx context.Context, now func() time.Time, at time.Time) bool {
+	timer := time.NewTimer(max(at.Sub(now()), 0))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,115 @@
+//nolint:testpackage // tests require access to internal helpers
+package adapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt/adapttest"
+)
+
+func TestWindDownRamp(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		window time.Duration
+		ramp   time.Duration
+	}{
+		{window: 0, ramp: 0},
+		{window: 4 * time.Second, ramp: 400 * time.Millisecond},
+		{window: 10 * time.Minute, ramp: time.Minute},
+		{window: 24 * time.Hour, ramp: MaxWindDownRamp},
+	}
+
+	for _, testCase := range testCases {
+		requireEqual(t, testCase.window.String(), WindDownRamp(testCase.window), testCase.ramp)
+	}
+}
+
+func TestWindDownRampsTargetToZeroBeforeDeadline(t *testing.T) {
+	t.Parallel()
+
+	shaper := adapttest.NewDutyCycler()
+	recorder := adapttest.NewRecorder()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.1}),
+		nil,
+		shaper,
+		recorder,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	const ramp = 50 * time.Millisecond
+
+	base := controller.Target()
+	controller.SetWindDown(time.Now().Add(ramp), ramp)
+
+	controller.windDown(t.Context())
+
+	calls := shaper.Calls()[1:]
+	requireEqual(t, "reductions", len(calls), WindDownSteps)
+
+	for index, target := range calls {
+		want := base * float64(WindDownSteps-index-1) / WindDownSteps
+		requireFloatApprox(t, "ramp target", target, want)
+	}
+
+	requireFloatApprox(t, "recorded target", recorder.Snapshot().Target, 0)
+
+	// Neither a policy step nor a restore raises the target after the ramp.
+	controller.mu.Lock()
+	controller.applyTargetLocked(0.3)
+	controller.restoreTargetLocked(0.3)
+	controller.mu.Unlock()
+
+	requireFloatApprox(t, "target after wind-down", shaper.Target(), 0)
+}
+
+func TestWindDownStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	shaper := adapttest.NewDutyCycler()
+
+	controller, err := NewAdaptiveController(
+		DefaultConfig(),
+		adapttest.NewMetricsClient(adapttest.Result{Value: 0.1}),
+		nil,
+		shaper,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewAdaptiveController: %v", err)
+	}
+
+	// Without a plan wind-down returns at once.
+	controller.windDown(t.Context())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	controller.SetWindDown(time.Now().Add(time.Hour), time.Minute)
+	controller.windDown(ctx)
+
+	requireEqual(t, "targets set", len(shaper.Calls()), 1)
+
+	// Cancelled mid-ramp, the reductions stop where they are.
+	ctx, cancel = context.WithCancel(t.Context())
+	controller.SetWindDown(time.Now().Add(time.Minute), time.Minute)
+
+	go func() {
+		for len(shaper.Calls()) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+	}()
+
+	controller.windDown(ctx)
+
+	requireEqual(t, "targets set after cancellation", len(shaper.Calls()), 2)
+}
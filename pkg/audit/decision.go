@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+// maxErrorLength bounds the error text of a decision record so a verbose
+// OCI error does not push the whole record past the slot.
+const maxErrorLength = 200
+
+// DecisionRecord converts a controller decision into a KindDecision record.
+func DecisionRecord(decision adapt.Decision) Record {
+	fields := map[string]any{
+		"mode":         decision.Mode,
+		"state":        decision.State.String(),
+		"p95":          decision.P95,
+		"target":       decision.Target,
+		"nextInterval": decision.NextInterval.String(),
+	}
+
+	if decision.StepID != "" {
+		fields["step"] = decision.StepID
+	}
+
+	if decision.Err != nil {
+		message := decision.Err.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+
+		fields["error"] = message
+	}
+
+	return Record{
+		Sequence:  0,
+		Timestamp: decision.Timestamp,
+		Kind:      KindDecision,
+		Fields:    fields,
+	}
+}
+
+// SetErrorHandler installs a hook invoked when ObserveDecision fails to
+// append. A nil handler resets the hook to a no-op.
+func (r *Ring) SetErrorHandler(handler func(error)) {
+	if handler == nil {
+		handler = func(error) {}
+	}
+
+	r.mu.Lock()
+	r.errorHandler = handler
+	r.mu.Unlock()
+}
+
+// ObserveDecision implements adapt.DecisionObserver by appending the decision
+// to the ring. The append is a memory copy, so it never delays the loop.
+func (r *Ring) ObserveDecision(decision adapt.Decision) {
+	err := r.Append(DecisionRecord(decision))
+	if err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	handler := r.errorHandler
+	r.mu.Unlock()
+
+	handler(err)
+}
+
+// ReplaySteps extracts the slow-loop steps from records, oldest first, for
+// adapt.Replay. A start record marks the next step as a restart; records of
+// other kinds and decisions without a P95 are skipped.
+func ReplaySteps(records []Record) []adapt.ReplayStep {
+	steps := make([]adapt.ReplayStep, 0, len(records))
+	restart := false
+
+	for _, record := range records {
+		if record.Kind == KindStart {
+			restart = true
+
+			continue
+		}
+
+		if record.Kind != KindDecision {
+			continue
+		}
+
+		p95, ok := record.Fields["p95"].(float64)
+		if !ok {
+			continue
+		}
+
+		_, failed := record.Fields["error"]
+		state, _ := record.Fields["state"].(string)
+
+		steps = append(steps, adapt.ReplayStep{
+			Timestamp:  record.Timestamp,
+			P95:        p95,
+			Failed:     failed,
+			Suppressed: state == adapt.StateSuppressed.String(),
+			Restart:    restart,
+		})
+		restart = false
+	}
+
+	return steps
+}
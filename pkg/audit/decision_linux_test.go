@@ -0,0 +1,169 @@
+//go:build linux
+
+package audit_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/audit"
+)
+
+var errOCIDown = errors.New("oci down")
+
+func failedDecision() adapt.Decision {
+	return adapt.Decision{
+		StepID:       "",
+		Timestamp:    time.Time{},
+		ResourceID:   "",
+		Mode:         "enforce",
+		State:        adapt.StateFallback,
+		P95:          0,
+		Target:       0,
+		NextInterval: time.Minute,
+		Err:          errOCIDown,
+	}
+}
+
+func TestRingObservesDecisions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+	ring := openRing(t, path, 4)
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	ring.ObserveDecision(adapt.Decision{
+		StepID:       "5f3a0c9e1b2d4a68",
+		Timestamp:    at,
+		ResourceID:   "ocid1.instance",
+		Mode:         "enforce",
+		State:        adapt.StateFallback,
+		P95:          0.12,
+		Target:       0.25,
+		NextInterval: 5 * time.Minute,
+		Err:          fmt.Errorf("%w: %s", errOCIDown, strings.Repeat("x", 400)),
+	})
+	ring.ObserveDecision(adapt.Decision{
+		StepID:       "",
+		Timestamp:    at.Add(time.Minute),
+		ResourceID:   "ocid1.instance",
+		Mode:         "enforce",
+		State:        adapt.StateNormal,
+		P95:          0.2,
+		Target:       0.3,
+		NextInterval: time.Hour,
+		Err:          nil,
+	})
+
+	records, err := audit.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %+v", records)
+	}
+
+	first := records[0]
+	if first.Kind != audit.KindDecision || !first.Timestamp.Equal(at) {
+		t.Fatalf("unexpected first record %+v", first)
+	}
+
+	if first.Fields["state"] != "fallback" || first.Fields["nextInterval"] != "5m0s" ||
+		first.Fields["target"] != 0.25 || first.Fields["mode"] != "enforce" ||
+		first.Fields["step"] != "5f3a0c9e1b2d4a68" {
+		t.Fatalf("unexpected decision fields %+v", first.Fields)
+	}
+
+	if message, _ := first.Fields["error"].(string); len(message) != 200 {
+		t.Fatalf("expected error truncated to 200 bytes, got %d", len(message))
+	}
+
+	if _, ok := records[1].Fields["error"]; ok {
+		t.Fatalf("expected no error field on a clean decision, got %+v", records[1].Fields)
+	}
+
+	if _, ok := records[1].Fields["step"]; ok {
+		t.Fatalf("expected no step field without a step ID, got %+v", records[1].Fields)
+	}
+}
+
+func TestRingReportsObserveFailures(t *testing.T) {
+	t.Parallel()
+
+	ring := openRing(t, filepath.Join(t.TempDir(), "audit.ring"), 1)
+
+	var got error
+
+	ring.SetErrorHandler(func(err error) { got = err })
+
+	err := ring.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	ring.ObserveDecision(failedDecision())
+
+	if !errors.Is(got, audit.ErrClosed) {
+		t.Fatalf("expected ErrClosed through the handler, got %v", got)
+	}
+
+	ring.SetErrorHandler(nil)
+	ring.ObserveDecision(failedDecision())
+}
+
+func TestReplayStepsFollowDecisionRecords(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	decision := func(offset time.Duration, state adapt.State, err error) audit.Record {
+		return audit.DecisionRecord(adapt.Decision{
+			StepID:       "",
+			Timestamp:    at.Add(offset),
+			ResourceID:   "",
+			Mode:         "enforce",
+			State:        state,
+			P95:          0.2,
+			Target:       0.25,
+			NextInterval: time.Hour,
+			Err:          err,
+		})
+	}
+
+	records := []audit.Record{
+		decision(0, adapt.StateNormal, nil),
+		{Sequence: 0, Timestamp: at, Kind: audit.KindStop, Fields: nil},
+		{Sequence: 0, Timestamp: at, Kind: audit.KindStart, Fields: nil},
+		decision(time.Hour, adapt.StateFallback, errOCIDown),
+		decision(2*time.Hour, adapt.StateSuppressed, nil),
+		{Sequence: 0, Timestamp: at, Kind: audit.KindDecision, Fields: map[string]any{}},
+	}
+
+	steps := audit.ReplaySteps(records)
+	want := []adapt.ReplayStep{
+		{Timestamp: at, P95: 0.2, Failed: false, Suppressed: false, Restart: false},
+		{Timestamp: at.Add(time.Hour), P95: 0.2, Failed: true, Suppressed: false, Restart: true},
+		{
+			Timestamp:  at.Add(2 * time.Hour),
+			P95:        0.2,
+			Failed:     false,
+			Suppressed: true,
+			Restart:    false,
+		},
+	}
+
+	if len(steps) != len(want) {
+		t.Fatalf("expected %d steps, got %+v", len(want), steps)
+	}
+
+	for index := range want {
+		if steps[index] != want[index] {
+			t.Fatalf("step %d: expected %+v, got %+v", index, want[index], steps[index])
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package audit
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapSupported reports that mapFile works on this platform.
+const mapSupported = true
+
+// mapFile maps size bytes of file shared, so writes reach the page cache and
+// the file without an explicit flush.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	data, err := unix.Mmap(
+		int(file.Fd()), //nolint:gosec // file descriptors fit in an int
+		0,
+		size,
+		unix.PROT_READ|unix.PROT_WRITE,
+		unix.MAP_SHARED,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("map audit ring: %w", err)
+	}
+
+	return data, nil
+}
+
+func unmapFile(data []byte) error {
+	err := unix.Munmap(data)
+	if err != nil {
+		return fmt.Errorf("unmap audit ring: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package audit
+
+import "os"
+
+// mapSupported is false where mapFile always fails, so Open leaves the file
+// alone.
+const mapSupported = false
+
+func mapFile(*os.File, int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
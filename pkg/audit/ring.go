@@ -0,0 +1,321 @@
+// Package audit keeps the most recent decision and lifecycle records in a
+// memory-mapped ring file. Every append lands in the kernel's page cache as
+// soon as it is copied, so the records outlive a crashed or killed process
+// and can be read back without its cooperation.
+package audit
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRecords is the number of records a ring keeps unless configured
+	// otherwise.
+	DefaultRecords = 256
+	// DefaultPath is where the packaged units keep the ring and where
+	// shaperctl audit dump looks unless told otherwise.
+	DefaultPath = "/var/lib/oci-cpu-shaper/audit.ring"
+)
+
+// Record kinds written by the daemon.
+const (
+	KindStart    = "start"
+	KindDecision = "decision"
+	KindStop     = "stop"
+)
+
+const (
+	// SlotSize is the fixed size of one record slot; a record's JSON form
+	// must fit in SlotSize minus the slot header.
+	SlotSize = 512
+
+	magic          = "SHAPAUD1"
+	formatVersion  = 1
+	fileHeaderSize = 64
+	slotHeaderSize = 16
+	maxPayload     = SlotSize - slotHeaderSize
+	maxRecords     = 1 << 16
+	fileMode       = 0o600
+)
+
+var (
+	// ErrInvalidRecords signals a ring capacity outside [1, 65536].
+	ErrInvalidRecords = errors.New("audit: record count must be within [1, 65536]")
+	// ErrNotRing signals a file that does not hold an audit ring.
+	ErrNotRing = errors.New("audit: not an audit ring file")
+	// ErrUnsupported signals a platform without memory-mapped files.
+	ErrUnsupported = errors.New("audit: memory-mapped rings are unsupported on this platform")
+	// ErrClosed signals an append to a closed ring.
+	ErrClosed = errors.New("audit: ring is closed")
+)
+
+// Record is one audit entry. Sequence increases by one per append across
+// restarts, so gaps and ordering survive the ring wrapping.
+type Record struct {
+	Sequence  uint64         `json:"seq"`
+	Timestamp time.Time      `json:"ts"`
+	Kind      string         `json:"kind"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// Ring appends records to a memory-mapped file of fixed size, overwriting
+// the oldest once it is full. It is safe for concurrent use.
+type Ring struct {
+	mu           sync.Mutex
+	file         *os.File
+	data         []byte
+	capacity     int
+	next         uint64
+	unmap        func([]byte) error
+	now          func() time.Time
+	errorHandler func(error)
+}
+
+// Open maps the ring file at path with room for records entries, creating it
+// when missing. An existing ring of the same capacity is continued, and an
+// empty file or a ring of another capacity is reinitialised. Any other file
+// fails with ErrNotRing and is left untouched, so a mistyped path cannot
+// destroy it.
+func Open(path string, records int) (*Ring, error) {
+	err := ValidateRecords(records)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mapSupported {
+		return nil, ErrUnsupported
+	}
+
+	size := fileHeaderSize + records*SlotSize
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, fileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open audit ring: %w", err)
+	}
+
+	err = checkRingFile(file, path)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	// Resizing keeps the bytes of an existing ring; a file of another size
+	// fails the header check below and is cleared.
+	err = file.Truncate(int64(size))
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("size audit ring: %w", err)
+	}
+
+	data, err := mapFile(file, size)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	ring := &Ring{
+		file:         file,
+		data:         data,
+		capacity:     records,
+		next:         1,
+		unmap:        unmapFile,
+		now:          time.Now,
+		errorHandler: func(error) {},
+	}
+
+	if decodeHeader(data) != records {
+		clear(data)
+		encodeHeader(data, records)
+	}
+
+	for _, record := range decodeSlots(data, records) {
+		ring.next = max(ring.next, record.Sequence+1)
+	}
+
+	return ring, nil
+}
+
+// checkRingFile accepts an empty regular file or one that starts with the
+// ring magic.
+func checkRingFile(file *os.File, path string) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat audit ring: %w", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s is not a regular file", ErrNotRing, path)
+	}
+
+	if info.Size() == 0 {
+		return nil
+	}
+
+	prefix := make([]byte, len(magic))
+
+	_, err = file.ReadAt(prefix, 0)
+	if err != nil || string(prefix) != magic {
+		return fmt.Errorf("%w: %s", ErrNotRing, path)
+	}
+
+	return nil
+}
+
+// ValidateRecords reports whether records is a supported ring capacity.
+func ValidateRecords(records int) error {
+	if records < 1 || records > maxRecords {
+		return fmt.Errorf("%w: %d", ErrInvalidRecords, records)
+	}
+
+	return nil
+}
+
+// Append stamps record with the next sequence number and, when it is zero,
+// the current time, and writes it over the oldest slot. Records whose JSON
+// form exceeds the slot keep their kind and time and replace their fields
+// with {"truncated": true}.
+func (r *Ring) Append(record Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.data == nil {
+		return ErrClosed
+	}
+
+	record.Sequence = r.next
+	if record.Timestamp.IsZero() {
+		record.Timestamp = r.now()
+	}
+
+	payload, err := json.Marshal(record)
+	if err == nil && len(payload) > maxPayload {
+		record.Fields = map[string]any{"truncated": true}
+		payload, err = json.Marshal(record)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encode audit record: %w", err)
+	}
+
+	slot := r.slot(int((record.Sequence - 1) % uint64(r.capacity)))
+
+	// Invalidate the slot before rewriting it so a crash mid-copy leaves a
+	// slot that fails its checksum instead of a stale record.
+	binary.LittleEndian.PutUint64(slot[0:8], 0)
+	copy(slot[slotHeaderSize:], payload)
+	clear(slot[slotHeaderSize+len(payload):])
+	//nolint:gosec // bounded by maxPayload
+	binary.LittleEndian.PutUint32(slot[8:12], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(slot[12:16], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint64(slot[0:8], record.Sequence)
+
+	r.next++
+
+	return nil
+}
+
+// Close unmaps and closes the ring file. Records already appended stay in
+// the file.
+func (r *Ring) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.data == nil {
+		return nil
+	}
+
+	err := r.unmap(r.data)
+	r.data = nil
+
+	return errors.Join(err, r.file.Close())
+}
+
+func (r *Ring) slot(index int) []byte {
+	start := fileHeaderSize + index*SlotSize
+
+	return r.data[start : start+SlotSize]
+}
+
+// ReadFile decodes the ring file at path without mapping it and returns its
+// valid records, oldest first. Slots left torn by a crash are skipped.
+func ReadFile(path string) ([]Record, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is the operator's own ring file
+	if err != nil {
+		return nil, fmt.Errorf("read audit ring: %w", err)
+	}
+
+	records := decodeHeader(data)
+	if records == 0 || len(data) < fileHeaderSize+records*SlotSize {
+		return nil, fmt.Errorf("%w: %s", ErrNotRing, path)
+	}
+
+	return decodeSlots(data, records), nil
+}
+
+func encodeHeader(data []byte, records int) {
+	copy(data[0:8], magic)
+	binary.LittleEndian.PutUint32(data[8:12], formatVersion)
+	binary.LittleEndian.PutUint32(data[12:16], SlotSize)
+	binary.LittleEndian.PutUint32(data[16:20], uint32(records)) //nolint:gosec // ≤ maxRecords
+}
+
+// decodeHeader returns the capacity recorded in a valid header, or zero.
+func decodeHeader(data []byte) int {
+	if len(data) < fileHeaderSize || string(data[0:8]) != magic ||
+		binary.LittleEndian.Uint32(data[8:12]) != formatVersion ||
+		binary.LittleEndian.Uint32(data[12:16]) != SlotSize {
+		return 0
+	}
+
+	records := int(binary.LittleEndian.Uint32(data[16:20]))
+	if records > maxRecords {
+		return 0
+	}
+
+	return records
+}
+
+func decodeSlots(data []byte, records int) []Record {
+	decoded := make([]Record, 0, records)
+
+	for index := range records {
+		start := fileHeaderSize + index*SlotSize
+		slot := data[start : start+SlotSize]
+
+		sequence := binary.LittleEndian.Uint64(slot[0:8])
+		length := int(binary.LittleEndian.Uint32(slot[8:12]))
+
+		if sequence == 0 || length == 0 || length > maxPayload {
+			continue
+		}
+
+		payload := slot[slotHeaderSize : slotHeaderSize+length]
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(slot[12:16]) {
+			continue
+		}
+
+		var record Record
+
+		err := json.Unmarshal(payload, &record)
+		if err != nil || record.Sequence != sequence {
+			continue
+		}
+
+		decoded = append(decoded, record)
+	}
+
+	sort.Slice(decoded, func(i, j int) bool { return decoded[i].Sequence < decoded[j].Sequence })
+
+	return decoded
+}
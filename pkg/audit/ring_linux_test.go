@@ -0,0 +1,232 @@
+//go:build linux
+
+package audit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/audit"
+)
+
+func openRing(t *testing.T, path string, records int) *audit.Ring {
+	t.Helper()
+
+	ring, err := audit.Open(path, records)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	t.Cleanup(func() { _ = ring.Close() })
+
+	return ring
+}
+
+func newRecord(kind string, fields map[string]any) audit.Record {
+	return audit.Record{Sequence: 0, Timestamp: time.Time{}, Kind: kind, Fields: fields}
+}
+
+func appendRecord(t *testing.T, ring *audit.Ring, record audit.Record) {
+	t.Helper()
+
+	err := ring.Append(record)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+func TestRingKeepsNewestRecordsAcrossReopen(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+	stamp := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	ring := openRing(t, path, 3)
+	for index := range 4 {
+		appendRecord(t, ring, audit.Record{
+			Sequence:  0,
+			Timestamp: stamp.Add(time.Duration(index) * time.Minute),
+			Kind:      audit.KindDecision,
+			Fields:    map[string]any{"target": float64(index) / 10},
+		})
+	}
+
+	// The records are readable while the writer still has the ring mapped,
+	// as after a crash.
+	records, err := audit.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if len(records) != 3 || records[0].Sequence != 2 || records[2].Sequence != 4 ||
+		records[2].Fields["target"] != 0.3 || !records[0].Timestamp.Equal(stamp.Add(time.Minute)) {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	err = ring.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	err = ring.Append(newRecord(audit.KindStop, nil))
+	if !errors.Is(err, audit.ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+
+	err = ring.Close()
+	if err != nil {
+		t.Fatalf("expected a second Close to be a no-op, got %v", err)
+	}
+
+	reopened := openRing(t, path, 3)
+	appendRecord(t, reopened, newRecord(audit.KindStart, nil))
+
+	err = reopened.Append(newRecord(audit.KindStop, map[string]any{"bad": func() {}}))
+	if err == nil {
+		t.Fatal("expected a record that cannot be encoded to fail")
+	}
+
+	records, err = audit.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	last := records[len(records)-1]
+	if last.Sequence != 5 || last.Kind != audit.KindStart || last.Timestamp.IsZero() {
+		t.Fatalf("expected the reopened ring to continue the sequence, got %+v", last)
+	}
+}
+
+func TestRingReinitialisesOtherFilesAndSkipsTornSlots(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.ring")
+
+	ring := openRing(t, path, 2)
+	appendRecord(t, ring, newRecord(audit.KindStart, nil))
+	appendRecord(t, ring, newRecord(
+		audit.KindDecision,
+		map[string]any{"error": strings.Repeat("x", audit.SlotSize)},
+	))
+	_ = ring.Close()
+
+	records, err := audit.ReadFile(path)
+	if err != nil || len(records) != 2 || records[1].Fields["truncated"] != true {
+		t.Fatalf("expected an oversized record to be truncated, got %+v (%v)", records, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read ring: %v", err)
+	}
+
+	// Corrupt the first record's payload as a crash mid-copy would.
+	data[64+16] ^= 0xff
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("write ring: %v", err)
+	}
+
+	records, err = audit.ReadFile(path)
+	if err != nil || len(records) != 1 || records[0].Sequence != 2 {
+		t.Fatalf("expected the torn slot to be skipped, got %+v (%v)", records, err)
+	}
+
+	// A slot whose header names another sequence than its payload is stale.
+	data[64+audit.SlotSize]++
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("write ring: %v", err)
+	}
+
+	records, err = audit.ReadFile(path)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected the mismatched slot to be skipped, got %+v (%v)", records, err)
+	}
+
+	// A header claiming more records than a ring may hold is not a ring.
+	data[19] = 0xff
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("write ring: %v", err)
+	}
+
+	_, err = audit.ReadFile(path)
+	if !errors.Is(err, audit.ErrNotRing) {
+		t.Fatalf("expected ErrNotRing for an oversized header, got %v", err)
+	}
+
+	resized := openRing(t, path, 4)
+	appendRecord(t, resized, newRecord(audit.KindStart, nil))
+
+	records, err = audit.ReadFile(path)
+	if err != nil || len(records) != 1 || records[0].Sequence != 1 {
+		t.Fatalf("expected a resized ring to start over, got %+v (%v)", records, err)
+	}
+}
+
+func TestOpenAndReadFileRejectInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, records := range []int{0, 1<<16 + 1} {
+		_, err := audit.Open(filepath.Join(dir, "audit.ring"), records)
+		if !errors.Is(err, audit.ErrInvalidRecords) {
+			t.Fatalf("expected ErrInvalidRecords for %d, got %v", records, err)
+		}
+	}
+
+	_, err := audit.Open(filepath.Join(dir, "missing", "audit.ring"), 1)
+	if err == nil {
+		t.Fatal("expected a missing directory to fail")
+	}
+
+	_, err = audit.Open(os.DevNull, 1)
+	if !errors.Is(err, audit.ErrNotRing) {
+		t.Fatalf("expected ErrNotRing for a device, got %v", err)
+	}
+
+	path := filepath.Join(dir, "other")
+
+	err = os.WriteFile(path, []byte("not a ring"), 0o600)
+	if err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	_, err = audit.ReadFile(path)
+	if !errors.Is(err, audit.ErrNotRing) {
+		t.Fatalf("expected ErrNotRing, got %v", err)
+	}
+
+	_, err = audit.Open(path, 1)
+	if !errors.Is(err, audit.ErrNotRing) {
+		t.Fatalf("expected Open to refuse a file that is not a ring, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "not a ring" {
+		t.Fatalf("expected the refused file to be left untouched, got %q (%v)", data, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+
+	err = os.WriteFile(empty, nil, 0o600)
+	if err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	appendRecord(t, openRing(t, empty, 1), newRecord(audit.KindStart, nil))
+
+	_, err = audit.ReadFile(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Fatal("expected a missing file to fail")
+	}
+}
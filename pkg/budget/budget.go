@@ -0,0 +1,145 @@
+// Package budget counts OCI API calls per UTC day against configured limits so
+// aggressive intervals or fleet deployments surface before free-tier quotas do.
+package budget
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// APIMonitoring identifies Monitoring SummarizeMetricsData queries.
+	APIMonitoring = "monitoring"
+	// APIIMDS identifies instance metadata service requests.
+	APIIMDS = "imds"
+	// DefaultWarnRatio is the share of a daily budget that triggers a warning.
+	DefaultWarnRatio = 0.8
+)
+
+// Usage reports the calls made against one API since the start of the UTC day.
+// A zero Limit means the API is counted without a budget.
+type Usage struct {
+	API   string
+	Calls int
+	Limit int
+}
+
+// Remaining returns the calls left in the budget, floored at zero.
+func (u Usage) Remaining() int {
+	return max(u.Limit-u.Calls, 0)
+}
+
+// Tracker counts calls per API and resets the counts at UTC midnight. The warn
+// handler fires at most once per API per day, when usage first reaches
+// DefaultWarnRatio of the budget.
+type Tracker struct {
+	mu          sync.Mutex
+	limits      map[string]int
+	calls       map[string]int
+	warned      map[string]bool
+	day         time.Time
+	now         func() time.Time
+	warnHandler func(Usage)
+}
+
+// NewTracker constructs a Tracker enforcing the provided daily limits keyed by
+// API. Non-positive limits disable the budget for that API.
+func NewTracker(limits map[string]int) *Tracker {
+	cloned := make(map[string]int, len(limits))
+
+	for api, limit := range limits {
+		if limit > 0 {
+			cloned[api] = limit
+		}
+	}
+
+	return &Tracker{
+		limits: cloned,
+		calls:  make(map[string]int),
+		warned: make(map[string]bool),
+		now:    time.Now,
+	}
+}
+
+// SetWarnHandler installs a callback invoked when an API approaches its budget.
+func (t *Tracker) SetWarnHandler(handler func(Usage)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.warnHandler = handler
+	t.mu.Unlock()
+}
+
+// Record counts one call against api.
+func (t *Tracker) Record(api string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.rolloverLocked()
+	t.calls[api]++
+
+	usage := Usage{API: api, Calls: t.calls[api], Limit: t.limits[api]}
+
+	var handler func(Usage)
+
+	if usage.Limit > 0 && !t.warned[api] &&
+		float64(usage.Calls) >= DefaultWarnRatio*float64(usage.Limit) {
+		t.warned[api] = true
+		handler = t.warnHandler
+	}
+
+	t.mu.Unlock()
+
+	if handler != nil {
+		handler(usage)
+	}
+}
+
+// Snapshot returns today's usage for every API that has a budget or has been
+// called, sorted by API name.
+func (t *Tracker) Snapshot() []Usage {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rolloverLocked()
+
+	apis := make(map[string]struct{}, len(t.limits)+len(t.calls))
+	for api := range t.limits {
+		apis[api] = struct{}{}
+	}
+
+	for api := range t.calls {
+		apis[api] = struct{}{}
+	}
+
+	usages := make([]Usage, 0, len(apis))
+	for api := range apis {
+		usages = append(usages, Usage{API: api, Calls: t.calls[api], Limit: t.limits[api]})
+	}
+
+	sort.Slice(usages, func(i, j int) bool { return usages[i].API < usages[j].API })
+
+	return usages
+}
+
+func (t *Tracker) rolloverLocked() {
+	now := t.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if day.Equal(t.day) {
+		return
+	}
+
+	t.day = day
+	clear(t.calls)
+	clear(t.warned)
+}
@@ -0,0 +1,58 @@
+package budget //nolint:testpackage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerWarnsOnceAndResetsAtUTCMidnight(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	tracker := NewTracker(map[string]int{APIMonitoring: 5})
+	tracker.now = func() time.Time { return now }
+
+	var warnings []Usage
+
+	tracker.SetWarnHandler(func(usage Usage) { warnings = append(warnings, usage) })
+
+	for range 5 {
+		tracker.Record(APIMonitoring)
+	}
+
+	if len(warnings) != 1 || warnings[0].Calls != 4 {
+		t.Fatalf("expected a single warning at 4 calls, got %+v", warnings)
+	}
+
+	tracker.Record(APIIMDS)
+
+	snapshot := tracker.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("expected imds and monitoring usage, got %+v", snapshot)
+	}
+
+	if snapshot[0] != (Usage{API: APIIMDS, Calls: 1}) {
+		t.Fatalf("unexpected imds usage %+v", snapshot[0])
+	}
+
+	if snapshot[1].Calls != 5 || snapshot[1].Remaining() != 0 {
+		t.Fatalf("unexpected monitoring usage %+v", snapshot[1])
+	}
+
+	now = now.Add(2 * time.Hour)
+
+	tracker.Record(APIMonitoring)
+
+	snapshot = tracker.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Calls != 1 || snapshot[0].Remaining() != 4 {
+		t.Fatalf("expected counts to reset on the next UTC day, got %+v", snapshot)
+	}
+
+	for range 3 {
+		tracker.Record(APIMonitoring)
+	}
+
+	if len(warnings) != 2 {
+		t.Fatalf("expected warning to re-arm after reset, got %+v", warnings)
+	}
+}
@@ -0,0 +1,173 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var (
+	errNetworkMetricsMissing = errors.New("budget: network totals unsupported by delegate")
+	errMaintenanceMissing    = errors.New("budget: maintenance schedule unsupported by delegate")
+)
+
+type clockSkewTracker interface {
+	SetClockSkewHandler(handler func(skew time.Duration))
+}
+
+type tokenExpiryTracker interface {
+	SetTokenExpiryHandler(handler func(expiry time.Time))
+}
+
+type queryWindowTracker interface {
+	SetQueryWindowHandler(handler func(window oci.QueryWindow))
+}
+
+// MetricsClient decorates an oci.MetricsClient, recording every Monitoring query
+// it issues against the tracker.
+type MetricsClient struct {
+	client  oci.MetricsClient
+	tracker *Tracker
+}
+
+// NewMetricsClient wraps client so its Monitoring queries count against tracker.
+func NewMetricsClient(client oci.MetricsClient, tracker *Tracker) *MetricsClient {
+	return &MetricsClient{client: client, tracker: tracker}
+}
+
+// QueryP95CPU records one Monitoring call and forwards to the delegate.
+func (m *MetricsClient) QueryP95CPU(
+	ctx context.Context,
+	resourceID string,
+) (float64, error) {
+	m.tracker.Record(APIMonitoring)
+
+	return m.client.QueryP95CPU(ctx, resourceID) //nolint:wrapcheck // transparent decorator
+}
+
+// QueryNetworkBytes7d forwards to the delegate when it reports network totals,
+// counting the inbound and outbound queries it issues.
+func (m *MetricsClient) QueryNetworkBytes7d(
+	ctx context.Context,
+	resourceID string,
+) (oci.NetworkTotals, error) {
+	querier, ok := m.client.(oci.NetworkMetricsClient)
+	if !ok {
+		return oci.NetworkTotals{}, errNetworkMetricsMissing
+	}
+
+	m.tracker.Record(APIMonitoring)
+	m.tracker.Record(APIMonitoring)
+
+	return querier.QueryNetworkBytes7d(ctx, resourceID) //nolint:wrapcheck // transparent decorator
+}
+
+// QueryStep forwards to the delegate, counting each query in the batch. When the
+// delegate cannot batch, the queries are issued and counted one by one.
+func (m *MetricsClient) QueryStep(
+	ctx context.Context,
+	resourceID string,
+	query oci.StepQuery,
+) (oci.StepMetrics, error) {
+	batch, ok := m.client.(oci.StepMetricsClient)
+	if !ok {
+		result, err := oci.QueryStepSerially(ctx, m, resourceID, query)
+
+		return result, err //nolint:wrapcheck // transparent decorator
+	}
+
+	m.tracker.Record(APIMonitoring)
+
+	if query.Network {
+		m.tracker.Record(APIMonitoring)
+		m.tracker.Record(APIMonitoring)
+	}
+
+	return batch.QueryStep(ctx, resourceID, query) //nolint:wrapcheck // transparent decorator
+}
+
+// SetClockSkewHandler forwards handler to the delegate when it tracks clock skew.
+func (m *MetricsClient) SetClockSkewHandler(handler func(skew time.Duration)) {
+	if tracker, ok := m.client.(clockSkewTracker); ok {
+		tracker.SetClockSkewHandler(handler)
+	}
+}
+
+// SetTokenExpiryHandler forwards handler to the delegate when it tracks the
+// expiry of its security token.
+func (m *MetricsClient) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	if tracker, ok := m.client.(tokenExpiryTracker); ok {
+		tracker.SetTokenExpiryHandler(handler)
+	}
+}
+
+// SetQueryWindowHandler forwards handler to the delegate when it reports the
+// window of its Monitoring queries.
+func (m *MetricsClient) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	if tracker, ok := m.client.(queryWindowTracker); ok {
+		tracker.SetQueryWindowHandler(handler)
+	}
+}
+
+// IMDSClient decorates an imds.Client, recording every metadata request it
+// issues against the tracker.
+type IMDSClient struct {
+	client  imds.Client
+	tracker *Tracker
+}
+
+// NewIMDSClient wraps client so its metadata requests count against tracker.
+func NewIMDSClient(client imds.Client, tracker *Tracker) *IMDSClient {
+	return &IMDSClient{client: client, tracker: tracker}
+}
+
+// Region records one IMDS call and forwards to the delegate.
+func (c *IMDSClient) Region(ctx context.Context) (string, error) {
+	c.tracker.Record(APIIMDS)
+
+	return c.client.Region(ctx) //nolint:wrapcheck // transparent decorator
+}
+
+// CanonicalRegion records one IMDS call and forwards to the delegate.
+func (c *IMDSClient) CanonicalRegion(ctx context.Context) (string, error) {
+	c.tracker.Record(APIIMDS)
+
+	return c.client.CanonicalRegion(ctx) //nolint:wrapcheck // transparent decorator
+}
+
+// InstanceID records one IMDS call and forwards to the delegate.
+func (c *IMDSClient) InstanceID(ctx context.Context) (string, error) {
+	c.tracker.Record(APIIMDS)
+
+	return c.client.InstanceID(ctx) //nolint:wrapcheck // transparent decorator
+}
+
+// CompartmentID records one IMDS call and forwards to the delegate.
+func (c *IMDSClient) CompartmentID(ctx context.Context) (string, error) {
+	c.tracker.Record(APIIMDS)
+
+	return c.client.CompartmentID(ctx) //nolint:wrapcheck // transparent decorator
+}
+
+// ShapeConfig records one IMDS call and forwards to the delegate.
+func (c *IMDSClient) ShapeConfig(ctx context.Context) (imds.ShapeConfig, error) {
+	c.tracker.Record(APIIMDS)
+
+	return c.client.ShapeConfig(ctx) //nolint:wrapcheck // transparent decorator
+}
+
+// MaintenanceRebootDue records one IMDS call and forwards to the delegate when
+// it reads the maintenance schedule.
+func (c *IMDSClient) MaintenanceRebootDue(ctx context.Context) (time.Time, error) {
+	reader, ok := c.client.(imds.MaintenanceReader)
+	if !ok {
+		return time.Time{}, errMaintenanceMissing
+	}
+
+	c.tracker.Record(APIIMDS)
+
+	return reader.MaintenanceRebootDue(ctx) //nolint:wrapcheck // transparent decorator
+}
@@ -0,0 +1,151 @@
+package budget //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+type p95OnlyClient struct{}
+
+func (p95OnlyClient) QueryP95CPU(context.Context, string) (float64, error) {
+	return 0.5, nil
+}
+
+type batchClient struct {
+	p95OnlyClient
+}
+
+func (batchClient) QueryStep(context.Context, string, oci.StepQuery) (oci.StepMetrics, error) {
+	return oci.StepMetrics{P95CPU: 0.5}, nil
+}
+
+type fixedIMDS struct{}
+
+func (fixedIMDS) Region(context.Context) (string, error)          { return "phx", nil }
+func (fixedIMDS) CanonicalRegion(context.Context) (string, error) { return "us-phoenix-1", nil }
+func (fixedIMDS) InstanceID(context.Context) (string, error)      { return "ocid1.instance", nil }
+func (fixedIMDS) CompartmentID(context.Context) (string, error)   { return "ocid1.cmpt", nil }
+
+func (fixedIMDS) ShapeConfig(context.Context) (imds.ShapeConfig, error) {
+	return imds.ShapeConfig{OCPUs: 1}, nil
+}
+
+type maintenanceIMDS struct{ fixedIMDS }
+
+func (maintenanceIMDS) MaintenanceRebootDue(context.Context) (time.Time, error) {
+	return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), nil
+}
+
+func callsFor(tracker *Tracker, api string) int {
+	for _, usage := range tracker.Snapshot() {
+		if usage.API == api {
+			return usage.Calls
+		}
+	}
+
+	return 0
+}
+
+func TestMetricsClientCountsMonitoringQueries(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(nil)
+	client := NewMetricsClient(batchClient{}, tracker)
+
+	_, _ = client.QueryP95CPU(t.Context(), "ocid1.instance")
+	_, _ = client.QueryStep(t.Context(), "ocid1.instance", oci.StepQuery{Network: true})
+
+	if calls := callsFor(tracker, APIMonitoring); calls != 4 {
+		t.Fatalf("expected 4 monitoring calls, got %d", calls)
+	}
+
+	_, err := client.QueryNetworkBytes7d(t.Context(), "ocid1.instance")
+	if !errors.Is(err, errNetworkMetricsMissing) {
+		t.Fatalf("expected errNetworkMetricsMissing, got %v", err)
+	}
+
+	if calls := callsFor(tracker, APIMonitoring); calls != 4 {
+		t.Fatalf("expected unsupported network query to go uncounted, got %d", calls)
+	}
+}
+
+func TestMetricsClientCountsSerialStepQueries(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(nil)
+	client := NewMetricsClient(p95OnlyClient{}, tracker)
+
+	result, err := client.QueryStep(t.Context(), "ocid1.instance", oci.StepQuery{Network: false})
+	if err != nil || result.P95CPU != 0.5 {
+		t.Fatalf("unexpected serial step result %+v (err %v)", result, err)
+	}
+
+	if calls := callsFor(tracker, APIMonitoring); calls != 1 {
+		t.Fatalf("expected 1 monitoring call, got %d", calls)
+	}
+}
+
+type windowClient struct {
+	p95OnlyClient
+
+	handler func(window oci.QueryWindow)
+}
+
+func (c *windowClient) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	c.handler = handler
+}
+
+func TestMetricsClientForwardsQueryWindowHandler(t *testing.T) {
+	t.Parallel()
+
+	delegate := new(windowClient)
+
+	NewMetricsClient(delegate, NewTracker(nil)).SetQueryWindowHandler(func(oci.QueryWindow) {})
+
+	if delegate.handler == nil {
+		t.Fatal("expected the query window handler to reach the delegate")
+	}
+
+	// Delegates that do not report windows are skipped.
+	NewMetricsClient(p95OnlyClient{}, NewTracker(nil)).SetQueryWindowHandler(nil)
+}
+
+func TestIMDSClientCountsRequests(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(map[string]int{APIIMDS: 10})
+	client := NewIMDSClient(fixedIMDS{}, tracker)
+
+	_, _ = client.Region(t.Context())
+	_, _ = client.CanonicalRegion(t.Context())
+	_, _ = client.InstanceID(t.Context())
+	_, _ = client.CompartmentID(t.Context())
+	_, _ = client.ShapeConfig(t.Context())
+
+	if calls := callsFor(tracker, APIIMDS); calls != 5 {
+		t.Fatalf("expected 5 imds calls, got %d", calls)
+	}
+
+	_, err := client.MaintenanceRebootDue(t.Context())
+	if !errors.Is(err, errMaintenanceMissing) {
+		t.Fatalf("expected errMaintenanceMissing, got %v", err)
+	}
+
+	if calls := callsFor(tracker, APIIMDS); calls != 5 {
+		t.Fatalf("expected unsupported maintenance read to go uncounted, got %d", calls)
+	}
+
+	due, err := NewIMDSClient(maintenanceIMDS{}, tracker).MaintenanceRebootDue(t.Context())
+	if err != nil || due.IsZero() {
+		t.Fatalf("expected forwarded maintenance due time, got %s (%v)", due, err)
+	}
+
+	if calls := callsFor(tracker, APIIMDS); calls != 6 {
+		t.Fatalf("expected 6 imds calls, got %d", calls)
+	}
+}
@@ -0,0 +1,232 @@
+// Package canary holds a new configuration in dry-run for an observation
+// period before enforcing it, remembering the fingerprint of the last promoted
+// configuration so restarts with an unchanged configuration enforce directly.
+package canary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/logging"
+)
+
+const (
+	// DefaultStateFile records the hash of the last promoted configuration.
+	DefaultStateFile = "/var/lib/oci-cpu-shaper/promoted-config"
+	// HashLength is the number of hex characters Hash returns.
+	HashLength = 16
+	// PromotedMode is the controller mode a canary is switched to on promotion.
+	PromotedMode = "enforce"
+)
+
+// ModeSetter is implemented by controllers whose mode can change at runtime.
+type ModeSetter interface {
+	SetMode(mode string)
+}
+
+// Hash fingerprints cfg by its JSON encoding. It returns an empty string when
+// cfg cannot be encoded.
+func Hash(cfg any) string {
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:])[:HashLength]
+}
+
+// ReadPromoted returns the hash recorded in path by a previous promotion. An
+// empty path or a missing file yields an empty hash.
+func ReadPromoted(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("read canary state file: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func writePromoted(path, hash string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return fmt.Errorf("create canary state directory: %w", err)
+	}
+
+	err = os.WriteFile(path, []byte(hash+"\n"), 0o600)
+	if err != nil {
+		return fmt.Errorf("write canary state file: %w", err)
+	}
+
+	return nil
+}
+
+// Gate holds the wrapped actuator idle while a canary configuration is
+// observed. The controller keeps computing targets; the latest one is applied
+// once the gate opens.
+type Gate struct {
+	actuator adapt.DutyCycler
+
+	mu     sync.Mutex
+	open   bool
+	target float64
+}
+
+// Wrap idles actuator and returns the gate in its place.
+//
+//nolint:ireturn // decorator returns the wrapped actuator's interface.
+func (g *Gate) Wrap(actuator adapt.DutyCycler) adapt.DutyCycler {
+	g.mu.Lock()
+	g.actuator = actuator
+	g.mu.Unlock()
+
+	actuator.SetTarget(0)
+
+	return g
+}
+
+// SetTarget records target and forwards it once the gate is open.
+func (g *Gate) SetTarget(target float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.target = target
+
+	if g.open && g.actuator != nil {
+		g.actuator.SetTarget(target)
+	}
+}
+
+// Target returns the latest target requested by the controller.
+func (g *Gate) Target() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.target
+}
+
+// Open applies the latest target and forwards every later one.
+func (g *Gate) Open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.open = true
+
+	if g.actuator != nil {
+		g.actuator.SetTarget(g.target)
+	}
+}
+
+// Rollout promotes a canary configuration from dry-run to enforce once its
+// observation period has elapsed.
+type Rollout struct {
+	gate        *Gate
+	hash        string
+	observation time.Duration
+	stateFile   string
+
+	handlerMu      sync.RWMutex
+	logger         logging.Logger
+	promoteHandler func(hash string)
+}
+
+// NewRollout constructs a Rollout for the configuration identified by hash.
+// An empty stateFile skips recording the promotion.
+func NewRollout(hash, stateFile string, observation time.Duration) *Rollout {
+	return &Rollout{
+		gate:        new(Gate),
+		hash:        hash,
+		observation: observation,
+		stateFile:   stateFile,
+	}
+}
+
+// Gate returns the gate that holds the actuator idle until promotion.
+func (r *Rollout) Gate() *Gate {
+	return r.gate
+}
+
+// SetLogger installs the logger used to report the promotion.
+func (r *Rollout) SetLogger(logger logging.Logger) {
+	r.handlerMu.Lock()
+	defer r.handlerMu.Unlock()
+
+	r.logger = logger
+}
+
+// SetPromoteHandler installs a callback invoked with the configuration hash
+// once the canary is promoted.
+func (r *Rollout) SetPromoteHandler(handler func(hash string)) {
+	r.handlerMu.Lock()
+	defer r.handlerMu.Unlock()
+
+	r.promoteHandler = handler
+}
+
+// Run waits for the observation period and promotes controller, unless ctx is
+// done first.
+func (r *Rollout) Run(ctx context.Context, controller adapt.Controller) {
+	timer := time.NewTimer(r.observation)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	r.Promote(controller)
+}
+
+// Promote opens the gate, switches controller to enforce and records the
+// configuration hash in the state file.
+func (r *Rollout) Promote(controller adapt.Controller) {
+	r.gate.Open()
+
+	if setter, ok := controller.(ModeSetter); ok {
+		setter.SetMode(PromotedMode)
+	}
+
+	r.handlerMu.RLock()
+	logger := logging.OrNop(r.logger)
+	handler := r.promoteHandler
+	r.handlerMu.RUnlock()
+
+	if handler != nil {
+		handler(r.hash)
+	}
+
+	logger.Info("canary configuration promoted to enforce", "configHash", r.hash)
+
+	if r.stateFile == "" {
+		return
+	}
+
+	err := writePromoted(r.stateFile, r.hash)
+	if err != nil {
+		logger.Warn(
+			"failed to record promoted config hash",
+			"path", r.stateFile,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,117 @@
+package canary //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+type recordingActuator struct {
+	target float64
+}
+
+func (r *recordingActuator) SetTarget(target float64) { r.target = target }
+
+func (r *recordingActuator) Target() float64 { return r.target }
+
+type modeRecordingController struct {
+	adapt.Controller
+
+	mode string
+}
+
+func (m *modeRecordingController) SetMode(mode string) { m.mode = mode }
+
+func TestHashFingerprintsEncoding(t *testing.T) {
+	t.Parallel()
+
+	base := Hash(map[string]float64{"targetMax": 0.3})
+	if len(base) != HashLength {
+		t.Fatalf("expected a %d character hash, got %q", HashLength, base)
+	}
+
+	if Hash(map[string]float64{"targetMax": 0.3}) != base {
+		t.Fatal("expected equal values to hash equally")
+	}
+
+	if Hash(map[string]float64{"targetMax": 0.35}) == base {
+		t.Fatal("expected a changed value to change the hash")
+	}
+
+	if Hash(func() {}) != "" {
+		t.Fatal("expected an unencodable value to yield an empty hash")
+	}
+}
+
+func TestRolloutGatesActuatorUntilPromotion(t *testing.T) {
+	t.Parallel()
+
+	stateFile := filepath.Join(t.TempDir(), "state", "promoted-config")
+	rollout := NewRollout("abc123", stateFile, time.Hour)
+
+	var promoted string
+
+	rollout.SetPromoteHandler(func(hash string) { promoted = hash })
+
+	pool := &recordingActuator{target: 0.5}
+	actuator := rollout.Gate().Wrap(pool)
+	actuator.SetTarget(0.3)
+
+	if pool.target != 0 || actuator.Target() != 0.3 {
+		t.Fatalf("expected the pool held idle, got %v (desired %v)", pool.target, actuator.Target())
+	}
+
+	controller := new(modeRecordingController)
+	rollout.Promote(controller)
+
+	if pool.target != 0.3 || controller.mode != PromotedMode || promoted != "abc123" {
+		t.Fatalf(
+			"expected 0.3 in enforce, got %v in %q (handler saw %q)",
+			pool.target,
+			controller.mode,
+			promoted,
+		)
+	}
+
+	actuator.SetTarget(0.25)
+
+	if pool.target != 0.25 {
+		t.Fatalf("expected targets forwarded after promotion, got %v", pool.target)
+	}
+
+	recorded, err := ReadPromoted(stateFile)
+	if err != nil || recorded != "abc123" {
+		t.Fatalf("expected the promoted hash to be recorded, got %q (err=%v)", recorded, err)
+	}
+}
+
+func TestReadPromotedToleratesMissingState(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"", filepath.Join(t.TempDir(), "absent")} {
+		hash, err := ReadPromoted(path)
+		if err != nil || hash != "" {
+			t.Fatalf("expected no hash for %q, got %q (err=%v)", path, hash, err)
+		}
+	}
+
+	_, err := ReadPromoted(t.TempDir())
+	if err == nil {
+		t.Fatal("expected reading a directory to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "promoted")
+
+	err = os.WriteFile(path, []byte("  feed  \n"), 0o600)
+	if err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	hash, err := ReadPromoted(path)
+	if err != nil || hash != "feed" {
+		t.Fatalf("expected trimmed hash, got %q (err=%v)", hash, err)
+	}
+}
@@ -0,0 +1,59 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Control describes a single cgroup interface file and how the shaper may use it.
+type Control struct {
+	File      string
+	Available bool
+	Writable  bool
+}
+
+// Capabilities summarises the CPU controls reachable through a Manager.
+type Capabilities struct {
+	Version   Version
+	CPUWeight Control
+	CPUQuota  Control
+	CPUUsage  Control
+}
+
+// Capabilities probes the interface files backing each control without modifying them.
+func (m *Manager) Capabilities() Capabilities {
+	weightFile, quotaFile, usageFile := v2WeightFile, v2MaxFile, v2StatFile
+	if m.version == VersionV1 {
+		weightFile, quotaFile, usageFile = v1SharesFile, v1QuotaFile, v1UsageFile
+	}
+
+	return Capabilities{
+		Version:   m.version,
+		CPUWeight: probeControl(m.cpuDir, weightFile),
+		CPUQuota:  probeControl(m.cpuDir, quotaFile),
+		CPUUsage:  probeControl(m.acctDir, usageFile),
+	}
+}
+
+func probeControl(dir, name string) Control {
+	control := Control{File: name, Available: false, Writable: false}
+	if dir == "" {
+		return control
+	}
+
+	path := filepath.Join(dir, name)
+
+	control.Available = fileExists(path)
+	if !control.Available {
+		return control
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		control.Writable = true
+
+		_ = file.Close()
+	}
+
+	return control
+}
@@ -0,0 +1,374 @@
+// Package cgroup detects the host cgroup hierarchy and exposes CPU controls that
+// behave the same on the unified (v2) layout and the legacy (v1) cpu/cpuacct
+// controllers.
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoot is the conventional cgroup filesystem mount point.
+const DefaultRoot = "/sys/fs/cgroup"
+
+// Version identifies the cgroup hierarchy layout.
+type Version int
+
+const (
+	// VersionUnknown indicates that no usable cgroup hierarchy was found.
+	VersionUnknown Version = iota
+	// VersionV1 is the legacy per-controller hierarchy (cpu, cpuacct).
+	VersionV1
+	// VersionV2 is the unified hierarchy.
+	VersionV2
+)
+
+const (
+	minWeight = 1
+	maxWeight = 10000
+	minShares = 2
+	maxShares = 262144
+
+	v2ControllersFile = "cgroup.controllers"
+	v2WeightFile      = "cpu.weight"
+	v2MaxFile         = "cpu.max"
+	v2StatFile        = "cpu.stat"
+	v1SharesFile      = "cpu.shares"
+	v1QuotaFile       = "cpu.cfs_quota_us"
+	v1PeriodFile      = "cpu.cfs_period_us"
+	v1UsageFile       = "cpuacct.usage"
+
+	nanosecondsPerMicrosecond = 1000
+)
+
+var (
+	errUnknownHierarchy = errors.New("cgroup: no supported hierarchy detected")
+	errInvalidWeight    = errors.New("cgroup: weight must be within [1,10000]")
+	errInvalidPeriod    = errors.New("cgroup: quota period must be positive")
+	errUsageMissing     = errors.New("cgroup: usage_usec not present in cpu.stat")
+	errGroupNotFound    = errors.New("cgroup: cpu controller entry not found")
+	errAcctNotMounted   = errors.New("cgroup: cpuacct controller not mounted")
+)
+
+// String returns the short label used in logs and doctor output.
+func (v Version) String() string {
+	switch v {
+	case VersionV1:
+		return "v1"
+	case VersionV2:
+		return "v2"
+	case VersionUnknown:
+		return "unknown"
+	default:
+		return "unknown"
+	}
+}
+
+// Detect inspects root and reports which cgroup layout is mounted there.
+func Detect(root string) Version {
+	if fileExists(filepath.Join(root, v2ControllersFile)) {
+		return VersionV2
+	}
+
+	if v1ControllerDir(root, "cpu") != "" {
+		return VersionV1
+	}
+
+	return VersionUnknown
+}
+
+// Manager reads and writes CPU controls for a single cgroup.
+type Manager struct {
+	version Version
+	cpuDir  string
+	acctDir string
+}
+
+// Open resolves the cgroup identified by group (relative to the hierarchy, "" for
+// the root as seen from the current namespace) under root.
+func Open(root, group string) (*Manager, error) {
+	switch Detect(root) {
+	case VersionV2:
+		dir := filepath.Join(root, group)
+
+		return &Manager{version: VersionV2, cpuDir: dir, acctDir: dir}, nil
+	case VersionV1:
+		manager := &Manager{
+			version: VersionV1,
+			cpuDir:  filepath.Join(v1ControllerDir(root, "cpu"), group),
+			acctDir: "",
+		}
+
+		if acct := v1ControllerDir(root, "cpuacct"); acct != "" {
+			manager.acctDir = filepath.Join(acct, group)
+		}
+
+		return manager, nil
+	case VersionUnknown:
+		return nil, fmt.Errorf("%w under %q", errUnknownHierarchy, root)
+	default:
+		return nil, fmt.Errorf("%w under %q", errUnknownHierarchy, root)
+	}
+}
+
+// OpenSelf resolves the cgroup that contains the current process. When the
+// membership path is not visible under root (for example, inside a container
+// without a private cgroup namespace) the hierarchy root is used instead.
+func OpenSelf(root string) (*Manager, error) {
+	group, err := GroupForPID(Detect(root), os.Getpid())
+	if err != nil {
+		group = ""
+	}
+
+	manager, err := Open(root, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if group != "" && !dirExists(manager.cpuDir) {
+		return Open(root, "")
+	}
+
+	return manager, nil
+}
+
+// Version reports the hierarchy layout backing the manager.
+func (m *Manager) Version() Version {
+	return m.version
+}
+
+// SetCPUWeight applies a cgroup v2 style weight in [1,10000]. On v1 hosts the
+// weight is converted to the equivalent cpu.shares value.
+func (m *Manager) SetCPUWeight(weight uint64) error {
+	if weight < minWeight || weight > maxWeight {
+		return fmt.Errorf("%w: %d", errInvalidWeight, weight)
+	}
+
+	if m.version == VersionV1 {
+		shares := strconv.FormatUint(WeightToShares(weight), 10)
+
+		return writeValue(filepath.Join(m.cpuDir, v1SharesFile), shares)
+	}
+
+	return writeValue(filepath.Join(m.cpuDir, v2WeightFile), strconv.FormatUint(weight, 10))
+}
+
+// CPUWeight reads the current weight on the cgroup v2 scale, converting cpu.shares
+// on v1 hosts.
+func (m *Manager) CPUWeight() (uint64, error) {
+	if m.version == VersionV1 {
+		shares, err := readUint(filepath.Join(m.cpuDir, v1SharesFile))
+		if err != nil {
+			return 0, err
+		}
+
+		return SharesToWeight(shares), nil
+	}
+
+	return readUint(filepath.Join(m.cpuDir, v2WeightFile))
+}
+
+// SetCPUQuota caps CPU time to quota per period. A non-positive quota removes the cap.
+func (m *Manager) SetCPUQuota(quota, period time.Duration) error {
+	if period <= 0 {
+		return errInvalidPeriod
+	}
+
+	periodMicros := period.Microseconds()
+
+	if m.version == VersionV1 {
+		err := writeValue(
+			filepath.Join(m.cpuDir, v1PeriodFile),
+			strconv.FormatInt(periodMicros, 10),
+		)
+		if err != nil {
+			return err
+		}
+
+		quotaValue := "-1"
+		if quota > 0 {
+			quotaValue = strconv.FormatInt(quota.Microseconds(), 10)
+		}
+
+		return writeValue(filepath.Join(m.cpuDir, v1QuotaFile), quotaValue)
+	}
+
+	quotaValue := "max"
+	if quota > 0 {
+		quotaValue = strconv.FormatInt(quota.Microseconds(), 10)
+	}
+
+	return writeValue(
+		filepath.Join(m.cpuDir, v2MaxFile),
+		quotaValue+" "+strconv.FormatInt(periodMicros, 10),
+	)
+}
+
+// UsageMicros returns the cumulative CPU time consumed by the cgroup in
+// microseconds. It fails on v1 hosts without a cpuacct mount.
+func (m *Manager) UsageMicros() (uint64, error) {
+	if m.acctDir == "" {
+		return 0, errAcctNotMounted
+	}
+
+	if m.version == VersionV1 {
+		nanos, err := readUint(filepath.Join(m.acctDir, v1UsageFile))
+		if err != nil {
+			return 0, err
+		}
+
+		return nanos / nanosecondsPerMicrosecond, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.acctDir, v2StatFile))
+	if err != nil {
+		return 0, fmt.Errorf("read cpu.stat: %w", err)
+	}
+
+	return parseUsageUsec(data)
+}
+
+// WeightToShares converts a v2 cpu.weight into the v1 cpu.shares scale using the
+// same mapping as runc and systemd.
+func WeightToShares(weight uint64) uint64 {
+	weight = min(max(weight, minWeight), maxWeight)
+
+	return minShares + ((weight-minWeight)*(maxShares-minShares))/(maxWeight-minWeight)
+}
+
+// SharesToWeight converts a v1 cpu.shares value into the v2 cpu.weight scale.
+func SharesToWeight(shares uint64) uint64 {
+	shares = min(max(shares, minShares), maxShares)
+
+	return minWeight + ((shares-minShares)*(maxWeight-minWeight))/(maxShares-minShares)
+}
+
+// GroupForPID returns the cgroup path of pid for the cpu controller, relative to
+// the hierarchy root, by parsing /proc/<pid>/cgroup.
+func GroupForPID(version Version, pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", fmt.Errorf("read cgroup membership: %w", err)
+	}
+
+	return groupFromProcCgroup(data, version)
+}
+
+func groupFromProcCgroup(data []byte, version Version) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if version == VersionV2 && parts[0] == "0" && parts[1] == "" {
+			return parts[2], nil
+		}
+
+		if version == VersionV1 && hasController(parts[1], "cpu") {
+			return parts[2], nil
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return "", fmt.Errorf("scan cgroup membership: %w", err)
+	}
+
+	return "", errGroupNotFound
+}
+
+func hasController(list, name string) bool {
+	return slices.Contains(strings.Split(list, ","), name)
+}
+
+func v1ControllerDir(root, controller string) string {
+	for _, candidate := range []string{controller, "cpu,cpuacct", "cpuacct,cpu"} {
+		dir := filepath.Join(root, candidate)
+
+		marker := v1SharesFile
+		if controller == "cpuacct" {
+			marker = v1UsageFile
+		}
+
+		if fileExists(filepath.Join(dir, marker)) {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+func parseUsageUsec(data []byte) (uint64, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("parse usage_usec: %w", err)
+			}
+
+			return value, nil
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return 0, fmt.Errorf("scan cpu.stat: %w", err)
+	}
+
+	return 0, errUsageMissing
+}
+
+func readUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+
+	return value, nil
+}
+
+func writeValue(path, value string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+
+	_, err = file.WriteString(value)
+	closeErr := file.Close()
+
+	err = errors.Join(err, closeErr)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && !info.IsDir()
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,390 @@
+package cgroup //nolint:testpackage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFixture(t *testing.T, path, contents string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatalf("create fixture dir: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("write fixture %s: %v", path, err)
+	}
+}
+
+func readFixture(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", path, err)
+	}
+
+	return string(data)
+}
+
+func newV2Root(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, v2ControllersFile), "cpu io memory\n")
+	writeFixture(t, filepath.Join(root, "shaper", v2WeightFile), "100\n")
+	writeFixture(t, filepath.Join(root, "shaper", v2MaxFile), "max 100000\n")
+	writeFixture(
+		t,
+		filepath.Join(root, "shaper", v2StatFile),
+		"usage_usec 4200\nuser_usec 4000\nsystem_usec 200\n",
+	)
+
+	return root
+}
+
+func newV1Root(t *testing.T, cpuDir, acctDir string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, cpuDir, "shaper", v1SharesFile), "1024\n")
+	writeFixture(t, filepath.Join(root, cpuDir, "shaper", v1QuotaFile), "-1\n")
+	writeFixture(t, filepath.Join(root, cpuDir, "shaper", v1PeriodFile), "100000\n")
+	writeFixture(t, filepath.Join(root, cpuDir, v1SharesFile), "1024\n")
+	writeFixture(t, filepath.Join(root, acctDir, v1UsageFile), "0\n")
+	writeFixture(t, filepath.Join(root, acctDir, "shaper", v1UsageFile), "7000000\n")
+
+	return root
+}
+
+func TestDetectRecognisesHierarchies(t *testing.T) {
+	t.Parallel()
+
+	if got := Detect(newV2Root(t)); got != VersionV2 {
+		t.Fatalf("expected v2, got %s", got)
+	}
+
+	if got := Detect(newV1Root(t, "cpu,cpuacct", "cpu,cpuacct")); got != VersionV1 {
+		t.Fatalf("expected v1, got %s", got)
+	}
+
+	if got := Detect(t.TempDir()); got != VersionUnknown {
+		t.Fatalf("expected unknown, got %s", got)
+	}
+}
+
+func TestOpenRejectsUnknownHierarchy(t *testing.T) {
+	t.Parallel()
+
+	_, err := Open(t.TempDir(), "")
+	if !errors.Is(err, errUnknownHierarchy) {
+		t.Fatalf("expected unknown hierarchy error, got %v", err)
+	}
+}
+
+func TestManagerV2WritesUnifiedControls(t *testing.T) {
+	t.Parallel()
+
+	root := newV2Root(t)
+
+	manager, err := Open(root, "shaper")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	err = manager.SetCPUWeight(50)
+	if err != nil {
+		t.Fatalf("SetCPUWeight: %v", err)
+	}
+
+	err = manager.SetCPUQuota(25*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetCPUQuota: %v", err)
+	}
+
+	if got := readFixture(t, filepath.Join(root, "shaper", v2WeightFile)); got != "50" {
+		t.Fatalf("unexpected cpu.weight %q", got)
+	}
+
+	if got := readFixture(t, filepath.Join(root, "shaper", v2MaxFile)); got != "25000 100000" {
+		t.Fatalf("unexpected cpu.max %q", got)
+	}
+
+	err = manager.SetCPUQuota(0, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetCPUQuota unlimited: %v", err)
+	}
+
+	if got := readFixture(t, filepath.Join(root, "shaper", v2MaxFile)); got != "max 100000" {
+		t.Fatalf("unexpected unlimited cpu.max %q", got)
+	}
+
+	usage, err := manager.UsageMicros()
+	if err != nil {
+		t.Fatalf("UsageMicros: %v", err)
+	}
+
+	if usage != 4200 {
+		t.Fatalf("expected 4200µs usage, got %d", usage)
+	}
+
+	weight, err := manager.CPUWeight()
+	if err != nil {
+		t.Fatalf("CPUWeight: %v", err)
+	}
+
+	if weight != 50 {
+		t.Fatalf("expected weight 50, got %d", weight)
+	}
+}
+
+func TestManagerV1WithoutCpuacctReportsUsageUnavailable(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, "cpu", v1SharesFile), "1024\n")
+	writeFixture(t, filepath.Join(root, "cpu", "shaper", v1SharesFile), "1024\n")
+
+	manager, err := Open(root, "shaper")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	_, err = manager.UsageMicros()
+	if !errors.Is(err, errAcctNotMounted) {
+		t.Fatalf("expected errAcctNotMounted, got %v", err)
+	}
+
+	err = manager.SetCPUWeight(100)
+	if err != nil {
+		t.Fatalf("expected the cpu controls to keep working, got %v", err)
+	}
+}
+
+func TestManagerV1TranslatesControls(t *testing.T) {
+	t.Parallel()
+
+	root := newV1Root(t, "cpu", "cpuacct")
+
+	manager, err := Open(root, "shaper")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if manager.Version() != VersionV1 {
+		t.Fatalf("expected v1 manager, got %s", manager.Version())
+	}
+
+	err = manager.SetCPUWeight(100)
+	if err != nil {
+		t.Fatalf("SetCPUWeight: %v", err)
+	}
+
+	err = manager.SetCPUQuota(50*time.Millisecond, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetCPUQuota: %v", err)
+	}
+
+	cpuDir := filepath.Join(root, "cpu", "shaper")
+
+	if got := readFixture(t, filepath.Join(cpuDir, v1SharesFile)); got != "2597" {
+		t.Fatalf("unexpected cpu.shares %q", got)
+	}
+
+	if got := readFixture(t, filepath.Join(cpuDir, v1PeriodFile)); got != "200000" {
+		t.Fatalf("unexpected cfs period %q", got)
+	}
+
+	if got := readFixture(t, filepath.Join(cpuDir, v1QuotaFile)); got != "50000" {
+		t.Fatalf("unexpected cfs quota %q", got)
+	}
+
+	err = manager.SetCPUQuota(-1, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetCPUQuota unlimited: %v", err)
+	}
+
+	if got := readFixture(t, filepath.Join(cpuDir, v1QuotaFile)); got != "-1" {
+		t.Fatalf("unexpected unlimited cfs quota %q", got)
+	}
+
+	usage, err := manager.UsageMicros()
+	if err != nil {
+		t.Fatalf("UsageMicros: %v", err)
+	}
+
+	if usage != 7000 {
+		t.Fatalf("expected 7000µs usage from cpuacct, got %d", usage)
+	}
+
+	weight, err := manager.CPUWeight()
+	if err != nil {
+		t.Fatalf("CPUWeight: %v", err)
+	}
+
+	// runc's shares-to-weight mapping truncates, so the round trip lands one below.
+	if weight != 99 {
+		t.Fatalf("expected weight 99 from cpu.shares 2597, got %d", weight)
+	}
+}
+
+func TestManagerRejectsInvalidInputs(t *testing.T) {
+	t.Parallel()
+
+	manager, err := Open(newV2Root(t), "shaper")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	err = manager.SetCPUWeight(0)
+	if !errors.Is(err, errInvalidWeight) {
+		t.Fatalf("expected invalid weight error, got %v", err)
+	}
+
+	err = manager.SetCPUQuota(time.Millisecond, 0)
+	if !errors.Is(err, errInvalidPeriod) {
+		t.Fatalf("expected invalid period error, got %v", err)
+	}
+
+	missing, err := Open(newV2Root(t), "absent")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := missing.SetCPUWeight(10); err == nil {
+		t.Fatal("expected write to missing cgroup to fail")
+	}
+}
+
+func TestWeightSharesConversion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		weight uint64
+		shares uint64
+	}{
+		{weight: 1, shares: 2},
+		{weight: 10000, shares: 262144},
+	}
+
+	for _, testCase := range testCases {
+		if got := WeightToShares(testCase.weight); got != testCase.shares {
+			t.Fatalf("WeightToShares(%d) = %d, want %d", testCase.weight, got, testCase.shares)
+		}
+
+		if got := SharesToWeight(testCase.shares); got != testCase.weight {
+			t.Fatalf("SharesToWeight(%d) = %d, want %d", testCase.shares, got, testCase.weight)
+		}
+	}
+
+	if got := WeightToShares(100); got != 2597 {
+		t.Fatalf("WeightToShares(100) = %d, want 2597", got)
+	}
+
+	if got := SharesToWeight(1024); got != 39 {
+		t.Fatalf("SharesToWeight(1024) = %d, want 39 (runc default)", got)
+	}
+
+	if got := WeightToShares(0); got != minShares {
+		t.Fatalf("expected clamped shares, got %d", got)
+	}
+}
+
+func TestGroupFromProcCgroup(t *testing.T) {
+	t.Parallel()
+
+	v1 := []byte("12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n1:name=systemd:/docker/abc\n")
+	v2 := []byte("0::/system.slice/shaper.service\n")
+
+	group, err := groupFromProcCgroup(v1, VersionV1)
+	if err != nil || group != "/docker/abc" {
+		t.Fatalf("unexpected v1 group %q (err=%v)", group, err)
+	}
+
+	group, err = groupFromProcCgroup(v2, VersionV2)
+	if err != nil || group != "/system.slice/shaper.service" {
+		t.Fatalf("unexpected v2 group %q (err=%v)", group, err)
+	}
+
+	_, err = groupFromProcCgroup(v2, VersionV1)
+	if !errors.Is(err, errGroupNotFound) {
+		t.Fatalf("expected missing group error, got %v", err)
+	}
+}
+
+func TestCapabilitiesReportControls(t *testing.T) {
+	t.Parallel()
+
+	root := newV1Root(t, "cpu,cpuacct", "cpu,cpuacct")
+
+	manager, err := Open(root, "shaper")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	caps := manager.Capabilities()
+	if caps.Version != VersionV1 {
+		t.Fatalf("expected v1 capabilities, got %s", caps.Version)
+	}
+
+	for _, control := range []Control{caps.CPUWeight, caps.CPUQuota, caps.CPUUsage} {
+		if !control.Available || !control.Writable {
+			t.Fatalf("expected %s to be available and writable, got %+v", control.File, control)
+		}
+	}
+
+	if caps.CPUWeight.File != v1SharesFile || caps.CPUUsage.File != v1UsageFile {
+		t.Fatalf("unexpected v1 control files: %+v", caps)
+	}
+
+	err = os.Remove(filepath.Join(root, "cpu,cpuacct", "shaper", v1QuotaFile))
+	if err != nil {
+		t.Fatalf("remove quota fixture: %v", err)
+	}
+
+	if manager.Capabilities().CPUQuota.Available {
+		t.Fatal("expected quota control to be reported unavailable")
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	t.Parallel()
+
+	labels := map[Version]string{
+		VersionV1:      "v1",
+		VersionV2:      "v2",
+		VersionUnknown: "unknown",
+		Version(9):     "unknown",
+	}
+
+	for version, want := range labels {
+		if got := version.String(); got != want {
+			t.Fatalf("Version(%d).String() = %q, want %q", int(version), got, want)
+		}
+	}
+}
+
+func TestOpenSelfFallsBackToHierarchyRoot(t *testing.T) {
+	t.Parallel()
+
+	root := newV2Root(t)
+
+	manager, err := OpenSelf(root)
+	if err != nil {
+		t.Fatalf("OpenSelf: %v", err)
+	}
+
+	if manager.Version() != VersionV2 {
+		t.Fatalf("expected v2 manager, got %s", manager.Version())
+	}
+
+	if manager.cpuDir != root {
+		t.Fatalf("expected fallback to hierarchy root %q, got %q", root, manager.cpuDir)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -76,11 +77,47 @@ type Sampler struct {
 	interval time.Duration
 	now      func() time.Time
 	started  atomic.Bool
+
+	mu             sync.Mutex
+	latest         Observation
+	hasLatest      bool
+	tickSource     Source
+	tickSnapshot   Snapshot
+	hasTick        bool
+	restartAfter   int
+	buffer         int
+	dropped        int
+	newSource      func() Source
+	restartHandler func(failures int, lastErr error)
+	dropHandler    func()
 }
 
 // DefaultInterval is used when a zero or negative interval is supplied.
 const DefaultInterval = time.Second
 
+// DefaultBuffer is how many observations Run queues for a consumer that falls
+// behind. Once the queue is full the oldest observation is dropped, so a slow
+// consumer never stalls sampling and reads the freshest samples when it
+// catches up.
+const DefaultBuffer = 8
+
+// DefaultRestartThreshold is the number of consecutive sampling errors after which
+// the sampler recreates its source.
+const DefaultRestartThreshold = 5
+
+// PauseGapFactor is how many intervals the wall clock may advance between two
+// ticks before the process is treated as having been suspended, for example
+// while its VM was paused or live-migrated. Gaps below MinPauseGap are never
+// treated as a pause, so scheduling jitter at short intervals is ignored.
+const (
+	PauseGapFactor = 3
+	MinPauseGap    = time.Second
+)
+
+// UserHZ is the rate of the jiffy counters in /proc/stat. The kernel reports
+// them in USER_HZ, which is 100 on every Linux ABI the shaper runs on.
+const UserHZ = 100
+
 const (
 	minimumCPUFields = 5
 	idleFieldIndex   = 3
@@ -91,6 +128,8 @@ var (
 	ErrSamplerAlreadyStarted    = errors.New("est: sampler already started")
 	ErrUnexpectedProcStatFormat = errors.New("est: unexpected /proc/stat format")
 	ErrProcStatTooShort         = errors.New("est: /proc/stat cpu line too short")
+	ErrNotSampling              = errors.New("est: sampler has no baseline snapshot yet")
+	ErrNoElapsedJiffies         = errors.New("est: no jiffies elapsed since the last tick")
 )
 
 // NewSampler constructs a Sampler using the provided Source and interval.
@@ -103,17 +142,77 @@ func NewSampler(src Source, interval time.Duration) *Sampler {
 	sampler.source = src
 	sampler.interval = interval
 	sampler.now = time.Now
+	sampler.restartAfter = DefaultRestartThreshold
+	sampler.buffer = DefaultBuffer
+	sampler.newSource = func() Source {
+		if src == nil {
+			return FileSource{Path: ""}
+		}
+
+		return src
+	}
 
 	return sampler
 }
 
+// SetRestartThreshold configures how many consecutive sampling errors trigger a
+// warm restart of the source. Zero or a negative value disables restarts.
+func (s *Sampler) SetRestartThreshold(failures int) {
+	s.mu.Lock()
+	s.restartAfter = max(failures, 0)
+	s.mu.Unlock()
+}
+
+// SetRestartHandler installs a hook invoked once each time the sampler recovers by
+// recreating its source. The handler receives the number of consecutive failures
+// and the last sampling error. A nil handler disables notifications.
+func (s *Sampler) SetRestartHandler(handler func(failures int, lastErr error)) {
+	s.mu.Lock()
+	s.restartHandler = handler
+	s.mu.Unlock()
+}
+
+// SetBuffer configures how many observations Run queues for a slow consumer.
+// Zero or a negative value selects DefaultBuffer. It only applies to channels
+// returned by later calls to Run.
+func (s *Sampler) SetBuffer(size int) {
+	if size <= 0 {
+		size = DefaultBuffer
+	}
+
+	s.mu.Lock()
+	s.buffer = size
+	s.mu.Unlock()
+}
+
+// SetDropHandler installs a hook invoked each time an observation is dropped
+// because the consumer let the queue fill up. A nil handler disables
+// notifications.
+func (s *Sampler) SetDropHandler(handler func()) {
+	s.mu.Lock()
+	s.dropHandler = handler
+	s.mu.Unlock()
+}
+
+// Dropped reports how many observations were dropped for a slow consumer.
+func (s *Sampler) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
 // Run begins sampling until the supplied context is cancelled. Observations are
-// delivered on the returned channel which is closed on exit.
+// delivered on the returned channel which is closed on exit. Sampling never
+// waits for the consumer: when the queue is full the oldest observation is
+// dropped and reported to the drop handler.
 func (s *Sampler) Run(ctx context.Context) <-chan Observation {
-	observations := make(chan Observation, 1)
+	s.mu.Lock()
+	observations := make(chan Observation, max(s.buffer, 1))
+	s.mu.Unlock()
 
 	if !s.started.CompareAndSwap(false, true) {
-		s.publishError(ctx, observations, ErrSamplerAlreadyStarted)
+		s.publishError(observations, ErrSamplerAlreadyStarted)
 		close(observations)
 
 		return observations
@@ -124,21 +223,69 @@ func (s *Sampler) Run(ctx context.Context) <-chan Observation {
 	return observations
 }
 
-func (s *Sampler) startSampling(ctx context.Context, observations chan<- Observation) {
-	defer close(observations)
+// Current returns the most recent observation produced by Run without waiting on
+// the channel. The boolean is false until the first observation is available.
+func (s *Sampler) Current() (Observation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latest, s.hasLatest
+}
+
+// SampleNow reads the source once and returns the utilisation between the
+// snapshot of the last tick and now, so a caller such as a metrics scrape sees
+// the host load up to this moment rather than as of the last tick. It neither
+// moves the tick baseline nor publishes the observation, so the Run cadence is
+// unaffected; the source must allow concurrent snapshots, as FileSource does.
+// It returns ErrNotSampling before Run takes its first snapshot and
+// ErrNoElapsedJiffies when called too soon after a tick to measure anything.
+func (s *Sampler) SampleNow(ctx context.Context) (Observation, error) {
+	s.mu.Lock()
+	src := s.tickSource
+	baseline := s.tickSnapshot
+	ready := s.hasTick
+	s.mu.Unlock()
+
+	if !ready {
+		return Observation{}, ErrNotSampling
+	}
+
+	snap, err := src.Snapshot(ctx)
+	if err != nil {
+		return Observation{}, fmt.Errorf("on-demand snapshot: %w", err)
+	}
 
-	src := s.source
-	if src == nil {
-		src = FileSource{Path: ""}
+	observation := buildObservation(s.timeSource()(), baseline, snap)
+	if observation.TotalJiffies == 0 {
+		return Observation{}, ErrNoElapsedJiffies
 	}
 
+	return observation, nil
+}
+
+// setTick records the snapshot the next tick and SampleNow measure from.
+func (s *Sampler) setTick(src Source, snap Snapshot) {
+	s.mu.Lock()
+	s.tickSource = src
+	s.tickSnapshot = snap
+	s.hasTick = true
+	s.mu.Unlock()
+}
+
+func (s *Sampler) startSampling(ctx context.Context, observations chan Observation) {
+	defer close(observations)
+
+	src := s.newSource()
+
 	last, err := src.Snapshot(ctx)
 	if err != nil {
-		s.publishError(ctx, observations, fmt.Errorf("initial snapshot: %w", err))
+		s.publishError(observations, fmt.Errorf("initial snapshot: %w", err))
 
 		return
 	}
 
+	s.setTick(src, last)
+
 	ticker := time.NewTicker(s.interval)
 	defer ticker.Stop()
 
@@ -150,33 +297,97 @@ func (s *Sampler) sampleLoop(
 	src Source,
 	last Snapshot,
 	ticker *time.Ticker,
-	observations chan<- Observation,
+	observations chan Observation,
 ) {
 	nowFn := s.timeSource()
+	failures := 0
+	lastTick := nowFn()
+	rebaseline := false
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			now := nowFn()
+
+			// A delta spanning a suspension averages the pause into the
+			// utilisation, so the next sample only re-baselines the counters.
+			if Paused(lastTick, now, s.interval) {
+				rebaseline = true
+			}
+
+			lastTick = now
+
 			snap, err := src.Snapshot(ctx)
 			if err != nil {
-				s.publishError(ctx, observations, fmt.Errorf("sample snapshot: %w", err))
+				failures++
+				s.publishError(observations, fmt.Errorf("sample snapshot: %w", err))
+
+				restarted, baseline, ok := s.restartSource(ctx, failures, err)
+				if ok {
+					src = restarted
+					last = baseline
+					failures = 0
+					rebaseline = false
+
+					s.setTick(src, last)
+				}
 
 				continue
 			}
 
-			obs := buildObservation(nowFn(), last, snap)
-			last = snap
+			failures = 0
 
-			if !s.publishObservation(ctx, observations, obs) {
-				return
+			if rebaseline {
+				last, rebaseline = snap, false
+				s.setTick(src, last)
+
+				continue
 			}
+
+			obs := buildObservation(now, last, snap)
+			last = snap
+			s.setTick(src, last)
+
+			s.publishObservation(observations, obs)
 		}
 	}
 }
 
-func (s *Sampler) publishError(ctx context.Context, observations chan<- Observation, err error) {
+// restartSource recreates the source once failures reaches the restart threshold and
+// returns it together with a fresh baseline snapshot. The counters of a recreated
+// source may not continue from the previous ones, so the baseline prevents a bogus
+// delta on the next sample.
+func (s *Sampler) restartSource(
+	ctx context.Context,
+	failures int,
+	lastErr error,
+) (Source, Snapshot, bool) {
+	s.mu.Lock()
+	threshold := s.restartAfter
+	handler := s.restartHandler
+	s.mu.Unlock()
+
+	if threshold <= 0 || failures < threshold {
+		return nil, Snapshot{}, false
+	}
+
+	src := s.newSource()
+
+	baseline, err := src.Snapshot(ctx)
+	if err != nil {
+		return nil, Snapshot{}, false
+	}
+
+	if handler != nil {
+		handler(failures, lastErr)
+	}
+
+	return src, baseline, true
+}
+
+func (s *Sampler) publishError(observations chan Observation, err error) {
 	observation := Observation{
 		Timestamp:    s.timeSource()(),
 		Utilisation:  0,
@@ -185,20 +396,60 @@ func (s *Sampler) publishError(ctx context.Context, observations chan<- Observat
 		Err:          err,
 	}
 
-	s.publishObservation(ctx, observations, observation)
+	s.publishObservation(observations, observation)
 }
 
-func (s *Sampler) publishObservation(
-	ctx context.Context,
-	observations chan<- Observation,
-	observation Observation,
-) bool {
+func (s *Sampler) publishObservation(observations chan Observation, observation Observation) {
+	s.mu.Lock()
+	s.latest = observation
+	s.hasLatest = true
+	s.mu.Unlock()
+
+	if !offer(observations, observation) {
+		return
+	}
+
+	s.mu.Lock()
+	s.dropped++
+	handler := s.dropHandler
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// offer queues observation without blocking. When the queue is full it drops
+// the oldest queued observation to make room and reports whether one was
+// dropped. Only the producer may call offer, so the freed slot cannot be taken
+// by another send.
+func offer(observations chan Observation, observation Observation) bool {
 	select {
 	case observations <- observation:
-		return true
-	case <-ctx.Done():
 		return false
+	default:
 	}
+
+	dropped := false
+
+	select {
+	case <-observations:
+		dropped = true
+	default:
+		// The consumer emptied the queue in the meantime.
+	}
+
+	observations <- observation
+
+	return dropped
+}
+
+// Paused reports whether the wall clock advanced more than PauseGapFactor
+// intervals (and at least MinPauseGap) between last and now. The monotonic
+// readings are ignored because they may not advance while the process is
+// suspended.
+func Paused(last, now time.Time, interval time.Duration) bool {
+	return now.Round(0).Sub(last.Round(0)) > max(PauseGapFactor*interval, MinPauseGap)
 }
 
 func (s *Sampler) timeSource() func() time.Time {
@@ -209,6 +460,12 @@ func (s *Sampler) timeSource() func() time.Time {
 	return time.Now
 }
 
+// Utilisation returns the busy share of the jiffies that elapsed between two
+// snapshots of the same source, in the range [0,1].
+func Utilisation(previous, current Snapshot) float64 {
+	return buildObservation(time.Time{}, previous, current).Utilisation
+}
+
 func buildObservation(timestamp time.Time, previous, current Snapshot) Observation {
 	totalDelta := diffCounter(previous.Total, current.Total)
 	idleDelta := diffCounter(previous.Idle, current.Idle)
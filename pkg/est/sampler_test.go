@@ -347,29 +347,88 @@ func TestSamplerEmitsErrorObservationWhenLoopFails(t *testing.T) {
 	}
 }
 
-func TestSamplerPublishObservationContextCancelled(t *testing.T) {
+func TestSamplerPublishObservationDropsOldestWhenFull(t *testing.T) {
 	t.Parallel()
 
-	sampler := new(Sampler)
+	sampler := NewSampler(nil, time.Millisecond)
+
+	var notified atomic.Int32
+
+	sampler.SetDropHandler(func() { notified.Add(1) })
+
+	observations := make(chan Observation, 2)
+	for index := range 5 {
+		sampler.publishObservation(observations, Observation{
+			Timestamp:    time.Unix(int64(index), 0),
+			Utilisation:  0,
+			BusyJiffies:  0,
+			TotalJiffies: 0,
+			Err:          nil,
+		})
+	}
+
+	if sampler.Dropped() != 3 || notified.Load() != 3 {
+		t.Fatalf(
+			"expected three dropped observations, got %d (handler %d)",
+			sampler.Dropped(),
+			notified.Load(),
+		)
+	}
+
+	for _, want := range []int64{3, 4} {
+		observation := <-observations
+		if observation.Timestamp.Unix() != want {
+			t.Fatalf("expected the newest observations to be kept, got %v", observation.Timestamp)
+		}
+	}
+}
+
+func TestSamplerDoesNotWaitForSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeSource{
+		snapshots: []Snapshot{{Idle: 0, Total: 0}},
+		err:       nil,
+		index:     0,
+	}
+	sampler := NewSampler(source, time.Millisecond)
+	sampler.SetBuffer(1)
+
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observations := sampler.Run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for sampler.Dropped() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the sampler to keep sampling, dropped %d", sampler.Dropped())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
 	cancel()
 
-	observations := make(chan Observation)
+	for range observations {
+		// Drain until the sampler closes its channel.
+	}
+}
 
-	if sampler.publishObservation(ctx, observations, Observation{
-		Timestamp:    time.Time{},
-		Utilisation:  0.5,
-		BusyJiffies:  0,
-		TotalJiffies: 0,
-		Err:          nil,
-	}) {
-		t.Fatal("expected publishObservation to report cancellation")
+func TestSamplerSetBufferDefaultsNonPositiveSizes(t *testing.T) {
+	t.Parallel()
+
+	sampler := NewSampler(nil, time.Millisecond)
+	sampler.SetBuffer(3)
+
+	if sampler.buffer != 3 {
+		t.Fatalf("expected buffer 3, got %d", sampler.buffer)
 	}
 
-	select {
-	case observation := <-observations:
-		t.Fatalf("expected channel to remain empty, received %#v", observation)
-	default:
+	sampler.SetBuffer(0)
+
+	if sampler.buffer != DefaultBuffer {
+		t.Fatalf("expected default buffer %d, got %d", DefaultBuffer, sampler.buffer)
 	}
 }
 
@@ -452,3 +511,232 @@ func TestFileSourceSnapshotOpenFailure(t *testing.T) {
 		t.Fatalf("expected open error, got %v", err)
 	}
 }
+
+func TestSamplerCurrentReturnsLatestObservation(t *testing.T) {
+	t.Parallel()
+
+	sampler := NewSampler(nil, time.Millisecond)
+
+	if _, ok := sampler.Current(); ok {
+		t.Fatal("expected no observation before sampling starts")
+	}
+
+	observations := make(chan Observation, 2)
+	first := Observation{
+		Timestamp:    time.Unix(10, 0),
+		Utilisation:  0.4,
+		BusyJiffies:  4,
+		TotalJiffies: 10,
+		Err:          nil,
+	}
+	second := Observation{
+		Timestamp:    time.Unix(11, 0),
+		Utilisation:  0.6,
+		BusyJiffies:  6,
+		TotalJiffies: 10,
+		Err:          nil,
+	}
+
+	sampler.publishObservation(observations, first)
+	sampler.publishObservation(observations, second)
+
+	current, ok := sampler.Current()
+	if !ok {
+		t.Fatal("expected current observation after publishing")
+	}
+
+	if current != second {
+		t.Fatalf("expected latest observation %+v, got %+v", second, current)
+	}
+}
+
+func TestSamplerRestartsSourceAfterConsecutiveErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	broken := SnapshotFunc(func(context.Context) (Snapshot, error) {
+		if calls.Add(1) == 1 {
+			return Snapshot{Idle: 1, Total: 10}, nil
+		}
+
+		return Snapshot{}, errTestBoom
+	})
+	healthy := &fakeSource{
+		snapshots: []Snapshot{
+			{Idle: 100, Total: 1000},
+			{Idle: 105, Total: 1010},
+		},
+		err:   nil,
+		index: 0,
+	}
+
+	sampler := NewSampler(broken, time.Millisecond)
+	sources := []Source{broken, healthy}
+	sampler.newSource = func() Source {
+		next := sources[0]
+		sources = sources[1:]
+
+		return next
+	}
+
+	sampler.SetRestartThreshold(3)
+
+	var (
+		restarts     atomic.Int32
+		seenFailures atomic.Int32
+	)
+
+	sampler.SetRestartHandler(func(failures int, lastErr error) {
+		restarts.Add(1)
+		seenFailures.Store(int32(failures)) //nolint:gosec // bounded by the threshold
+
+		if !errors.Is(lastErr, errTestBoom) {
+			t.Errorf("expected last error to wrap boom, got %v", lastErr)
+		}
+	})
+
+	observationsCh := sampler.Run(t.Context())
+	observations := make([]Observation, 0, 4)
+
+	for len(observations) < 4 {
+		select {
+		case observation := <-observationsCh:
+			observations = append(observations, observation)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for observations; collected %d", len(observations))
+		}
+	}
+
+	for index := range 3 {
+		if observations[index].Err == nil {
+			t.Fatalf("expected error observation %d, got %+v", index, observations[index])
+		}
+	}
+
+	assertObservation(t, observations[3], 0.5, 5, 10)
+
+	if got := restarts.Load(); got != 1 {
+		t.Fatalf("expected a single restart notification, got %d", got)
+	}
+
+	if got := seenFailures.Load(); got != 3 {
+		t.Fatalf("expected handler to receive 3 failures, got %d", got)
+	}
+}
+
+func TestSamplerRestartThresholdDisabled(t *testing.T) {
+	t.Parallel()
+
+	sampler := NewSampler(nil, time.Millisecond)
+	sampler.SetRestartThreshold(-1)
+
+	_, _, restarted := sampler.restartSource(context.Background(), 100, errTestBoom)
+	if restarted {
+		t.Fatal("expected restarts to be disabled")
+	}
+}
+
+func TestSamplerRebaselinesAfterPause(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeSource{snapshots: []Snapshot{
+		{Idle: 0, Total: 0},
+		{Idle: 5, Total: 10},
+		{Idle: 100, Total: 1000},
+		{Idle: 105, Total: 1010},
+	}}
+
+	sampler := NewSampler(source, time.Millisecond)
+
+	var clockReads atomic.Int32
+
+	start := time.Unix(0, 0)
+	sampler.now = func() time.Time {
+		// The wall clock jumps between the first and second tick, as if the
+		// process had been suspended.
+		if clockReads.Add(1) >= 3 {
+			return start.Add(time.Minute)
+		}
+
+		return start
+	}
+
+	observationsCh := sampler.Run(t.Context())
+
+	for index := range 2 {
+		select {
+		case observation := <-observationsCh:
+			// The delta spanning the pause (busy 895 of 990) is never published.
+			assertObservation(t, observation, 0.5, 5, 10)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for observation %d", index)
+		}
+	}
+}
+
+func TestSamplerSampleNowMeasuresFromLastTick(t *testing.T) {
+	t.Parallel()
+
+	var reads atomic.Int32
+
+	source := SnapshotFunc(func(context.Context) (Snapshot, error) {
+		// The Run baseline reads 10/20; each later read adds 10 jiffies, 8 busy.
+		read := uint64(reads.Add(1)) - 1
+
+		return Snapshot{Idle: 10 + 2*read, Total: 20 + 10*read}, nil
+	})
+
+	sampler := NewSampler(source, time.Hour)
+
+	_, err := sampler.SampleNow(t.Context())
+	if !errors.Is(err, ErrNotSampling) {
+		t.Fatalf("expected ErrNotSampling before Run, got %v", err)
+	}
+
+	sampler.Run(t.Context())
+
+	deadline := time.Now().Add(time.Second)
+	for reads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	observation, err := sampler.SampleNow(t.Context())
+	if err != nil {
+		t.Fatalf("SampleNow: %v", err)
+	}
+
+	assertObservation(t, observation, 0.8, 8, 10)
+
+	// The baseline stays at the tick, so a later sample spans both reads.
+	observation, err = sampler.SampleNow(t.Context())
+	if err != nil {
+		t.Fatalf("SampleNow: %v", err)
+	}
+
+	assertObservation(t, observation, 0.8, 16, 20)
+
+	if _, published := sampler.Current(); published {
+		t.Fatal("expected on-demand samples not to be published")
+	}
+}
+
+func TestSamplerSampleNowReportsSourceErrorsAndIdleDeltas(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeSource{snapshots: []Snapshot{{Idle: 10, Total: 20}}}
+	sampler := NewSampler(source, time.Hour)
+	sampler.setTick(source, Snapshot{Idle: 10, Total: 20})
+
+	_, err := sampler.SampleNow(t.Context())
+	if !errors.Is(err, ErrNoElapsedJiffies) {
+		t.Fatalf("expected ErrNoElapsedJiffies, got %v", err)
+	}
+
+	source.err = errTestBoom
+
+	_, err = sampler.SampleNow(t.Context())
+	if !errors.Is(err, errTestBoom) {
+		t.Fatalf("expected the source error, got %v", err)
+	}
+}
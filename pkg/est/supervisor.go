@@ -0,0 +1,287 @@
+package est
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SilenceFactor is the number of sampling intervals the supervisor waits for an
+// observation before treating the sampler as hung.
+const SilenceFactor = 5
+
+// Reasons reported when the supervisor replaces its sampler.
+const (
+	// RestartReasonClosed means the sampler closed its observation channel,
+	// for example after its initial snapshot failed.
+	RestartReasonClosed = "closed"
+	// RestartReasonSilent means the sampler published nothing for
+	// SilenceFactor intervals.
+	RestartReasonSilent = "silent"
+)
+
+// Supervisor runs a Sampler built by a factory and replaces it whenever its
+// observation channel closes or it stops publishing, so a dead sampler cannot
+// leave the controller without host observations. Its own channel stays open
+// until the context is cancelled.
+type Supervisor struct {
+	newSampler func() *Sampler
+	interval   time.Duration
+	started    atomic.Bool
+
+	mu             sync.Mutex
+	current        *Sampler
+	restarts       int
+	buffer         int
+	dropped        int
+	sourceHandler  func(failures int, lastErr error)
+	restartHandler func(reason string)
+	dropHandler    func()
+}
+
+// NewSupervisor constructs a Supervisor that obtains samplers from newSampler.
+// interval must match the samplers' interval; it sets the silence deadline and
+// the delay before a closed sampler is replaced.
+func NewSupervisor(newSampler func() *Sampler, interval time.Duration) *Supervisor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	supervisor := new(Supervisor)
+	supervisor.newSampler = newSampler
+	supervisor.interval = interval
+	supervisor.buffer = DefaultBuffer
+
+	return supervisor
+}
+
+// SetRestartHandler installs the source restart hook (see
+// Sampler.SetRestartHandler) on the running sampler and on every replacement.
+func (s *Supervisor) SetRestartHandler(handler func(failures int, lastErr error)) {
+	s.mu.Lock()
+	s.sourceHandler = handler
+	current := s.current
+	s.mu.Unlock()
+
+	if current != nil {
+		current.SetRestartHandler(handler)
+	}
+}
+
+// SetSamplerRestartHandler installs a hook invoked each time the supervisor
+// replaces its sampler, with RestartReasonClosed or RestartReasonSilent. A nil
+// handler disables notifications.
+func (s *Supervisor) SetSamplerRestartHandler(handler func(reason string)) {
+	s.mu.Lock()
+	s.restartHandler = handler
+	s.mu.Unlock()
+}
+
+// SetBuffer configures how many observations Run queues for a slow consumer
+// (see Sampler.SetBuffer). It must be called before Run.
+func (s *Supervisor) SetBuffer(size int) {
+	if size <= 0 {
+		size = DefaultBuffer
+	}
+
+	s.mu.Lock()
+	s.buffer = size
+	s.mu.Unlock()
+}
+
+// SetDropHandler installs a hook invoked each time an observation is dropped
+// because the consumer let the queue fill up, whether the supervisor or one of
+// its samplers dropped it. A nil handler disables notifications.
+func (s *Supervisor) SetDropHandler(handler func()) {
+	s.mu.Lock()
+	s.dropHandler = handler
+	current := s.current
+	s.mu.Unlock()
+
+	if current != nil {
+		current.SetDropHandler(handler)
+	}
+}
+
+// Dropped reports how many observations the supervisor dropped for a slow
+// consumer. Drops inside a sampler are only reported to the drop handler.
+func (s *Supervisor) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// Restarts reports how many times the sampler has been replaced.
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.restarts
+}
+
+// Current returns the most recent observation of the running sampler.
+func (s *Supervisor) Current() (Observation, bool) {
+	s.mu.Lock()
+	current := s.current
+	s.mu.Unlock()
+
+	if current == nil {
+		return Observation{}, false
+	}
+
+	return current.Current()
+}
+
+// SampleNow takes an on-demand sample from the running sampler (see
+// Sampler.SampleNow).
+func (s *Supervisor) SampleNow(ctx context.Context) (Observation, error) {
+	s.mu.Lock()
+	current := s.current
+	s.mu.Unlock()
+
+	if current == nil {
+		return Observation{}, ErrNotSampling
+	}
+
+	return current.SampleNow(ctx)
+}
+
+// Run starts the first sampler and supervises it until the supplied context is
+// cancelled. Observations of every sampler are delivered on the returned
+// channel, which is closed on exit. Like Sampler.Run it never waits for the
+// consumer and drops the oldest queued observation when the queue is full.
+func (s *Supervisor) Run(ctx context.Context) <-chan Observation {
+	s.mu.Lock()
+	observations := make(chan Observation, max(s.buffer, 1))
+	s.mu.Unlock()
+
+	if !s.started.CompareAndSwap(false, true) {
+		observations <- Observation{Timestamp: time.Now(), Err: ErrSamplerAlreadyStarted}
+		close(observations)
+
+		return observations
+	}
+
+	go s.supervise(ctx, observations)
+
+	return observations
+}
+
+func (s *Supervisor) supervise(ctx context.Context, out chan Observation) {
+	defer close(out)
+
+	silence := SilenceFactor * s.interval
+
+	watchdog := time.NewTimer(silence)
+	defer watchdog.Stop()
+
+	for {
+		samplerCtx, cancel := context.WithCancel(ctx)
+		reason := s.forward(ctx, s.start(samplerCtx), watchdog, silence, out)
+
+		cancel()
+
+		if reason == "" {
+			return
+		}
+
+		s.recordRestart(reason)
+
+		// A sampler that closed its channel usually failed its first snapshot;
+		// pace the replacements instead of spinning.
+		if reason == RestartReasonClosed && !sleepContext(ctx, s.interval) {
+			return
+		}
+	}
+}
+
+func (s *Supervisor) start(ctx context.Context) <-chan Observation {
+	sampler := s.newSampler()
+
+	s.mu.Lock()
+	s.current = sampler
+	handler := s.sourceHandler
+	dropHandler := s.dropHandler
+	s.mu.Unlock()
+
+	if handler != nil {
+		sampler.SetRestartHandler(handler)
+	}
+
+	if dropHandler != nil {
+		sampler.SetDropHandler(dropHandler)
+	}
+
+	return sampler.Run(ctx)
+}
+
+// forward relays observations until the sampler closes its channel or falls
+// silent, returning the restart reason, or "" once ctx is cancelled.
+func (s *Supervisor) forward(
+	ctx context.Context,
+	observations <-chan Observation,
+	watchdog *time.Timer,
+	silence time.Duration,
+	out chan Observation,
+) string {
+	watchdog.Reset(silence)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-watchdog.C:
+			return RestartReasonSilent
+		case observation, ok := <-observations:
+			if !ok {
+				if ctx.Err() != nil {
+					return ""
+				}
+
+				return RestartReasonClosed
+			}
+
+			watchdog.Reset(silence)
+
+			if offer(out, observation) {
+				s.recordDrop()
+			}
+		}
+	}
+}
+
+func (s *Supervisor) recordDrop() {
+	s.mu.Lock()
+	s.dropped++
+	handler := s.dropHandler
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+func (s *Supervisor) recordRestart(reason string) {
+	s.mu.Lock()
+	s.restarts++
+	handler := s.restartHandler
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler(reason)
+	}
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
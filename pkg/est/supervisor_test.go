@@ -0,0 +1,249 @@
+//nolint:testpackage // tests exercise internal helpers for coverage
+package est
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisorReplacesClosedAndSilentSamplers(t *testing.T) {
+	t.Parallel()
+
+	const interval = 10 * time.Millisecond
+
+	hung := 0
+	healthy := Snapshot{}
+
+	sources := []Source{
+		// The first sampler fails its initial snapshot and closes its channel.
+		&fakeSource{err: errTestBoom},
+		// The second takes a baseline and then hangs on every later snapshot.
+		SnapshotFunc(func(ctx context.Context) (Snapshot, error) {
+			hung++
+			if hung == 1 {
+				return Snapshot{}, nil
+			}
+
+			<-ctx.Done()
+
+			return Snapshot{}, ctx.Err()
+		}),
+		SnapshotFunc(func(context.Context) (Snapshot, error) {
+			healthy.Idle += 5
+			healthy.Total += 10
+
+			return healthy, nil
+		}),
+	}
+
+	built := 0
+	supervisor := NewSupervisor(func() *Sampler {
+		source := sources[min(built, len(sources)-1)]
+		built++
+
+		return NewSampler(source, interval)
+	}, interval)
+
+	var (
+		mu      sync.Mutex
+		reasons []string
+	)
+
+	supervisor.SetSamplerRestartHandler(func(reason string) {
+		mu.Lock()
+		reasons = append(reasons, reason)
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	observations := supervisor.Run(ctx)
+	deadline := time.After(2 * time.Second)
+
+	for waiting := true; waiting; {
+		select {
+		case observation := <-observations:
+			waiting = observation.Err != nil
+		case <-deadline:
+			t.Fatal("expected a healthy observation after the sampler was replaced")
+		}
+	}
+
+	mu.Lock()
+	got := slices.Clone(reasons)
+	mu.Unlock()
+
+	if !slices.Equal(got, []string{RestartReasonClosed, RestartReasonSilent}) {
+		t.Fatalf("unexpected restart reasons %v", got)
+	}
+
+	if supervisor.Restarts() != 2 {
+		t.Fatalf("expected 2 restarts, got %d", supervisor.Restarts())
+	}
+
+	if current, ok := supervisor.Current(); !ok || current.Utilisation != 0.5 {
+		t.Fatalf("expected the replacement's latest observation, got %+v (%v)", current, ok)
+	}
+
+	cancel()
+
+	for observation := range observations {
+		_ = observation // drain until the supervisor closes the channel
+	}
+}
+
+func TestSupervisorRunRejectsDoubleStart(t *testing.T) {
+	t.Parallel()
+
+	supervisor := NewSupervisor(func() *Sampler {
+		return NewSampler(&fakeSource{}, time.Hour)
+	}, time.Hour)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	_ = supervisor.Run(ctx)
+
+	observation, ok := <-supervisor.Run(ctx)
+	if !ok || !errors.Is(observation.Err, ErrSamplerAlreadyStarted) {
+		t.Fatalf("expected ErrSamplerAlreadyStarted, got %+v", observation)
+	}
+}
+
+func TestSupervisorDropsForSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	const interval = time.Millisecond
+
+	var healthy Snapshot
+
+	sampler := NewSampler(SnapshotFunc(func(context.Context) (Snapshot, error) {
+		healthy.Idle += 5
+		healthy.Total += 10
+
+		return healthy, nil
+	}), interval)
+
+	supervisor := NewSupervisor(func() *Sampler { return sampler }, interval)
+	supervisor.SetBuffer(2)
+
+	var (
+		mu      sync.Mutex
+		handled int
+	)
+
+	supervisor.SetDropHandler(func() {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	observations := supervisor.Run(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for supervisor.Dropped() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the supervisor to keep forwarding, dropped %d", supervisor.Dropped())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(observations) != 2 {
+		t.Fatalf("expected a full queue of 2, got %d", len(observations))
+	}
+
+	cancel()
+
+	for observation := range observations {
+		_ = observation // drain until the supervisor closes the channel
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if handled < supervisor.Dropped() {
+		t.Fatalf("expected every drop to be reported, got %d of %d", handled, supervisor.Dropped())
+	}
+
+	if restarts := supervisor.Restarts(); restarts != 0 {
+		t.Fatalf("expected dropping not to trip the watchdog, got %d restarts", restarts)
+	}
+}
+
+func TestSupervisorForwardsDropHandlerToRunningSampler(t *testing.T) {
+	t.Parallel()
+
+	sampler := NewSampler(&fakeSource{}, time.Hour)
+	supervisor := NewSupervisor(func() *Sampler { return sampler }, time.Hour)
+	supervisor.SetBuffer(0)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	_ = supervisor.Run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		supervisor.mu.Lock()
+		current := supervisor.current
+		supervisor.mu.Unlock()
+
+		if current != nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected the supervisor to start its sampler")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	called := false
+
+	supervisor.SetDropHandler(func() { called = true })
+
+	observations := make(chan Observation, 1)
+	sampler.publishObservation(observations, Observation{})
+	sampler.publishObservation(observations, Observation{})
+
+	if !called || sampler.Dropped() != 1 {
+		t.Fatalf("expected the sampler to report its drop, dropped %d", sampler.Dropped())
+	}
+}
+
+func TestSupervisorSampleNowForwardsToRunningSampler(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeSource{snapshots: []Snapshot{{Idle: 5, Total: 10}, {Idle: 6, Total: 20}}}
+	sampler := NewSampler(source, time.Hour)
+	supervisor := NewSupervisor(func() *Sampler { return sampler }, time.Hour)
+
+	_, err := supervisor.SampleNow(t.Context())
+	if !errors.Is(err, ErrNotSampling) {
+		t.Fatalf("expected ErrNotSampling before Run, got %v", err)
+	}
+
+	supervisor.mu.Lock()
+	supervisor.current = sampler
+	supervisor.mu.Unlock()
+
+	sampler.setTick(source, Snapshot{Idle: 5, Total: 10})
+	source.index = 1
+
+	observation, err := supervisor.SampleNow(t.Context())
+	if err != nil {
+		t.Fatalf("SampleNow: %v", err)
+	}
+
+	assertObservation(t, observation, 0.9, 9, 10)
+}
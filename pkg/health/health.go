@@ -0,0 +1,149 @@
+// Package health aggregates the state of the daemon's components into one
+// report, so /healthz can describe a process that keeps running degraded
+// rather than only answering or not.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Status is the health of one component or of the whole process.
+type Status string
+
+// Component and process states, from best to worst. StatusDisabled marks a
+// component that does not apply to this run or that the operator excluded, and
+// never affects the aggregate.
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDisabled Status = "disabled"
+)
+
+// Components the daemon registers.
+const (
+	ComponentEstimator = "estimator"
+	ComponentPool      = "pool"
+	ComponentOCI       = "oci"
+	ComponentMetrics   = "metrics"
+	ComponentGuards    = "guards"
+)
+
+// ErrUnknownComponent signals a component name other than the Component*
+// constants.
+var ErrUnknownComponent = errors.New("health: unknown component")
+
+// Components lists the component names in report order.
+func Components() []string {
+	return []string{
+		ComponentEstimator,
+		ComponentPool,
+		ComponentOCI,
+		ComponentMetrics,
+		ComponentGuards,
+	}
+}
+
+// ValidateComponent reports whether name is one of Components.
+func ValidateComponent(name string) error {
+	if slices.Contains(Components(), name) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w %q (supported: %s)",
+		ErrUnknownComponent,
+		name,
+		strings.Join(Components(), ", "),
+	)
+}
+
+// Check reports a component's current status and, unless it is healthy, a
+// short reason.
+type Check func() (Status, string)
+
+// Component is one entry of a Report.
+type Component struct {
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the aggregated health: Status is StatusDegraded when any enabled
+// component is, and StatusOK otherwise.
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Registry holds the checks of the registered components. Its methods are
+// safe for concurrent use and do nothing on a nil Registry.
+type Registry struct {
+	mu       sync.Mutex
+	checks   map[string]Check
+	disabled map[string]bool
+}
+
+// NewRegistry constructs an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Check), disabled: make(map[string]bool)}
+}
+
+// Register installs or replaces the check for name.
+func (r *Registry) Register(name string, check Check) {
+	if r == nil || check == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.checks[name] = check
+	r.mu.Unlock()
+}
+
+// Disable excludes name from the aggregate. Its check is no longer run and
+// the report lists it as StatusDisabled, whether it registers before or after.
+func (r *Registry) Disable(name string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.disabled[name] = true
+	r.mu.Unlock()
+}
+
+// Report runs the enabled checks and aggregates their statuses.
+func (r *Registry) Report() Report {
+	report := Report{Status: StatusOK, Components: map[string]Component{}}
+	if r == nil {
+		return report
+	}
+
+	r.mu.Lock()
+	checks := make(map[string]Check, len(r.checks))
+
+	for name, check := range r.checks {
+		if !r.disabled[name] {
+			checks[name] = check
+		}
+	}
+
+	for name := range r.disabled {
+		report.Components[name] = Component{Status: StatusDisabled, Detail: ""}
+	}
+
+	r.mu.Unlock()
+
+	for name, check := range checks {
+		status, detail := check()
+		report.Components[name] = Component{Status: status, Detail: detail}
+
+		if status == StatusDegraded {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,88 @@
+package health_test
+
+import (
+	"errors"
+	"testing"
+
+	"oci-cpu-shaper/pkg/health"
+)
+
+func TestRegistryAggregatesEnabledComponents(t *testing.T) {
+	t.Parallel()
+
+	registry := health.NewRegistry()
+	registry.Register(health.ComponentPool, func() (health.Status, string) {
+		return health.StatusOK, ""
+	})
+	registry.Register(health.ComponentMetrics, func() (health.Status, string) {
+		return health.StatusDisabled, "no listener configured"
+	})
+	registry.Register(health.ComponentGuards, nil)
+
+	report := registry.Report()
+	if report.Status != health.StatusOK || len(report.Components) != 2 {
+		t.Fatalf("unexpected healthy report %+v", report)
+	}
+
+	if report.Components[health.ComponentMetrics].Detail != "no listener configured" {
+		t.Fatalf("expected the detail to be reported, got %+v", report.Components)
+	}
+
+	registry.Register(health.ComponentOCI, func() (health.Status, string) {
+		return health.StatusDegraded, "query failed"
+	})
+
+	report = registry.Report()
+	if report.Status != health.StatusDegraded ||
+		report.Components[health.ComponentOCI] !=
+			(health.Component{Status: health.StatusDegraded, Detail: "query failed"}) {
+		t.Fatalf("expected a degraded oci component to degrade the report, got %+v", report)
+	}
+}
+
+func TestRegistryDisableExcludesComponent(t *testing.T) {
+	t.Parallel()
+
+	registry := health.NewRegistry()
+	registry.Disable(health.ComponentOCI)
+	registry.Register(health.ComponentOCI, func() (health.Status, string) {
+		t.Fatal("expected a disabled check not to run")
+
+		return health.StatusDegraded, ""
+	})
+	registry.Disable(health.ComponentGuards)
+
+	report := registry.Report()
+	if report.Status != health.StatusOK ||
+		report.Components[health.ComponentOCI].Status != health.StatusDisabled ||
+		report.Components[health.ComponentGuards].Status != health.StatusDisabled {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	var empty *health.Registry
+
+	empty.Register(health.ComponentPool, func() (health.Status, string) {
+		return health.StatusOK, ""
+	})
+	empty.Disable(health.ComponentPool)
+
+	if report := empty.Report(); report.Status != health.StatusOK || len(report.Components) != 0 {
+		t.Fatalf("expected an empty report from a nil registry, got %+v", report)
+	}
+}
+
+func TestValidateComponent(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range health.Components() {
+		err := health.ValidateComponent(name)
+		if err != nil {
+			t.Fatalf("ValidateComponent(%q): %v", name, err)
+		}
+	}
+
+	err := health.ValidateComponent("webhook")
+	if !errors.Is(err, health.ErrUnknownComponent) {
+		t.Fatalf("expected ErrUnknownComponent, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of the AES-256 key accepted by WithEncryptionKey.
+const KeySize = 32
+
+var (
+	// ErrInvalidKey reports an encryption key that is not KeySize bytes long.
+	ErrInvalidKey = errors.New("history: encryption key must be 32 bytes")
+	// ErrDecrypt reports a history file that cannot be authenticated with the
+	// configured key, for example a plaintext file or one written with another key.
+	ErrDecrypt = errors.New("history: cannot decrypt history file")
+)
+
+// Option customises a Store during Open.
+type Option func(*options)
+
+type options struct {
+	key []byte
+}
+
+// WithEncryptionKey seals every record written to the backing file with
+// AES-256-GCM under key, so no plaintext utilisation data reaches the disk.
+func WithEncryptionKey(key []byte) Option {
+	return func(opts *options) {
+		opts.key = append([]byte(nil), key...)
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create history cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create history cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// frameSize is the on-disk size of one record: the plain encoding, or the
+// nonce, ciphertext and tag when encryption is enabled.
+func (s *Store) frameSize() int {
+	if s.aead == nil {
+		return recordSize
+	}
+
+	return s.aead.NonceSize() + recordSize + s.aead.Overhead()
+}
+
+func (s *Store) sealRecord(record Record) ([]byte, error) {
+	plain := encodeRecord(record)
+	if s.aead == nil {
+		return plain, nil
+	}
+
+	frame := make([]byte, s.aead.NonceSize(), s.frameSize())
+
+	_, err := rand.Read(frame)
+	if err != nil {
+		return nil, fmt.Errorf("generate history nonce: %w", err)
+	}
+
+	return s.aead.Seal(frame, frame, plain, nil), nil
+}
+
+func (s *Store) openRecord(frame []byte) (Record, error) {
+	if s.aead == nil {
+		return decodeRecord(frame), nil
+	}
+
+	nonceSize := s.aead.NonceSize()
+
+	plain, err := s.aead.Open(nil, frame[:nonceSize], frame[nonceSize:], nil)
+	if err != nil {
+		return Record{}, ErrDecrypt
+	}
+
+	return decodeRecord(plain), nil
+}
@@ -0,0 +1,58 @@
+package history
+
+import (
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+type recorder struct {
+	delegate adapt.MetricsRecorder
+	store    *Store
+}
+
+// NewRecorder decorates delegate so host utilisation samples and applied targets
+// are also captured by store.
+//
+//nolint:ireturn // decorator is wired wherever an adapt.MetricsRecorder is accepted
+func NewRecorder(delegate adapt.MetricsRecorder, store *Store) adapt.MetricsRecorder {
+	if store == nil {
+		return delegate
+	}
+
+	return &recorder{delegate: delegate, store: store}
+}
+
+func (r *recorder) SetMode(mode string) {
+	if r.delegate != nil {
+		r.delegate.SetMode(mode)
+	}
+}
+
+func (r *recorder) SetState(state string) {
+	if r.delegate != nil {
+		r.delegate.SetState(state)
+	}
+}
+
+func (r *recorder) SetTarget(target float64) {
+	if r.delegate != nil {
+		r.delegate.SetTarget(target)
+	}
+
+	r.store.SetTarget(target)
+}
+
+func (r *recorder) ObserveOCIP95(value float64, fetchedAt time.Time) {
+	if r.delegate != nil {
+		r.delegate.ObserveOCIP95(value, fetchedAt)
+	}
+}
+
+func (r *recorder) ObserveHostCPU(utilisation float64) {
+	if r.delegate != nil {
+		r.delegate.ObserveHostCPU(utilisation)
+	}
+
+	r.store.ObserveHostCPU(utilisation)
+}
@@ -0,0 +1,400 @@
+// Package history keeps a rolling, minute-resolution record of local host
+// utilisation and applied duty-cycle targets so operators can audit shaper
+// behaviour without OCI Monitoring access.
+package history
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Retention bounds how far back the store keeps records.
+	Retention = 7 * 24 * time.Hour
+	// Resolution is the bucket width used to aggregate observations.
+	Resolution = time.Minute
+
+	capacity   = int(Retention / Resolution)
+	recordSize = 24
+
+	// compactFactor controls how many records the append-only file may hold
+	// relative to the ring before it is rewritten.
+	compactFactor = 2
+
+	historyDirMode = 0o750
+)
+
+// Record is a single minute bucket.
+type Record struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Utilisation float64   `json:"utilisation"`
+	Target      float64   `json:"target"`
+}
+
+// Store aggregates observations into minute buckets, keeps the last seven days in
+// a ring buffer, and optionally mirrors completed buckets to an append-only file.
+type Store struct {
+	mu sync.Mutex
+
+	ring  []Record
+	head  int
+	count int
+
+	path        string
+	file        *os.File
+	fileRecords int
+	closed      bool
+	aead        cipher.AEAD
+
+	bucket      time.Time
+	utilSum     float64
+	utilSamples int
+	target      float64
+
+	now          func() time.Time
+	errorHandler func(error)
+}
+
+// Open returns a Store backed by the file at path. An empty path keeps the
+// history in memory only. Existing records within the retention window are
+// loaded so history survives restarts.
+func Open(path string, opts ...Option) (*Store, error) {
+	return open(path, time.Now, opts...)
+}
+
+func open(path string, now func() time.Time, opts ...Option) (*Store, error) {
+	store := &Store{
+		ring:         make([]Record, capacity),
+		path:         path,
+		now:          now,
+		errorHandler: func(error) {},
+	}
+
+	var cfg options
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	if path == "" {
+		return store, nil
+	}
+
+	if cfg.key != nil {
+		aead, err := newAEAD(cfg.key)
+		if err != nil {
+			return nil, err
+		}
+
+		store.aead = aead
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), historyDirMode)
+	if err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+
+	err = store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.compactLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// SetErrorHandler installs a hook invoked when a completed bucket cannot be
+// written to the backing file. The bucket stays in memory. A nil handler
+// resets the hook to a no-op.
+func (s *Store) SetErrorHandler(handler func(error)) {
+	if handler == nil {
+		handler = func(error) {}
+	}
+
+	s.mu.Lock()
+	s.errorHandler = handler
+	s.mu.Unlock()
+}
+
+// ObserveHostCPU folds a host utilisation sample into the current minute bucket.
+func (s *Store) ObserveHostCPU(utilisation float64) {
+	if math.IsNaN(utilisation) || math.IsInf(utilisation, 0) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollLocked(s.now())
+	s.utilSum += utilisation
+	s.utilSamples++
+}
+
+// SetTarget records the duty-cycle target applied from now on.
+func (s *Store) SetTarget(target float64) {
+	if math.IsNaN(target) || math.IsInf(target, 0) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollLocked(s.now())
+	s.target = target
+}
+
+// Query returns completed buckets whose timestamps fall within [from, to].
+func (s *Store) Query(from, to time.Time) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollLocked(s.now())
+
+	records := make([]Record, 0)
+
+	for index := range s.count {
+		record := s.ring[(s.head+index)%capacity]
+		if record.Timestamp.Before(from) || record.Timestamp.After(to) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// Sync commits the completed buckets already written to the backing file to
+// stable storage. The in-progress bucket stays open so it is not recorded
+// twice once its minute ends.
+func (s *Store) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil || s.closed {
+		return nil
+	}
+
+	err := s.file.Sync()
+	if err != nil {
+		return fmt.Errorf("sync history file: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes the in-progress bucket and releases the backing file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.flushLocked()
+	s.closed = true
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	if err != nil {
+		return fmt.Errorf("close history file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) rollLocked(now time.Time) {
+	bucket := now.Truncate(Resolution)
+	if s.bucket.IsZero() {
+		s.bucket = bucket
+
+		return
+	}
+
+	if !bucket.After(s.bucket) {
+		return
+	}
+
+	s.flushLocked()
+	s.bucket = bucket
+}
+
+// flushLocked records the current bucket. A bucket without host samples is
+// skipped rather than recorded as idle, leaving a gap in the history.
+func (s *Store) flushLocked() {
+	if s.bucket.IsZero() || s.utilSamples == 0 {
+		return
+	}
+
+	record := Record{
+		Timestamp:   s.bucket,
+		Utilisation: s.utilSum / float64(s.utilSamples),
+		Target:      s.target,
+	}
+
+	s.utilSum = 0
+	s.utilSamples = 0
+
+	s.appendLocked(record)
+
+	if s.path == "" || s.closed {
+		return
+	}
+
+	err := s.writeLocked(record)
+	if err != nil {
+		s.errorHandler(err)
+	}
+}
+
+func (s *Store) writeLocked(record Record) error {
+	// A failed compaction leaves no file open; rewriting the file from the
+	// ring retries it and includes record.
+	if s.file == nil {
+		return s.compactLocked()
+	}
+
+	frame, err := s.sealRecord(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.file.Write(frame)
+	if err != nil {
+		return fmt.Errorf("write history record: %w", err)
+	}
+
+	s.fileRecords++
+	if s.fileRecords >= capacity*compactFactor {
+		return s.compactLocked()
+	}
+
+	return nil
+}
+
+func (s *Store) appendLocked(record Record) {
+	if s.count < capacity {
+		s.ring[(s.head+s.count)%capacity] = record
+		s.count++
+
+		return
+	}
+
+	s.ring[s.head] = record
+	s.head = (s.head + 1) % capacity
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read history file: %w", err)
+	}
+
+	cutoff := s.now().Add(-Retention)
+	size := s.frameSize()
+
+	for offset := 0; offset+size <= len(data); offset += size {
+		record, err := s.openRecord(data[offset : offset+size])
+		if err != nil {
+			return err
+		}
+
+		if record.Timestamp.Before(cutoff) {
+			continue
+		}
+
+		s.appendLocked(record)
+	}
+
+	return nil
+}
+
+// compactLocked rewrites the backing file with the ring contents and reopens it
+// for appending, bounding the on-disk size to a little over seven days.
+func (s *Store) compactLocked() error {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("create history file: %w", err)
+	}
+
+	for index := range s.count {
+		var frame []byte
+
+		frame, err = s.sealRecord(s.ring[(s.head+index)%capacity])
+		if err != nil {
+			break
+		}
+
+		_, err = tmp.Write(frame)
+		if err != nil {
+			break
+		}
+	}
+
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("write history file: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+
+	s.file = file
+	s.fileRecords = s.count
+
+	return nil
+}
+
+func encodeRecord(record Record) []byte {
+	buf := make([]byte, recordSize)
+
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(record.Timestamp.Unix()))
+	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(record.Utilisation))
+	binary.LittleEndian.PutUint64(buf[16:24], math.Float64bits(record.Target))
+
+	return buf
+}
+
+func decodeRecord(buf []byte) Record {
+	return Record{
+		Timestamp:   time.Unix(int64(binary.LittleEndian.Uint64(buf[0:8])), 0).UTC(),
+		Utilisation: math.Float64frombits(binary.LittleEndian.Uint64(buf[8:16])),
+		Target:      math.Float64frombits(binary.LittleEndian.Uint64(buf[16:24])),
+	}
+}
@@ -0,0 +1,441 @@
+package history //nolint:testpackage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(delta time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(delta)
+	c.mu.Unlock()
+}
+
+func TestStoreAggregatesMinuteBuckets(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	store, err := open("", clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.SetTarget(0.25)
+	store.ObserveHostCPU(0.2)
+	clock.Advance(30 * time.Second)
+	store.ObserveHostCPU(0.4)
+	clock.Advance(30 * time.Second)
+	store.SetTarget(0.3)
+	store.ObserveHostCPU(0.9)
+	clock.Advance(time.Minute)
+
+	records := store.Query(time.Time{}, clock.Now())
+	if len(records) != 2 {
+		t.Fatalf("expected 2 completed buckets, got %d: %+v", len(records), records)
+	}
+
+	first := records[0]
+	if !first.Timestamp.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected first bucket timestamp %s", first.Timestamp)
+	}
+
+	if diff := first.Utilisation - 0.3; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected averaged utilisation 0.3, got %f", first.Utilisation)
+	}
+
+	if first.Target != 0.25 {
+		t.Fatalf("expected first target 0.25, got %f", first.Target)
+	}
+
+	if records[1].Utilisation != 0.9 || records[1].Target != 0.3 {
+		t.Fatalf("unexpected second bucket %+v", records[1])
+	}
+
+	filtered := store.Query(records[1].Timestamp, clock.Now())
+	if len(filtered) != 1 {
+		t.Fatalf("expected range filter to return 1 record, got %d", len(filtered))
+	}
+}
+
+func TestStoreRingEvictsOldestBuckets(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	store, err := open("", clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	start := clock.Now()
+
+	for range capacity + 10 {
+		store.ObserveHostCPU(0.5)
+		clock.Advance(Resolution)
+	}
+
+	records := store.Query(time.Time{}, clock.Now())
+	if len(records) != capacity {
+		t.Fatalf("expected %d records, got %d", capacity, len(records))
+	}
+
+	if want := start.Add(10 * Resolution); !records[0].Timestamp.Equal(want) {
+		t.Fatalf("expected oldest bucket %s, got %s", want, records[0].Timestamp)
+	}
+}
+
+func TestStorePersistsAcrossRestarts(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "history.bin")
+
+	store, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.SetTarget(0.4)
+	store.ObserveHostCPU(0.6)
+	clock.Advance(Resolution)
+	store.ObserveHostCPU(0.7)
+
+	err = store.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open history file: %v", err)
+	}
+
+	_, err = file.Write([]byte{1, 2, 3})
+	if err != nil {
+		t.Fatalf("append partial record: %v", err)
+	}
+
+	_ = file.Close()
+
+	reopened, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	defer func() { _ = reopened.Close() }()
+
+	records := reopened.Query(time.Time{}, clock.Now())
+	if len(records) != 2 {
+		t.Fatalf("expected 2 persisted records, got %d", len(records))
+	}
+
+	if records[0].Utilisation != 0.6 || records[0].Target != 0.4 {
+		t.Fatalf("unexpected persisted record %+v", records[0])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat history file: %v", err)
+	}
+
+	if info.Size() != int64(2*recordSize) {
+		t.Fatalf("expected compacted file of %d bytes, got %d", 2*recordSize, info.Size())
+	}
+}
+
+func TestStoreSyncKeepsOpenBucket(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "history.bin")
+
+	store, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.ObserveHostCPU(0.5)
+	clock.Advance(Resolution)
+	store.ObserveHostCPU(0.7)
+
+	err = store.Sync()
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat history file: %v", err)
+	}
+
+	if info.Size() != int64(recordSize) {
+		t.Fatalf("expected only the completed bucket on disk, got %d bytes", info.Size())
+	}
+
+	err = store.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	err = store.Sync()
+	if err != nil {
+		t.Fatalf("expected sync after close to be a no-op, got %v", err)
+	}
+}
+
+func TestStoreSkipsBucketsWithoutSamples(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	store, err := open("", clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.SetTarget(0.4)
+	clock.Advance(Resolution)
+	store.SetTarget(0.5)
+	clock.Advance(Resolution)
+	store.ObserveHostCPU(0.6)
+	clock.Advance(Resolution)
+
+	records := store.Query(time.Time{}, clock.Now())
+	if len(records) != 1 || records[0].Utilisation != 0.6 || records[0].Target != 0.5 {
+		t.Fatalf("expected only the sampled bucket, got %+v", records)
+	}
+}
+
+func TestStoreReportsWriteFailuresAndRecovers(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "history.bin")
+
+	store, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	var failures []error
+
+	store.SetErrorHandler(func(err error) { failures = append(failures, err) })
+
+	// Closing the file underneath the store fails the next append.
+	_ = store.file.Close()
+
+	store.ObserveHostCPU(0.5)
+	clock.Advance(Resolution)
+	store.ObserveHostCPU(0.7)
+
+	if len(failures) != 1 || !errors.Is(failures[0], os.ErrClosed) {
+		t.Fatalf("expected the failed write to be reported, got %v", failures)
+	}
+
+	// A failed compaction leaves no file; the next bucket rewrites it.
+	store.file = nil
+
+	clock.Advance(Resolution)
+	store.ObserveHostCPU(0.9)
+
+	if len(failures) != 1 {
+		t.Fatalf("expected the rewrite to succeed, got %v", failures)
+	}
+
+	store.SetErrorHandler(nil)
+
+	err = store.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	defer reopened.Close()
+
+	if records := reopened.Query(time.Time{}, clock.Now()); len(records) != 3 {
+		t.Fatalf("expected every bucket on disk after the rewrite, got %+v", records)
+	}
+}
+
+func TestStoreEncryptsBackingFile(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "history.bin")
+	key := bytes.Repeat([]byte{0x42}, KeySize)
+
+	store, err := open(path, clock.Now, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.SetTarget(0.4)
+	store.ObserveHostCPU(0.6)
+	clock.Advance(Resolution)
+
+	err = store.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read history file: %v", err)
+	}
+
+	if bytes.Contains(data, encodeRecord(store.Query(time.Time{}, clock.Now())[0])) {
+		t.Fatal("expected the backing file to hold no plaintext records")
+	}
+
+	reopened, err := open(path, clock.Now, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	records := reopened.Query(time.Time{}, clock.Now())
+	if len(records) != 1 || records[0].Utilisation != 0.6 || records[0].Target != 0.4 {
+		t.Fatalf("unexpected decrypted records %+v", records)
+	}
+
+	_ = reopened.Close()
+
+	_, err = open(path, clock.Now, WithEncryptionKey(bytes.Repeat([]byte{0x24}, KeySize)))
+	if !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt with the wrong key, got %v", err)
+	}
+
+	_, err = open(path, clock.Now, WithEncryptionKey([]byte("short")))
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestStoreDropsRecordsOutsideRetention(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "history.bin")
+
+	store, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	store.ObserveHostCPU(0.1)
+	clock.Advance(Resolution)
+
+	err = store.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	clock.Advance(Retention + time.Hour)
+
+	reopened, err := open(path, clock.Now)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	defer func() { _ = reopened.Close() }()
+
+	if records := reopened.Query(time.Time{}, clock.Now()); len(records) != 0 {
+		t.Fatalf("expected expired records to be dropped, got %+v", records)
+	}
+}
+
+func TestStoreIgnoresNonFiniteSamples(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	store, err := Open("")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	store.now = clock.Now
+
+	nan := 0.0
+	nan /= nan
+
+	store.ObserveHostCPU(nan)
+	store.SetTarget(nan)
+
+	if store.utilSamples != 0 || store.target != 0 {
+		t.Fatalf("expected non-finite samples to be ignored, got %+v", store)
+	}
+}
+
+type countingRecorder struct {
+	targets []float64
+	samples []float64
+}
+
+func (c *countingRecorder) SetMode(string) {}
+
+func (c *countingRecorder) SetState(string) {}
+
+func (c *countingRecorder) SetTarget(target float64) { c.targets = append(c.targets, target) }
+
+func (c *countingRecorder) ObserveOCIP95(float64, time.Time) {}
+
+func (c *countingRecorder) ObserveHostCPU(utilisation float64) {
+	c.samples = append(c.samples, utilisation)
+}
+
+func TestRecorderForwardsToDelegateAndStore(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	store, err := open("", clock.Now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	delegate := new(countingRecorder)
+	recorder := NewRecorder(delegate, store)
+
+	recorder.SetTarget(0.35)
+	recorder.ObserveHostCPU(0.5)
+	clock.Advance(Resolution)
+
+	if len(delegate.targets) != 1 || len(delegate.samples) != 1 {
+		t.Fatalf("expected delegate to receive signals, got %+v", delegate)
+	}
+
+	records := store.Query(time.Time{}, clock.Now())
+	if len(records) != 1 || records[0].Target != 0.35 || records[0].Utilisation != 0.5 {
+		t.Fatalf("expected store to capture signals, got %+v", records)
+	}
+
+	if NewRecorder(delegate, nil) != delegate {
+		t.Fatal("expected nil store to return the delegate unchanged")
+	}
+}
@@ -0,0 +1,165 @@
+// Package hooks runs site-specific commands before and after the actuator
+// applies a new duty-cycle target, so integrations such as resizing nginx
+// worker counts or notifying a local agent can follow the shaper's decisions.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+const (
+	// PhasePre labels hooks run before the target is applied.
+	PhasePre = "pre"
+	// PhasePost labels hooks run after the target is applied.
+	PhasePost = "post"
+	// DefaultTimeout bounds a hook when no timeout is configured.
+	DefaultTimeout = 10 * time.Second
+
+	// maxOutputBytes bounds how much hook output is quoted in errors.
+	maxOutputBytes = 512
+)
+
+var errHookFailed = errors.New("hooks: command failed")
+
+// Hook describes a command run around target changes. Command holds the
+// executable and its arguments; an empty Command disables the hook.
+type Hook struct {
+	Command []string
+	Timeout time.Duration
+}
+
+// Enabled reports whether the hook has a command to run.
+func (h Hook) Enabled() bool {
+	return len(h.Command) > 0 && strings.TrimSpace(h.Command[0]) != ""
+}
+
+type runner func(ctx context.Context, command []string, env []string) ([]byte, error)
+
+// Actuator wraps a DutyCycler and runs the configured hooks whenever SetTarget
+// changes the applied target. Hooks run synchronously with the target update,
+// so each one delays the control loop by at most its timeout. Hook failures
+// are reported to the error handler and never block the target change.
+type Actuator struct {
+	delegate adapt.DutyCycler
+	pre      Hook
+	post     Hook
+	run      runner
+
+	mu           sync.Mutex
+	errorHandler func(error)
+}
+
+var _ adapt.DutyCycler = (*Actuator)(nil)
+
+// NewActuator constructs an Actuator that applies targets through delegate.
+func NewActuator(delegate adapt.DutyCycler, pre, post Hook) *Actuator {
+	return &Actuator{delegate: delegate, pre: pre, post: post, run: runCommand}
+}
+
+// SetErrorHandler installs a callback invoked when a hook fails or times out.
+// A nil handler discards failures.
+func (a *Actuator) SetErrorHandler(handler func(error)) {
+	a.mu.Lock()
+	a.errorHandler = handler
+	a.mu.Unlock()
+}
+
+// SetTarget runs the pre hook, applies target through the delegate, then runs
+// the post hook. Calls that leave the target unchanged skip both hooks.
+func (a *Actuator) SetTarget(target float64) {
+	previous := a.delegate.Target()
+	if target == previous {
+		a.delegate.SetTarget(target)
+
+		return
+	}
+
+	a.runHook(PhasePre, a.pre, previous, target)
+	a.delegate.SetTarget(target)
+	a.runHook(PhasePost, a.post, previous, target)
+}
+
+// Target returns the delegate's current target.
+func (a *Actuator) Target() float64 {
+	return a.delegate.Target()
+}
+
+func (a *Actuator) runHook(phase string, hook Hook, previous, target float64) {
+	if !hook.Enabled() {
+		return
+	}
+
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	env := []string{
+		"SHAPER_HOOK_PHASE=" + phase,
+		"SHAPER_PREVIOUS_TARGET=" + strconv.FormatFloat(previous, 'f', -1, 64),
+		"SHAPER_TARGET=" + strconv.FormatFloat(target, 'f', -1, 64),
+	}
+
+	output, err := a.run(ctx, hook.Command, env)
+	if err == nil {
+		return
+	}
+
+	a.mu.Lock()
+	handler := a.errorHandler
+	a.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+
+	handler(fmt.Errorf(
+		"%w: %s hook %q: %w: %s",
+		errHookFailed,
+		phase,
+		hook.Command[0],
+		err,
+		tail(output),
+	))
+}
+
+func runCommand(ctx context.Context, command []string, env []string) ([]byte, error) {
+	//nolint:gosec // hook commands come from operator-controlled configuration
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+
+	var output bytes.Buffer
+
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if err != nil {
+		return output.Bytes(), fmt.Errorf("run: %w", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+func tail(output []byte) string {
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) > maxOutputBytes {
+		trimmed = trimmed[len(trimmed)-maxOutputBytes:]
+	}
+
+	return string(trimmed)
+}
@@ -0,0 +1,116 @@
+package hooks //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeCycler struct {
+	target float64
+	calls  []float64
+}
+
+func (f *fakeCycler) SetTarget(target float64) {
+	f.target = target
+	f.calls = append(f.calls, target)
+}
+
+func (f *fakeCycler) Target() float64 {
+	return f.target
+}
+
+func TestActuatorRunsHooksAroundTargetChanges(t *testing.T) {
+	t.Parallel()
+
+	cycler := &fakeCycler{target: 0.2}
+	actuator := NewActuator(
+		cycler,
+		Hook{Command: []string{"pre"}},
+		Hook{Command: []string{"post"}},
+	)
+
+	var events []string
+
+	actuator.run = func(_ context.Context, command []string, env []string) ([]byte, error) {
+		applied := strconv.FormatFloat(cycler.target, 'f', -1, 64)
+		events = append(events, command[0]+":"+strings.Join(env, ",")+":applied="+applied)
+
+		return nil, nil
+	}
+
+	actuator.SetTarget(0.3)
+	actuator.SetTarget(0.3)
+
+	want := []string{
+		"pre:SHAPER_HOOK_PHASE=pre,SHAPER_PREVIOUS_TARGET=0.2,SHAPER_TARGET=0.3:applied=0.2",
+		"post:SHAPER_HOOK_PHASE=post,SHAPER_PREVIOUS_TARGET=0.2,SHAPER_TARGET=0.3:applied=0.3",
+	}
+
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected hook events:\n%s", strings.Join(events, "\n"))
+	}
+
+	if len(cycler.calls) != 2 || actuator.Target() != 0.3 {
+		t.Fatalf("expected delegate to receive both calls, got %v", cycler.calls)
+	}
+}
+
+func TestActuatorReportsHookFailuresWithoutBlockingTarget(t *testing.T) {
+	t.Parallel()
+
+	cycler := new(fakeCycler)
+	actuator := NewActuator(
+		cycler,
+		Hook{Command: []string{"sh", "-c", "echo boom >&2; exit 3"}},
+		Hook{Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond},
+	)
+
+	var failures []error
+
+	actuator.SetErrorHandler(func(err error) { failures = append(failures, err) })
+	actuator.SetTarget(0.4)
+
+	if cycler.target != 0.4 {
+		t.Fatalf("expected target to apply despite hook failures, got %.2f", cycler.target)
+	}
+
+	if len(failures) != 2 {
+		t.Fatalf("expected pre and post failures, got %v", failures)
+	}
+
+	if !errors.Is(failures[0], errHookFailed) || !strings.Contains(failures[0].Error(), "boom") {
+		t.Fatalf("expected pre failure to quote hook output, got %v", failures[0])
+	}
+
+	if !strings.Contains(failures[1].Error(), "post hook") {
+		t.Fatalf("expected post hook timeout, got %v", failures[1])
+	}
+}
+
+func TestRunCommandExportsTargets(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "target")
+	actuator := NewActuator(
+		new(fakeCycler),
+		Hook{},
+		Hook{Command: []string{"sh", "-c", `printf "$SHAPER_TARGET" > "$0"`, path}},
+	)
+
+	actuator.SetTarget(0.25)
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read hook output: %v", err)
+	}
+
+	if string(contents) != "0.25" {
+		t.Fatalf("expected hook to see SHAPER_TARGET=0.25, got %q", contents)
+	}
+}
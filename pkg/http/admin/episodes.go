@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+// SuppressionEpisodeProvider returns the recent suppression episodes, oldest
+// first, ending with the ongoing one if any.
+type SuppressionEpisodeProvider interface {
+	SuppressionEpisodes() []adapt.SuppressionEpisode
+}
+
+// Episode is one suppression episode in an EpisodesResponse. End is omitted
+// while the episode is ongoing, and durations are in seconds.
+type Episode struct {
+	Start           time.Time  `json:"start"`
+	End             *time.Time `json:"end,omitempty"`
+	Ongoing         bool       `json:"ongoing"`
+	DurationSeconds float64    `json:"durationSeconds"`
+	PeakHostLoad    float64    `json:"peakHostLoad"`
+	DeficitSeconds  float64    `json:"deficitSeconds"`
+	Sources         []string   `json:"sources"`
+}
+
+// EpisodesResponse is the JSON document returned by the suppression episodes
+// endpoint. DeficitSeconds totals the duty-cycle time the listed episodes
+// gave up.
+type EpisodesResponse struct {
+	Now            time.Time `json:"now"`
+	DeficitSeconds float64   `json:"deficitSeconds"`
+	Episodes       []Episode `json:"episodes"`
+}
+
+// EpisodesHandler serves the controller's recent suppression episodes as JSON
+// so operators can see how much shaping contention on the host cost.
+type EpisodesHandler struct {
+	provider SuppressionEpisodeProvider
+	now      func() time.Time
+}
+
+// NewEpisodesHandler constructs an EpisodesHandler backed by provider.
+func NewEpisodesHandler(provider SuppressionEpisodeProvider) *EpisodesHandler {
+	return &EpisodesHandler{provider: provider, now: time.Now}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *EpisodesHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.provider == nil {
+		http.Error(writer, "suppression episodes unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	now := h.now().UTC()
+	response := EpisodesResponse{Now: now, DeficitSeconds: 0, Episodes: []Episode{}}
+
+	for _, episode := range h.provider.SuppressionEpisodes() {
+		entry := Episode{
+			Start:           episode.Start.UTC(),
+			End:             nil,
+			Ongoing:         episode.Ongoing(),
+			DurationSeconds: episode.Duration(now).Seconds(),
+			PeakHostLoad:    episode.PeakHostLoad,
+			DeficitSeconds:  episode.Deficit.Seconds(),
+			Sources:         episode.Sources,
+		}
+
+		if !entry.Ongoing {
+			end := episode.End.UTC()
+			entry.End = &end
+		}
+
+		if entry.Sources == nil {
+			entry.Sources = []string{}
+		}
+
+		response.DeficitSeconds += entry.DeficitSeconds
+		response.Episodes = append(response.Episodes, entry)
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		http.Error(writer, "encode suppression episodes", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
@@ -0,0 +1,120 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+type stubEpisodes struct {
+	episodes []adapt.SuppressionEpisode
+}
+
+func (s stubEpisodes) SuppressionEpisodes() []adapt.SuppressionEpisode {
+	return s.episodes
+}
+
+func serveEpisodes(
+	t *testing.T,
+	provider admin.SuppressionEpisodeProvider,
+	method string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"episodes", admin.NewEpisodesHandler(provider))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/admin/episodes", nil))
+
+	return recorder
+}
+
+func TestEpisodesHandlerReturnsEpisodes(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provider := stubEpisodes{episodes: []adapt.SuppressionEpisode{
+		{
+			Start:        start,
+			End:          start.Add(10 * time.Minute),
+			PeakHostLoad: 0.92,
+			Deficit:      3 * time.Minute,
+			Sources:      []string{adapt.SuppressionSourceHostLoad},
+		},
+		{
+			Start:        time.Now().Add(-time.Minute),
+			End:          time.Time{},
+			PeakHostLoad: 0,
+			Deficit:      30 * time.Second,
+			Sources:      nil,
+		},
+	}}
+
+	recorder := serveEpisodes(t, provider, http.MethodGet)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("expected application/json content type, got %q", got)
+	}
+
+	var response admin.EpisodesResponse
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if len(response.Episodes) != 2 || response.DeficitSeconds != 210 || response.Now.IsZero() {
+		t.Fatalf("unexpected response %+v", response)
+	}
+
+	finished := response.Episodes[0]
+	if finished.Ongoing || finished.End == nil || !finished.End.Equal(start.Add(10*time.Minute)) ||
+		finished.DurationSeconds != 600 || finished.DeficitSeconds != 180 ||
+		finished.PeakHostLoad != 0.92 || len(finished.Sources) != 1 {
+		t.Fatalf("unexpected finished episode %+v", finished)
+	}
+
+	ongoing := response.Episodes[1]
+	if !ongoing.Ongoing || ongoing.End != nil || ongoing.DurationSeconds < 60 ||
+		ongoing.Sources == nil {
+		t.Fatalf("unexpected ongoing episode %+v", ongoing)
+	}
+}
+
+func TestEpisodesHandlerRejectsOtherMethodsAndMissingProvider(t *testing.T) {
+	t.Parallel()
+
+	recorder := serveEpisodes(t, stubEpisodes{episodes: nil}, http.MethodPost)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "GET" {
+		t.Fatalf("expected 405 with Allow: GET, got %d %q",
+			recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	recorder = serveEpisodes(t, stubEpisodes{episodes: nil}, http.MethodGet)
+	if body := recorder.Body.String(); !json.Valid([]byte(body)) ||
+		!containsEmptyArray(body, "episodes") {
+		t.Fatalf("expected an empty episodes array, got %s", body)
+	}
+
+	recorder = serveEpisodes(t, nil, http.MethodGet)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a provider, got %d", recorder.Code)
+	}
+}
+
+func containsEmptyArray(body, field string) bool {
+	var raw map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(body), &raw)
+
+	return err == nil && string(raw[field]) == "[]"
+}
@@ -0,0 +1,93 @@
+// Package admin serves the operator-facing /admin endpoints exposed alongside
+// the metrics listener.
+package admin
+
+import (
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Prefix is the path prefix under which admin routes are mounted.
+const Prefix = "/admin/"
+
+// authChallenge names the headers callers must sign, mirroring OCI request signing.
+const authChallenge = `Signature headers="date (request-target) host"`
+
+// ErrForbidden marks an authenticated caller that may not use the admin API.
+// Authenticators wrap it to answer 403 instead of 401.
+var ErrForbidden = errors.New("admin: caller not permitted")
+
+// Authenticator verifies the caller of an admin request before it is routed.
+type Authenticator interface {
+	Authenticate(request *http.Request) error
+}
+
+// Handler multiplexes admin endpoints.
+type Handler struct {
+	mux          *http.ServeMux
+	auth         Authenticator
+	loopbackOnly bool
+}
+
+// NewHandler constructs an empty admin Handler.
+func NewHandler() *Handler {
+	return &Handler{mux: http.NewServeMux()}
+}
+
+// Handle registers handler for pattern. Patterns follow net/http.ServeMux syntax
+// and must include the Prefix.
+func (h *Handler) Handle(pattern string, handler http.Handler) {
+	h.mux.Handle(pattern, handler)
+}
+
+// RequireAuthentication rejects requests that authenticator does not accept,
+// with 403 for errors wrapping ErrForbidden and 401 otherwise.
+func (h *Handler) RequireAuthentication(authenticator Authenticator) {
+	h.auth = authenticator
+}
+
+// RestrictToLoopback answers 403 to callers outside the loopback network. The
+// admin routes share the metrics listener, so without authentication this keeps
+// remote scrapers from forcing suppression or steps.
+func (h *Handler) RestrictToLoopback() {
+	h.loopbackOnly = true
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h.loopbackOnly && !isLoopback(request.RemoteAddr) {
+		http.Error(writer, "forbidden", http.StatusForbidden)
+
+		return
+	}
+
+	if h.auth != nil {
+		err := h.auth.Authenticate(request)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(writer, "forbidden", http.StatusForbidden)
+
+			return
+		}
+
+		if err != nil {
+			writer.Header().Set("WWW-Authenticate", authChallenge)
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+	}
+
+	h.mux.ServeHTTP(writer, request)
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
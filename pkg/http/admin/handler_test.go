@@ -0,0 +1,94 @@
+package admin_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+var errStubUnsigned = errors.New("stub: request not signed")
+
+type stubAuthenticator map[string]error
+
+func (s stubAuthenticator) Authenticate(request *http.Request) error {
+	return s[request.Header.Get("Authorization")]
+}
+
+func TestHandlerRequiresAuthentication(t *testing.T) {
+	t.Parallel()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"ping", http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+	))
+	handler.RequireAuthentication(stubAuthenticator{
+		"":         errStubUnsigned,
+		"outsider": fmt.Errorf("%w: not in dynamic group", admin.ErrForbidden),
+	})
+
+	testCases := []struct {
+		authorization string
+		want          int
+	}{
+		{authorization: "", want: http.StatusUnauthorized},
+		{authorization: "outsider", want: http.StatusForbidden},
+		{authorization: "member", want: http.StatusNoContent},
+	}
+
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodPost, admin.Prefix+"ping", nil)
+		request.Header.Set("Authorization", testCase.authorization)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != testCase.want {
+			t.Fatalf(
+				"%q: expected %d, got %d",
+				testCase.authorization,
+				testCase.want,
+				recorder.Code,
+			)
+		}
+	}
+}
+
+func TestHandlerRestrictsToLoopback(t *testing.T) {
+	t.Parallel()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"ping", http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+	))
+	handler.RestrictToLoopback()
+
+	testCases := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{remoteAddr: "127.0.0.1:40000", want: http.StatusNoContent},
+		{remoteAddr: "[::1]:40000", want: http.StatusNoContent},
+		{remoteAddr: "10.0.0.5:40000", want: http.StatusForbidden},
+		{remoteAddr: "not-an-address", want: http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodPost, admin.Prefix+"ping", nil)
+		request.RemoteAddr = testCase.remoteAddr
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != testCase.want {
+			t.Fatalf("%q: expected %d, got %d", testCase.remoteAddr, testCase.want, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"oci-cpu-shaper/pkg/history"
+)
+
+// DefaultHistoryWindow bounds history queries that do not specify a range.
+const DefaultHistoryWindow = 24 * time.Hour
+
+// HistoryQuerier returns recorded history buckets within a time range.
+type HistoryQuerier interface {
+	Query(from, to time.Time) []history.Record
+}
+
+// HistoryResponse is the JSON document returned by the history endpoint.
+type HistoryResponse struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Records []history.Record `json:"records"`
+}
+
+// HistoryHandler serves minute-level utilisation and target history as JSON.
+// Callers select the range with either since=<duration> or from/to RFC3339
+// timestamps; the last 24 hours are returned by default.
+type HistoryHandler struct {
+	store HistoryQuerier
+	now   func() time.Time
+}
+
+// NewHistoryHandler constructs a HistoryHandler backed by store.
+func NewHistoryHandler(store HistoryQuerier) *HistoryHandler {
+	return &HistoryHandler{store: store, now: time.Now}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HistoryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.store == nil {
+		http.Error(writer, "history unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	from, to, ok := h.parseRange(writer, request)
+	if !ok {
+		return
+	}
+
+	response := HistoryResponse{
+		From:    from,
+		To:      to,
+		Records: h.store.Query(from, to),
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		http.Error(writer, "encode history", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
+
+func (h *HistoryHandler) parseRange(
+	writer http.ResponseWriter,
+	request *http.Request,
+) (time.Time, time.Time, bool) {
+	query := request.URL.Query()
+	to := h.now().UTC()
+	from := to.Add(-DefaultHistoryWindow)
+
+	if since := query.Get("since"); since != "" {
+		window, err := time.ParseDuration(since)
+		if err != nil || window <= 0 {
+			http.Error(writer, "since must be a positive duration", http.StatusBadRequest)
+
+			return time.Time{}, time.Time{}, false
+		}
+
+		from = to.Add(-window)
+	}
+
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(writer, "from must be an RFC3339 timestamp", http.StatusBadRequest)
+
+			return time.Time{}, time.Time{}, false
+		}
+
+		from = parsed.UTC()
+	}
+
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(writer, "to must be an RFC3339 timestamp", http.StatusBadRequest)
+
+			return time.Time{}, time.Time{}, false
+		}
+
+		to = parsed.UTC()
+	}
+
+	if to.Before(from) {
+		http.Error(writer, "to must not precede from", http.StatusBadRequest)
+
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
@@ -0,0 +1,119 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/history"
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+type stubHistory struct {
+	from    time.Time
+	to      time.Time
+	records []history.Record
+}
+
+func (s *stubHistory) Query(from, to time.Time) []history.Record {
+	s.from = from
+	s.to = to
+
+	return s.records
+}
+
+func serveHistory(
+	t *testing.T,
+	store admin.HistoryQuerier,
+	target string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"history", admin.NewHistoryHandler(store))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return recorder
+}
+
+func TestHistoryHandlerReturnsRecords(t *testing.T) {
+	t.Parallel()
+
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &stubHistory{
+		records: []history.Record{{Timestamp: stamp, Utilisation: 0.4, Target: 0.3}},
+	}
+
+	recorder := serveHistory(
+		t,
+		store,
+		"/admin/history?from=2024-05-01T11:00:00Z&to=2024-05-01T13:00:00Z",
+	)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("expected application/json content type, got %q", got)
+	}
+
+	var response admin.HistoryResponse
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if len(response.Records) != 1 || response.Records[0].Target != 0.3 {
+		t.Fatalf("unexpected records %+v", response.Records)
+	}
+
+	if !store.from.Equal(stamp.Add(-time.Hour)) || !store.to.Equal(stamp.Add(time.Hour)) {
+		t.Fatalf("unexpected query range %s..%s", store.from, store.to)
+	}
+}
+
+func TestHistoryHandlerAppliesSinceWindow(t *testing.T) {
+	t.Parallel()
+
+	store := new(stubHistory)
+
+	recorder := serveHistory(t, store, "/admin/history?since=2h")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", recorder.Code)
+	}
+
+	if window := store.to.Sub(store.from); window != 2*time.Hour {
+		t.Fatalf("expected 2h window, got %s", window)
+	}
+}
+
+func TestHistoryHandlerRejectsInvalidRanges(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{
+		"/admin/history?since=soon",
+		"/admin/history?since=-1h",
+		"/admin/history?from=yesterday",
+		"/admin/history?to=tomorrow",
+		"/admin/history?from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+	} {
+		recorder := serveHistory(t, new(stubHistory), target)
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", target, recorder.Code)
+		}
+	}
+}
+
+func TestHistoryHandlerRequiresStore(t *testing.T) {
+	t.Parallel()
+
+	recorder := serveHistory(t, nil, "/admin/history")
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a store, got %d", recorder.Code)
+	}
+}
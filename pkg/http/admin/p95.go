@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+// P95HistoryProvider returns the recent OCI P95 readings, oldest first.
+type P95HistoryProvider interface {
+	P95History() []adapt.P95Sample
+}
+
+// P95Response is the JSON document returned by the P95 history endpoint.
+type P95Response struct {
+	Now     time.Time         `json:"now"`
+	Samples []adapt.P95Sample `json:"samples"`
+}
+
+// P95Handler serves the controller's recent OCI P95 readings as JSON so
+// shaper status can show the trend without a metrics stack.
+type P95Handler struct {
+	provider P95HistoryProvider
+	now      func() time.Time
+}
+
+// NewP95Handler constructs a P95Handler backed by provider.
+func NewP95Handler(provider P95HistoryProvider) *P95Handler {
+	return &P95Handler{provider: provider, now: time.Now}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *P95Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.provider == nil {
+		http.Error(writer, "p95 history unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	samples := h.provider.P95History()
+	if samples == nil {
+		samples = []adapt.P95Sample{}
+	}
+
+	payload, err := json.Marshal(P95Response{Now: h.now().UTC(), Samples: samples})
+	if err != nil {
+		http.Error(writer, "encode p95 history", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
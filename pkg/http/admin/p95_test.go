@@ -0,0 +1,93 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+type stubP95History struct {
+	samples []adapt.P95Sample
+}
+
+func (s stubP95History) P95History() []adapt.P95Sample {
+	return s.samples
+}
+
+func serveP95(
+	t *testing.T,
+	provider admin.P95HistoryProvider,
+	method string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"p95", admin.NewP95Handler(provider))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/admin/p95", nil))
+
+	return recorder
+}
+
+func TestP95HandlerReturnsSamples(t *testing.T) {
+	t.Parallel()
+
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provider := stubP95History{samples: []adapt.P95Sample{{Timestamp: stamp, P95: 0.25}}}
+
+	recorder := serveP95(t, provider, http.MethodGet)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("expected application/json content type, got %q", got)
+	}
+
+	var response admin.P95Response
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if len(response.Samples) != 1 || response.Samples[0].P95 != 0.25 ||
+		!response.Samples[0].Timestamp.Equal(stamp) || response.Now.IsZero() {
+		t.Fatalf("unexpected response %+v", response)
+	}
+
+	recorder = serveP95(t, stubP95History{samples: nil}, http.MethodGet)
+	if body := recorder.Body.String(); !json.Valid([]byte(body)) ||
+		!containsEmptySamples(body) {
+		t.Fatalf("expected an empty samples array, got %s", body)
+	}
+}
+
+func containsEmptySamples(body string) bool {
+	var raw map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(body), &raw)
+
+	return err == nil && string(raw["samples"]) == "[]"
+}
+
+func TestP95HandlerRejectsOtherMethodsAndMissingProvider(t *testing.T) {
+	t.Parallel()
+
+	recorder := serveP95(t, stubP95History{samples: nil}, http.MethodPost)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "GET" {
+		t.Fatalf("expected 405 with Allow: GET, got %d %q",
+			recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	recorder = serveP95(t, nil, http.MethodGet)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a provider, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,180 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/history"
+)
+
+const (
+	snapshotDirMode  = 0o750
+	snapshotFileMode = 0o600
+)
+
+// MetricsRenderer renders the current metrics exposition.
+type MetricsRenderer interface {
+	Render() ([]byte, error)
+}
+
+// HistorySyncer exposes the history store to the snapshot endpoint.
+type HistorySyncer interface {
+	HistoryQuerier
+	Sync() error
+}
+
+// Snapshot is the document written by the snapshot endpoint.
+type Snapshot struct {
+	Timestamp time.Time        `json:"timestamp"`
+	Metrics   string           `json:"metrics"`
+	History   []history.Record `json:"history"`
+}
+
+// SnapshotResponse is the JSON document returned by the snapshot endpoint.
+type SnapshotResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Path      string    `json:"path"`
+}
+
+// SnapshotHandler commits the history file to disk and writes the current
+// metrics and recorded history to a timestamped JSON file in dir on POST, so
+// operators capturing a support bundle get state that matches the moment they
+// asked for it.
+type SnapshotHandler struct {
+	dir     string
+	metrics MetricsRenderer
+	history HistorySyncer
+	now     func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewSnapshotHandler constructs a SnapshotHandler writing to dir.
+func NewSnapshotHandler(
+	dir string,
+	metrics MetricsRenderer,
+	history HistorySyncer,
+) *SnapshotHandler {
+	return &SnapshotHandler{
+		dir:     dir,
+		metrics: metrics,
+		history: history,
+		now:     time.Now,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SnapshotHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.dir == "" || h.metrics == nil || h.history == nil {
+		http.Error(writer, "snapshot unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	response, err := h.write()
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		http.Error(writer, "encode snapshot", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
+
+func (h *SnapshotHandler) write() (SnapshotResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err := h.history.Sync()
+	if err != nil {
+		return SnapshotResponse{}, fmt.Errorf("sync history: %w", err)
+	}
+
+	metrics, err := h.metrics.Render()
+	if err != nil {
+		return SnapshotResponse{}, fmt.Errorf("render metrics: %w", err)
+	}
+
+	now := h.now().UTC()
+	snapshot := Snapshot{
+		Timestamp: now,
+		Metrics:   string(metrics),
+		History:   h.history.Query(now.Add(-history.Retention), now),
+	}
+
+	payload, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return SnapshotResponse{}, fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	err = os.MkdirAll(h.dir, snapshotDirMode)
+	if err != nil {
+		return SnapshotResponse{}, fmt.Errorf("create snapshot directory: %w", err)
+	}
+
+	path := filepath.Join(h.dir, "snapshot-"+now.Format("20060102T150405.000Z")+".json")
+
+	err = writeFileSync(path, payload)
+	if err != nil {
+		return SnapshotResponse{}, err
+	}
+
+	return SnapshotResponse{Timestamp: now, Path: path}, nil
+}
+
+// writeFileSync writes payload to a temporary file next to path, syncs it, and
+// renames it into place so readers never see a partial snapshot.
+func writeFileSync(path string, payload []byte) error {
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, snapshotFileMode)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+
+	_, err = file.Write(payload)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/history"
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+var errStubSync = errors.New("stub: sync failed")
+
+type stubMetrics struct{}
+
+func (stubMetrics) Render() ([]byte, error) {
+	return []byte("shaper_target_ratio 0.2500\n"), nil
+}
+
+type syncingHistory struct {
+	stubHistory
+
+	syncs int
+	err   error
+}
+
+func (s *syncingHistory) Sync() error {
+	s.syncs++
+
+	return s.err
+}
+
+func serveSnapshot(
+	t *testing.T,
+	handler *admin.SnapshotHandler,
+	method string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := admin.NewHandler()
+	mux.Handle(admin.Prefix+"snapshot", handler)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(method, "/admin/snapshot", nil))
+
+	return recorder
+}
+
+func TestSnapshotHandlerWritesSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &syncingHistory{ //nolint:exhaustruct
+		stubHistory: stubHistory{ //nolint:exhaustruct
+			records: []history.Record{{Timestamp: stamp, Utilisation: 0.4, Target: 0.3}},
+		},
+	}
+
+	recorder := serveSnapshot(
+		t,
+		admin.NewSnapshotHandler(dir, stubMetrics{}, store),
+		http.MethodPost,
+	)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response admin.SnapshotResponse
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if store.syncs != 1 || filepath.Dir(response.Path) != dir {
+		t.Fatalf("expected one sync and a snapshot in %s, got %d / %+v", dir, store.syncs, response)
+	}
+
+	data, err := os.ReadFile(response.Path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+
+	var snapshot admin.Snapshot
+
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+
+	if snapshot.Metrics != "shaper_target_ratio 0.2500\n" || len(snapshot.History) != 1 ||
+		!snapshot.Timestamp.Equal(response.Timestamp) {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	if got := store.to.Sub(store.from); got != history.Retention {
+		t.Fatalf("expected the full retention window, got %v", got)
+	}
+}
+
+func TestSnapshotHandlerRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	store := &syncingHistory{} //nolint:exhaustruct
+	handler := admin.NewSnapshotHandler(t.TempDir(), stubMetrics{}, store)
+
+	recorder := serveSnapshot(t, handler, http.MethodGet)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "POST" {
+		t.Fatalf("expected 405 with Allow: POST, got %d", recorder.Code)
+	}
+
+	store.err = errStubSync
+
+	recorder = serveSnapshot(t, handler, http.MethodPost)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the history sync fails, got %d", recorder.Code)
+	}
+
+	recorder = serveSnapshot(t, admin.NewSnapshotHandler("", stubMetrics{}, store), http.MethodPost)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a snapshot directory, got %d", recorder.Code)
+	}
+}
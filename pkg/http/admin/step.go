@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+const (
+	// DefaultStepCooldown is the minimum spacing between forced steps so the
+	// endpoint cannot be used to hammer the Monitoring API.
+	DefaultStepCooldown = time.Minute
+	// DefaultStepTimeout bounds how long a request waits for the step.
+	DefaultStepTimeout = 30 * time.Second
+)
+
+// StepController runs an immediate control step on demand.
+type StepController interface {
+	RequestStep(ctx context.Context) (adapt.Decision, error)
+}
+
+// StepResponse is the JSON document returned by the step endpoint.
+type StepResponse struct {
+	StepID       string    `json:"stepId,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	State        string    `json:"state"`
+	P95          float64   `json:"p95"`
+	Target       float64   `json:"target"`
+	NextInterval string    `json:"nextInterval"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// StepHandler forces a Monitoring query and control step outside the regular
+// interval on POST, so operators can confirm recovery right after fixing IAM
+// policies. Requests within the cooldown of the previous step receive 429.
+type StepHandler struct {
+	controller StepController
+	cooldown   time.Duration
+	timeout    time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewStepHandler constructs a StepHandler backed by controller.
+func NewStepHandler(controller StepController) *StepHandler {
+	return &StepHandler{
+		controller: controller,
+		cooldown:   DefaultStepCooldown,
+		timeout:    DefaultStepTimeout,
+		now:        time.Now,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *StepHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.controller == nil {
+		http.Error(writer, "step unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", "POST")
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	wait := h.reserve()
+	if wait > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(writer, "step rate limited", http.StatusTooManyRequests)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), h.timeout)
+	defer cancel()
+
+	decision, err := h.controller.RequestStep(ctx)
+	if err != nil {
+		http.Error(writer, "step did not complete", http.StatusGatewayTimeout)
+
+		return
+	}
+
+	response := StepResponse{
+		StepID:       decision.StepID,
+		Timestamp:    decision.Timestamp,
+		State:        decision.State.String(),
+		P95:          decision.P95,
+		Target:       decision.Target,
+		NextInterval: decision.NextInterval.String(),
+	}
+	if decision.Err != nil {
+		response.Error = decision.Err.Error()
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		http.Error(writer, "encode step", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
+
+// reserve claims the next step slot, returning how long the caller must wait
+// when the previous step is still inside the cooldown.
+func (h *StepHandler) reserve() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if !h.last.IsZero() {
+		wait := h.last.Add(h.cooldown).Sub(now)
+		if wait > 0 {
+			return wait
+		}
+	}
+
+	h.last = now
+
+	return 0
+}
@@ -0,0 +1,95 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+var errStepMonitoringDenied = errors.New("monitoring denied")
+
+type stubStepper struct {
+	calls int
+}
+
+func (s *stubStepper) RequestStep(context.Context) (adapt.Decision, error) {
+	s.calls++
+
+	return adapt.Decision{
+		StepID:       "5f3a0c9e1b2d4a68",
+		Timestamp:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		State:        adapt.StateFallback,
+		Target:       0.25,
+		NextInterval: time.Hour,
+		Err:          errStepMonitoringDenied,
+	}, nil
+}
+
+func serveStep(t *testing.T, handler http.Handler, method string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/admin/step", nil))
+
+	return recorder
+}
+
+func TestStepHandlerRunsStepAndRateLimits(t *testing.T) {
+	t.Parallel()
+
+	stepper := new(stubStepper)
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"step", admin.NewStepHandler(stepper))
+
+	recorder := serveStep(t, handler, http.MethodPost)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response admin.StepResponse
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if response.StepID != "5f3a0c9e1b2d4a68" || response.State != "fallback" ||
+		response.NextInterval != "1h0m0s" ||
+		response.Error != errStepMonitoringDenied.Error() {
+		t.Fatalf("unexpected step response %+v", response)
+	}
+
+	recorder = serveStep(t, handler, http.MethodPost)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 within the cooldown, got %d", recorder.Code)
+	}
+
+	if recorder.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected Retry-After of 60s, got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	if stepper.calls != 1 {
+		t.Fatalf("expected a single forced step, got %d", stepper.calls)
+	}
+}
+
+func TestStepHandlerRejectsOtherMethodsAndMissingController(t *testing.T) {
+	t.Parallel()
+
+	recorder := serveStep(t, admin.NewStepHandler(new(stubStepper)), http.MethodGet)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", recorder.Code)
+	}
+
+	recorder = serveStep(t, admin.NewStepHandler(nil), http.MethodPost)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a controller, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// SuppressionSource identifies suppression requests raised through the admin API.
+const SuppressionSource = "admin"
+
+// SuppressionController accepts and reports external suppression requests.
+type SuppressionController interface {
+	RequestSuppression(source string, until time.Time)
+	SuppressionRequests() map[string]time.Time
+}
+
+// SuppressionResponse is the JSON document returned by the suppression endpoint.
+type SuppressionResponse struct {
+	Requests map[string]time.Time `json:"requests"`
+}
+
+// SuppressHandler lets external agents hold the controller suppressed. POST
+// with duration=<duration> requests suppression for that long, DELETE cancels
+// the admin request, and GET lists every outstanding request by source.
+type SuppressHandler struct {
+	controller SuppressionController
+	now        func() time.Time
+}
+
+// NewSuppressHandler constructs a SuppressHandler backed by controller.
+func NewSuppressHandler(controller SuppressionController) *SuppressHandler {
+	return &SuppressHandler{controller: controller, now: time.Now}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SuppressHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if h == nil || h.controller == nil {
+		http.Error(writer, "suppression unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		duration, err := time.ParseDuration(request.URL.Query().Get("duration"))
+		if err != nil || duration <= 0 {
+			http.Error(writer, "duration must be a positive duration", http.StatusBadRequest)
+
+			return
+		}
+
+		h.controller.RequestSuppression(SuppressionSource, h.now().Add(duration))
+	case http.MethodDelete:
+		h.controller.RequestSuppression(SuppressionSource, time.Time{})
+	default:
+		writer.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	payload, err := json.Marshal(SuppressionResponse{
+		Requests: h.controller.SuppressionRequests(),
+	})
+	if err != nil {
+		http.Error(writer, "encode suppression", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(payload)
+}
@@ -0,0 +1,126 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admin "oci-cpu-shaper/pkg/http/admin"
+)
+
+type stubSuppression struct {
+	requests map[string]time.Time
+}
+
+func (s *stubSuppression) RequestSuppression(source string, until time.Time) {
+	if until.IsZero() {
+		delete(s.requests, source)
+
+		return
+	}
+
+	s.requests[source] = until
+}
+
+func (s *stubSuppression) SuppressionRequests() map[string]time.Time {
+	return s.requests
+}
+
+func serveSuppress(
+	t *testing.T,
+	controller admin.SuppressionController,
+	method string,
+	target string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := admin.NewHandler()
+	handler.Handle(admin.Prefix+"suppress", admin.NewSuppressHandler(controller))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+
+	return recorder
+}
+
+func TestSuppressHandlerRequestsAndCancels(t *testing.T) {
+	t.Parallel()
+
+	controller := &stubSuppression{requests: map[string]time.Time{}}
+
+	recorder := serveSuppress(t, controller, http.MethodPost, "/admin/suppress?duration=30m")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response admin.SuppressionResponse
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	until, ok := response.Requests[admin.SuppressionSource]
+	if !ok || time.Until(until) <= 29*time.Minute {
+		t.Fatalf("expected a 30m admin request, got %v", response.Requests)
+	}
+
+	recorder = serveSuppress(t, controller, http.MethodDelete, "/admin/suppress")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", recorder.Code)
+	}
+
+	if len(controller.requests) != 0 {
+		t.Fatalf("expected DELETE to clear the admin request, got %v", controller.requests)
+	}
+}
+
+func TestSuppressHandlerRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	controller := &stubSuppression{requests: map[string]time.Time{}}
+
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{
+			name:   "missing duration",
+			method: http.MethodPost,
+			target: "/admin/suppress",
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "negative duration",
+			method: http.MethodPost,
+			target: "/admin/suppress?duration=-1m",
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported method",
+			method: http.MethodPut,
+			target: "/admin/suppress",
+			want:   http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := serveSuppress(t, controller, testCase.method, testCase.target)
+			if recorder.Code != testCase.want {
+				t.Fatalf("expected %d, got %d", testCase.want, recorder.Code)
+			}
+		})
+	}
+
+	recorder := serveSuppress(t, nil, http.MethodGet, "/admin/suppress")
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a controller, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,91 @@
+// Package index serves the landing page of the metrics listener, which names
+// the daemon, its build, and the endpoints the listener serves, so an operator
+// who finds the port open on an instance can tell what answers on it.
+package index
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+)
+
+// Build identifies the running binary on the page.
+type Build struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// Link is an endpoint listed on the page.
+type Link struct {
+	Path        string
+	Description string
+}
+
+//nolint:gochecknoglobals // parsed once at init
+var page = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>oci-cpu-shaper</title>
+</head>
+<body>
+<h1>oci-cpu-shaper</h1>
+<p>Keeps this instance above the OCI idle reclamation threshold with
+low-priority CPU load.</p>
+<p>Version {{.Build.Version}} (commit {{.Build.Commit}}, built {{.Build.Date}})</p>
+<ul>
+{{- range .Links}}
+<li><a href="{{.Path}}">{{.Path}}</a>: {{.Description}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// Handler renders the landing page at / and answers 404 for every other path,
+// so it can sit behind the catch-all pattern of a mux.
+type Handler struct {
+	body []byte
+}
+
+// NewHandler renders the page for build and links once; the page is static for
+// the life of the process.
+func NewHandler(build Build, links []Link) *Handler {
+	var body bytes.Buffer
+
+	// Executing a parsed template into a buffer only fails on template bugs,
+	// which the tests cover.
+	_ = page.Execute(&body, struct {
+		Build Build
+		Links []Link
+	}{Build: build, Links: links})
+
+	return &Handler{body: body.Bytes()}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(writer, request)
+
+		return
+	}
+
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Content-Length", strconv.Itoa(len(h.body)))
+
+	if request.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = writer.Write(h.body)
+}
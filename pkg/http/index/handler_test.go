@@ -0,0 +1,72 @@
+package index_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"oci-cpu-shaper/pkg/http/index"
+)
+
+func newHandler() *index.Handler {
+	return index.NewHandler(
+		index.Build{Version: "1.2.3", Commit: "abc123", Date: "2026-01-02"},
+		[]index.Link{
+			{Path: "/metrics", Description: "Prometheus metrics"},
+			{Path: "/healthz", Description: "<health> & components"},
+		},
+	)
+}
+
+func TestHandlerRendersLandingPage(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Fatalf("expected an HTML page, got %q", got)
+	}
+
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"Version 1.2.3 (commit abc123, built 2026-01-02)",
+		`<a href="/metrics">/metrics</a>: Prometheus metrics`,
+		`<a href="/healthz">/healthz</a>: &lt;health&gt; &amp; components`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in the page, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestHandlerServesOnlyTheRoot(t *testing.T) {
+	t.Parallel()
+
+	handler := newHandler()
+
+	for _, test := range []struct {
+		method, path string
+		want         int
+	}{
+		{method: http.MethodGet, path: "/unknown", want: http.StatusNotFound},
+		{method: http.MethodPost, path: "/", want: http.StatusMethodNotAllowed},
+		{method: http.MethodHead, path: "/", want: http.StatusOK},
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+
+		if recorder.Code != test.want {
+			t.Fatalf("%s %s: expected %d, got %d", test.method, test.path, test.want, recorder.Code)
+		}
+
+		if test.method == http.MethodHead && recorder.Body.Len() != 0 {
+			t.Fatalf("expected no body for HEAD, got %q", recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// ErrInvalidSample signals an access log sample outside [0, 1].
+var ErrInvalidSample = errors.New("listener: access log sample must be within [0, 1]")
+
+// ValidateSample reports whether sample is a share of requests AccessLog can
+// log.
+func ValidateSample(sample float64) error {
+	if math.IsNaN(sample) || sample < 0 || sample > 1 {
+		return fmt.Errorf("%w, got %v", ErrInvalidSample, sample)
+	}
+
+	return nil
+}
+
+// AccessLog logs the requests a listener serves at info level: method, path,
+// status, response size, remote address, user agent, and latency, so scrapers
+// and scanners hitting an exposed port can be traced. Sample is the share of
+// requests logged, picked at random so a busy scraper does not flood the log;
+// zero disables logging. Listener names the address in every entry.
+type AccessLog struct {
+	Logger   logging.Logger
+	Sample   float64
+	Listener string
+
+	now    func() time.Time
+	sample func() float64
+}
+
+// Wrap returns next with its requests logged. With a zero Sample or no Logger
+// next is returned as is.
+//
+//nolint:ireturn // callers mount the result on a server
+func (a AccessLog) Wrap(next http.Handler) http.Handler {
+	if a.Sample <= 0 || a.Logger == nil {
+		return next
+	}
+
+	now := a.now
+	if now == nil {
+		now = time.Now
+	}
+
+	sample := a.sample
+	if sample == nil {
+		sample = rand.Float64 //nolint:gosec // sampling needs no cryptographic randomness
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if a.Sample < 1 && sample() >= a.Sample {
+			next.ServeHTTP(writer, request)
+
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK, bytes: 0}
+		start := now()
+
+		next.ServeHTTP(recorder, request)
+
+		a.Logger.Info("http request",
+			"listener", a.Listener,
+			"method", request.Method,
+			"path", request.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"remote", request.RemoteAddr,
+			"userAgent", request.UserAgent(),
+			"latency", now().Sub(start),
+		)
+	})
+}
+
+// statusRecorder captures the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	written, err := r.ResponseWriter.Write(data)
+	r.bytes += written
+
+	return written, err //nolint:wrapcheck // the handler expects the writer's own error
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
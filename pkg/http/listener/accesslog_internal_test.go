@@ -0,0 +1,149 @@
+package listener
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+func TestAccessLogRecordsRequests(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+	clock := time.Unix(1_700_000_000, 0)
+
+	handler := AccessLog{
+		Logger:   logger,
+		Sample:   1,
+		Listener: "0.0.0.0:9108",
+		now: func() time.Time {
+			clock = clock.Add(15 * time.Millisecond)
+
+			return clock
+		},
+		sample: nil,
+	}.Wrap(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusTeapot)
+		_, _ = writer.Write([]byte("short and stout"))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics?debug=1", nil)
+	request.RemoteAddr = "203.0.113.7:51234"
+	request.Header.Set("User-Agent", "scanner/1.0")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusTeapot || recorder.Body.String() != "short and stout" {
+		t.Fatalf("expected the response to pass through, got %d %q",
+			recorder.Code, recorder.Body.String())
+	}
+
+	entries := logger.Entries()
+	if len(entries) != 1 || entries[0].Message != "http request" {
+		t.Fatalf("expected one access log entry, got %v", entries)
+	}
+
+	want := map[string]any{
+		"listener":  "0.0.0.0:9108",
+		"method":    http.MethodGet,
+		"path":      "/metrics",
+		"status":    http.StatusTeapot,
+		"bytes":     len("short and stout"),
+		"remote":    "203.0.113.7:51234",
+		"userAgent": "scanner/1.0",
+		"latency":   15 * time.Millisecond,
+	}
+
+	for key, value := range want {
+		if entries[0].Fields[key] != value {
+			t.Fatalf("expected %s=%v, got %v", key, value, entries[0].Fields[key])
+		}
+	}
+}
+
+func TestAccessLogSamplesRequests(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+	draws := []float64{0.05, 0.5, 0.09, 0.99}
+
+	handler := AccessLog{
+		Logger:   logger,
+		Sample:   0.1,
+		Listener: "",
+		now:      nil,
+		sample: func() float64 {
+			draw := draws[0]
+			draws = draws[1:]
+
+			return draw
+		},
+	}.Wrap(http.NotFoundHandler())
+
+	for range 4 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	entries := logger.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected the draws below 0.1 to be logged, got %d entries", len(entries))
+	}
+
+	if entries[0].Fields["status"] != http.StatusNotFound {
+		t.Fatalf("expected the 404 to be recorded, got %v", entries[0].Fields["status"])
+	}
+}
+
+type countingHandler struct {
+	served int
+}
+
+func (h *countingHandler) ServeHTTP(http.ResponseWriter, *http.Request) {
+	h.served++
+}
+
+func TestAccessLogDisabledReturnsHandler(t *testing.T) {
+	t.Parallel()
+
+	next := new(countingHandler)
+
+	var access AccessLog
+
+	if access.Wrap(next) != next {
+		t.Fatal("expected a zero sample to leave the handler unwrapped")
+	}
+
+	access.Sample = 0.5
+	if access.Wrap(next) != next {
+		t.Fatal("expected a missing logger to leave the handler unwrapped")
+	}
+
+	recorder := &statusRecorder{ResponseWriter: httptest.NewRecorder(), status: 0, bytes: 0}
+	if http.NewResponseController(recorder).Flush() != nil {
+		t.Fatal("expected the recorder to expose the underlying flusher")
+	}
+}
+
+func TestValidateSample(t *testing.T) {
+	t.Parallel()
+
+	for _, sample := range []float64{0, 0.25, 1} {
+		err := ValidateSample(sample)
+		if err != nil {
+			t.Fatalf("ValidateSample(%v): %v", sample, err)
+		}
+	}
+
+	for _, sample := range []float64{-0.1, 1.5, math.NaN()} {
+		err := ValidateSample(sample)
+		if !errors.Is(err, ErrInvalidSample) {
+			t.Fatalf("expected ErrInvalidSample for %v, got %v", sample, err)
+		}
+	}
+}
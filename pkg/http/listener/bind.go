@@ -0,0 +1,165 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// Fallbacks applied when a listener cannot bind its address.
+const (
+	// FallbackNone returns the bind error, failing startup.
+	FallbackNone = "none"
+	// FallbackRetry keeps retrying the address in the background with
+	// exponential backoff while the caller carries on without the listener.
+	FallbackRetry = "retry"
+	// FallbackEphemeral binds a kernel-chosen port on the same host instead.
+	FallbackEphemeral = "ephemeral"
+)
+
+// Backoff bounds for FallbackRetry unless a Binder overrides them.
+const (
+	DefaultRetryBackoff    = time.Second
+	DefaultMaxRetryBackoff = time.Minute
+)
+
+// ErrUnknownFallback signals a bind fallback other than the Fallback*
+// constants.
+var ErrUnknownFallback = errors.New("listener: unknown bind fallback")
+
+// ValidateFallback reports whether fallback names a supported bind fallback.
+func ValidateFallback(fallback string) error {
+	switch fallback {
+	case FallbackNone, FallbackRetry, FallbackEphemeral:
+		return nil
+	default:
+		return fmt.Errorf(
+			"%w %q (supported: %s, %s, %s)",
+			ErrUnknownFallback,
+			fallback,
+			FallbackNone,
+			FallbackRetry,
+			FallbackEphemeral,
+		)
+	}
+}
+
+// Binder opens a listener on Network and Addr and applies Fallback when the
+// address is taken or not permitted.
+type Binder struct {
+	Network  string
+	Addr     string
+	Fallback string
+	Logger   logging.Logger
+	// Backoff is the delay before the first retry, doubling up to MaxBackoff;
+	// zero values use DefaultRetryBackoff and DefaultMaxRetryBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Bind listens on the address and hands the listener to serve. When binding
+// fails, FallbackEphemeral serves on a kernel-chosen port of the same host and
+// logs it, and FallbackRetry returns nil at once and calls serve from a
+// goroutine when a retry succeeds, giving up when ctx ends. Bind returns the
+// bind error with FallbackNone or when the ephemeral port cannot be bound
+// either.
+func (b Binder) Bind(ctx context.Context, serve func(net.Listener)) error {
+	logger := logging.OrNop(b.Logger)
+
+	listener, err := b.listen(ctx, b.Addr)
+	if err == nil {
+		serve(listener)
+
+		return nil
+	}
+
+	switch b.Fallback {
+	case FallbackEphemeral:
+		return b.bindEphemeral(ctx, logger, err, serve)
+	case FallbackRetry:
+		logger.Warn("listener bind failed; retrying in the background",
+			"addr", b.Addr, "error", err)
+
+		go b.retry(ctx, logger, serve)
+
+		return nil
+	default:
+		return err
+	}
+}
+
+func (b Binder) bindEphemeral(
+	ctx context.Context,
+	logger logging.Logger,
+	cause error,
+	serve func(net.Listener),
+) error {
+	host, _, err := net.SplitHostPort(b.Addr)
+	if err != nil {
+		return fmt.Errorf("%w; ephemeral fallback: %w", cause, err)
+	}
+
+	listener, err := b.listen(ctx, net.JoinHostPort(host, "0"))
+	if err != nil {
+		return fmt.Errorf("%w; ephemeral fallback: %w", cause, err)
+	}
+
+	logger.Warn("listener bound to an ephemeral port",
+		"addr", b.Addr, "boundAddr", listener.Addr().String(), "error", cause)
+	serve(listener)
+
+	return nil
+}
+
+func (b Binder) retry(ctx context.Context, logger logging.Logger, serve func(net.Listener)) {
+	delay := b.Backoff
+	if delay <= 0 {
+		delay = DefaultRetryBackoff
+	}
+
+	maxDelay := b.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxRetryBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		listener, err := b.listen(ctx, b.Addr)
+		if err == nil {
+			logger.Info("listener bound after retrying",
+				"addr", b.Addr, "attempts", attempt)
+			serve(listener)
+
+			return
+		}
+
+		logger.Debug("listener bind retry failed",
+			"addr", b.Addr, "attempt", attempt, "error", err)
+
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+func (b Binder) listen(ctx context.Context, addr string) (net.Listener, error) {
+	var config net.ListenConfig
+
+	listener, err := config.Listen(ctx, b.Network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s %q: %w", b.Network, addr, err)
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,201 @@
+package listener_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/http/listener"
+)
+
+// occupy binds a loopback port and returns its address and a release func.
+func occupy(t *testing.T) (string, func()) {
+	t.Helper()
+
+	var config net.ListenConfig
+
+	taken, err := config.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	var once sync.Once
+
+	release := func() { once.Do(func() { _ = taken.Close() }) }
+	t.Cleanup(release)
+
+	return taken.Addr().String(), release
+}
+
+// collect returns a serve func that forwards listeners to the channel and
+// closes them when the test ends.
+func collect(t *testing.T) (func(net.Listener), <-chan net.Listener) {
+	t.Helper()
+
+	served := make(chan net.Listener, 1)
+
+	return func(bound net.Listener) {
+		t.Cleanup(func() { _ = bound.Close() })
+		served <- bound
+	}, served
+}
+
+func TestValidateFallback(t *testing.T) {
+	t.Parallel()
+
+	for _, fallback := range []string{
+		listener.FallbackNone,
+		listener.FallbackRetry,
+		listener.FallbackEphemeral,
+	} {
+		err := listener.ValidateFallback(fallback)
+		if err != nil {
+			t.Fatalf("ValidateFallback(%q): %v", fallback, err)
+		}
+	}
+
+	err := listener.ValidateFallback("random")
+	if !errors.Is(err, listener.ErrUnknownFallback) {
+		t.Fatalf("expected ErrUnknownFallback, got %v", err)
+	}
+}
+
+func TestBindServesFreeAddressAndFailsWithoutFallback(t *testing.T) {
+	t.Parallel()
+
+	serve, served := collect(t)
+
+	err := listener.Binder{Network: "tcp", Addr: "127.0.0.1:0"}.Bind(t.Context(), serve)
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	<-served
+
+	addr, _ := occupy(t)
+
+	err = listener.Binder{Network: "tcp", Addr: addr, Fallback: listener.FallbackNone}.Bind(
+		t.Context(),
+		func(net.Listener) { t.Fatal("expected no listener") },
+	)
+	if err == nil {
+		t.Fatal("expected the taken address to fail")
+	}
+}
+
+func TestBindFallsBackToEphemeralPort(t *testing.T) {
+	t.Parallel()
+
+	addr, _ := occupy(t)
+	logger := new(logtest.Recorder)
+	serve, served := collect(t)
+
+	binder := listener.Binder{
+		Network:  "tcp",
+		Addr:     addr,
+		Fallback: listener.FallbackEphemeral,
+		Logger:   logger,
+	}
+
+	err := binder.Bind(t.Context(), serve)
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	bound := (<-served).Addr().(*net.TCPAddr)
+	if !bound.IP.IsLoopback() || bound.String() == addr {
+		t.Fatalf("expected another loopback port than %s, got %s", addr, bound)
+	}
+
+	if logger.Count("warn: listener bound to an ephemeral port") == 0 {
+		t.Fatalf("expected the ephemeral port to be logged, got %v", logger.Lines())
+	}
+
+	binder.Addr = "127.0.0.1"
+
+	err = binder.Bind(t.Context(), serve)
+	if err == nil {
+		t.Fatal("expected an address without a port to fail")
+	}
+}
+
+func TestBindRetriesUntilAddressFrees(t *testing.T) {
+	t.Parallel()
+
+	addr, release := occupy(t)
+	logger := new(logtest.Recorder)
+	serve, served := collect(t)
+
+	binder := listener.Binder{
+		Network:    "tcp",
+		Addr:       addr,
+		Fallback:   listener.FallbackRetry,
+		Logger:     logger,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	}
+
+	err := binder.Bind(t.Context(), serve)
+	if err != nil {
+		t.Fatalf("expected Bind to defer to retries, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case <-served:
+		t.Fatal("expected no listener while the address is taken")
+	default:
+	}
+
+	release()
+
+	select {
+	case bound := <-served:
+		if bound.Addr().String() != addr {
+			t.Fatalf("expected %s after retrying, got %s", addr, bound.Addr())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the retry to bind the freed address")
+	}
+
+	if logger.Count("warn: listener bind failed; retrying in the background") == 0 ||
+		logger.Count("info: listener bound after retrying") == 0 {
+		t.Fatalf("unexpected log entries %v", logger.Lines())
+	}
+}
+
+func TestBindRetryStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	addr, release := occupy(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	serve, served := collect(t)
+
+	binder := listener.Binder{
+		Network:  "tcp",
+		Addr:     addr,
+		Fallback: listener.FallbackRetry,
+		Backoff:  time.Millisecond,
+	}
+
+	err := binder.Bind(ctx, serve)
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	release()
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case <-served:
+		t.Fatal("expected retries to stop once the context ended")
+	default:
+	}
+}
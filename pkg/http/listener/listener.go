@@ -0,0 +1,161 @@
+// Package listener secures and opens the daemon's HTTP listeners. Each listener
+// can serve TLS, optionally requiring client certificates, and gate routes
+// behind a bearer token or basic auth credentials, so one daemon can expose a
+// plaintext loopback endpoint next to an authenticated one on the VCN. A Binder
+// opens the socket and can fall back to retrying or an ephemeral port when the
+// address is taken.
+package listener
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Realm is announced in the WWW-Authenticate header of rejected requests.
+const Realm = "oci-cpu-shaper"
+
+var (
+	// ErrEmptySecret signals a credential file without content.
+	ErrEmptySecret = errors.New("listener: secret file is empty")
+	// ErrNoClientCA signals a client CA file without PEM certificates.
+	ErrNoClientCA = errors.New("listener: no certificates in client CA file")
+)
+
+// Auth holds the credentials a listener requires. A BearerToken is checked
+// against "Authorization: Bearer"; a Username and Password against basic
+// auth. The zero value requires nothing.
+type Auth struct {
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+// Enabled reports whether the listener requires credentials.
+func (a Auth) Enabled() bool {
+	return a.BearerToken != "" || a.Username != ""
+}
+
+// Wrap returns next gated behind the credentials. Requests without them are
+// answered with 401 Unauthorized. Without credentials next is returned as is.
+//
+//nolint:ireturn // callers mount the result on a mux
+func (a Auth) Wrap(next http.Handler) http.Handler {
+	if !a.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if a.authorized(request) {
+			next.ServeHTTP(writer, request)
+
+			return
+		}
+
+		scheme := "Bearer"
+		if a.BearerToken == "" {
+			scheme = "Basic"
+		}
+
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf("%s realm=%q", scheme, Realm))
+		http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func (a Auth) authorized(request *http.Request) bool {
+	if a.BearerToken != "" {
+		token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+
+		return ok && equal(token, a.BearerToken)
+	}
+
+	username, password, ok := request.BasicAuth()
+
+	// Both comparisons run so the response time does not reveal which failed.
+	userOK := equal(username, a.Username)
+	passwordOK := equal(password, a.Password)
+
+	return ok && userOK && passwordOK
+}
+
+// equal compares digests in constant time, so neither the content nor the
+// length of the secret leaks through timing.
+func equal(got, want string) bool {
+	gotSum := sha256.Sum256([]byte(got))
+	wantSum := sha256.Sum256([]byte(want))
+
+	return subtle.ConstantTimeCompare(gotSum[:], wantSum[:]) == 1
+}
+
+// ReadSecret reads a credential from path, ignoring surrounding whitespace
+// such as a trailing newline.
+func ReadSecret(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from operator configuration
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %w", path, err)
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s", ErrEmptySecret, path)
+	}
+
+	return secret, nil
+}
+
+// TLS names the PEM files a listener serves TLS with. A ClientCAFile makes the
+// listener require client certificates signed by one of its CAs.
+type TLS struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// Enabled reports whether the listener serves TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Config loads the certificate, key, and client CAs into a tls.Config
+// accepting TLS 1.2 and later. It returns nil when TLS is not enabled.
+// Certificates are read once, so rotating them needs a restart.
+func (t TLS) Config() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil //nolint:nilnil // a nil config means plaintext
+	}
+
+	certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls key pair: %w", err)
+	}
+
+	config := &tls.Config{ //nolint:exhaustruct // defaults suit the remaining fields
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if t.ClientCAFile == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(t.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client ca %s: %w", t.ClientCAFile, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", ErrNoClientCA, t.ClientCAFile)
+	}
+
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return config, nil
+}
@@ -0,0 +1,277 @@
+package listener_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/http/listener"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, configure func(*http.Request)) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if configure != nil {
+		configure(request)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestAuthWrapChecksBearerToken(t *testing.T) {
+	t.Parallel()
+
+	handler := listener.Auth{BearerToken: "s3cret"}.Wrap(okHandler())
+
+	testCases := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid", header: "Bearer s3cret", status: http.StatusOK},
+		{name: "wrong token", header: "Bearer other", status: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic s3cret", status: http.StatusUnauthorized},
+		{name: "missing", header: "", status: http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		recorder := serve(handler, func(request *http.Request) {
+			if testCase.header != "" {
+				request.Header.Set("Authorization", testCase.header)
+			}
+		})
+
+		if recorder.Code != testCase.status {
+			t.Fatalf("%s: status %d, want %d", testCase.name, recorder.Code, testCase.status)
+		}
+	}
+
+	recorder := serve(handler, nil)
+	if got := recorder.Header().Get("WWW-Authenticate"); got != `Bearer realm="oci-cpu-shaper"` {
+		t.Fatalf("unexpected WWW-Authenticate %q", got)
+	}
+}
+
+func TestAuthWrapChecksBasicCredentials(t *testing.T) {
+	t.Parallel()
+
+	handler := listener.Auth{Username: "prometheus", Password: "hunter2"}.Wrap(okHandler())
+
+	recorder := serve(handler, func(request *http.Request) {
+		request.SetBasicAuth("prometheus", "hunter2")
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected valid credentials to pass, got %d", recorder.Code)
+	}
+
+	recorder = serve(handler, func(request *http.Request) {
+		request.SetBasicAuth("prometheus", "wrong")
+	})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong password to be rejected, got %d", recorder.Code)
+	}
+
+	if got := recorder.Header().Get("WWW-Authenticate"); got != `Basic realm="oci-cpu-shaper"` {
+		t.Fatalf("unexpected WWW-Authenticate %q", got)
+	}
+}
+
+func TestAuthWrapWithoutCredentialsPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	var auth listener.Auth
+	if auth.Enabled() {
+		t.Fatal("expected the zero Auth to be disabled")
+	}
+
+	if recorder := serve(auth.Wrap(okHandler()), nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected requests to pass without credentials, got %d", recorder.Code)
+	}
+}
+
+func TestReadSecretTrimsWhitespace(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	writeFile(t, path, []byte("  s3cret\n"))
+
+	secret, err := listener.ReadSecret(path)
+	if err != nil || secret != "s3cret" {
+		t.Fatalf("ReadSecret = %q, %v", secret, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	writeFile(t, empty, []byte("\n"))
+
+	_, err = listener.ReadSecret(empty)
+	if !errors.Is(err, listener.ErrEmptySecret) {
+		t.Fatalf("expected ErrEmptySecret, got %v", err)
+	}
+
+	_, err = listener.ReadSecret(filepath.Join(dir, "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+}
+
+func TestTLSConfigServesAndRequiresClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPEM, keyPEM, certificate := selfSigned(t)
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	config, err := listener.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: ""}.Config()
+	if err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+
+	if config.ClientAuth != tls.NoClientCert || config.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	server := httptest.NewUnstartedServer(okHandler())
+	server.TLS = config
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+	}}
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("TLS request: %v", err)
+	}
+
+	_ = response.Body.Close()
+
+	mutual, err := listener.TLS{
+		CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile,
+	}.Config()
+	if err != nil {
+		t.Fatalf("Config with client CA: %v", err)
+	}
+
+	if mutual.ClientAuth != tls.RequireAndVerifyClientCert || mutual.ClientCAs == nil {
+		t.Fatalf("expected client certificates to be required, got %v", mutual.ClientAuth)
+	}
+}
+
+func TestTLSConfigRejectsInvalidFiles(t *testing.T) {
+	t.Parallel()
+
+	config, err := listener.TLS{}.Config()
+	if config != nil || err != nil {
+		t.Fatalf("expected plaintext without a certificate, got %v, %v", config, err)
+	}
+
+	dir := t.TempDir()
+	certPEM, keyPEM, _ := selfSigned(t)
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	notPEM := filepath.Join(dir, "ca.txt")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, notPEM, []byte("not a certificate"))
+
+	_, err = listener.TLS{CertFile: certFile, KeyFile: notPEM}.Config()
+	if err == nil {
+		t.Fatal("expected an invalid key to be rejected")
+	}
+
+	_, err = listener.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: notPEM}.Config()
+	if !errors.Is(err, listener.ErrNoClientCA) {
+		t.Fatalf("expected ErrNoClientCA, got %v", err)
+	}
+
+	missing := filepath.Join(dir, "missing.pem")
+
+	_, err = listener.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: missing}.Config()
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing client CA error, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	err := os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func selfSigned(t *testing.T) ([]byte, []byte, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "shaper"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, certificate
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// suppressionEpisodeBuckets are the upper bounds, in seconds, of the
+// shaper_suppression_episode_duration_seconds histogram: one minute to four
+// hours, spanning a brief spike to a sustained neighbour workload.
+//
+//nolint:gochecknoglobals // fixed histogram layout
+var suppressionEpisodeBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400}
+
+// suppressionEpisodeStats accumulates finished suppression episodes.
+type suppressionEpisodeStats struct {
+	count       uint64
+	durationSum float64
+	deficitSum  float64
+	buckets     []uint64
+}
+
+func (s suppressionEpisodeStats) clone() suppressionEpisodeStats {
+	s.buckets = slices.Clone(s.buckets)
+
+	return s
+}
+
+// ObserveSuppressionEpisode counts a finished suppression episode that lasted
+// duration and gave up deficit of duty-cycle time, so dashboards can chart how
+// much shaping host contention cost.
+func (e *Exporter) ObserveSuppressionEpisode(duration, deficit time.Duration) {
+	seconds := max(duration.Seconds(), 0)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.episodes.buckets == nil {
+		e.episodes.buckets = make([]uint64, len(suppressionEpisodeBuckets))
+	}
+
+	e.episodes.count++
+	e.episodes.durationSum += seconds
+	e.episodes.deficitSum += max(deficit.Seconds(), 0)
+
+	for index, bound := range suppressionEpisodeBuckets {
+		if seconds <= bound {
+			e.episodes.buckets[index]++
+		}
+	}
+}
+
+func suppressionEpisodeLines(stats suppressionEpisodeStats) []string {
+	lines := []string{
+		"# HELP shaper_suppression_episodes Suppression episodes that ended " +
+			"since startup.\n",
+		"# TYPE shaper_suppression_episodes counter\n",
+		fmt.Sprintf("shaper_suppression_episodes_total %d\n", stats.count),
+		"# HELP shaper_suppression_deficit_seconds Duty-cycle time the workers " +
+			"gave up to finished suppression episodes.\n",
+		"# TYPE shaper_suppression_deficit_seconds counter\n",
+		fmt.Sprintf("shaper_suppression_deficit_seconds_total %.3f\n", stats.deficitSum),
+		"# HELP shaper_suppression_episode_duration_seconds Duration of finished " +
+			"suppression episodes.\n",
+		"# TYPE shaper_suppression_episode_duration_seconds histogram\n",
+	}
+
+	for index, bound := range suppressionEpisodeBuckets {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_suppression_episode_duration_seconds_bucket{le=\"%s\"} %d\n",
+			strconv.FormatFloat(bound, 'f', -1, 64),
+			stats.buckets[index],
+		))
+	}
+
+	return append(
+		lines,
+		fmt.Sprintf(
+			"shaper_suppression_episode_duration_seconds_bucket{le=\"+Inf\"} %d\n",
+			stats.count,
+		),
+		fmt.Sprintf("shaper_suppression_episode_duration_seconds_sum %.3f\n", stats.durationSum),
+		fmt.Sprintf("shaper_suppression_episode_duration_seconds_count %d\n", stats.count),
+	)
+}
@@ -2,11 +2,16 @@ package metrics
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"math"
 	"net/http"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,13 +21,74 @@ const (
 	contentType           = "application/openmetrics-text; version=1.0.0; charset=utf-8"
 	millisecondsPerSecond = 1000.0
 	hundredPercent        = 100.0
+	nanosecondsPerSecond  = 1e9
+)
+
+// Metric families whose freshness is tracked by SetStaleAfter and reported by
+// shaper_metric_age_seconds.
+const (
+	MetricOCIP95  = "oci_p95"
+	MetricHostCPU = "host_cpu_percent"
 )
 
 var (
 	errNilWriter = errors.New("metrics: writer is nil")
 	errNilBuffer = errors.New("metrics: buffer factory returned nil")
+
+	labelValueEscaper = strings.NewReplacer( //nolint:gochecknoglobals
+		"\\", "\\\\",
+		"\"", "\\\"",
+		"\n", "\\n",
+	)
 )
 
+// ControllerBand describes the active controller thresholds exported as gauges so
+// dashboards can draw the goal band next to oci_p95.
+type ControllerBand struct {
+	GoalLow           float64
+	GoalHigh          float64
+	TargetMin         float64
+	TargetMax         float64
+	SuppressThreshold float64
+}
+
+// APIUsage reports today's OCI API calls and the configured daily budget. A zero
+// Limit omits the remaining-budget series for that API.
+type APIUsage struct {
+	API   string
+	Calls int
+	Limit int
+}
+
+// UpdateStatus reports whether a newer release than the running build exists.
+type UpdateStatus struct {
+	Available     bool
+	LatestVersion string
+}
+
+// ConfigStatus identifies the loaded configuration and whether it is still
+// observed in dry-run before being promoted to enforce.
+type ConfigStatus struct {
+	Hash   string
+	Canary bool
+}
+
+// ComponentError is the latest error a component reported through
+// ObserveError.
+type ComponentError struct {
+	Code string
+	At   time.Time
+}
+
+// BurstCredits reports the estimated CPU credit balance of a burstable shape.
+// ThrottleIn is the projected time until OCI clamps the instance to its
+// baseline and is ignored unless Draining.
+type BurstCredits struct {
+	Credits    float64
+	Draining   bool
+	ThrottleIn time.Duration
+}
+
 type byteBuffer interface {
 	io.Writer
 	Bytes() []byte
@@ -32,16 +98,65 @@ type byteBuffer interface {
 type Exporter struct {
 	mu sync.RWMutex
 
-	shaperTarget    float64
-	shaperMode      string
-	shaperState     string
-	ociP95          float64
-	ociLastSuccess  time.Time
-	dutyCycleMillis float64
-	workerCount     float64
-	hostCPUPercent  float64
+	shaperTarget      float64
+	shaperMode        string
+	shaperState       string
+	ociP95            float64
+	ociLastSuccess    time.Time
+	dutyCycleMillis   float64
+	workerCount       float64
+	hostCPUPercent    float64
+	hostCPUUpdated    time.Time
+	selfCPUPercent    float64
+	selfCPUSet        bool
+	staleAfter        map[string]time.Duration
+	runtimeMetrics    bool
+	instanceID        string
+	displayName       string
+	band              ControllerBand
+	bandSet           bool
+	apiUsage          func() []APIUsage
+	poolOutcome       string
+	workerPolicies    map[string]int
+	ociIdle           bool
+	ociIdleSet        bool
+	alarmSilenced     bool
+	alarmSilencedSet  bool
+	blackout          bool
+	blackoutSet       bool
+	calibrationError  float64
+	calibrationSet    bool
+	sleepOvershoot    func() time.Duration
+	effectiveQuantum  func() time.Duration
+	heartbeatAge      func() time.Duration
+	workerRestarts    func() uint64
+	burst             BurstCredits
+	burstSet          bool
+	update            UpdateStatus
+	updateSet         bool
+	metadataChanges   map[string]int
+	config            ConfigStatus
+	estimatorRestarts map[string]int
+	estimatorDropped  int
+	episodes          suppressionEpisodeStats
+	lastErrors        map[string]ComponentError
+	tokenExpiry       time.Time
+	queryStart        time.Time
+	queryEnd          time.Time
+	memoryRSS         int64
+	memoryTrims       uint64
+	memorySet         bool
+	labelsCapped      int
+	namespace         string
+	staticLabels      []staticLabel
+	scrapeSample      func(ctx context.Context) (float64, error)
+	scrapeInterval    time.Duration
+	scrapeSampled     time.Time
 
 	bufferFactory func() byteBuffer
+	readMemStats  func(*runtime.MemStats)
+	numGoroutine  func() int
+	now           func() time.Time
 }
 
 // NewExporter constructs an Exporter with zeroed metrics.
@@ -50,10 +165,262 @@ func NewExporter() *Exporter {
 	exporter.bufferFactory = func() byteBuffer {
 		return new(bytes.Buffer)
 	}
+	exporter.readMemStats = runtime.ReadMemStats
+	exporter.numGoroutine = runtime.NumGoroutine
+	exporter.now = time.Now
+	exporter.staleAfter = make(map[string]time.Duration)
+	exporter.namespace = DefaultNamespace
 
 	return exporter
 }
 
+// SetRuntimeMetricsEnabled toggles the Go runtime series (goroutines, heap, GC pauses)
+// appended to each scrape so the shaper's own footprint can be tracked.
+func (e *Exporter) SetRuntimeMetricsEnabled(enabled bool) {
+	e.mu.Lock()
+	e.runtimeMetrics = enabled
+	e.mu.Unlock()
+}
+
+// SetStaleAfter renders metric (MetricOCIP95 or MetricHostCPU) as NaN once its
+// last update is older than maxAge, so dashboards do not present hours-old
+// values as current, and exposes its age through shaper_metric_age_seconds. A
+// non-positive maxAge disables tracking and keeps the last value indefinitely.
+func (e *Exporter) SetStaleAfter(metric string, maxAge time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if maxAge <= 0 {
+		delete(e.staleAfter, metric)
+
+		return
+	}
+
+	e.staleAfter[metric] = maxAge
+}
+
+// SetInstanceInfo records the instance OCID and human-readable display name exposed
+// through the shaper_instance_info series. An empty instance ID hides the series.
+func (e *Exporter) SetInstanceInfo(instanceID, displayName string) {
+	e.mu.Lock()
+	e.instanceID = e.labelLocked(instanceID)
+	e.displayName = e.labelLocked(displayName)
+	e.mu.Unlock()
+}
+
+// SetControllerBand records the active controller thresholds. The band series are
+// omitted until the first call, for example when running the noop controller.
+func (e *Exporter) SetControllerBand(band ControllerBand) {
+	e.mu.Lock()
+	e.band = band
+	e.bandSet = true
+	e.mu.Unlock()
+}
+
+// SetAPIUsageSource installs the callback consulted on each scrape for the
+// shaper_api_calls_today and shaper_api_budget_remaining series.
+func (e *Exporter) SetAPIUsageSource(source func() []APIUsage) {
+	e.mu.Lock()
+	e.apiUsage = source
+	e.mu.Unlock()
+}
+
+// SetPoolStartOutcome records how the worker pool handled SCHED_IDLE at start:
+// "ok" or the start failure policy that applied. Empty hides the series.
+func (e *Exporter) SetPoolStartOutcome(outcome string) {
+	e.mu.Lock()
+	e.poolOutcome = e.labelLocked(outcome)
+	e.mu.Unlock()
+}
+
+// SetOCIIdle records whether the instance meets OCI's idle definition, which
+// combines the CPU P95 with seven-day network usage.
+func (e *Exporter) SetOCIIdle(idle bool) {
+	e.mu.Lock()
+	e.ociIdle = idle
+	e.ociIdleSet = true
+	e.mu.Unlock()
+}
+
+// SetPoolCalibrationError records the startup self-test result: the host
+// utilisation the workers added minus the share they were expected to add.
+func (e *Exporter) SetPoolCalibrationError(calibrationError float64) {
+	e.mu.Lock()
+	e.calibrationError = calibrationError
+	e.calibrationSet = true
+	e.mu.Unlock()
+}
+
+// SetEffectiveQuantumSource installs the callback consulted on each scrape for
+// shaper_worker_effective_quantum_seconds, the duty cycle the workers run at
+// the current target.
+func (e *Exporter) SetEffectiveQuantumSource(source func() time.Duration) {
+	e.mu.Lock()
+	e.effectiveQuantum = source
+	e.mu.Unlock()
+}
+
+// SetHeartbeatAgeSource installs the callback consulted on each scrape for
+// shaper_worker_heartbeat_age_seconds, how long ago the least recently active
+// worker completed a duty cycle.
+func (e *Exporter) SetHeartbeatAgeSource(source func() time.Duration) {
+	e.mu.Lock()
+	e.heartbeatAge = source
+	e.mu.Unlock()
+}
+
+// SetWorkerRestartsSource installs the callback consulted on each scrape for
+// shaper_worker_restarts_total, how many stalled workers the pool replaced.
+func (e *Exporter) SetWorkerRestartsSource(source func() uint64) {
+	e.mu.Lock()
+	e.workerRestarts = source
+	e.mu.Unlock()
+}
+
+// SetSleepOvershootSource installs the callback consulted on each scrape for
+// shaper_worker_sleep_overshoot_seconds, how far the workers' idle sleeps
+// overrun on average.
+func (e *Exporter) SetSleepOvershootSource(source func() time.Duration) {
+	e.mu.Lock()
+	e.sleepOvershoot = source
+	e.mu.Unlock()
+}
+
+// SetBlackout records whether a blackout window currently holds the shaper
+// idle.
+func (e *Exporter) SetBlackout(active bool) {
+	e.mu.Lock()
+	e.blackout = active
+	e.blackoutSet = true
+	e.mu.Unlock()
+}
+
+// SetAlarmSilenced records whether the guardrail alarm is currently suppressed,
+// leaving the shaper as the only protection against reclamation.
+func (e *Exporter) SetAlarmSilenced(silenced bool) {
+	e.mu.Lock()
+	e.alarmSilenced = silenced
+	e.alarmSilencedSet = true
+	e.mu.Unlock()
+}
+
+// SetBurstCredits records the latest burst credit estimate.
+func (e *Exporter) SetBurstCredits(credits BurstCredits) {
+	e.mu.Lock()
+	e.burst = credits
+	e.burstSet = true
+	e.mu.Unlock()
+}
+
+// SetUpdateStatus records the outcome of the latest release check.
+func (e *Exporter) SetUpdateStatus(status UpdateStatus) {
+	e.mu.Lock()
+	status.LatestVersion = e.labelLocked(status.LatestVersion)
+	e.update = status
+	e.updateSet = true
+	e.mu.Unlock()
+}
+
+// SetConfigStatus records the configuration hash and canary state.
+func (e *Exporter) SetConfigStatus(status ConfigStatus) {
+	e.mu.Lock()
+	status.Hash = e.labelLocked(status.Hash)
+	e.config = status
+	e.mu.Unlock()
+}
+
+// ObserveMetadataChange counts a change of the named instance metadata field
+// (for example "compartmentId" or "ocpus") detected after startup.
+func (e *Exporter) ObserveMetadataChange(field string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.metadataChanges == nil {
+		e.metadataChanges = make(map[string]int)
+	}
+
+	e.metadataChanges[labelKeyLocked(e, e.metadataChanges, field)]++
+}
+
+// ObserveEstimatorRestart counts a replacement of the host CPU sampler for the
+// given reason (for example "closed" or "silent").
+func (e *Exporter) ObserveEstimatorRestart(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.estimatorRestarts == nil {
+		e.estimatorRestarts = make(map[string]int)
+	}
+
+	e.estimatorRestarts[labelKeyLocked(e, e.estimatorRestarts, reason)]++
+}
+
+// ObserveError records code as the latest error of component, replacing the
+// one reported before. The code should be a short classification such as an
+// OCI service error code or an HTTP status rather than an error message.
+func (e *Exporter) ObserveError(component, code string, at time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lastErrors == nil {
+		e.lastErrors = make(map[string]ComponentError)
+	}
+
+	e.lastErrors[labelKeyLocked(e, e.lastErrors, component)] = ComponentError{
+		Code: e.labelLocked(code),
+		At:   at,
+	}
+}
+
+// SetTokenExpiry records when the instance principal security token expires.
+// The exported gauge counts down to it at every scrape.
+func (e *Exporter) SetTokenExpiry(expiry time.Time) {
+	e.mu.Lock()
+	e.tokenExpiry = expiry
+	e.mu.Unlock()
+}
+
+// SetQueryWindow records the start and end of the latest Monitoring query
+// window.
+func (e *Exporter) SetQueryWindow(start, end time.Time) {
+	e.mu.Lock()
+	e.queryStart = start
+	e.queryEnd = end
+	e.mu.Unlock()
+}
+
+// SetMemoryStatus records the resident set size of the daemon, in bytes, and
+// how often freed memory has been returned to the OS.
+func (e *Exporter) SetMemoryStatus(rss int64, trims uint64) {
+	e.mu.Lock()
+	e.memoryRSS = rss
+	e.memoryTrims = trims
+	e.memorySet = true
+	e.mu.Unlock()
+}
+
+// ObserveDroppedObservation counts a host CPU observation the estimator
+// dropped because the controller fell behind.
+func (e *Exporter) ObserveDroppedObservation() {
+	e.mu.Lock()
+	e.estimatorDropped++
+	e.mu.Unlock()
+}
+
+// SetWorkerPolicies records how many workers run under each scheduling policy
+// (for example "sched_idle", "nice" or "default").
+func (e *Exporter) SetWorkerPolicies(policies map[string]int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cloned := make(map[string]int, len(policies))
+	for _, policy := range slices.Sorted(maps.Keys(policies)) {
+		cloned[labelKeyLocked(e, cloned, policy)] += policies[policy]
+	}
+
+	e.workerPolicies = cloned
+}
+
 // SetMode records the controller mode label.
 func (e *Exporter) SetMode(mode string) {
 	trimmed := strings.TrimSpace(mode)
@@ -152,11 +519,27 @@ func (e *Exporter) ObserveHostCPU(utilisation float64) {
 
 	e.mu.Lock()
 	e.hostCPUPercent = percent
+	e.hostCPUUpdated = e.clock()
+	e.mu.Unlock()
+}
+
+// SetSelfCPU records the share of host CPU attributed to the shaper's own workers
+// and excluded from suppression decisions.
+func (e *Exporter) SetSelfCPU(share float64) {
+	if math.IsNaN(share) || share < 0 {
+		share = 0
+	}
+
+	e.mu.Lock()
+	e.selfCPUPercent = min(share*hundredPercent, hundredPercent)
+	e.selfCPUSet = true
 	e.mu.Unlock()
 }
 
 // ServeHTTP implements http.Handler for the metrics exporter.
-func (e *Exporter) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+func (e *Exporter) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	e.sampleOnScrape(request.Context())
+
 	data, err := e.Render()
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
@@ -198,8 +581,11 @@ func (e *Exporter) WriteTo(dst io.Writer) (int64, error) {
 		return 0, errNilWriter
 	}
 
-	snapshot := e.snapshot()
+	return e.writeSnapshot(dst, e.snapshot())
+}
 
+// writeSnapshot writes the series of snapshot to dst.
+func (e *Exporter) writeSnapshot(dst io.Writer, snapshot exporterSnapshot) (int64, error) {
 	lines := []string{
 		"# HELP shaper_target_ratio Target duty cycle ratio assigned to worker pool.\n",
 		"# TYPE shaper_target_ratio gauge\n",
@@ -225,13 +611,261 @@ func (e *Exporter) WriteTo(dst io.Writer) (int64, error) {
 		"# HELP host_cpu_percent Last recorded host CPU utilisation percentage.\n",
 		"# TYPE host_cpu_percent gauge\n",
 		fmt.Sprintf("host_cpu_percent %.2f\n", snapshot.hostCPUPercent),
-		"# EOF\n",
 	}
 
+	if snapshot.selfCPUSet {
+		lines = append(
+			lines,
+			"# HELP shaper_self_cpu_percent Host CPU percentage attributed to the shaper's "+
+				"workers and subtracted before suppression decisions.\n",
+			"# TYPE shaper_self_cpu_percent gauge\n",
+			fmt.Sprintf("shaper_self_cpu_percent %.2f\n", snapshot.selfCPUPercent),
+		)
+	}
+
+	lines = append(lines, freshnessLines(snapshot.ages)...)
+
+	if snapshot.instanceID != "" {
+		lines = append(
+			lines,
+			"# HELP shaper_instance_info Instance identity labels (value is always 1).\n",
+			"# TYPE shaper_instance_info gauge\n",
+			fmt.Sprintf(
+				"shaper_instance_info{instance_id=\"%s\",display_name=\"%s\"} 1\n",
+				escapeLabelValue(snapshot.instanceID),
+				escapeLabelValue(snapshot.displayName),
+			),
+		)
+	}
+
+	if snapshot.bandSet {
+		lines = append(lines, bandLines(snapshot.band)...)
+	}
+
+	if snapshot.ociIdleSet {
+		lines = append(
+			lines,
+			"# HELP shaper_oci_idle Whether the instance is idle by OCI's reclamation "+
+				"definition (CPU P95 and network usage both below 20%).\n",
+			"# TYPE shaper_oci_idle gauge\n",
+			fmt.Sprintf("shaper_oci_idle %d\n", boolToInt(snapshot.ociIdle)),
+		)
+	}
+
+	if snapshot.calibrationSet {
+		lines = append(
+			lines,
+			"# HELP shaper_pool_calibration_error Host utilisation added by the workers "+
+				"during the startup self-test minus the expected share.\n",
+			"# TYPE shaper_pool_calibration_error gauge\n",
+			fmt.Sprintf("shaper_pool_calibration_error %.4f\n", snapshot.calibrationError),
+		)
+	}
+
+	if snapshot.sleepOvershoot != nil {
+		lines = append(
+			lines,
+			"# HELP shaper_worker_sleep_overshoot_seconds Average time worker idle sleeps "+
+				"overrun, which the workers subtract from later sleeps.\n",
+			"# TYPE shaper_worker_sleep_overshoot_seconds gauge\n",
+			fmt.Sprintf(
+				"shaper_worker_sleep_overshoot_seconds %.6f\n",
+				snapshot.sleepOvershoot().Seconds(),
+			),
+		)
+	}
+
+	if snapshot.effectiveQuantum != nil {
+		lines = append(
+			lines,
+			"# HELP shaper_worker_effective_quantum_seconds Duty cycle the workers run at "+
+				"the current target; low targets stretch it beyond the configured quantum.\n",
+			"# TYPE shaper_worker_effective_quantum_seconds gauge\n",
+			fmt.Sprintf(
+				"shaper_worker_effective_quantum_seconds %.6f\n",
+				snapshot.effectiveQuantum().Seconds(),
+			),
+		)
+	}
+
+	if snapshot.heartbeatAge != nil {
+		lines = append(
+			lines,
+			"# HELP shaper_worker_heartbeat_age_seconds Time since the least recently "+
+				"active worker completed a duty cycle.\n",
+			"# TYPE shaper_worker_heartbeat_age_seconds gauge\n",
+			fmt.Sprintf(
+				"shaper_worker_heartbeat_age_seconds %.6f\n",
+				snapshot.heartbeatAge().Seconds(),
+			),
+		)
+	}
+
+	if snapshot.workerRestarts != nil {
+		lines = append(
+			lines,
+			"# HELP shaper_worker_restarts Workers replaced after missing their "+
+				"heartbeat.\n",
+			"# TYPE shaper_worker_restarts counter\n",
+			fmt.Sprintf("shaper_worker_restarts_total %d\n", snapshot.workerRestarts()),
+		)
+	}
+
+	if snapshot.alarmSilencedSet {
+		lines = append(
+			lines,
+			"# HELP shaper_guardrail_alarm_silenced Whether the guardrail alarm is inside "+
+				"a suppression window.\n",
+			"# TYPE shaper_guardrail_alarm_silenced gauge\n",
+			fmt.Sprintf(
+				"shaper_guardrail_alarm_silenced %d\n",
+				boolToInt(snapshot.alarmSilenced),
+			),
+		)
+	}
+
+	if snapshot.blackoutSet {
+		lines = append(
+			lines,
+			"# HELP shaper_blackout_active Whether a blackout window holds the workers idle.\n",
+			"# TYPE shaper_blackout_active gauge\n",
+			fmt.Sprintf("shaper_blackout_active %d\n", boolToInt(snapshot.blackout)),
+		)
+	}
+
+	if snapshot.burstSet {
+		lines = append(lines, burstLines(snapshot.burst)...)
+	}
+
+	if snapshot.updateSet {
+		lines = append(
+			lines,
+			"# HELP shaper_update_available Whether a newer release than the running "+
+				"build is published.\n",
+			"# TYPE shaper_update_available gauge\n",
+			fmt.Sprintf(
+				"shaper_update_available{latest_version=\"%s\"} %d\n",
+				escapeLabelValue(snapshot.update.LatestVersion),
+				boolToInt(snapshot.update.Available),
+			),
+		)
+	}
+
+	if snapshot.config.Hash != "" {
+		lines = append(
+			lines,
+			"# HELP shaper_config_info Hash of the loaded configuration (value is always 1).\n",
+			"# TYPE shaper_config_info gauge\n",
+			fmt.Sprintf(
+				"shaper_config_info{hash=\"%s\"} 1\n",
+				escapeLabelValue(snapshot.config.Hash),
+			),
+			"# HELP shaper_config_canary Whether the configuration is observed in dry-run "+
+				"before promotion to enforce.\n",
+			"# TYPE shaper_config_canary gauge\n",
+			fmt.Sprintf("shaper_config_canary %d\n", boolToInt(snapshot.config.Canary)),
+		)
+	}
+
+	if snapshot.poolOutcome != "" {
+		lines = append(
+			lines,
+			"# HELP shaper_pool_start_outcome Worker pool start outcome (value is always 1).\n",
+			"# TYPE shaper_pool_start_outcome gauge\n",
+			fmt.Sprintf(
+				"shaper_pool_start_outcome{outcome=\"%s\"} 1\n",
+				escapeLabelValue(snapshot.poolOutcome),
+			),
+		)
+	}
+
+	if len(snapshot.workerPolicies) > 0 {
+		lines = append(lines, workerPolicyLines(snapshot.workerPolicies)...)
+	}
+
+	if len(snapshot.metadataChanges) > 0 {
+		lines = append(lines, metadataChangeLines(snapshot.metadataChanges)...)
+	}
+
+	if len(snapshot.estimatorRestarts) > 0 {
+		lines = append(lines, estimatorRestartLines(snapshot.estimatorRestarts)...)
+	}
+
+	if len(snapshot.lastErrors) > 0 {
+		lines = append(lines, lastErrorLines(snapshot.lastErrors)...)
+	}
+
+	if snapshot.tokenExpirySet {
+		lines = append(
+			lines,
+			"# HELP shaper_oci_token_expiry_seconds Seconds until the instance principal "+
+				"security token expires.\n",
+			"# TYPE shaper_oci_token_expiry_seconds gauge\n",
+			fmt.Sprintf("shaper_oci_token_expiry_seconds %d\n", snapshot.tokenExpirySeconds),
+		)
+	}
+
+	if !snapshot.queryEnd.IsZero() {
+		lines = append(lines, queryWindowLines(snapshot.queryStart, snapshot.queryEnd)...)
+	}
+
+	if snapshot.memorySet {
+		lines = append(
+			lines,
+			"# HELP shaper_process_resident_memory_bytes Resident set size of the daemon at "+
+				"the latest memory check.\n",
+			"# TYPE shaper_process_resident_memory_bytes gauge\n",
+			fmt.Sprintf("shaper_process_resident_memory_bytes %d\n", snapshot.memoryRSS),
+			"# HELP shaper_memory_trims Times freed memory was returned to the OS.\n",
+			"# TYPE shaper_memory_trims counter\n",
+			fmt.Sprintf("shaper_memory_trims_total %d\n", snapshot.memoryTrims),
+		)
+	}
+
+	if snapshot.estimatorDropped > 0 {
+		lines = append(
+			lines,
+			"# HELP estimator_dropped_observations Host CPU observations dropped "+
+				"because the controller fell behind.\n",
+			"# TYPE estimator_dropped_observations counter\n",
+			fmt.Sprintf(
+				"estimator_dropped_observations_total %d\n",
+				snapshot.estimatorDropped,
+			),
+		)
+	}
+
+	if snapshot.episodes.count > 0 {
+		lines = append(lines, suppressionEpisodeLines(snapshot.episodes)...)
+	}
+
+	if snapshot.apiUsage != nil {
+		lines = append(lines, apiUsageLines(capAPIUsage(snapshot.apiUsage()))...)
+	}
+
+	if snapshot.labelsCapped > 0 {
+		lines = append(
+			lines,
+			"# HELP shaper_metric_labels_capped Label values truncated, cleaned or "+
+				"folded into \"other\" by the cardinality guard.\n",
+			"# TYPE shaper_metric_labels_capped counter\n",
+			fmt.Sprintf("shaper_metric_labels_capped_total %d\n", snapshot.labelsCapped),
+		)
+	}
+
+	if snapshot.runtimeMetrics {
+		lines = append(lines, e.runtimeLines()...)
+	}
+
+	lines = append(lines, "# EOF\n")
+
 	var total int64
 
 	for _, line := range lines {
-		n, err := io.WriteString(dst, line)
+		n, err := io.WriteString(
+			dst,
+			staticLabelLine(namespaceLine(line, snapshot.namespace), snapshot.staticLabels),
+		)
 
 		total += int64(n)
 		if err != nil {
@@ -251,6 +885,49 @@ type exporterSnapshot struct {
 	dutyCycleMillis     float64
 	workerCount         float64
 	hostCPUPercent      float64
+	selfCPUPercent      float64
+	selfCPUSet          bool
+	ages                map[string]float64
+	runtimeMetrics      bool
+	instanceID          string
+	displayName         string
+	band                ControllerBand
+	bandSet             bool
+	apiUsage            func() []APIUsage
+	poolOutcome         string
+	workerPolicies      map[string]int
+	ociIdle             bool
+	ociIdleSet          bool
+	alarmSilenced       bool
+	alarmSilencedSet    bool
+	blackout            bool
+	blackoutSet         bool
+	calibrationError    float64
+	calibrationSet      bool
+	sleepOvershoot      func() time.Duration
+	effectiveQuantum    func() time.Duration
+	heartbeatAge        func() time.Duration
+	workerRestarts      func() uint64
+	burst               BurstCredits
+	burstSet            bool
+	update              UpdateStatus
+	updateSet           bool
+	metadataChanges     map[string]int
+	config              ConfigStatus
+	estimatorRestarts   map[string]int
+	estimatorDropped    int
+	episodes            suppressionEpisodeStats
+	lastErrors          map[string]ComponentError
+	tokenExpirySeconds  int64
+	tokenExpirySet      bool
+	queryStart          time.Time
+	queryEnd            time.Time
+	memoryRSS           int64
+	memoryTrims         uint64
+	memorySet           bool
+	labelsCapped        int
+	namespace           string
+	staticLabels        []staticLabel
 }
 
 func (e *Exporter) snapshot() exporterSnapshot {
@@ -262,14 +939,362 @@ func (e *Exporter) snapshot() exporterSnapshot {
 		epoch = float64(e.ociLastSuccess.Unix())
 	}
 
+	now := e.clock()
+	ages := make(map[string]float64, 2)
+	ociP95 := e.ociP95
+	hostCPUPercent := e.hostCPUPercent
+
+	if e.trackedLocked(MetricOCIP95, e.ociLastSuccess) {
+		ages[MetricOCIP95] = now.Sub(e.ociLastSuccess).Seconds()
+		if now.Sub(e.ociLastSuccess) > e.staleAfter[MetricOCIP95] {
+			ociP95 = math.NaN()
+		}
+	}
+
+	if e.trackedLocked(MetricHostCPU, e.hostCPUUpdated) {
+		ages[MetricHostCPU] = now.Sub(e.hostCPUUpdated).Seconds()
+		if now.Sub(e.hostCPUUpdated) > e.staleAfter[MetricHostCPU] {
+			hostCPUPercent = math.NaN()
+		}
+	}
+
 	return exporterSnapshot{
 		shaperTarget:        e.shaperTarget,
 		shaperMode:          e.shaperMode,
 		shaperState:         e.shaperState,
-		ociP95:              e.ociP95,
+		ociP95:              ociP95,
 		ociLastSuccessEpoch: epoch,
 		dutyCycleMillis:     e.dutyCycleMillis,
 		workerCount:         e.workerCount,
-		hostCPUPercent:      e.hostCPUPercent,
+		hostCPUPercent:      hostCPUPercent,
+		selfCPUPercent:      e.selfCPUPercent,
+		selfCPUSet:          e.selfCPUSet,
+		ages:                ages,
+		runtimeMetrics:      e.runtimeMetrics,
+		instanceID:          e.instanceID,
+		displayName:         e.displayName,
+		band:                e.band,
+		bandSet:             e.bandSet,
+		apiUsage:            e.apiUsage,
+		poolOutcome:         e.poolOutcome,
+		workerPolicies:      e.workerPolicies,
+		ociIdle:             e.ociIdle,
+		ociIdleSet:          e.ociIdleSet,
+		alarmSilenced:       e.alarmSilenced,
+		alarmSilencedSet:    e.alarmSilencedSet,
+		blackout:            e.blackout,
+		blackoutSet:         e.blackoutSet,
+		calibrationError:    e.calibrationError,
+		calibrationSet:      e.calibrationSet,
+		sleepOvershoot:      e.sleepOvershoot,
+		effectiveQuantum:    e.effectiveQuantum,
+		heartbeatAge:        e.heartbeatAge,
+		workerRestarts:      e.workerRestarts,
+		burst:               e.burst,
+		burstSet:            e.burstSet,
+		update:              e.update,
+		updateSet:           e.updateSet,
+		config:              e.config,
+		metadataChanges:     maps.Clone(e.metadataChanges),
+		estimatorRestarts:   maps.Clone(e.estimatorRestarts),
+		estimatorDropped:    e.estimatorDropped,
+		episodes:            e.episodes.clone(),
+		lastErrors:          maps.Clone(e.lastErrors),
+		tokenExpirySeconds:  int64(e.tokenExpiry.Sub(now).Seconds()),
+		tokenExpirySet:      !e.tokenExpiry.IsZero(),
+		queryStart:          e.queryStart,
+		queryEnd:            e.queryEnd,
+		memoryRSS:           e.memoryRSS,
+		memoryTrims:         e.memoryTrims,
+		memorySet:           e.memorySet,
+		labelsCapped:        e.labelsCapped,
+		namespace:           e.namespace,
+		staticLabels:        e.staticLabels,
+	}
+}
+
+// trackedLocked reports whether metric has a staleness limit and has been
+// updated at least once.
+func (e *Exporter) trackedLocked(metric string, updated time.Time) bool {
+	_, ok := e.staleAfter[metric]
+
+	return ok && !updated.IsZero()
+}
+
+func (e *Exporter) clock() time.Time {
+	if e.now == nil {
+		return time.Now()
+	}
+
+	return e.now()
+}
+
+func freshnessLines(ages map[string]float64) []string {
+	if len(ages) == 0 {
+		return nil
+	}
+
+	lines := []string{
+		"# HELP shaper_metric_age_seconds Seconds since the metric family was last updated.\n",
+		"# TYPE shaper_metric_age_seconds gauge\n",
+	}
+
+	for _, metric := range []string{MetricOCIP95, MetricHostCPU} {
+		age, ok := ages[metric]
+		if !ok {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"shaper_metric_age_seconds{metric=\"%s\"} %.0f\n",
+			metric,
+			math.Max(0, age),
+		))
+	}
+
+	return lines
+}
+
+func bandLines(band ControllerBand) []string {
+	return []string{
+		"# HELP shaper_goal_low_ratio Lower bound of the OCI P95 goal band.\n",
+		"# TYPE shaper_goal_low_ratio gauge\n",
+		fmt.Sprintf("shaper_goal_low_ratio %.6f\n", band.GoalLow),
+		"# HELP shaper_goal_high_ratio Upper bound of the OCI P95 goal band.\n",
+		"# TYPE shaper_goal_high_ratio gauge\n",
+		fmt.Sprintf("shaper_goal_high_ratio %.6f\n", band.GoalHigh),
+		"# HELP shaper_target_min_ratio Minimum duty cycle target the controller may assign.\n",
+		"# TYPE shaper_target_min_ratio gauge\n",
+		fmt.Sprintf("shaper_target_min_ratio %.6f\n", band.TargetMin),
+		"# HELP shaper_target_max_ratio Maximum duty cycle target the controller may assign.\n",
+		"# TYPE shaper_target_max_ratio gauge\n",
+		fmt.Sprintf("shaper_target_max_ratio %.6f\n", band.TargetMax),
+		"# HELP shaper_suppress_threshold_ratio Host utilisation that triggers suppression.\n",
+		"# TYPE shaper_suppress_threshold_ratio gauge\n",
+		fmt.Sprintf("shaper_suppress_threshold_ratio %.6f\n", band.SuppressThreshold),
+	}
+}
+
+// burstLines reports an undrained balance as never throttling (+Inf).
+func burstLines(burst BurstCredits) []string {
+	throttle := math.Inf(1)
+	if burst.Draining {
+		throttle = max(burst.ThrottleIn.Seconds(), 0)
+	}
+
+	return []string{
+		"# HELP shaper_burst_credits_ratio Estimated CPU credit balance of a burstable " +
+			"shape as a fraction of a full one.\n",
+		"# TYPE shaper_burst_credits_ratio gauge\n",
+		fmt.Sprintf("shaper_burst_credits_ratio %.4f\n", burst.Credits),
+		"# HELP shaper_burst_throttle_projected_seconds Projected seconds until the " +
+			"instance is clamped to its baseline at the current utilisation.\n",
+		"# TYPE shaper_burst_throttle_projected_seconds gauge\n",
+		fmt.Sprintf("shaper_burst_throttle_projected_seconds %.0f\n", throttle),
+	}
+}
+
+func workerPolicyLines(policies map[string]int) []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	lines := []string{
+		"# HELP shaper_worker_sched_policy Workers running under each scheduling policy.\n",
+		"# TYPE shaper_worker_sched_policy gauge\n",
+	}
+
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_worker_sched_policy{policy=\"%s\"} %d\n",
+			escapeLabelValue(name),
+			policies[name],
+		))
+	}
+
+	return lines
+}
+
+func estimatorRestartLines(restarts map[string]int) []string {
+	reasons := slices.Sorted(maps.Keys(restarts))
+
+	lines := []string{
+		"# HELP estimator_restarts Host CPU sampler replacements by the estimator " +
+			"supervisor since startup.\n",
+		"# TYPE estimator_restarts counter\n",
+	}
+
+	for _, reason := range reasons {
+		lines = append(lines, fmt.Sprintf(
+			"estimator_restarts_total{reason=\"%s\"} %d\n",
+			escapeLabelValue(reason),
+			restarts[reason],
+		))
+	}
+
+	return lines
+}
+
+func metadataChangeLines(changes map[string]int) []string {
+	fields := slices.Sorted(maps.Keys(changes))
+
+	lines := []string{
+		"# HELP shaper_metadata_changes Instance metadata changes detected " +
+			"since startup.\n",
+		"# TYPE shaper_metadata_changes counter\n",
+	}
+
+	for _, field := range fields {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_metadata_changes_total{field=\"%s\"} %d\n",
+			escapeLabelValue(field),
+			changes[field],
+		))
+	}
+
+	return lines
+}
+
+func apiUsageLines(usages []APIUsage) []string {
+	if len(usages) == 0 {
+		return nil
+	}
+
+	lines := []string{
+		"# HELP shaper_api_calls_today OCI API calls made since 00:00 UTC.\n",
+		"# TYPE shaper_api_calls_today gauge\n",
+	}
+
+	for _, usage := range usages {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_api_calls_today{api=\"%s\"} %d\n",
+			escapeLabelValue(usage.API),
+			usage.Calls,
+		))
 	}
+
+	header := []string{
+		"# HELP shaper_api_budget_remaining OCI API calls left in today's configured budget.\n",
+		"# TYPE shaper_api_budget_remaining gauge\n",
+	}
+
+	for _, usage := range usages {
+		if usage.Limit <= 0 {
+			continue
+		}
+
+		lines = append(lines, header...)
+		header = nil
+
+		lines = append(lines, fmt.Sprintf(
+			"shaper_api_budget_remaining{api=\"%s\"} %d\n",
+			escapeLabelValue(usage.API),
+			max(usage.Limit-usage.Calls, 0),
+		))
+	}
+
+	return lines
+}
+
+func (e *Exporter) runtimeLines() []string {
+	readStats := e.readMemStats
+	if readStats == nil {
+		readStats = runtime.ReadMemStats
+	}
+
+	countGoroutines := e.numGoroutine
+	if countGoroutines == nil {
+		countGoroutines = runtime.NumGoroutine
+	}
+
+	var stats runtime.MemStats
+
+	readStats(&stats)
+
+	return []string{
+		"# HELP go_goroutines Number of goroutines that currently exist.\n",
+		"# TYPE go_goroutines gauge\n",
+		fmt.Sprintf("go_goroutines %d\n", countGoroutines()),
+		"# HELP go_memstats_heap_alloc_bytes Bytes of allocated heap objects.\n",
+		"# TYPE go_memstats_heap_alloc_bytes gauge\n",
+		fmt.Sprintf("go_memstats_heap_alloc_bytes %d\n", stats.HeapAlloc),
+		"# HELP go_memstats_sys_bytes Bytes of memory obtained from the OS.\n",
+		"# TYPE go_memstats_sys_bytes gauge\n",
+		fmt.Sprintf("go_memstats_sys_bytes %d\n", stats.Sys),
+		"# HELP go_gc_cycles Number of completed GC cycles.\n",
+		"# TYPE go_gc_cycles counter\n",
+		fmt.Sprintf("go_gc_cycles_total %d\n", stats.NumGC),
+		"# HELP go_gc_pause_seconds Cumulative GC stop-the-world pause time in seconds.\n",
+		"# TYPE go_gc_pause_seconds counter\n",
+		fmt.Sprintf(
+			"go_gc_pause_seconds_total %.6f\n",
+			float64(stats.PauseTotalNs)/nanosecondsPerSecond,
+		),
+	}
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+func queryWindowLines(start, end time.Time) []string {
+	return []string{
+		"# HELP shaper_oci_query_window_start_timestamp_seconds Unix time the latest " +
+			"Monitoring query window starts.\n",
+		"# TYPE shaper_oci_query_window_start_timestamp_seconds gauge\n",
+		fmt.Sprintf("shaper_oci_query_window_start_timestamp_seconds %d\n", start.Unix()),
+		"# HELP shaper_oci_query_window_end_timestamp_seconds Unix time the latest " +
+			"Monitoring query window ends.\n",
+		"# TYPE shaper_oci_query_window_end_timestamp_seconds gauge\n",
+		fmt.Sprintf("shaper_oci_query_window_end_timestamp_seconds %d\n", end.Unix()),
+		"# HELP shaper_oci_query_window_seconds Length of the latest Monitoring query " +
+			"window.\n",
+		"# TYPE shaper_oci_query_window_seconds gauge\n",
+		fmt.Sprintf("shaper_oci_query_window_seconds %d\n", int64(end.Sub(start).Seconds())),
+	}
+}
+
+func lastErrorLines(lastErrors map[string]ComponentError) []string {
+	components := slices.Sorted(maps.Keys(lastErrors))
+
+	lines := []string{
+		"# HELP shaper_last_error_info Latest error of each component, labelled with its " +
+			"code (value is always 1).\n",
+		"# TYPE shaper_last_error_info gauge\n",
+	}
+
+	for _, component := range components {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_last_error_info{component=\"%s\",code=\"%s\"} 1\n",
+			escapeLabelValue(component),
+			escapeLabelValue(lastErrors[component].Code),
+		))
+	}
+
+	lines = append(lines,
+		"# HELP shaper_last_error_timestamp_seconds Unix time of the latest error of each "+
+			"component.\n",
+		"# TYPE shaper_last_error_timestamp_seconds gauge\n",
+	)
+
+	for _, component := range components {
+		lines = append(lines, fmt.Sprintf(
+			"shaper_last_error_timestamp_seconds{component=\"%s\"} %d\n",
+			escapeLabelValue(component),
+			lastErrors[component].At.Unix(),
+		))
+	}
+
+	return lines
 }
@@ -1,12 +1,15 @@
 package metrics
 
 import (
+	"context"
 	"errors"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 var errFailingBuffer = errors.New("metrics: failing buffer")
@@ -86,3 +89,181 @@ func TestExporterObserveHostCPUClampsOutOfRangeValues(t *testing.T) {
 		t.Fatalf("expected utilisation to clamp to 100%%, got %.2f", snapshot.hostCPUPercent)
 	}
 }
+
+func TestExporterRuntimeMetricsUseInjectedReaders(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewExporter()
+	exporter.readMemStats = func(stats *runtime.MemStats) {
+		stats.HeapAlloc = 2048
+		stats.Sys = 4096
+		stats.NumGC = 7
+		stats.PauseTotalNs = 1_500_000
+	}
+	exporter.numGoroutine = func() int { return 12 }
+	exporter.SetRuntimeMetricsEnabled(true)
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+
+	for _, want := range []string{
+		"go_goroutines 12\n",
+		"go_memstats_heap_alloc_bytes 2048\n",
+		"go_memstats_sys_bytes 4096\n",
+		"# TYPE go_gc_cycles counter\ngo_gc_cycles_total 7\n",
+		"go_gc_pause_seconds_total 0.001500\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output, got %s", want, output)
+		}
+	}
+
+	if !strings.HasSuffix(output, "go_gc_pause_seconds_total 0.001500\n# EOF\n") {
+		t.Fatalf("expected runtime series before EOF marker, got %s", output)
+	}
+}
+
+func TestExporterRuntimeLinesFallBackToRuntimeReaders(t *testing.T) {
+	t.Parallel()
+
+	exporter := new(Exporter)
+
+	lines := exporter.runtimeLines()
+	if len(lines) == 0 {
+		t.Fatal("expected runtime lines from default readers")
+	}
+
+	if !strings.HasPrefix(lines[2], "go_goroutines ") {
+		t.Fatalf("expected goroutine gauge, got %q", lines[2])
+	}
+}
+
+func TestExporterExpiresStaleValues(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	exporter := NewExporter()
+	exporter.now = func() time.Time { return now }
+	exporter.SetStaleAfter(MetricOCIP95, 3*time.Hour)
+	exporter.SetStaleAfter(MetricHostCPU, 3*time.Second)
+
+	exporter.ObserveOCIP95(0.24, now.Add(-time.Hour))
+	exporter.ObserveHostCPU(0.5)
+
+	now = now.Add(10 * time.Second)
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"oci_p95 0.240000\n",
+		"host_cpu_percent NaN\n",
+		"shaper_metric_age_seconds{metric=\"oci_p95\"} 3610\n",
+		"shaper_metric_age_seconds{metric=\"host_cpu_percent\"} 10\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in output, got %s", want, data)
+		}
+	}
+
+	now = now.Add(3 * time.Hour)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "oci_p95 NaN\n") {
+		t.Fatalf("expected stale oci_p95 to render as NaN, got %s", data)
+	}
+}
+
+func TestExporterSamplesHostCPUOnScrape(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	exporter := NewExporter()
+	exporter.now = func() time.Time { return now }
+	exporter.ObserveHostCPU(0.1)
+
+	var (
+		calls  int
+		sample = 0.5
+		err    error
+	)
+
+	scrape := func() string {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		return recorder.Body.String()
+	}
+
+	exporter.SetScrapeSampler(func(context.Context) (float64, error) {
+		calls++
+
+		return sample, err
+	}, 0)
+
+	if body := scrape(); calls != 0 || !strings.Contains(body, "host_cpu_percent 10.00\n") {
+		t.Fatalf("expected a zero interval to disable sampling, got %d calls:\n%s", calls, body)
+	}
+
+	exporter.SetScrapeSampler(func(context.Context) (float64, error) {
+		calls++
+
+		return sample, err
+	}, 5*time.Second)
+
+	if body := scrape(); calls != 1 || !strings.Contains(body, "host_cpu_percent 50.00\n") {
+		t.Fatalf("expected the scrape to sample, got %d calls:\n%s", calls, body)
+	}
+
+	sample = 0.7
+	now = now.Add(time.Second)
+
+	if body := scrape(); calls != 1 || !strings.Contains(body, "host_cpu_percent 50.00\n") {
+		t.Fatalf("expected the interval to bound sampling, got %d calls:\n%s", calls, body)
+	}
+
+	err = errFailingBuffer
+	now = now.Add(5 * time.Second)
+
+	if body := scrape(); calls != 2 || !strings.Contains(body, "host_cpu_percent 50.00\n") {
+		t.Fatalf("expected a failed sample to keep the value, got %d calls:\n%s", calls, body)
+	}
+}
+
+func TestStaticLabelLineHandlesEscapesAndMalformedLines(t *testing.T) {
+	t.Parallel()
+
+	labels := []staticLabel{{name: "name", value: "ignored"}, {name: "team", value: "infra"}}
+
+	tests := map[string]string{
+		`info{name="a\"}, team=\"b"} 1`: `info{name="a\"}, team=\"b",team="infra"} 1`,
+		`series{ name = "x"} 1`:         `series{ name = "x",team="infra"} 1`,
+		`unterminated{name="x" 1`:       `unterminated{name="x" 1`,
+		`bare`:                          `bare`,
+		`# TYPE series gauge`:           `# TYPE series gauge`,
+	}
+
+	for line, want := range tests {
+		got := staticLabelLine(line, labels)
+		if got != want {
+			t.Fatalf("staticLabelLine(%q) = %q, want %q", line, got, want)
+		}
+	}
+
+	got := staticLabelLine("series 1", nil)
+	if got != "series 1" {
+		t.Fatalf("expected no labels to leave the line alone, got %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package metrics_test
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
@@ -158,3 +159,958 @@ type failingWriter struct{}
 func (failingWriter) Write([]byte) (int, error) {
 	return 0, errFailingWriter
 }
+
+func TestExporterOmitsRuntimeMetricsByDefault(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "go_goroutines") {
+		t.Fatalf("expected runtime metrics to be disabled, got %s", data)
+	}
+
+	exporter.SetRuntimeMetricsEnabled(true)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, name := range []string{
+		"go_goroutines",
+		"go_memstats_heap_alloc_bytes",
+		"go_memstats_sys_bytes",
+		"go_gc_cycles counter",
+		"go_gc_pause_seconds counter",
+	} {
+		if !strings.Contains(string(data), "# TYPE "+name) {
+			t.Fatalf("expected %s series once enabled, got %s", name, data)
+		}
+	}
+}
+
+func TestExporterRendersInstanceInfo(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_instance_info") {
+		t.Fatalf("expected instance info to be hidden without an instance ID, got %s", data)
+	}
+
+	exporter.SetInstanceInfo(" ocid1.instance.oc1..example ", `web "blue"`)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	want := `shaper_instance_info{instance_id="ocid1.instance.oc1..example",` +
+		`display_name="web \"blue\""} 1`
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected %q in output, got %s", want, data)
+	}
+
+	if !strings.HasSuffix(string(data), "# EOF\n") {
+		t.Fatalf("expected EOF terminator, got %s", data)
+	}
+}
+
+func TestExporterRendersControllerBand(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_goal_low_ratio") {
+		t.Fatalf("expected band series to be hidden until configured, got %s", data)
+	}
+
+	exporter.SetControllerBand(metrics.ControllerBand{
+		GoalLow:           0.23,
+		GoalHigh:          0.3,
+		TargetMin:         0.22,
+		TargetMax:         0.4,
+		SuppressThreshold: 0.85,
+	})
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_goal_low_ratio 0.230000\n",
+		"shaper_goal_high_ratio 0.300000\n",
+		"shaper_target_min_ratio 0.220000\n",
+		"shaper_target_max_ratio 0.400000\n",
+		"shaper_suppress_threshold_ratio 0.850000\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in output, got %s", want, data)
+		}
+	}
+
+	if !strings.HasSuffix(string(data), "# EOF\n") {
+		t.Fatalf("expected EOF terminator, got %s", data)
+	}
+}
+
+func TestExporterRendersAPIUsage(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetAPIUsageSource(func() []metrics.APIUsage {
+		return []metrics.APIUsage{
+			{API: "imds", Calls: 12},
+			{API: "monitoring", Calls: 950, Limit: 1000},
+		}
+	})
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_api_calls_today{api=\"imds\"} 12\n",
+		"shaper_api_calls_today{api=\"monitoring\"} 950\n",
+		"shaper_api_budget_remaining{api=\"monitoring\"} 50\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in output, got %s", want, data)
+		}
+	}
+
+	if strings.Contains(string(data), "shaper_api_budget_remaining{api=\"imds\"}") {
+		t.Fatalf("expected unbudgeted api to omit remaining series, got %s", data)
+	}
+}
+
+func TestExporterOmitsBudgetHeaderWithoutLimits(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetAPIUsageSource(func() []metrics.APIUsage {
+		return []metrics.APIUsage{{API: "imds", Calls: 12}}
+	})
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_api_calls_today{api=\"imds\"} 12\n") {
+		t.Fatalf("expected call count in output, got %s", data)
+	}
+
+	if strings.Contains(string(data), "shaper_api_budget_remaining") {
+		t.Fatalf("expected no budget family without a configured limit, got %s", data)
+	}
+}
+
+func TestExporterRendersWorkerPolicies(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetPoolStartOutcome("fallback")
+	exporter.SetWorkerPolicies(map[string]int{"sched_idle": 1, "nice": 3})
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+
+	for _, want := range []string{
+		"shaper_pool_start_outcome{outcome=\"fallback\"} 1\n",
+		"shaper_worker_sched_policy{policy=\"nice\"} 3\n" +
+			"shaper_worker_sched_policy{policy=\"sched_idle\"} 1\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output, got %s", want, data)
+		}
+	}
+}
+
+func TestExporterRendersOCIIdle(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_oci_idle") {
+		t.Fatalf("expected idle gauge to be hidden until evaluated, got %s", data)
+	}
+
+	exporter.SetOCIIdle(true)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_oci_idle 1\n") {
+		t.Fatalf("expected idle gauge set to 1, got %s", data)
+	}
+}
+
+func TestExporterRendersSelfCPU(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_self_cpu_percent") {
+		t.Fatalf("expected self cpu gauge to be hidden until reported, got %s", data)
+	}
+
+	exporter.SetSelfCPU(0.125)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_self_cpu_percent 12.50\n") {
+		t.Fatalf("expected self cpu gauge at 12.50, got %s", data)
+	}
+}
+
+func TestExporterRendersAlarmSilenced(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_guardrail_alarm_silenced") {
+		t.Fatalf("expected alarm silence gauge to be hidden until checked, got %s", data)
+	}
+
+	exporter.SetAlarmSilenced(true)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_guardrail_alarm_silenced 1\n") {
+		t.Fatalf("expected alarm silence gauge set to 1, got %s", data)
+	}
+}
+
+func TestExporterRendersBlackout(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_blackout_active") {
+		t.Fatalf("expected blackout gauge to be hidden without windows, got %s", data)
+	}
+
+	exporter.SetBlackout(true)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_blackout_active 1\n") {
+		t.Fatalf("expected blackout gauge set to 1, got %s", data)
+	}
+}
+
+func TestExporterRendersPoolCalibrationError(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_pool_calibration_error") {
+		t.Fatalf("expected calibration gauge to be hidden until calibrated, got %s", data)
+	}
+
+	exporter.SetPoolCalibrationError(-0.125)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_pool_calibration_error -0.1250\n") {
+		t.Fatalf("expected calibration gauge at -0.1250, got %s", data)
+	}
+}
+
+func TestExporterRendersBurstCredits(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_burst_") {
+		t.Fatalf("expected burst gauges to be hidden on non-burstable shapes, got %s", data)
+	}
+
+	exporter.SetBurstCredits(metrics.BurstCredits{Credits: 1, Draining: false, ThrottleIn: 0})
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_burst_credits_ratio 1.0000\n",
+		"shaper_burst_throttle_projected_seconds +Inf\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q, got %s", want, data)
+		}
+	}
+
+	exporter.SetBurstCredits(metrics.BurstCredits{
+		Credits:    0.25,
+		Draining:   true,
+		ThrottleIn: 90 * time.Minute,
+	})
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_burst_throttle_projected_seconds 5400\n") {
+		t.Fatalf("expected a 5400 s projection, got %s", data)
+	}
+}
+
+func TestExporterRendersUpdateStatus(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_update_available") {
+		t.Fatalf("expected update gauge to be hidden until checked, got %s", data)
+	}
+
+	exporter.SetUpdateStatus(metrics.UpdateStatus{Available: true, LatestVersion: " v1.4.0 "})
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_update_available{latest_version=\"v1.4.0\"} 1\n") {
+		t.Fatalf("expected update gauge set to 1, got %s", data)
+	}
+}
+
+func TestExporterCountsMetadataChanges(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.ObserveMetadataChange("ocpus")
+	exporter.ObserveMetadataChange("compartmentId")
+	exporter.ObserveMetadataChange("ocpus")
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	want := "shaper_metadata_changes_total{field=\"compartmentId\"} 1\n" +
+		"shaper_metadata_changes_total{field=\"ocpus\"} 2\n"
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected metadata change counters in output, got %s", data)
+	}
+}
+
+func TestExporterCountsEstimatorRestarts(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.ObserveEstimatorRestart("silent")
+	exporter.ObserveEstimatorRestart("closed")
+	exporter.ObserveEstimatorRestart("silent")
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	want := "estimator_restarts_total{reason=\"closed\"} 1\n" +
+		"estimator_restarts_total{reason=\"silent\"} 2\n"
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected estimator restart counters in output, got %s", data)
+	}
+}
+
+func TestExporterReportsLastErrorPerComponent(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_last_error") {
+		t.Fatalf("expected last error series to stay hidden before the first error, got %s", data)
+	}
+
+	exporter.ObserveError("oci", "503", time.Unix(1_700_000_000, 0))
+	exporter.ObserveError("estimator", "NotFound", time.Unix(1_700_000_100, 0))
+	exporter.ObserveError("oci", "NotAuthenticated", time.Unix(1_700_000_200, 0))
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	want := "shaper_last_error_info{component=\"estimator\",code=\"NotFound\"} 1\n" +
+		"shaper_last_error_info{component=\"oci\",code=\"NotAuthenticated\"} 1\n" +
+		"# HELP shaper_last_error_timestamp_seconds Unix time of the latest error of each " +
+		"component.\n" +
+		"# TYPE shaper_last_error_timestamp_seconds gauge\n" +
+		"shaper_last_error_timestamp_seconds{component=\"estimator\"} 1700000100\n" +
+		"shaper_last_error_timestamp_seconds{component=\"oci\"} 1700000200\n"
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected the latest error of each component, got %s", data)
+	}
+}
+
+func TestExporterReportsTokenExpiry(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_oci_token_expiry_seconds") {
+		t.Fatalf("expected the token expiry to stay hidden until known, got %s", data)
+	}
+
+	exporter.SetTokenExpiry(time.Now().Add(-time.Minute))
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_oci_token_expiry_seconds -60\n") {
+		t.Fatalf("expected an expired token to count below zero, got %s", data)
+	}
+}
+
+func TestExporterReportsQueryWindow(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_oci_query_window") {
+		t.Fatalf("expected the query window to stay hidden before the first query, got %s", data)
+	}
+
+	end := time.Unix(1_700_604_800, 0)
+	exporter.SetQueryWindow(end.Add(-7*24*time.Hour), end)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_oci_query_window_start_timestamp_seconds 1700000000\n",
+		"shaper_oci_query_window_end_timestamp_seconds 1700604800\n",
+		"# TYPE shaper_oci_query_window_seconds gauge\nshaper_oci_query_window_seconds 604800\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q, got %s", want, data)
+		}
+	}
+}
+
+func TestExporterReportsMemoryStatus(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_memory_trims_total") {
+		t.Fatalf("expected the memory series to stay hidden before the first check, got %s", data)
+	}
+
+	exporter.SetMemoryStatus(48<<20, 3)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_process_resident_memory_bytes 50331648\n",
+		"# TYPE shaper_memory_trims counter\nshaper_memory_trims_total 3\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q, got %s", want, data)
+		}
+	}
+}
+
+func TestExporterReportsSleepOvershoot(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_worker_sleep_overshoot_seconds") {
+		t.Fatalf("expected the sleep overshoot to stay hidden without a source, got %s", data)
+	}
+
+	exporter.SetSleepOvershootSource(func() time.Duration { return 750 * time.Microsecond })
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_worker_sleep_overshoot_seconds 0.000750\n") {
+		t.Fatalf("expected the sleep overshoot to be exported, got %s", data)
+	}
+}
+
+func TestExporterReportsEffectiveQuantum(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_worker_effective_quantum_seconds") {
+		t.Fatalf("expected the effective quantum to stay hidden without a source, got %s", data)
+	}
+
+	exporter.SetEffectiveQuantumSource(func() time.Duration { return 10 * time.Millisecond })
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_worker_effective_quantum_seconds 0.010000\n") {
+		t.Fatalf("expected the effective quantum to be exported, got %s", data)
+	}
+}
+
+func TestExporterReportsWorkerHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_worker_heartbeat_age_seconds") ||
+		strings.Contains(string(data), "shaper_worker_restarts_total") {
+		t.Fatalf("expected heartbeat metrics to stay hidden without sources, got %s", data)
+	}
+
+	exporter.SetHeartbeatAgeSource(func() time.Duration { return 1500 * time.Millisecond })
+	exporter.SetWorkerRestartsSource(func() uint64 { return 2 })
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shaper_worker_heartbeat_age_seconds 1.500000\n",
+		"# TYPE shaper_worker_restarts counter\n",
+		"shaper_worker_restarts_total 2\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in the exposition, got %s", want, data)
+		}
+	}
+}
+
+func TestExporterCountsDroppedObservations(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "estimator_dropped_observations_total") {
+		t.Fatalf("expected the drop counter to stay hidden before the first drop, got %s", data)
+	}
+
+	exporter.ObserveDroppedObservation()
+	exporter.ObserveDroppedObservation()
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "estimator_dropped_observations_total 2\n") {
+		t.Fatalf("expected dropped observation counter in output, got %s", data)
+	}
+}
+
+func TestExporterGuardsLabelCardinality(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetInstanceInfo("ocid1.instance.oc1..guard", "web\x00"+strings.Repeat("é", 100))
+
+	for index := range metrics.MaxLabelValues + 4 {
+		exporter.ObserveMetadataChange(fmt.Sprintf("field%02d", index))
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+
+	// "web" plus 62 two-byte runes fill 127 bytes; half a rune is not kept.
+	wantName := "display_name=\"web" + strings.Repeat("é", 62) + "\""
+	if !strings.Contains(output, wantName) {
+		t.Fatalf("expected a cleaned, truncated display name, got %s", output)
+	}
+
+	series := strings.Count(output, "shaper_metadata_changes_total{")
+	if series != metrics.MaxLabelValues {
+		t.Fatalf("expected %d metadata series, got %d", metrics.MaxLabelValues, series)
+	}
+
+	for _, want := range []string{
+		"shaper_metadata_changes_total{field=\"other\"} 5\n",
+		"shaper_metric_labels_capped_total 6\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output, got %s", want, output)
+		}
+	}
+}
+
+func TestExporterReportsConfigStatus(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_config_info") {
+		t.Fatalf("expected config series to stay hidden until set, got %s", data)
+	}
+
+	exporter.SetConfigStatus(metrics.ConfigStatus{Hash: "3f2a9c1d0b7e6a54", Canary: true})
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	want := "shaper_config_info{hash=\"3f2a9c1d0b7e6a54\"} 1\n"
+	if !strings.Contains(string(data), want) ||
+		!strings.Contains(string(data), "shaper_config_canary 1\n") {
+		t.Fatalf("expected config status in output, got %s", data)
+	}
+}
+
+func TestExporterRendersConfiguredNamespace(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetRuntimeMetricsEnabled(true)
+	exporter.ObserveEstimatorRestart("silent")
+
+	err := exporter.SetNamespace("acme")
+	if err != nil {
+		t.Fatalf("SetNamespace: %v", err)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+
+	for _, want := range []string{
+		"# HELP acme_target_ratio ",
+		"# TYPE acme_target_ratio gauge\n",
+		"\nacme_target_ratio 0.000000\n",
+		"acme_oci_p95 ",
+		"acme_host_cpu_percent ",
+		"acme_estimator_restarts_total{reason=\"silent\"} 1\n",
+		"\ngo_goroutines ",
+		"# EOF\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in namespaced output, got %s", want, output)
+		}
+	}
+
+	for line := range strings.Lines(output) {
+		if strings.HasPrefix(line, "shaper_") || strings.HasPrefix(line, "oci_") {
+			t.Fatalf("expected every shaper series to be namespaced, got %q", line)
+		}
+	}
+}
+
+func TestExporterRejectsInvalidNamespace(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	for _, namespace := range []string{"", "9shaper", "acme-shaper", "acme_", "acmé"} {
+		err := exporter.SetNamespace(namespace)
+		if !errors.Is(err, metrics.ErrInvalidNamespace) {
+			t.Fatalf("SetNamespace(%q) = %v, want ErrInvalidNamespace", namespace, err)
+		}
+	}
+
+	for _, namespace := range []string{"shaper", "_acme", "Acme2"} {
+		err := metrics.ValidateNamespace(namespace)
+		if err != nil {
+			t.Fatalf("ValidateNamespace(%q) = %v", namespace, err)
+		}
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "\nshaper_target_ratio 0.000000\n") {
+		t.Fatalf("expected the default namespace to keep historical names, got %s", data)
+	}
+}
+
+func TestExporterRendersStaticLabels(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	exporter.SetRuntimeMetricsEnabled(true)
+	exporter.SetState("suppressed")
+	exporter.SetInstanceInfo("ocid1.instance.oc1..abc", "web {1}")
+	exporter.ObserveSuppressionEpisode(2*time.Minute, time.Minute)
+
+	err := exporter.SetNamespace("acme")
+	if err != nil {
+		t.Fatalf("SetNamespace: %v", err)
+	}
+
+	err = exporter.SetStaticLabels(map[string]string{
+		"team":  "infra",
+		"env":   " pr\x00od ",
+		"state": "ignored",
+	})
+	if err != nil {
+		t.Fatalf("SetStaticLabels: %v", err)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+
+	for _, want := range []string{
+		"# TYPE acme_target_ratio gauge\n",
+		"\nacme_target_ratio{env=\"prod\",state=\"ignored\",team=\"infra\"} 0.000000\n",
+		"\nacme_state{state=\"suppressed\",env=\"prod\",team=\"infra\"} 1\n",
+		"acme_instance_info{instance_id=\"ocid1.instance.oc1..abc\"," +
+			"display_name=\"web {1}\",env=\"prod\",state=\"ignored\",team=\"infra\"} 1\n",
+		"acme_suppression_episode_duration_seconds_bucket{le=\"60\",env=",
+		"\ngo_goroutines{env=",
+		"# EOF\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in labelled output, got %s", want, output)
+		}
+	}
+
+	for line := range strings.Lines(output) {
+		if !strings.HasPrefix(line, "#") && !strings.Contains(line, "team=\"infra\"") {
+			t.Fatalf("expected every series to carry the static labels, got %q", line)
+		}
+	}
+
+	err = exporter.SetStaticLabels(nil)
+	if err != nil {
+		t.Fatalf("SetStaticLabels(nil): %v", err)
+	}
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "team=") {
+		t.Fatalf("expected clearing the static labels to remove them, got %s", data)
+	}
+}
+
+func TestExporterRejectsInvalidStaticLabels(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	err := exporter.SetStaticLabels(map[string]string{"team": "infra"})
+	if err != nil {
+		t.Fatalf("SetStaticLabels: %v", err)
+	}
+
+	tooMany := make(map[string]string)
+	for index := range metrics.MaxStaticLabels + 1 {
+		tooMany[fmt.Sprintf("label%d", index)] = "value"
+	}
+
+	for _, labels := range []map[string]string{
+		{"": "x"},
+		{"__name__": "x"},
+		{"9team": "x"},
+		{"team-name": "x"},
+		{"équipe": "x"},
+		tooMany,
+	} {
+		err = exporter.SetStaticLabels(labels)
+		if !errors.Is(err, metrics.ErrInvalidStaticLabel) {
+			t.Fatalf("SetStaticLabels(%v) = %v, want ErrInvalidStaticLabel", labels, err)
+		}
+	}
+
+	err = metrics.ValidateStaticLabels(map[string]string{"_role": "db", "Env2": ""})
+	if err != nil {
+		t.Fatalf("ValidateStaticLabels: %v", err)
+	}
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "\nshaper_target_ratio{team=\"infra\"} 0.000000\n") {
+		t.Fatalf("expected a rejected set to keep the current labels, got %s", data)
+	}
+}
+
+func TestExporterRendersSuppressionEpisodes(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+
+	data, err := exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if strings.Contains(string(data), "shaper_suppression_episodes_total") {
+		t.Fatalf("expected no episode series before the first episode, got %s", data)
+	}
+
+	exporter.ObserveSuppressionEpisode(90*time.Second, 27*time.Second)
+	exporter.ObserveSuppressionEpisode(5*time.Hour, time.Hour)
+
+	data, err = exporter.Render()
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	output := string(data)
+	for _, want := range []string{
+		"# TYPE shaper_suppression_episodes counter\n",
+		"shaper_suppression_episodes_total 2\n",
+		"shaper_suppression_deficit_seconds_total 3627.000\n",
+		"# TYPE shaper_suppression_episode_duration_seconds histogram\n",
+		"shaper_suppression_episode_duration_seconds_bucket{le=\"60\"} 0\n",
+		"shaper_suppression_episode_duration_seconds_bucket{le=\"300\"} 1\n",
+		"shaper_suppression_episode_duration_seconds_bucket{le=\"14400\"} 1\n",
+		"shaper_suppression_episode_duration_seconds_bucket{le=\"+Inf\"} 2\n",
+		"shaper_suppression_episode_duration_seconds_sum 18090.000\n",
+		"shaper_suppression_episode_duration_seconds_count 2\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output, got %s", want, output)
+		}
+	}
+}
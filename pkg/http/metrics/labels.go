@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Label guards keep scrapes small on tiny instances even when a label value
+// comes from outside the process, such as an instance display name.
+const (
+	// MaxLabelValueLength caps label values in bytes; longer values are
+	// truncated at a rune boundary.
+	MaxLabelValueLength = 128
+	// MaxLabelValues caps the distinct values a labelled series family keeps.
+	// Further values are folded into OverflowLabelValue.
+	MaxLabelValues = 16
+	// OverflowLabelValue replaces label values beyond MaxLabelValues.
+	OverflowLabelValue = "other"
+)
+
+// sanitizeLabelValue trims value, replaces invalid UTF-8, drops control
+// characters, and truncates it to MaxLabelValueLength. It reports whether the
+// value had to be altered beyond trimming.
+func sanitizeLabelValue(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, strings.ToValidUTF8(trimmed, string(utf8.RuneError)))
+
+	if len(cleaned) > MaxLabelValueLength {
+		cut := MaxLabelValueLength
+		for cut > 0 && !utf8.RuneStart(cleaned[cut]) {
+			cut--
+		}
+
+		cleaned = cleaned[:cut]
+	}
+
+	return cleaned, cleaned != trimmed
+}
+
+// labelLocked sanitizes value and counts it when it had to be altered.
+func (e *Exporter) labelLocked(value string) string {
+	cleaned, altered := sanitizeLabelValue(value)
+	if altered {
+		e.labelsCapped++
+	}
+
+	return cleaned
+}
+
+// labelKeyLocked sanitizes value for use as a key of a labelled family and
+// folds it into OverflowLabelValue once the family is full. One slot stays
+// reserved for the overflow key, so a family never exceeds MaxLabelValues.
+func labelKeyLocked[V any](e *Exporter, family map[string]V, value string) string {
+	key := e.labelLocked(value)
+
+	if _, ok := family[key]; ok || len(family) < MaxLabelValues-1 {
+		return key
+	}
+
+	e.labelsCapped++
+
+	return OverflowLabelValue
+}
+
+// capAPIUsage applies the label guards to usages reported by the API usage
+// source, which is read on every scrape.
+func capAPIUsage(usages []APIUsage) []APIUsage {
+	capped := make([]APIUsage, 0, min(len(usages), MaxLabelValues))
+
+	for _, usage := range usages {
+		if len(capped) == MaxLabelValues {
+			break
+		}
+
+		usage.API, _ = sanitizeLabelValue(usage.API)
+		capped = append(capped, usage)
+	}
+
+	return capped
+}
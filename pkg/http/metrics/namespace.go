@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultNamespace is the metric namespace the exporter renders unless
+// SetNamespace overrides it. Its series keep their historical names.
+const DefaultNamespace = "shaper"
+
+// runtimePrefix marks the Go runtime series, which keep their conventional
+// names in every namespace.
+const runtimePrefix = "go_"
+
+// ErrInvalidNamespace signals a namespace that is not a valid metric name
+// prefix.
+var ErrInvalidNamespace = errors.New("metrics: invalid namespace")
+
+// ValidateNamespace reports whether namespace can prefix metric names: a
+// letter or underscore followed by letters, digits, or underscores, without a
+// trailing underscore.
+func ValidateNamespace(namespace string) error {
+	if namespace == "" || strings.HasSuffix(namespace, "_") {
+		return fmt.Errorf("%w: %q", ErrInvalidNamespace, namespace)
+	}
+
+	for index, r := range namespace {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && index > 0:
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidNamespace, namespace)
+		}
+	}
+
+	return nil
+}
+
+// SetNamespace renders every shaper series under namespace, so several shaper
+// variants or an organisation's naming convention can share one Prometheus.
+// Series named shaper_* swap that prefix for namespace_, the remaining ones
+// (oci_p95, host_cpu_percent, estimator_*, ...) gain it, and the Go runtime
+// series are left alone. Label values, such as the metric label of
+// shaper_metric_age_seconds, keep the default names. An invalid namespace is
+// rejected and leaves the current one in place.
+func (e *Exporter) SetNamespace(namespace string) error {
+	err := ValidateNamespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.namespace = namespace
+	e.mu.Unlock()
+
+	return nil
+}
+
+// namespaceLine renames the metric of one exposition line into namespace.
+func namespaceLine(line, namespace string) string {
+	if namespace == "" || namespace == DefaultNamespace {
+		return line
+	}
+
+	for _, prefix := range []string{"# HELP ", "# TYPE "} {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			return prefix + namespaceName(rest, namespace)
+		}
+	}
+
+	if strings.HasPrefix(line, "#") {
+		return line
+	}
+
+	return namespaceName(line, namespace)
+}
+
+// namespaceName renames the metric name that starts text.
+func namespaceName(text, namespace string) string {
+	if strings.HasPrefix(text, runtimePrefix) {
+		return text
+	}
+
+	if rest, ok := strings.CutPrefix(text, DefaultNamespace+"_"); ok {
+		return namespace + "_" + rest
+	}
+
+	return namespace + "_" + text
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// SetScrapeSampler makes scrapes refresh host_cpu_percent with an on-demand
+// sample from sample, taken at most once per minInterval, so the series
+// reflects the host load at scrape time rather than at the last estimator
+// tick and lines up with node exporters scraped at the same moment. Scrapes
+// within minInterval of the last sample, and failed samples, keep the current
+// value. A nil sample or a non-positive minInterval disables scrape sampling.
+func (e *Exporter) SetScrapeSampler(
+	sample func(ctx context.Context) (float64, error),
+	minInterval time.Duration,
+) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if minInterval <= 0 {
+		sample = nil
+	}
+
+	e.scrapeSample = sample
+	e.scrapeInterval = minInterval
+	e.scrapeSampled = time.Time{}
+}
+
+// sampleOnScrape refreshes host_cpu_percent when a scrape sampler is set and
+// its interval has elapsed. Scrapes arriving while a sample is taken render
+// the previous value rather than sampling again.
+func (e *Exporter) sampleOnScrape(ctx context.Context) {
+	e.mu.Lock()
+	sample := e.scrapeSample
+	now := e.clock()
+
+	if sample == nil ||
+		(!e.scrapeSampled.IsZero() && now.Sub(e.scrapeSampled) < e.scrapeInterval) {
+		e.mu.Unlock()
+
+		return
+	}
+
+	e.scrapeSampled = now
+	e.mu.Unlock()
+
+	utilisation, err := sample(ctx)
+	if err != nil {
+		return
+	}
+
+	e.ObserveHostCPU(utilisation)
+}
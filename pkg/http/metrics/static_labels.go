@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MaxStaticLabels caps the labels SetStaticLabels attaches to every series, so
+// a configuration mistake cannot multiply the size of each scrape.
+const MaxStaticLabels = 8
+
+// ErrInvalidStaticLabel signals a static label set with an invalid or reserved
+// label name, or more than MaxStaticLabels labels.
+var ErrInvalidStaticLabel = errors.New("metrics: invalid static label")
+
+// staticLabel is one static label, its value sanitized and escaped.
+type staticLabel struct {
+	name  string
+	value string
+}
+
+// ValidateStaticLabels reports whether labels can be attached to every series:
+// at most MaxStaticLabels names, each a letter or underscore followed by
+// letters, digits, or underscores, and none starting with the reserved "__".
+func ValidateStaticLabels(labels map[string]string) error {
+	if len(labels) > MaxStaticLabels {
+		return fmt.Errorf(
+			"%w: %d labels, at most %d allowed",
+			ErrInvalidStaticLabel, len(labels), MaxStaticLabels,
+		)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		if !validLabelName(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidStaticLabel, name)
+		}
+	}
+
+	return nil
+}
+
+func validLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+
+	for index, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && index > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetStaticLabels attaches labels, such as team, env, or role, to every
+// exported series, so fleets can slice shaper metrics by ownership without a
+// relabel rule in each scrape configuration. A series keeps its own value for
+// a label it already carries, such as state on shaper_state or le on histogram
+// buckets. Values pass the same guards as other label values. An invalid set
+// is rejected and leaves the current labels in place; an empty one removes
+// them.
+func (e *Exporter) SetStaticLabels(labels map[string]string) error {
+	err := ValidateStaticLabels(labels)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rendered := make([]staticLabel, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		rendered = append(rendered, staticLabel{
+			name:  name,
+			value: escapeLabelValue(e.labelLocked(labels[name])),
+		})
+	}
+
+	e.staticLabels = rendered
+
+	return nil
+}
+
+// staticLabelLine appends labels to the sample of one exposition line,
+// skipping those the series already carries.
+func staticLabelLine(line string, labels []staticLabel) string {
+	if len(labels) == 0 || strings.HasPrefix(line, "#") {
+		return line
+	}
+
+	nameEnd := strings.IndexAny(line, "{ ")
+	if nameEnd < 0 {
+		return line
+	}
+
+	own, rest := "", line[nameEnd:]
+
+	var names []string
+
+	if line[nameEnd] == '{' {
+		var closing int
+
+		closing, names = scanLabelNames(line, nameEnd)
+		if closing < 0 {
+			return line
+		}
+
+		own, rest = line[nameEnd+1:closing], line[closing+1:]
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(line[:nameEnd])
+	builder.WriteByte('{')
+	builder.WriteString(own)
+
+	separate := own != ""
+
+	for _, label := range labels {
+		if slices.Contains(names, label.name) {
+			continue
+		}
+
+		if separate {
+			builder.WriteByte(',')
+		}
+
+		builder.WriteString(label.name + "=\"" + label.value + "\"")
+
+		separate = true
+	}
+
+	builder.WriteByte('}')
+	builder.WriteString(rest)
+
+	return builder.String()
+}
+
+// scanLabelNames returns the index of the brace closing the label set that
+// opens at open, or -1 when it is unterminated, and the label names it holds.
+func scanLabelNames(line string, open int) (int, []string) {
+	var names []string
+
+	start, quoted := open+1, false
+
+	for index := open + 1; index < len(line); index++ {
+		switch char := line[index]; {
+		case quoted && char == '\\':
+			index++
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == '=':
+			names = append(names, strings.TrimSpace(line[start:index]))
+		case char == ',':
+			start = index + 1
+		case char == '}':
+			return index, names
+		}
+	}
+
+	return -1, names
+}
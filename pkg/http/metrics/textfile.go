@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// TextfileName is the file TextfileWriter maintains in a node_exporter
+// textfile collector directory, which only reads files ending in ".prom".
+const TextfileName = "oci_cpu_shaper.prom"
+
+// DefaultTextfileInterval matches node_exporter's usual scrape interval, so
+// each scrape sees a fresh snapshot.
+const DefaultTextfileInterval = 15 * time.Second
+
+// textfileMode lets node_exporter, which usually runs as another user, read
+// the file.
+const textfileMode = 0o644
+
+// TextfileWriter writes the exporter's series into a node_exporter textfile
+// collector directory, so hosts that already run node_exporter can collect
+// them without another listener.
+type TextfileWriter struct {
+	exporter *Exporter
+	path     string
+
+	handlerMu sync.RWMutex
+	logger    logging.Logger
+}
+
+// NewTextfileWriter constructs a TextfileWriter that writes exporter's series
+// to TextfileName in dir.
+func NewTextfileWriter(exporter *Exporter, dir string) *TextfileWriter {
+	return &TextfileWriter{exporter: exporter, path: filepath.Join(dir, TextfileName)}
+}
+
+// SetLogger installs the logger used for write failures.
+func (w *TextfileWriter) SetLogger(logger logging.Logger) {
+	w.handlerMu.Lock()
+	defer w.handlerMu.Unlock()
+
+	w.logger = logger
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (w *TextfileWriter) log() logging.Logger {
+	w.handlerMu.RLock()
+	defer w.handlerMu.RUnlock()
+
+	return logging.OrNop(w.logger)
+}
+
+// Path returns the file the writer maintains.
+func (w *TextfileWriter) Path() string {
+	return w.path
+}
+
+// Run writes the file immediately and then every interval until ctx is done,
+// and removes it on the way out so node_exporter does not keep serving the
+// series of a stopped daemon.
+func (w *TextfileWriter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.Write()
+		if err != nil {
+			w.log().Warn("failed to write metrics textfile", "path", w.path, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			err = os.Remove(w.path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				w.log().Warn("failed to remove metrics textfile", "path", w.path, "error", err)
+			}
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Write renders the current series and replaces the file through a rename, so
+// node_exporter never reads a partial snapshot. The Go runtime series are left
+// out because node_exporter exports its own under the same names.
+func (w *TextfileWriter) Write() error {
+	snapshot := w.exporter.snapshot()
+	snapshot.runtimeMetrics = false
+
+	var buffer bytes.Buffer
+
+	_, err := w.exporter.writeSnapshot(&buffer, snapshot)
+	if err != nil {
+		return err
+	}
+
+	// The leading dot and missing ".prom" suffix keep the collector from
+	// reading the temporary file.
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), "."+TextfileName+".*")
+	if err != nil {
+		return fmt.Errorf("create metrics textfile: %w", err)
+	}
+
+	_, err = tmp.Write(buffer.Bytes())
+	if err == nil {
+		err = tmp.Chmod(textfileMode)
+	}
+
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), w.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("write metrics textfile: %w", err)
+	}
+
+	return nil
+}
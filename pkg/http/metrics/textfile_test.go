@@ -0,0 +1,127 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metrics "oci-cpu-shaper/pkg/http/metrics"
+)
+
+func TestTextfileWriterWritesReadableSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	exporter := metrics.NewExporter()
+	exporter.SetRuntimeMetricsEnabled(true)
+	exporter.SetTarget(0.3)
+
+	writer := metrics.NewTextfileWriter(exporter, dir)
+
+	err := writer.Write()
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if writer.Path() != filepath.Join(dir, metrics.TextfileName) {
+		t.Fatalf("unexpected path %q", writer.Path())
+	}
+
+	info, err := os.Stat(writer.Path())
+	if err != nil {
+		t.Fatalf("stat textfile: %v", err)
+	}
+
+	if info.Mode().Perm() != 0o644 {
+		t.Fatalf("expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(writer.Path())
+	if err != nil {
+		t.Fatalf("read textfile: %v", err)
+	}
+
+	if !strings.Contains(string(data), "shaper_target_ratio 0.300000\n") {
+		t.Fatalf("expected the target in the textfile, got %s", data)
+	}
+
+	if strings.Contains(string(data), "go_goroutines") {
+		t.Fatalf("expected no Go runtime series in the textfile, got %s", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected only the textfile in %s, got %v (err=%v)", dir, entries, err)
+	}
+}
+
+func TestTextfileWriterRunRefreshesAndRemovesFile(t *testing.T) {
+	t.Parallel()
+
+	exporter := metrics.NewExporter()
+	writer := metrics.NewTextfileWriter(exporter, t.TempDir())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		writer.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	exporter.SetTarget(0.45)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(writer.Path())
+		if strings.Contains(string(data), "shaper_target_ratio 0.450000\n") {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the textfile to pick up the new target, got %s", data)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	_, err := os.Stat(writer.Path())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the textfile to be removed on exit, got %v", err)
+	}
+}
+
+func TestTextfileWriterReportsWriteFailures(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+
+	writer := metrics.NewTextfileWriter(
+		metrics.NewExporter(),
+		filepath.Join(t.TempDir(), "missing"),
+	)
+	writer.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	err := writer.Write()
+	if err == nil {
+		t.Fatal("expected writing into a missing directory to fail")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	writer.Run(ctx, time.Hour)
+
+	if !strings.Contains(logs.String(), "failed to write metrics textfile") {
+		t.Fatalf("expected the failure to be logged, got %s", logs.String())
+	}
+}
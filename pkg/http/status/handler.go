@@ -3,8 +3,11 @@ package status
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
 )
 
 // Controller exposes the status surface required by the health handler.
@@ -14,16 +17,46 @@ type Controller interface {
 	LastEstimatorError() error
 }
 
+// HostSampler is implemented by controllers that can report the latest host
+// utilisation sample on demand.
+type HostSampler interface {
+	CurrentObservation() (est.Observation, bool)
+}
+
+// AlarmSilenceReporter is implemented by controllers that know whether the
+// guardrail alarm is suppressed (see adapt.AdaptiveController.SetAlarmSilence).
+type AlarmSilenceReporter interface {
+	AlarmSilencedUntil() time.Time
+}
+
+// ComponentReporter aggregates the health of the daemon's components; see
+// health.Registry.
+type ComponentReporter interface {
+	Report() health.Report
+}
+
 // Snapshot captures the controller status returned by the handler.
 type Snapshot struct {
-	State          string `json:"state"`
-	LastOCIError   string `json:"ociError"`
-	EstimatorError string `json:"estimatorError"`
+	State           string   `json:"state"`
+	LastOCIError    string   `json:"ociError"`
+	EstimatorError  string   `json:"estimatorError"`
+	HostUtilisation *float64 `json:"hostUtilisation,omitempty"`
+	HostSampledAt   string   `json:"hostSampledAt,omitempty"`
+	// Target and OCIP95 come from controllers implementing adapt.Introspector;
+	// OCIP95 is omitted until the first successful Monitoring query.
+	Target *float64 `json:"target,omitempty"`
+	OCIP95 *float64 `json:"ociP95,omitempty"`
+	// AlarmSilencedUntil is set while the guardrail alarm is suppressed.
+	AlarmSilencedUntil string `json:"alarmSilencedUntil,omitempty"`
+	// Status and Components are set once SetComponents installs a reporter.
+	Status     health.Status               `json:"status,omitempty"`
+	Components map[string]health.Component `json:"components,omitempty"`
 }
 
 // Handler renders controller health information as JSON.
 type Handler struct {
 	controller Controller
+	components ComponentReporter
 }
 
 // NewHandler constructs a Handler that proxies controller status.
@@ -31,6 +64,12 @@ func NewHandler(controller Controller) *Handler {
 	return &Handler{controller: controller}
 }
 
+// SetComponents adds the aggregated component health from reporter to the
+// document. A nil reporter omits it.
+func (h *Handler) SetComponents(reporter ComponentReporter) {
+	h.components = reporter
+}
+
 // ServeHTTP implements http.Handler.
 func (h *Handler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
 	if h == nil || h.controller == nil {
@@ -40,9 +79,17 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
 	}
 
 	snapshot := Snapshot{
-		State:          h.controller.State().String(),
-		LastOCIError:   "",
-		EstimatorError: "",
+		State:           h.controller.State().String(),
+		LastOCIError:    "",
+		EstimatorError:  "",
+		HostUtilisation: nil,
+		HostSampledAt:   "",
+		Target:          nil,
+		OCIP95:          nil,
+
+		AlarmSilencedUntil: "",
+		Status:             "",
+		Components:         nil,
 	}
 
 	lastOCIError := h.controller.LastError()
@@ -55,6 +102,37 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
 		snapshot.EstimatorError = estimatorErr.Error()
 	}
 
+	if sampler, ok := h.controller.(HostSampler); ok {
+		observation, available := sampler.CurrentObservation()
+		if available && observation.Err == nil {
+			utilisation := observation.Utilisation
+			snapshot.HostUtilisation = &utilisation
+			snapshot.HostSampledAt = observation.Timestamp.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if introspector, ok := h.controller.(adapt.Introspector); ok {
+		target := introspector.Target()
+		snapshot.Target = &target
+
+		if p95 := introspector.LastP95(); p95 > 0 {
+			snapshot.OCIP95 = &p95
+		}
+	}
+
+	if reporter, ok := h.controller.(AlarmSilenceReporter); ok {
+		until := reporter.AlarmSilencedUntil()
+		if !until.IsZero() {
+			snapshot.AlarmSilencedUntil = until.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if h.components != nil {
+		report := h.components.Report()
+		snapshot.Status = report.Status
+		snapshot.Components = report.Components
+	}
+
 	payload, err := json.Marshal(snapshot)
 	if err != nil {
 		http.Error(writer, "marshal status", http.StatusInternalServerError)
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/est"
+	"oci-cpu-shaper/pkg/health"
 	status "oci-cpu-shaper/pkg/http/status"
 )
 
@@ -94,3 +97,170 @@ func TestHandlerWithoutControllerReturnsServiceUnavailable(t *testing.T) {
 		t.Fatalf("expected 503 Service Unavailable, got %d", recorder.Code)
 	}
 }
+
+type samplingController struct {
+	stubController
+
+	observation est.Observation
+	available   bool
+}
+
+func (s *samplingController) CurrentObservation() (est.Observation, bool) {
+	return s.observation, s.available
+}
+
+func TestHandlerIncludesHostUtilisationSnapshot(t *testing.T) {
+	t.Parallel()
+
+	controller := &samplingController{
+		stubController: stubController{state: adapt.StateNormal},
+		observation: est.Observation{
+			Timestamp:    time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			Utilisation:  0.42,
+			BusyJiffies:  42,
+			TotalJiffies: 100,
+			Err:          nil,
+		},
+		available: true,
+	}
+
+	snapshot := serveSnapshot(t, controller)
+
+	if snapshot.HostUtilisation == nil || *snapshot.HostUtilisation != 0.42 {
+		t.Fatalf("expected host utilisation 0.42, got %v", snapshot.HostUtilisation)
+	}
+
+	if snapshot.HostSampledAt != "2024-06-01T12:00:00Z" {
+		t.Fatalf("unexpected sample timestamp %q", snapshot.HostSampledAt)
+	}
+
+	controller.observation.Err = errEstimatorStalled
+
+	snapshot = serveSnapshot(t, controller)
+	if snapshot.HostUtilisation != nil || snapshot.HostSampledAt != "" {
+		t.Fatalf("expected host utilisation to be omitted for failed samples, got %+v", snapshot)
+	}
+}
+
+type introspectedController struct {
+	stubController
+
+	target float64
+	p95    float64
+}
+
+func (i *introspectedController) Target() float64 { return i.target }
+
+func (i *introspectedController) LastP95() float64 { return i.p95 }
+
+func TestHandlerReportsTargetAndP95(t *testing.T) {
+	t.Parallel()
+
+	controller := &introspectedController{
+		stubController: stubController{state: adapt.StateNormal},
+		target:         0.3,
+		p95:            0,
+	}
+
+	snapshot := serveSnapshot(t, controller)
+	if snapshot.Target == nil || *snapshot.Target != 0.3 || snapshot.OCIP95 != nil {
+		t.Fatalf("expected target without p95 before the first query, got %+v", snapshot)
+	}
+
+	controller.p95 = 0.24
+
+	snapshot = serveSnapshot(t, controller)
+	if snapshot.OCIP95 == nil || *snapshot.OCIP95 != 0.24 {
+		t.Fatalf("expected p95 0.24, got %v", snapshot.OCIP95)
+	}
+
+	snapshot = serveSnapshot(t, &controller.stubController)
+	if snapshot.Target != nil {
+		t.Fatalf("expected target to be omitted, got %v", *snapshot.Target)
+	}
+}
+
+type silencedController struct {
+	stubController
+
+	until time.Time
+}
+
+func (s *silencedController) AlarmSilencedUntil() time.Time { return s.until }
+
+func TestHandlerReportsAlarmSilence(t *testing.T) {
+	t.Parallel()
+
+	controller := &silencedController{
+		stubController: stubController{state: adapt.StateNormal},
+		until:          time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC),
+	}
+
+	snapshot := serveSnapshot(t, controller)
+	if snapshot.AlarmSilencedUntil != "2024-06-01T14:00:00Z" {
+		t.Fatalf("unexpected alarm silence %q", snapshot.AlarmSilencedUntil)
+	}
+
+	controller.until = time.Time{}
+
+	snapshot = serveSnapshot(t, controller)
+	if snapshot.AlarmSilencedUntil != "" {
+		t.Fatalf("expected alarm silence to be omitted, got %q", snapshot.AlarmSilencedUntil)
+	}
+}
+
+func TestHandlerReportsComponentHealth(t *testing.T) {
+	t.Parallel()
+
+	registry := health.NewRegistry()
+	registry.Register(health.ComponentOCI, func() (health.Status, string) {
+		return health.StatusDegraded, errMetricsUnavailable.Error()
+	})
+	registry.Disable(health.ComponentPool)
+
+	handler := status.NewHandler(&stubController{state: adapt.StateFallback})
+	handler.SetComponents(registry)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected a degraded process to answer 200, got %d", recorder.Code)
+	}
+
+	var snapshot status.Snapshot
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &snapshot)
+	if err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if snapshot.Status != health.StatusDegraded ||
+		snapshot.Components[health.ComponentOCI].Detail != errMetricsUnavailable.Error() ||
+		snapshot.Components[health.ComponentPool].Status != health.StatusDisabled {
+		t.Fatalf("unexpected component health %+v", snapshot)
+	}
+
+	snapshot = serveSnapshot(t, &stubController{state: adapt.StateNormal})
+	if snapshot.Status != "" || snapshot.Components != nil {
+		t.Fatalf("expected component health to be omitted without a reporter, got %+v", snapshot)
+	}
+}
+
+func serveSnapshot(t *testing.T, controller status.Controller) status.Snapshot {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+
+	status.NewHandler(controller).ServeHTTP(recorder, request)
+
+	var snapshot status.Snapshot
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &snapshot)
+	if err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return snapshot
+}
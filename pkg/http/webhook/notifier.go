@@ -0,0 +1,197 @@
+// Package webhook forwards controller decisions to an external HTTP endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+)
+
+// DefaultTimeout bounds each webhook delivery when no timeout is configured.
+const DefaultTimeout = 5 * time.Second
+
+var (
+	errURLRequired       = errors.New("webhook: url is required")
+	errUnsupportedScheme = errors.New("webhook: url scheme must be http or https")
+	errUnexpectedStatus  = errors.New("webhook: unexpected status code")
+)
+
+// Payload is the JSON document posted after each controller step.
+type Payload struct {
+	Timestamp    time.Time `json:"timestamp"`
+	ResourceID   string    `json:"resourceId"`
+	Mode         string    `json:"mode"`
+	State        string    `json:"state"`
+	P95          float64   `json:"p95"`
+	Target       float64   `json:"target"`
+	NextInterval string    `json:"nextInterval"`
+	Error        string    `json:"error"`
+}
+
+// Notifier posts controller decisions to a configured URL.
+type Notifier struct {
+	endpoint string
+	client   *http.Client
+	timeout  time.Duration
+
+	mu           sync.Mutex
+	errorHandler func(error)
+	inflight     sync.WaitGroup
+}
+
+var _ adapt.DecisionObserver = (*Notifier)(nil)
+
+// NewNotifier validates the endpoint and constructs a Notifier. A nil client uses
+// http.DefaultClient and a non-positive timeout falls back to DefaultTimeout.
+func NewNotifier(endpoint string, client *http.Client, timeout time.Duration) (*Notifier, error) {
+	trimmed := strings.TrimSpace(endpoint)
+	if trimmed == "" {
+		return nil, errURLRequired
+	}
+
+	parsed, err := url.Parse(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: parse url: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedScheme, parsed.Scheme)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	notifier := new(Notifier)
+	notifier.endpoint = trimmed
+	notifier.client = client
+	notifier.timeout = timeout
+	notifier.SetErrorHandler(nil)
+
+	return notifier, nil
+}
+
+// SetErrorHandler installs a hook invoked when an asynchronous delivery fails.
+//
+// A nil handler resets the hook to a no-op.
+func (n *Notifier) SetErrorHandler(handler func(error)) {
+	if handler == nil {
+		handler = func(error) {}
+	}
+
+	n.mu.Lock()
+	n.errorHandler = handler
+	n.mu.Unlock()
+}
+
+// ObserveDecision implements adapt.DecisionObserver by delivering the decision in the
+// background so slow endpoints never delay the control loop.
+func (n *Notifier) ObserveDecision(decision adapt.Decision) {
+	n.inflight.Add(1)
+
+	go func() {
+		defer n.inflight.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		defer cancel()
+
+		err := n.Post(ctx, decision)
+		if err != nil {
+			n.mu.Lock()
+			handler := n.errorHandler
+			n.mu.Unlock()
+
+			handler(err)
+		}
+	}()
+}
+
+// Wait blocks until all background deliveries have completed.
+func (n *Notifier) Wait() {
+	n.inflight.Wait()
+}
+
+// WaitTimeout waits up to timeout for background deliveries to complete and
+// reports whether they all finished. Shutdown uses it so the final decisions
+// are delivered without letting a stalled endpoint hold the process open.
+func (n *Notifier) WaitTimeout(timeout time.Duration) bool {
+	done := make(chan struct{})
+
+	go func() {
+		n.inflight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Post synchronously delivers the decision payload to the configured endpoint.
+func (n *Notifier) Post(ctx context.Context, decision adapt.Decision) error {
+	body, err := json.Marshal(NewPayload(decision))
+	if err != nil {
+		return fmt.Errorf("webhook: encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: post decision: %w", err)
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// NewPayload converts a controller decision into its JSON representation.
+func NewPayload(decision adapt.Decision) Payload {
+	payload := Payload{
+		Timestamp:    decision.Timestamp.UTC(),
+		ResourceID:   decision.ResourceID,
+		Mode:         decision.Mode,
+		State:        decision.State.String(),
+		P95:          decision.P95,
+		Target:       decision.Target,
+		NextInterval: decision.NextInterval.String(),
+		Error:        "",
+	}
+
+	if decision.Err != nil {
+		payload.Error = decision.Err.Error()
+	}
+
+	return payload
+}
@@ -0,0 +1,201 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/http/webhook"
+)
+
+var errStepFailed = errors.New("step failed")
+
+func TestNewNotifierValidatesEndpoint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		endpoint string
+		want     string
+	}{
+		{name: "empty", endpoint: "  ", want: "url is required"},
+		{name: "scheme", endpoint: "ftp://example.com/hook", want: "scheme must be http or https"},
+		{name: "malformed", endpoint: "http://[::1", want: "parse url"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := webhook.NewNotifier(testCase.endpoint, nil, 0)
+			if err == nil || !strings.Contains(err.Error(), testCase.want) {
+				t.Fatalf("expected error containing %q, got %v", testCase.want, err)
+			}
+		})
+	}
+}
+
+func TestNotifierObserveDecisionPostsPayload(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan webhook.Payload, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected JSON content type, got %q", got)
+		}
+
+		var payload webhook.Payload
+
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+
+		received <- payload
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	notifier, err := webhook.NewNotifier(" "+server.URL+" ", server.Client(), time.Second)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	notifier.ObserveDecision(adapt.Decision{
+		StepID:       "",
+		Timestamp:    time.Unix(1_700_000_000, 0),
+		ResourceID:   "ocid1.instance.oc1..hook",
+		Mode:         "enforce",
+		State:        adapt.StateFallback,
+		P95:          0,
+		Target:       0.25,
+		NextInterval: time.Hour,
+		Err:          errStepFailed,
+	})
+	notifier.Wait()
+
+	payload := <-received
+	if payload.State != "fallback" || payload.ResourceID != "ocid1.instance.oc1..hook" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+
+	if payload.NextInterval != "1h0m0s" || payload.Error != errStepFailed.Error() {
+		t.Fatalf("unexpected interval or error: %+v", payload)
+	}
+
+	if !payload.Timestamp.Equal(time.Unix(1_700_000_000, 0)) {
+		t.Fatalf("unexpected timestamp: %v", payload.Timestamp)
+	}
+}
+
+func TestNotifierReportsDeliveryFailures(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+
+	notifier, err := webhook.NewNotifier(server.URL, server.Client(), 0)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		failures []error
+	)
+
+	notifier.SetErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		failures = append(failures, err)
+	})
+
+	notifier.ObserveDecision(normalDecision())
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "unexpected status code: 502") {
+		t.Fatalf("expected a single 502 failure, got %v", failures)
+	}
+}
+
+func TestNotifierWaitTimeoutBoundsStalledDeliveries(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	notifier, err := webhook.NewNotifier(server.URL, server.Client(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	notifier.ObserveDecision(normalDecision())
+
+	if notifier.WaitTimeout(20 * time.Millisecond) {
+		t.Fatal("expected WaitTimeout to give up on a stalled delivery")
+	}
+
+	release <- struct{}{}
+
+	if !notifier.WaitTimeout(time.Second) {
+		t.Fatal("expected WaitTimeout to report the released delivery as finished")
+	}
+}
+
+func TestNotifierPostHonoursContext(t *testing.T) {
+	t.Parallel()
+
+	notifier, err := webhook.NewNotifier("http://127.0.0.1:1/hook", nil, time.Second)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	notifier.SetErrorHandler(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = notifier.Post(ctx, normalDecision())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+}
+
+func normalDecision() adapt.Decision {
+	return adapt.Decision{
+		StepID:       "",
+		Timestamp:    time.Unix(0, 0),
+		ResourceID:   "",
+		Mode:         "dry-run",
+		State:        adapt.StateNormal,
+		P95:          0.2,
+		Target:       0.27,
+		NextInterval: time.Hour,
+		Err:          nil,
+	}
+}
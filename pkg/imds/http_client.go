@@ -106,6 +106,7 @@ type HTTPClient struct {
 	baseURL    string
 	maxAttempt int
 	backoff    time.Duration
+	flights    flightGroup
 }
 
 // Region returns the canonical region for the running instance.
@@ -162,6 +163,34 @@ func (c *HTTPClient) ShapeConfig(ctx context.Context) (ShapeConfig, error) {
 	return cfg, nil
 }
 
+// MaintenanceRebootDue returns the timeMaintenanceRebootDue attribute of the
+// instance document, or the zero time while no maintenance is scheduled.
+func (c *HTTPClient) MaintenanceRebootDue(ctx context.Context) (time.Time, error) {
+	payload, err := c.fetch(ctx, "")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var document instanceDocument
+
+	err = json.Unmarshal(payload, &document)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decode instance response: %w", err)
+	}
+
+	due := strings.TrimSpace(document.TimeMaintenanceRebootDue)
+	if due == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse timeMaintenanceRebootDue: %w", err)
+	}
+
+	return parsed, nil
+}
+
 func (c *HTTPClient) getText(ctx context.Context, resource string) (string, error) {
 	payload, err := c.fetch(ctx, resource)
 	if err != nil {
@@ -185,7 +214,14 @@ func (c *HTTPClient) getJSON(ctx context.Context, resource string, out any) erro
 	return nil
 }
 
+// fetch retrieves resource, sharing one request between concurrent callers.
 func (c *HTTPClient) fetch(ctx context.Context, resource string) ([]byte, error) {
+	return c.flights.do(ctx, resource, func() ([]byte, error) {
+		return c.fetchWithRetry(ctx, resource)
+	})
+}
+
+func (c *HTTPClient) fetchWithRetry(ctx context.Context, resource string) ([]byte, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= c.maxAttempt; attempt++ {
@@ -312,6 +348,10 @@ func metadataRequest(ctx context.Context, method, url string) (*http.Request, er
 	return req, nil
 }
 
+type instanceDocument struct {
+	TimeMaintenanceRebootDue string `json:"timeMaintenanceRebootDue"`
+}
+
 type regionInfo struct {
 	CanonicalRegionName string `json:"canonicalRegionName"`
 }
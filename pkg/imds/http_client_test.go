@@ -23,6 +23,7 @@ const (
 	shapeConfigResourcePath     = "/opc/v2/instance/shape-config"
 	canonicalRegionResourcePath = "/opc/v2/instance/regionInfo"
 	compartmentIDResourcePath   = "/opc/v2/instance/compartmentId"
+	instanceResourcePath        = "/opc/v2/instance/"
 	metadataAuthHeaderValue     = "Bearer Oracle"
 	authorizationHeaderKey      = "Authorization"
 )
@@ -415,8 +416,166 @@ func TestShapeConfigDecodeError(t *testing.T) {
 	}
 }
 
+func TestHTTPClientMaintenanceRebootDue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		body    string
+		want    time.Time
+		wantErr string
+	}{
+		{
+			name: "scheduled",
+			body: `{"id":"ocid1.instance.oc1..example",` +
+				`"timeMaintenanceRebootDue":"2024-06-01T12:30:00Z"}`,
+			want:    time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC),
+			wantErr: "",
+		},
+		{
+			name:    "absent",
+			body:    `{"id":"ocid1.instance.oc1..example"}`,
+			want:    time.Time{},
+			wantErr: "",
+		},
+		{
+			name:    "empty",
+			body:    `{"timeMaintenanceRebootDue":""}`,
+			want:    time.Time{},
+			wantErr: "",
+		},
+		{
+			name:    "malformed time",
+			body:    `{"timeMaintenanceRebootDue":"tomorrow"}`,
+			want:    time.Time{},
+			wantErr: "parse timeMaintenanceRebootDue",
+		},
+		{
+			name:    "not json",
+			body:    "not-json",
+			want:    time.Time{},
+			wantErr: "decode instance response",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newIMDSTestClient(t, map[string]string{instanceResourcePath: testCase.body})
+
+			got, err := client.MaintenanceRebootDue(context.Background())
+			if testCase.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("MaintenanceRebootDue() error = %v, want %q", err, testCase.wantErr)
+				}
+
+				return
+			}
+
+			requireNoError(t, err, "MaintenanceRebootDue()")
+
+			if !got.Equal(testCase.want) {
+				t.Fatalf("MaintenanceRebootDue() = %s, want %s", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestHTTPClientMaintenanceRebootDuePropagatesFetchError(t *testing.T) {
+	t.Parallel()
+
+	server := newIPv4TestServer(
+		t,
+		http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+			writer.WriteHeader(http.StatusNotFound)
+		}),
+	)
+	t.Cleanup(server.Close)
+
+	client := imds.NewClient(server.Client(), imds.WithBaseURL(server.URL+"/opc/v2"))
+
+	reader, ok := client.(imds.MaintenanceReader)
+	if !ok {
+		t.Fatalf("client %T does not implement imds.MaintenanceReader", client)
+	}
+
+	_, err := reader.MaintenanceRebootDue(context.Background())
+	if err == nil {
+		t.Fatal("MaintenanceRebootDue() expected error, got nil")
+	}
+}
+
 // newIPv4TestServer binds to the IPv4 loopback explicitly so tests still work when
 // the sandbox forbids listening on IPv6.
+func TestHTTPClientCoalescesConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	const callers = 8
+
+	var calls atomic.Int32
+
+	arrived := make(chan struct{}, callers)
+	release := make(chan struct{})
+
+	server := newIPv4TestServer(
+		t,
+		http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			arrived <- struct{}{}
+			<-release
+
+			_, _ = writer.Write([]byte("us-ashburn-1"))
+		}),
+	)
+	t.Cleanup(server.Close)
+
+	client := imds.NewClient(server.Client(), imds.WithBaseURL(server.URL+"/opc/v2"))
+
+	var (
+		started sync.WaitGroup
+		done    sync.WaitGroup
+	)
+
+	results := make(chan string, callers)
+
+	for range callers {
+		started.Add(1)
+		done.Add(1)
+
+		go func() {
+			defer done.Done()
+
+			started.Done()
+
+			region, err := client.Region(context.Background())
+			if err != nil {
+				t.Errorf("Region(): %v", err)
+			}
+
+			results <- region
+		}()
+	}
+
+	started.Wait()
+	<-arrived
+	// Give the remaining callers time to join the in-flight request.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+	close(results)
+
+	for region := range results {
+		requireEqual(t, "Region()", region, "us-ashburn-1")
+	}
+
+	requireEqual(t, "shared requests", calls.Load(), int32(1))
+
+	_, err := client.Region(context.Background())
+	requireNoError(t, err, "Region() after shared request")
+	requireEqual(t, "requests after completion", calls.Load(), int32(2))
+}
+
 func newIPv4TestServer(t *testing.T, handler http.Handler) *httptest.Server {
 	t.Helper()
 
@@ -1,7 +1,11 @@
 // Package imds contains clients for the OCI Instance Metadata Service (IMDSv2).
 package imds
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
 
 // DefaultEndpoint is the canonical IMDSv2 endpoint for OCI instances.
 const DefaultEndpoint = "http://169.254.169.254/opc/v2"
@@ -20,7 +24,17 @@ type Client interface {
 	ShapeConfig(ctx context.Context) (ShapeConfig, error)
 }
 
-// ShapeConfig contains the compute shape metadata exported by IMDSv2.
+// MaintenanceReader is implemented by clients that can read the maintenance
+// reboot OCI has scheduled for the instance.
+type MaintenanceReader interface {
+	// MaintenanceRebootDue returns when the instance is due to be rebooted or
+	// migrated for maintenance, or the zero time when nothing is scheduled.
+	MaintenanceRebootDue(ctx context.Context) (time.Time, error)
+}
+
+// ShapeConfig contains the compute shape metadata exported by IMDSv2. Optional
+// attributes are read through accessors such as GPUs, and RawJSON keeps the
+// document as served so fields added by later IMDS releases stay reachable.
 type ShapeConfig struct {
 	OCPUs                     float64 `json:"ocpus"`
 	MemoryInGBs               float64 `json:"memoryInGBs"`
@@ -29,4 +43,7 @@ type ShapeConfig struct {
 	ThreadsPerCore            int     `json:"threadsPerCore"`
 	NetworkingBandwidthInGbps float64 `json:"networkingBandwidthInGbps"`
 	MaxVnicAttachments        int     `json:"maxVnicAttachments"`
+	// RawJSON is the shape-config document as decoded; it is empty for values
+	// not built by UnmarshalJSON.
+	RawJSON json.RawMessage `json:"-"`
 }
@@ -0,0 +1,198 @@
+package imds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// baselinePrefix starts the BaselineOcpuUtilization values OCI reports, such as
+// BASELINE_1_8.
+const baselinePrefix = "BASELINE_"
+
+// UnmarshalJSON decodes an IMDS shape-config document. Decoding is lenient so
+// schema changes do not break older releases: unknown fields are kept in
+// RawJSON, numbers may arrive quoted, and a known field whose type no longer
+// matches is left at its zero value instead of failing the whole document.
+func (s *ShapeConfig) UnmarshalJSON(data []byte) error {
+	doc, err := parseSchemaDocument(data)
+	if err != nil {
+		return err
+	}
+
+	baseline, _ := doc.string("baselineOcpuUtilization")
+	threads, _ := doc.integer("threadsPerCore")
+	vnics, _ := doc.integer("maxVnicAttachments")
+
+	*s = ShapeConfig{
+		OCPUs:                     doc.float("ocpus"),
+		MemoryInGBs:               doc.float("memoryInGBs"),
+		BaselineOcpuUtilization:   baseline,
+		BaselineOCPUs:             doc.float("baselineOcpus"),
+		ThreadsPerCore:            threads,
+		NetworkingBandwidthInGbps: doc.float("networkingBandwidthInGbps"),
+		MaxVnicAttachments:        vnics,
+		RawJSON:                   bytes.Clone(data),
+	}
+
+	return nil
+}
+
+// GPUs returns the number of GPUs attached to the shape; ok is false when the
+// document does not report one.
+func (s ShapeConfig) GPUs() (int, bool) {
+	return s.document().integer("gpus")
+}
+
+// GPUDescription returns the GPU model reported for the shape, if any.
+func (s ShapeConfig) GPUDescription() (string, bool) {
+	return s.document().string("gpuDescription")
+}
+
+// LocalDisks returns the number of local NVMe disks on the shape, if reported.
+func (s ShapeConfig) LocalDisks() (int, bool) {
+	return s.document().integer("localDisks")
+}
+
+// LocalDisksTotalSizeInGBs returns the combined size of the local disks, if
+// reported.
+func (s ShapeConfig) LocalDisksTotalSizeInGBs() (float64, bool) {
+	return s.document().number("localDisksTotalSizeInGBs")
+}
+
+// BaselineFraction returns the share of each OCPU a burstable shape is
+// guaranteed, parsed from BaselineOcpuUtilization (BASELINE_1_8 is 0.125) or,
+// failing that, BaselineOCPUs over OCPUs. ok is false for shapes that always
+// run at their full OCPU count.
+func (s ShapeConfig) BaselineFraction() (float64, bool) {
+	fraction := 0.0
+
+	numerator, denominator, found := strings.Cut(
+		strings.TrimPrefix(strings.TrimSpace(s.BaselineOcpuUtilization), baselinePrefix),
+		"_",
+	)
+	if found {
+		num, numErr := strconv.Atoi(numerator)
+		den, denErr := strconv.Atoi(denominator)
+
+		if numErr == nil && denErr == nil && num > 0 && den > 0 {
+			fraction = float64(num) / float64(den)
+		}
+	} else if s.BaselineOCPUs > 0 && s.OCPUs > 0 {
+		fraction = s.BaselineOCPUs / s.OCPUs
+	}
+
+	if fraction <= 0 || fraction >= 1 {
+		return 0, false
+	}
+
+	return fraction, true
+}
+
+func (s ShapeConfig) document() schemaDocument {
+	if len(s.RawJSON) == 0 {
+		return nil
+	}
+
+	doc, err := parseSchemaDocument(s.RawJSON)
+	if err != nil {
+		return nil
+	}
+
+	return doc
+}
+
+// UnmarshalJSON decodes /instance/regionInfo. Documents without
+// canonicalRegionName fall back to regionIdentifier, which carries the same
+// name in the layout OCI documents for IMDSv2.
+func (r *regionInfo) UnmarshalJSON(data []byte) error {
+	doc, err := parseSchemaDocument(data)
+	if err != nil {
+		return err
+	}
+
+	name, _ := doc.string("canonicalRegionName")
+	if strings.TrimSpace(name) == "" {
+		name, _ = doc.string("regionIdentifier")
+	}
+
+	*r = regionInfo{CanonicalRegionName: name}
+
+	return nil
+}
+
+// schemaDocument holds the undecoded fields of an IMDS JSON document.
+type schemaDocument map[string]json.RawMessage
+
+func parseSchemaDocument(data []byte) (schemaDocument, error) {
+	var doc schemaDocument
+
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata document: %w", err)
+	}
+
+	return doc, nil
+}
+
+func (d schemaDocument) float(name string) float64 {
+	value, _ := d.number(name)
+
+	return value
+}
+
+// number accepts JSON numbers and numeric strings.
+func (d schemaDocument) number(name string) (float64, bool) {
+	raw, ok := d[name]
+	if !ok || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return 0, false
+	}
+
+	var value float64
+
+	err := json.Unmarshal(raw, &value)
+	if err == nil {
+		return value, true
+	}
+
+	text, ok := d.string(name)
+	if !ok {
+		return 0, false
+	}
+
+	value, err = strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// integer accepts whole numbers, including ones written as 2.0 or "2".
+func (d schemaDocument) integer(name string) (int, bool) {
+	value, ok := d.number(name)
+	if !ok || value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+		return 0, false
+	}
+
+	return int(value), true
+}
+
+func (d schemaDocument) string(name string) (string, bool) {
+	raw, ok := d[name]
+	if !ok {
+		return "", false
+	}
+
+	var value *string
+
+	err := json.Unmarshal(raw, &value)
+	if err != nil || value == nil {
+		return "", false
+	}
+
+	return *value, true
+}
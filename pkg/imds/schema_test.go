@@ -0,0 +1,154 @@
+package imds_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"oci-cpu-shaper/pkg/imds"
+)
+
+func TestShapeConfigToleratesSchemaChanges(t *testing.T) {
+	t.Parallel()
+
+	body := `{"ocpus":"2","memoryInGBs":16,"baselineOcpuUtilization":null,` +
+		`"threadsPerCore":2.0,"maxVnicAttachments":"many","networkingBandwidthInGbps":1,` +
+		`"gpus":1,"gpuDescription":"NVIDIA A10","localDisks":2,` +
+		`"localDisksTotalSizeInGBs":"6800","futureField":{"nested":[1,2]}}`
+
+	var shape imds.ShapeConfig
+
+	err := json.Unmarshal([]byte(body), &shape)
+	requireNoError(t, err, "Unmarshal")
+
+	requireEqual(t, "OCPUs", shape.OCPUs, 2.0)
+	requireEqual(t, "MemoryInGBs", shape.MemoryInGBs, 16.0)
+	requireEqual(t, "BaselineOcpuUtilization", shape.BaselineOcpuUtilization, "")
+	requireEqual(t, "ThreadsPerCore", shape.ThreadsPerCore, 2)
+	requireEqual(t, "MaxVnicAttachments", shape.MaxVnicAttachments, 0)
+	requireEqual(t, "RawJSON", string(shape.RawJSON), body)
+
+	gpus, ok := shape.GPUs()
+	requireEqual(t, "GPUs ok", ok, true)
+	requireEqual(t, "GPUs", gpus, 1)
+
+	description, ok := shape.GPUDescription()
+	requireEqual(t, "GPUDescription ok", ok, true)
+	requireEqual(t, "GPUDescription", description, "NVIDIA A10")
+
+	disks, ok := shape.LocalDisks()
+	requireEqual(t, "LocalDisks ok", ok, true)
+	requireEqual(t, "LocalDisks", disks, 2)
+
+	size, ok := shape.LocalDisksTotalSizeInGBs()
+	requireEqual(t, "LocalDisksTotalSizeInGBs ok", ok, true)
+	requireEqual(t, "LocalDisksTotalSizeInGBs", size, 6800.0)
+
+	var future struct {
+		FutureField struct {
+			Nested []int `json:"nested"`
+		} `json:"futureField"`
+	}
+
+	err = json.Unmarshal(shape.RawJSON, &future)
+	requireNoError(t, err, "Unmarshal RawJSON")
+	requireEqual(t, "futureField.nested", len(future.FutureField.Nested), 2)
+}
+
+func TestShapeConfigOptionalFieldsAbsent(t *testing.T) {
+	t.Parallel()
+
+	var decoded imds.ShapeConfig
+
+	err := json.Unmarshal(
+		[]byte(`{"ocpus":1,"gpus":1.5,"localDisks":"two","localDisksTotalSizeInGBs":"NaN"}`),
+		&decoded,
+	)
+	requireNoError(t, err, "Unmarshal")
+
+	for name, shape := range map[string]imds.ShapeConfig{
+		"decoded":       decoded,
+		"constructed":   {OCPUs: 1},
+		"corrupted raw": {RawJSON: json.RawMessage(`[`)},
+	} {
+		if _, ok := shape.GPUs(); ok {
+			t.Fatalf("%s: GPUs reported without a whole gpus field", name)
+		}
+
+		if _, ok := shape.GPUDescription(); ok {
+			t.Fatalf("%s: GPUDescription reported without gpuDescription", name)
+		}
+
+		if _, ok := shape.LocalDisks(); ok {
+			t.Fatalf("%s: LocalDisks reported without a numeric localDisks field", name)
+		}
+
+		if _, ok := shape.LocalDisksTotalSizeInGBs(); ok {
+			t.Fatalf("%s: LocalDisksTotalSizeInGBs reported without the field", name)
+		}
+	}
+}
+
+func TestShapeConfigRejectsNonObjectDocuments(t *testing.T) {
+	t.Parallel()
+
+	client := newIMDSTestClient(t, map[string]string{
+		shapeConfigResourcePath:     `[{"ocpus":1}]`,
+		canonicalRegionResourcePath: `"us-phoenix-1"`,
+	})
+
+	_, err := client.ShapeConfig(context.Background())
+	if err == nil {
+		t.Fatal("ShapeConfig() accepted an array document")
+	}
+
+	_, err = client.CanonicalRegion(context.Background())
+	if err == nil {
+		t.Fatal("CanonicalRegion() accepted a string document")
+	}
+}
+
+func TestCanonicalRegionFallsBackToRegionIdentifier(t *testing.T) {
+	t.Parallel()
+
+	client := newIMDSTestClient(t, map[string]string{
+		canonicalRegionResourcePath: `{"realmKey":"oc1","regionKey":"PHX",` +
+			`"regionIdentifier":"us-phoenix-1","canonicalRegionName":null}`,
+	})
+
+	region, err := client.CanonicalRegion(context.Background())
+	requireNoError(t, err, "CanonicalRegion()")
+	requireEqual(t, "CanonicalRegion()", region, "us-phoenix-1")
+}
+
+func TestShapeConfigBaselineFraction(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		shape imds.ShapeConfig
+		want  float64
+		ok    bool
+	}{
+		{"eighth", imds.ShapeConfig{BaselineOcpuUtilization: "BASELINE_1_8"}, 0.125, true},
+		{"half", imds.ShapeConfig{BaselineOcpuUtilization: " BASELINE_1_2 "}, 0.5, true},
+		{"full", imds.ShapeConfig{BaselineOcpuUtilization: "BASELINE_1_1"}, 0, false},
+		{"malformed", imds.ShapeConfig{BaselineOcpuUtilization: "BASELINE_one_8"}, 0, false},
+		{"ocpu ratio", imds.ShapeConfig{OCPUs: 2, BaselineOCPUs: 0.25}, 0.125, true},
+		{"unreported", imds.ShapeConfig{OCPUs: 2}, 0, false},
+	}
+
+	for _, tc := range cases {
+		got, ok := tc.shape.BaselineFraction()
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf(
+				"%s: BaselineFraction() = %v, %v, want %v, %v",
+				tc.name,
+				got,
+				ok,
+				tc.want,
+				tc.ok,
+			)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package imds
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightGroup coalesces concurrent fetches of the same resource so callers
+// racing during startup share a single request to the link-local service.
+// The zero value is ready for use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	payload []byte
+	err     error
+}
+
+// do runs fn for key unless a call for the same key is already in flight, in
+// which case it waits for that call's result. The shared call runs under the
+// first caller's context; later callers stop waiting when their own context
+// ends. The returned payload is shared and must not be modified.
+func (g *flightGroup) do(
+	ctx context.Context,
+	key string,
+	fn func() ([]byte, error),
+) ([]byte, error) {
+	g.mu.Lock()
+
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+
+	if call, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.payload, call.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for shared %s request: %w", key, ctx.Err())
+		}
+	}
+
+	call := &flight{done: make(chan struct{})}
+	g.flights[key] = call
+	g.mu.Unlock()
+
+	call.payload, call.err = fn()
+
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+
+	close(call.done)
+
+	return call.payload, call.err
+}
@@ -0,0 +1,189 @@
+// Package learnstate keeps the controller's suppression threshold learning in
+// a JSON state file, so the learned thresholds and the progress towards them
+// survive restarts.
+package learnstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// DefaultSaveInterval is how often Start saves the learning progress, bounding
+// what a crash loses.
+const DefaultSaveInterval = 15 * time.Minute
+
+const (
+	stateDirMode  = 0o750
+	stateFileMode = 0o600
+)
+
+// Learner is implemented by controllers that learn their suppression
+// thresholds, such as adapt.AdaptiveController.
+type Learner interface {
+	SuppressLearning() adapt.SuppressLearningState
+	RestoreSuppressLearning(state adapt.SuppressLearningState)
+	SetSuppressLearningHandler(handler func(state adapt.SuppressLearningState))
+}
+
+// Read decodes the state file at path.
+func Read(path string) (adapt.SuppressLearningState, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is the operator's own state file
+	if err != nil {
+		return adapt.SuppressLearningState{}, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var state adapt.SuppressLearningState
+
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return adapt.SuppressLearningState{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// Write replaces the state file at path with state, creating its directory
+// when missing. The state is written and synced to a temporary file that is
+// renamed over path, so a crash leaves either the old or the new state.
+func Write(path string, state adapt.SuppressLearningState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode suppression learning state: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+
+	err = os.MkdirAll(dir, stateDirMode)
+	if err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+
+	temp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create temporary state file: %w", err)
+	}
+
+	_, err = temp.Write(append(data, '\n'))
+	if err == nil {
+		err = temp.Chmod(stateFileMode)
+	}
+
+	if err == nil {
+		err = temp.Sync()
+	}
+
+	err = errors.Join(err, temp.Close())
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Store saves a Learner's progress to a state file. An empty path keeps the
+// learning in memory only.
+type Store struct {
+	path   string
+	logger logging.Logger
+}
+
+// NewStore returns a Store for the state file at path that logs failures to
+// logger. A nil logger discards them.
+func NewStore(path string, logger logging.Logger) *Store {
+	if logger == nil {
+		logger = logging.Nop()
+	}
+
+	return &Store{path: path, logger: logger}
+}
+
+// Restore resumes learner from the state file and reports whether it did. A
+// missing file restarts learning silently and an unreadable one with a
+// warning.
+func (s *Store) Restore(learner Learner) bool {
+	if s.path == "" {
+		return false
+	}
+
+	state, err := Read(s.path)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return false
+	case err != nil:
+		s.logger.Warn("failed to read suppression learning state; learning afresh",
+			"path", s.path, "error", err)
+
+		return false
+	default:
+		learner.RestoreSuppressLearning(state)
+
+		return true
+	}
+}
+
+// Save writes learner's current state, logging a failure.
+func (s *Store) Save(learner Learner) {
+	if s.path == "" {
+		return
+	}
+
+	err := Write(s.path, learner.SuppressLearning())
+	if err != nil {
+		s.logger.Warn("failed to save suppression learning state",
+			"path", s.path, "error", err)
+	}
+}
+
+// Start saves learner's progress every interval until ctx ends or the
+// returned stop is called; stop saves once more before it returns.
+func (s *Store) Start(ctx context.Context, learner Learner, interval time.Duration) func() {
+	if s.path == "" {
+		return func() {}
+	}
+
+	if interval <= 0 {
+		interval = DefaultSaveInterval
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				s.Save(learner)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		s.Save(learner)
+	}
+}
@@ -0,0 +1,281 @@
+package learnstate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/learnstate"
+)
+
+// stubLearner holds a learning state and counts how often it was read.
+type stubLearner struct {
+	mu       sync.Mutex
+	state    adapt.SuppressLearningState
+	reads    int
+	restored []adapt.SuppressLearningState
+}
+
+func (l *stubLearner) SuppressLearning() adapt.SuppressLearningState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reads++
+
+	return l.state
+}
+
+func (l *stubLearner) RestoreSuppressLearning(state adapt.SuppressLearningState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.state = state
+	l.restored = append(l.restored, state)
+}
+
+func (*stubLearner) SetSuppressLearningHandler(func(adapt.SuppressLearningState)) {}
+
+func (l *stubLearner) readCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.reads
+}
+
+// syncBuffer is a bytes.Buffer safe for the save loop's concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func newLogger() (*slog.Logger, *syncBuffer) {
+	buf := new(syncBuffer)
+
+	return slog.New(slog.NewTextHandler(buf, nil)), buf
+}
+
+func learningState() adapt.SuppressLearningState {
+	return adapt.SuppressLearningState{
+		Observed:  2 * time.Hour,
+		Histogram: []uint64{3, 4},
+		Threshold: 0.7,
+		Resume:    0.55,
+	}
+}
+
+func TestWriteAndReadRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "suppress-learning.json")
+
+	err := learnstate.Write(path, learningState())
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	err = learnstate.Write(path, learningState())
+	if err != nil {
+		t.Fatalf("Write over an existing state: %v", err)
+	}
+
+	state, err := learnstate.Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if state.Observed != 2*time.Hour || len(state.Histogram) != 2 || state.Threshold != 0.7 {
+		t.Fatalf("expected the written state back, got %+v", state)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a 0600 state file, got %v (%v)", info, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected no temporary files left behind, got %v (%v)", entries, err)
+	}
+}
+
+func TestReadAndWriteReportFailures(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := learnstate.Read(filepath.Join(dir, "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file to fail with ErrNotExist, got %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt")
+
+	err = os.WriteFile(corrupt, []byte("{"), 0o600)
+	if err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	_, err = learnstate.Read(corrupt)
+	if err == nil {
+		t.Fatal("expected a corrupt file to fail")
+	}
+
+	state := learningState()
+	state.Threshold = math.NaN()
+
+	err = learnstate.Write(filepath.Join(dir, "nan"), state)
+	if err == nil {
+		t.Fatal("expected a state that cannot be encoded to fail")
+	}
+
+	err = learnstate.Write(filepath.Join(corrupt, "state"), learningState())
+	if err == nil {
+		t.Fatal("expected a directory that cannot be created to fail")
+	}
+
+	// A non-empty directory cannot be replaced by the renamed state.
+	blocked := filepath.Join(dir, "blocked")
+
+	err = os.MkdirAll(filepath.Join(blocked, "entry"), 0o750)
+	if err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	err = learnstate.Write(blocked, learningState())
+	if err == nil {
+		t.Fatal("expected a failed rename to fail")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected the temporary file to be removed, got %v (%v)", entries, err)
+	}
+}
+
+func TestStoreRestoresAndSaves(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suppress-learning.json")
+	logger, logs := newLogger()
+	store := learnstate.NewStore(path, logger)
+	learner := &stubLearner{state: learningState()}
+
+	if store.Restore(learner) {
+		t.Fatal("expected nothing to restore without a state file")
+	}
+
+	store.Save(learner)
+
+	restored := new(stubLearner)
+	if !store.Restore(restored) || restored.restored[0].Threshold != 0.7 {
+		t.Fatalf("expected the saved state to be restored, got %+v", restored.restored)
+	}
+
+	err := os.WriteFile(path, []byte("{"), 0o600)
+	if err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	if store.Restore(restored) {
+		t.Fatal("expected a corrupt state not to be restored")
+	}
+
+	blocked := filepath.Join(dir, "blocked")
+
+	err = os.MkdirAll(filepath.Join(blocked, "entry"), 0o750)
+	if err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	learnstate.NewStore(blocked, logger).Save(learner)
+
+	for _, message := range []string{
+		"failed to read suppression learning state; learning afresh",
+		"failed to save suppression learning state",
+	} {
+		if !strings.Contains(logs.String(), message) {
+			t.Fatalf("expected %q to be logged, got:\n%s", message, logs)
+		}
+	}
+}
+
+func TestStoreStartSavesPeriodicallyAndOnStop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "suppress-learning.json")
+	store := learnstate.NewStore(path, nil)
+	learner := &stubLearner{state: learningState()}
+
+	stop := store.Start(t.Context(), learner, time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for learner.readCount() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected periodic saves")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+
+	reads := learner.readCount()
+
+	_, err := learnstate.Read(path)
+	if err != nil {
+		t.Fatalf("expected the state to be saved, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	stop = store.Start(ctx, learner, 0)
+
+	cancel()
+	stop()
+
+	if learner.readCount() != reads+1 {
+		t.Fatalf("expected one save on stop after cancellation, got %d", learner.readCount()-reads)
+	}
+}
+
+func TestStoreWithoutPathKeepsLearningInMemory(t *testing.T) {
+	t.Parallel()
+
+	store := learnstate.NewStore("", nil)
+	learner := &stubLearner{state: learningState()}
+
+	if store.Restore(learner) {
+		t.Fatal("expected nothing to restore without a path")
+	}
+
+	store.Save(learner)
+	store.Start(t.Context(), learner, time.Millisecond)()
+
+	if learner.readCount() != 0 {
+		t.Fatalf("expected no saves without a path, got %d", learner.readCount())
+	}
+}
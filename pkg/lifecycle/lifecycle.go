@@ -0,0 +1,109 @@
+// Package lifecycle stops a program's subsystems one after another in a fixed
+// order, each within its own timeout, so a component that needs another to
+// finish first, such as a metrics server exporting the final controller state,
+// is not cancelled alongside it.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// DefaultStageTimeout bounds a stage added with a non-positive timeout.
+const DefaultStageTimeout = 5 * time.Second
+
+// ErrStageTimeout signals a stage whose stop function did not return within
+// its timeout.
+var ErrStageTimeout = errors.New("lifecycle: stage timed out")
+
+// StopFunc stops one subsystem and waits for it to finish. The context ends
+// when the stage times out.
+type StopFunc func(ctx context.Context) error
+
+type stage struct {
+	name    string
+	timeout time.Duration
+	stop    StopFunc
+}
+
+// Manager runs shutdown stages in the order they were added. It is not safe
+// for concurrent use; the goroutine that owns the run adds the stages and
+// calls Shutdown once.
+type Manager struct {
+	logger logging.Logger
+	stages []stage
+	now    func() time.Time
+}
+
+// NewManager returns a Manager that reports each stage to logger. A nil
+// logger discards the reports.
+func NewManager(logger logging.Logger) *Manager {
+	return &Manager{
+		logger: logging.OrNop(logger),
+		stages: nil,
+		now:    time.Now,
+	}
+}
+
+// Add appends a stage named name that stop runs within timeout.
+func (m *Manager) Add(name string, timeout time.Duration, stop StopFunc) {
+	if timeout <= 0 {
+		timeout = DefaultStageTimeout
+	}
+
+	m.stages = append(m.stages, stage{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every stage in order and returns their failures joined. A
+// stage that fails or times out is logged and does not hold back the stages
+// after it; a stop function still running after its timeout is left to
+// finish in the background. Cancelling ctx times out the remaining stages.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	for _, stage := range m.stages {
+		err := m.run(ctx, stage)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) run(ctx context.Context, stage stage) error {
+	stageCtx, cancel := context.WithTimeout(ctx, stage.timeout)
+	defer cancel()
+
+	started := m.now()
+	result := make(chan error, 1)
+
+	go func() {
+		result <- stage.stop(stageCtx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-result:
+	case <-stageCtx.Done():
+		err = fmt.Errorf("%w after %s", ErrStageTimeout, stage.timeout)
+	}
+
+	elapsed := m.now().Sub(started)
+
+	if err != nil {
+		m.logger.Warn("shutdown stage failed",
+			"stage", stage.name, "elapsed", elapsed, "error", err)
+
+		return fmt.Errorf("stop %s: %w", stage.name, err)
+	}
+
+	m.logger.Info("shutdown stage complete", "stage", stage.name, "elapsed", elapsed)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/lifecycle"
+)
+
+var errStop = errors.New("stop failed")
+
+// stageReports returns "level: msg stage" for each report.
+func stageReports(logger *logtest.Recorder) []string {
+	var reports []string
+
+	for _, entry := range logger.Entries() {
+		reports = append(reports, fmt.Sprint(entry.Line(), " ", entry.Fields["stage"]))
+	}
+
+	return reports
+}
+
+func TestShutdownRunsStagesInOrder(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+	manager := lifecycle.NewManager(logger)
+
+	var order []string
+
+	for _, name := range []string{"controller", "pool", "estimator", "metrics"} {
+		manager.Add(name, time.Second, func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("expected stage %s to run with a deadline", name)
+			}
+
+			order = append(order, name)
+
+			return nil
+		})
+	}
+
+	err := manager.Shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	want := []string{"controller", "pool", "estimator", "metrics"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("expected stages %v, got %v", want, order)
+	}
+
+	reports := stageReports(logger)
+	if !slices.Contains(reports, "info: shutdown stage complete metrics") ||
+		len(reports) != len(want) {
+		t.Fatalf("expected one completion report per stage, got %v", reports)
+	}
+}
+
+func TestShutdownContinuesAfterFailuresAndTimeouts(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+	manager := lifecycle.NewManager(logger)
+	release := make(chan struct{})
+
+	defer close(release)
+
+	var ran []string
+
+	manager.Add("failing", time.Second, func(context.Context) error {
+		ran = append(ran, "failing")
+
+		return errStop
+	})
+	manager.Add("stuck", 10*time.Millisecond, func(context.Context) error {
+		<-release
+
+		return nil
+	})
+	manager.Add("last", 0, func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		if time.Until(deadline) <= time.Second {
+			t.Errorf("expected the default stage timeout, got %s", time.Until(deadline))
+		}
+
+		ran = append(ran, "last")
+
+		return nil
+	})
+
+	err := manager.Shutdown(t.Context())
+	if !errors.Is(err, errStop) || !errors.Is(err, lifecycle.ErrStageTimeout) {
+		t.Fatalf("expected the failure and the timeout, got %v", err)
+	}
+
+	if !slices.Equal(ran, []string{"failing", "last"}) {
+		t.Fatalf("expected every stage to run, got %v", ran)
+	}
+
+	reports := stageReports(logger)
+
+	for _, want := range []string{
+		"warn: shutdown stage failed failing",
+		"warn: shutdown stage failed stuck",
+		"info: shutdown stage complete last",
+	} {
+		if !slices.Contains(reports, want) {
+			t.Fatalf("expected %q in %v", want, reports)
+		}
+	}
+}
+
+func TestShutdownWithoutLoggerOrStages(t *testing.T) {
+	t.Parallel()
+
+	err := lifecycle.NewManager(nil).Shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("expected no stages to succeed, got %v", err)
+	}
+
+	manager := lifecycle.NewManager(nil)
+	manager.Add("cancelled", time.Minute, func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err = manager.Shutdown(ctx)
+	if err == nil {
+		t.Fatal("expected a cancelled shutdown to fail the stage")
+	}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import "context"
+
+// StepKey is the key/value key under which WithStep logs the step ID.
+const StepKey = "step"
+
+type stepKey struct{}
+
+// WithStepID returns a copy of ctx carrying id, the identifier of the
+// controller step the work done under ctx belongs to. An empty id leaves ctx
+// unchanged.
+func WithStepID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, stepKey{}, id)
+}
+
+// StepID returns the step ID carried by ctx, or "" outside a step.
+func StepID(ctx context.Context) string {
+	id, _ := ctx.Value(stepKey{}).(string)
+
+	return id
+}
+
+// WithStep returns a Logger that adds the step ID carried by ctx to every
+// message, so the lines logged for one decision can be correlated. Outside a
+// step it returns logger unchanged.
+//
+//nolint:ireturn // callers only depend on the interface
+func WithStep(ctx context.Context, logger Logger) Logger {
+	id := StepID(ctx)
+	if id == "" {
+		return logger
+	}
+
+	return stepLogger{logger: logger, id: id}
+}
+
+type stepLogger struct {
+	logger Logger
+	id     string
+}
+
+func (l stepLogger) Debug(msg string, keysAndValues ...any) {
+	l.logger.Debug(msg, l.with(keysAndValues)...)
+}
+
+func (l stepLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Info(msg, l.with(keysAndValues)...)
+}
+
+func (l stepLogger) Warn(msg string, keysAndValues ...any) {
+	l.logger.Warn(msg, l.with(keysAndValues)...)
+}
+
+func (l stepLogger) Error(msg string, keysAndValues ...any) {
+	l.logger.Error(msg, l.with(keysAndValues)...)
+}
+
+func (l stepLogger) with(keysAndValues []any) []any {
+	return append([]any{StepKey, l.id}, keysAndValues...)
+}
@@ -0,0 +1,40 @@
+// Package logging defines the minimal structured logger accepted by the library
+// packages, so programs embedding them can route diagnostics into any backend.
+package logging
+
+// Logger receives leveled diagnostics with alternating key/value pairs. Its
+// method set matches *slog.Logger, which can be passed directly; other
+// backends need a small adapter. Implementations must be safe for concurrent
+// use.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+// Nop returns a Logger that discards every message. Library packages use it
+// until a caller installs a logger.
+//
+//nolint:ireturn // callers only depend on the interface
+func Nop() Logger {
+	return nop{}
+}
+
+// OrNop returns logger, or Nop when logger is nil.
+//
+//nolint:ireturn // callers only depend on the interface
+func OrNop(logger Logger) Logger {
+	if logger == nil {
+		return Nop()
+	}
+
+	return logger
+}
+
+type nop struct{}
+
+func (nop) Debug(string, ...any) {}
+func (nop) Info(string, ...any)  {}
+func (nop) Warn(string, ...any)  {}
+func (nop) Error(string, ...any) {}
@@ -0,0 +1,86 @@
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+func TestSlogSatisfiesLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	var logger logging.Logger = slog.New(slog.NewTextHandler(&buffer, nil))
+
+	logger.Warn("fallback engaged", "target", 0.2)
+
+	if !strings.Contains(buffer.String(), "fallback engaged") ||
+		!strings.Contains(buffer.String(), "target=0.2") {
+		t.Fatalf("unexpected slog output %q", buffer.String())
+	}
+}
+
+func TestOrNopReplacesNil(t *testing.T) {
+	t.Parallel()
+
+	logger := logging.OrNop(nil)
+	if logger == nil {
+		t.Fatal("expected a no-op logger")
+	}
+
+	logger.Debug("discarded", "key", "value")
+	logger.Info("discarded")
+	logger.Warn("discarded")
+	logger.Error("discarded")
+
+	custom := logging.Nop()
+	if logging.OrNop(custom) != custom {
+		t.Fatal("expected OrNop to keep a supplied logger")
+	}
+}
+
+func TestWithStepTagsMessagesWithTheStepID(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	base := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       slog.LevelDebug,
+		ReplaceAttr: nil,
+	}))
+
+	if logging.WithStep(t.Context(), base) != logging.Logger(base) {
+		t.Fatal("expected the logger unchanged outside a step")
+	}
+
+	if logging.WithStepID(t.Context(), "") != t.Context() {
+		t.Fatal("expected an empty step ID to leave the context unchanged")
+	}
+
+	ctx := logging.WithStepID(t.Context(), "5f3a")
+	if logging.StepID(ctx) != "5f3a" || logging.StepID(t.Context()) != "" {
+		t.Fatalf("unexpected step IDs %q and %q", logging.StepID(ctx), logging.StepID(t.Context()))
+	}
+
+	logger := logging.WithStep(ctx, base)
+	logger.Debug("debug", "key", 1)
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], "msg=debug step=5f3a key=1") {
+		t.Fatalf("expected four lines tagged with the step, got %q", buffer.String())
+	}
+
+	for _, line := range lines {
+		if !strings.Contains(line, "step=5f3a") {
+			t.Fatalf("expected %q tagged with the step", line)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package zapslog bridges zap to log/slog. NewCore keeps *zap.Logger call sites
+// unchanged while a slog.Handler writes the records, and ReplaceAttr makes the
+// standard library's JSON handler emit the keys and encodings of zap's
+// production configuration, so log pipelines parse either backend alike.
+// LibraryLogger goes the other way and hands a *zap.Logger to the library
+// packages as a logging.Logger.
+//
+// Library consumers that only need pkg/logging can pass a *slog.Logger
+// directly and never import zap; this package exists for programs, such as
+// the daemon, whose own call sites are written against zap.
+package zapslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// Keys of zap's production encoder configuration as the daemon sets it up.
+const (
+	TimeKey       = "timestamp"
+	LevelKey      = "level"
+	MessageKey    = "message"
+	CallerKey     = "caller"
+	LoggerKey     = "logger"
+	StacktraceKey = "stacktrace"
+)
+
+// levelScale spaces zap levels like slog's, which leaves room for custom levels
+// between the named ones.
+const levelScale = 4
+
+type core struct {
+	zapcore.LevelEnabler
+
+	handler slog.Handler
+}
+
+// NewCore returns a zapcore.Core that writes entries at levels enabler allows
+// through handler. Fields become slog attributes in the order they were
+// logged; durations are written in seconds and times as Unix epoch seconds, as
+// zap's production encoder does.
+//
+//nolint:ireturn // zap composes cores through the interface
+func NewCore(handler slog.Handler, enabler zapcore.LevelEnabler) zapcore.Core {
+	return &core{LevelEnabler: enabler, handler: handler}
+}
+
+//nolint:ireturn // required by zapcore.Core
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{LevelEnabler: c.LevelEnabler, handler: c.handler.WithAttrs(attrs(fields))}
+}
+
+func (c *core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) || !c.handler.Enabled(context.Background(), Level(entry.Level)) {
+		return checked
+	}
+
+	return checked.AddCore(entry, c)
+}
+
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, Level(entry.Level), entry.Message, 0)
+
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String(LoggerKey, entry.LoggerName))
+	}
+
+	if entry.Caller.Defined {
+		record.AddAttrs(slog.String(CallerKey, entry.Caller.TrimmedPath()))
+	}
+
+	record.AddAttrs(attrs(fields)...)
+
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String(StacktraceKey, entry.Stack))
+	}
+
+	err := c.handler.Handle(context.Background(), record)
+	if err != nil {
+		return fmt.Errorf("write slog record: %w", err)
+	}
+
+	return nil
+}
+
+func (c *core) Sync() error {
+	return nil
+}
+
+var _ logging.Logger = LibraryLogger{}
+
+// LibraryLogger adapts zap to logging.Logger so library diagnostics share the
+// program's encoder, level, and output.
+type LibraryLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewLibraryLogger returns a LibraryLogger writing to logger. Reported callers
+// are the library call sites rather than the adapter.
+func NewLibraryLogger(logger *zap.Logger) LibraryLogger {
+	return LibraryLogger{sugar: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+// Debug logs msg at debug level.
+func (l LibraryLogger) Debug(msg string, keysAndValues ...any) {
+	l.sugar.Debugw(msg, keysAndValues...)
+}
+
+// Info logs msg at info level.
+func (l LibraryLogger) Info(msg string, keysAndValues ...any) {
+	l.sugar.Infow(msg, keysAndValues...)
+}
+
+// Warn logs msg at warn level.
+func (l LibraryLogger) Warn(msg string, keysAndValues ...any) {
+	l.sugar.Warnw(msg, keysAndValues...)
+}
+
+// Error logs msg at error level.
+func (l LibraryLogger) Error(msg string, keysAndValues ...any) {
+	l.sugar.Errorw(msg, keysAndValues...)
+}
+
+// Level maps a zap level to its slog equivalent. DPanic, Panic, and Fatal sit
+// above slog.LevelError.
+func Level(level zapcore.Level) slog.Level {
+	return slog.Level(level) * levelScale
+}
+
+// ReplaceAttr is a slog.HandlerOptions.ReplaceAttr that renames the built-in
+// time, level, and message attributes to zap's keys, writes the time as Unix
+// epoch seconds, and names levels as zap does ("info", "dpanic").
+func ReplaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.TimeKey:
+		if attr.Value.Kind() == slog.KindTime {
+			return slog.Float64(TimeKey, epochSeconds(attr.Value.Time()))
+		}
+	case slog.LevelKey:
+		level, ok := attr.Value.Any().(slog.Level)
+		if ok {
+			return slog.String(LevelKey, zapLevel(level).String())
+		}
+	case slog.MessageKey:
+		attr.Key = MessageKey
+	}
+
+	return attr
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	scaled := zapcore.Level(level / levelScale) //nolint:gosec // bounded below
+
+	return min(max(scaled, zapcore.DebugLevel), zapcore.FatalLevel)
+}
+
+// attrs encodes fields through zap's map encoder so every field type, including
+// errors, objects, and namespaces, is rendered as zap would render it.
+func attrs(fields []zapcore.Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	result := make([]slog.Attr, 0, len(encoder.Fields))
+
+	for _, field := range fields {
+		value, ok := encoder.Fields[field.Key]
+		if !ok {
+			continue
+		}
+
+		result = append(result, slog.Any(field.Key, encodeValue(value)))
+		delete(encoder.Fields, field.Key)
+	}
+
+	// Keys zap derives from a field, such as errorVerbose, follow in order.
+	for _, key := range slices.Sorted(maps.Keys(encoder.Fields)) {
+		result = append(result, slog.Any(key, encodeValue(encoder.Fields[key])))
+	}
+
+	return result
+}
+
+func encodeValue(value any) any {
+	switch typed := value.(type) {
+	case time.Duration:
+		return typed.Seconds()
+	case time.Time:
+		return epochSeconds(typed)
+	case map[string]any:
+		encoded := make(map[string]any, len(typed))
+		for key, nested := range typed {
+			encoded[key] = encodeValue(nested)
+		}
+
+		return encoded
+	case []any:
+		encoded := make([]any, len(typed))
+		for index, nested := range typed {
+			encoded[index] = encodeValue(nested)
+		}
+
+		return encoded
+	default:
+		return value
+	}
+}
+
+func epochSeconds(at time.Time) float64 {
+	return float64(at.UnixNano()) / float64(time.Second)
+}
@@ -0,0 +1,245 @@
+package zapslog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"oci-cpu-shaper/pkg/logging/zapslog"
+)
+
+var errHandlerFailed = errors.New("handler failed")
+
+func newTestLogger(buffer *bytes.Buffer, level zapcore.Level) *zap.Logger {
+	handler := slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       zapslog.Level(zapcore.DebugLevel),
+		ReplaceAttr: zapslog.ReplaceAttr,
+	})
+
+	return zap.New(
+		zapslog.NewCore(handler, level),
+		zap.AddCaller(),
+		zap.AddStacktrace(zap.ErrorLevel),
+	)
+}
+
+func decodeLines(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+
+	for line := range strings.Lines(buffer.String()) {
+		var entry map[string]any
+
+		err := json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestCoreWritesZapProductionKeys(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	at := time.Unix(1700000000, 500000000)
+	logger := newTestLogger(&buffer, zapcore.InfoLevel).
+		Named("shaper").
+		With(zap.String("mode", "enforce"))
+
+	logger.Info(
+		"controller step",
+		zap.Float64("target", 0.25),
+		zap.Duration("interval", 90*time.Second),
+		zap.Time("fetchedAt", at),
+		zap.Error(errHandlerFailed),
+		zap.Durations("history", []time.Duration{time.Second}),
+		zap.Namespace("oci"),
+		zap.Int("calls", 3),
+	)
+	logger.Debug("filtered out")
+
+	entries := decodeLines(t, &buffer)
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d: %s", len(entries), buffer.String())
+	}
+
+	entry := entries[0]
+
+	for key, want := range map[string]any{
+		zapslog.LevelKey:   "info",
+		zapslog.MessageKey: "controller step",
+		zapslog.LoggerKey:  "shaper",
+		"mode":             "enforce",
+		"target":           0.25,
+		"interval":         90.0,
+		"fetchedAt":        1700000000.5,
+		"error":            errHandlerFailed.Error(),
+	} {
+		if entry[key] != want {
+			t.Fatalf("%s = %#v, want %#v", key, entry[key], want)
+		}
+	}
+
+	if _, ok := entry[zapslog.TimeKey].(float64); !ok {
+		t.Fatalf("expected an epoch %s, got %#v", zapslog.TimeKey, entry[zapslog.TimeKey])
+	}
+
+	caller, _ := entry[zapslog.CallerKey].(string)
+	if !strings.Contains(caller, "zapslog_test.go") {
+		t.Fatalf("unexpected caller %q", caller)
+	}
+
+	history, _ := entry["history"].([]any)
+	if len(history) != 1 || history[0] != 1.0 {
+		t.Fatalf("unexpected history %#v", entry["history"])
+	}
+
+	namespace, _ := entry["oci"].(map[string]any)
+	if namespace["calls"] != 3.0 {
+		t.Fatalf("unexpected namespace %#v", entry["oci"])
+	}
+}
+
+func TestCoreAddsStacktraceAndMapsLevels(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	logger := newTestLogger(&buffer, zapcore.DebugLevel)
+	logger.Debug("detail")
+	logger.Warn("careful")
+	logger.Error("failed")
+	logger.DPanic("development panic")
+
+	entries := decodeLines(t, &buffer)
+
+	levels := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		levels = append(levels, entry[zapslog.LevelKey])
+	}
+
+	want := []any{"debug", "warn", "error", "dpanic"}
+	if len(levels) != len(want) {
+		t.Fatalf("levels = %v, want %v", levels, want)
+	}
+
+	for index := range want {
+		if levels[index] != want[index] {
+			t.Fatalf("levels = %v, want %v", levels, want)
+		}
+	}
+
+	if _, ok := entries[2][zapslog.StacktraceKey].(string); !ok {
+		t.Fatalf("expected a stacktrace on error entries, got %#v", entries[2])
+	}
+
+	if zapslog.Level(zapcore.ErrorLevel) != slog.LevelError {
+		t.Fatalf("Level(error) = %v, want %v", zapslog.Level(zapcore.ErrorLevel), slog.LevelError)
+	}
+}
+
+func TestReplaceAttrLeavesOtherAttrs(t *testing.T) {
+	t.Parallel()
+
+	for _, attr := range []slog.Attr{
+		slog.String(slog.TimeKey, "not a time"),
+		slog.String(slog.LevelKey, "custom"),
+		slog.Int("calls", 1),
+	} {
+		if got := zapslog.ReplaceAttr(nil, attr); !got.Equal(attr) {
+			t.Fatalf("ReplaceAttr(%v) = %v", attr, got)
+		}
+	}
+
+	grouped := slog.String(slog.MessageKey, "nested")
+	if got := zapslog.ReplaceAttr([]string{"oci"}, grouped); !got.Equal(grouped) {
+		t.Fatalf("ReplaceAttr renamed a grouped attribute: %v", got)
+	}
+
+	below := zapslog.ReplaceAttr(nil, slog.Any(slog.LevelKey, slog.LevelDebug-8))
+	if below.Value.String() != "debug" {
+		t.Fatalf("expected levels below debug to clamp, got %v", below)
+	}
+}
+
+type failingHandler struct {
+	slog.Handler
+}
+
+func (failingHandler) Handle(context.Context, slog.Record) error {
+	return errHandlerFailed
+}
+
+func TestCoreReportsHandlerErrors(t *testing.T) {
+	t.Parallel()
+
+	core := zapslog.NewCore(
+		failingHandler{Handler: slog.NewTextHandler(&bytes.Buffer{}, nil)},
+		zapcore.InfoLevel,
+	)
+
+	err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "dropped"}, nil)
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+
+	checked := core.Check(zapcore.Entry{Level: zapcore.DebugLevel, Message: "dropped"}, nil)
+	if checked != nil {
+		t.Fatal("expected entries below the enabler to be skipped")
+	}
+
+	err = core.Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+}
+
+func TestLibraryLoggerWritesThroughZap(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := zapslog.NewLibraryLogger(zap.New(core, zap.AddCaller()))
+
+	logger.Debug("tuned", "step", 1)
+	logger.Info("started")
+	logger.Warn("slow", "lag", time.Second)
+	logger.Error("failed")
+
+	entries := observed.All()
+	if len(entries) != 4 {
+		t.Fatalf("expected four entries, got %d", len(entries))
+	}
+
+	levels := []zapcore.Level{
+		zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel,
+	}
+	for index, level := range levels {
+		if entries[index].Level != level {
+			t.Fatalf("expected entry %d at %s, got %s", index, level, entries[index].Level)
+		}
+	}
+
+	if entries[2].ContextMap()["lag"] != time.Second {
+		t.Fatalf("expected the key/value pairs as fields, got %v", entries[2].ContextMap())
+	}
+
+	if !strings.HasSuffix(entries[0].Caller.File, "zapslog_test.go") {
+		t.Fatalf("expected the library call site as caller, got %s", entries[0].Caller.File)
+	}
+}
@@ -0,0 +1,145 @@
+// Package memtrim keeps the daemon's memory footprint predictable on small
+// shapes such as VM.Standard.E2.1.Micro: it applies a soft Go memory limit and
+// periodically returns freed heap to the OS once the resident set grows past a
+// threshold.
+package memtrim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	// ErrInvalidConfig signals a negative limit, interval, or threshold.
+	ErrInvalidConfig = errors.New("memtrim: limit, interval, and threshold must not be negative")
+	// ErrUnsupported signals a platform whose resident set size cannot be read.
+	ErrUnsupported = errors.New("memtrim: resident set size is unavailable on this platform")
+)
+
+// Config selects the memory limit and trimming cadence.
+type Config struct {
+	// Limit is the soft memory limit in bytes applied with
+	// debug.SetMemoryLimit. Zero keeps the runtime's limit, which GOMEMLIMIT
+	// sets.
+	Limit int64
+	// Interval spaces resident set checks; zero disables trimming.
+	Interval time.Duration
+	// TrimAbove is the resident set size in bytes above which a check returns
+	// freed memory to the OS; zero trims on every check.
+	TrimAbove int64
+}
+
+// Validate reports whether c can be applied.
+func (c Config) Validate() error {
+	if c.Limit < 0 || c.Interval < 0 || c.TrimAbove < 0 {
+		return fmt.Errorf(
+			"%w: limit %d, interval %s, trimAbove %d",
+			ErrInvalidConfig, c.Limit, c.Interval, c.TrimAbove,
+		)
+	}
+
+	return nil
+}
+
+// Status describes one resident set check.
+type Status struct {
+	// RSS is the resident set size in bytes after the check.
+	RSS int64
+	// Trimmed reports whether the check returned freed memory to the OS, and
+	// Freed how far that lowered the resident set.
+	Trimmed bool
+	Freed   int64
+	// Trims counts the trims since the Trimmer was built.
+	Trims uint64
+}
+
+// Trimmer applies a Config. It is not safe for concurrent use; Run owns it
+// once started.
+type Trimmer struct {
+	cfg   Config
+	trims uint64
+
+	readRSS        func() (int64, error)
+	freeOSMemory   func()
+	setMemoryLimit func(limit int64) int64
+}
+
+// New validates cfg and constructs a Trimmer for it.
+func New(cfg Config) (*Trimmer, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trimmer{
+		cfg:            cfg,
+		trims:          0,
+		readRSS:        ReadRSS,
+		freeOSMemory:   debug.FreeOSMemory,
+		setMemoryLimit: debug.SetMemoryLimit,
+	}, nil
+}
+
+// ApplyLimit sets the configured soft memory limit and returns the limit it
+// replaced. Without a configured limit it leaves the runtime untouched and
+// returns the current limit with applied false.
+func (t *Trimmer) ApplyLimit() (int64, bool) {
+	if t.cfg.Limit == 0 {
+		return t.setMemoryLimit(-1), false
+	}
+
+	return t.setMemoryLimit(t.cfg.Limit), true
+}
+
+// Check reads the resident set size and, once it exceeds the threshold,
+// forces a garbage collection and returns the freed memory to the OS.
+func (t *Trimmer) Check() (Status, error) {
+	rss, err := t.readRSS()
+	if err != nil {
+		return Status{RSS: 0, Trimmed: false, Freed: 0, Trims: t.trims}, err
+	}
+
+	if rss <= t.cfg.TrimAbove {
+		return Status{RSS: rss, Trimmed: false, Freed: 0, Trims: t.trims}, nil
+	}
+
+	t.freeOSMemory()
+	t.trims++
+
+	after, err := t.readRSS()
+	if err != nil {
+		return Status{RSS: rss, Trimmed: true, Freed: 0, Trims: t.trims}, err
+	}
+
+	return Status{RSS: after, Trimmed: true, Freed: max(0, rss-after), Trims: t.trims}, nil
+}
+
+// Run checks every configured interval until ctx is cancelled, passing each
+// outcome to handler. It returns at once when trimming is disabled and stops
+// after reporting ErrUnsupported, which later checks would repeat.
+func (t *Trimmer) Run(ctx context.Context, handler func(status Status, err error)) {
+	if t == nil || handler == nil || t.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(t.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := t.Check()
+		handler(status, err)
+
+		if errors.Is(err, ErrUnsupported) {
+			return
+		}
+	}
+}
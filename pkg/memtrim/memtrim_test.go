@@ -0,0 +1,186 @@
+package memtrim //nolint:testpackage // replaces the runtime and procfs seams
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errStatm = errors.New("statm unreadable")
+
+// fakeRuntime scripts resident set readings and records the runtime calls.
+type fakeRuntime struct {
+	readings []int64
+	err      error
+	frees    int
+	limits   []int64
+}
+
+func (f *fakeRuntime) install(trimmer *Trimmer) {
+	trimmer.readRSS = func() (int64, error) {
+		if f.err != nil {
+			return 0, f.err
+		}
+
+		reading := f.readings[0]
+		if len(f.readings) > 1 {
+			f.readings = f.readings[1:]
+		}
+
+		return reading, nil
+	}
+	trimmer.freeOSMemory = func() { f.frees++ }
+	trimmer.setMemoryLimit = func(limit int64) int64 {
+		f.limits = append(f.limits, limit)
+
+		return 1 << 40
+	}
+}
+
+func newTrimmer(t *testing.T, cfg Config, runtime *fakeRuntime) *Trimmer {
+	t.Helper()
+
+	trimmer, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	runtime.install(trimmer)
+
+	return trimmer
+}
+
+func TestNewRejectsNegativeSettings(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []Config{
+		{Limit: -1, Interval: 0, TrimAbove: 0},
+		{Limit: 0, Interval: -time.Second, TrimAbove: 0},
+		{Limit: 0, Interval: 0, TrimAbove: -1},
+	} {
+		_, err := New(cfg)
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("%+v: expected ErrInvalidConfig, got %v", cfg, err)
+		}
+	}
+}
+
+func TestApplyLimitOnlySetsAConfiguredLimit(t *testing.T) {
+	t.Parallel()
+
+	runtime := &fakeRuntime{readings: nil, err: nil, frees: 0, limits: nil}
+
+	previous, applied := newTrimmer(t, Config{Limit: 0, Interval: 0, TrimAbove: 0}, runtime).
+		ApplyLimit()
+	if applied || previous != 1<<40 {
+		t.Fatalf("expected the current limit left in place, got %d, %v", previous, applied)
+	}
+
+	_, applied = newTrimmer(t, Config{Limit: 64 << 20, Interval: 0, TrimAbove: 0}, runtime).
+		ApplyLimit()
+	if !applied || len(runtime.limits) != 2 || runtime.limits[0] != -1 ||
+		runtime.limits[1] != 64<<20 {
+		t.Fatalf("expected a query then the configured limit, got %v", runtime.limits)
+	}
+}
+
+func TestCheckTrimsAboveTheThreshold(t *testing.T) {
+	t.Parallel()
+
+	runtime := &fakeRuntime{
+		readings: []int64{40 << 20, 70 << 20, 50 << 20},
+		err:      nil,
+		frees:    0,
+		limits:   nil,
+	}
+	trimmer := newTrimmer(t, Config{Limit: 0, Interval: 0, TrimAbove: 60 << 20}, runtime)
+
+	status, err := trimmer.Check()
+	if err != nil || status != (Status{RSS: 40 << 20, Trimmed: false, Freed: 0, Trims: 0}) {
+		t.Fatalf("expected no trim below the threshold, got %+v, %v", status, err)
+	}
+
+	status, err = trimmer.Check()
+	if err != nil ||
+		status != (Status{RSS: 50 << 20, Trimmed: true, Freed: 20 << 20, Trims: 1}) ||
+		runtime.frees != 1 {
+		t.Fatalf("expected one trim freeing 20 MiB, got %+v, %v", status, err)
+	}
+
+	runtime.err = errStatm
+
+	status, err = trimmer.Check()
+	if !errors.Is(err, errStatm) || status.Trims != 1 {
+		t.Fatalf("expected the read error, got %+v, %v", status, err)
+	}
+}
+
+func TestCheckReportsAFailedReadAfterTrimming(t *testing.T) {
+	t.Parallel()
+
+	runtime := &fakeRuntime{readings: []int64{1}, err: nil, frees: 0, limits: nil}
+	trimmer := newTrimmer(t, Config{Limit: 0, Interval: 0, TrimAbove: 0}, runtime)
+	calls := 0
+	trimmer.readRSS = func() (int64, error) {
+		calls++
+		if calls > 1 {
+			return 0, errStatm
+		}
+
+		return 1 << 20, nil
+	}
+
+	status, err := trimmer.Check()
+	if !errors.Is(err, errStatm) || !status.Trimmed || status.RSS != 1<<20 {
+		t.Fatalf("expected the trim reported with the read error, got %+v, %v", status, err)
+	}
+}
+
+func TestRunChecksEveryIntervalUntilUnsupported(t *testing.T) {
+	t.Parallel()
+
+	runtime := &fakeRuntime{readings: []int64{8 << 20}, err: nil, frees: 0, limits: nil}
+	trimmer := newTrimmer(t, Config{Limit: 0, Interval: time.Millisecond, TrimAbove: 0}, runtime)
+
+	var statuses []Status
+
+	trimmer.Run(t.Context(), func(status Status, err error) {
+		statuses = append(statuses, status)
+		if len(statuses) == 3 {
+			runtime.err = ErrUnsupported
+		}
+
+		if err != nil && !errors.Is(err, ErrUnsupported) {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	if len(statuses) != 4 || statuses[2].Trims != 3 {
+		t.Fatalf("expected three trims and the unsupported check, got %+v", statuses)
+	}
+}
+
+func TestRunReturnsWhenDisabledOrCancelled(t *testing.T) {
+	t.Parallel()
+
+	runtime := &fakeRuntime{readings: []int64{1}, err: nil, frees: 0, limits: nil}
+	called := false
+	handler := func(Status, error) { called = true }
+
+	newTrimmer(t, Config{Limit: 0, Interval: 0, TrimAbove: 0}, runtime).Run(t.Context(), handler)
+
+	var unset *Trimmer
+
+	unset.Run(t.Context(), handler)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	newTrimmer(t, Config{Limit: 0, Interval: time.Hour, TrimAbove: 0}, runtime).Run(ctx, handler)
+	newTrimmer(t, Config{Limit: 0, Interval: time.Hour, TrimAbove: 0}, runtime).Run(ctx, nil)
+
+	if called {
+		t.Fatal("expected no checks")
+	}
+}
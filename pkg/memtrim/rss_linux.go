@@ -0,0 +1,40 @@
+//go:build linux
+
+package memtrim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const statmPath = "/proc/self/statm"
+
+var errMalformedStatm = errors.New("memtrim: malformed statm")
+
+// ReadRSS returns the resident set size of the process in bytes, read from the
+// second field of /proc/self/statm.
+func ReadRSS() (int64, error) {
+	return readStatm(statmPath)
+}
+
+func readStatm(path string) (int64, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // fixed procfs path or test fixture
+	if err != nil {
+		return 0, fmt.Errorf("read resident set size: %w", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("parse %s: %w: %q", path, errMalformedStatm, data)
+	}
+
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return pages * int64(os.Getpagesize()), nil
+}
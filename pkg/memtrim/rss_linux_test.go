@@ -0,0 +1,55 @@
+package memtrim //nolint:testpackage // exercises the statm parser directly
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRSSReportsTheResidentSet(t *testing.T) {
+	t.Parallel()
+
+	rss, err := ReadRSS()
+	if err != nil || rss <= 0 {
+		t.Fatalf("expected a positive resident set, got %d, %v", rss, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "statm")
+
+	err = os.WriteFile(path, []byte("4096 25 12 1 0 300 0\n"), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rss, err = readStatm(path)
+	if err != nil || rss != 25*int64(os.Getpagesize()) {
+		t.Fatalf("expected 25 pages, got %d, %v", rss, err)
+	}
+}
+
+func TestReadStatmRejectsMalformedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{"short": "4096", "text": "4096 many"} {
+		path := filepath.Join(dir, name)
+
+		err := os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		_, err = readStatm(path)
+		if err == nil || !strings.Contains(err.Error(), "parse") {
+			t.Fatalf("%s: expected a parse error, got %v", name, err)
+		}
+	}
+
+	_, err := readStatm(filepath.Join(dir, "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
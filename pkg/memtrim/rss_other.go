@@ -0,0 +1,8 @@
+//go:build !linux
+
+package memtrim
+
+// ReadRSS returns ErrUnsupported outside Linux.
+func ReadRSS() (int64, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,240 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var errNetworkMetricsMissing = errors.New("metadata: network totals unsupported by delegate")
+
+// ClientFactory builds a Monitoring client scoped to a compartment and region.
+type ClientFactory func(compartmentID, region string) (oci.MetricsClient, error)
+
+type clockSkewTracker interface {
+	SetClockSkewHandler(handler func(skew time.Duration))
+}
+
+type tokenExpiryTracker interface {
+	SetTokenExpiryHandler(handler func(expiry time.Time))
+}
+
+type queryWindowTracker interface {
+	SetQueryWindowHandler(handler func(window oci.QueryWindow))
+}
+
+// MonitoringClients builds Monitoring clients through a factory and remembers
+// them so they can be rebuilt in place when the compartment or region they
+// were built for changes.
+type MonitoringClients struct {
+	factory ClientFactory
+
+	mu      sync.Mutex
+	clients []*rebindableMetricsClient
+}
+
+// NewMonitoringClients constructs MonitoringClients that build through factory.
+func NewMonitoringClients(factory ClientFactory) *MonitoringClients {
+	return &MonitoringClients{factory: factory}
+}
+
+// Build constructs a Monitoring client that Rebind can later point at a new
+// compartment or region. It satisfies ClientFactory.
+//
+//nolint:ireturn // satisfies ClientFactory.
+func (m *MonitoringClients) Build(compartmentID, region string) (oci.MetricsClient, error) {
+	delegate, err := m.factory(compartmentID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &rebindableMetricsClient{
+		factory:       m.factory,
+		compartmentID: compartmentID,
+		region:        region,
+		delegate:      delegate,
+	}
+
+	m.mu.Lock()
+	m.clients = append(m.clients, client)
+	m.mu.Unlock()
+
+	return client, nil
+}
+
+// Rebind rebuilds every client built for the previous compartment or region
+// and reports how many were rebuilt. Clients pinned to other values by
+// configuration are left alone.
+func (m *MonitoringClients) Rebind(previous, current Instance) (int, error) {
+	m.mu.Lock()
+	clients := append([]*rebindableMetricsClient(nil), m.clients...)
+	m.mu.Unlock()
+
+	var (
+		rebound int
+		errs    []error
+	)
+
+	for _, client := range clients {
+		changed, err := client.rebind(previous, current)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if changed {
+			rebound++
+		}
+	}
+
+	return rebound, errors.Join(errs...)
+}
+
+// rebindableMetricsClient forwards to a Monitoring client that can be replaced
+// without rebuilding the controller holding it.
+type rebindableMetricsClient struct {
+	factory ClientFactory
+
+	mu            sync.RWMutex
+	compartmentID string
+	region        string
+	delegate      oci.MetricsClient
+	skewHandler   func(skew time.Duration)
+	tokenHandler  func(expiry time.Time)
+	windowHandler func(window oci.QueryWindow)
+}
+
+func (r *rebindableMetricsClient) current() oci.MetricsClient { //nolint:ireturn // delegate
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.delegate
+}
+
+func (r *rebindableMetricsClient) rebind(previous, current Instance) (bool, error) {
+	r.mu.RLock()
+	builtCompartment, builtRegion := r.compartmentID, r.region
+	r.mu.RUnlock()
+
+	compartmentID, region := builtCompartment, builtRegion
+
+	if compartmentID == previous.CompartmentID {
+		compartmentID = current.CompartmentID
+	}
+
+	if region == previous.Region {
+		region = current.Region
+	}
+
+	if compartmentID == builtCompartment && region == builtRegion {
+		return false, nil
+	}
+
+	delegate, err := r.factory(compartmentID, region)
+	if err != nil {
+		return false, fmt.Errorf("rebuild monitoring client: %w", err)
+	}
+
+	r.mu.Lock()
+	r.compartmentID = compartmentID
+	r.region = region
+	r.delegate = delegate
+	handler := r.skewHandler
+	tokenHandler := r.tokenHandler
+	windowHandler := r.windowHandler
+	r.mu.Unlock()
+
+	if handler != nil {
+		if tracker, ok := delegate.(clockSkewTracker); ok {
+			tracker.SetClockSkewHandler(handler)
+		}
+	}
+
+	if tokenHandler != nil {
+		if tracker, ok := delegate.(tokenExpiryTracker); ok {
+			tracker.SetTokenExpiryHandler(tokenHandler)
+		}
+	}
+
+	if windowHandler != nil {
+		if tracker, ok := delegate.(queryWindowTracker); ok {
+			tracker.SetQueryWindowHandler(windowHandler)
+		}
+	}
+
+	return true, nil
+}
+
+func (r *rebindableMetricsClient) QueryP95CPU(
+	ctx context.Context,
+	resourceID string,
+) (float64, error) {
+	return r.current().QueryP95CPU(ctx, resourceID) //nolint:wrapcheck // transparent decorator
+}
+
+// QueryNetworkBytes7d forwards to the delegate when it reports network totals.
+func (r *rebindableMetricsClient) QueryNetworkBytes7d(
+	ctx context.Context,
+	resourceID string,
+) (oci.NetworkTotals, error) {
+	querier, ok := r.current().(oci.NetworkMetricsClient)
+	if !ok {
+		return oci.NetworkTotals{}, errNetworkMetricsMissing
+	}
+
+	return querier.QueryNetworkBytes7d(ctx, resourceID) //nolint:wrapcheck // transparent decorator
+}
+
+// QueryStep forwards to the current delegate, batching when it supports it.
+func (r *rebindableMetricsClient) QueryStep(
+	ctx context.Context,
+	resourceID string,
+	query oci.StepQuery,
+) (oci.StepMetrics, error) {
+	result, err := oci.QueryStep(ctx, r.current(), resourceID, query)
+
+	return result, err //nolint:wrapcheck // transparent decorator
+}
+
+// SetClockSkewHandler forwards handler to the delegate and to its replacements.
+func (r *rebindableMetricsClient) SetClockSkewHandler(handler func(skew time.Duration)) {
+	r.mu.Lock()
+	r.skewHandler = handler
+	delegate := r.delegate
+	r.mu.Unlock()
+
+	if tracker, ok := delegate.(clockSkewTracker); ok {
+		tracker.SetClockSkewHandler(handler)
+	}
+}
+
+// SetTokenExpiryHandler forwards handler to the delegate and to its
+// replacements.
+func (r *rebindableMetricsClient) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	r.mu.Lock()
+	r.tokenHandler = handler
+	delegate := r.delegate
+	r.mu.Unlock()
+
+	if tracker, ok := delegate.(tokenExpiryTracker); ok {
+		tracker.SetTokenExpiryHandler(handler)
+	}
+}
+
+// SetQueryWindowHandler forwards handler to the delegate and to its
+// replacements.
+func (r *rebindableMetricsClient) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	r.mu.Lock()
+	r.windowHandler = handler
+	delegate := r.delegate
+	r.mu.Unlock()
+
+	if tracker, ok := delegate.(queryWindowTracker); ok {
+		tracker.SetQueryWindowHandler(handler)
+	}
+}
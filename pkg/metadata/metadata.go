@@ -0,0 +1,93 @@
+// Package metadata re-reads instance metadata from IMDS while the daemon runs,
+// reports compartment, region and shape changes, and rebuilds Monitoring
+// clients that were built for a compartment or region the instance left.
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+)
+
+// Change fields reported by Instance.Diff.
+const (
+	FieldCompartmentID = "compartmentId"
+	FieldRegion        = "region"
+	FieldOCPUs         = "ocpus"
+	FieldMemoryInGBs   = "memoryInGBs"
+)
+
+// DefaultRefreshInterval spaces IMDS metadata re-reads an hour apart, which
+// costs three IMDS calls per hour against the IMDS daily budget.
+const DefaultRefreshInterval = time.Hour
+
+// Instance is the subset of IMDS metadata that can change while the daemon
+// runs: the instance can move compartments or be resized.
+type Instance struct {
+	CompartmentID string
+	Region        string
+	OCPUs         float64
+	MemoryInGBs   float64
+}
+
+// Change describes one field that differs between two metadata reads.
+type Change struct {
+	Field    string
+	Previous string
+	Current  string
+}
+
+// Diff lists the fields that differ between m and next, in a stable order.
+func (m Instance) Diff(next Instance) []Change {
+	var changes []Change
+
+	add := func(field, previous, current string) {
+		if previous != current {
+			changes = append(changes, Change{
+				Field:    field,
+				Previous: previous,
+				Current:  current,
+			})
+		}
+	}
+
+	add(FieldCompartmentID, m.CompartmentID, next.CompartmentID)
+	add(FieldRegion, m.Region, next.Region)
+	add(FieldOCPUs, formatFloat(m.OCPUs), formatFloat(next.OCPUs))
+	add(FieldMemoryInGBs, formatFloat(m.MemoryInGBs), formatFloat(next.MemoryInGBs))
+
+	return changes
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Read fetches the compartment, region and shape of the running instance.
+func Read(ctx context.Context, client imds.Client) (Instance, error) {
+	compartmentID, err := client.CompartmentID(ctx)
+	if err != nil {
+		return Instance{}, fmt.Errorf("lookup compartment ocid: %w", err)
+	}
+
+	region, err := client.Region(ctx)
+	if err != nil {
+		return Instance{}, fmt.Errorf("lookup instance region: %w", err)
+	}
+
+	shape, err := client.ShapeConfig(ctx)
+	if err != nil {
+		return Instance{}, fmt.Errorf("lookup shape config: %w", err)
+	}
+
+	return Instance{
+		CompartmentID: strings.TrimSpace(compartmentID),
+		Region:        strings.TrimSpace(region),
+		OCPUs:         shape.OCPUs,
+		MemoryInGBs:   shape.MemoryInGBs,
+	}, nil
+}
@@ -0,0 +1,235 @@
+package metadata //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var errStubMonitoringAuth = errors.New("stub: monitoring auth failed")
+
+type stubIMDS struct {
+	compartmentID string
+	region        string
+	shape         imds.ShapeConfig
+}
+
+func (s *stubIMDS) Region(context.Context) (string, error)          { return s.region, nil }
+func (s *stubIMDS) CanonicalRegion(context.Context) (string, error) { return s.region, nil }
+func (s *stubIMDS) InstanceID(context.Context) (string, error)      { return "ocid1.instance", nil }
+
+func (s *stubIMDS) CompartmentID(context.Context) (string, error) {
+	return s.compartmentID, nil
+}
+
+func (s *stubIMDS) ShapeConfig(context.Context) (imds.ShapeConfig, error) {
+	return s.shape, nil
+}
+
+type scopedMetricsClient struct {
+	scope string
+}
+
+func (s scopedMetricsClient) QueryP95CPU(context.Context, string) (float64, error) {
+	if strings.HasSuffix(s.scope, "moved") {
+		return 0.3, nil
+	}
+
+	return 0.1, nil
+}
+
+func TestWatcherReportsChangesAndRebindsClients(t *testing.T) {
+	t.Parallel()
+
+	var built []string
+
+	monitoring := NewMonitoringClients(
+		func(compartmentID, region string) (oci.MetricsClient, error) {
+			built = append(built, compartmentID+"@"+region)
+
+			return scopedMetricsClient{scope: compartmentID}, nil
+		},
+	)
+
+	followed, err := monitoring.Build("ocid1.compartment.oc1..original", "us-phoenix-1")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	_, err = monitoring.Build("ocid1.compartment.oc1..pinned", "us-phoenix-1")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	client := &stubIMDS{
+		compartmentID: "ocid1.compartment.oc1..original",
+		region:        "us-phoenix-1",
+		shape:         imds.ShapeConfig{OCPUs: 1, MemoryInGBs: 6},
+	}
+	logger := new(logtest.Recorder)
+
+	var fields []string
+
+	watcher := NewWatcher(client, monitoring)
+	watcher.SetLogger(logger)
+	watcher.SetChangeHandler(func(change Change) { fields = append(fields, change.Field) })
+
+	watcher.Check(t.Context())
+	watcher.Check(t.Context())
+
+	if len(logger.Lines()) != 0 || len(built) != 2 {
+		t.Fatalf("expected no reports, got logs %v and builds %v", logger.Lines(), built)
+	}
+
+	client.compartmentID = "ocid1.compartment.oc1..moved"
+	client.shape = imds.ShapeConfig{OCPUs: 2, MemoryInGBs: 12}
+
+	watcher.Check(t.Context())
+
+	if !slices.Equal(fields, []string{"compartmentId", "ocpus", "memoryInGBs"}) {
+		t.Fatalf("expected compartment, ocpu and memory changes, got %v", fields)
+	}
+
+	if logger.Count("warn: instance metadata changed") != 3 {
+		t.Fatalf("expected three change warnings, got %v", logger.Lines())
+	}
+
+	if len(built) != 3 || built[2] != "ocid1.compartment.oc1..moved@us-phoenix-1" {
+		t.Fatalf("expected only the followed client to be rebuilt, got %v", built)
+	}
+
+	p95, err := followed.QueryP95CPU(t.Context(), "ocid1.instance")
+	if err != nil || p95 != 0.3 {
+		t.Fatalf("expected the rebuilt delegate to serve queries, got %v (err=%v)", p95, err)
+	}
+}
+
+func TestWatcherRetriesFailedRebuilds(t *testing.T) {
+	t.Parallel()
+
+	fail := false
+
+	monitoring := NewMonitoringClients(func(compartmentID, _ string) (oci.MetricsClient, error) {
+		if fail {
+			return nil, errStubMonitoringAuth
+		}
+
+		return scopedMetricsClient{scope: compartmentID}, nil
+	})
+
+	followed, err := monitoring.Build("ocid1.compartment.oc1..original", "us-phoenix-1")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	client := &stubIMDS{
+		compartmentID: "ocid1.compartment.oc1..original",
+		region:        "us-phoenix-1",
+		shape:         imds.ShapeConfig{OCPUs: 1, MemoryInGBs: 6},
+	}
+	logger := new(logtest.Recorder)
+	watcher := NewWatcher(client, monitoring)
+	watcher.SetLogger(logger)
+
+	watcher.Check(t.Context())
+
+	client.compartmentID = "ocid1.compartment.oc1..moved"
+	fail = true
+
+	watcher.Check(t.Context())
+
+	if logger.Count("error: failed to rebuild monitoring client") != 1 {
+		t.Fatalf("expected the failed rebuild to be logged, got %v", logger.Lines())
+	}
+
+	p95, err := followed.QueryP95CPU(t.Context(), "ocid1.instance")
+	if err != nil || p95 != 0.1 {
+		t.Fatalf("expected the original delegate to keep serving, got %v (err=%v)", p95, err)
+	}
+
+	fail = false
+
+	watcher.Check(t.Context())
+
+	if logger.Count("warn: instance metadata changed") != 1 {
+		t.Fatalf("expected the change to be reported once, got %v", logger.Lines())
+	}
+
+	p95, err = followed.QueryP95CPU(t.Context(), "ocid1.instance")
+	if err != nil || p95 != 0.3 {
+		t.Fatalf("expected the retried rebuild to take effect, got %v (err=%v)", p95, err)
+	}
+}
+
+type trackingMetricsClient struct {
+	scopedMetricsClient
+
+	skew   func(skew time.Duration)
+	token  func(expiry time.Time)
+	window func(window oci.QueryWindow)
+}
+
+func (c *trackingMetricsClient) SetClockSkewHandler(handler func(skew time.Duration)) {
+	c.skew = handler
+}
+
+func (c *trackingMetricsClient) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	c.token = handler
+}
+
+func (c *trackingMetricsClient) SetQueryWindowHandler(handler func(window oci.QueryWindow)) {
+	c.window = handler
+}
+
+func TestMonitoringClientsForwardHandlersToRebuiltDelegates(t *testing.T) {
+	t.Parallel()
+
+	var delegates []*trackingMetricsClient
+
+	monitoring := NewMonitoringClients(func(compartmentID, _ string) (oci.MetricsClient, error) {
+		delegate := new(trackingMetricsClient)
+		delegate.scope = compartmentID
+		delegates = append(delegates, delegate)
+
+		return delegate, nil
+	})
+
+	client, err := monitoring.Build("ocid1.compartment.oc1..original", "us-phoenix-1")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	tracker, ok := client.(interface {
+		clockSkewTracker
+		tokenExpiryTracker
+		queryWindowTracker
+	})
+	if !ok {
+		t.Fatalf("expected the built client to forward every handler, got %T", client)
+	}
+
+	tracker.SetClockSkewHandler(func(time.Duration) {})
+	tracker.SetTokenExpiryHandler(func(time.Time) {})
+	tracker.SetQueryWindowHandler(func(oci.QueryWindow) {})
+
+	previous := Instance{CompartmentID: "ocid1.compartment.oc1..original", Region: "us-phoenix-1"}
+	current := Instance{CompartmentID: "ocid1.compartment.oc1..moved", Region: "us-phoenix-1"}
+
+	rebound, err := monitoring.Rebind(previous, current)
+	if err != nil || rebound != 1 {
+		t.Fatalf("expected one client rebuilt, got %d (err=%v)", rebound, err)
+	}
+
+	for index, delegate := range delegates {
+		if delegate.skew == nil || delegate.token == nil || delegate.window == nil {
+			t.Fatalf("expected delegate %d to receive every handler, got %+v", index, delegate)
+		}
+	}
+}
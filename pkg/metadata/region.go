@@ -0,0 +1,64 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oci-cpu-shaper/pkg/imds"
+)
+
+// RegionCheck pairs the configured region with the region IMDS reports for
+// the running instance. Monitoring answers queries for an instance in another
+// region with an empty result rather than an error, so a mismatch otherwise
+// goes unnoticed.
+type RegionCheck struct {
+	// Configured is the region set through oci.region.
+	Configured string
+	// Region is the region IMDS reports, which is a short code such as iad
+	// for older regions.
+	Region string
+	// Canonical is the instance's canonical region name, such as us-ashburn-1.
+	Canonical string
+}
+
+// CheckRegion reads the region of the running instance and pairs it with
+// configured.
+func CheckRegion(ctx context.Context, client imds.Client, configured string) (RegionCheck, error) {
+	region, err := client.Region(ctx)
+	if err != nil {
+		return RegionCheck{}, fmt.Errorf("lookup instance region: %w", err)
+	}
+
+	canonical, err := client.CanonicalRegion(ctx)
+	if err != nil {
+		return RegionCheck{}, fmt.Errorf("lookup canonical region: %w", err)
+	}
+
+	return RegionCheck{
+		Configured: strings.TrimSpace(configured),
+		Region:     strings.TrimSpace(region),
+		Canonical:  strings.TrimSpace(canonical),
+	}, nil
+}
+
+// Instance returns the region to build clients for the running instance: the
+// canonical name, or the IMDS region when the canonical name is unknown.
+func (c RegionCheck) Instance() string {
+	if c.Canonical != "" {
+		return c.Canonical
+	}
+
+	return c.Region
+}
+
+// Mismatch reports whether Configured names neither the IMDS region nor the
+// canonical region, ignoring case. An empty side never mismatches.
+func (c RegionCheck) Mismatch() bool {
+	if c.Configured == "" || c.Instance() == "" {
+		return false
+	}
+
+	return !strings.EqualFold(c.Configured, c.Region) &&
+		!strings.EqualFold(c.Configured, c.Canonical)
+}
@@ -0,0 +1,97 @@
+package metadata //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"oci-cpu-shaper/pkg/imds"
+)
+
+var errStubIMDS = errors.New("stub: imds unreachable")
+
+type canonicalIMDS struct {
+	stubIMDS
+
+	canonical    string
+	regionErr    error
+	canonicalErr error
+}
+
+func (c *canonicalIMDS) Region(context.Context) (string, error) {
+	return c.region, c.regionErr
+}
+
+func (c *canonicalIMDS) CanonicalRegion(context.Context) (string, error) {
+	return c.canonical, c.canonicalErr
+}
+
+func TestCheckRegionDetectsMismatch(t *testing.T) {
+	t.Parallel()
+
+	client := &canonicalIMDS{
+		stubIMDS:     stubIMDS{compartmentID: "", region: " iad ", shape: imds.ShapeConfig{}},
+		canonical:    "us-ashburn-1\n",
+		regionErr:    nil,
+		canonicalErr: nil,
+	}
+
+	tests := map[string]struct {
+		configured string
+		mismatch   bool
+	}{
+		"canonical name":   {configured: "us-ashburn-1", mismatch: false},
+		"short code":       {configured: "IAD", mismatch: false},
+		"other region":     {configured: " us-phoenix-1 ", mismatch: true},
+		"region unset":     {configured: "", mismatch: false},
+		"canonical casing": {configured: "US-Ashburn-1", mismatch: false},
+	}
+
+	for name, test := range tests {
+		check, err := CheckRegion(t.Context(), client, test.configured)
+		if err != nil {
+			t.Fatalf("%s: CheckRegion: %v", name, err)
+		}
+
+		if check.Region != "iad" || check.Instance() != "us-ashburn-1" {
+			t.Fatalf("%s: expected trimmed IMDS regions, got %+v", name, check)
+		}
+
+		if check.Mismatch() != test.mismatch {
+			t.Fatalf("%s: expected mismatch %v, got %+v", name, test.mismatch, check)
+		}
+	}
+
+	short := RegionCheck{Configured: "us-ashburn-1", Region: "iad", Canonical: ""}
+	if short.Instance() != "iad" || !short.Mismatch() {
+		t.Fatalf("expected the IMDS region without a canonical name, got %+v", short)
+	}
+
+	if (RegionCheck{Configured: "us-ashburn-1", Region: "", Canonical: ""}).Mismatch() {
+		t.Fatal("expected no mismatch without an IMDS region")
+	}
+}
+
+func TestCheckRegionReportsIMDSFailures(t *testing.T) {
+	t.Parallel()
+
+	client := &canonicalIMDS{
+		stubIMDS:     stubIMDS{compartmentID: "", region: "iad", shape: imds.ShapeConfig{}},
+		canonical:    "",
+		regionErr:    errStubIMDS,
+		canonicalErr: nil,
+	}
+
+	_, err := CheckRegion(t.Context(), client, "us-ashburn-1")
+	if !errors.Is(err, errStubIMDS) {
+		t.Fatalf("expected the region lookup error, got %v", err)
+	}
+
+	client.regionErr = nil
+	client.canonicalErr = errStubIMDS
+
+	_, err = CheckRegion(t.Context(), client, "us-ashburn-1")
+	if !errors.Is(err, errStubIMDS) {
+		t.Fatalf("expected the canonical region lookup error, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package metadata
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// Custom instance metadata keys written by StatusPublisher. They show up in
+// the console under the instance's metadata and in
+// `oci compute instance get --query data.metadata`.
+const (
+	StatusKeyMode    = "oci-cpu-shaper-mode"
+	StatusKeyState   = "oci-cpu-shaper-state"
+	StatusKeyTarget  = "oci-cpu-shaper-target"
+	StatusKeyUpdated = "oci-cpu-shaper-updated"
+)
+
+// Status is the shaper status published into custom instance metadata.
+type Status struct {
+	Mode   string
+	State  string
+	Target float64
+}
+
+func (s Status) values() map[string]string {
+	return map[string]string{
+		StatusKeyMode:   s.Mode,
+		StatusKeyState:  s.State,
+		StatusKeyTarget: strconv.FormatFloat(s.Target, 'f', 3, 64),
+	}
+}
+
+// StatusWriter merges key/value pairs into the custom metadata of an instance
+// and reports whether an update was sent. oci.ComputeClient implements it.
+type StatusWriter interface {
+	SetInstanceMetadata(
+		ctx context.Context,
+		instanceOCID string,
+		values map[string]string,
+	) (bool, error)
+}
+
+// StatusPublisher writes the shaper status into the instance's custom
+// metadata, so external automation and the console can see it per instance
+// without reaching the daemon.
+type StatusPublisher struct {
+	writer     StatusWriter
+	instanceID string
+	status     func() Status
+	now        func() time.Time
+
+	handlerMu sync.RWMutex
+	logger    logging.Logger
+
+	// last holds the values of the last published status. It only advances
+	// once an update succeeds, so failed writes are retried.
+	last map[string]string
+}
+
+// NewStatusPublisher constructs a StatusPublisher that reads the status to
+// publish from status and writes it to instanceID through writer.
+func NewStatusPublisher(
+	writer StatusWriter,
+	instanceID string,
+	status func() Status,
+) *StatusPublisher {
+	return &StatusPublisher{writer: writer, instanceID: instanceID, status: status, now: time.Now}
+}
+
+// SetLogger installs the logger used for publish failures and updates.
+func (p *StatusPublisher) SetLogger(logger logging.Logger) {
+	p.handlerMu.Lock()
+	defer p.handlerMu.Unlock()
+
+	p.logger = logger
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (p *StatusPublisher) log() logging.Logger {
+	p.handlerMu.RLock()
+	defer p.handlerMu.RUnlock()
+
+	return logging.OrNop(p.logger)
+}
+
+// Run publishes the status immediately and then every interval until ctx is
+// done.
+func (p *StatusPublisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.Publish(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish writes the current status, stamped with StatusKeyUpdated, when it
+// differs from the last one published. Targets are compared at the three
+// decimals written. Publish is not safe for concurrent use.
+func (p *StatusPublisher) Publish(ctx context.Context) {
+	status := p.status()
+	current := status.values()
+
+	if p.last != nil && maps.Equal(current, p.last) {
+		return
+	}
+
+	values := maps.Clone(current)
+	values[StatusKeyUpdated] = p.now().UTC().Format(time.RFC3339)
+
+	_, err := p.writer.SetInstanceMetadata(ctx, p.instanceID, values)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.log().Warn("failed to publish status to instance metadata", "error", err)
+		}
+
+		return
+	}
+
+	p.last = current
+
+	p.log().Debug(
+		"status published to instance metadata",
+		"mode", status.Mode,
+		"state", status.State,
+		"target", status.Target,
+	)
+}
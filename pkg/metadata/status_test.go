@@ -0,0 +1,116 @@
+package metadata //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+var errStubMetadataWrite = errors.New("stub: update instance failed")
+
+type recordingStatusWriter struct {
+	writes []map[string]string
+	err    error
+}
+
+func (r *recordingStatusWriter) SetInstanceMetadata(
+	_ context.Context,
+	instanceOCID string,
+	values map[string]string,
+) (bool, error) {
+	if instanceOCID != "ocid1.instance" {
+		return false, errStubMetadataWrite
+	}
+
+	r.writes = append(r.writes, values)
+
+	return true, r.err
+}
+
+func TestStatusPublisherWritesChangedStatus(t *testing.T) {
+	t.Parallel()
+
+	writer := new(recordingStatusWriter)
+	status := Status{Mode: "enforce", State: "normal", Target: 0.25}
+
+	publisher := NewStatusPublisher(writer, "ocid1.instance", func() Status { return status })
+	publisher.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	publisher.Publish(t.Context())
+	publisher.Publish(t.Context())
+
+	// Changes below the published precision are not written.
+	status.Target = 0.2501
+	publisher.Publish(t.Context())
+
+	if len(writer.writes) != 1 {
+		t.Fatalf("expected one write for an unchanged status, got %d", len(writer.writes))
+	}
+
+	first := writer.writes[0]
+	for key, want := range map[string]string{
+		StatusKeyMode:    "enforce",
+		StatusKeyState:   "normal",
+		StatusKeyTarget:  "0.250",
+		StatusKeyUpdated: "2024-06-01T12:00:00Z",
+	} {
+		if first[key] != want {
+			t.Fatalf("%s = %q, want %q", key, first[key], want)
+		}
+	}
+
+	status.State = "fallback"
+	publisher.Publish(t.Context())
+
+	if len(writer.writes) != 2 || writer.writes[1][StatusKeyState] != "fallback" {
+		t.Fatalf("expected the state change to be written, got %v", writer.writes)
+	}
+}
+
+func TestStatusPublisherRetriesFailedWrites(t *testing.T) {
+	t.Parallel()
+
+	writer := &recordingStatusWriter{writes: nil, err: errStubMetadataWrite}
+	logger := new(logtest.Recorder)
+
+	publisher := NewStatusPublisher(writer, "ocid1.instance", func() Status {
+		return Status{Mode: "dry-run", State: "normal", Target: 0.3}
+	})
+	publisher.SetLogger(logger)
+
+	publisher.Publish(t.Context())
+
+	writer.err = nil
+	publisher.Publish(t.Context())
+	publisher.Publish(t.Context())
+
+	if len(writer.writes) != 2 {
+		t.Fatalf("expected the failed write to be retried once, got %d writes", len(writer.writes))
+	}
+
+	if logger.Count("warn: failed to publish status to instance metadata") != 1 {
+		t.Fatalf("expected one publish failure warning, got %v", logger.Lines())
+	}
+}
+
+func TestStatusPublisherRunStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	writer := new(recordingStatusWriter)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	publisher := NewStatusPublisher(writer, "ocid1.instance", func() Status {
+		cancel()
+
+		return Status{Mode: "enforce", State: "normal", Target: 0.25}
+	})
+
+	publisher.Run(ctx, time.Hour)
+
+	if len(writer.writes) != 1 {
+		t.Fatalf("expected one write before stopping, got %d", len(writer.writes))
+	}
+}
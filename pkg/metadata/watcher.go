@@ -0,0 +1,146 @@
+package metadata
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// Watcher re-reads instance metadata from IMDS, reports changes, and rebinds
+// Monitoring clients when the compartment or region moves.
+type Watcher struct {
+	client  imds.Client
+	clients *MonitoringClients
+
+	handlerMu     sync.RWMutex
+	logger        logging.Logger
+	changeHandler func(Change)
+
+	last  Instance
+	known bool
+	// bound is the metadata the Monitoring clients were last built for. It
+	// only advances once a rebuild succeeds, so failed rebuilds are retried.
+	bound Instance
+}
+
+// NewWatcher constructs a Watcher reading from client. A nil clients skips
+// rebinding and only reports changes.
+func NewWatcher(client imds.Client, clients *MonitoringClients) *Watcher {
+	return &Watcher{client: client, clients: clients}
+}
+
+// SetLogger installs the logger used for refresh failures, changes and rebinds.
+func (w *Watcher) SetLogger(logger logging.Logger) {
+	w.handlerMu.Lock()
+	defer w.handlerMu.Unlock()
+
+	w.logger = logger
+}
+
+// SetChangeHandler installs a callback invoked once per changed field.
+func (w *Watcher) SetChangeHandler(handler func(Change)) {
+	w.handlerMu.Lock()
+	defer w.handlerMu.Unlock()
+
+	w.changeHandler = handler
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (w *Watcher) log() logging.Logger {
+	w.handlerMu.RLock()
+	defer w.handlerMu.RUnlock()
+
+	return logging.OrNop(w.logger)
+}
+
+func (w *Watcher) onChange() func(Change) {
+	w.handlerMu.RLock()
+	defer w.handlerMu.RUnlock()
+
+	return w.changeHandler
+}
+
+// Run checks metadata immediately and then every interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads metadata once. The first successful read records the baseline;
+// later reads report every changed field and rebind Monitoring clients. Check
+// is not safe for concurrent use.
+func (w *Watcher) Check(ctx context.Context) {
+	current, err := Read(ctx, w.client)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.log().Warn("failed to refresh instance metadata", "error", err)
+		}
+
+		return
+	}
+
+	if !w.known {
+		w.last = current
+		w.bound = current
+		w.known = true
+
+		return
+	}
+
+	changes := w.last.Diff(current)
+	w.last = current
+	handler := w.onChange()
+
+	for _, change := range changes {
+		w.log().Warn(
+			"instance metadata changed",
+			"field", change.Field,
+			"previous", change.Previous,
+			"current", change.Current,
+		)
+
+		if handler != nil {
+			handler(change)
+		}
+	}
+
+	w.rebindClients(current)
+}
+
+func (w *Watcher) rebindClients(current Instance) {
+	if w.clients == nil ||
+		(w.bound.CompartmentID == current.CompartmentID && w.bound.Region == current.Region) {
+		return
+	}
+
+	rebound, err := w.clients.Rebind(w.bound, current)
+	if err != nil {
+		w.log().Error("failed to rebuild monitoring client", "error", err)
+
+		return
+	}
+
+	w.bound = current
+
+	if rebound > 0 {
+		w.log().Info(
+			"monitoring client rebuilt for new instance metadata",
+			"compartmentId", current.CompartmentID,
+			"region", current.Region,
+			"clients", rebound,
+		)
+	}
+}
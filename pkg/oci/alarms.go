@@ -0,0 +1,442 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/monitoring"
+	"github.com/oracle/oci-go-sdk/v65/ons"
+)
+
+const alarmListPageLimit = 1000
+
+var (
+	// ErrGuardrailAlarmNotFound indicates that no active alarm in the compartment matches the
+	// seven-day P95 CpuUtilization guardrail for the instance.
+	ErrGuardrailAlarmNotFound = errors.New("oci: guardrail alarm not found")
+	// ErrAlarmDestinationsUnhealthy indicates that the guardrail alarm has no destinations or
+	// routes to a topic that is missing or not ACTIVE.
+	ErrAlarmDestinationsUnhealthy = errors.New("oci: guardrail alarm destinations unhealthy")
+	// ErrAlarmNotActive indicates that an updated alarm did not reach ACTIVE with the
+	// requested destinations before the wait ended, or is being deleted.
+	ErrAlarmNotActive = errors.New("oci: alarm did not become active")
+
+	errMissingAlarmClients = errors.New("oci: monitoring and notification clients are required")
+	errNilAlarmClient      = errors.New("oci: alarm client receiver is nil")
+	errMissingAlarmID      = errors.New("oci: alarm OCID is required")
+	errMissingTopicIDs     = errors.New("oci: at least one topic OCID is required")
+	errInvalidPollInterval = errors.New("oci: poll interval must be positive")
+)
+
+type alarmAPI interface {
+	ListAlarms(
+		ctx context.Context,
+		request monitoring.ListAlarmsRequest,
+	) (monitoring.ListAlarmsResponse, error)
+	GetAlarm(
+		ctx context.Context,
+		request monitoring.GetAlarmRequest,
+	) (monitoring.GetAlarmResponse, error)
+	UpdateAlarm(
+		ctx context.Context,
+		request monitoring.UpdateAlarmRequest,
+	) (monitoring.UpdateAlarmResponse, error)
+}
+
+type topicAPI interface {
+	ListTopics(ctx context.Context, request ons.ListTopicsRequest) (ons.ListTopicsResponse, error)
+	GetTopic(ctx context.Context, request ons.GetTopicRequest) (ons.GetTopicResponse, error)
+}
+
+// Topic summarises an OCI Notifications topic that can receive alarm messages.
+type Topic struct {
+	ID    string
+	Name  string
+	State string
+}
+
+// GuardrailAlarm identifies the alarm guarding the instance's seven-day P95 CpuUtilization.
+type GuardrailAlarm struct {
+	ID           string
+	DisplayName  string
+	Destinations []string
+	// Suppression is the alarm's suppression window; it is zero when none is set.
+	Suppression AlarmSuppression
+}
+
+// AlarmStatus is an observation of an alarm's lifecycle state and destinations.
+type AlarmStatus struct {
+	State        string
+	Destinations []string
+}
+
+// AlarmSuppression is a window during which an alarm sends no notifications.
+type AlarmSuppression struct {
+	From        time.Time
+	Until       time.Time
+	Description string
+}
+
+// SilencedUntil returns when the suppression covering now ends, or the zero time
+// when the alarm is not suppressed at now.
+func (a GuardrailAlarm) SilencedUntil(now time.Time) time.Time {
+	window := a.Suppression
+	if window.Until.IsZero() || now.Before(window.From) || !now.Before(window.Until) {
+		return time.Time{}
+	}
+
+	return window.Until
+}
+
+// AlarmClient manages the guardrail alarm and the Notifications topics it routes to.
+type AlarmClient struct {
+	alarms alarmAPI
+	topics topicAPI
+}
+
+// NewInstancePrincipalAlarmClient constructs an AlarmClient authenticated with the instance
+// principal. The region pins the Monitoring and Notifications endpoints when it is non-empty.
+func NewInstancePrincipalAlarmClient(region string) (*AlarmClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	monitoringClient, err := monitoring.NewMonitoringClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create monitoring client: %w", err)
+	}
+
+	topicClient, err := ons.NewNotificationControlPlaneClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create notification client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		monitoringClient.SetRegion(trimmedRegion)
+		topicClient.SetRegion(trimmedRegion)
+	}
+
+	return newAlarmClient(monitoringClient, topicClient)
+}
+
+func newAlarmClient(alarms alarmAPI, topics topicAPI) (*AlarmClient, error) {
+	if alarms == nil || topics == nil {
+		return nil, errMissingAlarmClients
+	}
+
+	return &AlarmClient{alarms: alarms, topics: topics}, nil
+}
+
+// ListTopics returns every Notifications topic in the compartment.
+func (c *AlarmClient) ListTopics(ctx context.Context, compartmentID string) ([]Topic, error) {
+	if c == nil || c.topics == nil {
+		return nil, errNilAlarmClient
+	}
+
+	if compartmentID == "" {
+		return nil, errMissingCompartmentID
+	}
+
+	request := ons.ListTopicsRequest{ //nolint:exhaustruct
+		CompartmentId: common.String(compartmentID),
+		Limit:         common.Int(alarmListPageLimit),
+	}
+
+	topics := make([]Topic, 0)
+
+	for {
+		response, err := c.topics.ListTopics(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("list topics: %w", err)
+		}
+
+		for _, item := range response.Items {
+			topics = append(topics, Topic{
+				ID:    stringValue(item.TopicId),
+				Name:  stringValue(item.Name),
+				State: string(item.LifecycleState),
+			})
+		}
+
+		if response.OpcNextPage == nil || *response.OpcNextPage == "" {
+			return topics, nil
+		}
+
+		request.Page = response.OpcNextPage
+	}
+}
+
+// FindGuardrailAlarm locates the active alarm enforcing the seven-day P95 CpuUtilization
+// guardrail for instanceID. ErrGuardrailAlarmNotFound is returned when none matches.
+func (c *AlarmClient) FindGuardrailAlarm(
+	ctx context.Context,
+	compartmentID string,
+	instanceID string,
+) (GuardrailAlarm, error) {
+	if c == nil || c.alarms == nil {
+		return GuardrailAlarm{}, errNilAlarmClient
+	}
+
+	if compartmentID == "" {
+		return GuardrailAlarm{}, errMissingCompartmentID
+	}
+
+	if instanceID == "" {
+		return GuardrailAlarm{}, errMissingInstanceOCID
+	}
+
+	request := monitoring.ListAlarmsRequest{ //nolint:exhaustruct
+		CompartmentId:  common.String(compartmentID),
+		LifecycleState: monitoring.AlarmLifecycleStateActive,
+		Limit:          common.Int(alarmListPageLimit),
+	}
+
+	for {
+		response, err := c.alarms.ListAlarms(ctx, request)
+		if err != nil {
+			return GuardrailAlarm{}, fmt.Errorf("list alarms: %w", err)
+		}
+
+		for _, summary := range response.Items {
+			if !GuardrailQuery(instanceID).Matches(stringValue(summary.Query)) {
+				continue
+			}
+
+			detail, err := c.alarms.GetAlarm(
+				ctx,
+				monitoring.GetAlarmRequest{AlarmId: summary.Id}, //nolint:exhaustruct
+			)
+			if err != nil {
+				return GuardrailAlarm{}, fmt.Errorf(
+					"get alarm %s: %w",
+					stringValue(summary.Id),
+					err,
+				)
+			}
+
+			return GuardrailAlarm{
+				ID:           stringValue(detail.Id),
+				DisplayName:  stringValue(detail.DisplayName),
+				Destinations: append([]string(nil), detail.Destinations...),
+				Suppression:  alarmSuppression(detail.Suppression),
+			}, nil
+		}
+
+		if response.OpcNextPage == nil || *response.OpcNextPage == "" {
+			return GuardrailAlarm{}, ErrGuardrailAlarmNotFound
+		}
+
+		request.Page = response.OpcNextPage
+	}
+}
+
+// SetAlarmDestinations replaces the destinations of alarmID with topicIDs.
+func (c *AlarmClient) SetAlarmDestinations(
+	ctx context.Context,
+	alarmID string,
+	topicIDs []string,
+) error {
+	if c == nil || c.alarms == nil {
+		return errNilAlarmClient
+	}
+
+	if alarmID == "" {
+		return errMissingAlarmID
+	}
+
+	if len(topicIDs) == 0 {
+		return errMissingTopicIDs
+	}
+
+	request := monitoring.UpdateAlarmRequest{ //nolint:exhaustruct
+		AlarmId: common.String(alarmID),
+		UpdateAlarmDetails: monitoring.UpdateAlarmDetails{ //nolint:exhaustruct
+			Destinations: append([]string(nil), topicIDs...),
+		},
+	}
+
+	_, err := c.alarms.UpdateAlarm(ctx, request)
+	if err != nil {
+		return fmt.Errorf("update alarm %s: %w", alarmID, err)
+	}
+
+	return nil
+}
+
+// WaitForAlarmActive polls alarmID every interval until it is ACTIVE and, when
+// destinations is non-empty, routes to exactly those topics, so callers learn
+// that an update has taken effect rather than only that it was accepted. Each
+// observation that differs from the previous one is passed to report. The wait
+// ends with ErrAlarmNotActive when ctx is done first or the alarm is deleted.
+func (c *AlarmClient) WaitForAlarmActive(
+	ctx context.Context,
+	alarmID string,
+	destinations []string,
+	interval time.Duration,
+	report func(AlarmStatus),
+) error {
+	if c == nil || c.alarms == nil {
+		return errNilAlarmClient
+	}
+
+	if alarmID == "" {
+		return errMissingAlarmID
+	}
+
+	if interval <= 0 {
+		return errInvalidPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last AlarmStatus
+
+	for {
+		response, err := c.alarms.GetAlarm(
+			ctx,
+			monitoring.GetAlarmRequest{AlarmId: common.String(alarmID)}, //nolint:exhaustruct
+		)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("get alarm %s: %w", alarmID, err)
+		}
+
+		if err == nil {
+			status := AlarmStatus{
+				State:        string(response.LifecycleState),
+				Destinations: append([]string(nil), response.Destinations...),
+			}
+
+			if report != nil && !sameAlarmStatus(status, last) {
+				report(status)
+			}
+
+			last = status
+
+			switch {
+			case response.LifecycleState == monitoring.AlarmLifecycleStateActive &&
+				(len(destinations) == 0 || sameSet(status.Destinations, destinations)):
+				return nil
+			case response.LifecycleState == monitoring.AlarmLifecycleStateDeleting ||
+				response.LifecycleState == monitoring.AlarmLifecycleStateDeleted:
+				return fmt.Errorf("%w: alarm %s is %s", ErrAlarmNotActive, alarmID, status.State)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"%w: alarm %s last seen %s: %w",
+				ErrAlarmNotActive,
+				alarmID,
+				last.State,
+				ctx.Err(),
+			)
+		case <-ticker.C:
+		}
+	}
+}
+
+// VerifyDestinations confirms that alarm routes to at least one topic and that every
+// destination is an ACTIVE Notifications topic. Failures wrap
+// ErrAlarmDestinationsUnhealthy and name the offending destinations.
+func (c *AlarmClient) VerifyDestinations(ctx context.Context, alarm GuardrailAlarm) error {
+	if c == nil || c.topics == nil {
+		return errNilAlarmClient
+	}
+
+	if len(alarm.Destinations) == 0 {
+		return fmt.Errorf(
+			"%w: alarm %s has no destinations",
+			ErrAlarmDestinationsUnhealthy,
+			alarm.ID,
+		)
+	}
+
+	var problems []string
+
+	for _, destination := range alarm.Destinations {
+		response, err := c.topics.GetTopic(
+			ctx,
+			ons.GetTopicRequest{TopicId: common.String(destination)}, //nolint:exhaustruct
+		)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%v)", destination, err))
+
+			continue
+		}
+
+		if response.LifecycleState != ons.NotificationTopicLifecycleStateActive {
+			problems = append(
+				problems,
+				fmt.Sprintf("%s (state %s)", destination, response.LifecycleState),
+			)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf(
+			"%w: %s",
+			ErrAlarmDestinationsUnhealthy,
+			strings.Join(problems, ", "),
+		)
+	}
+
+	return nil
+}
+
+func sameAlarmStatus(a, b AlarmStatus) bool {
+	return a.State == b.State && slices.Equal(a.Destinations, b.Destinations)
+}
+
+func sameSet(actual, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	sortedActual := slices.Sorted(slices.Values(actual))
+	sortedExpected := slices.Sorted(slices.Values(expected))
+
+	return slices.Equal(sortedActual, sortedExpected)
+}
+
+func alarmSuppression(suppression *monitoring.Suppression) AlarmSuppression {
+	var window AlarmSuppression
+
+	if suppression == nil {
+		return window
+	}
+
+	if suppression.TimeSuppressFrom != nil {
+		window.From = suppression.TimeSuppressFrom.Time
+	}
+
+	if suppression.TimeSuppressUntil != nil {
+		window.Until = suppression.TimeSuppressUntil.Time
+	}
+
+	window.Description = stringValue(suppression.Description)
+
+	return window
+}
+
+func stringValue(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+
+	return *ptr
+}
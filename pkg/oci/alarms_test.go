@@ -0,0 +1,375 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/monitoring"
+	"github.com/oracle/oci-go-sdk/v65/ons"
+)
+
+const (
+	alarmTestCompartment = "ocid1.compartment.oc1..example"
+	alarmTestInstance    = "ocid1.instance.oc1..example"
+	alarmTestGuardrailID = "ocid1.alarm.oc1..guardrail"
+)
+
+type stubAlarmAPI struct {
+	pages   [][]monitoring.AlarmSummary
+	details map[string]monitoring.Alarm
+	updated monitoring.UpdateAlarmRequest
+	err     error
+	// sequence, when set, is returned by successive GetAlarm calls; the last
+	// entry repeats.
+	sequence []monitoring.Alarm
+	gets     int
+}
+
+func (s *stubAlarmAPI) ListAlarms(
+	_ context.Context,
+	request monitoring.ListAlarmsRequest,
+) (monitoring.ListAlarmsResponse, error) {
+	var response monitoring.ListAlarmsResponse
+
+	if s.err != nil {
+		return response, s.err
+	}
+
+	index := 0
+	if request.Page != nil {
+		index = len(*request.Page)
+	}
+
+	response.Items = s.pages[index]
+	if index+1 < len(s.pages) {
+		response.OpcNextPage = common.String(strings.Repeat("p", index+1))
+	}
+
+	return response, nil
+}
+
+func (s *stubAlarmAPI) GetAlarm(
+	_ context.Context,
+	request monitoring.GetAlarmRequest,
+) (monitoring.GetAlarmResponse, error) {
+	var response monitoring.GetAlarmResponse
+
+	s.gets++
+
+	if len(s.sequence) > 0 {
+		response.Alarm = s.sequence[min(s.gets, len(s.sequence))-1]
+
+		return response, nil
+	}
+
+	response.Alarm = s.details[*request.AlarmId]
+
+	return response, nil
+}
+
+func (s *stubAlarmAPI) UpdateAlarm(
+	_ context.Context,
+	request monitoring.UpdateAlarmRequest,
+) (monitoring.UpdateAlarmResponse, error) {
+	s.updated = request
+
+	return monitoring.UpdateAlarmResponse{}, s.err
+}
+
+type stubTopicAPI struct {
+	topics []ons.NotificationTopicSummary
+	states map[string]ons.NotificationTopicLifecycleStateEnum
+}
+
+func (s *stubTopicAPI) ListTopics(
+	_ context.Context,
+	_ ons.ListTopicsRequest,
+) (ons.ListTopicsResponse, error) {
+	var response ons.ListTopicsResponse
+
+	response.Items = s.topics
+
+	return response, nil
+}
+
+func (s *stubTopicAPI) GetTopic(
+	_ context.Context,
+	request ons.GetTopicRequest,
+) (ons.GetTopicResponse, error) {
+	var response ons.GetTopicResponse
+
+	state, ok := s.states[*request.TopicId]
+	if !ok {
+		return response, errForcedFailure
+	}
+
+	response.LifecycleState = state
+
+	return response, nil
+}
+
+func guardrailQuery(instanceID string) *string {
+	return common.String(
+		"CpuUtilization[1m]{resourceId = \"" + instanceID + "\"}" +
+			".groupBy(resourceId).window(7d).percentile(0.95) < 20",
+	)
+}
+
+func TestFindGuardrailAlarmWalksPages(t *testing.T) {
+	t.Parallel()
+
+	alarms := &stubAlarmAPI{
+		pages: [][]monitoring.AlarmSummary{
+			{{Id: common.String("ocid1.alarm.oc1..other"), Query: guardrailQuery("ocid1.other")}},
+			{{Id: common.String(alarmTestGuardrailID), Query: guardrailQuery(alarmTestInstance)}},
+		},
+		details: map[string]monitoring.Alarm{
+			alarmTestGuardrailID: {
+				Id:           common.String(alarmTestGuardrailID),
+				DisplayName:  common.String("cpu-guardrail"),
+				Destinations: []string{"ocid1.onstopic.oc1..ops"},
+			},
+		},
+	}
+
+	client, err := newAlarmClient(alarms, new(stubTopicAPI))
+	requireNoError(t, err, "construct alarm client")
+
+	alarm, err := client.FindGuardrailAlarm(t.Context(), alarmTestCompartment, alarmTestInstance)
+	requireNoError(t, err, "find guardrail")
+	requireEqual(t, alarm.ID, alarmTestGuardrailID, "alarm id")
+	requireEqual(t, alarm.DisplayName, "cpu-guardrail", "display name")
+	requireEqual(t, len(alarm.Destinations), 1, "destination count")
+}
+
+func TestFindGuardrailAlarmReportsSuppression(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	until := from.Add(2 * time.Hour)
+
+	alarms := &stubAlarmAPI{
+		pages: [][]monitoring.AlarmSummary{
+			{{Id: common.String(alarmTestGuardrailID), Query: guardrailQuery(alarmTestInstance)}},
+		},
+		details: map[string]monitoring.Alarm{
+			alarmTestGuardrailID: {
+				Id: common.String(alarmTestGuardrailID),
+				Suppression: &monitoring.Suppression{
+					TimeSuppressFrom:  &common.SDKTime{Time: from},
+					TimeSuppressUntil: &common.SDKTime{Time: until},
+					Description:       common.String("maintenance"),
+				},
+			},
+		},
+	}
+
+	client, err := newAlarmClient(alarms, new(stubTopicAPI))
+	requireNoError(t, err, "construct alarm client")
+
+	alarm, err := client.FindGuardrailAlarm(t.Context(), alarmTestCompartment, alarmTestInstance)
+	requireNoError(t, err, "find guardrail")
+	requireEqual(t, alarm.Suppression.Description, "maintenance", "suppression description")
+	requireEqual(t, alarm.SilencedUntil(from.Add(-time.Minute)), time.Time{}, "before window")
+	requireEqual(t, alarm.SilencedUntil(from.Add(time.Hour)), until, "inside window")
+	requireEqual(t, alarm.SilencedUntil(until), time.Time{}, "after window")
+}
+
+func TestFindGuardrailAlarmReportsMissing(t *testing.T) {
+	t.Parallel()
+
+	alarms := &stubAlarmAPI{
+		pages: [][]monitoring.AlarmSummary{
+			{{
+				Id:    common.String("ocid1.alarm.oc1..mem"),
+				Query: common.String("MemoryUtilization[1m]"),
+			}},
+		},
+	}
+
+	client, err := newAlarmClient(alarms, new(stubTopicAPI))
+	requireNoError(t, err, "construct alarm client")
+
+	_, err = client.FindGuardrailAlarm(t.Context(), alarmTestCompartment, alarmTestInstance)
+	if !errors.Is(err, ErrGuardrailAlarmNotFound) {
+		t.Fatalf("expected ErrGuardrailAlarmNotFound, got %v", err)
+	}
+}
+
+func TestListTopicsMapsSummaries(t *testing.T) {
+	t.Parallel()
+
+	topics := &stubTopicAPI{
+		topics: []ons.NotificationTopicSummary{{
+			TopicId:        common.String("ocid1.onstopic.oc1..ops"),
+			Name:           common.String("ops"),
+			LifecycleState: ons.NotificationTopicSummaryLifecycleStateActive,
+		}},
+	}
+
+	client, err := newAlarmClient(new(stubAlarmAPI), topics)
+	requireNoError(t, err, "construct alarm client")
+
+	got, err := client.ListTopics(t.Context(), alarmTestCompartment)
+	requireNoError(t, err, "list topics")
+	requireEqual(t, len(got), 1, "topic count")
+	requireEqual(
+		t,
+		got[0],
+		Topic{ID: "ocid1.onstopic.oc1..ops", Name: "ops", State: "ACTIVE"},
+		"topic",
+	)
+}
+
+func TestSetAlarmDestinationsUpdatesAlarm(t *testing.T) {
+	t.Parallel()
+
+	alarms := new(stubAlarmAPI)
+
+	client, err := newAlarmClient(alarms, new(stubTopicAPI))
+	requireNoError(t, err, "construct alarm client")
+
+	err = client.SetAlarmDestinations(
+		t.Context(),
+		alarmTestGuardrailID,
+		[]string{"ocid1.onstopic.oc1..ops"},
+	)
+	requireNoError(t, err, "set destinations")
+	requireEqual(t, *alarms.updated.AlarmId, alarmTestGuardrailID, "updated alarm")
+	requireEqual(
+		t,
+		strings.Join(alarms.updated.Destinations, ","),
+		"ocid1.onstopic.oc1..ops",
+		"destinations",
+	)
+
+	err = client.SetAlarmDestinations(t.Context(), alarmTestGuardrailID, nil)
+	if !errors.Is(err, errMissingTopicIDs) {
+		t.Fatalf("expected errMissingTopicIDs, got %v", err)
+	}
+}
+
+func TestVerifyDestinations(t *testing.T) {
+	t.Parallel()
+
+	topics := &stubTopicAPI{
+		states: map[string]ons.NotificationTopicLifecycleStateEnum{
+			"ocid1.onstopic.oc1..ops":     ons.NotificationTopicLifecycleStateActive,
+			"ocid1.onstopic.oc1..retired": ons.NotificationTopicLifecycleStateDeleting,
+		},
+	}
+
+	client, err := newAlarmClient(new(stubAlarmAPI), topics)
+	requireNoError(t, err, "construct alarm client")
+
+	testCases := []struct {
+		name         string
+		destinations []string
+		wantErr      bool
+	}{
+		{name: "active", destinations: []string{"ocid1.onstopic.oc1..ops"}},
+		{name: "empty", wantErr: true},
+		{name: "inactive", destinations: []string{"ocid1.onstopic.oc1..retired"}, wantErr: true},
+		{name: "unknown", destinations: []string{"ocid1.onstopic.oc1..gone"}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := client.VerifyDestinations(
+				t.Context(),
+				GuardrailAlarm{ID: alarmTestGuardrailID, Destinations: testCase.destinations},
+			)
+			if testCase.wantErr != errors.Is(err, ErrAlarmDestinationsUnhealthy) {
+				t.Fatalf("unexpected verification result: %v", err)
+			}
+		})
+	}
+}
+
+func activeAlarm(destinations ...string) monitoring.Alarm {
+	return monitoring.Alarm{ //nolint:exhaustruct
+		LifecycleState: monitoring.AlarmLifecycleStateActive,
+		Destinations:   destinations,
+	}
+}
+
+func TestWaitForAlarmActiveReportsStatesUntilDestinationsApply(t *testing.T) {
+	t.Parallel()
+
+	alarms := &stubAlarmAPI{ //nolint:exhaustruct
+		sequence: []monitoring.Alarm{
+			activeAlarm("old"),
+			activeAlarm("old"),
+			activeAlarm("b", "a"),
+		},
+	}
+
+	client, err := newAlarmClient(alarms, &stubTopicAPI{}) //nolint:exhaustruct
+	if err != nil {
+		t.Fatalf("newAlarmClient: %v", err)
+	}
+
+	var reported []AlarmStatus
+
+	err = client.WaitForAlarmActive(
+		context.Background(),
+		alarmTestGuardrailID,
+		[]string{"a", "b"},
+		time.Millisecond,
+		func(status AlarmStatus) { reported = append(reported, status) },
+	)
+	if err != nil {
+		t.Fatalf("WaitForAlarmActive: %v", err)
+	}
+
+	if alarms.gets != 3 || len(reported) != 2 || reported[1].Destinations[0] != "b" {
+		t.Fatalf("expected three polls and two reports, got %d / %+v", alarms.gets, reported)
+	}
+}
+
+func TestWaitForAlarmActiveFailsOnDeletionAndTimeout(t *testing.T) {
+	t.Parallel()
+
+	alarms := &stubAlarmAPI{ //nolint:exhaustruct
+		sequence: []monitoring.Alarm{{LifecycleState: monitoring.AlarmLifecycleStateDeleting}},
+	}
+
+	client, err := newAlarmClient(alarms, &stubTopicAPI{}) //nolint:exhaustruct
+	if err != nil {
+		t.Fatalf("newAlarmClient: %v", err)
+	}
+
+	ctx := context.Background()
+
+	err = client.WaitForAlarmActive(ctx, alarmTestGuardrailID, nil, time.Millisecond, nil)
+	if !errors.Is(err, ErrAlarmNotActive) || !strings.Contains(err.Error(), "DELETING") {
+		t.Fatalf("expected a deleting alarm to fail, got %v", err)
+	}
+
+	alarms.sequence = []monitoring.Alarm{activeAlarm("old")}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+
+	err = client.WaitForAlarmActive(
+		timeoutCtx,
+		alarmTestGuardrailID,
+		[]string{"new"},
+		time.Millisecond,
+		nil,
+	)
+	if !errors.Is(err, ErrAlarmNotActive) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+
+	err = client.WaitForAlarmActive(ctx, alarmTestGuardrailID, nil, 0, nil)
+	if !errors.Is(err, errInvalidPollInterval) {
+		t.Fatalf("expected a zero interval to be rejected, got %v", err)
+	}
+}
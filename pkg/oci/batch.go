@@ -0,0 +1,191 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultQueryConcurrency bounds the SummarizeMetricsData calls a single control
+// step keeps in flight.
+const defaultQueryConcurrency = 3
+
+var errNetworkUnsupported = errors.New("oci: network totals unsupported")
+
+// QueryStep fetches the metrics for one control step from client. Clients that
+// implement StepMetricsClient answer with a single batch; others are queried
+// one metric after another.
+func QueryStep(
+	ctx context.Context,
+	client MetricsClient,
+	resourceID string,
+	query StepQuery,
+) (StepMetrics, error) {
+	if batch, ok := client.(StepMetricsClient); ok {
+		return batch.QueryStep(ctx, resourceID, query) //nolint:wrapcheck // transparent dispatch
+	}
+
+	return QueryStepSerially(ctx, client, resourceID, query)
+}
+
+// QueryStepSerially fetches the metrics for one control step through client's
+// individual query methods, one after another. Decorators implementing
+// StepMetricsClient use it when their delegate cannot batch.
+func QueryStepSerially(
+	ctx context.Context,
+	client MetricsClient,
+	resourceID string,
+	query StepQuery,
+) (StepMetrics, error) {
+	p95, err := client.QueryP95CPU(ctx, resourceID)
+	if err != nil {
+		return StepMetrics{}, err //nolint:wrapcheck // transparent dispatch
+	}
+
+	result := StepMetrics{P95CPU: p95}
+
+	if !query.Network {
+		return result, nil
+	}
+
+	network, ok := client.(NetworkMetricsClient)
+	if !ok {
+		result.NetworkErr = errNetworkUnsupported
+
+		return result, nil
+	}
+
+	result.Network, result.NetworkErr = network.QueryNetworkBytes7d(ctx, resourceID)
+
+	return result, nil
+}
+
+// QueryStep issues the CPU P95 query and, when requested, the inbound and
+// outbound network queries concurrently. Every query shares one window,
+// anchored on the skew-corrected clock and spanning the trailing seven days, so
+// the results describe the same period.
+func (c *Client) QueryStep(
+	ctx context.Context,
+	instanceOCID string,
+	query StepQuery,
+) (StepMetrics, error) {
+	if c == nil {
+		return StepMetrics{}, errNilClient
+	}
+
+	if instanceOCID == "" {
+		return StepMetrics{}, errMissingInstanceOCID
+	}
+
+	err := c.refreshToken()
+	if err != nil {
+		return StepMetrics{}, err
+	}
+
+	start, end := computeWindow(c.skewedNow(), true)
+	c.recordWindow(start, end)
+
+	scope := c.queryScope()
+	cpuRequest := buildSummarizeRequest(c.compartmentID, scope, instanceOCID, start, end)
+
+	var (
+		result   StepMetrics
+		cpuValue float32
+		cpuFound bool
+		cpuErr   error
+	)
+
+	jobs := []func(){
+		func() {
+			cpuValue, cpuFound, cpuErr = c.collectLatestDatapoint(ctx, cpuRequest)
+		},
+	}
+
+	var networkErrs []error
+
+	if query.Network {
+		metrics := networkMetrics(&result.Network)
+		networkErrs = make([]error, len(metrics))
+
+		for index, metric := range metrics {
+			request := buildQueryRequest(
+				c.compartmentID,
+				scope,
+				networkQuery(metric.name, instanceOCID),
+				start,
+				end,
+			)
+
+			jobs = append(jobs, func() {
+				sum, err := c.sumDatapoints(ctx, request)
+				if err != nil {
+					networkErrs[index] = fmt.Errorf("%s: %w", metric.name, err)
+
+					return
+				}
+
+				*metric.dst = sum
+			})
+		}
+	}
+
+	c.runQueries(jobs)
+
+	if cpuErr != nil {
+		return StepMetrics{}, cpuErr
+	}
+
+	if !cpuFound {
+		return StepMetrics{}, ErrNoMetricsData
+	}
+
+	result.P95CPU = float64(cpuValue)
+
+	if err := errors.Join(networkErrs...); err != nil {
+		result.Network = NetworkTotals{}
+		result.NetworkErr = err
+	}
+
+	return result, nil
+}
+
+// runQueries runs jobs concurrently, keeping at most queryConcurrency of them in
+// flight, and returns once all have finished.
+func (c *Client) runQueries(jobs []func()) {
+	limit := c.queryConcurrency
+	if limit <= 0 || limit > len(jobs) {
+		limit = len(jobs)
+	}
+
+	slots := make(chan struct{}, limit)
+
+	var group sync.WaitGroup
+
+	for _, job := range jobs {
+		slots <- struct{}{}
+
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+			defer func() { <-slots }()
+
+			job()
+		}()
+	}
+
+	group.Wait()
+}
+
+type networkMetric struct {
+	name string
+	dst  *float64
+}
+
+func networkMetrics(totals *NetworkTotals) []networkMetric {
+	return []networkMetric{
+		{name: metricNetworkBytesIn, dst: &totals.BytesIn},
+		{name: metricNetworkBytesOut, dst: &totals.BytesOut},
+	}
+}
@@ -0,0 +1,183 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/monitoring"
+)
+
+var errStubMonitoringThrottled = errors.New("stub: monitoring throttled")
+
+// queryKeyedMetricsClient answers by metric name so concurrent queries can be
+// served in any order, and records how many were in flight at once.
+type queryKeyedMetricsClient struct {
+	now     time.Time
+	values  map[string]float64
+	failing string
+
+	mu       sync.Mutex
+	requests []monitoring.SummarizeMetricsDataRequest
+	inFlight int
+	peak     int
+}
+
+func (s *queryKeyedMetricsClient) SummarizeMetricsData(
+	_ context.Context,
+	request monitoring.SummarizeMetricsDataRequest,
+	_ *string,
+) (monitoring.SummarizeMetricsDataResponse, *string, error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	query := *request.SummarizeMetricsDataDetails.Query
+	name := query[:strings.Index(query, "[")]
+
+	if name == s.failing {
+		return monitoring.SummarizeMetricsDataResponse{}, nil, errStubMonitoringThrottled
+	}
+
+	return metricResponse(metricData("instance", "compartment", s.now, s.values[name])), nil, nil
+}
+
+func TestQueryStepBatchesQueriesOverSharedWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 9, 12, 0, 0, 0, time.UTC)
+	stub := &queryKeyedMetricsClient{
+		now: now,
+		values: map[string]float64{
+			metricName:            0.31,
+			metricNetworkBytesIn:  350,
+			metricNetworkBytesOut: 900,
+		},
+	}
+
+	client, err := newTestClient(stub, "compartment", func() time.Time { return now })
+	requireNoError(t, err, "create client")
+
+	result, err := client.QueryStep(t.Context(), "instance", StepQuery{Network: true})
+	requireNoError(t, err, "QueryStep")
+	requireEqual(t, result.P95CPU, float64(float32(0.31)), "p95")
+	requireEqual(t, result.Network, NetworkTotals{BytesIn: 350, BytesOut: 900}, "network totals")
+	requireEqual(t, len(stub.requests), 3, "request count")
+
+	if stub.peak < 2 {
+		t.Fatalf("expected queries to run concurrently, peak in flight was %d", stub.peak)
+	}
+
+	for _, request := range stub.requests {
+		assertRequestWindow(t, request, now.Add(-7*24*time.Hour), now)
+	}
+
+	stub.requests, stub.peak = nil, 0
+	client.queryConcurrency = 1
+
+	result, err = client.QueryStep(t.Context(), "instance", StepQuery{Network: false})
+	requireNoError(t, err, "QueryStep without network")
+	requireEqual(t, result.Network, NetworkTotals{}, "network totals")
+	requireEqual(t, len(stub.requests), 1, "request count")
+
+	stub.requests = nil
+	stub.failing = metricNetworkBytesOut
+
+	_, err = client.QueryStep(t.Context(), "instance", StepQuery{Network: true})
+	requireNoError(t, err, "QueryStep with failing network query")
+	requireEqual(t, stub.peak, 1, "peak in flight under a limit of one")
+}
+
+func TestQueryStepReportsNetworkErrorsSeparately(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 9, 12, 0, 0, 0, time.UTC)
+	stub := &queryKeyedMetricsClient{
+		now:     now,
+		values:  map[string]float64{metricName: 0.5, metricNetworkBytesIn: 10},
+		failing: metricNetworkBytesOut,
+	}
+
+	client, err := newTestClient(stub, "compartment", func() time.Time { return now })
+	requireNoError(t, err, "create client")
+
+	result, err := client.QueryStep(t.Context(), "instance", StepQuery{Network: true})
+	requireNoError(t, err, "QueryStep")
+	requireEqual(t, result.P95CPU, 0.5, "p95")
+	requireEqual(t, result.Network, NetworkTotals{}, "network totals")
+
+	if !errors.Is(result.NetworkErr, errStubMonitoringThrottled) {
+		t.Fatalf("expected the network error to be reported, got %v", result.NetworkErr)
+	}
+
+	stub.failing = metricName
+
+	_, err = client.QueryStep(t.Context(), "instance", StepQuery{Network: true})
+	if !errors.Is(err, errStubMonitoringThrottled) {
+		t.Fatalf("expected the cpu error to fail the step, got %v", err)
+	}
+
+	_, err = client.QueryStep(t.Context(), "", StepQuery{})
+	if !errors.Is(err, errMissingInstanceOCID) {
+		t.Fatalf("expected errMissingInstanceOCID, got %v", err)
+	}
+}
+
+type serialMetricsClient struct {
+	p95     float64
+	network *NetworkTotals
+}
+
+func (s serialMetricsClient) QueryP95CPU(context.Context, string) (float64, error) {
+	return s.p95, nil
+}
+
+type serialNetworkMetricsClient struct {
+	serialMetricsClient
+}
+
+func (s serialNetworkMetricsClient) QueryNetworkBytes7d(
+	context.Context,
+	string,
+) (NetworkTotals, error) {
+	return *s.network, nil
+}
+
+func TestQueryStepFallsBackToSerialQueries(t *testing.T) {
+	t.Parallel()
+
+	totals := NetworkTotals{BytesIn: 1, BytesOut: 2}
+
+	result, err := QueryStep(
+		t.Context(),
+		serialNetworkMetricsClient{serialMetricsClient{p95: 0.2, network: &totals}},
+		"instance",
+		StepQuery{Network: true},
+	)
+	requireNoError(t, err, "QueryStep")
+	requireEqual(t, result.P95CPU, 0.2, "p95")
+	requireEqual(t, result.Network, totals, "network totals")
+
+	result, err = QueryStep(
+		t.Context(),
+		serialMetricsClient{p95: 0.2},
+		"instance",
+		StepQuery{Network: true},
+	)
+	requireNoError(t, err, "QueryStep without network support")
+
+	if !errors.Is(result.NetworkErr, errNetworkUnsupported) {
+		t.Fatalf("expected errNetworkUnsupported, got %v", result.NetworkErr)
+	}
+}
@@ -0,0 +1,163 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+var (
+	errMissingComputeClient = errors.New("oci: compute client is required")
+	errNilComputeClient     = errors.New("oci: compute client receiver is nil")
+	errMissingDisplayName   = errors.New("oci: instance display name unavailable")
+)
+
+type computeAPI interface {
+	GetInstance(
+		ctx context.Context,
+		request core.GetInstanceRequest,
+	) (core.GetInstanceResponse, error)
+	UpdateInstance(
+		ctx context.Context,
+		request core.UpdateInstanceRequest,
+	) (core.UpdateInstanceResponse, error)
+}
+
+// ComputeClient resolves human-readable instance attributes and maintains
+// custom instance metadata via the Core Compute API.
+type ComputeClient struct {
+	compute computeAPI
+}
+
+// NewInstancePrincipalComputeClient constructs a ComputeClient authenticated with the
+// instance principal. The region pins the Compute endpoint when it is non-empty.
+func NewInstancePrincipalComputeClient(region string) (*ComputeClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	computeClient, err := core.NewComputeClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create compute client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		computeClient.SetRegion(trimmedRegion)
+	}
+
+	return newComputeClient(computeClient)
+}
+
+func newComputeClient(compute computeAPI) (*ComputeClient, error) {
+	if compute == nil {
+		return nil, errMissingComputeClient
+	}
+
+	return &ComputeClient{compute: compute}, nil
+}
+
+// InstanceDisplayName returns the display name assigned to the supplied compute instance.
+func (c *ComputeClient) InstanceDisplayName(
+	ctx context.Context,
+	instanceOCID string,
+) (string, error) {
+	if c == nil || c.compute == nil {
+		return "", errNilComputeClient
+	}
+
+	if instanceOCID == "" {
+		return "", errMissingInstanceOCID
+	}
+
+	var request core.GetInstanceRequest
+
+	request.InstanceId = &instanceOCID
+
+	response, err := c.compute.GetInstance(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("get instance: %w", err)
+	}
+
+	if response.DisplayName == nil {
+		return "", errMissingDisplayName
+	}
+
+	name := strings.TrimSpace(*response.DisplayName)
+	if name == "" {
+		return "", errMissingDisplayName
+	}
+
+	return name, nil
+}
+
+// SetInstanceMetadata merges values into the custom metadata of the supplied
+// compute instance and reports whether an update was sent. Keys already
+// holding the requested values are left alone. UpdateInstance replaces the
+// whole metadata map, so the current map (including the immutable
+// ssh_authorized_keys and user_data keys) is read first and the update is
+// conditioned on its ETag; a concurrent change fails the update instead of
+// being overwritten.
+func (c *ComputeClient) SetInstanceMetadata(
+	ctx context.Context,
+	instanceOCID string,
+	values map[string]string,
+) (bool, error) {
+	if c == nil || c.compute == nil {
+		return false, errNilComputeClient
+	}
+
+	if instanceOCID == "" {
+		return false, errMissingInstanceOCID
+	}
+
+	var getRequest core.GetInstanceRequest
+
+	getRequest.InstanceId = &instanceOCID
+
+	current, err := c.compute.GetInstance(ctx, getRequest)
+	if err != nil {
+		return false, fmt.Errorf("get instance: %w", err)
+	}
+
+	merged := make(map[string]string, len(current.Metadata)+len(values))
+	maps.Copy(merged, current.Metadata)
+
+	changed := false
+
+	for key, value := range values {
+		existing, ok := merged[key]
+		if !ok || existing != value {
+			merged[key] = value
+			changed = true
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	var updateRequest core.UpdateInstanceRequest
+
+	updateRequest.InstanceId = &instanceOCID
+	updateRequest.IfMatch = current.Etag
+	updateRequest.UpdateInstanceDetails.Metadata = merged
+
+	_, err = c.compute.UpdateInstance(ctx, updateRequest)
+	if err != nil {
+		return false, fmt.Errorf("update instance metadata: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,226 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+type stubComputeAPI struct {
+	displayName *string
+	metadata    map[string]string
+	etag        *string
+	err         error
+	updateErr   error
+	requested   string
+	updates     []core.UpdateInstanceRequest
+}
+
+func (s *stubComputeAPI) GetInstance(
+	_ context.Context,
+	request core.GetInstanceRequest,
+) (core.GetInstanceResponse, error) {
+	if request.InstanceId != nil {
+		s.requested = *request.InstanceId
+	}
+
+	var response core.GetInstanceResponse
+
+	response.DisplayName = s.displayName
+	response.Metadata = s.metadata
+	response.Etag = s.etag
+
+	return response, s.err
+}
+
+func (s *stubComputeAPI) UpdateInstance(
+	_ context.Context,
+	request core.UpdateInstanceRequest,
+) (core.UpdateInstanceResponse, error) {
+	s.updates = append(s.updates, request)
+
+	var response core.UpdateInstanceResponse
+
+	return response, s.updateErr
+}
+
+func TestInstanceDisplayNameReturnsTrimmedName(t *testing.T) {
+	t.Parallel()
+
+	name := "  web-01  "
+	getter := &stubComputeAPI{displayName: &name}
+
+	client, err := newComputeClient(getter)
+	requireNoError(t, err, "construct compute client")
+
+	got, err := client.InstanceDisplayName(t.Context(), "ocid1.instance.oc1..example")
+	requireNoError(t, err, "resolve display name")
+	requireEqual(t, got, "web-01", "display name")
+	requireEqual(t, getter.requested, "ocid1.instance.oc1..example", "instance OCID")
+}
+
+func TestInstanceDisplayNameHandlesFailures(t *testing.T) {
+	t.Parallel()
+
+	blank := " "
+
+	testCases := []struct {
+		name   string
+		getter *stubComputeAPI
+		ocid   string
+		want   error
+	}{
+		{
+			name:   "missing ocid",
+			getter: new(stubComputeAPI),
+			ocid:   "",
+			want:   errMissingInstanceOCID,
+		},
+		{
+			name:   "api error",
+			getter: &stubComputeAPI{err: errForcedFailure},
+			ocid:   "ocid1.instance.oc1..example",
+			want:   errForcedFailure,
+		},
+		{
+			name:   "nil display name",
+			getter: new(stubComputeAPI),
+			ocid:   "ocid1.instance.oc1..example",
+			want:   errMissingDisplayName,
+		},
+		{
+			name:   "blank display name",
+			getter: &stubComputeAPI{displayName: &blank},
+			ocid:   "ocid1.instance.oc1..example",
+			want:   errMissingDisplayName,
+		},
+	}
+
+	for _, tc := range testCases {
+		testCase := tc
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, err := newComputeClient(testCase.getter)
+			requireNoError(t, err, "construct compute client")
+
+			_, err = client.InstanceDisplayName(t.Context(), testCase.ocid)
+			if !errors.Is(err, testCase.want) {
+				t.Fatalf("expected %v, got %v", testCase.want, err)
+			}
+		})
+	}
+}
+
+func TestComputeClientRejectsNilDependencies(t *testing.T) {
+	t.Parallel()
+
+	_, err := newComputeClient(nil)
+	if !errors.Is(err, errMissingComputeClient) {
+		t.Fatalf("expected missing compute client error, got %v", err)
+	}
+
+	var client *ComputeClient
+
+	_, err = client.InstanceDisplayName(t.Context(), "ocid1.instance.oc1..example")
+	if !errors.Is(err, errNilComputeClient) {
+		t.Fatalf("expected nil receiver error, got %v", err)
+	}
+}
+
+func TestSetInstanceMetadataMergesConditionally(t *testing.T) {
+	t.Parallel()
+
+	etag := "etag-1"
+	compute := &stubComputeAPI{
+		metadata: map[string]string{
+			"ssh_authorized_keys":  "ssh-ed25519 AAAA",
+			"oci-cpu-shaper-state": "normal",
+		},
+		etag: &etag,
+	}
+
+	client, err := newComputeClient(compute)
+	requireNoError(t, err, "construct compute client")
+
+	updated, err := client.SetInstanceMetadata(
+		t.Context(),
+		"ocid1.instance.oc1..example",
+		map[string]string{"oci-cpu-shaper-state": "normal"},
+	)
+	requireNoError(t, err, "unchanged metadata")
+	requireEqual(t, updated, false, "unchanged update sent")
+	requireEqual(t, len(compute.updates), 0, "updates for unchanged metadata")
+
+	updated, err = client.SetInstanceMetadata(
+		t.Context(),
+		"ocid1.instance.oc1..example",
+		map[string]string{"oci-cpu-shaper-state": "fallback", "oci-cpu-shaper-target": "0.25"},
+	)
+	requireNoError(t, err, "changed metadata")
+	requireEqual(t, updated, true, "changed update sent")
+	requireEqual(t, len(compute.updates), 1, "updates for changed metadata")
+
+	request := compute.updates[0]
+	requireEqual(t, *request.InstanceId, "ocid1.instance.oc1..example", "updated instance")
+	requireEqual(t, *request.IfMatch, etag, "if-match")
+
+	metadata := request.UpdateInstanceDetails.Metadata
+	requireEqual(t, metadata["ssh_authorized_keys"], "ssh-ed25519 AAAA", "preserved key")
+	requireEqual(t, metadata["oci-cpu-shaper-state"], "fallback", "state")
+	requireEqual(t, metadata["oci-cpu-shaper-target"], "0.25", "target")
+}
+
+func TestSetInstanceMetadataHandlesFailures(t *testing.T) {
+	t.Parallel()
+
+	const instance = "ocid1.instance.oc1..a"
+
+	values := map[string]string{"oci-cpu-shaper-state": "normal"}
+
+	testCases := []struct {
+		name    string
+		compute *stubComputeAPI
+		ocid    string
+		want    error
+	}{
+		{"missing ocid", new(stubComputeAPI), "", errMissingInstanceOCID},
+		{"get error", &stubComputeAPI{err: errForcedFailure}, instance, errForcedFailure},
+		{"update error", &stubComputeAPI{updateErr: errForcedFailure}, instance, errForcedFailure},
+	}
+
+	for _, testCase := range testCases {
+		client, err := newComputeClient(testCase.compute)
+		requireNoError(t, err, "construct compute client")
+
+		_, err = client.SetInstanceMetadata(t.Context(), testCase.ocid, values)
+		if !errors.Is(err, testCase.want) {
+			t.Fatalf("%s: expected %v, got %v", testCase.name, testCase.want, err)
+		}
+	}
+
+	var client *ComputeClient
+
+	_, err := client.SetInstanceMetadata(t.Context(), instance, values)
+	if !errors.Is(err, errNilComputeClient) {
+		t.Fatalf("expected nil receiver error, got %v", err)
+	}
+}
+
+func TestNewInstancePrincipalComputeClientPropagatesProviderError(t *testing.T) {
+	t.Parallel()
+
+	overrideInstancePrincipalProvider(t, func() (common.ConfigurationProvider, error) {
+		return nil, errForcedFailure
+	})
+
+	_, err := NewInstancePrincipalComputeClient("us-ashburn-1")
+	if err == nil || !strings.Contains(err.Error(), "build instance principal provider") {
+		t.Fatalf("expected wrapped provider error, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+var (
+	errMissingIdentityClient = errors.New("oci: identity client is required")
+	errNilIdentityClient     = errors.New("oci: identity client receiver is nil")
+	errMissingDynamicGroupID = errors.New("oci: dynamic group OCID is required")
+	errMissingMatchingRule   = errors.New("oci: dynamic group has no matching rule")
+
+	// ErrInvalidMatchingRule reports a matching rule that cannot be parsed or
+	// uses attributes that instance principal tokens do not carry.
+	ErrInvalidMatchingRule = errors.New("oci: invalid matching rule")
+)
+
+const (
+	ruleAttributeInstance    = "instance.id"
+	ruleAttributeCompartment = "instance.compartment.id"
+)
+
+type dynamicGroupGetter interface {
+	GetDynamicGroup(
+		ctx context.Context,
+		request identity.GetDynamicGroupRequest,
+	) (identity.GetDynamicGroupResponse, error)
+}
+
+// IdentityClient reads dynamic group definitions from the OCI Identity API.
+type IdentityClient struct {
+	identity dynamicGroupGetter
+}
+
+// NewInstancePrincipalIdentityClient constructs an IdentityClient authenticated
+// with the instance principal. The region pins the Identity endpoint when it is
+// non-empty.
+func NewInstancePrincipalIdentityClient(region string) (*IdentityClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	identityClient, err := identity.NewIdentityClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create identity client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		identityClient.SetRegion(trimmedRegion)
+	}
+
+	return newIdentityClient(identityClient)
+}
+
+func newIdentityClient(getter dynamicGroupGetter) (*IdentityClient, error) {
+	if getter == nil {
+		return nil, errMissingIdentityClient
+	}
+
+	return &IdentityClient{identity: getter}, nil
+}
+
+// DynamicGroupMatchingRule returns the matching rule of the dynamic group.
+func (c *IdentityClient) DynamicGroupMatchingRule(
+	ctx context.Context,
+	dynamicGroupOCID string,
+) (string, error) {
+	if c == nil || c.identity == nil {
+		return "", errNilIdentityClient
+	}
+
+	trimmed := strings.TrimSpace(dynamicGroupOCID)
+	if trimmed == "" {
+		return "", errMissingDynamicGroupID
+	}
+
+	var request identity.GetDynamicGroupRequest
+
+	request.DynamicGroupId = &trimmed
+
+	response, err := c.identity.GetDynamicGroup(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("get dynamic group: %w", err)
+	}
+
+	if response.MatchingRule == nil || strings.TrimSpace(*response.MatchingRule) == "" {
+		return "", errMissingMatchingRule
+	}
+
+	return *response.MatchingRule, nil
+}
+
+// MatchingRule is a parsed dynamic group matching rule. Only the instance.id and
+// instance.compartment.id attributes are supported because they are the ones
+// instance principal tokens carry; rules may nest ANY and ALL groups.
+type MatchingRule struct {
+	group bool
+	all   bool
+	rules []MatchingRule
+
+	attribute string
+	value     string
+	negate    bool
+}
+
+// ParseMatchingRule parses text using the dynamic group matching rule syntax,
+// for example `ANY {instance.compartment.id = 'ocid1.compartment.oc1..aaa'}`.
+func ParseMatchingRule(text string) (MatchingRule, error) {
+	parser := ruleParser{input: text}
+
+	rule, err := parser.rule()
+	if err != nil {
+		return MatchingRule{}, err
+	}
+
+	parser.skipSpace()
+
+	if parser.pos != len(parser.input) {
+		return MatchingRule{}, parser.fail("unexpected trailing input")
+	}
+
+	return rule, nil
+}
+
+// Matches reports whether principal satisfies the rule.
+func (r MatchingRule) Matches(principal Principal) bool {
+	if !r.group {
+		var actual string
+
+		switch r.attribute {
+		case ruleAttributeInstance:
+			actual = principal.InstanceID
+		case ruleAttributeCompartment:
+			actual = principal.CompartmentID
+		}
+
+		// A principal without the attribute satisfies neither = nor !=, so a
+		// negated clause cannot admit a token that omits the OCID it tests.
+		if actual == "" {
+			return false
+		}
+
+		return (actual == r.value) != r.negate
+	}
+
+	for _, rule := range r.rules {
+		matched := rule.Matches(principal)
+		if matched != r.all {
+			return matched
+		}
+	}
+
+	return r.all
+}
+
+type ruleParser struct {
+	input string
+	pos   int
+}
+
+func (p *ruleParser) fail(reason string) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidMatchingRule, reason, p.pos)
+}
+
+func (p *ruleParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *ruleParser) consume(token string) bool {
+	p.skipSpace()
+
+	if len(p.input)-p.pos < len(token) ||
+		!strings.EqualFold(p.input[p.pos:p.pos+len(token)], token) {
+		return false
+	}
+
+	p.pos += len(token)
+
+	return true
+}
+
+func (p *ruleParser) word() string {
+	p.skipSpace()
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(" \t\r\n{},=!'\"", rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	return p.input[start:p.pos]
+}
+
+func (p *ruleParser) rule() (MatchingRule, error) {
+	start := p.pos
+	keyword := strings.ToLower(p.word())
+
+	if keyword == "any" || keyword == "all" {
+		if p.consume("{") {
+			return p.group(keyword == "all")
+		}
+	}
+
+	p.pos = start
+
+	return p.clause()
+}
+
+func (p *ruleParser) group(all bool) (MatchingRule, error) {
+	rule := MatchingRule{group: true, all: all}
+
+	for {
+		child, err := p.rule()
+		if err != nil {
+			return MatchingRule{}, err
+		}
+
+		rule.rules = append(rule.rules, child)
+
+		if p.consume("}") {
+			return rule, nil
+		}
+
+		if !p.consume(",") {
+			return MatchingRule{}, p.fail("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *ruleParser) clause() (MatchingRule, error) {
+	attribute := strings.ToLower(p.word())
+	if attribute != ruleAttributeInstance && attribute != ruleAttributeCompartment {
+		return MatchingRule{}, p.fail(fmt.Sprintf("unsupported attribute %q", attribute))
+	}
+
+	rule := MatchingRule{attribute: attribute}
+
+	switch {
+	case p.consume("!="):
+		rule.negate = true
+	case p.consume("="):
+	default:
+		return MatchingRule{}, p.fail("expected '=' or '!='")
+	}
+
+	value, err := p.value()
+	if err != nil {
+		return MatchingRule{}, err
+	}
+
+	rule.value = value
+
+	return rule, nil
+}
+
+func (p *ruleParser) value() (string, error) {
+	p.skipSpace()
+
+	if p.pos < len(p.input) && (p.input[p.pos] == '\'' || p.input[p.pos] == '"') {
+		quote := p.input[p.pos]
+
+		end := strings.IndexByte(p.input[p.pos+1:], quote)
+		if end < 0 {
+			return "", p.fail("unterminated value")
+		}
+
+		value := strings.TrimSpace(p.input[p.pos+1 : p.pos+1+end])
+		p.pos += end + 2
+
+		if value == "" {
+			return "", p.fail("empty value")
+		}
+
+		return value, nil
+	}
+
+	value := p.word()
+	if value == "" {
+		return "", p.fail("expected a value")
+	}
+
+	return value, nil
+}
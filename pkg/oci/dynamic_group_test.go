@@ -0,0 +1,123 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+var errStubIdentityDenied = errors.New("stub: identity denied")
+
+type stubDynamicGroupGetter struct {
+	rule      *string
+	err       error
+	requested string
+}
+
+func (s *stubDynamicGroupGetter) GetDynamicGroup(
+	_ context.Context,
+	request identity.GetDynamicGroupRequest,
+) (identity.GetDynamicGroupResponse, error) {
+	if request.DynamicGroupId != nil {
+		s.requested = *request.DynamicGroupId
+	}
+
+	var response identity.GetDynamicGroupResponse
+
+	response.MatchingRule = s.rule
+
+	return response, s.err
+}
+
+func TestDynamicGroupMatchingRuleReadsRule(t *testing.T) {
+	t.Parallel()
+
+	rule := "ANY {instance.compartment.id = 'ocid1.compartment.oc1..fleet'}"
+	getter := &stubDynamicGroupGetter{rule: &rule}
+
+	client, err := newIdentityClient(getter)
+	requireNoError(t, err, "construct identity client")
+
+	got, err := client.DynamicGroupMatchingRule(t.Context(), " ocid1.dynamicgroup.oc1..fleet ")
+	requireNoError(t, err, "read matching rule")
+	requireEqual(t, got, rule, "matching rule")
+	requireEqual(t, getter.requested, "ocid1.dynamicgroup.oc1..fleet", "dynamic group OCID")
+
+	_, err = client.DynamicGroupMatchingRule(t.Context(), " ")
+	if !errors.Is(err, errMissingDynamicGroupID) {
+		t.Fatalf("expected errMissingDynamicGroupID, got %v", err)
+	}
+
+	denied, err := newIdentityClient(&stubDynamicGroupGetter{err: errStubIdentityDenied})
+	requireNoError(t, err, "construct identity client")
+
+	_, err = denied.DynamicGroupMatchingRule(t.Context(), "ocid1.dynamicgroup.oc1..fleet")
+	if !errors.Is(err, errStubIdentityDenied) {
+		t.Fatalf("expected identity error, got %v", err)
+	}
+}
+
+func TestParseMatchingRule(t *testing.T) {
+	t.Parallel()
+
+	member := Principal{InstanceID: "ocid1.instance.a", CompartmentID: "ocid1.compartment.a"}
+	other := Principal{InstanceID: "ocid1.instance.b", CompartmentID: "ocid1.compartment.b"}
+
+	testCases := []struct {
+		rule   string
+		member bool
+		other  bool
+	}{
+		{rule: "instance.id = 'ocid1.instance.a'", member: true},
+		{
+			rule: "Any {instance.compartment.id = 'ocid1.compartment.a', " +
+				"instance.id = 'ocid1.instance.b'}",
+			member: true,
+			other:  true,
+		},
+		{
+			rule: "ALL {instance.compartment.id = ocid1.compartment.a, " +
+				"instance.id != ocid1.instance.a}",
+		},
+		{
+			rule: "any {all {instance.id = \"ocid1.instance.b\"}, " +
+				"instance.id = 'ocid1.instance.c'}",
+			other: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		rule, err := ParseMatchingRule(testCase.rule)
+		requireNoError(t, err, testCase.rule)
+		requireEqual(t, rule.Matches(member), testCase.member, testCase.rule+" member")
+		requireEqual(t, rule.Matches(other), testCase.other, testCase.rule+" other")
+	}
+
+	anonymous := Principal{InstanceID: "ocid1.instance.c"}
+
+	for _, text := range []string{
+		"instance.compartment.id = 'ocid1.compartment.a'",
+		"instance.compartment.id != 'ocid1.compartment.a'",
+		"ANY {instance.compartment.id != 'ocid1.compartment.a'}",
+	} {
+		rule, err := ParseMatchingRule(text)
+		requireNoError(t, err, text)
+		requireEqual(t, rule.Matches(anonymous), false, text+" without compartment")
+	}
+
+	for _, invalid := range []string{
+		"",
+		"ANY {}",
+		"ANY {instance.id = 'a'",
+		"tag.fleet.role.value = 'shaper'",
+		"instance.id = 'a' trailing",
+		"instance.id 'a'",
+	} {
+		_, err := ParseMatchingRule(invalid)
+		if !errors.Is(err, ErrInvalidMatchingRule) {
+			t.Fatalf("expected %q to be rejected, got %v", invalid, err)
+		}
+	}
+}
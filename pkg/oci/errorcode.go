@@ -0,0 +1,73 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Error codes ErrorCode returns for errors that carry no OCI service code.
+const (
+	ErrorCodeNoData     = "NoData"
+	ErrorCodeTimeout    = "Timeout"
+	ErrorCodeCanceled   = "Canceled"
+	ErrorCodeNetwork    = "NetworkError"
+	ErrorCodeNotFound   = "NotFound"
+	ErrorCodePermission = "PermissionDenied"
+	ErrorCodeUnknown    = "Unknown"
+)
+
+// serviceError matches the code and status accessors of the SDK's
+// common.ServiceError.
+type serviceError interface {
+	GetCode() string
+	GetHTTPStatusCode() int
+}
+
+// ErrorCode classifies err into a short, low-cardinality code suitable for a
+// metric label: the service error code of a failed OCI call (for example
+// NotAuthenticated or TooManyRequests), its HTTP status when the service gave
+// no code, or one of the ErrorCode constants. It returns "" for a nil error.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var service serviceError
+	if errors.As(err, &service) {
+		code := service.GetCode()
+		if code != "" {
+			return code
+		}
+
+		status := service.GetHTTPStatusCode()
+		if status > 0 {
+			return strconv.Itoa(status)
+		}
+	}
+
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrNoMetricsData):
+		return ErrorCodeNoData
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCanceled
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrorCodeTimeout
+		}
+
+		return ErrorCodeNetwork
+	case errors.Is(err, os.ErrNotExist):
+		return ErrorCodeNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ErrorCodePermission
+	default:
+		return ErrorCodeUnknown
+	}
+}
@@ -0,0 +1,60 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+)
+
+var errOpaque = errors.New("opaque")
+
+type fakeServiceError struct {
+	code   string
+	status int
+}
+
+func (e fakeServiceError) Error() string          { return "service error" }
+func (e fakeServiceError) GetCode() string        { return e.code }
+func (e fakeServiceError) GetHTTPStatusCode() int { return e.status }
+
+type fakeNetError struct {
+	timeout bool
+}
+
+func (e fakeNetError) Error() string   { return "net error" }
+func (e fakeNetError) Timeout() bool   { return e.timeout }
+func (e fakeNetError) Temporary() bool { return false }
+
+func TestErrorCodeClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("query: %w", fakeServiceError{code: "NotAuthenticated", status: 401}),
+			"NotAuthenticated"},
+		{fakeServiceError{code: "", status: 503}, "503"},
+		{fakeServiceError{code: "", status: 0}, ErrorCodeUnknown},
+		{fmt.Errorf("%w after 3 attempts: %w", ErrRetriesExhausted, ErrNoMetricsData),
+			ErrorCodeNoData},
+		{context.DeadlineExceeded, ErrorCodeTimeout},
+		{context.Canceled, ErrorCodeCanceled},
+		{fakeNetError{timeout: true}, ErrorCodeTimeout},
+		{&net.OpError{Op: "dial", Err: fakeNetError{timeout: false}}, ErrorCodeNetwork},
+		{fmt.Errorf("read: %w", os.ErrNotExist), ErrorCodeNotFound},
+		{os.ErrPermission, ErrorCodePermission},
+		{errOpaque, ErrorCodeUnknown},
+	}
+
+	for _, test := range tests {
+		got := ErrorCode(test.err)
+		if got != test.want {
+			t.Fatalf("ErrorCode(%v) = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/events"
+)
+
+// Compute event types that precede an instance going away or being
+// interrupted: scheduled maintenance reminders, instance actions such as the
+// stop an idle reclamation issues, and termination.
+const (
+	EventTypeMaintenanceReminder = "com.oraclecloud.computeapi.instancemaintenancereminder"
+	EventTypeInstanceAction      = "com.oraclecloud.computeapi.instanceaction.begin"
+	EventTypeTerminateInstance   = "com.oraclecloud.computeapi.terminateinstance.begin"
+)
+
+const eventRuleListPageLimit = 1000
+
+var (
+	errMissingEventsClient = errors.New("oci: events client is required")
+	errNilEventsClient     = errors.New("oci: events client receiver is nil")
+	errMissingRuleName     = errors.New("oci: event rule display name is required")
+	errMissingEventTypes   = errors.New("oci: at least one event type is required")
+)
+
+type eventsAPI interface {
+	ListRules(
+		ctx context.Context,
+		request events.ListRulesRequest,
+	) (events.ListRulesResponse, error)
+	CreateRule(
+		ctx context.Context,
+		request events.CreateRuleRequest,
+	) (events.CreateRuleResponse, error)
+	UpdateRule(
+		ctx context.Context,
+		request events.UpdateRuleRequest,
+	) (events.UpdateRuleResponse, error)
+}
+
+// InstanceEventTypes returns the event types an instance events rule matches
+// by default.
+func InstanceEventTypes() []string {
+	return []string{
+		EventTypeMaintenanceReminder,
+		EventTypeInstanceAction,
+		EventTypeTerminateInstance,
+	}
+}
+
+// InstanceEventCondition returns the Events rule condition matching eventTypes
+// for the instance instanceID only.
+func InstanceEventCondition(instanceID string, eventTypes []string) (string, error) {
+	if instanceID == "" {
+		return "", errMissingInstanceOCID
+	}
+
+	if len(eventTypes) == 0 {
+		return "", errMissingEventTypes
+	}
+
+	condition := struct {
+		EventType []string          `json:"eventType"`
+		Data      map[string]string `json:"data"`
+	}{
+		EventType: eventTypes,
+		Data:      map[string]string{"resourceId": instanceID},
+	}
+
+	encoded, err := json.Marshal(condition)
+	if err != nil {
+		return "", fmt.Errorf("encode event condition: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// EventRule describes an Events rule that forwards matching events to
+// Notifications topics.
+type EventRule struct {
+	CompartmentID string
+	DisplayName   string
+	Description   string
+	Condition     string
+	TopicIDs      []string
+}
+
+// EventRuleResult reports the rule EnsureRule created or updated.
+type EventRuleResult struct {
+	ID      string
+	State   string
+	Created bool
+}
+
+// EventsClient manages Events rules.
+type EventsClient struct {
+	rules eventsAPI
+}
+
+// NewInstancePrincipalEventsClient constructs an EventsClient authenticated
+// with the instance principal. The region pins the Events endpoint when it is
+// non-empty.
+func NewInstancePrincipalEventsClient(region string) (*EventsClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	client, err := events.NewEventsClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create events client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		client.SetRegion(trimmedRegion)
+	}
+
+	return newEventsClient(client)
+}
+
+func newEventsClient(rules eventsAPI) (*EventsClient, error) {
+	if rules == nil {
+		return nil, errMissingEventsClient
+	}
+
+	return &EventsClient{rules: rules}, nil
+}
+
+// EnsureRule creates rule, or updates the condition, description, and topics
+// of the live rule with the same display name in the compartment, so running
+// it again converges instead of adding duplicates. The rule is enabled either
+// way.
+func (c *EventsClient) EnsureRule(ctx context.Context, rule EventRule) (EventRuleResult, error) {
+	if c == nil || c.rules == nil {
+		return EventRuleResult{}, errNilEventsClient
+	}
+
+	switch {
+	case rule.CompartmentID == "":
+		return EventRuleResult{}, errMissingCompartmentID
+	case rule.DisplayName == "":
+		return EventRuleResult{}, errMissingRuleName
+	case len(rule.TopicIDs) == 0:
+		return EventRuleResult{}, errMissingTopicIDs
+	}
+
+	existingID, err := c.findRule(ctx, rule.CompartmentID, rule.DisplayName)
+	if err != nil {
+		return EventRuleResult{}, err
+	}
+
+	actions := notificationActions(rule.TopicIDs)
+
+	if existingID == "" {
+		return c.createRule(ctx, rule, actions)
+	}
+
+	response, err := c.rules.UpdateRule(ctx, events.UpdateRuleRequest{ //nolint:exhaustruct
+		RuleId: common.String(existingID),
+		UpdateRuleDetails: events.UpdateRuleDetails{ //nolint:exhaustruct
+			Description: common.String(rule.Description),
+			Condition:   common.String(rule.Condition),
+			IsEnabled:   common.Bool(true),
+			Actions:     actions,
+		},
+	})
+	if err != nil {
+		return EventRuleResult{}, fmt.Errorf("update event rule %s: %w", existingID, err)
+	}
+
+	return EventRuleResult{
+		ID:      existingID,
+		State:   string(response.LifecycleState),
+		Created: false,
+	}, nil
+}
+
+func (c *EventsClient) createRule(
+	ctx context.Context,
+	rule EventRule,
+	actions *events.ActionDetailsList,
+) (EventRuleResult, error) {
+	response, err := c.rules.CreateRule(ctx, events.CreateRuleRequest{ //nolint:exhaustruct
+		CreateRuleDetails: events.CreateRuleDetails{ //nolint:exhaustruct
+			CompartmentId: common.String(rule.CompartmentID),
+			DisplayName:   common.String(rule.DisplayName),
+			Description:   common.String(rule.Description),
+			Condition:     common.String(rule.Condition),
+			IsEnabled:     common.Bool(true),
+			Actions:       actions,
+		},
+	})
+	if err != nil {
+		return EventRuleResult{}, fmt.Errorf("create event rule %s: %w", rule.DisplayName, err)
+	}
+
+	return EventRuleResult{
+		ID:      stringValue(response.Id),
+		State:   string(response.LifecycleState),
+		Created: true,
+	}, nil
+}
+
+// findRule returns the ID of the rule named displayName that is not being
+// deleted, or "" when there is none.
+func (c *EventsClient) findRule(
+	ctx context.Context,
+	compartmentID string,
+	displayName string,
+) (string, error) {
+	request := events.ListRulesRequest{ //nolint:exhaustruct
+		CompartmentId: common.String(compartmentID),
+		DisplayName:   common.String(displayName),
+		Limit:         common.Int(eventRuleListPageLimit),
+	}
+
+	for {
+		response, err := c.rules.ListRules(ctx, request)
+		if err != nil {
+			return "", fmt.Errorf("list event rules: %w", err)
+		}
+
+		for _, summary := range response.Items {
+			if stringValue(summary.DisplayName) != displayName ||
+				summary.LifecycleState == events.RuleLifecycleStateDeleting ||
+				summary.LifecycleState == events.RuleLifecycleStateDeleted {
+				continue
+			}
+
+			return stringValue(summary.Id), nil
+		}
+
+		if response.OpcNextPage == nil || *response.OpcNextPage == "" {
+			return "", nil
+		}
+
+		request.Page = response.OpcNextPage
+	}
+}
+
+func notificationActions(topicIDs []string) *events.ActionDetailsList {
+	actions := make([]events.ActionDetails, 0, len(topicIDs))
+
+	for _, topicID := range topicIDs {
+		actions = append(actions, events.CreateNotificationServiceActionDetails{
+			IsEnabled:   common.Bool(true),
+			Description: common.String("oci-cpu-shaper instance events"),
+			TopicId:     common.String(topicID),
+		})
+	}
+
+	return &events.ActionDetailsList{Actions: actions}
+}
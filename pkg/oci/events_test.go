@@ -0,0 +1,247 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/events"
+)
+
+const (
+	eventsTestRuleName = "oci-cpu-shaper-events"
+	eventsTestTopic    = "ocid1.onstopic.oc1..ops"
+)
+
+type stubEventsAPI struct {
+	pages   [][]events.RuleSummary
+	created events.CreateRuleRequest
+	updated events.UpdateRuleRequest
+	listErr error
+	err     error
+}
+
+func (s *stubEventsAPI) ListRules(
+	_ context.Context,
+	request events.ListRulesRequest,
+) (events.ListRulesResponse, error) {
+	var response events.ListRulesResponse
+
+	if s.listErr != nil {
+		return response, s.listErr
+	}
+
+	index := 0
+	if request.Page != nil {
+		index = len(*request.Page)
+	}
+
+	if index < len(s.pages) {
+		response.Items = s.pages[index]
+	}
+
+	if index+1 < len(s.pages) {
+		response.OpcNextPage = common.String(strings.Repeat("p", index+1))
+	}
+
+	return response, nil
+}
+
+func (s *stubEventsAPI) CreateRule(
+	_ context.Context,
+	request events.CreateRuleRequest,
+) (events.CreateRuleResponse, error) {
+	var response events.CreateRuleResponse
+
+	s.created = request
+	response.Id = common.String("ocid1.eventrule.oc1..created")
+	response.LifecycleState = events.RuleLifecycleStateActive
+
+	return response, s.err
+}
+
+func (s *stubEventsAPI) UpdateRule(
+	_ context.Context,
+	request events.UpdateRuleRequest,
+) (events.UpdateRuleResponse, error) {
+	var response events.UpdateRuleResponse
+
+	s.updated = request
+	response.LifecycleState = events.RuleLifecycleStateUpdating
+
+	return response, s.err
+}
+
+func testEventRule() EventRule {
+	return EventRule{
+		CompartmentID: alarmTestCompartment,
+		DisplayName:   eventsTestRuleName,
+		Description:   "instance events",
+		Condition:     `{"eventType":["x"]}`,
+		TopicIDs:      []string{eventsTestTopic},
+	}
+}
+
+func TestInstanceEventConditionMatchesInstance(t *testing.T) {
+	t.Parallel()
+
+	condition, err := InstanceEventCondition(alarmTestInstance, InstanceEventTypes())
+	if err != nil {
+		t.Fatalf("InstanceEventCondition: %v", err)
+	}
+
+	want := `{"eventType":["` + EventTypeMaintenanceReminder + `","` + EventTypeInstanceAction +
+		`","` + EventTypeTerminateInstance + `"],"data":{"resourceId":"` + alarmTestInstance + `"}}`
+	if condition != want {
+		t.Fatalf("unexpected condition\n got %s\nwant %s", condition, want)
+	}
+
+	_, err = InstanceEventCondition("", InstanceEventTypes())
+	if !errors.Is(err, errMissingInstanceOCID) {
+		t.Fatalf("expected missing instance error, got %v", err)
+	}
+
+	_, err = InstanceEventCondition(alarmTestInstance, nil)
+	if !errors.Is(err, errMissingEventTypes) {
+		t.Fatalf("expected missing event types error, got %v", err)
+	}
+}
+
+func TestEnsureRuleCreatesMissingRule(t *testing.T) {
+	t.Parallel()
+
+	api := &stubEventsAPI{pages: [][]events.RuleSummary{{
+		{Id: common.String("ocid1.eventrule.oc1..other"), DisplayName: common.String("other")},
+		{
+			Id:             common.String("ocid1.eventrule.oc1..gone"),
+			DisplayName:    common.String(eventsTestRuleName),
+			LifecycleState: events.RuleLifecycleStateDeleting,
+		},
+	}}}
+
+	client, err := newEventsClient(api)
+	if err != nil {
+		t.Fatalf("newEventsClient: %v", err)
+	}
+
+	result, err := client.EnsureRule(t.Context(), testEventRule())
+	if err != nil {
+		t.Fatalf("EnsureRule: %v", err)
+	}
+
+	if !result.Created || result.ID != "ocid1.eventrule.oc1..created" || result.State != "ACTIVE" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	details := api.created.CreateRuleDetails
+	if *details.DisplayName != eventsTestRuleName || !*details.IsEnabled ||
+		*details.CompartmentId != alarmTestCompartment || len(details.Actions.Actions) != 1 {
+		t.Fatalf("unexpected create request %+v", details)
+	}
+
+	action, ok := details.Actions.Actions[0].(events.CreateNotificationServiceActionDetails)
+	if !ok || *action.TopicId != eventsTestTopic || !*action.GetIsEnabled() ||
+		action.GetDescription() == nil {
+		t.Fatalf("unexpected action %+v", details.Actions.Actions[0])
+	}
+}
+
+func TestEnsureRuleUpdatesExistingRuleOnLaterPage(t *testing.T) {
+	t.Parallel()
+
+	api := &stubEventsAPI{pages: [][]events.RuleSummary{
+		{},
+		{{
+			Id:             common.String("ocid1.eventrule.oc1..existing"),
+			DisplayName:    common.String(eventsTestRuleName),
+			LifecycleState: events.RuleLifecycleStateInactive,
+		}},
+	}}
+
+	client, err := newEventsClient(api)
+	if err != nil {
+		t.Fatalf("newEventsClient: %v", err)
+	}
+
+	result, err := client.EnsureRule(t.Context(), testEventRule())
+	if err != nil {
+		t.Fatalf("EnsureRule: %v", err)
+	}
+
+	if result.Created || result.ID != "ocid1.eventrule.oc1..existing" ||
+		result.State != "UPDATING" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if *api.updated.RuleId != "ocid1.eventrule.oc1..existing" ||
+		*api.updated.Condition != `{"eventType":["x"]}` || !*api.updated.IsEnabled {
+		t.Fatalf("unexpected update request %+v", api.updated)
+	}
+}
+
+func TestEnsureRuleHandlesFailures(t *testing.T) {
+	t.Parallel()
+
+	existing := [][]events.RuleSummary{{{
+		Id:          common.String("ocid1.eventrule.oc1..existing"),
+		DisplayName: common.String(eventsTestRuleName),
+	}}}
+
+	testCases := []struct {
+		name   string
+		api    *stubEventsAPI
+		mutate func(*EventRule)
+		want   error
+	}{
+		{"compartment", &stubEventsAPI{}, func(r *EventRule) { r.CompartmentID = "" },
+			errMissingCompartmentID},
+		{"name", &stubEventsAPI{}, func(r *EventRule) { r.DisplayName = "" }, errMissingRuleName},
+		{"topics", &stubEventsAPI{}, func(r *EventRule) { r.TopicIDs = nil }, errMissingTopicIDs},
+		{"list", &stubEventsAPI{listErr: errForcedFailure}, func(*EventRule) {}, errForcedFailure},
+		{"create", &stubEventsAPI{err: errForcedFailure}, func(*EventRule) {}, errForcedFailure},
+		{"update", &stubEventsAPI{pages: existing, err: errForcedFailure}, func(*EventRule) {},
+			errForcedFailure},
+	}
+
+	for _, testCase := range testCases {
+		client, err := newEventsClient(testCase.api)
+		if err != nil {
+			t.Fatalf("newEventsClient: %v", err)
+		}
+
+		rule := testEventRule()
+		testCase.mutate(&rule)
+
+		_, err = client.EnsureRule(t.Context(), rule)
+		if !errors.Is(err, testCase.want) {
+			t.Fatalf("%s: expected %v, got %v", testCase.name, testCase.want, err)
+		}
+	}
+
+	var client *EventsClient
+
+	_, err := client.EnsureRule(t.Context(), testEventRule())
+	if !errors.Is(err, errNilEventsClient) {
+		t.Fatalf("expected nil receiver error, got %v", err)
+	}
+
+	_, err = newEventsClient(nil)
+	if !errors.Is(err, errMissingEventsClient) {
+		t.Fatalf("expected missing client error, got %v", err)
+	}
+}
+
+func TestNewInstancePrincipalEventsClientPropagatesProviderError(t *testing.T) {
+	t.Parallel()
+
+	overrideInstancePrincipalProvider(t, func() (common.ConfigurationProvider, error) {
+		return nil, errForcedFailure
+	})
+
+	_, err := NewInstancePrincipalEventsClient("us-ashburn-1")
+	if err == nil || !strings.Contains(err.Error(), "build instance principal provider") {
+		t.Fatalf("expected wrapped provider error, got %v", err)
+	}
+}
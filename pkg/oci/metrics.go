@@ -13,12 +13,16 @@ import (
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/common/auth"
 	"github.com/oracle/oci-go-sdk/v65/monitoring"
+
+	"oci-cpu-shaper/pkg/logging"
 )
 
 const (
 	monitoringNamespace     = "oci_computeagent"
-	metricQueryTemplate     = "CpuUtilization[1m]{resourceId = \"%s\"}.percentile(0.95)"
 	metricName              = "CpuUtilization"
+	cpuPercentile           = 0.95
+	metricNetworkBytesIn    = "NetworksBytesIn"
+	metricNetworkBytesOut   = "NetworksBytesOut"
 	maxOneMinuteWindowHours = 7 * 24
 )
 
@@ -54,10 +58,58 @@ type Client struct {
 	metrics       metricsClient
 	compartmentID string
 	now           func() time.Time
+	// queryConcurrency bounds the queries QueryStep keeps in flight.
+	queryConcurrency int
+
+	skewMu      sync.Mutex
+	skew        time.Duration
+	skewHandler func(skew time.Duration)
+
+	// token is the instance principal provider whose security token signs
+	// queries; nil for clients built from other providers.
+	token        common.KeyProvider
+	tokenMu      sync.Mutex
+	tokenExpiry  time.Time
+	tokenHandler func(expiry time.Time)
+
+	scopeMu sync.RWMutex
+	scope   QueryScope
+
+	windowMu      sync.Mutex
+	window        QueryWindow
+	windowHandler func(window QueryWindow)
+
+	loggerMu sync.RWMutex
+	logger   Logger
+}
+
+// Logger receives Monitoring client diagnostics; see logging.Logger.
+type Logger = logging.Logger
+
+// SetLogger routes client diagnostics, such as each Monitoring query and its
+// window, to logger. A nil logger discards them, which is the default.
+func (c *Client) SetLogger(logger Logger) {
+	if c == nil {
+		return
+	}
+
+	c.loggerMu.Lock()
+	c.logger = logging.OrNop(logger)
+	c.loggerMu.Unlock()
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (c *Client) log() Logger {
+	c.loggerMu.RLock()
+	defer c.loggerMu.RUnlock()
+
+	return logging.OrNop(c.logger)
 }
 
 // NewInstancePrincipalClient constructs a Client backed by the OCI Go SDK using instance principal
 // authentication. The compartment OCID identifies the tenancy scope for Monitoring queries.
+// Each query first renews the provider's security token when it is close to expiry (see
+// SetTokenExpiryHandler), so the token cannot lapse part-way through a query.
 func NewInstancePrincipalClient(compartmentID, region string) (*Client, error) {
 	if compartmentID == "" {
 		return nil, errMissingCompartmentID
@@ -74,6 +126,26 @@ func NewInstancePrincipalClient(compartmentID, region string) (*Client, error) {
 		return nil, fmt.Errorf("build instance principal provider: %w", err)
 	}
 
+	client, err := NewClientWithProvider(provider, compartmentID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	client.token = provider
+
+	return client, nil
+}
+
+// NewClientWithProvider constructs a Client authenticated by an arbitrary SDK configuration
+// provider, such as one read from an OCI CLI config file. An empty region keeps the provider's.
+func NewClientWithProvider(
+	provider common.ConfigurationProvider,
+	compartmentID, region string,
+) (*Client, error) {
+	if compartmentID == "" {
+		return nil, errMissingCompartmentID
+	}
+
 	newMonitoringClientMu.RLock()
 
 	monitoringClientFn := newMonitoringClientFn
@@ -111,9 +183,10 @@ func newClient(
 	}
 
 	return &Client{
-		metrics:       metrics,
-		compartmentID: compartmentID,
-		now:           clock,
+		metrics:          metrics,
+		compartmentID:    compartmentID,
+		now:              clock,
+		queryConcurrency: defaultQueryConcurrency,
 	}, nil
 }
 
@@ -121,6 +194,9 @@ func newClient(
 // When last7d is true the query spans the trailing seven days at one-minute resolution, otherwise a
 // 24-hour window is used. The Monitoring API limits one-minute queries to seven days of history, so
 // the window is truncated as necessary. ErrNoMetricsData is returned when the API yields no datapoints.
+// The window is anchored on the local clock corrected by the skew observed in previous responses
+// (see ClockSkew), so instances with a drifting clock do not query empty ranges, and is reported
+// through LastQueryWindow.
 func (c *Client) QueryP95CPU(
 	ctx context.Context,
 	instanceOCID string,
@@ -134,8 +210,15 @@ func (c *Client) QueryP95CPU(
 		return 0, errMissingInstanceOCID
 	}
 
-	start, end := computeWindow(c.now().UTC(), last7d)
-	request := buildSummarizeRequest(c.compartmentID, instanceOCID, start, end)
+	err := c.refreshToken()
+	if err != nil {
+		return 0, err
+	}
+
+	start, end := computeWindow(c.skewedNow(), last7d)
+	c.recordWindow(start, end)
+
+	request := buildSummarizeRequest(c.compartmentID, c.queryScope(), instanceOCID, start, end)
 
 	value, found, err := c.collectLatestDatapoint(ctx, request)
 	if err != nil {
@@ -165,12 +248,63 @@ func computeWindow(now time.Time, last7d bool) (time.Time, time.Time) {
 	return start, end
 }
 
+// QueryNetworkBytes7d returns the bytes the instance received and sent over the trailing seven
+// days, summed from daily NetworksBytesIn and NetworksBytesOut aggregates. OCI weighs these
+// alongside the CPU P95 when deciding whether an Always Free instance is idle.
+func (c *Client) QueryNetworkBytes7d(
+	ctx context.Context,
+	instanceOCID string,
+) (NetworkTotals, error) {
+	if c == nil {
+		return NetworkTotals{}, errNilClient
+	}
+
+	if instanceOCID == "" {
+		return NetworkTotals{}, errMissingInstanceOCID
+	}
+
+	err := c.refreshToken()
+	if err != nil {
+		return NetworkTotals{}, err
+	}
+
+	start, end := computeWindow(c.skewedNow(), true)
+	c.recordWindow(start, end)
+
+	var totals NetworkTotals
+
+	for _, metric := range networkMetrics(&totals) {
+		query := networkQuery(metric.name, instanceOCID)
+		request := buildQueryRequest(c.compartmentID, c.queryScope(), query, start, end)
+
+		sum, err := c.sumDatapoints(ctx, request)
+		if err != nil {
+			return NetworkTotals{}, fmt.Errorf("%s: %w", metric.name, err)
+		}
+
+		*metric.dst = sum
+	}
+
+	return totals, nil
+}
+
 func buildSummarizeRequest(
-	compartmentID, instanceOCID string,
+	compartmentID string,
+	scope QueryScope,
+	instanceOCID string,
+	start, end time.Time,
+) monitoring.SummarizeMetricsDataRequest {
+	return buildQueryRequest(compartmentID, scope, cpuQuery(instanceOCID), start, end)
+}
+
+func buildQueryRequest(
+	compartmentID string,
+	scope QueryScope,
+	metricQuery MetricQuery,
 	start, end time.Time,
 ) monitoring.SummarizeMetricsDataRequest {
 	namespace := monitoringNamespace
-	query := fmt.Sprintf(metricQueryTemplate, escapeDimensionValue(instanceOCID))
+	query := scope.apply(metricQuery).String()
 	startTime := common.SDKTime{Time: start}
 	endTime := common.SDKTime{Time: end}
 
@@ -181,6 +315,11 @@ func buildSummarizeRequest(
 	details.StartTime = &startTime
 	details.EndTime = &endTime
 
+	if scope.ResourceGroup != "" {
+		resourceGroup := scope.ResourceGroup
+		details.ResourceGroup = &resourceGroup
+	}
+
 	var request monitoring.SummarizeMetricsDataRequest
 
 	request.CompartmentId = &compartmentID
@@ -201,12 +340,27 @@ func (c *Client) collectLatestDatapoint(
 
 	found := false
 
+	pages := 0
+	window := requestWindow(request)
+	logger := logging.WithStep(ctx, c.log())
+	request = withStepRequestID(ctx, request)
+
 	for {
 		response, nextPage, err := c.metrics.SummarizeMetricsData(ctx, request, pageToken)
 		if err != nil {
+			logger.Debug(
+				"monitoring query failed",
+				"query", queryText(request),
+				"start", window.Start,
+				"end", window.End,
+				"error", err,
+			)
+
 			return 0, false, fmt.Errorf("summarize metrics: %w", err)
 		}
 
+		pages++
+
 		latestTimestamp, latestValue, found = foldMetricStreams(
 			response.Items,
 			latestTimestamp,
@@ -214,12 +368,24 @@ func (c *Client) collectLatestDatapoint(
 			found,
 		)
 
+		c.observeServerTime(response.RawResponse, c.now().UTC(), latestTimestamp)
+
 		pageToken = normalizePageToken(nextPage)
 		if pageToken == nil {
 			break
 		}
 	}
 
+	logger.Debug(
+		"monitoring query",
+		"query", queryText(request),
+		"start", window.Start,
+		"end", window.End,
+		"pages", pages,
+		"found", found,
+		"latest", latestTimestamp,
+	)
+
 	if !found {
 		return 0, false, nil
 	}
@@ -227,6 +393,83 @@ func (c *Client) collectLatestDatapoint(
 	return latestValue, true, nil
 }
 
+// withStepRequestID sends the step ID carried by ctx as the opc-request-id of
+// request, so OCI support can find every call a controller step made.
+func withStepRequestID(
+	ctx context.Context,
+	request monitoring.SummarizeMetricsDataRequest,
+) monitoring.SummarizeMetricsDataRequest {
+	id := logging.StepID(ctx)
+	if id != "" && request.OpcRequestId == nil {
+		request.OpcRequestId = &id
+	}
+
+	return request
+}
+
+func queryText(request monitoring.SummarizeMetricsDataRequest) string {
+	if request.SummarizeMetricsDataDetails.Query == nil {
+		return ""
+	}
+
+	return *request.SummarizeMetricsDataDetails.Query
+}
+
+// sumDatapoints adds up every datapoint across all pages of the response.
+func (c *Client) sumDatapoints(
+	ctx context.Context,
+	request monitoring.SummarizeMetricsDataRequest,
+) (float64, error) {
+	var (
+		pageToken *string
+		sum       float64
+	)
+
+	window := requestWindow(request)
+	logger := logging.WithStep(ctx, c.log())
+	request = withStepRequestID(ctx, request)
+
+	for {
+		response, nextPage, err := c.metrics.SummarizeMetricsData(ctx, request, pageToken)
+		if err != nil {
+			logger.Debug(
+				"monitoring query failed",
+				"query", queryText(request),
+				"start", window.Start,
+				"end", window.End,
+				"error", err,
+			)
+
+			return 0, fmt.Errorf("summarize metrics: %w", err)
+		}
+
+		for _, stream := range response.Items {
+			for _, datapoint := range stream.AggregatedDatapoints {
+				if datapoint.Value != nil {
+					sum += *datapoint.Value
+				}
+			}
+		}
+
+		c.observeServerTime(response.RawResponse, c.now().UTC(), time.Time{})
+
+		pageToken = normalizePageToken(nextPage)
+		if pageToken == nil {
+			break
+		}
+	}
+
+	logger.Debug(
+		"monitoring query",
+		"query", queryText(request),
+		"start", window.Start,
+		"end", window.End,
+		"sum", sum,
+	)
+
+	return sum, nil
+}
+
 func foldMetricStreams(
 	streams []monitoring.MetricData,
 	latestTimestamp time.Time,
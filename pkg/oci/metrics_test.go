@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -19,6 +20,8 @@ import (
 
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/monitoring"
+
+	"oci-cpu-shaper/pkg/logging"
 )
 
 var (
@@ -207,6 +210,64 @@ func TestQueryP95CPUFetchesLatestDatapoint(t *testing.T) {
 	requireEqual(t, verifying.pages[1], "next", "second page token")
 }
 
+func TestQueryNetworkBytes7dSumsDailyTotals(t *testing.T) {
+	t.Parallel()
+
+	instanceID := "ocid1.instance.oc1.phx.exampleuniqueID"
+	compartmentID := "ocid1.compartment.oc1..exampleuniqueID"
+	now := time.Date(2025, time.January, 9, 12, 0, 0, 0, time.UTC)
+
+	server := newIPv4TestServer(
+		t,
+		http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+			writer.WriteHeader(http.StatusOK)
+		}),
+	)
+	t.Cleanup(server.Close)
+
+	responses := []monitoring.SummarizeMetricsDataResponse{
+		metricResponse(
+			metricData(instanceID, compartmentID, now.Add(-48*time.Hour), 100),
+			metricData(instanceID, compartmentID, now.Add(-24*time.Hour), 200),
+		),
+		metricResponse(metricData(instanceID, compartmentID, now, 50)),
+		metricResponse(metricData(instanceID, compartmentID, now, 900)),
+	}
+
+	verifying := newHTTPVerifyingClient(t, server, responses, []string{"next", "", ""})
+
+	client, err := newTestClient(verifying, compartmentID, func() time.Time { return now })
+	requireNoError(t, err, "create client")
+
+	totals, err := client.QueryNetworkBytes7d(context.Background(), instanceID)
+	requireNoError(t, err, "QueryNetworkBytes7d")
+
+	requireEqual(t, totals, NetworkTotals{BytesIn: 350, BytesOut: 900}, "unexpected totals")
+
+	verifying.mu.Lock()
+	defer verifying.mu.Unlock()
+
+	requireEqual(t, len(verifying.requests), 3, "request count")
+	requireEqual(
+		t,
+		*verifying.requests[0].Query,
+		"NetworksBytesIn[1d]{resourceId = \""+instanceID+"\"}.sum()",
+		"inbound query",
+	)
+	requireEqual(
+		t,
+		*verifying.requests[2].Query,
+		"NetworksBytesOut[1d]{resourceId = \""+instanceID+"\"}.sum()",
+		"outbound query",
+	)
+	assertRequestWindow(t, verifying.requests[2], now.Add(-7*24*time.Hour), now)
+
+	_, err = client.QueryNetworkBytes7d(context.Background(), "")
+	if !errors.Is(err, errMissingInstanceOCID) {
+		t.Fatalf("expected errMissingInstanceOCID, got %v", err)
+	}
+}
+
 func TestQueryP95CPUHandlesMissingData(t *testing.T) {
 	t.Parallel()
 
@@ -292,7 +353,7 @@ func TestBuildSummarizeRequestEscapesInstanceOCID(t *testing.T) {
 	compartmentID := "ocid1.compartment.oc1..exampleuniqueID"
 	instanceID := "ocid1.instance.oc1..example\"uniqueID"
 
-	request := buildSummarizeRequest(compartmentID, instanceID, start, end)
+	request := buildSummarizeRequest(compartmentID, QueryScope{}, instanceID, start, end)
 
 	if request.CompartmentId == nil {
 		t.Fatalf("request missing compartment ID: %#v", request)
@@ -306,7 +367,8 @@ func TestBuildSummarizeRequestEscapesInstanceOCID(t *testing.T) {
 		t.Fatalf("request missing query: %#v", details)
 	}
 
-	expectedQuery := fmt.Sprintf(metricQueryTemplate, escapeDimensionValue(instanceID))
+	expectedQuery := "CpuUtilization[1m]{resourceId = " +
+		"\"ocid1.instance.oc1..example\\\"uniqueID\"}.percentile(0.95)"
 	requireEqual(t, *details.Query, expectedQuery, "escaped query")
 
 	if details.StartTime == nil || details.EndTime == nil {
@@ -345,6 +407,7 @@ func TestCollectLatestDatapointAggregatesAcrossPages(t *testing.T) {
 
 	request := buildSummarizeRequest(
 		"ocid.compartment",
+		QueryScope{},
 		"ocid.instance",
 		now.Add(-2*time.Hour),
 		now,
@@ -378,6 +441,7 @@ func TestCollectLatestDatapointHandlesEmptyResponses(t *testing.T) {
 
 	request := buildSummarizeRequest(
 		"ocid.compartment",
+		QueryScope{},
 		"ocid.instance",
 		time.Now().Add(-time.Hour),
 		time.Now(),
@@ -401,6 +465,7 @@ func TestCollectLatestDatapointPropagatesErrors(t *testing.T) {
 
 	request := buildSummarizeRequest(
 		"ocid.compartment",
+		QueryScope{},
 		"ocid.instance",
 		time.Now().Add(-time.Hour),
 		time.Now(),
@@ -538,6 +603,18 @@ func TestNewInstancePrincipalClientSuccess(t *testing.T) {
 	if !ok || sdkClient == nil || sdkClient.client == nil {
 		t.Fatalf("expected sdkMonitoringClient, got %#v", client.metrics)
 	}
+	if client.token == nil {
+		t.Fatal("expected the instance principal provider to be kept for token renewal")
+	}
+}
+
+func TestNewClientWithProviderRequiresCompartment(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClientWithProvider(stubConfigurationProvider(t), "", "us-ashburn-1")
+	if !errors.Is(err, errMissingCompartmentID) {
+		t.Fatalf("expected errMissingCompartmentID, got %v", err)
+	}
 }
 
 func TestNewStaticMetricsClientReturnsConstantValue(t *testing.T) {
@@ -579,7 +656,13 @@ func TestSDKMonitoringClientSummarizeMetricsDataSuccess(t *testing.T) {
 	caller := newStubAPICaller(newJSONResponse(body, headers), nil) //nolint:bodyclose
 	client := &sdkMonitoringClient{client: caller}
 
-	request := buildSummarizeRequest("ocid.compartment", "ocid.instance", now.Add(-time.Hour), now)
+	request := buildSummarizeRequest(
+		"ocid.compartment",
+		QueryScope{},
+		"ocid.instance",
+		now.Add(-time.Hour),
+		now,
+	)
 	summary, next, err := client.SummarizeMetricsData(
 		context.Background(),
 		request,
@@ -601,6 +684,7 @@ func TestSDKMonitoringClientSummarizeMetricsDataWrapsCallErrors(t *testing.T) {
 
 	request := buildSummarizeRequest(
 		"ocid.compartment",
+		QueryScope{},
 		"ocid.instance",
 		time.Now().Add(-time.Minute),
 		time.Now(),
@@ -626,6 +710,7 @@ func TestSDKMonitoringClientSummarizeMetricsDataHandlesDecodeErrors(t *testing.T
 
 	request := buildSummarizeRequest(
 		"ocid.compartment",
+		QueryScope{},
 		"ocid.instance",
 		time.Now().Add(-time.Minute),
 		time.Now(),
@@ -1045,3 +1130,54 @@ func (s *stubAPICaller) Call(
 
 	return s.response, nil
 }
+
+func TestClientTagsQueriesWithTheStepID(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+	metrics := &skewedMetricsClient{serverTime: now}
+
+	client, err := newTestClient(metrics, "ocid1.compartment.oc1..example", func() time.Time {
+		return now
+	})
+	requireNoError(t, err, "construct client")
+
+	// The fake records requests without locking, so issue them one at a time.
+	client.queryConcurrency = 1
+
+	var buffer bytes.Buffer
+
+	client.SetLogger(slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       slog.LevelDebug,
+		ReplaceAttr: nil,
+	})))
+
+	ctx := logging.WithStepID(t.Context(), "5f3a")
+
+	_, err = client.QueryStep(ctx, "ocid1.instance.oc1..example", StepQuery{Network: true})
+	requireNoError(t, err, "query step")
+
+	_, err = client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..example", false)
+	requireNoError(t, err, "query p95")
+
+	if len(metrics.requests) != 4 {
+		t.Fatalf("expected four requests, got %d", len(metrics.requests))
+	}
+
+	for _, request := range metrics.requests[:3] {
+		if request.OpcRequestId == nil || *request.OpcRequestId != "5f3a" {
+			t.Fatalf("expected the step ID as opc-request-id, got %v", request.OpcRequestId)
+		}
+	}
+
+	if metrics.requests[3].OpcRequestId != nil {
+		t.Fatalf("expected no opc-request-id outside a step, got %q",
+			*metrics.requests[3].OpcRequestId)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 4 || strings.Count(buffer.String(), "step=5f3a") != 3 {
+		t.Fatalf("expected the step queries logged with the step ID, got %s", buffer.String())
+	}
+}
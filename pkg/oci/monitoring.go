@@ -7,3 +7,38 @@ import "context"
 type MetricsClient interface {
 	QueryP95CPU(ctx context.Context, resourceID string) (float64, error)
 }
+
+// NetworkTotals holds the bytes an instance received and sent over the
+// trailing seven days.
+type NetworkTotals struct {
+	BytesIn  float64
+	BytesOut float64
+}
+
+// NetworkMetricsClient is implemented by MetricsClients that can also report
+// the seven-day network totals OCI weighs, together with the CPU P95, when
+// deciding whether an Always Free instance is idle.
+type NetworkMetricsClient interface {
+	QueryNetworkBytes7d(ctx context.Context, resourceID string) (NetworkTotals, error)
+}
+
+// StepQuery selects the metrics fetched alongside the CPU P95 for one control
+// step.
+type StepQuery struct {
+	Network bool
+}
+
+// StepMetrics holds the metrics fetched for one control step. A failed network
+// query does not fail the step; its error is reported in NetworkErr instead.
+type StepMetrics struct {
+	P95CPU     float64
+	Network    NetworkTotals
+	NetworkErr error
+}
+
+// StepMetricsClient is implemented by MetricsClients that fetch every metric a
+// control step needs as one batch, over a shared query window, rather than as
+// serialized independent calls.
+type StepMetricsClient interface {
+	QueryStep(ctx context.Context, resourceID string, query StepQuery) (StepMetrics, error)
+}
@@ -0,0 +1,134 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// ErrObjectNotModified is returned by GetObject when the object still carries the
+// ETag supplied by the caller.
+var ErrObjectNotModified = errors.New("oci: object not modified")
+
+var (
+	errMissingObjectStorageClient = errors.New("oci: object storage client is required")
+	errNilObjectStorageClient     = errors.New("oci: object storage client receiver is nil")
+	errIncompleteObjectRef        = errors.New("oci: namespace, bucket, and object are required")
+)
+
+type objectGetter interface {
+	GetObject(
+		ctx context.Context,
+		request objectstorage.GetObjectRequest,
+	) (objectstorage.GetObjectResponse, error)
+}
+
+// ObjectRef names an Object Storage object.
+type ObjectRef struct {
+	Namespace string
+	Bucket    string
+	Name      string
+}
+
+// ObjectStorageClient reads objects from OCI Object Storage.
+type ObjectStorageClient struct {
+	objects objectGetter
+}
+
+// NewInstancePrincipalObjectStorageClient constructs an ObjectStorageClient
+// authenticated with the instance principal. The region pins the Object Storage
+// endpoint when it is non-empty.
+func NewInstancePrincipalObjectStorageClient(region string) (*ObjectStorageClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create object storage client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		client.SetRegion(trimmedRegion)
+	}
+
+	return newObjectStorageClient(client)
+}
+
+func newObjectStorageClient(getter objectGetter) (*ObjectStorageClient, error) {
+	if getter == nil {
+		return nil, errMissingObjectStorageClient
+	}
+
+	return &ObjectStorageClient{objects: getter}, nil
+}
+
+// GetObject returns the content and ETag of ref. When etag is non-empty and still
+// matches the object, it returns ErrObjectNotModified without transferring the body.
+func (c *ObjectStorageClient) GetObject(
+	ctx context.Context,
+	ref ObjectRef,
+	etag string,
+) ([]byte, string, error) {
+	if c == nil || c.objects == nil {
+		return nil, "", errNilObjectStorageClient
+	}
+
+	if ref.Namespace == "" || ref.Bucket == "" || ref.Name == "" {
+		return nil, "", errIncompleteObjectRef
+	}
+
+	var request objectstorage.GetObjectRequest
+
+	request.NamespaceName = &ref.Namespace
+	request.BucketName = &ref.Bucket
+	request.ObjectName = &ref.Name
+
+	if etag != "" {
+		request.IfNoneMatch = &etag
+	}
+
+	response, err := c.objects.GetObject(ctx, request)
+	if err != nil {
+		return nil, "", fmt.Errorf("get object: %w", err)
+	}
+
+	if response.Content != nil {
+		defer func() {
+			_ = response.Content.Close()
+		}()
+	}
+
+	if response.RawResponse != nil && response.RawResponse.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrObjectNotModified
+	}
+
+	if response.Content == nil {
+		return nil, "", fmt.Errorf("get object: %w", io.ErrUnexpectedEOF)
+	}
+
+	content, err := io.ReadAll(response.Content)
+	if err != nil {
+		return nil, "", fmt.Errorf("read object: %w", err)
+	}
+
+	var newETag string
+	if response.ETag != nil {
+		newETag = *response.ETag
+	}
+
+	return content, newETag, nil
+}
@@ -0,0 +1,97 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+var errStubObjectDenied = errors.New("stub: object denied")
+
+type stubObjectGetter struct {
+	content string
+	etag    string
+	err     error
+	request objectstorage.GetObjectRequest
+}
+
+func (s *stubObjectGetter) GetObject(
+	_ context.Context,
+	request objectstorage.GetObjectRequest,
+) (objectstorage.GetObjectResponse, error) {
+	s.request = request
+
+	var response objectstorage.GetObjectResponse
+	if s.err != nil {
+		return response, s.err
+	}
+
+	response.RawResponse = &http.Response{StatusCode: http.StatusOK} //nolint:exhaustruct
+
+	if request.IfNoneMatch != nil && *request.IfNoneMatch == s.etag {
+		response.RawResponse.StatusCode = http.StatusNotModified
+
+		return response, nil
+	}
+
+	response.Content = io.NopCloser(strings.NewReader(s.content))
+	response.ETag = &s.etag
+
+	return response, nil
+}
+
+func TestObjectStorageGetObjectHonoursETag(t *testing.T) {
+	t.Parallel()
+
+	getter := &stubObjectGetter{content: "controller: {}\n", etag: "v2"} //nolint:exhaustruct
+	ref := ObjectRef{Namespace: "tenancy", Bucket: "configs", Name: "shaper/config.yaml"}
+
+	client, err := newObjectStorageClient(getter)
+	requireNoError(t, err, "construct object storage client")
+
+	content, etag, err := client.GetObject(t.Context(), ref, "v1")
+	requireNoError(t, err, "get changed object")
+	requireEqual(t, string(content), "controller: {}\n", "object content")
+	requireEqual(t, etag, "v2", "object etag")
+	requireEqual(t, *getter.request.ObjectName, "shaper/config.yaml", "object name")
+
+	_, etag, err = client.GetObject(t.Context(), ref, "v2")
+	if !errors.Is(err, ErrObjectNotModified) {
+		t.Fatalf("expected ErrObjectNotModified, got %v", err)
+	}
+
+	requireEqual(t, etag, "v2", "unchanged etag")
+}
+
+func TestObjectStorageGetObjectHandlesFailures(t *testing.T) {
+	t.Parallel()
+
+	ref := ObjectRef{Namespace: "tenancy", Bucket: "configs", Name: "config.yaml"}
+
+	getter := &stubObjectGetter{err: errStubObjectDenied} //nolint:exhaustruct
+
+	client, err := newObjectStorageClient(getter)
+	requireNoError(t, err, "construct object storage client")
+
+	_, _, err = client.GetObject(t.Context(), ref, "")
+	if !errors.Is(err, errStubObjectDenied) {
+		t.Fatalf("expected API error, got %v", err)
+	}
+
+	incomplete := ObjectRef{Namespace: "tenancy"} //nolint:exhaustruct
+
+	_, _, err = client.GetObject(t.Context(), incomplete, "")
+	if !errors.Is(err, errIncompleteObjectRef) {
+		t.Fatalf("expected errIncompleteObjectRef, got %v", err)
+	}
+
+	_, err = newObjectStorageClient(nil)
+	if !errors.Is(err, errMissingObjectStorageClient) {
+		t.Fatalf("expected errMissingObjectStorageClient, got %v", err)
+	}
+}
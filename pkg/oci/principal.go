@@ -0,0 +1,522 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSignatureSkew bounds how far the signed Date header may drift from
+	// the local clock, which also limits how long a captured request replays.
+	DefaultSignatureSkew = 5 * time.Minute
+
+	securityTokenPrefix = "ST$"
+	maxSignedBodyBytes  = 1 << 20
+	issuerKeysTimeout   = 10 * time.Second
+	issuerKeysRefresh   = time.Minute
+)
+
+var (
+	// ErrUnauthenticated reports a request whose instance principal signature
+	// could not be verified.
+	ErrUnauthenticated = errors.New("oci: request signature not verified")
+	// ErrForbidden reports a verified instance principal outside the dynamic group.
+	ErrForbidden = errors.New("oci: principal does not match the dynamic group")
+
+	errMissingIssuerKeys  = errors.New("oci: token issuer keys URL is required")
+	errInsecureIssuerKeys = errors.New("oci: token issuer keys URL must use https")
+	errMissingTenancyID   = errors.New("oci: tenancy OCID is required")
+	errIssuerKeysStatus   = errors.New("oci: unexpected issuer keys status")
+)
+
+// Principal identifies the instance behind an instance principal security token.
+type Principal struct {
+	InstanceID    string
+	CompartmentID string
+	TenancyID     string
+}
+
+// PrincipalVerifier authenticates inbound HTTP requests signed with an
+// instance principal, the same way OCI services authenticate the shaper. The
+// signature is checked against the session key embedded in the caller's
+// security token, the token against the issuer's published keys and the
+// expected tenancy, and the instance against a dynamic group matching rule.
+type PrincipalVerifier struct {
+	keys    *issuerKeySet
+	tenancy string
+	rule    MatchingRule
+	skew    time.Duration
+	now     func() time.Time
+}
+
+// NewPrincipalVerifier constructs a PrincipalVerifier that trusts tokens signed
+// by the JSON Web Key Set served over https at keysURL for tenancyID, and
+// admits instances matching rule. Matching rules compare OCIDs only, so the
+// tenancy check keeps instances of other tenancies out. A nil client uses a
+// client with a ten second timeout.
+func NewPrincipalVerifier(
+	keysURL string,
+	tenancyID string,
+	rule MatchingRule,
+	client *http.Client,
+) (*PrincipalVerifier, error) {
+	trimmed, err := ValidateIssuerKeysURL(keysURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tenancy := strings.TrimSpace(tenancyID)
+	if tenancy == "" {
+		return nil, errMissingTenancyID
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: issuerKeysTimeout}
+	}
+
+	return &PrincipalVerifier{
+		keys:    &issuerKeySet{url: trimmed, client: client, now: time.Now},
+		tenancy: tenancy,
+		rule:    rule,
+		skew:    DefaultSignatureSkew,
+		now:     time.Now,
+	}, nil
+}
+
+// ValidateIssuerKeysURL returns the trimmed keysURL, or an error unless it is
+// an absolute https URL. The key set decides which tokens are trusted, so it
+// must not be fetched over a connection an attacker could tamper with.
+func ValidateIssuerKeysURL(keysURL string) (string, error) {
+	trimmed := strings.TrimSpace(keysURL)
+	if trimmed == "" {
+		return "", errMissingIssuerKeys
+	}
+
+	parsed, err := url.Parse(trimmed)
+	if err != nil || !strings.EqualFold(parsed.Scheme, "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: %q", errInsecureIssuerKeys, trimmed)
+	}
+
+	return trimmed, nil
+}
+
+// Verify returns the principal that signed request. Failures wrap
+// ErrUnauthenticated, or ErrForbidden when the signature is valid but the
+// instance is not a member of the dynamic group. A signed body is read and
+// restored so handlers can still consume it.
+func (v *PrincipalVerifier) Verify(request *http.Request) (Principal, error) {
+	params, err := parseSignatureHeader(request.Header.Get("Authorization"))
+	if err != nil {
+		return Principal{}, err
+	}
+
+	token, ok := strings.CutPrefix(params["keyId"], securityTokenPrefix)
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: keyId is not a security token", ErrUnauthenticated)
+	}
+
+	now := v.now()
+
+	principal, sessionKey, err := v.verifyToken(request.Context(), token, now)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	err = v.verifySignature(request, params, sessionKey, now)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if !v.rule.Matches(principal) {
+		return principal, ErrForbidden
+	}
+
+	return principal, nil
+}
+
+func parseSignatureHeader(header string) (map[string]string, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Signature") {
+		return nil, fmt.Errorf("%w: missing Signature authorization", ErrUnauthenticated)
+	}
+
+	params := make(map[string]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		name, value, found := strings.Cut(rest, "=\"")
+		if !found {
+			return nil, fmt.Errorf("%w: malformed authorization header", ErrUnauthenticated)
+		}
+
+		end := strings.IndexByte(value, '"')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: malformed authorization header", ErrUnauthenticated)
+		}
+
+		params[strings.TrimSpace(name)] = value[:end]
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value[end+1:]), ","))
+	}
+
+	if version, ok := params["version"]; ok && version != "1" {
+		return nil, fmt.Errorf("%w: unsupported signature version %q", ErrUnauthenticated, version)
+	}
+
+	if !strings.EqualFold(params["algorithm"], "rsa-sha256") {
+		return nil, fmt.Errorf("%w: unsupported signature algorithm", ErrUnauthenticated)
+	}
+
+	return params, nil
+}
+
+func (v *PrincipalVerifier) verifySignature(
+	request *http.Request,
+	params map[string]string,
+	key *rsa.PublicKey,
+	now time.Time,
+) error {
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+
+	required := []string{"date", "(request-target)", "host"}
+	if request.Method == http.MethodPost || request.Method == http.MethodPut ||
+		request.Method == http.MethodPatch {
+		required = append(required, "x-content-sha256")
+	}
+
+	for _, name := range required {
+		if !slices.Contains(headers, name) {
+			return fmt.Errorf("%w: %s is not signed", ErrUnauthenticated, name)
+		}
+	}
+
+	date, err := http.ParseTime(request.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("%w: invalid date header", ErrUnauthenticated)
+	}
+
+	if drift := now.Sub(date); drift > v.skew || drift < -v.skew {
+		return fmt.Errorf("%w: date is outside the allowed skew", ErrUnauthenticated)
+	}
+
+	if slices.Contains(headers, "x-content-sha256") {
+		err = verifyBodyHash(request)
+		if err != nil {
+			return err
+		}
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrUnauthenticated)
+	}
+
+	digest := sha256.Sum256([]byte(signingString(request, headers)))
+
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature mismatch", ErrUnauthenticated)
+	}
+
+	return nil
+}
+
+func signingString(request *http.Request, headers []string) string {
+	parts := make([]string, len(headers))
+
+	for index, name := range headers {
+		var value string
+
+		switch name {
+		case "(request-target)":
+			target := request.RequestURI
+			if target == "" {
+				target = request.URL.RequestURI()
+			}
+
+			value = strings.ToLower(request.Method) + " " + target
+		case "host":
+			value = request.Host
+		default:
+			value = request.Header.Get(name)
+		}
+
+		parts[index] = name + ": " + value
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func verifyBodyHash(request *http.Request) error {
+	var body []byte
+
+	if request.Body != nil && request.Body != http.NoBody {
+		data, err := io.ReadAll(io.LimitReader(request.Body, maxSignedBodyBytes+1))
+		if err != nil {
+			return fmt.Errorf("%w: read body: %w", ErrUnauthenticated, err)
+		}
+
+		if len(data) > maxSignedBodyBytes {
+			return fmt.Errorf("%w: body too large", ErrUnauthenticated)
+		}
+
+		body = data
+		request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	sum := sha256.Sum256(body)
+	if request.Header.Get("X-Content-Sha256") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return fmt.Errorf("%w: body hash mismatch", ErrUnauthenticated)
+	}
+
+	return nil
+}
+
+type tokenClaims struct {
+	Expires     int64           `json:"exp"`
+	NotBefore   int64           `json:"nbf"`
+	Subject     string          `json:"sub"`
+	Type        string          `json:"ptype"`
+	Instance    string          `json:"opc-instance"`
+	Compartment string          `json:"opc-compartment"`
+	Tenant      string          `json:"opc-tenant"`
+	SessionKey  json.RawMessage `json:"jwk"`
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+func (v *PrincipalVerifier) verifyToken(
+	ctx context.Context,
+	token string,
+	now time.Time,
+) (Principal, *rsa.PublicKey, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, nil, fmt.Errorf("%w: malformed security token", ErrUnauthenticated)
+	}
+
+	var header tokenHeader
+
+	err := decodeTokenPart(parts[0], &header)
+	if err != nil || header.Algorithm != "RS256" {
+		return Principal{}, nil, fmt.Errorf("%w: unsupported security token", ErrUnauthenticated)
+	}
+
+	issuerKey, err := v.keys.key(ctx, header.KeyID)
+	if err != nil {
+		return Principal{}, nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, nil, fmt.Errorf("%w: malformed security token", ErrUnauthenticated)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	err = rsa.VerifyPKCS1v15(issuerKey, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return Principal{}, nil, fmt.Errorf("%w: untrusted security token", ErrUnauthenticated)
+	}
+
+	var claims tokenClaims
+
+	err = decodeTokenPart(parts[1], &claims)
+	if err != nil {
+		return Principal{}, nil, fmt.Errorf("%w: malformed token claims", ErrUnauthenticated)
+	}
+
+	if claims.Expires <= now.Unix() || (claims.NotBefore != 0 && claims.NotBefore > now.Unix()) {
+		return Principal{}, nil, fmt.Errorf("%w: security token expired", ErrUnauthenticated)
+	}
+
+	principal := Principal{
+		InstanceID:    claims.Instance,
+		CompartmentID: claims.Compartment,
+		TenancyID:     claims.Tenant,
+	}
+	if principal.InstanceID == "" && claims.Type == "instance" {
+		principal.InstanceID = claims.Subject
+	}
+
+	if principal.InstanceID == "" {
+		return Principal{}, nil, fmt.Errorf("%w: not an instance principal", ErrUnauthenticated)
+	}
+
+	if principal.TenancyID != v.tenancy {
+		return Principal{}, nil, fmt.Errorf(
+			"%w: token issued for another tenancy",
+			ErrUnauthenticated,
+		)
+	}
+
+	sessionKey, err := parseSessionKey(claims.SessionKey)
+	if err != nil {
+		return Principal{}, nil, err
+	}
+
+	return principal, sessionKey, nil
+}
+
+func decodeTokenPart(part string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return fmt.Errorf("decode token part: %w", err)
+	}
+
+	err = json.Unmarshal(data, target)
+	if err != nil {
+		return fmt.Errorf("decode token part: %w", err)
+	}
+
+	return nil
+}
+
+type jsonWebKey struct {
+	KeyID    string `json:"kid"`
+	Type     string `json:"kty"`
+	Modulus  string `json:"n"`
+	Exponent string `json:"e"`
+}
+
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	if k.Type != "RSA" {
+		return nil, fmt.Errorf("%w: unsupported key type %q", ErrUnauthenticated, k.Type)
+	}
+
+	modulus, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.Modulus, "="))
+	if err != nil || len(modulus) == 0 {
+		return nil, fmt.Errorf("%w: malformed key modulus", ErrUnauthenticated)
+	}
+
+	exponent, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.Exponent, "="))
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, fmt.Errorf("%w: malformed key exponent", ErrUnauthenticated)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// parseSessionKey decodes the jwk claim, which OCI encodes as a JSON string
+// holding the key object.
+func parseSessionKey(raw json.RawMessage) (*rsa.PublicKey, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: security token has no session key", ErrUnauthenticated)
+	}
+
+	var encoded string
+	if json.Unmarshal(raw, &encoded) == nil {
+		raw = json.RawMessage(encoded)
+	}
+
+	var key jsonWebKey
+
+	err := json.Unmarshal(raw, &key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed session key", ErrUnauthenticated)
+	}
+
+	return key.publicKey()
+}
+
+// issuerKeySet caches the token issuer's JSON Web Key Set and refetches it,
+// at most once a minute, when a token names an unknown key.
+type issuerKeySet struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func (s *issuerKeySet) key(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[keyID]; ok {
+		return key, nil
+	}
+
+	if !s.fetched.IsZero() && s.now().Sub(s.fetched) < issuerKeysRefresh {
+		return nil, fmt.Errorf("%w: unknown token issuer key %q", ErrUnauthenticated, keyID)
+	}
+
+	err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown token issuer key %q", ErrUnauthenticated, keyID)
+	}
+
+	return key, nil
+}
+
+func (s *issuerKeySet) fetch(ctx context.Context) error {
+	s.fetched = s.now()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("build issuer keys request: %w", err)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("fetch issuer keys: %w", err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", errIssuerKeysStatus, response.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxSignedBodyBytes)).Decode(&document)
+	if err != nil {
+		return fmt.Errorf("decode issuer keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+
+	for _, entry := range document.Keys {
+		key, keyErr := entry.publicKey()
+		if keyErr != nil {
+			continue
+		}
+
+		keys[entry.KeyID] = key
+	}
+
+	s.keys = keys
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package oci //nolint:testpackage
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+const (
+	testInstanceID    = "ocid1.instance.oc1.phx.fleet"
+	testCompartmentID = "ocid1.compartment.oc1..fleet"
+	testTenancyID     = "ocid1.tenancy.oc1..fleet"
+)
+
+type sessionKeyProvider struct {
+	key   *rsa.PrivateKey
+	token string
+}
+
+func (p sessionKeyProvider) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	return p.key, nil
+}
+
+func (p sessionKeyProvider) KeyID() (string, error) {
+	return securityTokenPrefix + p.token, nil
+}
+
+type principalFixture struct {
+	verifier *PrincipalVerifier
+	signer   common.HTTPRequestSigner
+	now      time.Time
+}
+
+func newPrincipalFixture(t *testing.T, rule string, expires time.Time) principalFixture {
+	t.Helper()
+
+	issuer, err := rsa.GenerateKey(rand.Reader, 2048)
+	requireNoError(t, err, "generate issuer key")
+
+	session, err := rsa.GenerateKey(rand.Reader, 2048)
+	requireNoError(t, err, "generate session key")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []any{testJWK("issuer", &issuer.PublicKey)},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	sessionJWK, err := json.Marshal(testJWK("session", &session.PublicKey))
+	requireNoError(t, err, "encode session key")
+
+	token := signTestToken(t, issuer, map[string]any{
+		"exp":             expires.Unix(),
+		"ptype":           "instance",
+		"sub":             testInstanceID,
+		"opc-instance":    testInstanceID,
+		"opc-compartment": testCompartmentID,
+		"opc-tenant":      testTenancyID,
+		"jwk":             string(sessionJWK),
+	})
+
+	parsed, err := ParseMatchingRule(rule)
+	requireNoError(t, err, "parse matching rule")
+
+	verifier, err := NewPrincipalVerifier(server.URL, testTenancyID, parsed, server.Client())
+	requireNoError(t, err, "construct verifier")
+
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+
+	return principalFixture{
+		verifier: verifier,
+		signer:   common.DefaultRequestSigner(sessionKeyProvider{key: session, token: token}),
+		now:      now,
+	}
+}
+
+func testJWK(keyID string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": keyID,
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func signTestToken(t *testing.T, issuer *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "issuer"})
+	requireNoError(t, err, "encode token header")
+
+	payload, err := json.Marshal(claims)
+	requireNoError(t, err, "encode token claims")
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, issuer, crypto.SHA256, digest[:])
+	requireNoError(t, err, "sign token")
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// signedRequest signs a client request and converts it into the form a server
+// handler receives.
+func (f principalFixture) signedRequest(t *testing.T, method, target string) *http.Request {
+	t.Helper()
+
+	client, err := http.NewRequestWithContext(
+		t.Context(),
+		method,
+		"http://10.0.0.5:9108"+target,
+		strings.NewReader(""),
+	)
+	requireNoError(t, err, "build request")
+
+	client.Header.Set("Date", f.now.UTC().Format(http.TimeFormat))
+	requireNoError(t, f.signer.Sign(client), "sign request")
+
+	server := httptest.NewRequest(method, target, http.NoBody)
+	server.Host = client.URL.Host
+	server.Header = client.Header.Clone()
+
+	return server
+}
+
+func TestPrincipalVerifierAcceptsDynamicGroupMembers(t *testing.T) {
+	t.Parallel()
+
+	fixture := newPrincipalFixture(
+		t,
+		"ANY {instance.compartment.id = '"+testCompartmentID+"'}",
+		time.Now().Add(time.Hour),
+	)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		principal, err := fixture.verifier.Verify(
+			fixture.signedRequest(t, method, "/admin/suppress?duration=30m"),
+		)
+		requireNoError(t, err, "verify "+method)
+		requireEqual(t, principal.InstanceID, testInstanceID, "instance")
+		requireEqual(t, principal.CompartmentID, testCompartmentID, "compartment")
+	}
+}
+
+func TestPrincipalVerifierRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	fixture := newPrincipalFixture(
+		t,
+		"instance.id = '"+testInstanceID+"'",
+		time.Now().Add(time.Hour),
+	)
+
+	tampered := fixture.signedRequest(t, http.MethodPost, "/admin/step")
+	tampered.URL.Path = "/admin/suppress"
+	tampered.RequestURI = "/admin/suppress"
+
+	stale := fixture.signedRequest(t, http.MethodGet, "/admin/history")
+	fixture.verifier.now = func() time.Time { return fixture.now.Add(10 * time.Minute) }
+
+	_, err := fixture.verifier.Verify(stale)
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected stale date to be rejected, got %v", err)
+	}
+
+	fixture.verifier.now = func() time.Time { return fixture.now }
+
+	unsigned := httptest.NewRequest(http.MethodGet, "/admin/history", http.NoBody)
+
+	for name, request := range map[string]*http.Request{
+		"tampered": tampered,
+		"unsigned": unsigned,
+	} {
+		_, err = fixture.verifier.Verify(request)
+		if !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("%s: expected ErrUnauthenticated, got %v", name, err)
+		}
+	}
+}
+
+func TestPrincipalVerifierRejectsOutsidersAndExpiredTokens(t *testing.T) {
+	t.Parallel()
+
+	outsider := newPrincipalFixture(
+		t,
+		"ALL {instance.compartment.id = '"+testCompartmentID+"', instance.id != '"+
+			testInstanceID+"'}",
+		time.Now().Add(time.Hour),
+	)
+
+	_, err := outsider.verifier.Verify(outsider.signedRequest(t, http.MethodGet, "/admin/history"))
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	expired := newPrincipalFixture(
+		t,
+		"instance.compartment.id = '"+testCompartmentID+"'",
+		time.Now().Add(-time.Minute),
+	)
+
+	_, err = expired.verifier.Verify(expired.signedRequest(t, http.MethodGet, "/admin/history"))
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestPrincipalVerifierRejectsOtherTenancies(t *testing.T) {
+	t.Parallel()
+
+	fixture := newPrincipalFixture(
+		t,
+		"instance.id = '"+testInstanceID+"'",
+		time.Now().Add(time.Hour),
+	)
+	fixture.verifier.tenancy = "ocid1.tenancy.oc1..other"
+
+	_, err := fixture.verifier.Verify(fixture.signedRequest(t, http.MethodGet, "/admin/history"))
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected a token from another tenancy to be rejected, got %v", err)
+	}
+}
+
+func TestNewPrincipalVerifierRequiresHTTPSKeysAndTenancy(t *testing.T) {
+	t.Parallel()
+
+	rule, err := ParseMatchingRule("instance.id = '" + testInstanceID + "'")
+	requireNoError(t, err, "parse matching rule")
+
+	for _, testCase := range []struct {
+		keysURL string
+		tenancy string
+		want    error
+	}{
+		{keysURL: "", tenancy: testTenancyID, want: errMissingIssuerKeys},
+		{
+			keysURL: "http://auth.example.com/keys",
+			tenancy: testTenancyID,
+			want:    errInsecureIssuerKeys,
+		},
+		{keysURL: "auth.example.com/keys", tenancy: testTenancyID, want: errInsecureIssuerKeys},
+		{keysURL: "https://auth.example.com/keys", tenancy: " ", want: errMissingTenancyID},
+	} {
+		_, err = NewPrincipalVerifier(testCase.keysURL, testCase.tenancy, rule, nil)
+		if !errors.Is(err, testCase.want) {
+			t.Fatalf(
+				"%q/%q: expected %v, got %v",
+				testCase.keysURL,
+				testCase.tenancy,
+				testCase.want,
+				err,
+			)
+		}
+	}
+
+	_, err = NewPrincipalVerifier(" https://auth.example.com/keys ", testTenancyID, rule, nil)
+	requireNoError(t, err, "construct verifier")
+}
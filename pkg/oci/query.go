@@ -0,0 +1,384 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// dimensionResourceID is the dimension every query filters on to select the
+	// instance; a QueryScope cannot replace it.
+	dimensionResourceID = "resourceId"
+
+	statisticPercentile = "percentile"
+	statisticSum        = "sum"
+	statisticMean       = "mean"
+
+	// guardrailWindow and guardrailThreshold mirror how OCI decides that an
+	// Always Free instance is idle (§3).
+	guardrailWindow    = 7 * 24 * time.Hour
+	guardrailThreshold = 20
+)
+
+var (
+	// ErrInvalidQuery reports a MetricQuery that Monitoring would reject.
+	ErrInvalidQuery = errors.New("oci: invalid monitoring query")
+	// ErrInvalidQueryScope reports a QueryScope that cannot be expressed in a
+	// Monitoring query.
+	ErrInvalidQueryScope = errors.New("oci: invalid query scope")
+)
+
+var (
+	// identifierPattern matches the metric and dimension names MQL accepts
+	// unquoted, such as CpuUtilization or faultDomain.
+	identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`) //nolint:gochecknoglobals
+	// resourceGroupPattern follows the characters Monitoring allows in a
+	// resource group name.
+	resourceGroupPattern = regexp.MustCompile(`^[A-Za-z0-9._$-]+$`) //nolint:gochecknoglobals
+
+	predicateOperators = []string{"<", "<=", ">", ">=", "==", "!="} //nolint:gochecknoglobals
+)
+
+// Dimension is a name = "value" filter of a MetricQuery.
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+// MetricQuery builds a Monitoring Query Language expression of the form
+//
+//	Metric[interval]{name = "value", ...}.window(window).statistic() operator value
+//
+// where the dimensions, window and predicate are optional. Its methods return
+// modified copies, so a partial query can be shared as a template; Validate
+// reports a query Monitoring would reject.
+type MetricQuery struct {
+	metric     string
+	interval   time.Duration
+	dimensions []Dimension
+	window     time.Duration
+	statistic  string
+	percentile float64
+	operator   string
+	threshold  float64
+}
+
+// NewMetricQuery starts a query of metric aggregated per interval, which MQL
+// accepts in whole minutes, hours or days.
+func NewMetricQuery(metric string, interval time.Duration) MetricQuery {
+	return MetricQuery{
+		metric:     metric,
+		interval:   interval,
+		dimensions: nil,
+		window:     0,
+		statistic:  "",
+		percentile: 0,
+		operator:   "",
+		threshold:  0,
+	}
+}
+
+// Where adds a dimension filter. Values are quoted and escaped.
+func (q MetricQuery) Where(name, value string) MetricQuery {
+	q.dimensions = append(slices.Clip(q.dimensions), Dimension{Name: name, Value: value})
+
+	return q
+}
+
+// Window evaluates the statistic over a sliding window instead of each
+// interval, as alarms do.
+func (q MetricQuery) Window(window time.Duration) MetricQuery {
+	q.window = window
+
+	return q
+}
+
+// Percentile selects the given percentile, between 0 and 1 exclusive.
+func (q MetricQuery) Percentile(percentile float64) MetricQuery {
+	q.statistic = statisticPercentile
+	q.percentile = percentile
+
+	return q
+}
+
+// Sum selects the sum.
+func (q MetricQuery) Sum() MetricQuery {
+	q.statistic = statisticSum
+
+	return q
+}
+
+// Mean selects the mean.
+func (q MetricQuery) Mean() MetricQuery {
+	q.statistic = statisticMean
+
+	return q
+}
+
+// Predicate turns the query into an alarm condition comparing the statistic
+// against threshold with operator, one of <, <=, >, >=, == and !=.
+func (q MetricQuery) Predicate(operator string, threshold float64) MetricQuery {
+	q.operator = operator
+	q.threshold = threshold
+
+	return q
+}
+
+// Validate reports ErrInvalidQuery when Monitoring would reject the query: a
+// metric or dimension name that is not an identifier, a repeated dimension, an
+// interval or window that is not a whole number of minutes, hours or days, a
+// window shorter than the interval, a missing statistic, a percentile outside
+// (0, 1) or an unknown predicate operator.
+func (q MetricQuery) Validate() error {
+	if !identifierPattern.MatchString(q.metric) {
+		return fmt.Errorf("%w: metric name %q is not an identifier", ErrInvalidQuery, q.metric)
+	}
+
+	if q.interval < time.Minute || !mqlDurationValid(q.interval) {
+		return fmt.Errorf("%w: interval %s is not a whole number of minutes, hours or days",
+			ErrInvalidQuery, q.interval)
+	}
+
+	if q.window != 0 && (q.window < q.interval || !mqlDurationValid(q.window)) {
+		return fmt.Errorf("%w: window %s must be a whole number of minutes, hours or days "+
+			"no shorter than the %s interval", ErrInvalidQuery, q.window, q.interval)
+	}
+
+	seen := make(map[string]bool, len(q.dimensions))
+
+	for _, dimension := range q.dimensions {
+		if !identifierPattern.MatchString(dimension.Name) {
+			return fmt.Errorf("%w: dimension name %q is not an identifier",
+				ErrInvalidQuery, dimension.Name)
+		}
+
+		if seen[strings.ToLower(dimension.Name)] {
+			return fmt.Errorf("%w: dimension %s is filtered twice", ErrInvalidQuery, dimension.Name)
+		}
+
+		seen[strings.ToLower(dimension.Name)] = true
+	}
+
+	switch {
+	case q.statistic == "":
+		return fmt.Errorf("%w: no statistic selected", ErrInvalidQuery)
+	case q.statistic == statisticPercentile && (q.percentile <= 0 || q.percentile >= 1):
+		return fmt.Errorf("%w: percentile %v must be within (0, 1)", ErrInvalidQuery, q.percentile)
+	case q.operator != "" && !slices.Contains(predicateOperators, q.operator):
+		return fmt.Errorf("%w: unknown predicate operator %q", ErrInvalidQuery, q.operator)
+	}
+
+	return nil
+}
+
+// String renders the query as MQL.
+func (q MetricQuery) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(q.metric)
+	builder.WriteString("[" + formatMQLDuration(q.interval) + "]")
+
+	if len(q.dimensions) > 0 {
+		filters := make([]string, 0, len(q.dimensions))
+		for _, dimension := range q.dimensions {
+			filters = append(filters, dimension.filter())
+		}
+
+		builder.WriteString("{" + strings.Join(filters, ", ") + "}")
+	}
+
+	for _, part := range q.calls() {
+		builder.WriteString("." + part)
+	}
+
+	if q.operator != "" {
+		builder.WriteString(" " + q.operator + " " + formatMQLNumber(q.threshold))
+	}
+
+	return builder.String()
+}
+
+// Matches reports whether query, as written by hand or returned by the API,
+// contains every part of q, ignoring case and whitespace. Other parts, such as
+// a groupBy() or further dimensions, are allowed, so an alarm still matches
+// after it has been tightened by hand.
+func (q MetricQuery) Matches(query string) bool {
+	normalized := normalizeMQL(query)
+	if normalized == "" {
+		return false
+	}
+
+	fragments := []string{q.metric + "[" + formatMQLDuration(q.interval) + "]"}
+
+	for _, dimension := range q.dimensions {
+		fragments = append(fragments, dimension.filter())
+	}
+
+	for _, part := range q.calls() {
+		fragments = append(fragments, "."+part)
+	}
+
+	if q.operator != "" {
+		fragments = append(fragments, q.operator+formatMQLNumber(q.threshold))
+	}
+
+	for _, fragment := range fragments {
+		if !strings.Contains(normalized, normalizeMQL(fragment)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// calls returns the window and statistic in evaluation order.
+func (q MetricQuery) calls() []string {
+	calls := make([]string, 0, 2)
+
+	if q.window != 0 {
+		calls = append(calls, "window("+formatMQLDuration(q.window)+")")
+	}
+
+	switch q.statistic {
+	case "":
+	case statisticPercentile:
+		calls = append(calls, "percentile("+formatMQLNumber(q.percentile)+")")
+	default:
+		calls = append(calls, q.statistic+"()")
+	}
+
+	return calls
+}
+
+func (d Dimension) filter() string {
+	return fmt.Sprintf("%s = \"%s\"", d.Name, escapeDimensionValue(d.Value))
+}
+
+func normalizeMQL(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), ""))
+}
+
+// mqlDurationValid reports whether d is a whole number of minutes, the
+// smallest unit MQL intervals and windows accept.
+func mqlDurationValid(d time.Duration) bool {
+	return d > 0 && d%time.Minute == 0
+}
+
+// formatMQLDuration writes d in the largest MQL unit that divides it, such as
+// 1m, 1h or 7d.
+func formatMQLDuration(d time.Duration) string {
+	switch {
+	case !mqlDurationValid(d):
+		return d.String()
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	default:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	}
+}
+
+func formatMQLNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// GuardrailQuery returns the alarm condition that guards instanceOCID against
+// Always Free reclamation: its seven-day P95 CpuUtilization below 20%. The
+// guardrail lookup matches existing alarms against it, and the Terraform
+// module in deploy/terraform/alarms creates it.
+func GuardrailQuery(instanceOCID string) MetricQuery {
+	return NewMetricQuery(metricName, time.Minute).
+		Where(dimensionResourceID, instanceOCID).
+		Window(guardrailWindow).
+		Percentile(cpuPercentile).
+		Predicate("<", guardrailThreshold)
+}
+
+// QueryScope narrows every Monitoring query of a Client beyond the instance's
+// resourceId: Dimensions adds filters such as availabilityDomain or
+// faultDomain, and ResourceGroup selects metrics published under a resource
+// group.
+type QueryScope struct {
+	ResourceGroup string
+	Dimensions    map[string]string
+}
+
+// ValidateQueryScope rejects dimension names MQL cannot express, a filter on
+// resourceId, which the client always sets to the instance, and resource group
+// names Monitoring does not accept.
+func ValidateQueryScope(scope QueryScope) error {
+	for _, name := range slices.Sorted(maps.Keys(scope.Dimensions)) {
+		switch {
+		case !identifierPattern.MatchString(name):
+			return fmt.Errorf("%w: dimension name %q is not an identifier",
+				ErrInvalidQueryScope, name)
+		case strings.EqualFold(name, dimensionResourceID):
+			return fmt.Errorf("%w: the %s dimension is always the instance",
+				ErrInvalidQueryScope, dimensionResourceID)
+		}
+	}
+
+	if scope.ResourceGroup != "" && !resourceGroupPattern.MatchString(scope.ResourceGroup) {
+		return fmt.Errorf("%w: resource group %q may only contain letters, digits, "+
+			"'.', '_', '-' and '$'", ErrInvalidQueryScope, scope.ResourceGroup)
+	}
+
+	return nil
+}
+
+// SetQueryScope applies scope to every later query. The scope is validated
+// with ValidateQueryScope and left unchanged when it is invalid.
+func (c *Client) SetQueryScope(scope QueryScope) error {
+	if c == nil {
+		return errNilClient
+	}
+
+	err := ValidateQueryScope(scope)
+	if err != nil {
+		return err
+	}
+
+	scope.Dimensions = maps.Clone(scope.Dimensions)
+
+	c.scopeMu.Lock()
+	c.scope = scope
+	c.scopeMu.Unlock()
+
+	return nil
+}
+
+func (c *Client) queryScope() QueryScope {
+	c.scopeMu.RLock()
+	defer c.scopeMu.RUnlock()
+
+	return c.scope
+}
+
+// apply adds the scope's dimensions to query in name order, so the rendered
+// query is stable.
+func (s QueryScope) apply(query MetricQuery) MetricQuery {
+	for _, name := range slices.Sorted(maps.Keys(s.Dimensions)) {
+		query = query.Where(name, s.Dimensions[name])
+	}
+
+	return query
+}
+
+func cpuQuery(instanceOCID string) MetricQuery {
+	return NewMetricQuery(metricName, time.Minute).
+		Where(dimensionResourceID, instanceOCID).
+		Percentile(cpuPercentile)
+}
+
+func networkQuery(metric, instanceOCID string) MetricQuery {
+	return NewMetricQuery(metric, 24*time.Hour).
+		Where(dimensionResourceID, instanceOCID).
+		Sum()
+}
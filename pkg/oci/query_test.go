@@ -0,0 +1,226 @@
+package oci //nolint:testpackage
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricQueryRendersMQL(t *testing.T) {
+	t.Parallel()
+
+	base := NewMetricQuery("CpuUtilization", time.Minute)
+	scoped := base.Where("resourceId", "ocid1.instance").Where("faultDomain", `FAULT"1`)
+
+	requireEqual(t, base.Percentile(0.95).String(), "CpuUtilization[1m].percentile(0.95)", "bare")
+	requireEqual(
+		t,
+		scoped.Sum().String(),
+		`CpuUtilization[1m]{resourceId = "ocid1.instance", faultDomain = "FAULT\"1"}.sum()`,
+		"filtered",
+	)
+	requireEqual(t, base.String(), "CpuUtilization[1m]", "no statistic")
+
+	// Where copies, so branches of a shared query do not see each other's filters.
+	left := scoped.Where("availabilityDomain", "AD-1")
+	right := scoped.Where("availabilityDomain", "AD-2")
+
+	requireEqual(
+		t,
+		left.String(),
+		`CpuUtilization[1m]{resourceId = "ocid1.instance", faultDomain = "FAULT\"1", `+
+			`availabilityDomain = "AD-1"}`,
+		"left branch",
+	)
+	requireEqual(
+		t,
+		right.String(),
+		`CpuUtilization[1m]{resourceId = "ocid1.instance", faultDomain = "FAULT\"1", `+
+			`availabilityDomain = "AD-2"}`,
+		"right branch",
+	)
+}
+
+func TestMetricQueryRendersAlarmConditions(t *testing.T) {
+	t.Parallel()
+
+	query := NewMetricQuery("CpuUtilization", time.Minute).
+		Where("resourceId", "ocid1.instance").
+		Window(7*24*time.Hour).
+		Mean().
+		Predicate(">=", 87.5)
+
+	requireEqual(
+		t,
+		query.String(),
+		`CpuUtilization[1m]{resourceId = "ocid1.instance"}.window(7d).mean() >= 87.5`,
+		"alarm condition",
+	)
+	requireEqual(
+		t,
+		NewMetricQuery("NetworksBytesIn", 2*time.Hour).Window(90*time.Minute).Sum().String(),
+		"NetworksBytesIn[2h].window(90m).sum()",
+		"units",
+	)
+	requireNoError(t, query.Validate(), "validate")
+}
+
+func TestMetricQueryValidateRejectsInvalidQueries(t *testing.T) {
+	t.Parallel()
+
+	valid := NewMetricQuery("CpuUtilization", time.Minute).Where("resourceId", "ocid1").Sum()
+
+	for name, query := range map[string]MetricQuery{
+		"metric":     NewMetricQuery("cpu utilization", time.Minute).Sum(),
+		"interval":   NewMetricQuery("CpuUtilization", 30*time.Second).Sum(),
+		"fraction":   NewMetricQuery("CpuUtilization", 90*time.Second).Sum(),
+		"window":     valid.Window(30 * time.Second),
+		"dimension":  valid.Where("fault domain", "x"),
+		"repeated":   valid.Where("ResourceId", "ocid2"),
+		"statistic":  NewMetricQuery("CpuUtilization", time.Minute),
+		"percentile": valid.Percentile(1),
+		"operator":   valid.Predicate("=<", 20),
+	} {
+		err := query.Validate()
+		if !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("%s: expected ErrInvalidQuery, got %v", name, err)
+		}
+	}
+
+	requireEqual(t, NewMetricQuery("X", 90*time.Second).String(), "X[1m30s]", "invalid interval")
+}
+
+func TestGuardrailQueryMatchesAlarms(t *testing.T) {
+	t.Parallel()
+
+	guardrail := GuardrailQuery("ocid1.instance.oc1..example")
+
+	requireNoError(t, guardrail.Validate(), "validate")
+
+	for query, want := range map[string]bool{
+		guardrail.String(): true,
+		"cpuutilization[1m]{resourceId=\"OCID1.instance.oc1..example\"}\n" +
+			".groupBy(resourceId).window(7d).percentile(0.95) < 20": true,
+		`CpuUtilization[1m]{resourceId="ocid1.instance.oc1..example"}.percentile(0.95) < 20`: false,
+		`CpuUtilization[1m]{resourceId="ocid1.instance.oc1..other"}` +
+			`.window(7d).percentile(0.95) < 20`: false,
+		`CpuUtilization[1m]{resourceId="ocid1.instance.oc1..example"}` +
+			`.window(7d).percentile(0.95) > 20`: false,
+		"": false,
+	} {
+		if guardrail.Matches(query) != want {
+			t.Fatalf("expected Matches(%q) to be %v", query, want)
+		}
+	}
+}
+
+// TestGuardrailQueryMatchesTerraformModule keeps the alarm the Terraform
+// module creates identical, up to whitespace, to the one the guardrail lookup
+// expects.
+func TestGuardrailQueryMatchesTerraformModule(t *testing.T) {
+	t.Parallel()
+
+	module, err := os.ReadFile("../../deploy/terraform/alarms/main.tf")
+	requireNoError(t, err, "read terraform module")
+
+	match := regexp.MustCompile(`alarm_query\s*=\s*"((?:[^"\\]|\\.)*)"`).FindSubmatch(module)
+	if match == nil {
+		t.Fatal("terraform module defines no alarm_query")
+	}
+
+	query := strings.ReplaceAll(string(match[1]), `\"`, `"`)
+	want := GuardrailQuery("${var.instance_ocid}").String()
+
+	if normalizeMQL(query) != normalizeMQL(want) {
+		t.Fatalf("terraform alarm_query %s drifted from oci.GuardrailQuery %s", query, want)
+	}
+}
+
+func TestClientAppliesQueryScope(t *testing.T) {
+	t.Parallel()
+
+	metrics := &skewedMetricsClient{serverTime: time.Now()}
+
+	client, err := newTestClient(metrics, "ocid1.compartment.oc1..example", nil)
+	requireNoError(t, err, "construct client")
+
+	// The recording client is not safe for concurrent queries.
+	client.queryConcurrency = 1
+
+	scope := QueryScope{
+		ResourceGroup: "shaper-fleet",
+		Dimensions: map[string]string{
+			"faultDomain":        "FAULT-DOMAIN-2",
+			"availabilityDomain": "Uocm:PHX-AD-1",
+		},
+	}
+
+	err = client.SetQueryScope(scope)
+	requireNoError(t, err, "set scope")
+
+	scope.Dimensions["faultDomain"] = "FAULT-DOMAIN-3"
+
+	_, err = client.QueryStep(t.Context(), "ocid1.instance", StepQuery{Network: true})
+	requireNoError(t, err, "query step")
+
+	if len(metrics.requests) != 3 {
+		t.Fatalf("expected cpu and network queries, got %d", len(metrics.requests))
+	}
+
+	queries := make(map[string]bool, len(metrics.requests))
+
+	for _, request := range metrics.requests {
+		details := request.SummarizeMetricsDataDetails
+		if details.ResourceGroup == nil || *details.ResourceGroup != "shaper-fleet" {
+			t.Fatalf("expected the resource group on every query, got %v", details.ResourceGroup)
+		}
+
+		queries[*details.Query] = true
+	}
+
+	const dimensions = `{resourceId = "ocid1.instance", ` +
+		`availabilityDomain = "Uocm:PHX-AD-1", faultDomain = "FAULT-DOMAIN-2"}`
+
+	for _, want := range []string{
+		"CpuUtilization[1m]" + dimensions + ".percentile(0.95)",
+		"NetworksBytesIn[1d]" + dimensions + ".sum()",
+		"NetworksBytesOut[1d]" + dimensions + ".sum()",
+	} {
+		if !queries[want] {
+			t.Fatalf("expected query %s, got %v", want, queries)
+		}
+	}
+}
+
+func TestValidateQueryScopeRejectsUnusableScopes(t *testing.T) {
+	t.Parallel()
+
+	for name, scope := range map[string]QueryScope{
+		"dimension name": {Dimensions: map[string]string{"fault domain": "x"}},
+		"resource id":    {Dimensions: map[string]string{"ResourceId": "ocid1.other"}},
+		"resource group": {ResourceGroup: "fleet a"},
+	} {
+		err := ValidateQueryScope(scope)
+		if !errors.Is(err, ErrInvalidQueryScope) {
+			t.Fatalf("%s: expected ErrInvalidQueryScope, got %v", name, err)
+		}
+	}
+
+	client, err := newTestClient(&skewedMetricsClient{serverTime: time.Now()}, "ocid1.c", nil)
+	requireNoError(t, err, "construct client")
+
+	err = client.SetQueryScope(QueryScope{ResourceGroup: "fleet a", Dimensions: nil})
+	if !errors.Is(err, ErrInvalidQueryScope) {
+		t.Fatalf("expected ErrInvalidQueryScope, got %v", err)
+	}
+
+	var unset *Client
+
+	err = unset.SetQueryScope(QueryScope{ResourceGroup: "", Dimensions: nil})
+	if !errors.Is(err, errNilClient) {
+		t.Fatalf("expected errNilClient, got %v", err)
+	}
+}
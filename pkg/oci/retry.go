@@ -0,0 +1,92 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// ErrRetriesExhausted wraps the last error once a RetryPolicy runs out of attempts.
+var ErrRetriesExhausted = errors.New("oci: retries exhausted")
+
+// RetryPolicy describes how a Monitoring call is retried after transient failures.
+type RetryPolicy struct {
+	// Retries is the number of additional attempts after the first one.
+	Retries int
+	// Backoff is the delay before the first retry; it doubles for each further retry.
+	Backoff time.Duration
+	// Logger, when set, is told about each retry.
+	Logger Logger
+}
+
+// Retry invokes call until it succeeds, returns a permanent error, or the policy runs out
+// of attempts. ErrNoMetricsData, missing arguments, and context errors are permanent
+// because repeating the request cannot change their outcome.
+func Retry[T any](
+	ctx context.Context,
+	policy RetryPolicy,
+	call func(ctx context.Context) (T, error),
+) (T, error) {
+	var zero T
+
+	delay := policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		result, err := call(ctx)
+		if err == nil {
+			return result, nil
+		}
+
+		if permanentError(ctx, err) {
+			return zero, err
+		}
+
+		if attempt >= policy.Retries {
+			if policy.Retries == 0 {
+				return zero, err
+			}
+
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrRetriesExhausted, attempt+1, err)
+		}
+
+		logging.WithStep(ctx, logging.OrNop(policy.Logger)).Debug(
+			"retrying oci call", "attempt", attempt+1, "backoff", delay, "error", err,
+		)
+
+		waitErr := waitRetry(ctx, delay)
+		if waitErr != nil {
+			return zero, fmt.Errorf("%w: %w", waitErr, err)
+		}
+
+		delay *= 2
+	}
+}
+
+func permanentError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	return errors.Is(err, ErrNoMetricsData) ||
+		errors.Is(err, errMissingInstanceOCID) ||
+		errors.Is(err, errNilClient)
+}
+
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("context done while waiting to retry: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetryRecoversFromTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	value, err := Retry(
+		t.Context(),
+		RetryPolicy{Retries: 2, Backoff: time.Millisecond},
+		func(context.Context) (float32, error) {
+			calls++
+			if calls < 3 {
+				return 0, errTransient
+			}
+
+			return 12.5, nil
+		},
+	)
+	if err != nil || value != 12.5 {
+		t.Fatalf("expected 12.5 after retries, got %v (%v)", value, err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetryStopsWhenExhausted(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	_, err := Retry(
+		t.Context(),
+		RetryPolicy{Retries: 1, Backoff: time.Millisecond},
+		func(context.Context) (float32, error) {
+			calls++
+
+			return 0, errTransient
+		},
+	)
+	if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, errTransient) {
+		t.Fatalf("expected exhausted retries wrapping the last error, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryDoesNotRepeatPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	_, err := Retry(
+		t.Context(),
+		RetryPolicy{Retries: 3, Backoff: time.Millisecond},
+		func(context.Context) (float32, error) {
+			calls++
+
+			return 0, ErrNoMetricsData
+		},
+	)
+	if !errors.Is(err, ErrNoMetricsData) || errors.Is(err, ErrRetriesExhausted) {
+		t.Fatalf("expected ErrNoMetricsData unchanged, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestRetryHonoursContextDuringBackoff(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := Retry(
+		ctx,
+		RetryPolicy{Retries: 5, Backoff: time.Hour},
+		func(context.Context) (float32, error) {
+			return 0, errTransient
+		},
+	)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errTransient) {
+		t.Fatalf("expected the backoff to stop at the deadline, got %v", err)
+	}
+}
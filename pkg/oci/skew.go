@@ -0,0 +1,102 @@
+package oci
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultSkewTolerance is the offset between the local clock and OCI Monitoring
+// that is tolerated before query windows are shifted. HTTP Date headers only
+// carry second precision and datapoints are aggregated per minute, so smaller
+// offsets are indistinguishable from noise.
+const DefaultSkewTolerance = 2 * time.Minute
+
+// ClockSkew returns the offset currently applied to query windows. Positive
+// values mean the local clock is behind OCI Monitoring.
+func (c *Client) ClockSkew() time.Duration {
+	if c == nil {
+		return 0
+	}
+
+	c.skewMu.Lock()
+	defer c.skewMu.Unlock()
+
+	return c.skew
+}
+
+// SetClockSkewHandler installs a callback invoked whenever the applied skew
+// changes by more than DefaultSkewTolerance, including when it returns to zero.
+func (c *Client) SetClockSkewHandler(handler func(skew time.Duration)) {
+	if c == nil {
+		return
+	}
+
+	c.skewMu.Lock()
+	c.skewHandler = handler
+	c.skewMu.Unlock()
+}
+
+// skewedNow returns the local time corrected by the applied skew so query
+// windows line up with the timestamps Monitoring stores.
+func (c *Client) skewedNow() time.Time {
+	return c.now().UTC().Add(c.ClockSkew())
+}
+
+// observeServerTime estimates the skew from the response Date header, falling
+// back to the newest datapoint when the header is unavailable: a datapoint
+// stamped in the local future proves the local clock is behind by at least
+// that much.
+func (c *Client) observeServerTime(
+	response *http.Response,
+	receivedAt time.Time,
+	latestDatapoint time.Time,
+) {
+	var observed time.Duration
+
+	switch {
+	case response != nil && response.Header.Get("Date") != "":
+		serverTime, err := http.ParseTime(response.Header.Get("Date"))
+		if err != nil {
+			c.log().Debug("ignoring unparsable monitoring date header", "error", err)
+
+			return
+		}
+
+		observed = serverTime.Sub(receivedAt)
+	case !latestDatapoint.IsZero() && latestDatapoint.After(receivedAt):
+		observed = latestDatapoint.Sub(receivedAt)
+	default:
+		return
+	}
+
+	c.updateSkew(observed)
+}
+
+func (c *Client) updateSkew(observed time.Duration) {
+	applied := observed
+	if absDuration(observed) <= DefaultSkewTolerance {
+		applied = 0
+	}
+
+	c.skewMu.Lock()
+
+	previous := c.skew
+	c.skew = applied
+	handler := c.skewHandler
+
+	c.skewMu.Unlock()
+
+	significant := absDuration(applied-previous) > DefaultSkewTolerance ||
+		(applied == 0) != (previous == 0)
+	if handler != nil && significant {
+		handler(applied)
+	}
+}
+
+func absDuration(value time.Duration) time.Duration {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,126 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/monitoring"
+)
+
+type skewedMetricsClient struct {
+	serverTime time.Time
+	withHeader bool
+	requests   []monitoring.SummarizeMetricsDataRequest
+}
+
+func (s *skewedMetricsClient) SummarizeMetricsData(
+	_ context.Context,
+	request monitoring.SummarizeMetricsDataRequest,
+	_ *string,
+) (monitoring.SummarizeMetricsDataResponse, *string, error) {
+	s.requests = append(s.requests, request)
+
+	var response monitoring.SummarizeMetricsDataResponse
+
+	if s.withHeader {
+		response.RawResponse = &http.Response{Header: http.Header{}}
+		response.RawResponse.Header.Set("Date", s.serverTime.Format(http.TimeFormat))
+	}
+
+	value := 12.5
+	response.Items = []monitoring.MetricData{{
+		AggregatedDatapoints: []monitoring.AggregatedDatapoint{{
+			Timestamp: &common.SDKTime{Time: s.serverTime.Add(-time.Minute)},
+			Value:     &value,
+		}},
+	}}
+
+	return response, nil, nil
+}
+
+func TestQueryP95CPUShiftsWindowBySkew(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		withHeader bool
+	}{
+		{name: "date header", withHeader: true},
+		{name: "future datapoint", withHeader: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			local := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			metrics := &skewedMetricsClient{
+				serverTime: local.Add(time.Hour),
+				withHeader: testCase.withHeader,
+			}
+
+			client, err := newClient(metrics, "ocid1.compartment.oc1..skew", func() time.Time {
+				return local
+			})
+			requireNoError(t, err, "construct client")
+
+			var reported []time.Duration
+
+			client.SetClockSkewHandler(func(skew time.Duration) {
+				reported = append(reported, skew)
+			})
+
+			_, err = client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..skew", true)
+			requireNoError(t, err, "first query")
+
+			if skew := client.ClockSkew(); skew < 58*time.Minute || skew > time.Hour {
+				t.Fatalf("expected roughly one hour of skew, got %s", skew)
+			}
+
+			if len(reported) != 1 {
+				t.Fatalf("expected a single skew report, got %v", reported)
+			}
+
+			_, err = client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..skew", true)
+			requireNoError(t, err, "second query")
+
+			end := metrics.requests[1].EndTime.Time
+			if end.Before(local.Add(58 * time.Minute)) {
+				t.Fatalf("expected the query window to follow Monitoring time, got end %s", end)
+			}
+
+			if len(reported) != 1 {
+				t.Fatalf("expected stable skew not to be re-reported, got %v", reported)
+			}
+		})
+	}
+}
+
+func TestUpdateSkewIgnoresSmallOffsetsAndReportsRecovery(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient(new(skewedMetricsClient), "ocid1.compartment.oc1..skew", nil)
+	requireNoError(t, err, "construct client")
+
+	var reported []time.Duration
+
+	client.SetClockSkewHandler(func(skew time.Duration) {
+		reported = append(reported, skew)
+	})
+
+	client.updateSkew(30 * time.Second)
+	requireEqual(t, client.ClockSkew(), time.Duration(0), "small skew")
+
+	client.updateSkew(-10 * time.Minute)
+	requireEqual(t, client.ClockSkew(), -10*time.Minute, "large skew")
+
+	client.updateSkew(time.Second)
+	requireEqual(t, client.ClockSkew(), time.Duration(0), "recovered skew")
+
+	if len(reported) != 2 || reported[0] != -10*time.Minute || reported[1] != 0 {
+		t.Fatalf("expected skew and recovery reports, got %v", reported)
+	}
+}
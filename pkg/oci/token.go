@@ -0,0 +1,123 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// tokenExpiryClaim is the JWT claim carrying the expiry of the instance
+// principal security token as Unix seconds.
+const tokenExpiryClaim = "exp"
+
+// claimHolder matches the SDK's auth.ClaimHolder, which instance principal
+// providers implement to expose the claims of their security token.
+type claimHolder interface {
+	GetClaim(key string) (interface{}, error)
+}
+
+// TokenExpiry returns the expiry of the instance principal security token as
+// of the latest query, or the zero time when it is unknown, for example for a
+// client built from an OCI CLI config file.
+func (c *Client) TokenExpiry() time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return c.tokenExpiry
+}
+
+// SetTokenExpiryHandler installs a callback invoked with the expiry of the
+// instance principal security token whenever it changes: on the first query
+// and after every renewal.
+func (c *Client) SetTokenExpiryHandler(handler func(expiry time.Time)) {
+	if c == nil {
+		return
+	}
+
+	c.tokenMu.Lock()
+	c.tokenHandler = handler
+	c.tokenMu.Unlock()
+}
+
+// refreshToken renews the instance principal security token ahead of a query.
+// The SDK renews the token on KeyID once it is within its expiry buffer, so
+// asking for the key here moves the renewal, and any failure of it, in front
+// of the query instead of into the signing of one of its pages. A changed
+// expiry is reported to the handler.
+func (c *Client) refreshToken() error {
+	if c.token == nil {
+		return nil
+	}
+
+	_, err := c.token.KeyID()
+	if err != nil {
+		return fmt.Errorf("refresh instance principal token: %w", err)
+	}
+
+	expiry, ok := tokenExpiry(c.token)
+	if !ok {
+		return nil
+	}
+
+	c.tokenMu.Lock()
+
+	previous := c.tokenExpiry
+	c.tokenExpiry = expiry
+	handler := c.tokenHandler
+
+	c.tokenMu.Unlock()
+
+	if expiry.Equal(previous) {
+		return nil
+	}
+
+	c.log().Debug("instance principal token renewed",
+		"expiry", expiry, "remaining", expiry.Sub(c.now()))
+
+	if handler != nil {
+		handler(expiry)
+	}
+
+	return nil
+}
+
+// tokenExpiry reads the expiry claim of the security token behind provider.
+func tokenExpiry(provider common.KeyProvider) (time.Time, bool) {
+	holder, ok := provider.(claimHolder)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	claim, err := holder.GetClaim(tokenExpiryClaim)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var seconds int64
+
+	switch value := claim.(type) {
+	case float64:
+		seconds = int64(value)
+	case int64:
+		seconds = value
+	case json.Number:
+		seconds, err = value.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if seconds <= 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
@@ -0,0 +1,151 @@
+package oci //nolint:testpackage
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTokenClaim = errors.New("claim unavailable")
+
+type tokenProvider struct {
+	expiries []interface{}
+	keyErr   error
+	keyCalls int
+}
+
+func (p *tokenProvider) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	return nil, nil //nolint:nilnil // the key is never used
+}
+
+func (p *tokenProvider) KeyID() (string, error) {
+	p.keyCalls++
+
+	return "ST$token", p.keyErr
+}
+
+func (p *tokenProvider) GetClaim(key string) (interface{}, error) {
+	if key != tokenExpiryClaim || len(p.expiries) == 0 {
+		return nil, errTokenClaim
+	}
+
+	expiry := p.expiries[0]
+	if len(p.expiries) > 1 {
+		p.expiries = p.expiries[1:]
+	}
+
+	return expiry, nil
+}
+
+func TestClientRenewsTokenBeforeQueries(t *testing.T) {
+	t.Parallel()
+
+	local := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := local.Add(20 * time.Minute)
+	renewed := local.Add(time.Hour)
+
+	provider := &tokenProvider{
+		expiries: []interface{}{
+			float64(first.Unix()),
+			float64(first.Unix()),
+			json.Number("1714568400"),
+		},
+	}
+
+	client, err := newTestClient(
+		&skewedMetricsClient{serverTime: local},
+		"ocid1.compartment.oc1..example",
+		func() time.Time { return local },
+	)
+	requireNoError(t, err, "construct client")
+
+	client.token = provider
+
+	var reported []time.Time
+
+	client.SetTokenExpiryHandler(func(expiry time.Time) {
+		reported = append(reported, expiry)
+	})
+
+	for range 3 {
+		_, err = client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..example", false)
+		requireNoError(t, err, "query")
+	}
+
+	if provider.keyCalls != 3 {
+		t.Fatalf("expected the token to be checked before every query, got %d", provider.keyCalls)
+	}
+
+	if len(reported) != 2 || !reported[0].Equal(first) || !reported[1].Equal(renewed) {
+		t.Fatalf("expected the first expiry and its renewal to be reported, got %v", reported)
+	}
+
+	if !client.TokenExpiry().Equal(renewed) {
+		t.Fatalf("expected the renewed expiry, got %v", client.TokenExpiry())
+	}
+}
+
+func TestClientFailsQueriesWhenTokenRenewalFails(t *testing.T) {
+	t.Parallel()
+
+	metrics := &skewedMetricsClient{serverTime: time.Now()}
+
+	client, err := newTestClient(metrics, "ocid1.compartment.oc1..example", nil)
+	requireNoError(t, err, "construct client")
+
+	client.token = &tokenProvider{keyErr: errForcedFailure}
+
+	_, err = client.QueryStep(t.Context(), "ocid1.instance.oc1..example", StepQuery{Network: false})
+	if !errors.Is(err, errForcedFailure) {
+		t.Fatalf("expected the renewal error, got %v", err)
+	}
+
+	_, err = client.QueryNetworkBytes7d(t.Context(), "ocid1.instance.oc1..example")
+	if !errors.Is(err, errForcedFailure) {
+		t.Fatalf("expected the renewal error, got %v", err)
+	}
+
+	if len(metrics.requests) != 0 {
+		t.Fatalf("expected no query with a lapsing token, got %d", len(metrics.requests))
+	}
+}
+
+func TestTokenExpiryIgnoresUnusableClaims(t *testing.T) {
+	t.Parallel()
+
+	for name, claim := range map[string]interface{}{
+		"string":     "soon",
+		"zero":       float64(0),
+		"bad number": json.Number("soon"),
+	} {
+		_, ok := tokenExpiry(&tokenProvider{expiries: []interface{}{claim}})
+		if ok {
+			t.Fatalf("%s: expected no expiry", name)
+		}
+	}
+
+	_, ok := tokenExpiry(new(tokenProvider))
+	if ok {
+		t.Fatal("expected no expiry without the claim")
+	}
+
+	_, ok = tokenExpiry(stubConfigurationProvider(t))
+	if ok {
+		t.Fatal("expected no expiry from a provider without claims")
+	}
+
+	expiry, ok := tokenExpiry(&tokenProvider{expiries: []interface{}{int64(1714568400)}})
+	if !ok || expiry.Unix() != 1714568400 {
+		t.Fatalf("expected an int64 claim to be read, got %v", expiry)
+	}
+
+	var client *Client
+
+	client.SetTokenExpiryHandler(func(time.Time) {})
+
+	if !client.TokenExpiry().IsZero() {
+		t.Fatal("expected no expiry from a nil client")
+	}
+}
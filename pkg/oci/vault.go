@@ -0,0 +1,98 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/secrets"
+)
+
+var (
+	errMissingSecretsClient = errors.New("oci: secrets client is required")
+	errNilSecretsClient     = errors.New("oci: secrets client receiver is nil")
+	errMissingSecretID      = errors.New("oci: secret OCID is required")
+	errUnsupportedSecret    = errors.New("oci: secret bundle is not base64 content")
+)
+
+type secretBundleGetter interface {
+	GetSecretBundle(
+		ctx context.Context,
+		request secrets.GetSecretBundleRequest,
+	) (secrets.GetSecretBundleResponse, error)
+}
+
+// VaultClient reads secret material from OCI Vault.
+type VaultClient struct {
+	secrets secretBundleGetter
+}
+
+// NewInstancePrincipalVaultClient constructs a VaultClient authenticated with the
+// instance principal. The region pins the Secrets endpoint when it is non-empty.
+func NewInstancePrincipalVaultClient(region string) (*VaultClient, error) {
+	instancePrincipalProviderMu.RLock()
+
+	providerFn := instancePrincipalProviderFn
+
+	instancePrincipalProviderMu.RUnlock()
+
+	provider, err := providerFn()
+	if err != nil {
+		return nil, fmt.Errorf("build instance principal provider: %w", err)
+	}
+
+	secretsClient, err := secrets.NewSecretsClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("create secrets client: %w", err)
+	}
+
+	trimmedRegion := strings.TrimSpace(region)
+	if trimmedRegion != "" {
+		secretsClient.SetRegion(trimmedRegion)
+	}
+
+	return newVaultClient(secretsClient)
+}
+
+func newVaultClient(getter secretBundleGetter) (*VaultClient, error) {
+	if getter == nil {
+		return nil, errMissingSecretsClient
+	}
+
+	return &VaultClient{secrets: getter}, nil
+}
+
+// SecretContent returns the decoded content of the current version of the secret.
+func (c *VaultClient) SecretContent(ctx context.Context, secretOCID string) ([]byte, error) {
+	if c == nil || c.secrets == nil {
+		return nil, errNilSecretsClient
+	}
+
+	trimmed := strings.TrimSpace(secretOCID)
+	if trimmed == "" {
+		return nil, errMissingSecretID
+	}
+
+	var request secrets.GetSecretBundleRequest
+
+	request.SecretId = &trimmed
+
+	response, err := c.secrets.GetSecretBundle(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("get secret bundle: %w", err)
+	}
+
+	content, ok := response.SecretBundleContent.(secrets.Base64SecretBundleContentDetails)
+	if !ok || content.Content == nil {
+		return nil, errUnsupportedSecret
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(*content.Content)
+	if err != nil {
+		return nil, fmt.Errorf("decode secret content: %w", err)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,106 @@
+package oci //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/secrets"
+)
+
+var errStubVaultDenied = errors.New("stub: vault denied")
+
+type stubSecretGetter struct {
+	content   secrets.SecretBundleContentDetails
+	err       error
+	requested string
+}
+
+func (s *stubSecretGetter) GetSecretBundle(
+	_ context.Context,
+	request secrets.GetSecretBundleRequest,
+) (secrets.GetSecretBundleResponse, error) {
+	if request.SecretId != nil {
+		s.requested = *request.SecretId
+	}
+
+	var response secrets.GetSecretBundleResponse
+
+	response.SecretBundleContent = s.content
+
+	return response, s.err
+}
+
+func TestVaultSecretContentDecodesBase64(t *testing.T) {
+	t.Parallel()
+
+	encoded := "c2VjcmV0LWtleQ=="
+	getter := &stubSecretGetter{
+		content: secrets.Base64SecretBundleContentDetails{Content: &encoded},
+	}
+
+	client, err := newVaultClient(getter)
+	requireNoError(t, err, "construct vault client")
+
+	got, err := client.SecretContent(t.Context(), " ocid1.vaultsecret.oc1..example ")
+	requireNoError(t, err, "read secret")
+	requireEqual(t, string(got), "secret-key", "secret content")
+	requireEqual(t, getter.requested, "ocid1.vaultsecret.oc1..example", "secret OCID")
+}
+
+func TestVaultSecretContentHandlesFailures(t *testing.T) {
+	t.Parallel()
+
+	invalid := "not base64!"
+
+	testCases := []struct {
+		name   string
+		getter *stubSecretGetter
+		ocid   string
+		want   error
+	}{
+		{name: "missing ocid", getter: new(stubSecretGetter), ocid: " ", want: errMissingSecretID},
+		{
+			name:   "api error",
+			getter: &stubSecretGetter{err: errStubVaultDenied},
+			ocid:   "ocid1.vaultsecret.oc1..example",
+			want:   errStubVaultDenied,
+		},
+		{
+			name:   "missing content",
+			getter: new(stubSecretGetter),
+			ocid:   "ocid1.vaultsecret.oc1..example",
+			want:   errUnsupportedSecret,
+		},
+		{
+			name: "invalid base64",
+			getter: &stubSecretGetter{
+				content: secrets.Base64SecretBundleContentDetails{Content: &invalid},
+			},
+			ocid: "ocid1.vaultsecret.oc1..example",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, err := newVaultClient(testCase.getter)
+			requireNoError(t, err, "construct vault client")
+
+			_, err = client.SecretContent(t.Context(), testCase.ocid)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if testCase.want != nil && !errors.Is(err, testCase.want) {
+				t.Fatalf("expected %v, got %v", testCase.want, err)
+			}
+		})
+	}
+
+	_, err := newVaultClient(nil)
+	if !errors.Is(err, errMissingSecretsClient) {
+		t.Fatalf("expected errMissingSecretsClient, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package oci
+
+import (
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/monitoring"
+)
+
+// QueryWindow is the time range a Monitoring query covered.
+type QueryWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Length returns how much history the window spans. One-minute queries are
+// truncated to seven days, the same period OCI's window(7d) alarm evaluates.
+func (w QueryWindow) Length() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// LastQueryWindow returns the window of the latest Monitoring query, or the
+// zero window before the first one.
+func (c *Client) LastQueryWindow() QueryWindow {
+	if c == nil {
+		return QueryWindow{}
+	}
+
+	c.windowMu.Lock()
+	defer c.windowMu.Unlock()
+
+	return c.window
+}
+
+// SetQueryWindowHandler installs a callback invoked with the window of every
+// Monitoring query, or batch of queries sharing one window, before it is
+// issued.
+func (c *Client) SetQueryWindowHandler(handler func(window QueryWindow)) {
+	if c == nil {
+		return
+	}
+
+	c.windowMu.Lock()
+	c.windowHandler = handler
+	c.windowMu.Unlock()
+}
+
+// recordWindow remembers the window about to be queried and reports it to the
+// handler.
+func (c *Client) recordWindow(start, end time.Time) {
+	window := QueryWindow{Start: start, End: end}
+
+	c.windowMu.Lock()
+	c.window = window
+	handler := c.windowHandler
+	c.windowMu.Unlock()
+
+	if handler != nil {
+		handler(window)
+	}
+}
+
+// requestWindow returns the window request covers.
+func requestWindow(request monitoring.SummarizeMetricsDataRequest) QueryWindow {
+	var window QueryWindow
+
+	details := request.SummarizeMetricsDataDetails
+	if details.StartTime != nil {
+		window.Start = details.StartTime.Time
+	}
+
+	if details.EndTime != nil {
+		window.End = details.EndTime.Time
+	}
+
+	return window
+}
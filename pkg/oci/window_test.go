@@ -0,0 +1,82 @@
+package oci //nolint:testpackage
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientReportsQueryWindows(t *testing.T) {
+	t.Parallel()
+
+	local := time.Date(2024, 5, 8, 12, 0, 0, 500, time.UTC)
+	end := local.Truncate(time.Second)
+
+	client, err := newTestClient(
+		&skewedMetricsClient{serverTime: local},
+		"ocid1.compartment.oc1..example",
+		func() time.Time { return local },
+	)
+	requireNoError(t, err, "construct client")
+
+	if client.LastQueryWindow() != (QueryWindow{}) {
+		t.Fatalf("expected no window before the first query, got %+v", client.LastQueryWindow())
+	}
+
+	var (
+		buffer   bytes.Buffer
+		reported []QueryWindow
+	)
+
+	client.SetLogger(slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       slog.LevelDebug,
+		ReplaceAttr: nil,
+	})))
+	client.SetQueryWindowHandler(func(window QueryWindow) {
+		reported = append(reported, window)
+	})
+
+	_, err = client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..example", false)
+	requireNoError(t, err, "query p95")
+
+	_, err = client.QueryNetworkBytes7d(t.Context(), "ocid1.instance.oc1..example")
+	requireNoError(t, err, "query network")
+
+	_, err = client.QueryStep(t.Context(), "ocid1.instance.oc1..example", StepQuery{Network: false})
+	requireNoError(t, err, "query step")
+
+	week := QueryWindow{Start: end.Add(-7 * 24 * time.Hour), End: end}
+	want := []QueryWindow{{Start: end.Add(-24 * time.Hour), End: end}, week, week}
+
+	if len(reported) != len(want) {
+		t.Fatalf("expected one window per query, got %+v", reported)
+	}
+
+	for index := range want {
+		if !reported[index].Start.Equal(want[index].Start) ||
+			!reported[index].End.Equal(want[index].End) {
+			t.Fatalf("window %d: expected %+v, got %+v", index, want[index], reported[index])
+		}
+	}
+
+	last := client.LastQueryWindow()
+	if last.Length() != 7*24*time.Hour || !last.End.Equal(end) {
+		t.Fatalf("expected the trailing seven days, got %+v", last)
+	}
+
+	logged := "start=2024-05-01T12:00:00.000Z end=2024-05-08T12:00:00.000Z"
+	if !strings.Contains(buffer.String(), logged) {
+		t.Fatalf("expected the window logged with each query, got %s", buffer.String())
+	}
+
+	var unset *Client
+
+	unset.SetQueryWindowHandler(func(QueryWindow) {})
+
+	if unset.LastQueryWindow() != (QueryWindow{}) {
+		t.Fatal("expected a nil client to report no window")
+	}
+}
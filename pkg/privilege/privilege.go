@@ -0,0 +1,124 @@
+// Package privilege switches the daemon from root to an unprivileged user once
+// the setup that needs root, such as binding listeners, lowering worker
+// scheduling priority, and adjusting the cgroup, is done, so the long-running
+// process that serves network listeners does not keep running as root.
+package privilege
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownUser reports a user that neither names an account nor is a
+	// numeric ID usable without one.
+	ErrUnknownUser = errors.New("privilege: unknown user")
+	// ErrUnknownGroup reports a group that is neither a group name nor a
+	// numeric ID.
+	ErrUnknownGroup = errors.New("privilege: unknown group")
+	// ErrNotRoot reports a process that cannot switch to another user because
+	// it does not run as root.
+	ErrNotRoot = errors.New("privilege: switching users requires root")
+	// ErrNotDropped reports a process that could still regain root after the
+	// switch.
+	ErrNotDropped = errors.New("privilege: root privileges were not dropped")
+	// ErrUnsupported is returned on platforms where the daemon cannot switch
+	// users.
+	ErrUnsupported = errors.New("privilege: switching users is not supported on this platform")
+)
+
+// Identity is the user and group the process switches to. The group also
+// becomes the only supplementary group.
+type Identity struct {
+	UID int
+	GID int
+}
+
+// Resolve looks up userName and groupName, each a name or a numeric ID. An
+// empty groupName selects the user's primary group, which a numeric user
+// without an account, as in distroless images, does not have.
+func Resolve(userName, groupName string) (Identity, error) {
+	userName = strings.TrimSpace(userName)
+	groupName = strings.TrimSpace(groupName)
+
+	uid, primary, err := lookupUser(userName)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	gid := primary
+
+	if groupName != "" {
+		gid, err = lookupGroup(groupName)
+		if err != nil {
+			return Identity{}, err
+		}
+	}
+
+	if gid < 0 {
+		return Identity{}, fmt.Errorf("%w: %s has no account to take a primary group from; "+
+			"set the group", ErrUnknownUser, userName)
+	}
+
+	return Identity{UID: uid, GID: gid}, nil
+}
+
+// lookupUser returns the user's ID and primary group, or a primary group of -1
+// for a numeric ID without an account.
+func lookupUser(name string) (int, int, error) {
+	account, err := user.Lookup(name)
+	if err != nil {
+		account, err = user.LookupId(name)
+	}
+
+	if err != nil {
+		uid, parseErr := parseID(name)
+		if parseErr != nil {
+			return 0, 0, fmt.Errorf("%w: %q: %w", ErrUnknownUser, name, err)
+		}
+
+		return uid, -1, nil
+	}
+
+	uid, uidErr := parseID(account.Uid)
+	gid, gidErr := parseID(account.Gid)
+
+	if uidErr != nil || gidErr != nil {
+		return 0, 0, fmt.Errorf("%w: %q has uid %q and gid %q",
+			ErrUnknownUser, name, account.Uid, account.Gid)
+	}
+
+	return uid, gid, nil
+}
+
+func lookupGroup(name string) (int, error) {
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		gid, parseErr := parseID(name)
+		if parseErr != nil {
+			return 0, fmt.Errorf("%w: %q: %w", ErrUnknownGroup, name, err)
+		}
+
+		return gid, nil
+	}
+
+	gid, err := parseID(group.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q has gid %q", ErrUnknownGroup, name, group.Gid)
+	}
+
+	return gid, nil
+}
+
+// parseID parses a non-negative user or group ID.
+func parseID(value string) (int, error) {
+	id, err := strconv.ParseUint(value, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("parse id %q: %w", value, err)
+	}
+
+	return int(id), nil
+}
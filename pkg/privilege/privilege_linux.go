@@ -0,0 +1,64 @@
+//go:build linux
+
+package privilege
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// credentials wraps the calls Drop makes so tests can run it without root.
+type credentials struct {
+	geteuid   func() int
+	getegid   func() int
+	setgroups func(gids []int) error
+	setgid    func(gid int) error
+	setuid    func(uid int) error
+}
+
+// Drop switches every thread of the process to identity: the supplementary
+// groups, then the group, then the user, since changing the user first would
+// take away the right to change the rest. Threads keep the scheduling policy
+// and nice value they already have. A process that already runs as identity
+// is left alone, and any other process that is not root gets ErrNotRoot.
+func Drop(identity Identity) error {
+	return drop(identity, credentials{
+		geteuid:   syscall.Geteuid,
+		getegid:   syscall.Getegid,
+		setgroups: syscall.Setgroups,
+		setgid:    syscall.Setgid,
+		setuid:    syscall.Setuid,
+	})
+}
+
+func drop(identity Identity, calls credentials) error {
+	euid := calls.geteuid()
+	if euid == identity.UID && calls.getegid() == identity.GID {
+		return nil
+	}
+
+	if euid != 0 {
+		return fmt.Errorf("%w: running as uid %d", ErrNotRoot, euid)
+	}
+
+	err := calls.setgroups([]int{identity.GID})
+	if err != nil {
+		return fmt.Errorf("setgroups(%d): %w", identity.GID, err)
+	}
+
+	err = calls.setgid(identity.GID)
+	if err != nil {
+		return fmt.Errorf("setgid(%d): %w", identity.GID, err)
+	}
+
+	err = calls.setuid(identity.UID)
+	if err != nil {
+		return fmt.Errorf("setuid(%d): %w", identity.UID, err)
+	}
+
+	if identity.UID != 0 && calls.setuid(0) == nil {
+		return fmt.Errorf("%w: setuid(0) still succeeds", ErrNotDropped)
+	}
+
+	return nil
+}
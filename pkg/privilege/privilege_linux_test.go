@@ -0,0 +1,126 @@
+//go:build linux
+
+//nolint:testpackage // tests replace the credential calls
+package privilege
+
+import (
+	"errors"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+var errTestDenied = errors.New("denied")
+
+// fakeCredentials records the calls made by drop against a simulated process.
+type fakeCredentials struct {
+	euid, egid int
+	calls      []string
+	fail       string
+	// keepRoot lets setuid(0) succeed after the switch, as if the saved user
+	// ID were still root.
+	keepRoot bool
+}
+
+func (f *fakeCredentials) credentials() credentials {
+	return credentials{
+		geteuid: func() int { return f.euid },
+		getegid: func() int { return f.egid },
+		setgroups: func([]int) error {
+			return f.record("setgroups")
+		},
+		setgid: func(gid int) error {
+			err := f.record("setgid")
+			if err == nil {
+				f.egid = gid
+			}
+
+			return err
+		},
+		setuid: func(uid int) error {
+			if uid == 0 && f.euid != 0 && !f.keepRoot {
+				return syscall.EPERM
+			}
+
+			err := f.record("setuid")
+			if err == nil {
+				f.euid = uid
+			}
+
+			return err
+		},
+	}
+}
+
+func (f *fakeCredentials) record(call string) error {
+	f.calls = append(f.calls, call)
+	if call == f.fail {
+		return errTestDenied
+	}
+
+	return nil
+}
+
+func TestDropSwitchesGroupsBeforeUser(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeCredentials{euid: 0, egid: 0, calls: nil, fail: "", keepRoot: false}
+
+	err := drop(Identity{UID: 65532, GID: 65532}, fake.credentials())
+	if err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+
+	if !slices.Equal(fake.calls, []string{"setgroups", "setgid", "setuid"}) {
+		t.Fatalf("expected groups, group, then user, got %v", fake.calls)
+	}
+
+	if fake.euid != 65532 || fake.egid != 65532 {
+		t.Fatalf("expected uid and gid 65532, got %d and %d", fake.euid, fake.egid)
+	}
+}
+
+func TestDropSkipsProcessAlreadyRunningAsIdentity(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeCredentials{euid: 1000, egid: 1000, calls: nil, fail: "", keepRoot: false}
+
+	err := drop(Identity{UID: 1000, GID: 1000}, fake.credentials())
+	if err != nil || len(fake.calls) != 0 {
+		t.Fatalf("expected no calls, got %v (err %v)", fake.calls, err)
+	}
+
+	err = drop(Identity{UID: 65532, GID: 65532}, fake.credentials())
+	if !errors.Is(err, ErrNotRoot) {
+		t.Fatalf("expected ErrNotRoot, got %v", err)
+	}
+}
+
+func TestDropReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	for _, call := range []string{"setgroups", "setgid", "setuid"} {
+		fake := &fakeCredentials{euid: 0, egid: 0, calls: nil, fail: call, keepRoot: false}
+
+		err := drop(Identity{UID: 65532, GID: 65532}, fake.credentials())
+		if !errors.Is(err, errTestDenied) {
+			t.Fatalf("expected %s to fail the drop, got %v", call, err)
+		}
+	}
+
+	fake := &fakeCredentials{euid: 0, egid: 0, calls: nil, fail: "", keepRoot: true}
+
+	err := drop(Identity{UID: 65532, GID: 65532}, fake.credentials())
+	if !errors.Is(err, ErrNotDropped) {
+		t.Fatalf("expected ErrNotDropped, got %v", err)
+	}
+}
+
+func TestDropLeavesCurrentIdentityAlone(t *testing.T) {
+	t.Parallel()
+
+	err := Drop(Identity{UID: syscall.Geteuid(), GID: syscall.Getegid()})
+	if err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+}
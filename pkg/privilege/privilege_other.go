@@ -0,0 +1,8 @@
+//go:build !linux
+
+package privilege
+
+// Drop is unavailable outside linux.
+func Drop(Identity) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,80 @@
+package privilege_test
+
+import (
+	"errors"
+	"testing"
+
+	"oci-cpu-shaper/pkg/privilege"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		user, group string
+		want        privilege.Identity
+	}{
+		"name":            {user: "root", group: "", want: privilege.Identity{UID: 0, GID: 0}},
+		"numeric account": {user: " 0 ", group: "", want: privilege.Identity{UID: 0, GID: 0}},
+		"group name":      {user: "root", group: "root", want: privilege.Identity{UID: 0, GID: 0}},
+		"numeric group": {
+			user:  "root",
+			group: "4242424",
+			want:  privilege.Identity{UID: 0, GID: 4242424},
+		},
+		"numeric without account": {
+			user:  "4242424",
+			group: "4242425",
+			want:  privilege.Identity{UID: 4242424, GID: 4242425},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			identity, err := privilege.Resolve(test.user, test.group)
+			if err != nil {
+				t.Fatalf("Resolve(%q, %q): %v", test.user, test.group, err)
+			}
+
+			if identity != test.want {
+				t.Fatalf("expected %+v, got %+v", test.want, identity)
+			}
+		})
+	}
+}
+
+func TestResolveRejectsUnknownIdentities(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		user, group string
+		want        error
+	}{
+		"unknown user": {user: "no-such-shaper-user", group: "", want: privilege.ErrUnknownUser},
+		"empty user":   {user: " ", group: "root", want: privilege.ErrUnknownUser},
+		"numeric without group": {
+			user:  "4242424",
+			group: "",
+			want:  privilege.ErrUnknownUser,
+		},
+		"unknown group": {
+			user:  "root",
+			group: "no-such-shaper-group",
+			want:  privilege.ErrUnknownGroup,
+		},
+		"negative group": {user: "root", group: "-1", want: privilege.ErrUnknownGroup},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := privilege.Resolve(test.user, test.group)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+		})
+	}
+}
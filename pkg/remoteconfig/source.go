@@ -0,0 +1,348 @@
+// Package remoteconfig mirrors a configuration document served over https or
+// stored in OCI Object Storage into a local cache file, so fleets can share one
+// centrally managed configuration and restarts survive an unreachable remote.
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+const (
+	// HTTPSScheme prefixes configuration documents fetched over https.
+	HTTPSScheme = "https://"
+	// ObjectScheme prefixes os://<namespace>/<bucket>/<object> locations.
+	ObjectScheme = "os://"
+
+	// DefaultCachePath holds the last fetched configuration; its ETag is kept
+	// alongside with an .etag suffix.
+	DefaultCachePath = "/var/lib/oci-cpu-shaper/remote-config.yaml"
+	// DefaultRefreshInterval spaces re-fetches of the remote document.
+	DefaultRefreshInterval = 5 * time.Minute
+	// FetchTimeout bounds each fetch of the remote document.
+	FetchTimeout = 30 * time.Second
+
+	maxConfigBytes = 1 << 20
+)
+
+var (
+	// ErrInvalidURL reports a location that is neither https nor a complete
+	// Object Storage reference.
+	ErrInvalidURL = errors.New(
+		"remote configuration must be os://<namespace>/<bucket>/<object> or https://",
+	)
+	// ErrCacheUnset reports a remote location without a cache path.
+	ErrCacheUnset = errors.New("remote configuration requires --config-cache")
+
+	errNotModified = errors.New("remote configuration not modified")
+	errStatus      = errors.New("unexpected remote configuration status")
+	errTooLarge    = errors.New("remote configuration exceeds 1 MiB")
+)
+
+// ObjectReader reads an Object Storage object, honouring a previously seen
+// ETag. oci.ObjectStorageClient implements it.
+type ObjectReader interface {
+	GetObject(ctx context.Context, ref oci.ObjectRef, etag string) ([]byte, string, error)
+}
+
+// Validator reports whether the configuration document at path would load.
+type Validator func(path string) error
+
+// IsRemote reports whether location names a remote configuration document.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, HTTPSScheme) || strings.HasPrefix(location, ObjectScheme)
+}
+
+// IsObjectStorage reports whether location names an Object Storage object.
+func IsObjectStorage(location string) bool {
+	return strings.HasPrefix(location, ObjectScheme)
+}
+
+// ParseObjectURL splits an os://<namespace>/<bucket>/<object> location.
+func ParseObjectURL(location string) (oci.ObjectRef, error) {
+	parts := strings.SplitN(strings.TrimPrefix(location, ObjectScheme), "/", 3)
+	if !IsObjectStorage(location) || len(parts) != 3 ||
+		parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return oci.ObjectRef{}, fmt.Errorf("%w: %q", ErrInvalidURL, location)
+	}
+
+	return oci.ObjectRef{Namespace: parts[0], Bucket: parts[1], Name: parts[2]}, nil
+}
+
+// Source mirrors one remote configuration document into a cache file so the
+// usual file loader can read it, and so a restart can fall back to the last
+// good copy when the remote is unreachable.
+type Source struct {
+	location  string
+	cachePath string
+	fetch     func(ctx context.Context, etag string) ([]byte, string, error)
+	etag      string
+	// staleErr records why Sync kept the cached copy instead of a fresh one.
+	staleErr error
+
+	loggerMu sync.RWMutex
+	logger   logging.Logger
+}
+
+// cachedETag is the sidecar recording which location and ETag the cache holds.
+type cachedETag struct {
+	Location string `json:"location"`
+	ETag     string `json:"etag"`
+}
+
+// NewHTTPSSource constructs a Source fetching location with client. A nil
+// client uses one bounded by FetchTimeout.
+func NewHTTPSSource(location, cachePath string, client *http.Client) (*Source, error) {
+	if !strings.HasPrefix(location, HTTPSScheme) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, location)
+	}
+
+	if strings.TrimSpace(cachePath) == "" {
+		return nil, ErrCacheUnset
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: FetchTimeout} //nolint:exhaustruct
+	}
+
+	return &Source{
+		location:  location,
+		cachePath: cachePath,
+		fetch: func(ctx context.Context, etag string) ([]byte, string, error) {
+			return fetchHTTPS(ctx, client, location, etag)
+		},
+	}, nil
+}
+
+// NewObjectSource constructs a Source reading ref through reader.
+func NewObjectSource(ref oci.ObjectRef, cachePath string, reader ObjectReader) (*Source, error) {
+	if strings.TrimSpace(cachePath) == "" {
+		return nil, ErrCacheUnset
+	}
+
+	return &Source{
+		location:  ObjectScheme + ref.Namespace + "/" + ref.Bucket + "/" + ref.Name,
+		cachePath: cachePath,
+		fetch: func(ctx context.Context, etag string) ([]byte, string, error) {
+			content, newETag, err := reader.GetObject(ctx, ref, etag)
+			if errors.Is(err, oci.ErrObjectNotModified) {
+				return nil, etag, errNotModified
+			}
+
+			return content, newETag, err //nolint:wrapcheck // already descriptive
+		},
+	}, nil
+}
+
+// Location returns the remote location the source mirrors.
+func (s *Source) Location() string {
+	return s.location
+}
+
+// CachePath returns the file the configuration should be loaded from.
+func (s *Source) CachePath() string {
+	return s.cachePath
+}
+
+// StaleErr returns the fetch error that made Sync fall back to the cached
+// copy, or nil when the cache is current.
+func (s *Source) StaleErr() error {
+	return s.staleErr
+}
+
+// SetLogger installs the logger used by Watch to report refresh outcomes.
+func (s *Source) SetLogger(logger logging.Logger) {
+	s.loggerMu.Lock()
+	defer s.loggerMu.Unlock()
+
+	s.logger = logger
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (s *Source) log() logging.Logger {
+	s.loggerMu.RLock()
+	defer s.loggerMu.RUnlock()
+
+	return logging.OrNop(s.logger)
+}
+
+func fetchHTTPS(
+	ctx context.Context,
+	client *http.Client,
+	location string,
+	etag string,
+) ([]byte, string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("build remote configuration request: %w", err)
+	}
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch remote configuration: %w", err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, etag, errNotModified
+	default:
+		return nil, "", fmt.Errorf("%w: %s", errStatus, response.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxConfigBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read remote configuration: %w", err)
+	}
+
+	if len(content) > maxConfigBytes {
+		return nil, "", errTooLarge
+	}
+
+	return content, response.Header.Get("ETag"), nil
+}
+
+// Sync brings the cache up to date before the configuration is loaded. When
+// the remote cannot be reached but a cached copy of the same location exists,
+// it keeps that copy and reports the fetch error through StaleErr.
+func (s *Source) Sync(ctx context.Context) error {
+	cached, haveCache := s.readCachedETag()
+
+	ctx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	content, etag, err := s.fetch(ctx, cached)
+
+	switch {
+	case err == nil:
+		return s.store(content, etag)
+	case errors.Is(err, errNotModified) && haveCache:
+		s.etag = cached
+
+		return nil
+	case haveCache:
+		s.etag = cached
+		s.staleErr = err
+
+		return nil
+	default:
+		return err
+	}
+}
+
+// refresh fetches the remote configuration and returns its content when it
+// differs from the cached copy. The cache itself is only updated by store.
+func (s *Source) refresh(ctx context.Context) ([]byte, string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	content, etag, err := s.fetch(ctx, s.etag)
+	if errors.Is(err, errNotModified) {
+		return nil, s.etag, false, nil
+	}
+
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	current, readErr := os.ReadFile(s.cachePath)
+	if readErr == nil && bytes.Equal(current, content) {
+		// Same bytes under a new ETag, for example after a re-upload.
+		_ = s.writeETag(etag)
+
+		return nil, etag, false, nil
+	}
+
+	return content, etag, true, nil
+}
+
+// readCachedETag returns the ETag of the cached copy and whether the cache holds
+// this location at all; a cache left by another location is never used.
+func (s *Source) readCachedETag() (string, bool) {
+	data, err := os.ReadFile(s.cachePath + ".etag")
+	if err != nil {
+		return "", false
+	}
+
+	var cached cachedETag
+
+	err = json.Unmarshal(data, &cached)
+	if err != nil || cached.Location != s.location {
+		return "", false
+	}
+
+	_, err = os.Stat(s.cachePath)
+	if err != nil {
+		return "", false
+	}
+
+	return cached.ETag, true
+}
+
+// store replaces the cached configuration atomically and records its ETag.
+func (s *Source) store(content []byte, etag string) error {
+	err := writeFileAtomic(s.cachePath, content)
+	if err != nil {
+		return fmt.Errorf("cache remote configuration: %w", err)
+	}
+
+	return s.writeETag(etag)
+}
+
+func (s *Source) writeETag(etag string) error {
+	s.etag = etag
+
+	data, err := json.Marshal(cachedETag{Location: s.location, ETag: etag})
+	if err != nil {
+		return fmt.Errorf("encode remote configuration etag: %w", err)
+	}
+
+	err = writeFileAtomic(s.cachePath+".etag", data)
+	if err != nil {
+		return fmt.Errorf("cache remote configuration etag: %w", err)
+	}
+
+	return nil
+}
+
+func writeFileAtomic(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	temp := path + ".tmp"
+
+	err = os.WriteFile(temp, content, 0o600)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", temp, err)
+	}
+
+	err = os.Rename(temp, path)
+	if err != nil {
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+
+	return nil
+}
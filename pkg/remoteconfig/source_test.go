@@ -0,0 +1,247 @@
+package remoteconfig //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"oci-cpu-shaper/internal/logtest"
+	"oci-cpu-shaper/pkg/oci"
+)
+
+var (
+	errStubInvalid           = errors.New("stub: invalid configuration")
+	errStubObjectUnavailable = errors.New("stub: object storage unavailable")
+)
+
+// configServer serves a configuration document with an ETag and honours
+// If-None-Match.
+type configServer struct {
+	mu          sync.Mutex
+	content     string
+	etag        string
+	notModified int
+}
+
+func (s *configServer) set(content, etag string) {
+	s.mu.Lock()
+	s.content, s.etag = content, etag
+	s.mu.Unlock()
+}
+
+func (s *configServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		writer.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	writer.Header().Set("ETag", s.etag)
+	_, _ = io.WriteString(writer, s.content)
+}
+
+func TestSourceCachesAndFallsBack(t *testing.T) {
+	t.Parallel()
+
+	handler := new(configServer)
+	handler.set("controller:\n  targetMax: 0.4\n", `"v1"`)
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	cache := filepath.Join(t.TempDir(), "remote", "config.yaml")
+	location := server.URL + "/config.yaml"
+
+	syncSource := func(location string) (*Source, error) {
+		source, err := NewHTTPSSource(location, cache, server.Client())
+		if err != nil {
+			t.Fatalf("NewHTTPSSource: %v", err)
+		}
+
+		return source, source.Sync(t.Context())
+	}
+
+	source, err := syncSource(location)
+	if err != nil || source.etag != `"v1"` {
+		t.Fatalf("expected first sync to fetch v1, got %q (%v)", source.etag, err)
+	}
+
+	cached, err := os.ReadFile(cache)
+	if err != nil || string(cached) != "controller:\n  targetMax: 0.4\n" {
+		t.Fatalf("unexpected cached configuration %q (%v)", cached, err)
+	}
+
+	_, err = syncSource(location)
+	if err != nil || handler.notModified != 1 {
+		t.Fatalf("expected a conditional re-fetch, got %d (%v)", handler.notModified, err)
+	}
+
+	server.Close()
+
+	source, err = syncSource(location)
+	if err != nil || source.StaleErr() == nil {
+		t.Fatalf("expected stale cached copy, got stale=%v err=%v", source.StaleErr(), err)
+	}
+
+	_, err = syncSource(server.URL + "/other.yaml")
+	if err == nil {
+		t.Fatal("expected a different location not to reuse the cache")
+	}
+
+	_, err = NewHTTPSSource(location, " ", nil)
+	if !errors.Is(err, ErrCacheUnset) {
+		t.Fatalf("expected ErrCacheUnset, got %v", err)
+	}
+}
+
+func TestApplyRefresh(t *testing.T) {
+	t.Parallel()
+
+	logger := new(logtest.Recorder)
+	cache := filepath.Join(t.TempDir(), "config.yaml")
+
+	var (
+		content = "controller: {}\n"
+		etag    = "v1"
+	)
+
+	source := &Source{
+		location:  "https://config.example/shaper.yaml",
+		cachePath: cache,
+		fetch: func(_ context.Context, current string) ([]byte, string, error) {
+			if current == etag {
+				return nil, current, errNotModified
+			}
+
+			return []byte(content), etag, nil
+		},
+	}
+	source.SetLogger(logger)
+
+	err := source.Sync(t.Context())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	validate := func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil || strings.Contains(string(data), "invalid") {
+			return errStubInvalid
+		}
+
+		return nil
+	}
+
+	if source.ApplyRefresh(t.Context(), validate) {
+		t.Fatal("expected an unmodified configuration not to restart")
+	}
+
+	etag = "v2"
+	if source.ApplyRefresh(t.Context(), validate) || source.etag != "v2" {
+		t.Fatalf("expected a re-upload to only update the etag, got %q", source.etag)
+	}
+
+	content, etag = "invalid: true\n", "v3"
+	if source.ApplyRefresh(t.Context(), validate) {
+		t.Fatal("expected an invalid configuration not to restart")
+	}
+
+	content, etag = "controller:\n  targetMax: 0.5\n", "v4"
+	if !source.ApplyRefresh(t.Context(), validate) {
+		t.Fatal("expected a valid change to restart")
+	}
+
+	cached, _ := os.ReadFile(cache)
+	if string(cached) != content || source.etag != "v4" {
+		t.Fatalf("expected the cache to hold v4, got %q (%q)", cached, source.etag)
+	}
+
+	if logger.Count("warn: remote configuration rejected; keeping current configuration") != 1 ||
+		logger.Count("info: remote configuration changed; restarting to apply") != 1 {
+		t.Fatalf("unexpected log entries %v", logger.Lines())
+	}
+}
+
+type stubObjectReader struct {
+	ref  oci.ObjectRef
+	etag string
+	err  error
+}
+
+func (s *stubObjectReader) GetObject(
+	_ context.Context,
+	ref oci.ObjectRef,
+	etag string,
+) ([]byte, string, error) {
+	s.ref = ref
+
+	if s.err != nil {
+		return nil, "", s.err
+	}
+
+	if etag == s.etag {
+		return nil, etag, oci.ErrObjectNotModified
+	}
+
+	return []byte("controller: {}\n"), s.etag, nil
+}
+
+func TestObjectSourceReadsObjectStorage(t *testing.T) {
+	t.Parallel()
+
+	ref, err := ParseObjectURL("os://tenancy/configs/shaper/prod.yaml")
+	if err != nil {
+		t.Fatalf("ParseObjectURL: %v", err)
+	}
+
+	reader := &stubObjectReader{etag: "abc"} //nolint:exhaustruct
+
+	source, err := NewObjectSource(ref, filepath.Join(t.TempDir(), "config.yaml"), reader)
+	if err != nil {
+		t.Fatalf("NewObjectSource: %v", err)
+	}
+
+	if source.Location() != "os://tenancy/configs/shaper/prod.yaml" {
+		t.Fatalf("unexpected location %q", source.Location())
+	}
+
+	err = source.Sync(t.Context())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	want := oci.ObjectRef{Namespace: "tenancy", Bucket: "configs", Name: "shaper/prod.yaml"}
+	if reader.ref != want || source.etag != "abc" {
+		t.Fatalf("unexpected object request %+v (etag %q)", reader.ref, source.etag)
+	}
+
+	_, _, changed, err := source.refresh(t.Context())
+	if err != nil || changed {
+		t.Fatalf("expected matching etag to be unchanged, got %v (%v)", changed, err)
+	}
+
+	reader.err = errStubObjectUnavailable
+
+	_, _, _, err = source.refresh(t.Context())
+	if !errors.Is(err, errStubObjectUnavailable) {
+		t.Fatalf("expected object storage error, got %v", err)
+	}
+
+	for _, invalid := range []string{"os://tenancy/configs", "https://tenancy/configs/a"} {
+		_, err = ParseObjectURL(invalid)
+		if !errors.Is(err, ErrInvalidURL) {
+			t.Fatalf("%q: expected ErrInvalidURL, got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package remoteconfig
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch re-fetches the remote configuration every interval until a changed
+// document passes validate, commits it to the cache and returns true, so the
+// caller can restart with it. Invalid documents are reported and ignored.
+// Watch returns false once ctx is done.
+func (s *Source) Watch(ctx context.Context, interval time.Duration, validate Validator) bool {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
+		if s.ApplyRefresh(ctx, validate) {
+			return true
+		}
+	}
+}
+
+// ApplyRefresh fetches the remote configuration once. It returns true when a
+// changed document passed validate and replaced the cached copy.
+func (s *Source) ApplyRefresh(ctx context.Context, validate Validator) bool {
+	content, etag, changed, err := s.refresh(ctx)
+	if err != nil {
+		s.log().Warn("remote configuration refresh failed", "config", s.location, "error", err)
+
+		return false
+	}
+
+	if !changed {
+		return false
+	}
+
+	candidate := s.cachePath + ".candidate"
+
+	err = writeFileAtomic(candidate, content)
+	if err == nil {
+		err = validate(candidate)
+	}
+
+	_ = os.Remove(candidate)
+
+	if err != nil {
+		s.log().Warn(
+			"remote configuration rejected; keeping current configuration",
+			"config", s.location,
+			"etag", etag,
+			"error", err,
+		)
+
+		return false
+	}
+
+	err = s.store(content, etag)
+	if err != nil {
+		s.log().Warn("failed to cache remote configuration", "error", err)
+
+		return false
+	}
+
+	s.log().Info(
+		"remote configuration changed; restarting to apply",
+		"config", s.location,
+		"etag", etag,
+	)
+
+	return true
+}
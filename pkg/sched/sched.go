@@ -0,0 +1,85 @@
+// Package sched wraps the per-thread scheduling calls the worker pool relies on
+// (sched_setscheduler, setpriority, sched_setaffinity) behind one platform
+// layer, and probes which of them the host actually permits so operators learn
+// why a worker could not lower its priority.
+package sched
+
+import "errors"
+
+// NiceLowest is the weakest nice value accepted by Linux.
+const NiceLowest = 19
+
+// ErrUnsupported is returned on platforms without per-thread scheduling control.
+var ErrUnsupported = errors.New("sched: per-thread scheduling is not supported on this platform")
+
+// Status classifies the outcome of a probed scheduling call.
+type Status string
+
+const (
+	// StatusOK means the call succeeded.
+	StatusOK Status = "ok"
+	// StatusDenied means the kernel or a seccomp filter answered EPERM or EACCES.
+	StatusDenied Status = "denied"
+	// StatusBlocked means the call returned ENOSYS, which seccomp profiles use to
+	// hide syscalls they do not allow.
+	StatusBlocked Status = "blocked"
+	// StatusUnsupported means the platform has no such call.
+	StatusUnsupported Status = "unsupported"
+	// StatusFailed covers any other error.
+	StatusFailed Status = "failed"
+)
+
+// Seccomp modes reported in /proc/self/status.
+const (
+	SeccompDisabled = "disabled"
+	SeccompStrict   = "strict"
+	SeccompFilter   = "filter"
+	SeccompUnknown  = "unknown"
+)
+
+// Check is the result of probing one scheduling call.
+type Check struct {
+	Status Status
+	Err    error
+}
+
+// String renders the status, followed by the error when the call failed.
+func (c Check) String() string {
+	if c.Err == nil || c.Status == StatusOK {
+		return string(c.Status)
+	}
+
+	return string(c.Status) + " (" + c.Err.Error() + ")"
+}
+
+// Capabilities reports which scheduling calls a worker thread may make and the
+// process attributes that usually explain a refusal.
+type Capabilities struct {
+	// Platform is GOOS/GOARCH.
+	Platform  string
+	SchedIdle Check
+	Nice      Check
+	Affinity  Check
+	// CapSysNice reports whether CAP_SYS_NICE is in the effective set.
+	CapSysNice bool
+	// Seccomp is one of the Seccomp* modes.
+	Seccomp    string
+	NoNewPrivs bool
+}
+
+// Hint suggests why SCHED_IDLE was refused, or returns an empty string when it
+// is available or nothing points to a cause.
+func (c Capabilities) Hint() string {
+	switch {
+	case c.SchedIdle.Status == StatusOK:
+		return ""
+	case c.SchedIdle.Status == StatusUnsupported:
+		return "per-thread scheduling is only available on linux"
+	case c.Seccomp == SeccompFilter || c.Seccomp == SeccompStrict:
+		return "a seccomp profile is active; allow sched_setscheduler and setpriority in it"
+	case !c.CapSysNice:
+		return "CAP_SYS_NICE is missing; grant it or relax the scheduling policy limits"
+	default:
+		return ""
+	}
+}
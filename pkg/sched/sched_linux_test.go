@@ -0,0 +1,149 @@
+//go:build linux
+
+package sched //nolint:testpackage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// withSeams swaps the syscall wrappers for the duration of a test.
+func withSeams(
+	t *testing.T,
+	scheduler func(pid, policy int) error,
+	priority func(which, who, prio int) error,
+	status string,
+) {
+	t.Helper()
+
+	seamsMu.Lock()
+	previousScheduler, previousPriority := schedSetScheduler, setPriority
+	previousStatus := statusPath
+	schedSetScheduler, setPriority, statusPath = scheduler, priority, status
+	seamsMu.Unlock()
+
+	t.Cleanup(func() {
+		seamsMu.Lock()
+		schedSetScheduler, setPriority = previousScheduler, previousPriority
+		statusPath = previousStatus
+		seamsMu.Unlock()
+	})
+}
+
+//nolint:paralleltest // replaces package seams
+func TestSetIdleAndNiceTargetCallingThread(t *testing.T) {
+	var gotPolicy, gotWhich, gotWho, gotPrio int
+
+	withSeams(
+		t,
+		func(pid, policy int) error {
+			if pid != 0 {
+				t.Fatalf("expected pid 0, got %d", pid)
+			}
+
+			gotPolicy = policy
+
+			return nil
+		},
+		func(which, who, prio int) error {
+			gotWhich, gotWho, gotPrio = which, who, prio
+
+			return nil
+		},
+		statusPath,
+	)
+
+	err := SetIdle()
+	if err != nil || gotPolicy != unix.SCHED_IDLE {
+		t.Fatalf("expected SCHED_IDLE, got policy %d (%v)", gotPolicy, err)
+	}
+
+	err = SetNice(NiceLowest)
+	if err != nil || gotWhich != unix.PRIO_PROCESS || gotWho != 0 || gotPrio != NiceLowest {
+		t.Fatalf("unexpected setpriority(%d, %d, %d): %v", gotWhich, gotWho, gotPrio, err)
+	}
+}
+
+//nolint:paralleltest // replaces package seams
+func TestProbeClassifiesRefusalsAndReadsStatus(t *testing.T) {
+	status := filepath.Join(t.TempDir(), "status")
+
+	err := os.WriteFile(
+		status,
+		[]byte("Name:\tshaper\nCapEff:\t0000000000800000\nNoNewPrivs:\t1\nSeccomp:\t2\n"),
+		0o600,
+	)
+	if err != nil {
+		t.Fatalf("write status: %v", err)
+	}
+
+	withSeams(
+		t,
+		func(int, int) error { return unix.EPERM },
+		func(int, int, int) error { return unix.ENOSYS },
+		status,
+	)
+
+	caps := Probe()
+
+	if caps.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Fatalf("unexpected platform %q", caps.Platform)
+	}
+
+	if caps.SchedIdle.Status != StatusDenied || !errors.Is(caps.SchedIdle.Err, unix.EPERM) {
+		t.Fatalf("expected SCHED_IDLE to be denied, got %+v", caps.SchedIdle)
+	}
+
+	if caps.Nice.Status != StatusBlocked {
+		t.Fatalf("expected setpriority to be blocked, got %+v", caps.Nice)
+	}
+
+	if !caps.CapSysNice || caps.Seccomp != SeccompFilter || !caps.NoNewPrivs {
+		t.Fatalf("unexpected process attributes %+v", caps)
+	}
+}
+
+func TestProbeLeavesCallingThreadUntouched(t *testing.T) {
+	t.Parallel()
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	before, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if err != nil {
+		t.Skipf("getpriority unavailable: %v", err)
+	}
+
+	caps := Probe()
+	if caps.Affinity.Status != StatusOK {
+		t.Fatalf("expected affinity to be reapplied, got %+v", caps.Affinity)
+	}
+
+	after, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if err != nil || after != before {
+		t.Fatalf("expected caller priority %d to survive, got %d (%v)", before, after, err)
+	}
+}
+
+func TestReadStatusToleratesMissingFields(t *testing.T) {
+	t.Parallel()
+
+	caps := Capabilities{Seccomp: SeccompUnknown} //nolint:exhaustruct
+
+	readStatus([]byte("CapEff:\tzz\nSeccomp:\t9\nnoise\n"), &caps)
+
+	if caps.CapSysNice || caps.Seccomp != SeccompUnknown || caps.NoNewPrivs {
+		t.Fatalf("expected malformed fields to be ignored, got %+v", caps)
+	}
+
+	readStatus([]byte("Seccomp:\t0\n"), &caps)
+
+	if caps.Seccomp != SeccompDisabled {
+		t.Fatalf("expected seccomp disabled, got %q", caps.Seccomp)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package sched
+
+import "runtime"
+
+// SetIdle is unavailable outside linux.
+func SetIdle() error {
+	return ErrUnsupported
+}
+
+// SetNice is unavailable outside linux.
+func SetNice(int) error {
+	return ErrUnsupported
+}
+
+// SetAffinity is unavailable outside linux.
+func SetAffinity([]int) error {
+	return ErrUnsupported
+}
+
+// Probe reports every call as unsupported.
+func Probe() Capabilities {
+	unsupported := Check{Status: StatusUnsupported, Err: ErrUnsupported}
+
+	return Capabilities{
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		SchedIdle:  unsupported,
+		Nice:       unsupported,
+		Affinity:   unsupported,
+		CapSysNice: false,
+		Seccomp:    SeccompUnknown,
+		NoNewPrivs: false,
+	}
+}
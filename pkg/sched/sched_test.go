@@ -0,0 +1,74 @@
+package sched //nolint:testpackage
+
+import (
+	"errors"
+	"testing"
+)
+
+var errStubRefused = errors.New("operation not permitted")
+
+func TestCheckStringIncludesError(t *testing.T) {
+	t.Parallel()
+
+	ok := Check{Status: StatusOK, Err: nil}
+	if ok.String() != "ok" {
+		t.Fatalf("expected plain status, got %q", ok.String())
+	}
+
+	denied := Check{Status: StatusDenied, Err: errStubRefused}
+	if denied.String() != "denied (operation not permitted)" {
+		t.Fatalf("expected status with error, got %q", denied.String())
+	}
+}
+
+func TestCapabilitiesHint(t *testing.T) {
+	t.Parallel()
+
+	denied := Check{Status: StatusDenied, Err: errStubRefused}
+
+	testCases := []struct {
+		name string
+		caps Capabilities
+		want string
+	}{
+		{
+			name: "available",
+			caps: Capabilities{SchedIdle: Check{Status: StatusOK}}, //nolint:exhaustruct
+			want: "",
+		},
+		{
+			name: "unsupported platform",
+			caps: Capabilities{SchedIdle: Check{Status: StatusUnsupported}}, //nolint:exhaustruct
+			want: "per-thread scheduling is only available on linux",
+		},
+		{
+			name: "seccomp filter",
+			caps: Capabilities{SchedIdle: denied, Seccomp: SeccompFilter}, //nolint:exhaustruct
+			want: "a seccomp profile is active; allow sched_setscheduler and setpriority in it",
+		},
+		{
+			name: "missing capability",
+			caps: Capabilities{SchedIdle: denied, Seccomp: SeccompDisabled}, //nolint:exhaustruct
+			want: "CAP_SYS_NICE is missing; grant it or relax the scheduling policy limits",
+		},
+		{
+			name: "no known cause",
+			caps: Capabilities{ //nolint:exhaustruct
+				SchedIdle:  denied,
+				Seccomp:    SeccompDisabled,
+				CapSysNice: true,
+			},
+			want: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.caps.Hint(); got != testCase.want {
+				t.Fatalf("expected hint %q, got %q", testCase.want, got)
+			}
+		})
+	}
+}
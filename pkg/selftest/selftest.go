@@ -0,0 +1,362 @@
+// Package selftest runs one control cycle against in-process fake IMDS and
+// Monitoring services and checks the metrics it exports, so a freshly
+// installed binary and its environment (resolver, TLS stack, system trust
+// store, loopback networking) can be validated without OCI credentials.
+package selftest
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/verify"
+)
+
+// Check names, in the order Run performs them.
+const (
+	CheckDNS        = "dns"
+	CheckCerts      = "certs"
+	CheckIMDS       = "imds"
+	CheckMonitoring = "monitoring"
+	CheckController = "controller"
+	CheckMetrics    = "metrics"
+)
+
+// DefaultTimeout bounds a self-test run when Options.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// resolvedHost is the name the fake services are reached through, so the
+// resolver is exercised the way it is for OCI endpoints.
+const resolvedHost = "localhost"
+
+// metricsBodyLimit bounds the scraped metrics exposition.
+const metricsBodyLimit = 1 << 20
+
+var (
+	// ErrSkipped marks a check that did not run because one it depends on
+	// failed.
+	ErrSkipped = errors.New("selftest: skipped after an earlier failure")
+	// ErrUnexpected signals a check whose result differs from what the fake
+	// services serve.
+	ErrUnexpected = errors.New("selftest: unexpected result")
+)
+
+// Check is the outcome of one self-test step. Err is nil when it passed.
+type Check struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// Report lists the checks of a run in order.
+type Report struct {
+	Checks []Check
+}
+
+// Passed reports whether every check passed.
+func (r Report) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+
+	return len(r.Checks) > 0
+}
+
+func (r *Report) add(name, detail string, err error) bool {
+	r.Checks = append(r.Checks, Check{Name: name, Detail: detail, Err: err})
+
+	return err == nil
+}
+
+func (r *Report) skip(names ...string) {
+	for _, name := range names {
+		r.add(name, "", ErrSkipped)
+	}
+}
+
+// Options tunes a run. Nil functions use the standard library.
+type Options struct {
+	// Timeout bounds the whole run; zero selects DefaultTimeout.
+	Timeout time.Duration
+	// LookupHost resolves host names, net.DefaultResolver.LookupHost by
+	// default.
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+	// SystemCertPool loads the system trust store, x509.SystemCertPool by
+	// default.
+	SystemCertPool func() (*x509.CertPool, error)
+
+	// listen opens the fake services' loopback listeners, net.Listen unless
+	// a test replaces it.
+	listen func(network, address string) (net.Listener, error)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	if o.LookupHost == nil {
+		o.LookupHost = net.DefaultResolver.LookupHost
+	}
+
+	if o.SystemCertPool == nil {
+		o.SystemCertPool = x509.SystemCertPool
+	}
+
+	if o.listen == nil {
+		o.listen = net.Listen
+	}
+
+	return o
+}
+
+// Run resolves localhost, loads the system trust store, starts the fake
+// services on loopback, reads the fake instance's metadata, queries its CPU
+// P95 over TLS, runs one controller step, and scrapes the resulting metrics.
+// A failed check skips the checks that depend on it.
+func Run(ctx context.Context, opts Options) Report {
+	opts = opts.withDefaults()
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var report Report
+
+	addrs, err := opts.LookupHost(ctx, resolvedHost)
+	if err != nil {
+		err = fmt.Errorf("resolve %s: %w", resolvedHost, err)
+	}
+
+	resolved := report.add(
+		CheckDNS,
+		resolvedHost+" resolves to "+strings.Join(addrs, ", "),
+		err,
+	)
+
+	roots, err := opts.SystemCertPool()
+	if err != nil {
+		err = fmt.Errorf("load system trust store: %w", err)
+	}
+
+	report.add(CheckCerts, "system trust store loaded", err)
+
+	if !resolved {
+		report.skip(CheckIMDS, CheckMonitoring, CheckController, CheckMetrics)
+
+		return report
+	}
+
+	services, err := startServices(opts, roots)
+	if err != nil {
+		report.add(CheckIMDS, "", err)
+		report.skip(CheckMonitoring, CheckController, CheckMetrics)
+
+		return report
+	}
+	defer services.close()
+
+	runChecks(ctx, &report, services)
+
+	return report
+}
+
+// runChecks runs the checks that talk to the fake services.
+func runChecks(ctx context.Context, report *Report, services *services) {
+	instanceID, detail, err := checkIMDS(ctx, services.imdsURL)
+	imdsOK := report.add(CheckIMDS, detail, err)
+
+	p95, err := services.monitoring.QueryP95CPU(ctx, fakeInstanceID)
+	if err == nil && p95 != fakeP95 {
+		err = fmt.Errorf("%w: p95 %.4f, want %.4f", ErrUnexpected, p95, fakeP95)
+	}
+
+	monitoringOK := report.add(CheckMonitoring, fmt.Sprintf("p95 %.4f over tls", p95), err)
+
+	if !imdsOK || !monitoringOK {
+		report.skip(CheckController, CheckMetrics)
+
+		return
+	}
+
+	exporter := metricshttp.NewExporter()
+
+	decision, err := runStep(ctx, instanceID, services.monitoring, exporter)
+	if !report.add(CheckController, describeDecision(decision), err) {
+		report.skip(CheckMetrics)
+
+		return
+	}
+
+	detail, err = checkMetrics(ctx, services, exporter)
+	report.add(CheckMetrics, detail, err)
+}
+
+// checkIMDS reads the fake instance's metadata through the production IMDS
+// client and returns its instance OCID.
+func checkIMDS(ctx context.Context, baseURL string) (string, string, error) {
+	client := imds.NewClient(nil, imds.WithBaseURL(baseURL))
+
+	instanceID, err := client.InstanceID(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("read instance id: %w", err)
+	}
+
+	region, err := client.CanonicalRegion(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("read region: %w", err)
+	}
+
+	shape, err := client.ShapeConfig(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("read shape config: %w", err)
+	}
+
+	if instanceID != fakeInstanceID || region != fakeRegion || shape.OCPUs != fakeOCPUs {
+		return "", "", fmt.Errorf(
+			"%w: instance %q in %q with %.1f ocpus",
+			ErrUnexpected, instanceID, region, shape.OCPUs,
+		)
+	}
+
+	detail := fmt.Sprintf("instance %s in %s, %.0f ocpu", instanceID, region, shape.OCPUs)
+
+	return instanceID, detail, nil
+}
+
+// runStep runs one controller step against the fake Monitoring service and
+// expects it to leave fallback for the normal state.
+func runStep(
+	ctx context.Context,
+	instanceID string,
+	metrics *monitoringClient,
+	exporter *metricshttp.Exporter,
+) (adapt.Decision, error) {
+	cfg := adapt.DefaultConfig()
+	cfg.ResourceID = instanceID
+	cfg.Mode = "self-test"
+
+	controller, err := adapt.NewAdaptiveController(cfg, metrics, nil, new(dutyCycle), exporter)
+	if err != nil {
+		return adapt.Decision{}, fmt.Errorf("build controller: %w", err)
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_ = controller.Run(runCtx)
+	}()
+
+	decision, err := controller.RequestStep(ctx)
+
+	stop()
+	<-done
+
+	if err != nil {
+		return adapt.Decision{}, fmt.Errorf("run controller step: %w", err)
+	}
+
+	if decision.Err != nil {
+		return decision, fmt.Errorf("controller step: %w", decision.Err)
+	}
+
+	if decision.State != adapt.StateNormal || decision.P95 != fakeP95 {
+		return decision, fmt.Errorf(
+			"%w: state %s with p95 %.4f", ErrUnexpected, decision.State, decision.P95,
+		)
+	}
+
+	return decision, nil
+}
+
+func describeDecision(decision adapt.Decision) string {
+	if decision.Timestamp.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprintf("state %s, target %.2f", decision.State, decision.Target)
+}
+
+// checkMetrics serves exporter on loopback, scrapes it, and checks the
+// exposition carries the controller state the step left.
+func checkMetrics(
+	ctx context.Context,
+	services *services,
+	exporter *metricshttp.Exporter,
+) (string, error) {
+	server, err := services.serve(exporter, nil)
+	if err != nil {
+		return "", err
+	}
+	defer server.close()
+
+	body, err := scrape(ctx, services.http, server.url+"/metrics")
+	if err != nil {
+		return "", err
+	}
+
+	err = verify.RequireState(body, "", adapt.StateNormal.String())
+	if err != nil {
+		return "", fmt.Errorf("check scraped metrics: %w", err)
+	}
+
+	return fmt.Sprintf("scraped %d bytes, state %s", len(body), adapt.StateNormal), nil
+}
+
+func scrape(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("build metrics request: %w", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("scrape metrics: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: metrics status %d", ErrUnexpected, response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, metricsBodyLimit))
+	if err != nil {
+		return nil, fmt.Errorf("read metrics: %w", err)
+	}
+
+	return body, nil
+}
+
+// dutyCycle records the target the controller applies instead of driving
+// workers, so a self-test adds no load.
+type dutyCycle struct {
+	mu     sync.Mutex
+	target float64
+}
+
+func (d *dutyCycle) SetTarget(target float64) {
+	d.mu.Lock()
+	d.target = target
+	d.mu.Unlock()
+}
+
+func (d *dutyCycle) Target() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.target
+}
@@ -0,0 +1,464 @@
+//nolint:testpackage // tests drive the unexported checks against broken services
+package selftest
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"oci-cpu-shaper/pkg/adapt"
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/verify"
+)
+
+var errStub = errors.New("stub failure")
+
+func checkNames(report Report) []string {
+	names := make([]string, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+	}
+
+	return names
+}
+
+func requireCheck(t *testing.T, report Report, name string, want error) Check {
+	t.Helper()
+
+	for _, check := range report.Checks {
+		if check.Name != name {
+			continue
+		}
+
+		if want == nil && check.Err != nil || want != nil && !errors.Is(check.Err, want) {
+			t.Fatalf("%s: expected error %v, got %v", name, want, check.Err)
+		}
+
+		return check
+	}
+
+	t.Fatalf("expected a %s check in %v", name, checkNames(report))
+
+	return Check{}
+}
+
+func TestRunPassesAgainstFakeServices(t *testing.T) {
+	t.Parallel()
+
+	report := Run(t.Context(), Options{})
+	if !report.Passed() {
+		t.Fatalf("expected the self-test to pass, got %+v", report.Checks)
+	}
+
+	want := []string{
+		CheckDNS, CheckCerts, CheckIMDS, CheckMonitoring, CheckController, CheckMetrics,
+	}
+	if got := checkNames(report); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected checks %v, got %v", want, got)
+	}
+
+	for _, check := range report.Checks {
+		if check.Detail == "" {
+			t.Fatalf("expected a detail for %s", check.Name)
+		}
+	}
+
+	if (Report{}).Passed() {
+		t.Fatal("expected an empty report not to pass")
+	}
+}
+
+func TestRunSkipsServiceChecksWhenResolutionFails(t *testing.T) {
+	t.Parallel()
+
+	report := Run(t.Context(), Options{
+		Timeout: 0,
+		LookupHost: func(context.Context, string) ([]string, error) {
+			return nil, errStub
+		},
+		SystemCertPool: nil,
+	})
+
+	if report.Passed() {
+		t.Fatal("expected the self-test to fail")
+	}
+
+	requireCheck(t, report, CheckDNS, errStub)
+	requireCheck(t, report, CheckCerts, nil)
+
+	for _, name := range []string{CheckIMDS, CheckMonitoring, CheckController, CheckMetrics} {
+		requireCheck(t, report, name, ErrSkipped)
+	}
+}
+
+func TestRunReportsMissingTrustStore(t *testing.T) {
+	t.Parallel()
+
+	report := Run(t.Context(), Options{
+		Timeout:    0,
+		LookupHost: nil,
+		SystemCertPool: func() (*x509.CertPool, error) {
+			return nil, errStub
+		},
+	})
+
+	requireCheck(t, report, CheckCerts, errStub)
+	requireCheck(t, report, CheckMonitoring, nil)
+	requireCheck(t, report, CheckMetrics, nil)
+
+	if report.Passed() {
+		t.Fatal("expected a missing trust store to fail the self-test")
+	}
+}
+
+// failingPath wraps the fake IMDS so one endpoint answers 404.
+func failingPath(path string) http.Handler {
+	handler := imdsHandler()
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/"+path) {
+			http.NotFound(writer, request)
+
+			return
+		}
+
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func TestCheckIMDSReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	mismatched := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/id") {
+			_, _ = writer.Write([]byte("ocid1.instance.oc1..other"))
+
+			return
+		}
+
+		imdsHandler().ServeHTTP(writer, request)
+	})
+
+	tests := map[string]struct {
+		handler http.Handler
+		want    string
+	}{
+		"instance id":  {handler: failingPath("id"), want: "read instance id"},
+		"region":       {handler: failingPath("regionInfo"), want: "read region"},
+		"shape config": {handler: failingPath("shape-config"), want: "read shape config"},
+		"mismatch":     {handler: mismatched, want: ErrUnexpected.Error()},
+	}
+
+	for name, test := range tests {
+		server := httptest.NewServer(test.handler)
+
+		_, _, err := checkIMDS(t.Context(), server.URL+"/opc/v2")
+
+		server.Close()
+
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%s: expected %q, got %v", name, test.want, err)
+		}
+	}
+}
+
+func TestIMDSHandlerRequiresAuthorization(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/opc/v2/instance/id", nil)
+	imdsHandler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the IMDSv2 header, got %d", recorder.Code)
+	}
+}
+
+func TestMonitoringClientReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		want    string
+	}{
+		"status": {
+			handler: func(writer http.ResponseWriter, _ *http.Request) {
+				http.Error(writer, "down", http.StatusServiceUnavailable)
+			},
+			want: "monitoring status 503",
+		},
+		"body": {
+			handler: func(writer http.ResponseWriter, _ *http.Request) {
+				_, _ = writer.Write([]byte("{"))
+			},
+			want: "decode monitoring response",
+		},
+		"no data": {
+			handler: func(writer http.ResponseWriter, _ *http.Request) {
+				_, _ = writer.Write([]byte("[]"))
+			},
+			want: "metrics unavailable",
+		},
+	}
+
+	for name, test := range tests {
+		server := httptest.NewServer(test.handler)
+		client := &monitoringClient{http: server.Client(), url: server.URL}
+
+		_, err := client.QueryP95CPU(t.Context(), fakeInstanceID)
+
+		server.Close()
+
+		if err == nil || !strings.Contains(strings.ToLower(err.Error()), test.want) {
+			t.Fatalf("%s: expected %q, got %v", name, test.want, err)
+		}
+	}
+
+	client := &monitoringClient{http: http.DefaultClient, url: "http://" + resolvedHost + ":0"}
+
+	_, err := client.QueryP95CPU(t.Context(), fakeInstanceID)
+	if err == nil || !strings.Contains(err.Error(), "query monitoring") {
+		t.Fatalf("expected an unreachable service to fail, got %v", err)
+	}
+
+	client.url = "://invalid"
+
+	_, err = client.QueryP95CPU(t.Context(), fakeInstanceID)
+	if err == nil || !strings.Contains(err.Error(), "build query") {
+		t.Fatalf("expected an invalid URL to fail, got %v", err)
+	}
+}
+
+func TestMonitoringHandlerRejectsOtherQueries(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(
+		http.MethodPost,
+		summarizePath+"?compartmentId="+fakeCompartmentID,
+		strings.NewReader(
+			`{"namespace":"oci_computeagent","query":"MemoryUtilization[1m].mean()"}`,
+		),
+	)
+	monitoringHandler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for another query, got %d", recorder.Code)
+	}
+}
+
+// sequencedMonitoring serves the fake Monitoring responses until calls
+// exceeds healthy, then answers with fail.
+func sequencedMonitoring(healthy int32, fail http.HandlerFunc) http.Handler {
+	var calls atomic.Int32
+
+	handler := monitoringHandler()
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if calls.Add(1) > healthy {
+			fail(writer, request)
+
+			return
+		}
+
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func newTestServices(t *testing.T, imds, monitoring http.Handler) *services {
+	t.Helper()
+
+	imdsServer := httptest.NewServer(imds)
+	t.Cleanup(imdsServer.Close)
+
+	monitoringServer := httptest.NewServer(monitoring)
+	t.Cleanup(monitoringServer.Close)
+
+	return &services{
+		listen:  net.Listen,
+		imds:    nil,
+		imdsURL: imdsServer.URL + "/opc/v2",
+		tls:     nil,
+		monitoring: &monitoringClient{
+			http: monitoringServer.Client(),
+			url:  monitoringServer.URL + summarizePath,
+		},
+		http: monitoringServer.Client(),
+	}
+}
+
+func TestRunChecksSkipsAfterFailures(t *testing.T) {
+	t.Parallel()
+
+	unavailable := func(writer http.ResponseWriter, _ *http.Request) {
+		http.Error(writer, "down", http.StatusServiceUnavailable)
+	}
+	drifted := func(writer http.ResponseWriter, _ *http.Request) {
+		writeJSON(writer, []summarizeItem{{AggregatedDatapoints: []datapoint{{Value: 90}}}})
+	}
+
+	tests := map[string]struct {
+		imds       http.Handler
+		monitoring http.Handler
+		failed     string
+		want       error
+		skipped    []string
+	}{
+		"imds": {
+			imds:       failingPath("id"),
+			monitoring: monitoringHandler(),
+			failed:     CheckIMDS,
+			want:       nil,
+			skipped:    []string{CheckController, CheckMetrics},
+		},
+		"monitoring value": {
+			imds:       imdsHandler(),
+			monitoring: sequencedMonitoring(0, drifted),
+			failed:     CheckMonitoring,
+			want:       ErrUnexpected,
+			skipped:    []string{CheckController, CheckMetrics},
+		},
+		"controller query": {
+			imds:       imdsHandler(),
+			monitoring: sequencedMonitoring(1, unavailable),
+			failed:     CheckController,
+			want:       ErrUnexpected,
+			skipped:    []string{CheckMetrics},
+		},
+		"controller state": {
+			imds:       imdsHandler(),
+			monitoring: sequencedMonitoring(1, drifted),
+			failed:     CheckController,
+			want:       ErrUnexpected,
+			skipped:    []string{CheckMetrics},
+		},
+	}
+
+	for name, test := range tests {
+		services := newTestServices(t, test.imds, test.monitoring)
+
+		var report Report
+
+		runChecks(t.Context(), &report, services)
+		services.close()
+
+		failed := report.Checks[slices.Index(checkNames(report), test.failed)]
+		if failed.Err == nil || test.want != nil && !errors.Is(failed.Err, test.want) {
+			t.Fatalf("%s: expected the %s check to fail with %v, got %v",
+				name, test.failed, test.want, failed.Err)
+		}
+
+		for _, skipped := range test.skipped {
+			requireCheck(t, report, skipped, ErrSkipped)
+		}
+	}
+}
+
+func TestRunStepReportsCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	services := newTestServices(t, imdsHandler(), monitoringHandler())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := runStep(ctx, fakeInstanceID, services.monitoring, metricshttp.NewExporter())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled step to fail, got %v", err)
+	}
+
+	if describeDecision(adapt.Decision{}) != "" {
+		t.Fatal("expected no detail without a decision")
+	}
+}
+
+func TestCheckMetricsReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	services := newTestServices(t, imdsHandler(), monitoringHandler())
+
+	_, err := checkMetrics(t.Context(), services, metricshttp.NewExporter())
+	if !errors.Is(err, verify.ErrStateMissing) && !errors.Is(err, verify.ErrStateMismatch) {
+		t.Fatalf("expected metrics without a normal state to fail, got %v", err)
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err = scrape(t.Context(), server.Client(), server.URL)
+	if !errors.Is(err, ErrUnexpected) {
+		t.Fatalf("expected a 404 scrape to fail, got %v", err)
+	}
+
+	_, err = scrape(t.Context(), server.Client(), "http://"+resolvedHost+":0")
+	if err == nil || !strings.Contains(err.Error(), "scrape metrics") {
+		t.Fatalf("expected an unreachable listener to fail, got %v", err)
+	}
+
+	_, err = scrape(t.Context(), server.Client(), "://invalid")
+	if err == nil || !strings.Contains(err.Error(), "build metrics request") {
+		t.Fatalf("expected an invalid URL to fail, got %v", err)
+	}
+}
+
+// listenFailingAfter returns a listen function that fails once healthy calls
+// have succeeded.
+func listenFailingAfter(healthy int32) func(network, address string) (net.Listener, error) {
+	var calls atomic.Int32
+
+	return func(network, address string) (net.Listener, error) {
+		if calls.Add(1) > healthy {
+			return nil, errStub
+		}
+
+		return net.Listen(network, address)
+	}
+}
+
+func TestRunReportsServiceStartupFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		healthy int32
+	}{
+		{name: "imds listener", healthy: 0},
+		{name: "monitoring listener", healthy: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			report := Run(t.Context(), Options{listen: listenFailingAfter(test.healthy)})
+			if report.Passed() {
+				t.Fatalf("expected the self-test to fail, got %+v", report.Checks)
+			}
+
+			requireCheck(t, report, CheckIMDS, errStub)
+			requireCheck(t, report, CheckMetrics, ErrSkipped)
+		})
+	}
+
+	report := Run(t.Context(), Options{listen: listenFailingAfter(2)})
+	requireCheck(t, report, CheckController, nil)
+	requireCheck(t, report, CheckMetrics, errStub)
+}
+
+func TestDutyCycleRecordsTarget(t *testing.T) {
+	t.Parallel()
+
+	cycle := new(dutyCycle)
+	cycle.SetTarget(0.3)
+
+	if cycle.Target() != 0.3 {
+		t.Fatalf("expected the recorded target, got %v", cycle.Target())
+	}
+}
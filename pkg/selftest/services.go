@@ -0,0 +1,343 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oci-cpu-shaper/pkg/oci"
+)
+
+// The fake instance the services describe.
+const (
+	fakeInstanceID    = "ocid1.instance.oc1.iad.selftest"
+	fakeCompartmentID = "ocid1.compartment.oc1..selftest"
+	fakeRegion        = "us-ashburn-1"
+	fakeOCPUs         = 1
+	fakeP95           = 0.30
+)
+
+const (
+	// imdsAuthorization is the header IMDSv2 requires on every request.
+	imdsAuthorization = "Bearer Oracle"
+	// summarizePath is the Monitoring SummarizeMetricsData endpoint.
+	summarizePath = "/20180401/metrics/actions/summarizeMetricsData"
+	// serviceTimeout bounds each request to and from the fake services.
+	serviceTimeout = 5 * time.Second
+	// certLifetime is how long the fake Monitoring certificate is valid.
+	certLifetime = time.Hour
+	// requestBodyLimit bounds a SummarizeMetricsData request body.
+	requestBodyLimit = 1 << 16
+)
+
+// services are the fake IMDS and Monitoring endpoints of a run and the
+// clients that reach them.
+type services struct {
+	listen     func(network, address string) (net.Listener, error)
+	imds       *server
+	imdsURL    string
+	tls        *server
+	monitoring *monitoringClient
+	http       *http.Client
+}
+
+func startServices(opts Options, roots *x509.CertPool) (*services, error) {
+	certificate, leaf, err := newCertificate(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	started := &services{
+		listen:     opts.listen,
+		imds:       nil,
+		imdsURL:    "",
+		tls:        nil,
+		monitoring: nil,
+		http:       nil,
+	}
+
+	started.imds, err = started.serve(imdsHandler(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	started.tls, err = started.serve(monitoringHandler(), &tls.Config{ //nolint:exhaustruct
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		started.imds.close()
+
+		return nil, err
+	}
+
+	// The fake's certificate is trusted on top of the system roots, so the
+	// handshake verifies a chain the way it does for OCI endpoints.
+	pool := x509.NewCertPool()
+	if roots != nil {
+		pool = roots.Clone()
+	}
+
+	pool.AddCert(leaf)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.TLSClientConfig = &tls.Config{                     //nolint:exhaustruct
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	started.http = &http.Client{Transport: transport, Timeout: serviceTimeout} //nolint:exhaustruct
+	started.imdsURL = started.imds.url + "/opc/v2"
+	started.monitoring = &monitoringClient{
+		http: started.http,
+		url:  started.tls.url + summarizePath,
+	}
+
+	return started, nil
+}
+
+func (s *services) close() {
+	s.imds.close()
+	s.tls.close()
+	s.http.CloseIdleConnections()
+}
+
+// server is an HTTP server on a loopback port, addressed by resolvedHost.
+type server struct {
+	http *http.Server
+	url  string
+}
+
+// serve starts handler on a loopback port, over TLS when tlsConfig is set.
+func (s *services) serve(handler http.Handler, tlsConfig *tls.Config) (*server, error) {
+	listener, err := s.listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen on loopback: %w", err)
+	}
+
+	scheme := "http"
+	port := listener.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
+	}
+
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: serviceTimeout} //nolint:exhaustruct
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	return &server{
+		http: srv,
+		url:  scheme + "://" + net.JoinHostPort(resolvedHost, strconv.Itoa(port)),
+	}, nil
+}
+
+func (s *server) close() {
+	if s != nil {
+		_ = s.http.Close()
+	}
+}
+
+// newCertificate issues a self-signed ECDSA certificate for resolvedHost and
+// the loopback addresses, valid from now for certLifetime.
+func newCertificate(now time.Time) (tls.Certificate, *x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("generate certificate key: %w", err)
+	}
+
+	template := &x509.Certificate{ //nolint:exhaustruct
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: "oci-cpu-shaper self-test"}, //nolint:exhaustruct
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(certLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{resolvedHost},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("create certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("parse certificate: %w", err)
+	}
+
+	return tls.Certificate{ //nolint:exhaustruct
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, leaf, nil
+}
+
+// imdsHandler serves the IMDSv2 instance endpoints the shaper reads at
+// startup.
+func imdsHandler() http.Handler {
+	mux := http.NewServeMux()
+	text := map[string]string{
+		"id":            fakeInstanceID,
+		"compartmentId": fakeCompartmentID,
+		"region":        "iad",
+	}
+
+	for name, value := range text {
+		mux.HandleFunc(
+			"GET /opc/v2/instance/"+name,
+			func(writer http.ResponseWriter, _ *http.Request) {
+				writer.Header().Set("Content-Type", "text/plain")
+				_, _ = writer.Write([]byte(value))
+			},
+		)
+	}
+
+	documents := map[string]any{
+		"regionInfo":   map[string]string{"canonicalRegionName": fakeRegion},
+		"shape-config": map[string]any{"ocpus": fakeOCPUs, "memoryInGBs": 1},
+	}
+
+	for name, document := range documents {
+		mux.HandleFunc(
+			"GET /opc/v2/instance/"+name,
+			func(writer http.ResponseWriter, _ *http.Request) {
+				writeJSON(writer, document)
+			},
+		)
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != imdsAuthorization {
+			http.Error(writer, "missing IMDSv2 authorization", http.StatusUnauthorized)
+
+			return
+		}
+
+		mux.ServeHTTP(writer, request)
+	})
+}
+
+// summarizeRequest and summarizeItem follow the SummarizeMetricsData request
+// and response documents.
+type summarizeRequest struct {
+	Namespace string `json:"namespace"`
+	Query     string `json:"query"`
+}
+
+type summarizeItem struct {
+	AggregatedDatapoints []datapoint `json:"aggregatedDatapoints"`
+}
+
+type datapoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// monitoringHandler answers the P95 CPU query for the fake instance in
+// percent, as Monitoring does.
+func monitoringHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+summarizePath, func(writer http.ResponseWriter, request *http.Request) {
+		var body summarizeRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, requestBodyLimit)).
+			Decode(&body)
+		if err != nil || request.URL.Query().Get("compartmentId") != fakeCompartmentID ||
+			body.Namespace != "oci_computeagent" || body.Query != p95Query(fakeInstanceID) {
+			http.Error(writer, "unexpected query", http.StatusBadRequest)
+
+			return
+		}
+
+		writeJSON(writer, []summarizeItem{{AggregatedDatapoints: []datapoint{
+			{Timestamp: time.Now().UTC().Truncate(time.Minute), Value: fakeP95 * 100},
+		}}})
+	})
+
+	return mux
+}
+
+func writeJSON(writer http.ResponseWriter, payload any) {
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(payload)
+}
+
+func p95Query(resourceID string) string {
+	return oci.NewMetricQuery("CpuUtilization", time.Minute).
+		Where("resourceId", resourceID).
+		Percentile(0.95). //nolint:mnd // the P95 the reclaim policy evaluates
+		String()
+}
+
+// monitoringClient queries the fake Monitoring service over TLS; it
+// implements oci.MetricsClient for the controller.
+type monitoringClient struct {
+	http *http.Client
+	url  string
+}
+
+func (c *monitoringClient) QueryP95CPU(ctx context.Context, resourceID string) (float64, error) {
+	payload, err := json.Marshal(summarizeRequest{
+		Namespace: "oci_computeagent",
+		Query:     p95Query(resourceID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("encode query: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.url+"?compartmentId="+fakeCompartmentID,
+		bytes.NewReader(payload),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("query monitoring: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: monitoring status %d", ErrUnexpected, response.StatusCode)
+	}
+
+	var items []summarizeItem
+
+	err = json.NewDecoder(response.Body).Decode(&items)
+	if err != nil {
+		return 0, fmt.Errorf("decode monitoring response: %w", err)
+	}
+
+	if len(items) == 0 || len(items[0].AggregatedDatapoints) == 0 {
+		return 0, oci.ErrNoMetricsData
+	}
+
+	points := items[0].AggregatedDatapoints
+
+	return points[len(points)-1].Value / 100, nil //nolint:mnd // percent to ratio
+}
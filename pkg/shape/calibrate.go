@@ -0,0 +1,107 @@
+package shape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oci-cpu-shaper/pkg/est"
+)
+
+var errMissingCalibrationSource = errors.New("shape: calibration source is required")
+
+// Calibration compares the host utilisation the pool was asked to add with the
+// utilisation /proc/stat attributed to it.
+type Calibration struct {
+	// Target is the duty cycle the workers ran at and Expected the host-wide
+	// share that should have added, given how many CPUs the workers cover.
+	Target   float64
+	Expected float64
+	// Baseline is the host utilisation with the workers idle and Achieved the
+	// increase measured once they ran at Target.
+	Baseline float64
+	Achieved float64
+}
+
+// Error is the achieved share minus the expected one. Large magnitudes point to
+// coarse timers or workers starved by the scheduler.
+func (c Calibration) Error() float64 {
+	return c.Achieved - c.Expected
+}
+
+// Calibrate runs the started pool at target for the second half of window,
+// after measuring the host at rest during the first, and reports how much host
+// utilisation source saw the workers add. The previous target is restored
+// before it returns.
+func (p *Pool) Calibrate(
+	ctx context.Context,
+	source est.Source,
+	target float64,
+	window time.Duration,
+) (Calibration, error) {
+	if source == nil {
+		return Calibration{}, errMissingCalibrationSource
+	}
+
+	previous := p.Target()
+	defer p.SetTarget(previous)
+
+	p.SetTarget(0)
+
+	baseline, err := measureUtilisation(ctx, source, window/2)
+	if err != nil {
+		return Calibration{}, fmt.Errorf("measure calibration baseline: %w", err)
+	}
+
+	p.SetTarget(target)
+	target = p.Target()
+
+	loaded, err := measureUtilisation(ctx, source, window/2)
+	if err != nil {
+		return Calibration{}, fmt.Errorf("measure calibration load: %w", err)
+	}
+
+	hostCPUs := max(p.hostCPUs, 1)
+	expected := p.duty(target) * float64(min(p.workers, hostCPUs)) / float64(hostCPUs)
+
+	calibration := Calibration{
+		Target:   target,
+		Expected: expected,
+		Baseline: baseline,
+		Achieved: max(loaded-baseline, 0),
+	}
+
+	p.logger.Debug("worker pool calibrated",
+		"target", calibration.Target, "expected", calibration.Expected,
+		"achieved", calibration.Achieved, "baseline", calibration.Baseline)
+
+	return calibration, nil
+}
+
+func measureUtilisation(
+	ctx context.Context,
+	source est.Source,
+	window time.Duration,
+) (float64, error) {
+	start, err := source.Snapshot(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot: %w", err)
+	}
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("calibration interrupted: %w", ctx.Err())
+	case <-timer.C:
+	}
+
+	end, err := source.Snapshot(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot: %w", err)
+	}
+
+	return est.Utilisation(start, end), nil
+}
@@ -0,0 +1,105 @@
+//nolint:testpackage // tests require access to unexported hooks
+package shape
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/est"
+)
+
+var errTestSnapshotFailed = errors.New("snapshot failed")
+
+// scriptedSource replays snapshots and records the pool target seen by each read.
+type scriptedSource struct {
+	pool      *Pool
+	snapshots []est.Snapshot
+	targets   []float64
+	err       error
+}
+
+func (s *scriptedSource) Snapshot(context.Context) (est.Snapshot, error) {
+	if s.err != nil {
+		return est.Snapshot{}, s.err
+	}
+
+	s.targets = append(s.targets, s.pool.Target())
+	snapshot := s.snapshots[0]
+	s.snapshots = s.snapshots[1:]
+
+	return snapshot, nil
+}
+
+func TestPoolCalibrateMeasuresAddedUtilisation(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(2, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	pool.hostCPUs = 4
+	pool.SetTarget(0.3)
+
+	source := &scriptedSource{
+		pool: pool,
+		snapshots: []est.Snapshot{
+			{Idle: 0, Total: 0},
+			{Idle: 90, Total: 100},
+			{Idle: 90, Total: 100},
+			{Idle: 150, Total: 200},
+		},
+	}
+
+	calibration, err := pool.Calibrate(context.Background(), source, 0.5, 2*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Calibrate: %v", err)
+	}
+
+	for name, got := range map[string][2]float64{
+		"target":   {calibration.Target, 0.5},
+		"expected": {calibration.Expected, 0.25},
+		"baseline": {calibration.Baseline, 0.1},
+		"achieved": {calibration.Achieved, 0.3},
+		"error":    {calibration.Error(), 0.05},
+	} {
+		if math.Abs(got[0]-got[1]) > 1e-9 {
+			t.Fatalf("expected %s %v, got %v", name, got[1], got[0])
+		}
+	}
+
+	want := []float64{0, 0, 0.5, 0.5}
+	for i, target := range source.targets {
+		if target != want[i] {
+			t.Fatalf("expected targets %v during calibration, got %v", want, source.targets)
+		}
+	}
+
+	if pool.Target() != 0.3 {
+		t.Fatalf("expected the previous target to be restored, got %v", pool.Target())
+	}
+}
+
+func TestPoolCalibrateReportsSourceErrors(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	_, err = pool.Calibrate(context.Background(), nil, 0.5, time.Millisecond)
+	if !errors.Is(err, errMissingCalibrationSource) {
+		t.Fatalf("expected errMissingCalibrationSource, got %v", err)
+	}
+
+	source := &scriptedSource{pool: pool, err: errTestSnapshotFailed}
+
+	_, err = pool.Calibrate(context.Background(), source, 0.5, time.Millisecond)
+	if !errors.Is(err, errTestSnapshotFailed) {
+		t.Fatalf("expected snapshot error, got %v", err)
+	}
+}
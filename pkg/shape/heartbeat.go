@@ -0,0 +1,154 @@
+package shape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRestartAfterMissedQuanta is how many effective quanta a worker
+	// may go without a heartbeat before the pool replaces it, subject to
+	// MinRestartAge.
+	DefaultRestartAfterMissedQuanta = 1000
+	// MinRestartAge is the shortest heartbeat age that replaces a worker. A
+	// SCHED_IDLE worker on a busy host is routinely starved for seconds, and
+	// its replacement would be starved just the same.
+	MinRestartAge = 30 * time.Second
+	// heartbeatCheckInterval is how often the pool looks for stalled workers.
+	heartbeatCheckInterval = time.Second
+)
+
+// ErrInvalidMissedQuanta signals a negative SetRestartAfterMissedQuanta count.
+var ErrInvalidMissedQuanta = errors.New("shape: missed quanta must not be negative")
+
+// workerSlot is one position in the pool. The worker holding it stores a
+// heartbeat on every cycle; a replacement takes over a fresh slot so the
+// stalled worker, should it ever wake, finds its retire channel closed and
+// exits.
+type workerSlot struct {
+	heartbeat atomic.Int64
+	retire    chan struct{}
+	done      chan struct{}
+	// abandoned is closed once the worker this slot replaced has exited; nil
+	// for the original worker.
+	abandoned <-chan struct{}
+}
+
+func newWorkerSlot(now time.Time, abandoned <-chan struct{}) *workerSlot {
+	slot := &workerSlot{
+		heartbeat: atomic.Int64{},
+		retire:    make(chan struct{}),
+		done:      make(chan struct{}),
+		abandoned: abandoned,
+	}
+	slot.beat(now)
+
+	return slot
+}
+
+func (s *workerSlot) beat(now time.Time) {
+	s.heartbeat.Store(now.UnixNano())
+}
+
+func (s *workerSlot) age(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, s.heartbeat.Load()))
+}
+
+// replaceable reports whether the worker this slot replaced has exited, so at
+// most one stalled worker per slot is left behind.
+func (s *workerSlot) replaceable() bool {
+	if s.abandoned == nil {
+		return true
+	}
+
+	select {
+	case <-s.abandoned:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetRestartAfterMissedQuanta replaces a worker whose heartbeat is older than
+// missed effective quanta and MinRestartAge, for example one blocked in a hung
+// system call, so a partially dead pool does not silently under-deliver the
+// duty cycle. Zero only reports heartbeat ages. It must be called before Start.
+func (p *Pool) SetRestartAfterMissedQuanta(missed int) error {
+	if missed < 0 {
+		return fmt.Errorf("%w, got %d", ErrInvalidMissedQuanta, missed)
+	}
+
+	p.restartAfterMissed = missed
+
+	return nil
+}
+
+// HeartbeatAge reports how long ago the worker with the oldest heartbeat last
+// completed a cycle, or zero before Start.
+func (p *Pool) HeartbeatAge() time.Duration {
+	now := p.nowFunc()
+
+	p.slotsMu.Lock()
+	defer p.slotsMu.Unlock()
+
+	var oldest time.Duration
+
+	for _, slot := range p.slots {
+		oldest = max(oldest, slot.age(now))
+	}
+
+	return oldest
+}
+
+// WorkerRestarts reports how many stalled workers the pool has replaced.
+func (p *Pool) WorkerRestarts() uint64 {
+	return p.restarts.Load()
+}
+
+// watchHeartbeats replaces stalled workers until the pool stops.
+func (p *Pool) watchHeartbeats(ctx context.Context, stop <-chan struct{}) {
+	ticker := p.tickerFactory(heartbeatCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C():
+			p.restartStalledWorkers(ctx, stop)
+		}
+	}
+}
+
+func (p *Pool) restartStalledWorkers(ctx context.Context, stop <-chan struct{}) {
+	limit := max(time.Duration(p.restartAfterMissed)*p.EffectiveQuantum(), p.minRestartAge)
+	now := p.nowFunc()
+
+	p.slotsMu.Lock()
+	defer p.slotsMu.Unlock()
+
+	for index, slot := range p.slots {
+		age := slot.age(now)
+		if age <= limit || !slot.replaceable() {
+			continue
+		}
+
+		close(slot.retire)
+
+		replacement := newWorkerSlot(now, slot.done)
+		p.slots[index] = replacement
+		p.restarts.Add(1)
+
+		p.logger.Warn("worker missed heartbeats; restarting",
+			"worker", index, "age", age, "limit", limit)
+
+		// The replacement's start hook reports its own failures; nobody
+		// waits for the outcome.
+		go p.worker(ctx, make(chan workerStart, 1), stop, replacement)
+	}
+}
@@ -0,0 +1,172 @@
+//nolint:testpackage // tests drive the heartbeat watchdog through internal hooks
+package shape
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+// chanTicker is a ticker the test fires by hand.
+type chanTicker struct {
+	ch chan time.Time
+}
+
+func (t *chanTicker) C() <-chan time.Time { return t.ch }
+func (t *chanTicker) Reset(time.Duration) {}
+func (t *chanTicker) Stop()               {}
+
+func waitFor(t *testing.T, condition func() bool, what string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolRestartsStalledWorkers(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(2, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = pool.SetRestartAfterMissedQuanta(5)
+	if err != nil {
+		t.Fatalf("SetRestartAfterMissedQuanta: %v", err)
+	}
+
+	pool.minRestartAge = 0
+
+	logger := new(logtest.Recorder)
+	pool.SetLogger(logger)
+
+	checks := &chanTicker{ch: make(chan time.Time)}
+	pool.tickerFactory = func(period time.Duration) ticker {
+		if period == heartbeatCheckInterval {
+			return checks
+		}
+
+		return &runtimeTicker{ticker: time.NewTicker(period)}
+	}
+
+	// The first busy window hangs like a blocked system call until released.
+	var (
+		hanging atomic.Bool
+		hung    = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	pool.busyFunc = func(time.Duration) {
+		if hanging.CompareAndSwap(false, true) {
+			close(hung)
+			<-release
+		}
+	}
+	pool.sleepFunc = func(time.Duration) {}
+	pool.SetTarget(0.5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if pool.HeartbeatAge() != 0 {
+		t.Fatalf("expected no heartbeat age before Start, got %v", pool.HeartbeatAge())
+	}
+
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+
+	<-hung
+	waitFor(t, func() bool {
+		return pool.HeartbeatAge() > 20*time.Millisecond
+	}, "a stale heartbeat")
+
+	checks.ch <- time.Now()
+
+	waitFor(t, func() bool {
+		return pool.WorkerRestarts() == 1
+	}, "the stalled worker to be replaced")
+	waitFor(t, func() bool { return pool.HeartbeatAge() < 5*time.Millisecond }, "fresh heartbeats")
+
+	// The replacement keeps beating, so a later check leaves it alone.
+	checks.ch <- time.Now()
+
+	close(release)
+
+	if pool.WorkerRestarts() != 1 {
+		t.Fatalf("expected one restart, got %d", pool.WorkerRestarts())
+	}
+
+	messages := logger.Messages()
+	if len(messages) == 0 || messages[len(messages)-1] != "worker missed heartbeats; restarting" {
+		t.Fatalf("expected the restart to be logged, got %v", messages)
+	}
+}
+
+func TestWorkerSlotKeepsOneStalledWorkerBehind(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	original := newWorkerSlot(now, nil)
+
+	if !original.replaceable() || original.age(now.Add(time.Second)) != time.Second {
+		t.Fatal("expected the original worker to be replaceable with a one second age")
+	}
+
+	replacement := newWorkerSlot(now, original.done)
+	if replacement.replaceable() {
+		t.Fatal("expected a replacement to wait for the stalled worker to exit")
+	}
+
+	close(original.done)
+
+	if !replacement.replaceable() {
+		t.Fatal("expected the replacement to be replaceable once the stalled worker exited")
+	}
+}
+
+func TestSetRestartAfterMissedQuantaRejectsNegativeCounts(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = pool.SetRestartAfterMissedQuanta(-1)
+	if !errors.Is(err, ErrInvalidMissedQuanta) {
+		t.Fatalf("expected ErrInvalidMissedQuanta, got %v", err)
+	}
+}
+
+func TestPoolKeepsStarvedWorkersUntilMinRestartAge(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	pool.nowFunc = func() time.Time { return now }
+	pool.slots = []*workerSlot{newWorkerSlot(now.Add(-MinRestartAge+time.Second), nil)}
+
+	// Over a thousand quanta stale, but younger than the floor.
+	pool.restartStalledWorkers(context.Background(), nil)
+
+	if pool.WorkerRestarts() != 0 {
+		t.Fatalf("expected a starved worker to be kept, got %d restarts", pool.WorkerRestarts())
+	}
+}
@@ -0,0 +1,59 @@
+package shape
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StartFailurePolicy selects how the pool reacts when a worker cannot lower its
+// scheduling priority (SCHED_IDLE) at start.
+type StartFailurePolicy string
+
+const (
+	// StartFailureContinue keeps the worker running at its inherited priority.
+	StartFailureContinue StartFailurePolicy = "continue"
+	// StartFailureFallback renices the worker to 19 so it still yields to most
+	// host work; callers may additionally lower the cgroup weight.
+	StartFailureFallback StartFailurePolicy = "fallback"
+	// StartFailureAbort stops every worker and fails Start.
+	StartFailureAbort StartFailurePolicy = "abort"
+)
+
+// StartOutcomeOK reports that every worker applied its start hook.
+const StartOutcomeOK = "ok"
+
+// Scheduling policies a worker can end up with after its start hook.
+const (
+	// WorkerPolicySchedIdle marks a worker running under SCHED_IDLE.
+	WorkerPolicySchedIdle = "sched_idle"
+	// WorkerPolicyNice marks a worker reniced to 19 because SCHED_IDLE was
+	// refused, for example by a seccomp profile that blocks sched_setscheduler.
+	WorkerPolicyNice = "nice"
+	// WorkerPolicyDefault marks a worker left at the inherited policy and nice
+	// value, relying on the cgroup CPU weight alone.
+	WorkerPolicyDefault = "default"
+)
+
+var (
+	// ErrStartAborted is returned by Start when the abort policy is active and a
+	// worker failed to lower its scheduling priority.
+	ErrStartAborted = errors.New("shape: worker start aborted")
+
+	errUnknownStartFailurePolicy = errors.New("shape: unknown start failure policy")
+)
+
+// ParseStartFailurePolicy validates a policy name; an empty value selects
+// StartFailureContinue.
+func ParseStartFailurePolicy(value string) (StartFailurePolicy, error) {
+	trimmed := StartFailurePolicy(strings.ToLower(strings.TrimSpace(value)))
+
+	switch trimmed {
+	case "":
+		return StartFailureContinue, nil
+	case StartFailureContinue, StartFailureFallback, StartFailureAbort:
+		return trimmed, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errUnknownStartFailurePolicy, value)
+	}
+}
@@ -3,27 +3,66 @@ package shape
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"runtime"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"oci-cpu-shaper/pkg/logging"
 )
 
+// Logger receives worker pool diagnostics; see logging.Logger.
+type Logger = logging.Logger
+
 // Pool drives a group of duty-cycle workers that consume CPU in short quanta.
 type Pool struct {
 	workers int
 	quantum time.Duration
+	// hostCPUs is the CPU count Calibrate spreads the workers' load over.
+	hostCPUs int
+	// dutyScale turns the target into each worker's duty cycle; see
+	// SetHostRelativeTarget.
+	dutyScale float64
 
 	busyFunc  func(time.Duration)
 	sleepFunc func(time.Duration)
 	yieldFunc func()
+	nowFunc   func() time.Time
+
+	// spinCalibrator measures the cost of one spin iteration at Start and
+	// spinCost holds the result in nanoseconds; see countedBusyWait.
+	spinCalibrator func() float64
+	spinCost       float64
 
 	tickerFactory func(time.Duration) ticker
 
 	workerStartHook         func() error
 	workerStartErrorHandler func(error)
+	fallbackHook            func() error
+	startPolicy             StartFailurePolicy
+
+	outcomeMu sync.Mutex
+	outcome   string
+	policies  map[string]int
 
 	targetBits atomic.Uint64
+	busyNanos  atomic.Int64
+	// overshootNanos and coalescing track how far idle sleeps overrun; see
+	// idleSleeper.
+	overshootNanos atomic.Int64
+	coalescing     atomic.Bool
+
+	// slots holds each worker's heartbeat; see watchHeartbeats.
+	slotsMu            sync.Mutex
+	slots              []*workerSlot
+	restartAfterMissed int
+	minRestartAge      time.Duration
+	restarts           atomic.Uint64
+
+	logger Logger
 }
 
 // DefaultQuantum bounds the busy loop to a responsive interval.
@@ -57,13 +96,21 @@ func NewPool(workers int, quantum time.Duration) (*Pool, error) {
 	poolInstance := new(Pool)
 	poolInstance.workers = workers
 	poolInstance.quantum = quantum
-	poolInstance.busyFunc = busyWait
+	poolInstance.hostCPUs = runtime.NumCPU()
+	poolInstance.dutyScale = 1
+	poolInstance.busyFunc = poolInstance.busyWait
+	poolInstance.spinCalibrator = calibrateSpin
 	poolInstance.sleepFunc = time.Sleep
 	poolInstance.yieldFunc = runtime.Gosched
+	poolInstance.nowFunc = time.Now
 	poolInstance.tickerFactory = func(duration time.Duration) ticker {
 		return &runtimeTicker{ticker: time.NewTicker(duration)}
 	}
 	poolInstance.SetWorkerStartErrorHandler(nil)
+	poolInstance.startPolicy = StartFailureContinue
+	poolInstance.restartAfterMissed = DefaultRestartAfterMissedQuanta
+	poolInstance.minRestartAge = MinRestartAge
+	poolInstance.logger = logging.Nop()
 	poolInstance.SetTarget(0)
 
 	configureRootfulHooks(poolInstance)
@@ -71,11 +118,162 @@ func NewPool(workers int, quantum time.Duration) (*Pool, error) {
 	return poolInstance, nil
 }
 
-// Start launches the worker goroutines. The pool terminates when the context is cancelled.
-func (p *Pool) Start(ctx context.Context) {
+// WorkersForShape returns a worker count for a shape of ocpus OCPUs with
+// threadsPerCore hardware threads per core, on a host where allowedCPUs
+// hardware threads are usable. An OCPU is one physical core, so one worker per
+// OCPU avoids counting SMT siblings twice, and a cpuset narrower than the shape
+// caps the count at the cores it spans. It returns 0 when ocpus is unknown.
+func WorkersForShape(ocpus float64, threadsPerCore, allowedCPUs int) int {
+	if ocpus <= 0 {
+		return 0
+	}
+
+	workers := int(math.Ceil(ocpus))
+
+	if allowedCPUs > 0 {
+		threads := max(threadsPerCore, 1)
+		workers = min(workers, (allowedCPUs+threads-1)/threads)
+	}
+
+	return max(workers, 1)
+}
+
+// Start launches the worker goroutines and waits until each has run its start
+// hook. The pool terminates when the context is cancelled. Under
+// StartFailureAbort a failed hook stops every worker and Start returns
+// ErrStartAborted.
+func (p *Pool) Start(ctx context.Context) error {
+	p.calibrateBusyWait()
+
+	results := make(chan workerStart, p.workers)
+	stop := make(chan struct{})
+	slots := make([]*workerSlot, p.workers)
+
+	for index := range slots {
+		slots[index] = newWorkerSlot(p.nowFunc(), nil)
+	}
+
+	p.slotsMu.Lock()
+	p.slots = slots
+	p.slotsMu.Unlock()
+
+	for _, slot := range slots {
+		go p.worker(ctx, results, stop, slot)
+	}
+
+	var firstErr error
+
+	policies := make(map[string]int)
+
 	for range p.workers {
-		go p.worker(ctx)
+		result := <-results
+		policies[result.policy]++
+
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
 	}
+
+	p.outcomeMu.Lock()
+	p.policies = policies
+	p.outcomeMu.Unlock()
+
+	p.logger.Debug("worker pool started",
+		"workers", p.workers, "quantum", p.quantum, "policies", policies)
+
+	if firstErr == nil {
+		p.setStartOutcome(StartOutcomeOK)
+		p.startWatchdog(ctx, stop)
+
+		return nil
+	}
+
+	p.setStartOutcome(string(p.startPolicy))
+	p.logger.Warn("worker start hook failed",
+		"policy", string(p.startPolicy), "error", firstErr)
+
+	if p.startPolicy == StartFailureAbort {
+		close(stop)
+
+		return fmt.Errorf("%w: %w", ErrStartAborted, firstErr)
+	}
+
+	p.startWatchdog(ctx, stop)
+
+	return nil
+}
+
+// Wait blocks until the workers exit after the context passed to Start is
+// cancelled, so the pool can be drained before the rest of the program stops.
+// Workers abandoned as stalled are not waited for. It returns early with the
+// context's error when ctx ends first.
+func (p *Pool) Wait(ctx context.Context) error {
+	p.slotsMu.Lock()
+	slots := slices.Clone(p.slots)
+	p.slotsMu.Unlock()
+
+	for _, slot := range slots {
+		select {
+		case <-slot.done:
+		case <-ctx.Done():
+			return fmt.Errorf("wait for workers: %w", ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) startWatchdog(ctx context.Context, stop <-chan struct{}) {
+	if p.restartAfterMissed > 0 {
+		go p.watchHeartbeats(ctx, stop)
+	}
+}
+
+// SetLogger routes pool diagnostics to logger. A nil logger discards them,
+// which is the default. It must be called before Start.
+func (p *Pool) SetLogger(logger Logger) {
+	p.logger = logging.OrNop(logger)
+}
+
+// SetStartFailurePolicy selects how Start reacts when a worker cannot lower its
+// scheduling priority. It must be called before Start.
+func (p *Pool) SetStartFailurePolicy(policy StartFailurePolicy) {
+	if policy == "" {
+		policy = StartFailureContinue
+	}
+
+	p.startPolicy = policy
+}
+
+// StartOutcome reports StartOutcomeOK when every worker applied its start hook,
+// the name of the StartFailurePolicy that handled a failure otherwise, or an
+// empty string before Start returns.
+func (p *Pool) StartOutcome() string {
+	p.outcomeMu.Lock()
+	defer p.outcomeMu.Unlock()
+
+	return p.outcome
+}
+
+// WorkerPolicies counts the scheduling policy each worker ended up with, keyed
+// by WorkerPolicySchedIdle, WorkerPolicyNice or WorkerPolicyDefault. It is
+// empty before Start returns.
+func (p *Pool) WorkerPolicies() map[string]int {
+	p.outcomeMu.Lock()
+	defer p.outcomeMu.Unlock()
+
+	counts := make(map[string]int, len(p.policies))
+	for policy, count := range p.policies {
+		counts[policy] = count
+	}
+
+	return counts
+}
+
+func (p *Pool) setStartOutcome(outcome string) {
+	p.outcomeMu.Lock()
+	p.outcome = outcome
+	p.outcomeMu.Unlock()
 }
 
 // Workers returns the number of worker goroutines managed by the pool.
@@ -100,7 +298,27 @@ func (p *Pool) SetTarget(target float64) {
 		target = 1
 	}
 
-	p.targetBits.Store(math.Float64bits(target))
+	previous := math.Float64frombits(p.targetBits.Swap(math.Float64bits(target)))
+	if previous != target {
+		p.logger.Debug("duty-cycle target updated", "previous", previous, "target", target)
+	}
+}
+
+// SetHostRelativeTarget makes the target a share of every host CPU rather
+// than of each worker. A pool with fewer workers than CPUs then runs each
+// worker at target × CPUs / workers, up to the whole quantum, so a target adds
+// the same host load whatever the worker count. Call it before Start.
+func (p *Pool) SetHostRelativeTarget(enabled bool) {
+	p.dutyScale = 1
+
+	if enabled && p.hostCPUs > p.workers {
+		p.dutyScale = float64(p.hostCPUs) / float64(p.workers)
+	}
+}
+
+// duty returns the share of each quantum a worker spins for at target.
+func (p *Pool) duty(target float64) float64 {
+	return min(target*p.dutyScale, 1)
 }
 
 // Target returns the current duty-cycle target.
@@ -108,6 +326,13 @@ func (p *Pool) Target() float64 {
 	return math.Float64frombits(p.targetBits.Load())
 }
 
+// BusyTime returns the CPU time the workers have spent spinning since the pool
+// was created, summed across workers, so callers can tell the pool's own load
+// apart from the rest of the host.
+func (p *Pool) BusyTime() time.Duration {
+	return time.Duration(p.busyNanos.Load())
+}
+
 // SetWorkerStartErrorHandler installs a hook invoked when the worker start hook fails.
 //
 // A nil handler resets the hook to a no-op.
@@ -119,44 +344,75 @@ func (p *Pool) SetWorkerStartErrorHandler(handler func(error)) {
 	p.workerStartErrorHandler = handler
 }
 
-func (p *Pool) worker(ctx context.Context) {
+type workerStart struct {
+	policy string
+	err    error
+}
+
+func (p *Pool) worker(
+	ctx context.Context,
+	started chan<- workerStart,
+	stop <-chan struct{},
+	slot *workerSlot,
+) {
+	defer close(slot.done)
+
 	quantum := p.quantum
 	busyFn := p.busyFunc
-	sleepFn := p.sleepFunc
+	sleeper := p.newIdleSleeper()
 	yieldFn := p.yieldFunc
 	startHook := p.workerStartHook
 	startErrorHandler := p.workerStartErrorHandler
 
-	ticker := p.tickerFactory(quantum)
-	defer ticker.Stop()
-
 	if startHook != nil {
-		err := startHook()
-		if err != nil && startErrorHandler != nil {
-			startErrorHandler(err)
-		}
+		// Scheduling policy and nice value are per thread on Linux. Keep the
+		// worker on the thread the hook adjusted; the runtime discards the
+		// thread when the goroutine exits still locked, so the lowered
+		// priority never leaks to other goroutines.
+		runtime.LockOSThread()
 	}
 
+	period := quantum
+
+	ticker := p.tickerFactory(period)
+	defer ticker.Stop()
+
+	policy, err := p.runStartHook(startHook, startErrorHandler)
+	started <- workerStart{policy: policy, err: err}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-stop:
+			return
+		case <-slot.retire:
+			return
 		case <-ticker.C():
-			target := p.Target()
+			slot.beat(p.nowFunc())
+
+			duty := p.duty(p.Target())
 
-			busyDuration := min(time.Duration(target*float64(quantum)), quantum)
+			// Low duty cycles stretch the cycle so the busy window stays
+			// measurable; the ticker keeps each cycle's start on time.
+			cycle := effectiveQuantum(quantum, duty)
+			if cycle != period {
+				period = cycle
+				ticker.Reset(period)
+			}
 
-			idleDuration := quantum - busyDuration
+			busyDuration := min(time.Duration(duty*float64(cycle)), cycle)
+
+			idleDuration := cycle - busyDuration
 
 			if busyDuration > 0 {
 				busyFn(busyDuration)
+				p.busyNanos.Add(int64(busyDuration))
 			} else {
 				yieldFn()
 			}
 
-			if idleDuration > 0 {
-				sleepFn(idleDuration)
-			} else {
+			if idleDuration <= 0 || !sleeper.idle(idleDuration) {
 				yieldFn()
 			}
 
@@ -165,6 +421,36 @@ func (p *Pool) worker(ctx context.Context) {
 	}
 }
 
+func (p *Pool) runStartHook(startHook func() error, errorHandler func(error)) (string, error) {
+	if startHook == nil {
+		return WorkerPolicyDefault, nil
+	}
+
+	err := startHook()
+	if err == nil {
+		return WorkerPolicySchedIdle, nil
+	}
+
+	if errorHandler != nil {
+		errorHandler(err)
+	}
+
+	if p.startPolicy != StartFailureFallback || p.fallbackHook == nil {
+		return WorkerPolicyDefault, err
+	}
+
+	fallbackErr := p.fallbackHook()
+	if fallbackErr != nil {
+		if errorHandler != nil {
+			errorHandler(fmt.Errorf("fallback priority: %w", fallbackErr))
+		}
+
+		return WorkerPolicyDefault, err
+	}
+
+	return WorkerPolicyNice, err
+}
+
 func busyWait(duration time.Duration) {
 	if duration <= 0 {
 		return
@@ -178,6 +464,7 @@ func busyWait(duration time.Duration) {
 
 type ticker interface {
 	C() <-chan time.Time
+	Reset(period time.Duration)
 	Stop()
 }
 
@@ -189,6 +476,10 @@ func (t *runtimeTicker) C() <-chan time.Time {
 	return t.ticker.C
 }
 
+func (t *runtimeTicker) Reset(period time.Duration) {
+	t.ticker.Reset(period)
+}
+
 func (t *runtimeTicker) Stop() {
 	t.ticker.Stop()
 }
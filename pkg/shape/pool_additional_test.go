@@ -103,3 +103,73 @@ func TestNewPoolClampsQuantumWithinBounds(t *testing.T) {
 		t.Fatalf("expected quantum to clamp to %s, got %s", maxQuantum, got)
 	}
 }
+
+func TestWorkersForShape(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		ocpus          float64
+		threadsPerCore int
+		allowedCPUs    int
+		want           int
+	}{
+		{"smt host", 2, 2, 4, 2},
+		{"ampere", 4, 1, 4, 4},
+		{"fractional ocpus", 1.5, 2, 4, 2},
+		{"restricted cpuset", 8, 2, 4, 2},
+		{"single thread cpuset", 4, 2, 1, 1},
+		{"unknown threads", 2, 0, 1, 1},
+		{"unknown host", 3, 2, 0, 3},
+		{"unknown shape", 0, 2, 4, 0},
+	}
+
+	for _, tc := range cases {
+		got := WorkersForShape(tc.ocpus, tc.threadsPerCore, tc.allowedCPUs)
+		if got != tc.want {
+			t.Fatalf("%s: WorkersForShape() = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPoolHostRelativeTargetScalesDutyCycle(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(2, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	pool.hostCPUs = 4
+
+	if got := pool.duty(0.3); got != 0.3 {
+		t.Fatalf("expected the target as the duty cycle by default, got %v", got)
+	}
+
+	pool.SetHostRelativeTarget(true)
+
+	for target, want := range map[float64]float64{0: 0, 0.2: 0.4, 0.5: 1, 0.8: 1} {
+		if got := pool.duty(target); got != want {
+			t.Fatalf("expected duty %v at target %v, got %v", want, target, got)
+		}
+	}
+
+	pool.SetTarget(0.01)
+
+	if got := pool.EffectiveQuantum(); got != 10*time.Millisecond {
+		t.Fatalf("expected the effective quantum to follow the scaled duty, got %v", got)
+	}
+
+	pool.SetHostRelativeTarget(false)
+
+	if got := pool.duty(0.2); got != 0.2 {
+		t.Fatalf("expected disabling to restore the target, got %v", got)
+	}
+
+	pool.hostCPUs = 1
+	pool.SetHostRelativeTarget(true)
+
+	if got := pool.duty(0.2); got != 0.2 {
+		t.Fatalf("expected no scaling with a worker per CPU, got %v", got)
+	}
+}
@@ -115,6 +115,13 @@ func TestPoolLoad24hEquivalent(t *testing.T) {
 
 	pool.tickerFactory = scheduler.newTicker
 
+	// The scheduler hands out one ticker per worker; keep the heartbeat
+	// watchdog from taking one.
+	err = pool.SetRestartAfterMissedQuanta(0)
+	if err != nil {
+		t.Fatalf("unexpected error disabling worker restarts: %v", err)
+	}
+
 	var (
 		busyTotal  atomic.Int64
 		idleTotal  atomic.Int64
@@ -137,7 +144,10 @@ func TestPoolLoad24hEquivalent(t *testing.T) {
 	startMetrics := captureProcessMetrics(t)
 	wallStart := time.Now()
 
-	pool.Start(ctx)
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
 	pool.SetTarget(dutyTarget)
 
 	<-scheduler.Ready()
@@ -310,6 +320,8 @@ func (t *manualTicker) C() <-chan time.Time {
 	return t.ch
 }
 
+func (t *manualTicker) Reset(time.Duration) {}
+
 func (t *manualTicker) Stop() {
 	t.stopOnce.Do(func() {
 		close(t.stopCh)
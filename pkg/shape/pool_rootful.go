@@ -4,4 +4,5 @@ package shape
 
 func configureRootfulHooks(pool *Pool) {
 	pool.workerStartHook = trySchedIdle
+	pool.fallbackHook = trySetNice
 }
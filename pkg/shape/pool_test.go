@@ -10,7 +10,10 @@ import (
 	"time"
 )
 
-var errTestSchedIdleDenied = errors.New("sched idle denied")
+var (
+	errTestSchedIdleDenied = errors.New("sched idle denied")
+	errTestNiceDenied      = errors.New("setpriority denied")
+)
 
 func TestPoolAppliesDutyCycle(t *testing.T) {
 	t.Parallel()
@@ -44,7 +47,10 @@ func TestPoolAppliesDutyCycle(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	pool.Start(ctx)
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
 	pool.SetTarget(0.4)
 
 	time.Sleep(20 * time.Millisecond)
@@ -55,6 +61,15 @@ func TestPoolAppliesDutyCycle(t *testing.T) {
 	defer metricsMu.Unlock()
 
 	assertBusyAndSleepDurations(t, busyDurations, sleepDurations, 5*time.Millisecond)
+
+	var spun time.Duration
+	for _, busy := range busyDurations {
+		spun += busy
+	}
+
+	if pool.BusyTime() != spun {
+		t.Fatalf("expected BusyTime %v to match spun time %v", pool.BusyTime(), spun)
+	}
 }
 
 func assertBusyAndSleepDurations(
@@ -113,7 +128,10 @@ func TestPoolYieldsUnderZeroTarget(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	pool.Start(ctx)
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
 	pool.SetTarget(0)
 
 	time.Sleep(5 * time.Millisecond)
@@ -178,7 +196,10 @@ func TestPoolWorkerStartHookSuccess(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	pool.Start(ctx)
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
 
 	done := make(chan struct{})
 
@@ -203,6 +224,14 @@ func TestPoolWorkerStartHookSuccess(t *testing.T) {
 	if got := handlerCount.Load(); got != 0 {
 		t.Fatalf("expected no error handler invocations, got %d", got)
 	}
+
+	if got := pool.StartOutcome(); got != StartOutcomeOK {
+		t.Fatalf("expected ok outcome, got %q", got)
+	}
+
+	if got := pool.WorkerPolicies()[WorkerPolicySchedIdle]; got != workers {
+		t.Fatalf("expected %d sched_idle workers, got %d", workers, got)
+	}
 }
 
 //nolint:funlen // integration-style test ensures handler runs per worker
@@ -245,7 +274,10 @@ func TestPoolWorkerStartHookErrorPropagates(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	pool.Start(ctx)
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
 
 	done := make(chan struct{})
 
@@ -271,3 +303,158 @@ func TestPoolWorkerStartHookErrorPropagates(t *testing.T) {
 		t.Fatalf("expected handler count %d, got %d", workers, got)
 	}
 }
+
+func TestPoolStartFailurePolicies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		policy        StartFailurePolicy
+		wantErr       bool
+		wantFallbacks int32
+		wantPolicy    string
+	}{
+		{policy: StartFailureContinue, wantPolicy: WorkerPolicyDefault},
+		{policy: StartFailureFallback, wantFallbacks: 2, wantPolicy: WorkerPolicyNice},
+		{policy: StartFailureAbort, wantErr: true, wantPolicy: WorkerPolicyDefault},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.policy), func(t *testing.T) {
+			t.Parallel()
+
+			pool, err := NewPool(2, time.Millisecond)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var fallbacks atomic.Int32
+
+			pool.workerStartHook = func() error { return errTestSchedIdleDenied }
+			pool.fallbackHook = func() error {
+				fallbacks.Add(1)
+
+				return nil
+			}
+			pool.sleepFunc = func(time.Duration) {}
+			pool.yieldFunc = func() {}
+			pool.SetStartFailurePolicy(testCase.policy)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err = pool.Start(ctx)
+			if gotErr := errors.Is(err, ErrStartAborted); gotErr != testCase.wantErr {
+				t.Fatalf("unexpected start error: %v", err)
+			}
+
+			if testCase.wantErr && !errors.Is(err, errTestSchedIdleDenied) {
+				t.Fatalf("expected hook error to be wrapped, got %v", err)
+			}
+
+			if got := fallbacks.Load(); got != testCase.wantFallbacks {
+				t.Fatalf("expected %d fallbacks, got %d", testCase.wantFallbacks, got)
+			}
+
+			if got := pool.StartOutcome(); got != string(testCase.policy) {
+				t.Fatalf("expected outcome %q, got %q", testCase.policy, got)
+			}
+
+			if got := pool.WorkerPolicies(); got[testCase.wantPolicy] != 2 || len(got) != 1 {
+				t.Fatalf("expected both workers on %q, got %v", testCase.wantPolicy, got)
+			}
+		})
+	}
+}
+
+func TestParseStartFailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParseStartFailurePolicy(" Fallback ")
+	if err != nil || policy != StartFailureFallback {
+		t.Fatalf("expected fallback policy, got %q (%v)", policy, err)
+	}
+
+	policy, err = ParseStartFailurePolicy("")
+	if err != nil || policy != StartFailureContinue {
+		t.Fatalf("expected continue by default, got %q (%v)", policy, err)
+	}
+
+	_, err = ParseStartFailurePolicy("retry")
+	if !errors.Is(err, errUnknownStartFailurePolicy) {
+		t.Fatalf("expected unknown policy error, got %v", err)
+	}
+}
+
+func TestPoolReportsDefaultPolicyWhenFallbackFails(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var handled []error
+
+	pool.workerStartHook = func() error { return errTestSchedIdleDenied }
+	pool.fallbackHook = func() error { return errTestNiceDenied }
+	pool.workerStartErrorHandler = func(err error) { handled = append(handled, err) }
+	pool.sleepFunc = func(time.Duration) {}
+	pool.yieldFunc = func() {}
+	pool.SetStartFailurePolicy(StartFailureFallback)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+
+	if got := pool.WorkerPolicies()[WorkerPolicyDefault]; got != 1 {
+		t.Fatalf("expected worker to stay on the default policy, got %v", pool.WorkerPolicies())
+	}
+
+	if len(handled) != 2 || !errors.Is(handled[1], errTestNiceDenied) {
+		t.Fatalf("expected sched_idle and fallback errors to be reported, got %v", handled)
+	}
+}
+
+func TestPoolWaitDrainsWorkers(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(2, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool.sleepFunc = func(time.Duration) {}
+	pool.yieldFunc = func() {}
+
+	err = pool.Wait(t.Context())
+	if err != nil {
+		t.Fatalf("expected an unstarted pool to have nothing to wait for, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+
+	expired, stop := context.WithTimeout(t.Context(), time.Millisecond)
+	defer stop()
+
+	err = pool.Wait(expired)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected running workers to outlast the wait, got %v", err)
+	}
+
+	cancel()
+
+	err = pool.Wait(t.Context())
+	if err != nil {
+		t.Fatalf("expected the workers to exit after cancellation, got %v", err)
+	}
+}
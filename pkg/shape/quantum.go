@@ -0,0 +1,39 @@
+package shape
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// minBusyWindow is the shortest busy window a duty cycle aims for. Shorter
+	// bursts are lost to scheduling noise, so the delivered load drifts from
+	// the target.
+	minBusyWindow = 200 * time.Microsecond
+	// maxEffectiveQuantum bounds how far low targets stretch a duty cycle, so
+	// a target change still takes effect within this long.
+	maxEffectiveQuantum = 50 * time.Millisecond
+)
+
+// effectiveQuantum returns the duty cycle a worker runs at target: the
+// configured quantum, lengthened by whole quanta while its busy window would
+// be shorter than minBusyWindow, up to maxEffectiveQuantum. At a 1ms quantum a
+// target of 0.02 runs 10ms cycles with 200µs busy windows instead of 20µs
+// ones. A zero target keeps the quantum, as there is nothing to measure.
+func effectiveQuantum(quantum time.Duration, target float64) time.Duration {
+	busy := target * float64(quantum)
+	if busy <= 0 || busy >= float64(minBusyWindow) {
+		return quantum
+	}
+
+	quanta := math.Ceil(float64(minBusyWindow) / busy)
+	limit := float64(max(maxEffectiveQuantum/quantum, 1))
+
+	return time.Duration(math.Min(quanta, limit)) * quantum
+}
+
+// EffectiveQuantum reports the duty cycle the workers run at the current
+// target; see Quantum for the configured one.
+func (p *Pool) EffectiveQuantum() time.Duration {
+	return effectiveQuantum(p.quantum, p.duty(p.Target()))
+}
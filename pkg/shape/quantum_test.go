@@ -0,0 +1,99 @@
+//nolint:testpackage // tests drive the worker loop through internal hooks
+package shape
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEffectiveQuantumScalesWithTarget(t *testing.T) {
+	t.Parallel()
+
+	const ms = time.Millisecond
+
+	tests := map[string]struct {
+		quantum time.Duration
+		target  float64
+		want    time.Duration
+	}{
+		"idle":               {quantum: ms, target: 0, want: ms},
+		"measurable":         {quantum: ms, target: 0.25, want: ms},
+		"at the minimum":     {quantum: ms, target: 0.2, want: ms},
+		"low target":         {quantum: ms, target: 0.02, want: 10 * ms},
+		"rounded up":         {quantum: ms, target: 0.03, want: 7 * ms},
+		"long quantum":       {quantum: 5 * ms, target: 0.02, want: 10 * ms},
+		"bounded":            {quantum: ms, target: 0.001, want: maxEffectiveQuantum},
+		"bounded by quantum": {quantum: 3 * ms, target: 1e-9, want: 48 * ms},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := effectiveQuantum(test.quantum, test.target)
+			if got != test.want {
+				t.Fatalf("effectiveQuantum(%v, %v) = %v, want %v",
+					test.quantum, test.target, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPoolStretchesCyclesAtLowTargets(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool.SetTarget(0.02)
+
+	if pool.EffectiveQuantum() != 10*time.Millisecond {
+		t.Fatalf("expected a 10ms effective quantum, got %v", pool.EffectiveQuantum())
+	}
+
+	var (
+		mu     sync.Mutex
+		busy   []time.Duration
+		sleeps []time.Duration
+	)
+
+	pool.busyFunc = func(duration time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		busy = append(busy, duration)
+	}
+	pool.sleepFunc = func(duration time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sleeps = append(sleeps, duration)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = pool.Start(ctx)
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+	time.Sleep(2 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assertBusyAndSleepDurations(t, busy, sleeps, 10*time.Millisecond)
+
+	// One cycle per tick: a ticker left at the 1ms quantum would have run
+	// about sixty.
+	if len(busy) > 20 {
+		t.Fatalf("expected about six 10ms cycles, got %d", len(busy))
+	}
+}
@@ -5,18 +5,28 @@ package shape
 import (
 	"sync"
 
-	"golang.org/x/sys/unix"
+	"oci-cpu-shaper/pkg/sched"
 )
 
 var (
 	schedSetSchedulerMu sync.RWMutex
-	schedSetScheduler   = unix.SchedSetScheduler
+	schedSetIdle        = sched.SetIdle
+	schedSetNice        = sched.SetNice
 )
 
 func trySchedIdle() error {
 	schedSetSchedulerMu.RLock()
-	fn := schedSetScheduler
+	fn := schedSetIdle
 	schedSetSchedulerMu.RUnlock()
 
-	return fn(0, unix.SCHED_IDLE, &unix.SchedParam{})
+	return fn() //nolint:wrapcheck // sched names the failing call
+}
+
+// trySetNice lowers the calling thread to nice 19, matching trySchedIdle.
+func trySetNice() error {
+	schedSetSchedulerMu.RLock()
+	fn := schedSetNice
+	schedSetSchedulerMu.RUnlock()
+
+	return fn(sched.NiceLowest) //nolint:wrapcheck // sched names the failing call
 }
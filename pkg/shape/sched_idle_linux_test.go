@@ -7,38 +7,28 @@ import (
 	"testing"
 
 	"golang.org/x/sys/unix"
+
+	"oci-cpu-shaper/pkg/sched"
 )
 
 func TestTrySchedIdleSuccess(t *testing.T) {
 	t.Parallel()
 
 	schedSetSchedulerMu.Lock()
-	original := schedSetScheduler
+	original := schedSetIdle
 	schedSetSchedulerMu.Unlock()
 
 	t.Cleanup(func() {
 		schedSetSchedulerMu.Lock()
-		schedSetScheduler = original
+		schedSetIdle = original
 		schedSetSchedulerMu.Unlock()
 	})
 
 	var called bool
 	schedSetSchedulerMu.Lock()
-	schedSetScheduler = func(pid int, policy int, param *unix.SchedParam) error {
+	schedSetIdle = func() error {
 		called = true
 
-		if pid != 0 {
-			t.Fatalf("expected pid 0, got %d", pid)
-		}
-
-		if policy != unix.SCHED_IDLE {
-			t.Fatalf("expected SCHED_IDLE policy, got %d", policy)
-		}
-
-		if param == nil {
-			t.Fatalf("expected non-nil sched param")
-		}
-
 		return nil
 	}
 	schedSetSchedulerMu.Unlock()
@@ -48,7 +38,7 @@ func TestTrySchedIdleSuccess(t *testing.T) {
 	}
 
 	if !called {
-		t.Fatalf("expected schedSetScheduler to be called")
+		t.Fatalf("expected sched.SetIdle to be called")
 	}
 }
 
@@ -56,17 +46,17 @@ func TestTrySchedIdleEPERM(t *testing.T) {
 	t.Parallel()
 
 	schedSetSchedulerMu.Lock()
-	original := schedSetScheduler
+	original := schedSetIdle
 	schedSetSchedulerMu.Unlock()
 
 	t.Cleanup(func() {
 		schedSetSchedulerMu.Lock()
-		schedSetScheduler = original
+		schedSetIdle = original
 		schedSetSchedulerMu.Unlock()
 	})
 
 	schedSetSchedulerMu.Lock()
-	schedSetScheduler = func(int, int, *unix.SchedParam) error {
+	schedSetIdle = func() error {
 		return unix.EPERM
 	}
 	schedSetSchedulerMu.Unlock()
@@ -77,3 +67,35 @@ func TestTrySchedIdleEPERM(t *testing.T) {
 		t.Fatalf("expected EPERM, got %v", err)
 	}
 }
+
+func TestTrySetNiceLowersCallingThread(t *testing.T) {
+	t.Parallel()
+
+	schedSetSchedulerMu.Lock()
+	original := schedSetNice
+	schedSetSchedulerMu.Unlock()
+
+	t.Cleanup(func() {
+		schedSetSchedulerMu.Lock()
+		schedSetNice = original
+		schedSetSchedulerMu.Unlock()
+	})
+
+	var gotPrio int
+
+	schedSetSchedulerMu.Lock()
+	schedSetNice = func(prio int) error {
+		gotPrio = prio
+
+		return nil
+	}
+	schedSetSchedulerMu.Unlock()
+
+	if err := trySetNice(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPrio != sched.NiceLowest {
+		t.Fatalf("unexpected nice value %d", gotPrio)
+	}
+}
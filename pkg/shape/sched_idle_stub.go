@@ -5,3 +5,7 @@ package shape
 func trySchedIdle() error {
 	return nil
 }
+
+func trySetNice() error {
+	return nil
+}
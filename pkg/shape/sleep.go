@@ -0,0 +1,90 @@
+package shape
+
+import "time"
+
+const (
+	// overshootWeight is the weight of each new sample in the moving average of
+	// how far idle sleeps overrun.
+	overshootWeight = 0.2
+	// coalescingShare is the share of a quantum the average overshoot must
+	// exceed before the pool reports that the host coalesces timers.
+	coalescingShare = 0.1
+)
+
+// idleSleeper shortens a worker's idle sleeps by how far earlier ones overran.
+//
+// Without high-resolution timers, or on a NO_HZ idle CPU, the kernel wakes a
+// sleeping worker on its next tick rather than when the sleep ends, so a 600µs
+// sleep can take several milliseconds. The quantum then stretches by the
+// overshoot and the busy share falls below the target. Requesting the idle time
+// minus the average overshoot lets the worker wake when the quantum ends. Each
+// worker owns its sleeper, so it needs no locking.
+type idleSleeper struct {
+	pool      *Pool
+	sleep     func(time.Duration)
+	now       func() time.Time
+	overshoot float64
+	primed    bool
+}
+
+func (p *Pool) newIdleSleeper() *idleSleeper {
+	return &idleSleeper{
+		pool:      p,
+		sleep:     p.sleepFunc,
+		now:       p.nowFunc,
+		overshoot: 0,
+		primed:    false,
+	}
+}
+
+// idle sleeps for about duration and reports whether it slept. When the average
+// overshoot covers all of duration it skips the sleep and leaves the wait to the
+// quantum ticker, decaying the average so that a later quantum probes the timer
+// again once the host stops coalescing.
+func (s *idleSleeper) idle(duration time.Duration) bool {
+	requested := duration - time.Duration(s.overshoot)
+	if requested <= 0 {
+		s.overshoot -= overshootWeight * s.overshoot
+
+		return false
+	}
+
+	start := s.now()
+	s.sleep(requested)
+
+	sample := float64(max(s.now().Sub(start)-requested, 0))
+
+	if s.primed {
+		s.overshoot += overshootWeight * (sample - s.overshoot)
+	} else {
+		s.overshoot = sample
+		s.primed = true
+	}
+
+	s.pool.recordSleepOvershoot(time.Duration(s.overshoot))
+
+	return true
+}
+
+// recordSleepOvershoot publishes a worker's average overshoot and logs once
+// when it first shows that the host coalesces timers.
+func (p *Pool) recordSleepOvershoot(overshoot time.Duration) {
+	p.overshootNanos.Store(int64(overshoot))
+
+	if float64(overshoot) < coalescingShare*float64(p.quantum) {
+		return
+	}
+
+	if p.coalescing.CompareAndSwap(false, true) {
+		p.logger.Info("timer coalescing detected; shortening worker sleeps",
+			"overshoot", overshoot, "quantum", p.quantum)
+	}
+}
+
+// SleepOvershoot reports how far the workers' idle sleeps overrun on average,
+// as last measured by any worker. The workers already request sleeps shorter
+// by this much; a value that stays near the quantum means the host wakes
+// sleepers no more often than its timer tick.
+func (p *Pool) SleepOvershoot() time.Duration {
+	return time.Duration(p.overshootNanos.Load())
+}
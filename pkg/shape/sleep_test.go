@@ -0,0 +1,154 @@
+//nolint:testpackage // tests require access to the idle sleeper
+package shape
+
+import (
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+// coalescingClock simulates a kernel that wakes sleepers only on its tick: a
+// sleep lasts until the next multiple of tick.
+type coalescingClock struct {
+	now       time.Time
+	tick      time.Duration
+	requested []time.Duration
+}
+
+func (c *coalescingClock) Now() time.Time {
+	return c.now
+}
+
+func (c *coalescingClock) Sleep(duration time.Duration) {
+	c.requested = append(c.requested, duration)
+	wake := c.now.Add(duration)
+
+	remainder := time.Duration(wake.UnixNano()) % c.tick
+	if remainder > 0 {
+		wake = wake.Add(c.tick - remainder)
+	}
+
+	c.now = wake
+}
+
+func TestIdleSleeperCompensatesForTimerCoalescing(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, 4*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger := new(logtest.Recorder)
+	pool.SetLogger(logger)
+
+	clock := &coalescingClock{
+		now:       time.Unix(1_700_000_000, 0),
+		tick:      time.Millisecond,
+		requested: nil,
+	}
+	pool.sleepFunc = clock.Sleep
+	pool.nowFunc = clock.Now
+
+	sleeper := pool.newIdleSleeper()
+
+	// Uncompensated, every 2.5ms sleep ends on the next 1ms tick, 0.5ms late.
+	for range 20 {
+		sleeper.idle(2500 * time.Microsecond)
+	}
+
+	start := clock.Now()
+
+	for range 100 {
+		if !sleeper.idle(2500 * time.Microsecond) {
+			t.Fatal("expected the sleeper to sleep")
+		}
+	}
+
+	mean := clock.Now().Sub(start) / 100
+	if mean < 2400*time.Microsecond || mean > 2600*time.Microsecond {
+		t.Fatalf("expected compensated sleeps to average 2.5ms, got %s", mean)
+	}
+
+	if pool.SleepOvershoot() <= 0 {
+		t.Fatal("expected the overshoot to be reported")
+	}
+
+	messages := logger.Messages()
+	if len(messages) != 1 || messages[0] != "timer coalescing detected; shortening worker sleeps" {
+		t.Fatalf("expected a single coalescing report, got %v", messages)
+	}
+}
+
+func TestIdleSleeperSkipsSleepsShorterThanOvershoot(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock := &coalescingClock{
+		now:       time.Unix(1_700_000_000, 0),
+		tick:      4 * time.Millisecond,
+		requested: nil,
+	}
+	pool.sleepFunc = clock.Sleep
+	pool.nowFunc = clock.Now
+
+	sleeper := pool.newIdleSleeper()
+
+	if !sleeper.idle(500 * time.Microsecond) {
+		t.Fatal("expected the first sleep to measure the timer")
+	}
+
+	// A 4ms tick overruns every sub-quantum sleep, so the worker leaves the
+	// wait to the quantum ticker until the average decays.
+	skipped := 0
+
+	for !sleeper.idle(500 * time.Microsecond) {
+		skipped++
+	}
+
+	if skipped == 0 || len(clock.requested) != 2 {
+		t.Fatalf("expected skipped sleeps before a probe, got %d skips and %d sleeps",
+			skipped, len(clock.requested))
+	}
+}
+
+func TestIdleSleeperLeavesAccurateTimersAlone(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger := new(logtest.Recorder)
+	pool.SetLogger(logger)
+
+	clock := &coalescingClock{
+		now:       time.Unix(1_700_000_000, 0),
+		tick:      time.Nanosecond,
+		requested: nil,
+	}
+	pool.sleepFunc = clock.Sleep
+	pool.nowFunc = clock.Now
+
+	sleeper := pool.newIdleSleeper()
+
+	for range 10 {
+		sleeper.idle(600 * time.Microsecond)
+	}
+
+	for _, requested := range clock.requested {
+		if requested != 600*time.Microsecond {
+			t.Fatalf("expected unshortened sleeps, got %s", requested)
+		}
+	}
+
+	if pool.SleepOvershoot() != 0 || len(logger.Messages()) != 0 {
+		t.Fatalf("expected no overshoot, got %s and %v", pool.SleepOvershoot(), logger.Messages())
+	}
+}
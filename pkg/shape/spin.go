@@ -0,0 +1,124 @@
+package shape
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// spinCalibrationRounds and spinCalibrationRound size the spin loop
+	// measurement Start takes. The fastest round wins, since preemption only
+	// ever makes a round look slower.
+	spinCalibrationRounds = 5
+	spinCalibrationRound  = 2 * time.Millisecond
+	// spinBatch is how many iterations run between clock reads while
+	// calibrating, so the clock's own cost stays out of the measurement.
+	spinBatch = 1024
+	// spinChunk is how much counted spinning runs between wall clock checks,
+	// capped at spinMaxChunk iterations should calibration have undershot.
+	spinChunk    = 100 * time.Microsecond
+	spinMaxChunk = 1 << 20
+	// spinOverrunFactor bounds a counted spin in wall time, in case the core
+	// slowed down after calibration or the worker was descheduled for long.
+	spinOverrunFactor = 4
+	// lcgMultiplier and lcgIncrement drive the spin loop body; any
+	// multiply-add chain the compiler cannot fold would do.
+	lcgMultiplier = 6364136223846793005
+	lcgIncrement  = 1442695040888963407
+)
+
+// spinSink keeps the spin loop's result observable so the compiler cannot
+// drop the loop.
+var spinSink atomic.Uint64 //nolint:gochecknoglobals // optimisation barrier
+
+// spin runs iterations of a fixed multiply-add chain.
+func spin(iterations int64) {
+	state := spinSink.Load()
+	for range iterations {
+		state = state*lcgMultiplier + lcgIncrement
+	}
+
+	spinSink.Store(state)
+}
+
+// calibrateSpin returns the cost of one spin iteration in nanoseconds, or zero
+// when the clock could not resolve it.
+func calibrateSpin() float64 {
+	best := math.Inf(1)
+
+	for range spinCalibrationRounds {
+		var iterations int64
+
+		start := time.Now()
+		elapsed := time.Duration(0)
+
+		for elapsed < spinCalibrationRound {
+			spin(spinBatch)
+			iterations += spinBatch
+			elapsed = time.Since(start)
+		}
+
+		best = min(best, float64(elapsed)/float64(iterations))
+	}
+
+	if best <= 0 || math.IsInf(best, 0) {
+		return 0
+	}
+
+	return best
+}
+
+// countedBusyWait spins for duration by running the number of iterations
+// calibration says it takes, instead of polling the clock. The pool then
+// consumes the CPU time it was asked to even when the clock is slow to read
+// (as on some ARM cores) or the worker is briefly descheduled, which a
+// deadline-based loop would count as busy time.
+func countedBusyWait(duration time.Duration, nanosPerIteration float64) {
+	if duration <= 0 {
+		return
+	}
+
+	remaining := int64(float64(duration) / nanosPerIteration)
+	chunk := min(max(int64(float64(spinChunk)/nanosPerIteration), 1), spinMaxChunk)
+	deadline := time.Now().Add(spinOverrunFactor * duration)
+
+	for remaining > 0 {
+		batch := min(chunk, remaining)
+		spin(batch)
+		remaining -= batch
+
+		if remaining > 0 && time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+// busyWait spins with counted iterations once Start has calibrated the loop,
+// and on the clock before that or when calibration failed.
+func (p *Pool) busyWait(duration time.Duration) {
+	cost := p.spinCost
+	if cost <= 0 {
+		busyWait(duration)
+
+		return
+	}
+
+	countedBusyWait(duration, cost)
+}
+
+// calibrateBusyWait measures the spin loop once, before the workers start.
+func (p *Pool) calibrateBusyWait() {
+	if p.spinCalibrator == nil || p.spinCost > 0 {
+		return
+	}
+
+	p.spinCost = p.spinCalibrator()
+	if p.spinCost <= 0 {
+		p.logger.Warn("spin loop calibration failed; busy periods follow the clock")
+
+		return
+	}
+
+	p.logger.Debug("spin loop calibrated", "nanosPerIteration", p.spinCost)
+}
@@ -0,0 +1,103 @@
+//nolint:testpackage // tests require access to unexported hooks
+package shape
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrateSpinMeasuresIterationCost(t *testing.T) {
+	t.Parallel()
+
+	cost := calibrateSpin()
+	if cost <= 0 || cost > float64(time.Microsecond) {
+		t.Fatalf("expected a sub-microsecond spin iteration cost, got %vns", cost)
+	}
+}
+
+func TestCountedBusyWaitSpinsForDuration(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	countedBusyWait(0, 1)
+
+	if elapsed := time.Since(start); elapsed > time.Millisecond {
+		t.Fatalf("countedBusyWait should return immediately for zero duration, took %v", elapsed)
+	}
+
+	cost := calibrateSpin()
+	start = time.Now()
+
+	countedBusyWait(500*time.Microsecond, cost)
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("countedBusyWait exceeded expected duration, took %v", elapsed)
+	}
+}
+
+func TestCountedBusyWaitStopsAtOverrunDeadline(t *testing.T) {
+	t.Parallel()
+
+	// A cost far below the real one asks for far more iterations than fit in
+	// the wall-time guard.
+	start := time.Now()
+
+	countedBusyWait(time.Millisecond, 1e-6)
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("countedBusyWait ignored its overrun deadline, took %v", elapsed)
+	}
+}
+
+func TestPoolCalibratesBusyWaitOnce(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := 0
+	pool.spinCalibrator = func() float64 {
+		calls++
+
+		return 2.5
+	}
+
+	pool.calibrateBusyWait()
+	pool.calibrateBusyWait()
+
+	if calls != 1 || pool.spinCost != 2.5 {
+		t.Fatalf("expected one calibration of 2.5ns, got %d calls and %vns", calls, pool.spinCost)
+	}
+
+	pool.busyFunc(100 * time.Microsecond)
+}
+
+func TestPoolBusyWaitFallsBackToClockWithoutCalibration(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(1, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool.spinCalibrator = func() float64 { return 0 }
+	pool.calibrateBusyWait()
+
+	if pool.spinCost != 0 {
+		t.Fatalf("expected failed calibration to leave the cost unset, got %vns", pool.spinCost)
+	}
+
+	start := time.Now()
+
+	pool.busyFunc(200 * time.Microsecond)
+
+	if elapsed := time.Since(start); elapsed < 200*time.Microsecond {
+		t.Fatalf("clock-based busy wait returned early after %v", elapsed)
+	}
+
+	pool.spinCalibrator = nil
+	pool.calibrateBusyWait()
+}
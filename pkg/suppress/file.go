@@ -0,0 +1,121 @@
+// Package suppress turns external signals into controller suppression
+// requests so planned heavy work never competes with synthetic load.
+package suppress
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// SourceFile identifies requests raised by the signal file.
+	SourceFile = "file"
+	// DefaultPollInterval is how often the signal file is checked.
+	DefaultPollInterval = 5 * time.Second
+	// maxSignalBytes bounds how much of the signal file is read for a duration.
+	maxSignalBytes = 64
+)
+
+// Requester accepts suppression requests keyed by source. A zero until clears
+// the source's request.
+type Requester interface {
+	RequestSuppression(source string, until time.Time)
+}
+
+// FileWatcher requests suppression while a well-known file is present. The
+// request lasts for the configured duration measured from the file's
+// modification time, so touching the file extends it. A Go duration written
+// into the file (for example "2h") overrides the default for that touch.
+// Removing the file clears the request.
+type FileWatcher struct {
+	path     string
+	duration time.Duration
+	interval time.Duration
+	target   Requester
+	last     time.Time
+}
+
+// NewFileWatcher constructs a FileWatcher for path that reports to target.
+func NewFileWatcher(path string, duration time.Duration, target Requester) *FileWatcher {
+	return &FileWatcher{
+		path:     path,
+		duration: duration,
+		interval: DefaultPollInterval,
+		target:   target,
+	}
+}
+
+// Run polls the signal file until ctx is cancelled.
+func (w *FileWatcher) Run(ctx context.Context) {
+	if w == nil || w.target == nil || w.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *FileWatcher) check() {
+	until := w.requestedUntil()
+	if until.Equal(w.last) {
+		return
+	}
+
+	w.last = until
+	w.target.RequestSuppression(SourceFile, until)
+}
+
+func (w *FileWatcher) requestedUntil() time.Time {
+	info, err := os.Stat(w.path)
+	if err != nil || info.IsDir() {
+		return time.Time{}
+	}
+
+	duration := w.duration
+
+	contents, err := readHead(w.path)
+	if err == nil {
+		parsed, parseErr := time.ParseDuration(strings.TrimSpace(contents))
+		if parseErr == nil && parsed > 0 {
+			duration = parsed
+		}
+	}
+
+	if duration <= 0 {
+		return time.Time{}
+	}
+
+	return info.ModTime().Add(duration)
+}
+
+func readHead(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck // caller falls back to the default duration
+	}
+
+	defer func() { _ = file.Close() }()
+
+	buf := make([]byte, maxSignalBytes)
+
+	n, err := file.Read(buf)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err //nolint:wrapcheck // caller falls back to the default duration
+	}
+
+	return string(buf[:n]), nil
+}
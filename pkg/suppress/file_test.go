@@ -0,0 +1,78 @@
+package suppress //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type recordingRequester struct {
+	sources []string
+	untils  []time.Time
+}
+
+func (r *recordingRequester) RequestSuppression(source string, until time.Time) {
+	r.sources = append(r.sources, source)
+	r.untils = append(r.untils, until)
+}
+
+func touch(t *testing.T, path, contents string, modTime time.Time) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("write signal file: %v", err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatalf("set signal file times: %v", err)
+	}
+}
+
+func TestFileWatcherRequestsAndClearsSuppression(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "suppress")
+	requester := new(recordingRequester)
+	watcher := NewFileWatcher(path, time.Hour, requester)
+
+	watcher.check()
+
+	if len(requester.untils) != 0 {
+		t.Fatalf("expected no request without a signal file, got %v", requester.untils)
+	}
+
+	touched := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	touch(t, path, "", touched)
+	watcher.check()
+	watcher.check()
+
+	want := touched.Add(time.Hour)
+	if len(requester.untils) != 1 || !requester.untils[0].Equal(want) {
+		t.Fatalf("expected a single request until %s, got %v", want, requester.untils)
+	}
+
+	if requester.sources[0] != SourceFile {
+		t.Fatalf("expected source %q, got %q", SourceFile, requester.sources[0])
+	}
+
+	touch(t, path, "2h\n", touched)
+	watcher.check()
+
+	if got := requester.untils[len(requester.untils)-1]; !got.Equal(touched.Add(2 * time.Hour)) {
+		t.Fatalf("expected file duration to override the default, got %s", got)
+	}
+
+	err := os.Remove(path)
+	if err != nil {
+		t.Fatalf("remove signal file: %v", err)
+	}
+
+	watcher.check()
+
+	if got := requester.untils[len(requester.untils)-1]; !got.IsZero() {
+		t.Fatalf("expected removal to clear the request, got %s", got)
+	}
+}
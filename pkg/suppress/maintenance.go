@@ -0,0 +1,151 @@
+package suppress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/logging"
+)
+
+const (
+	// SourceMaintenance identifies requests raised by scheduled maintenance.
+	SourceMaintenance = "maintenance"
+	// DefaultMaintenanceInterval is how often IMDS is checked for scheduled
+	// maintenance.
+	DefaultMaintenanceInterval = 15 * time.Minute
+	// DefaultMaintenanceLead is how long before the maintenance is due that
+	// shaping pauses.
+	DefaultMaintenanceLead = 30 * time.Minute
+	// DefaultMaintenanceGrace is how long after the maintenance was due that
+	// shaping stays paused while IMDS still announces it.
+	DefaultMaintenanceGrace = time.Hour
+)
+
+// MaintenanceWatcher requests suppression around the maintenance reboot or
+// live migration IMDS announces for the instance, so synthetic load never
+// competes with it. The request starts lead before the maintenance is due and
+// lasts until grace after it, or until IMDS stops announcing it once the
+// instance is back. A failed read keeps the current request.
+type MaintenanceWatcher struct {
+	reader   imds.MaintenanceReader
+	target   Requester
+	interval time.Duration
+	lead     time.Duration
+	grace    time.Duration
+	now      func() time.Time
+
+	loggerMu sync.RWMutex
+	logger   logging.Logger
+
+	due   time.Time
+	until time.Time
+}
+
+// NewMaintenanceWatcher constructs a MaintenanceWatcher that polls reader
+// every interval and reports to target.
+func NewMaintenanceWatcher(
+	reader imds.MaintenanceReader,
+	target Requester,
+	interval time.Duration,
+	lead time.Duration,
+	grace time.Duration,
+) *MaintenanceWatcher {
+	return &MaintenanceWatcher{
+		reader:   reader,
+		target:   target,
+		interval: interval,
+		lead:     lead,
+		grace:    grace,
+		now:      time.Now,
+	}
+}
+
+// SetLogger installs the logger used for announcements, pauses, and failed
+// reads.
+func (w *MaintenanceWatcher) SetLogger(logger logging.Logger) {
+	w.loggerMu.Lock()
+	defer w.loggerMu.Unlock()
+
+	w.logger = logger
+}
+
+//nolint:ireturn // callers only depend on the interface
+func (w *MaintenanceWatcher) log() logging.Logger {
+	w.loggerMu.RLock()
+	defer w.loggerMu.RUnlock()
+
+	return logging.OrNop(w.logger)
+}
+
+// Run checks IMDS immediately and then every interval until ctx is done. A
+// check that finds maintenance due sooner wakes up in time to start the pause.
+func (w *MaintenanceWatcher) Run(ctx context.Context) {
+	if w == nil || w.reader == nil || w.target == nil || w.interval <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		timer.Reset(w.check(ctx))
+	}
+}
+
+// check reads the maintenance schedule once, updates the suppression request,
+// and returns the delay until the next check.
+func (w *MaintenanceWatcher) check(ctx context.Context) time.Duration {
+	due, err := w.reader.MaintenanceRebootDue(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.log().Warn("failed to read scheduled maintenance", "error", err)
+		}
+
+		return w.interval
+	}
+
+	now := w.now()
+	pauseAt := due.Add(-w.lead)
+
+	if !due.Equal(w.due) {
+		switch {
+		case !due.IsZero():
+			w.log().Info("instance maintenance scheduled", "due", due, "pauseAt", pauseAt)
+		case w.until.IsZero():
+			w.log().Info("scheduled instance maintenance cancelled")
+		}
+
+		w.due = due
+	}
+
+	var until time.Time
+	if !due.IsZero() && !now.Before(pauseAt) && now.Before(due.Add(w.grace)) {
+		until = due.Add(w.grace)
+	}
+
+	if !until.Equal(w.until) {
+		switch {
+		case !until.IsZero():
+			w.log().Warn("pausing shaping for instance maintenance", "due", due, "until", until)
+		default:
+			w.log().Info("instance maintenance over; resuming shaping")
+		}
+
+		w.until = until
+		w.target.RequestSuppression(SourceMaintenance, until)
+	}
+
+	if until.IsZero() && now.Before(pauseAt) {
+		return min(w.interval, pauseAt.Sub(now))
+	}
+
+	return w.interval
+}
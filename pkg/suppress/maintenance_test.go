@@ -0,0 +1,215 @@
+package suppress //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/logtest"
+)
+
+var errIMDSUnavailable = errors.New("imds unavailable")
+
+type scheduleReader struct {
+	mu    sync.Mutex
+	due   time.Time
+	err   error
+	reads int
+}
+
+func (r *scheduleReader) MaintenanceRebootDue(context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reads++
+
+	return r.due, r.err
+}
+
+func (r *scheduleReader) set(due time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.due = due
+	r.err = err
+}
+
+func TestMaintenanceWatcherPausesAroundMaintenance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	due := start.Add(2 * time.Hour)
+	now := start
+
+	reader := new(scheduleReader)
+	requester := new(recordingRequester)
+	logger := new(logtest.Recorder)
+
+	watcher := NewMaintenanceWatcher(reader, requester, 15*time.Minute, 30*time.Minute, time.Hour)
+	watcher.SetLogger(logger)
+	watcher.now = func() time.Time { return now }
+
+	if next := watcher.check(t.Context()); next != 15*time.Minute || len(requester.untils) != 0 {
+		t.Fatalf("expected no request without maintenance, got %v (next %s)",
+			requester.untils, next)
+	}
+
+	reader.set(due, nil)
+
+	if next := watcher.check(t.Context()); next != 15*time.Minute || len(requester.untils) != 0 {
+		t.Fatalf("expected no request before the lead, got %v (next %s)", requester.untils, next)
+	}
+
+	now = due.Add(-40 * time.Minute)
+
+	if next := watcher.check(t.Context()); next != 10*time.Minute {
+		t.Fatalf("expected to wake up when the pause starts, got %s", next)
+	}
+
+	now = due.Add(-30 * time.Minute)
+	watcher.check(t.Context())
+	watcher.check(t.Context())
+
+	want := due.Add(time.Hour)
+	if len(requester.untils) != 1 || !requester.untils[0].Equal(want) {
+		t.Fatalf("expected a single request until %s, got %v", want, requester.untils)
+	}
+
+	if requester.sources[0] != SourceMaintenance {
+		t.Fatalf("expected source %q, got %q", SourceMaintenance, requester.sources[0])
+	}
+
+	reader.set(time.Time{}, errIMDSUnavailable)
+	watcher.check(t.Context())
+
+	if len(requester.untils) != 1 {
+		t.Fatalf("expected a failed read to keep the request, got %v", requester.untils)
+	}
+
+	now = due.Add(10 * time.Minute)
+	reader.set(time.Time{}, nil)
+	watcher.check(t.Context())
+
+	if len(requester.untils) != 2 || !requester.untils[1].IsZero() {
+		t.Fatalf("expected the instance's return to clear the request, got %v", requester.untils)
+	}
+
+	wantMessages := []string{
+		"instance maintenance scheduled",
+		"pausing shaping for instance maintenance",
+		"failed to read scheduled maintenance",
+		"instance maintenance over; resuming shaping",
+	}
+
+	messages := logger.Messages()
+	if len(messages) != len(wantMessages) {
+		t.Fatalf("expected log messages %v, got %v", wantMessages, messages)
+	}
+
+	for index, message := range wantMessages {
+		if messages[index] != message {
+			t.Fatalf("expected log messages %v, got %v", wantMessages, messages)
+		}
+	}
+}
+
+func TestMaintenanceWatcherReleasesAfterGrace(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := due.Add(-5 * time.Minute)
+
+	reader := &scheduleReader{due: due}
+	requester := new(recordingRequester)
+
+	watcher := NewMaintenanceWatcher(reader, requester, time.Minute, 30*time.Minute, time.Hour)
+	watcher.now = func() time.Time { return now }
+
+	watcher.check(t.Context())
+
+	now = due.Add(time.Hour)
+	watcher.check(t.Context())
+
+	if len(requester.untils) != 2 || !requester.untils[1].IsZero() {
+		t.Fatalf("expected the request to lapse after the grace period, got %v", requester.untils)
+	}
+}
+
+func TestMaintenanceWatcherLogsCancelledMaintenance(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reader := &scheduleReader{due: due}
+	requester := new(recordingRequester)
+	logger := new(logtest.Recorder)
+
+	watcher := NewMaintenanceWatcher(reader, requester, time.Minute, 30*time.Minute, time.Hour)
+	watcher.SetLogger(logger)
+	watcher.now = func() time.Time { return due.Add(-24 * time.Hour) }
+
+	watcher.check(t.Context())
+	reader.set(time.Time{}, nil)
+	watcher.check(t.Context())
+
+	if len(requester.untils) != 0 {
+		t.Fatalf("expected no request for maintenance cancelled before its lead, got %v",
+			requester.untils)
+	}
+
+	messages := logger.Messages()
+	if messages[len(messages)-1] != "scheduled instance maintenance cancelled" {
+		t.Fatalf("expected cancellation to be logged, got %v", messages)
+	}
+}
+
+func TestMaintenanceWatcherRunStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	reader := new(scheduleReader)
+	watcher := NewMaintenanceWatcher(
+		reader,
+		new(recordingRequester),
+		time.Millisecond,
+		time.Minute,
+		time.Minute,
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+
+	for {
+		reader.mu.Lock()
+		reads := reader.reads
+		reader.mu.Unlock()
+
+		if reads >= 2 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("expected repeated reads, got %d", reads)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	// A watcher without an interval never polls.
+	NewMaintenanceWatcher(reader, new(recordingRequester), 0, 0, 0).Run(t.Context())
+}
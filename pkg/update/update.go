@@ -0,0 +1,210 @@
+// Package update compares the running build against the latest GitHub release
+// so long-lived unattended instances can surface that an upgrade is available.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRepository is the GitHub repository releases are published from.
+	DefaultRepository = "senomorf/oci-cpu-shaper"
+	// DefaultInterval spaces release checks a day apart, well inside GitHub's
+	// unauthenticated rate limit.
+	DefaultInterval = 24 * time.Hour
+	// DefaultTimeout bounds each release lookup.
+	DefaultTimeout = 30 * time.Second
+
+	defaultAPIURL   = "https://api.github.com"
+	maxReleaseBytes = 1 << 20
+	versionParts    = 3
+)
+
+var (
+	errInvalidRepository = errors.New("update: repository must be in owner/name form")
+	errUnexpectedStatus  = errors.New("update: unexpected status code")
+	errMissingTag        = errors.New("update: latest release has no tag")
+)
+
+// Result describes the outcome of a release check.
+type Result struct {
+	Current string
+	Latest  string
+	URL     string
+	// Comparable is false when either version is not a release version (for
+	// example "dev" builds), in which case Available is always false.
+	Comparable bool
+	Available  bool
+}
+
+// Checker queries the GitHub releases API for the latest published release.
+type Checker struct {
+	repository string
+	current    string
+	apiURL     string
+	client     *http.Client
+}
+
+// NewChecker validates repository and constructs a Checker for the running
+// version current. A nil client uses http.DefaultClient.
+func NewChecker(repository, current string, client *http.Client) (*Checker, error) {
+	trimmed := strings.Trim(strings.TrimSpace(repository), "/")
+
+	owner, name, ok := strings.Cut(trimmed, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%w: %q", errInvalidRepository, repository)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Checker{
+		repository: trimmed,
+		current:    strings.TrimSpace(current),
+		apiURL:     defaultAPIURL,
+		client:     client,
+	}, nil
+}
+
+// Check fetches the latest release and compares it with the running version.
+func (c *Checker) Check(ctx context.Context) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	endpoint := c.apiURL + "/repos/" + c.repository + "/releases/latest"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("update: build request: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/vnd.github+json")
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Result{}, fmt.Errorf("update: fetch latest release: %w", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("%w: %d", errUnexpectedStatus, response.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxReleaseBytes)).Decode(&release)
+	if err != nil {
+		return Result{}, fmt.Errorf("update: decode release: %w", err)
+	}
+
+	latest := strings.TrimSpace(release.TagName)
+	if latest == "" {
+		return Result{}, errMissingTag
+	}
+
+	available, comparable := Newer(c.current, latest)
+
+	return Result{
+		Current:    c.current,
+		Latest:     latest,
+		URL:        release.HTMLURL,
+		Comparable: comparable,
+		Available:  available,
+	}, nil
+}
+
+// Run checks immediately and then every interval until ctx is cancelled,
+// passing each outcome to handler. A non-positive interval uses DefaultInterval.
+func (c *Checker) Run(
+	ctx context.Context,
+	interval time.Duration,
+	handler func(result Result, err error),
+) {
+	if c == nil || handler == nil {
+		return
+	}
+
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := c.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		handler(result, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Newer reports whether latest is a newer release than current. Versions are
+// compared as MAJOR.MINOR.PATCH with an optional "v" prefix, ignoring build
+// metadata; a pre-release suffix ranks a version below the matching release.
+// comparable is false when either version cannot be parsed.
+func Newer(current, latest string) (bool, bool) {
+	currentParts, currentPre, ok := parseVersion(current)
+	if !ok {
+		return false, false
+	}
+
+	latestParts, latestPre, ok := parseVersion(latest)
+	if !ok {
+		return false, false
+	}
+
+	for index := range currentParts {
+		if latestParts[index] != currentParts[index] {
+			return latestParts[index] > currentParts[index], true
+		}
+	}
+
+	return currentPre && !latestPre, true
+}
+
+func parseVersion(version string) ([versionParts]int, bool, bool) {
+	var parts [versionParts]int
+
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	core, _, _ := strings.Cut(trimmed, "+")
+	core, _, hasPre := strings.Cut(core, "-")
+
+	fields := strings.Split(core, ".")
+	if len(fields) != versionParts {
+		return parts, false, false
+	}
+
+	for index, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil || value < 0 {
+			return parts, false, false
+		}
+
+		parts[index] = value
+	}
+
+	return parts, hasPre, true
+}
@@ -0,0 +1,174 @@
+package update //nolint:testpackage // tests point the checker at a local server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		current    string
+		latest     string
+		available  bool
+		comparable bool
+	}{
+		{current: "v1.2.3", latest: "v1.2.4", available: true, comparable: true},
+		{current: "1.2.3", latest: "v1.10.0", available: true, comparable: true},
+		{current: "v2.0.0", latest: "v1.9.9", available: false, comparable: true},
+		{current: "v1.2.3", latest: "v1.2.3", available: false, comparable: true},
+		{current: "v1.3.0-rc.1", latest: "v1.3.0", available: true, comparable: true},
+		{current: "v1.3.0+abc", latest: "v1.3.0", available: false, comparable: true},
+		{current: "dev", latest: "v1.0.0", available: false, comparable: false},
+		{current: "v1.0.0", latest: "nightly", available: false, comparable: false},
+	}
+
+	for _, testCase := range testCases {
+		available, comparable := Newer(testCase.current, testCase.latest)
+		if available != testCase.available || comparable != testCase.comparable {
+			t.Fatalf(
+				"Newer(%q, %q) = %v, %v; want %v, %v",
+				testCase.current,
+				testCase.latest,
+				available,
+				comparable,
+				testCase.available,
+				testCase.comparable,
+			)
+		}
+	}
+}
+
+func TestCheckerReportsLatestRelease(t *testing.T) {
+	t.Parallel()
+
+	var requested string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+
+		_, _ = w.Write([]byte(`{"tag_name":"v1.4.0","html_url":"https://example.com/v1.4.0"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	checker, err := NewChecker(" /example/shaper/ ", "v1.3.2", server.Client())
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	checker.apiURL = server.URL
+
+	result, err := checker.Check(t.Context())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	want := Result{
+		Current:    "v1.3.2",
+		Latest:     "v1.4.0",
+		URL:        "https://example.com/v1.4.0",
+		Comparable: true,
+		Available:  true,
+	}
+	if result != want || requested != "/repos/example/shaper/releases/latest" {
+		t.Fatalf("unexpected result %+v for %q", result, requested)
+	}
+}
+
+func TestCheckerSurfacesFailures(t *testing.T) {
+	t.Parallel()
+
+	for _, repository := range []string{"", "shaper", "a/b/c", "/shaper"} {
+		_, err := NewChecker(repository, "v1.0.0", nil)
+		if !errors.Is(err, errInvalidRepository) {
+			t.Fatalf("expected errInvalidRepository for %q, got %v", repository, err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{name: "rate limited", status: http.StatusForbidden, want: errUnexpectedStatus},
+		{name: "missing tag", status: http.StatusOK, body: `{}`, want: errMissingTag},
+		{name: "malformed", status: http.StatusOK, body: `{`},
+	}
+
+	for _, testCase := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(testCase.status)
+			_, _ = w.Write([]byte(testCase.body))
+		}))
+
+		checker, err := NewChecker(DefaultRepository, "v1.0.0", server.Client())
+		if err != nil {
+			t.Fatalf("NewChecker: %v", err)
+		}
+
+		checker.apiURL = server.URL
+
+		_, err = checker.Check(t.Context())
+
+		server.Close()
+
+		if err == nil || (testCase.want != nil && !errors.Is(err, testCase.want)) {
+			t.Fatalf("%s: expected %v, got %v", testCase.name, testCase.want, err)
+		}
+	}
+}
+
+func TestCheckerRunChecksPeriodically(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name":"v1.0.0"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	checker, err := NewChecker(DefaultRepository, "v1.0.0", server.Client())
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	checker.apiURL = server.URL
+
+	ctx, cancel := context.WithCancel(t.Context())
+	results := make(chan Result, 2)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		checker.Run(ctx, time.Millisecond, func(result Result, err error) {
+			if err != nil {
+				t.Errorf("unexpected check error: %v", err)
+			}
+
+			select {
+			case results <- result:
+			default:
+			}
+		})
+	}()
+
+	for range 2 {
+		select {
+		case result := <-results:
+			if result.Available || !result.Comparable {
+				t.Fatalf("expected an up-to-date comparable result, got %+v", result)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for release checks")
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,76 @@
+package verify
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/logging"
+)
+
+// TransitionLogger decorates an adapt.MetricsRecorder so every controller
+// state change is also logged as TransitionMessage with from and to fields.
+type TransitionLogger struct {
+	logger   logging.Logger
+	delegate adapt.MetricsRecorder
+
+	mu        sync.Mutex
+	lastState string
+}
+
+// NewTransitionLogger wraps delegate; a nil delegate stays nil so callers can
+// keep skipping metrics entirely.
+//
+//nolint:ireturn // the decorator is only used through the recorder interface
+func NewTransitionLogger(
+	logger logging.Logger,
+	delegate adapt.MetricsRecorder,
+) adapt.MetricsRecorder {
+	if delegate == nil {
+		return nil
+	}
+
+	return &TransitionLogger{
+		logger:    logging.OrNop(logger),
+		delegate:  delegate,
+		mu:        sync.Mutex{},
+		lastState: "",
+	}
+}
+
+// SetMode implements adapt.MetricsRecorder.
+func (r *TransitionLogger) SetMode(mode string) {
+	r.delegate.SetMode(mode)
+}
+
+// SetState implements adapt.MetricsRecorder and logs the state when it changes.
+func (r *TransitionLogger) SetState(state string) {
+	trimmed := strings.TrimSpace(state)
+	r.delegate.SetState(trimmed)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if trimmed == r.lastState {
+		return
+	}
+
+	r.logger.Info(TransitionMessage, "from", r.lastState, "to", trimmed)
+	r.lastState = trimmed
+}
+
+// SetTarget implements adapt.MetricsRecorder.
+func (r *TransitionLogger) SetTarget(target float64) {
+	r.delegate.SetTarget(target)
+}
+
+// ObserveOCIP95 implements adapt.MetricsRecorder.
+func (r *TransitionLogger) ObserveOCIP95(value float64, fetchedAt time.Time) {
+	r.delegate.ObserveOCIP95(value, fetchedAt)
+}
+
+// ObserveHostCPU implements adapt.MetricsRecorder.
+func (r *TransitionLogger) ObserveHostCPU(utilisation float64) {
+	r.delegate.ObserveHostCPU(utilisation)
+}
@@ -0,0 +1,76 @@
+package verify_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/verify"
+)
+
+// stateRecorder keeps the last value of every recorder call.
+type stateRecorder struct {
+	mode    string
+	state   string
+	target  float64
+	p95     float64
+	hostCPU float64
+}
+
+func (r *stateRecorder) SetMode(mode string)                      { r.mode = mode }
+func (r *stateRecorder) SetState(state string)                    { r.state = state }
+func (r *stateRecorder) SetTarget(target float64)                 { r.target = target }
+func (r *stateRecorder) ObserveOCIP95(value float64, _ time.Time) { r.p95 = value }
+func (r *stateRecorder) ObserveHostCPU(utilisation float64)       { r.hostCPU = utilisation }
+
+func TestTransitionLoggerLogsStateChangesOnce(t *testing.T) {
+	t.Parallel()
+
+	if verify.NewTransitionLogger(nil, nil) != nil {
+		t.Fatal("expected a nil delegate to stay nil")
+	}
+
+	var buffer bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       nil,
+		ReplaceAttr: zapslog.ReplaceAttr,
+	}))
+	delegate := new(stateRecorder)
+	recorder := verify.NewTransitionLogger(logger, delegate)
+
+	recorder.SetMode("enforce")
+	recorder.SetState(" fallback ")
+	recorder.SetState("fallback")
+	recorder.SetTarget(0.25)
+	recorder.ObserveOCIP95(0.3, time.Unix(100, 0))
+	recorder.ObserveHostCPU(0.4)
+	recorder.SetState("normal")
+
+	if *delegate != (stateRecorder{mode: "enforce", state: "normal", target: 0.25, p95: 0.3,
+		hostCPU: 0.4}) {
+		t.Fatalf("expected every call forwarded, got %+v", *delegate)
+	}
+
+	transitions, err := verify.Transitions(&buffer)
+	if err != nil {
+		t.Fatalf("Transitions: %v", err)
+	}
+
+	want := []verify.Transition{{From: "", To: "fallback"}, {From: "fallback", To: "normal"}}
+	if len(transitions) != len(want) || transitions[0] != want[0] || transitions[1] != want[1] {
+		t.Fatalf("expected %v logged, got %v (log %s)", want, transitions,
+			strings.TrimSpace(buffer.String()))
+	}
+
+	// A nil logger discards the transitions but still forwards them.
+	verify.NewTransitionLogger(nil, delegate).SetState("suppressed")
+
+	if delegate.state != "suppressed" {
+		t.Fatalf("expected the state forwarded without a logger, got %q", delegate.state)
+	}
+}
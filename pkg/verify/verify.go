@@ -0,0 +1,159 @@
+// Package verify checks a running shaper against expected controller state
+// transitions and the state its metrics listener exports, so end-to-end tests
+// and deployment pipelines assert the same behaviour the same way.
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+)
+
+// TransitionMessage is the log message TransitionLogger writes for every
+// controller state change and Transitions looks for.
+const TransitionMessage = "controller state transition"
+
+// maxLogLine bounds one log line; longer lines end the scan with an error.
+const maxLogLine = 1 << 20
+
+var (
+	// ErrInvalidExpectation signals a transition that is not written from→to.
+	ErrInvalidExpectation = errors.New("verify: transition must be written from→to")
+	// ErrTransitionMissing signals an expected transition absent from the log.
+	ErrTransitionMissing = errors.New("verify: expected transition not logged")
+	// ErrStateMissing signals metrics without a controller state series.
+	ErrStateMissing = errors.New("verify: metrics carry no controller state")
+	// ErrStateMismatch signals metrics exporting a different controller state.
+	ErrStateMismatch = errors.New("verify: unexpected controller state")
+)
+
+// Transition is one controller state change. From is empty for the state the
+// controller starts in.
+type Transition struct {
+	From string
+	To   string
+}
+
+// String writes the transition the way ParseTransition reads it.
+func (t Transition) String() string {
+	return t.From + "→" + t.To
+}
+
+// ParseTransition reads a from→to expectation; -> may stand in for the arrow
+// and the from side may be empty to match the initial state.
+func ParseTransition(value string) (Transition, error) {
+	from, to, ok := strings.Cut(value, "→")
+	if !ok {
+		from, to, ok = strings.Cut(value, "->")
+	}
+
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || to == "" {
+		return Transition{}, fmt.Errorf("%w, got %q", ErrInvalidExpectation, value)
+	}
+
+	return Transition{From: from, To: to}, nil
+}
+
+// Transitions reads the controller state transitions from JSON log lines in
+// order. Text before the first brace of a line, such as a journal prefix, and
+// lines that are not JSON objects are skipped.
+func Transitions(log io.Reader) ([]Transition, error) {
+	var transitions []Transition
+
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(nil, maxLogLine)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		start := bytes.IndexByte(line, '{')
+		if start < 0 {
+			continue
+		}
+
+		var entry struct {
+			Message string `json:"message"`
+			From    string `json:"from"`
+			To      string `json:"to"`
+		}
+
+		err := json.Unmarshal(line[start:], &entry)
+		if err != nil || entry.Message != TransitionMessage {
+			continue
+		}
+
+		transitions = append(transitions, Transition{From: entry.From, To: entry.To})
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("read log: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// RequireTransitions reports every expected transition missing from observed.
+func RequireTransitions(observed []Transition, expected ...Transition) error {
+	var missing []error
+
+	for _, want := range expected {
+		found := false
+
+		for _, transition := range observed {
+			if transition == want {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, fmt.Errorf("%w: %s", ErrTransitionMissing, want))
+		}
+	}
+
+	return errors.Join(missing...)
+}
+
+// State returns the controller state exported in a metrics exposition whose
+// metric names carry namespace; an empty namespace means the default. Static
+// labels the exporter appends after the state label are ignored.
+func State(metrics []byte, namespace string) (string, error) {
+	if namespace == "" {
+		namespace = metricshttp.DefaultNamespace
+	}
+
+	pattern := regexp.MustCompile(
+		`(?m)^` + regexp.QuoteMeta(namespace) + `_state\{state="([^"]*)"(?:,[^\n]*)?\} 1$`,
+	)
+
+	match := pattern.FindSubmatch(metrics)
+	if match == nil {
+		return "", ErrStateMissing
+	}
+
+	return string(match[1]), nil
+}
+
+// RequireState reports whether the metrics exposition exports state.
+func RequireState(metrics []byte, namespace, state string) error {
+	exported, err := State(metrics, namespace)
+	if err != nil {
+		return err
+	}
+
+	if exported != state {
+		return fmt.Errorf("%w: expected %q, got %q", ErrStateMismatch, state, exported)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package verify_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"oci-cpu-shaper/pkg/verify"
+)
+
+func TestParseTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]verify.Transition{
+		"fallback→normal":        {From: "fallback", To: "normal"},
+		" normal -> suppressed ": {From: "normal", To: "suppressed"},
+		"→fallback":              {From: "", To: "fallback"},
+	}
+
+	for value, want := range tests {
+		got, err := verify.ParseTransition(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseTransition(%q) = %+v, %v; want %+v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"normal", "fallback→", ""} {
+		_, err := verify.ParseTransition(value)
+		if !errors.Is(err, verify.ErrInvalidExpectation) {
+			t.Fatalf("ParseTransition(%q): expected ErrInvalidExpectation, got %v", value, err)
+		}
+	}
+
+	if got := (verify.Transition{From: "", To: "fallback"}).String(); got != "→fallback" {
+		t.Fatalf("unexpected String: %q", got)
+	}
+}
+
+func TestTransitionsReadsJSONLogLines(t *testing.T) {
+	t.Parallel()
+
+	log := strings.Join([]string{
+		`{"level":"info","message":"controller state transition","from":"","to":"fallback"}`,
+		`not json at all`,
+		`{"level":"info","message":"initialized subsystems"}`,
+		`2026-10-16T10:00:00+0000 host shaper[42]: {"message":"controller state transition",` +
+			`"from":"fallback","to":"normal"}`,
+		`{"message":"controller state transition", truncated`,
+	}, "\n")
+
+	transitions, err := verify.Transitions(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Transitions: %v", err)
+	}
+
+	want := []verify.Transition{{From: "", To: "fallback"}, {From: "fallback", To: "normal"}}
+	if len(transitions) != len(want) || transitions[0] != want[0] || transitions[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, transitions)
+	}
+
+	err = verify.RequireTransitions(transitions, want...)
+	if err != nil {
+		t.Fatalf("RequireTransitions: %v", err)
+	}
+
+	err = verify.RequireTransitions(transitions,
+		verify.Transition{From: "normal", To: "suppressed"},
+		want[1],
+		verify.Transition{From: "normal", To: "fallback"},
+	)
+	if !errors.Is(err, verify.ErrTransitionMissing) ||
+		!strings.Contains(err.Error(), "normal→suppressed") ||
+		!strings.Contains(err.Error(), "normal→fallback") {
+		t.Fatalf("expected both missing transitions reported, got %v", err)
+	}
+
+	_, err = verify.Transitions(iotest.ErrReader(errors.New("boom")))
+	if err == nil || !strings.Contains(err.Error(), "read log") {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}
+
+func TestRequireStateReadsTheNamespacedSeries(t *testing.T) {
+	t.Parallel()
+
+	metrics := []byte("# TYPE shaper_state gauge\n" +
+		"shaper_state{state=\"normal\"} 1\n" +
+		"acme_state{state=\"suppressed\",team=\"infra\",env=\"prod\"} 1\n")
+
+	err := verify.RequireState(metrics, "", "normal")
+	if err != nil {
+		t.Fatalf("RequireState: %v", err)
+	}
+
+	err = verify.RequireState(metrics, "acme", "suppressed")
+	if err != nil {
+		t.Fatalf("RequireState with namespace: %v", err)
+	}
+
+	err = verify.RequireState(metrics, "", "fallback")
+	if !errors.Is(err, verify.ErrStateMismatch) || !strings.Contains(err.Error(), `"normal"`) {
+		t.Fatalf("expected ErrStateMismatch naming the exported state, got %v", err)
+	}
+
+	err = verify.RequireState(metrics, "other", "normal")
+	if !errors.Is(err, verify.ErrStateMissing) {
+		t.Fatalf("expected ErrStateMissing, got %v", err)
+	}
+}
@@ -10,11 +10,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"oci-cpu-shaper/internal/e2eclient"
 	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/pkg/verify"
 	interne2e "oci-cpu-shaper/tests/internal/e2e"
 )
 
@@ -67,9 +69,8 @@ oci:
 	}
 
 	assertMetricsState(t, offlineMetrics, "normal")
-	requireTransition(t, offlineLogs, "", "fallback")
-	requireTransition(t, offlineLogs, "fallback", "normal")
-	assertOfflineLog(t, offlineLogs, true)
+	requireTransitions(t, offlineLogs, "→fallback", "fallback→normal")
+	assertOfflineLog(t, parseLogEntries(t, offlineLogs), true)
 
 	onlineIMDS := interne2e.StartIMDSServer(t, interne2e.IMDSConfig{
 		Region:          "us-test-1",
@@ -109,8 +110,8 @@ oci:
 		t.Fatal("expected online mode to contact IMDS")
 	}
 
-	requirePathObserved(t, imdsRequests, "/opc/v2/region")
-	requirePathObserved(t, imdsRequests, "/opc/v2/compartmentId")
+	requirePathObserved(t, imdsRequests, "/opc/v2/instance/region")
+	requirePathObserved(t, imdsRequests, "/opc/v2/instance/compartmentId")
 
 	monitoringRequests := onlineMonitoring.Requests()
 	if len(monitoringRequests) < 1 {
@@ -118,9 +119,70 @@ oci:
 	}
 
 	assertMetricsState(t, onlineMetrics, "normal")
-	requireTransition(t, onlineLogs, "", "fallback")
-	requireTransition(t, onlineLogs, "fallback", "normal")
-	assertOfflineLog(t, onlineLogs, false)
+	requireTransitions(t, onlineLogs, "→fallback", "fallback→normal")
+	assertOfflineLog(t, parseLogEntries(t, onlineLogs), false)
+}
+
+func TestCLITimeCompressedRelaxedWeek(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	repoRoot := interne2e.RepositoryRoot(t)
+	binary := interne2e.BuildShaperBinary(t, repoRoot, "e2e")
+
+	imdsServer := interne2e.StartIMDSServer(t, interne2e.IMDSConfig{
+		Region:          "us-test-1",
+		CanonicalRegion: "us-test-1",
+		InstanceID:      "ocid1.instance.oc1..week",
+		CompartmentID:   "ocid1.compartment.oc1..week",
+		Shape:           imds.ShapeConfig{OCPUs: 4, MemoryInGBs: 64},
+	})
+	// A P95 above relaxedThreshold keeps the controller on relaxedInterval.
+	monitoring := interne2e.StartMonitoringServer(t, []interne2e.MonitoringResponse{{Value: 0.3}})
+
+	metricsPort := interne2e.FreePort(t)
+	config := writeConfig(t, "week.yaml", fmt.Sprintf(`
+controller:
+  interval: 1h
+  relaxedInterval: 6h
+estimator:
+  interval: 30m
+pool:
+  workers: 1
+  quantum: 150ms
+http:
+  bind: "127.0.0.1:%d"
+`, metricsPort))
+
+	// One wall-clock second covers 42 virtual hours, so the 4s run spans a week.
+	const weekScale = 151200
+
+	logs, metrics := runShaper(ctx, t, binary, config, metricsPort, map[string]string{
+		"OCI_CPU_SHAPER_IMDS_ENDPOINT":  imdsServer.Endpoint(),
+		e2eclient.MonitoringEndpointEnv: monitoring.URL(),
+		e2eclient.TimeScaleEnv:          strconv.Itoa(weekScale),
+	})
+
+	assertMetricsState(t, metrics, "normal")
+	requireTransitions(t, logs, "→fallback", "fallback→normal")
+
+	requests := monitoring.Requests()
+	if len(requests) < 2 {
+		t.Fatalf("expected repeated monitoring queries, saw %d", len(requests))
+	}
+
+	for index := 1; index < len(requests); index++ {
+		gap := requests[index].At.Sub(requests[index-1].At)
+		if gap < 5*time.Hour {
+			t.Fatalf("expected relaxed 6h spacing, query %d came after %s", index, gap)
+		}
+	}
+
+	span := requests[len(requests)-1].At.Sub(requests[0].At)
+	if span < 5*24*time.Hour {
+		t.Fatalf("expected queries to span most of a virtual week, got %s over %d queries",
+			span, len(requests))
+	}
 }
 
 func runShaper(
@@ -130,7 +192,7 @@ func runShaper(
 	configPath string,
 	metricsPort int,
 	env map[string]string,
-) ([]logEntry, []byte) {
+) ([]byte, []byte) {
 	t.Helper()
 
 	var output bytes.Buffer
@@ -169,9 +231,7 @@ func runShaper(
 		t.Fatalf("shaper exited with error: %v\n%s", err, output.String())
 	}
 
-	entries := parseLogEntries(t, output.Bytes())
-
-	return entries, metricsData
+	return output.Bytes(), metricsData
 }
 
 func writeConfig(t *testing.T, name, contents string) string {
@@ -211,29 +271,32 @@ func parseLogEntries(t *testing.T, data []byte) []logEntry {
 func assertMetricsState(t *testing.T, metrics []byte, expected string) {
 	t.Helper()
 
-	want := fmt.Sprintf(`shaper_state{state="%s"} 1`, expected)
-	if !bytes.Contains(metrics, []byte(want)) {
-		t.Fatalf("expected metrics to include %q\nmetrics:\n%s", want, metrics)
+	if err := verify.RequireState(metrics, "", expected); err != nil {
+		t.Fatalf("%v\nmetrics:\n%s", err, metrics)
 	}
 }
 
-func requireTransition(t *testing.T, logs []logEntry, from, to string) {
+func requireTransitions(t *testing.T, logs []byte, expected ...string) {
 	t.Helper()
 
-	for _, entry := range logs {
-		message, _ := entry["message"].(string)
-		if message != "controller state transition" {
-			continue
-		}
+	observed, err := verify.Transitions(bytes.NewReader(logs))
+	if err != nil {
+		t.Fatalf("read transitions: %v", err)
+	}
 
-		prev, _ := entry["from"].(string)
-		next, _ := entry["to"].(string)
-		if prev == from && next == to {
-			return
+	wants := make([]verify.Transition, 0, len(expected))
+	for _, value := range expected {
+		want, err := verify.ParseTransition(value)
+		if err != nil {
+			t.Fatalf("parse expectation: %v", err)
 		}
+
+		wants = append(wants, want)
 	}
 
-	t.Fatalf("expected transition from %q to %q not found", from, to)
+	if err := verify.RequireTransitions(observed, wants...); err != nil {
+		t.Fatalf("%v\nobserved: %v", err, observed)
+	}
 }
 
 func assertOfflineLog(t *testing.T, logs []logEntry, offline bool) {
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"sync"
 	"testing"
 	"time"
 
@@ -18,7 +17,10 @@ import (
 
 	"oci-cpu-shaper/internal/e2eclient"
 	"oci-cpu-shaper/pkg/adapt"
+	"oci-cpu-shaper/pkg/adapt/adapttest"
 	metricshttp "oci-cpu-shaper/pkg/http/metrics"
+	"oci-cpu-shaper/pkg/logging/zapslog"
+	"oci-cpu-shaper/pkg/verify"
 	interne2e "oci-cpu-shaper/tests/internal/e2e"
 )
 
@@ -27,7 +29,7 @@ func TestControllerFallbackRecoversAfterMonitoringGap(t *testing.T) {
 	logger := zap.New(observerCore)
 
 	exporter := metricshttp.NewExporter()
-	recorder := e2eclient.NewLoggingRecorder(logger, exporter)
+	recorder := verify.NewTransitionLogger(zapslog.NewLibraryLogger(logger), exporter)
 
 	monitoring := interne2e.StartMonitoringServer(t, []interne2e.MonitoringResponse{
 		{Status: http.StatusNoContent},
@@ -45,7 +47,7 @@ func TestControllerFallbackRecoversAfterMonitoringGap(t *testing.T) {
 	cfg.RelaxedInterval = 200 * time.Millisecond
 	cfg.FallbackTarget = 0.25
 
-	shaper := newRecordingShaper()
+	shaper := adapttest.NewDutyCycler()
 
 	controller, err := adapt.NewAdaptiveController(cfg, metricsClient, nil, shaper, recorder)
 	if err != nil {
@@ -96,7 +98,7 @@ func waitForTransition(t *testing.T, observed *observer.ObservedLogs, from, to s
 	for {
 		entries := observed.TakeAll()
 		for _, entry := range entries {
-			if entry.Message != "controller state transition" {
+			if entry.Message != verify.TransitionMessage {
 				continue
 			}
 
@@ -141,25 +143,3 @@ func assertNonZeroMetric(t *testing.T, metrics []byte, name string) {
 		t.Fatalf("expected metric %q to be non-zero, got %q\nmetrics:\n%s", name, value, metrics)
 	}
 }
-
-type recordingShaper struct {
-	mu     sync.Mutex
-	target float64
-}
-
-func newRecordingShaper() *recordingShaper {
-	return &recordingShaper{}
-}
-
-func (r *recordingShaper) SetTarget(target float64) {
-	r.mu.Lock()
-	r.target = target
-	r.mu.Unlock()
-}
-
-func (r *recordingShaper) Target() float64 {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	return r.target
-}
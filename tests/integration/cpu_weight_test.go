@@ -4,8 +4,6 @@
 package integration
 
 import (
-	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -15,6 +13,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"oci-cpu-shaper/pkg/cgroup"
 )
 
 const (
@@ -31,7 +31,7 @@ func TestCPUWeightResponsiveness(t *testing.T) {
 		t.Skipf("docker CLI not available: %v", err)
 	}
 
-	ensureCgroupV2(t)
+	version := ensureCPUController(t)
 
 	repoRoot := repositoryRoot(t)
 	hogBinary := buildHogBinary(t, repoRoot)
@@ -59,8 +59,8 @@ func TestCPUWeightResponsiveness(t *testing.T) {
 
 	time.Sleep(10 * time.Second)
 
-	highWeightStats := readCPUStats(t, highWeightName)
-	lowWeightStats := readCPUStats(t, lowWeightName)
+	highWeightStats := readCPUStats(t, version, highWeightName)
+	lowWeightStats := readCPUStats(t, version, lowWeightName)
 
 	t.Logf("high-weight container usage: %d µs (weight=%d)", highWeightStats.usageMicros, highWeightStats.weight)
 	t.Logf("low-weight container usage: %d µs (weight=%d)", lowWeightStats.usageMicros, lowWeightStats.weight)
@@ -96,18 +96,30 @@ type cpuStats struct {
 	weight      uint64
 }
 
-func ensureCgroupV2(t *testing.T) {
+func ensureCPUController(t *testing.T) cgroup.Version {
 	t.Helper()
 
-	controllersPath := "/sys/fs/cgroup/cgroup.controllers"
-	data, err := os.ReadFile(controllersPath)
-	if err != nil {
-		t.Fatalf("cgroup v2 controllers file not readable (%s): %v", controllersPath, err)
+	version := cgroup.Detect(cgroup.DefaultRoot)
+	if version == cgroup.VersionUnknown {
+		t.Fatalf("no cgroup cpu controller detected under %s", cgroup.DefaultRoot)
 	}
 
-	if !strings.Contains(string(data), "cpu") {
-		t.Fatalf("cgroup v2 cpu controller is unavailable; controllers=%q", strings.TrimSpace(string(data)))
+	if version == cgroup.VersionV2 {
+		controllersPath := filepath.Join(cgroup.DefaultRoot, "cgroup.controllers")
+
+		data, err := os.ReadFile(controllersPath)
+		if err != nil {
+			t.Fatalf("cgroup v2 controllers file not readable (%s): %v", controllersPath, err)
+		}
+
+		if !strings.Contains(string(data), "cpu") {
+			t.Fatalf("cgroup v2 cpu controller is unavailable; controllers=%q", strings.TrimSpace(string(data)))
+		}
 	}
+
+	t.Logf("detected cgroup %s hierarchy", version)
+
+	return version
 }
 
 func repositoryRoot(t *testing.T) string {
@@ -210,23 +222,29 @@ func waitForRunning(t *testing.T, name string, timeout time.Duration) {
 	t.Fatalf("container %s did not report running state within %s", name, timeout)
 }
 
-func readCPUStats(t *testing.T, containerName string) cpuStats {
+func readCPUStats(t *testing.T, version cgroup.Version, containerName string) cpuStats {
 	t.Helper()
 
 	pid := containerPID(t, containerName)
-	cgroupPath := cgroupPathForPID(t, pid)
 
-	statsPath := filepath.Join(cgroupPath, "cpu.stat")
-	weightPath := filepath.Join(cgroupPath, "cpu.weight")
+	group, err := cgroup.GroupForPID(version, pid)
+	if err != nil {
+		t.Fatalf("resolve cgroup for %s: %v", containerName, err)
+	}
+
+	manager, err := cgroup.Open(cgroup.DefaultRoot, group)
+	if err != nil {
+		t.Fatalf("open cgroup for %s: %v", containerName, err)
+	}
 
-	usage, err := parseUsageMicros(statsPath)
+	usage, err := manager.UsageMicros()
 	if err != nil {
-		t.Fatalf("parse cpu.stat for %s: %v", containerName, err)
+		t.Fatalf("read cpu usage for %s: %v", containerName, err)
 	}
 
-	weight, err := parseWeight(weightPath)
+	weight, err := manager.CPUWeight()
 	if err != nil {
-		t.Fatalf("parse cpu.weight for %s: %v", containerName, err)
+		t.Fatalf("read cpu weight for %s: %v", containerName, err)
 	}
 
 	return cpuStats{
@@ -256,85 +274,6 @@ func containerPID(t *testing.T, name string) int {
 	return pid
 }
 
-func cgroupPathForPID(t *testing.T, pid int) string {
-	t.Helper()
-
-	cgroupFile := fmt.Sprintf("/proc/%d/cgroup", pid)
-	data, err := os.ReadFile(cgroupFile)
-	if err != nil {
-		t.Fatalf("read cgroup data for pid %d: %v", pid, err)
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, ":")
-		if len(parts) != 3 {
-			continue
-		}
-
-		if parts[0] == "0" {
-			relPath := parts[2]
-			if relPath == "" {
-				break
-			}
-
-			absPath := filepath.Join("/sys/fs/cgroup", relPath)
-			if _, err := os.Stat(absPath); err == nil {
-				return absPath
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		t.Fatalf("scan cgroup entries for pid %d: %v", pid, err)
-	}
-
-	t.Fatalf("cgroup v2 path not found for pid %d", pid)
-
-	return ""
-}
-
-func parseUsageMicros(path string) (uint64, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return 0, err
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	for scanner.Scan() {
-		fields := strings.Fields(scanner.Text())
-		if len(fields) == 2 && fields[0] == "usage_usec" {
-			value, convErr := strconv.ParseUint(fields[1], 10, 64)
-			if convErr != nil {
-				return 0, convErr
-			}
-
-			return value, nil
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return 0, err
-	}
-
-	return 0, errors.New("usage_usec not present in cpu.stat")
-}
-
-func parseWeight(path string) (uint64, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return 0, err
-	}
-
-	trimmed := strings.TrimSpace(string(data))
-	if trimmed == "" {
-		return 0, errors.New("cpu.weight empty")
-	}
-
-	return strconv.ParseUint(trimmed, 10, 64)
-}
-
 func containerName(prefix string) string {
 	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
 }
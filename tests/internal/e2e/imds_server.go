@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"oci-cpu-shaper/pkg/imds"
 )
@@ -19,12 +20,16 @@ type IMDSConfig struct {
 	InstanceID      string
 	CompartmentID   string
 	Shape           imds.ShapeConfig
+	// MaintenanceRebootDue, when non-zero, is announced as the instance's
+	// scheduled maintenance.
+	MaintenanceRebootDue time.Time
 }
 
 // IMDSServer emulates the subset of IMDS endpoints exercised by the CLI.
 type IMDSServer struct {
-	server *httptest.Server
-	cfg    IMDSConfig
+	server  *httptest.Server
+	cfg     IMDSConfig
+	network networkShaper
 
 	mu       sync.Mutex
 	requests []string
@@ -37,7 +42,7 @@ func StartIMDSServer(tb testing.TB, cfg IMDSConfig) *IMDSServer {
 	handler := new(IMDSServer)
 	handler.cfg = cfg
 
-	server := httptest.NewServer(http.HandlerFunc(handler.serveHTTP))
+	server := httptest.NewServer(handler.network.wrap(http.HandlerFunc(handler.serveHTTP)))
 	tb.Cleanup(server.Close)
 
 	handler.server = server
@@ -54,6 +59,13 @@ func (s *IMDSServer) Endpoint() string {
 	return s.server.URL + path.Clean("/opc/v2")
 }
 
+// SetNetworkConditions applies latency and bandwidth shaping to subsequent
+// requests, so retry budgets and per-call timeouts can be exercised against a
+// slow metadata service.
+func (s *IMDSServer) SetNetworkConditions(conditions NetworkConditions) {
+	s.network.set(conditions)
+}
+
 // Requests returns a snapshot of observed IMDS paths.
 func (s *IMDSServer) Requests() []string {
 	if s == nil {
@@ -75,25 +87,42 @@ func (s *IMDSServer) serveHTTP(writer http.ResponseWriter, req *http.Request) {
 	s.mu.Unlock()
 
 	switch strings.TrimPrefix(req.URL.Path, "/") {
-	case "opc/v2/region":
+	case "opc/v2/instance/":
+		s.writeJSON(writer, s.instanceDocument())
+	case "opc/v2/instance/region":
 		s.writeText(writer, s.cfg.Region)
-	case "opc/v2/regionInfo":
+	case "opc/v2/instance/regionInfo":
 		payload := struct {
 			CanonicalRegionName string `json:"canonicalRegionName"`
 		}{CanonicalRegionName: s.cfg.CanonicalRegion}
 
 		s.writeJSON(writer, payload)
-	case "opc/v2/id":
+	case "opc/v2/instance/id":
 		s.writeText(writer, s.cfg.InstanceID)
-	case "opc/v2/compartmentId":
+	case "opc/v2/instance/compartmentId":
 		s.writeText(writer, s.cfg.CompartmentID)
-	case "opc/v2/shape-config":
+	case "opc/v2/instance/shape-config":
 		s.writeJSON(writer, s.cfg.Shape)
 	default:
 		http.NotFound(writer, req)
 	}
 }
 
+func (s *IMDSServer) instanceDocument() map[string]any {
+	document := map[string]any{
+		"id":            s.cfg.InstanceID,
+		"compartmentId": s.cfg.CompartmentID,
+		"region":        s.cfg.Region,
+		"shapeConfig":   s.cfg.Shape,
+	}
+
+	if !s.cfg.MaintenanceRebootDue.IsZero() {
+		document["timeMaintenanceRebootDue"] = s.cfg.MaintenanceRebootDue.UTC().Format(time.RFC3339)
+	}
+
+	return document
+}
+
 func (s *IMDSServer) writeText(writer http.ResponseWriter, body string) {
 	writer.Header().Set("Content-Type", "text/plain")
 	_, _ = writer.Write([]byte(body))
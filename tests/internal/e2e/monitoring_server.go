@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/e2eclient"
 )
 
 const defaultMonitoringValue = 0.25
@@ -25,12 +28,16 @@ type monitoringPayload struct {
 // MonitoringRequest captures a single request observed by the fake Monitoring service.
 type MonitoringRequest struct {
 	ResourceID string
+	// At is the client's query time, virtual when the daemon runs with
+	// e2eclient.TimeScaleEnv. It is zero when the client did not report one.
+	At time.Time
 }
 
 // MonitoringServer provides a lightweight HTTP interface that mimics the OCI Monitoring API
 // enough for the CLI to exercise the adaptive controller in tests.
 type MonitoringServer struct {
-	server *httptest.Server
+	server  *httptest.Server
+	network networkShaper
 
 	mu        sync.Mutex
 	requests  []MonitoringRequest
@@ -48,7 +55,7 @@ func StartMonitoringServer(tb testing.TB, responses []MonitoringResponse) *Monit
 
 	handler := http.HandlerFunc(srv.handleRequest(tb))
 
-	server := httptest.NewServer(handler)
+	server := httptest.NewServer(srv.network.wrap(handler))
 	tb.Cleanup(server.Close)
 
 	srv.server = server
@@ -65,6 +72,13 @@ func (s *MonitoringServer) URL() string {
 	return s.server.URL
 }
 
+// SetNetworkConditions applies latency and bandwidth shaping to subsequent
+// requests, so the daemon's per-call timeouts and fallback paths can be
+// exercised against a slow Monitoring endpoint.
+func (s *MonitoringServer) SetNetworkConditions(conditions NetworkConditions) {
+	s.network.set(conditions)
+}
+
 // Requests returns a snapshot of the requests observed so far.
 func (s *MonitoringServer) Requests() []MonitoringRequest {
 	if s == nil {
@@ -84,27 +98,7 @@ func (s *MonitoringServer) handleRequest(tb testing.TB) func(http.ResponseWriter
 	tb.Helper()
 
 	return func(writer http.ResponseWriter, req *http.Request) {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		resourceID := req.URL.Query().Get("resource")
-		s.requests = append(s.requests, MonitoringRequest{ResourceID: resourceID})
-
-		var resp MonitoringResponse
-		if len(s.responses) == 0 {
-			resp = MonitoringResponse{
-				Status: http.StatusOK,
-				Value:  defaultMonitoringValue,
-				Body:   "",
-			}
-		} else {
-			if s.next < len(s.responses) {
-				resp = s.responses[s.next]
-				s.next++
-			} else {
-				resp = s.responses[len(s.responses)-1]
-			}
-		}
+		resp := s.record(req)
 
 		status := resp.Status
 		if status == 0 {
@@ -133,3 +127,34 @@ func (s *MonitoringServer) handleRequest(tb testing.TB) func(http.ResponseWriter
 		}
 	}
 }
+
+// record notes req and selects its response. Responses are written without the
+// lock held so throttled bodies do not serialize concurrent requests.
+func (s *MonitoringServer) record(req *http.Request) MonitoringResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := req.URL.Query()
+	queriedAt, _ := time.Parse(time.RFC3339Nano, query.Get(e2eclient.MonitoringTimeParam))
+	s.requests = append(s.requests, MonitoringRequest{
+		ResourceID: query.Get("resource"),
+		At:         queriedAt,
+	})
+
+	if len(s.responses) == 0 {
+		return MonitoringResponse{
+			Status: http.StatusOK,
+			Value:  defaultMonitoringValue,
+			Body:   "",
+		}
+	}
+
+	if s.next < len(s.responses) {
+		resp := s.responses[s.next]
+		s.next++
+
+		return resp
+	}
+
+	return s.responses[len(s.responses)-1]
+}
@@ -0,0 +1,132 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// throttleChunksPerSecond sets how finely bandwidth shaping paces response bodies.
+const throttleChunksPerSecond = 20
+
+// NetworkConditions emulates a slow or congested path between the daemon and a
+// fake server. The zero value serves responses immediately.
+type NetworkConditions struct {
+	// Latency delays every response before its status line is written.
+	Latency time.Duration
+	// Latencies, when set, overrides Latency per request: the n-th request
+	// observed after the conditions were applied waits Latencies[n]. Requests
+	// past the end of the slice fall back to Latency.
+	Latencies []time.Duration
+	// BytesPerSecond caps the rate at which response bodies are written. Zero
+	// leaves bodies unthrottled.
+	BytesPerSecond int
+}
+
+// networkShaper applies NetworkConditions to the handlers of a fake server.
+type networkShaper struct {
+	mu         sync.Mutex
+	conditions NetworkConditions
+	served     int
+}
+
+func (n *networkShaper) set(conditions NetworkConditions) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	conditions.Latencies = append([]time.Duration(nil), conditions.Latencies...)
+	n.conditions = conditions
+	n.served = 0
+}
+
+func (n *networkShaper) next() (time.Duration, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	latency := n.conditions.Latency
+	if n.served < len(n.conditions.Latencies) {
+		latency = n.conditions.Latencies[n.served]
+	}
+
+	n.served++
+
+	return latency, n.conditions.BytesPerSecond
+}
+
+// wrap delays and throttles handler according to the current conditions. A
+// client giving up on the request, for example after its per-call timeout,
+// aborts the delay so the fake does not hold the connection open.
+func (n *networkShaper) wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		latency, bytesPerSecond := n.next()
+
+		if !sleepContext(req.Context(), latency) {
+			return
+		}
+
+		if bytesPerSecond > 0 {
+			writer = &throttledWriter{
+				ResponseWriter: writer,
+				ctx:            req.Context(),
+				bytesPerSecond: bytesPerSecond,
+			}
+		}
+
+		handler.ServeHTTP(writer, req)
+	})
+}
+
+// throttledWriter paces writes to bytesPerSecond, flushing each chunk so the
+// client observes the body trickling in.
+type throttledWriter struct {
+	http.ResponseWriter
+
+	ctx            context.Context //nolint:containedctx // bounded by the request lifetime
+	bytesPerSecond int
+}
+
+func (w *throttledWriter) Write(payload []byte) (int, error) {
+	chunkSize := max(w.bytesPerSecond/throttleChunksPerSecond, 1)
+	written := 0
+
+	for len(payload) > 0 {
+		chunk := payload[:min(chunkSize, len(payload))]
+
+		count, err := w.ResponseWriter.Write(chunk)
+		written += count
+
+		if err != nil {
+			return written, err //nolint:wrapcheck // mirrors the underlying writer
+		}
+
+		if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		payload = payload[len(chunk):]
+		delay := time.Duration(len(chunk)) * time.Second / time.Duration(w.bytesPerSecond)
+
+		if !sleepContext(w.ctx, delay) {
+			return written, w.ctx.Err() //nolint:wrapcheck // surfaces the client's cancellation
+		}
+	}
+
+	return written, nil
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
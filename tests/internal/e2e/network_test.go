@@ -0,0 +1,79 @@
+package e2e_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"oci-cpu-shaper/internal/e2eclient"
+	"oci-cpu-shaper/pkg/imds"
+	"oci-cpu-shaper/tests/internal/e2e"
+)
+
+func TestIMDSServerLatencyExercisesRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	server := e2e.StartIMDSServer(t, e2e.IMDSConfig{Region: "us-ashburn-1"})
+	server.SetNetworkConditions(e2e.NetworkConditions{
+		Latencies: []time.Duration{time.Second},
+	})
+
+	client := imds.NewClient(
+		&http.Client{Timeout: 100 * time.Millisecond},
+		imds.WithBaseURL(server.Endpoint()),
+		imds.WithMaxAttempts(2),
+		imds.WithBackoff(10*time.Millisecond),
+	)
+
+	region, err := client.Region(t.Context())
+	if err != nil || region != "us-ashburn-1" {
+		t.Fatalf("expected the retry to succeed after a timeout, got %q (%v)", region, err)
+	}
+
+	// The timed out attempt is abandoned during the injected delay, so only the
+	// retry reaches the handler.
+	if requests := server.Requests(); len(requests) != 1 {
+		t.Fatalf("expected only the retry to be served, got %v", requests)
+	}
+
+	server.SetNetworkConditions(e2e.NetworkConditions{Latency: time.Second})
+
+	single := imds.NewClient(
+		&http.Client{Timeout: 100 * time.Millisecond},
+		imds.WithBaseURL(server.Endpoint()),
+		imds.WithMaxAttempts(1),
+	)
+
+	_, err = single.Region(t.Context())
+	if err == nil {
+		t.Fatal("expected a slow metadata service to exhaust a single-attempt budget")
+	}
+}
+
+func TestMonitoringServerBandwidthShapingSlowsResponses(t *testing.T) {
+	t.Parallel()
+
+	server := e2e.StartMonitoringServer(t, []e2e.MonitoringResponse{{Value: 0.42}})
+	server.SetNetworkConditions(e2e.NetworkConditions{
+		Latency:        50 * time.Millisecond,
+		BytesPerSecond: 100,
+	})
+
+	client, err := e2eclient.NewMonitoringClient(server.URL())
+	if err != nil {
+		t.Fatalf("NewMonitoringClient returned error: %v", err)
+	}
+
+	started := time.Now()
+
+	value, err := client.QueryP95CPU(t.Context(), "ocid1.instance.oc1..example")
+	if err != nil || value != 0.42 {
+		t.Fatalf("expected 0.42, got %.2f (%v)", value, err)
+	}
+
+	// The payload is about 15 bytes, so at 100 B/s it takes well over 100ms on
+	// top of the injected latency.
+	if elapsed := time.Since(started); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected shaping to slow the response, took %v", elapsed)
+	}
+}